@@ -0,0 +1,285 @@
+// Package audio converts speech between the formats providers return and the
+// formats Telegram and speech-to-text expect. WAV headers and OGG/Opus
+// durations are handled in Go; everything else goes through ffmpeg, which the
+// Docker images install.
+package audio
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+type Format string
+
+const (
+	WAV Format = "wav"
+	MP3 Format = "mp3"
+	// OGG is OGG/Opus, the format of Telegram voice notes
+	OGG Format = "ogg"
+	// PCM is raw signed 16-bit little-endian samples
+	PCM Format = "pcm"
+)
+
+// Opus always counts samples at 48 kHz, whatever the input rate was
+const opusSampleRate = 48000
+
+// Options change the audio on the way through. Zero keeps the input's value.
+type Options struct {
+	SampleRate int
+	Channels   int
+	// Loudness is the EBU R128 integrated loudness to normalize to, in LUFS
+	Loudness float64
+	// Tempo speeds speech up or slows it down without changing its pitch
+	Tempo float64
+	// Pitch shifts the voice in semitones without changing its tempo
+	Pitch float64
+}
+
+// Peaks are kept this far below full scale, in dBTP, so normalizing a quiet
+// provider up doesn't clip
+const truePeak = -1.5
+
+// outputArgs are the ffmpeg arguments that encode each format. Speech needs
+// little bandwidth, so the compressed formats use low bitrates.
+var outputArgs = map[Format][]string{
+	WAV: {"-c:a", "pcm_s16le", "-f", "wav"},
+	MP3: {"-c:a", "libmp3lame", "-b:a", "64k", "-f", "mp3"},
+	OGG: {"-c:a", "libopus", "-b:a", "32k", "-application", "voip", "-f", "ogg"},
+	PCM: {"-c:a", "pcm_s16le", "-f", "s16le"},
+}
+
+// FileName is a file name with the format's extension, for uploads.
+func FileName(format Format) string {
+	return "response." + string(format)
+}
+
+// PCMToWAV adds a WAV header to raw 16-bit little-endian samples.
+func PCMToWAV(pcm []byte, sampleRate int, channels int) []byte {
+	const bitsPerSample = 16
+	byteRate := sampleRate * channels * bitsPerSample / 8
+	blockAlign := channels * bitsPerSample / 8
+
+	var buf bytes.Buffer
+	buf.WriteString("RIFF")
+	binary.Write(&buf, binary.LittleEndian, uint32(36+len(pcm)))
+	buf.WriteString("WAVE")
+	buf.WriteString("fmt ")
+	binary.Write(&buf, binary.LittleEndian, uint32(16)) // PCM format chunk size
+	binary.Write(&buf, binary.LittleEndian, uint16(1))  // PCM
+	binary.Write(&buf, binary.LittleEndian, uint16(channels))
+	binary.Write(&buf, binary.LittleEndian, uint32(sampleRate))
+	binary.Write(&buf, binary.LittleEndian, uint32(byteRate))
+	binary.Write(&buf, binary.LittleEndian, uint16(blockAlign))
+	binary.Write(&buf, binary.LittleEndian, uint16(bitsPerSample))
+	buf.WriteString("data")
+	binary.Write(&buf, binary.LittleEndian, uint32(len(pcm)))
+	buf.Write(pcm)
+	return buf.Bytes()
+}
+
+// Convert re-encodes audio, or the sound track of a video, in any format
+// ffmpeg reads. Raw PCM input needs PCMToWAV first, since it doesn't say its
+// own sample rate.
+func Convert(ctx context.Context, data []byte, format Format, options Options) ([]byte, error) {
+	tracer := otel.Tracer("audio/Convert")
+	ctx, span := tracer.Start(ctx, "Convert")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("audio.format", string(format)),
+		attribute.Int("audio.input.size", len(data)),
+		attribute.Float64("audio.loudness", options.Loudness),
+		attribute.Float64("audio.tempo", options.Tempo),
+		attribute.Float64("audio.pitch", options.Pitch),
+	)
+
+	encode, ok := outputArgs[format]
+	if !ok {
+		return nil, fmt.Errorf("unknown audio format %q", format)
+	}
+
+	// The input goes through a temp file because MP4s often keep their index
+	// at the end, which ffmpeg can't seek to on a pipe
+	input, err := writeTemp(data)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	defer os.Remove(input)
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "ffmpeg", ffmpegArgs(input, encode, options)...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		span.RecordError(err)
+		return nil, fmt.Errorf("ffmpeg failed: %w: %s", err, bytes.TrimSpace(stderr.Bytes()))
+	}
+
+	span.SetAttributes(attribute.Int("audio.output.size", stdout.Len()))
+	return stdout.Bytes(), nil
+}
+
+func ffmpegArgs(input string, encode []string, options Options) []string {
+	args := []string{"-hide_banner", "-loglevel", "error", "-i", input, "-vn"}
+	if filters := audioFilters(options); len(filters) > 0 {
+		args = append(args, "-af", strings.Join(filters, ","))
+	}
+	if options.Channels > 0 {
+		args = append(args, "-ac", strconv.Itoa(options.Channels))
+	}
+	// loudnorm works at 192 kHz, so normalized audio should set the rate
+	if options.SampleRate > 0 {
+		args = append(args, "-ar", strconv.Itoa(options.SampleRate))
+	}
+	args = append(args, encode...)
+	return append(args, "pipe:1")
+}
+
+func audioFilters(options Options) []string {
+	var filters []string
+	if options.Pitch != 0 {
+		// Playing the samples faster raises the pitch and the tempo together;
+		// atempo then undoes the tempo. The input is resampled first, since its
+		// rate isn't known up front.
+		const rate = 48000
+		factor := math.Pow(2, options.Pitch/12)
+		filters = append(filters,
+			fmt.Sprintf("aresample=%d", rate),
+			fmt.Sprintf("asetrate=%d", int(math.Round(rate*factor))),
+			fmt.Sprintf("aresample=%d", rate),
+			fmt.Sprintf("atempo=%.4f", 1/factor),
+		)
+	}
+	// atempo only takes 0.5 to 2 at a time, which is plenty for speech
+	if options.Tempo > 0 && options.Tempo != 1 {
+		filters = append(filters, fmt.Sprintf("atempo=%g", min(max(options.Tempo, 0.5), 2)))
+	}
+	if options.Loudness != 0 {
+		// Single pass: speech is short enough that the dynamic mode sounds fine
+		filters = append(filters, fmt.Sprintf("loudnorm=I=%g:TP=%g:LRA=11", options.Loudness, truePeak))
+	}
+	return filters
+}
+
+// Duration is how long audio plays. WAV and OGG/Opus are read directly;
+// other formats are probed with ffprobe.
+func Duration(ctx context.Context, data []byte) (time.Duration, error) {
+	if duration, ok := wavDuration(data); ok {
+		return duration, nil
+	}
+	if duration, ok := oggOpusDuration(data); ok {
+		return duration, nil
+	}
+
+	tracer := otel.Tracer("audio/Duration")
+	ctx, span := tracer.Start(ctx, "Duration")
+	defer span.End()
+
+	input, err := writeTemp(data)
+	if err != nil {
+		span.RecordError(err)
+		return 0, err
+	}
+	defer os.Remove(input)
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "ffprobe",
+		"-v", "error",
+		"-show_entries", "format=duration",
+		"-of", "default=noprint_wrappers=1:nokey=1",
+		input,
+	)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		span.RecordError(err)
+		return 0, fmt.Errorf("ffprobe failed: %w: %s", err, bytes.TrimSpace(stderr.Bytes()))
+	}
+	seconds, err := strconv.ParseFloat(strings.TrimSpace(stdout.String()), 64)
+	if err != nil {
+		return 0, fmt.Errorf("unexpected ffprobe duration %q", stdout.String())
+	}
+	return time.Duration(seconds * float64(time.Second)), nil
+}
+
+func writeTemp(data []byte) (string, error) {
+	file, err := os.CreateTemp("", "gulabo-audio-*")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temp file: %w", err)
+	}
+	_, err = file.Write(data)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(file.Name())
+		return "", fmt.Errorf("failed to write temp file: %w", err)
+	}
+	return file.Name(), nil
+}
+
+// wavDuration reads a plain PCM WAV's length from its header.
+func wavDuration(data []byte) (time.Duration, bool) {
+	if len(data) < 44 || string(data[:4]) != "RIFF" || string(data[8:12]) != "WAVE" {
+		return 0, false
+	}
+	byteRate := binary.LittleEndian.Uint32(data[28:32])
+	// Only the canonical layout, with the data chunk right after fmt
+	if byteRate == 0 || string(data[36:40]) != "data" {
+		return 0, false
+	}
+	size := binary.LittleEndian.Uint32(data[40:44])
+	// Streamed WAVs don't know their size up front and leave it at the maximum
+	if int(size) > len(data)-44 {
+		size = uint32(len(data) - 44)
+	}
+	return time.Duration(size) * time.Second / time.Duration(byteRate), true
+}
+
+// oggOpusDuration reads how long an OGG/Opus file plays. The last page's
+// granule position is the sample count, which includes the encoder's
+// pre-skip from the OpusHead header.
+func oggOpusDuration(ogg []byte) (time.Duration, bool) {
+	var preSkip int64
+	granule := int64(-1)
+	for len(ogg) >= 27 && string(ogg[:4]) == "OggS" {
+		segments := int(ogg[26])
+		header := 27 + segments
+		if len(ogg) < header {
+			return 0, false
+		}
+		size := 0
+		for _, segment := range ogg[27:header] {
+			size += int(segment)
+		}
+		if len(ogg) < header+size {
+			return 0, false
+		}
+
+		payload := ogg[header : header+size]
+		if bytes.HasPrefix(payload, []byte("OpusHead")) && len(payload) >= 12 {
+			preSkip = int64(binary.LittleEndian.Uint16(payload[10:12]))
+		}
+		// -1 marks a page where no packet ends
+		if position := int64(binary.LittleEndian.Uint64(ogg[6:14])); position >= 0 {
+			granule = position
+		}
+		ogg = ogg[header+size:]
+	}
+	if granule <= preSkip {
+		return 0, false
+	}
+	return time.Duration(granule-preSkip) * time.Second / opusSampleRate, true
+}
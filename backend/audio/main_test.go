@@ -0,0 +1,85 @@
+package audio
+
+import (
+	"context"
+	"encoding/binary"
+	"strings"
+	"testing"
+	"time"
+)
+
+// oggPage builds an Ogg page holding one packet shorter than 255 bytes.
+func oggPage(granule int64, packet []byte) []byte {
+	page := make([]byte, 27, 28+len(packet))
+	copy(page, "OggS")
+	binary.LittleEndian.PutUint64(page[6:14], uint64(granule))
+	page[26] = 1
+	page = append(page, byte(len(packet)))
+	return append(page, packet...)
+}
+
+func TestOggOpusDuration(t *testing.T) {
+	head := append([]byte("OpusHead"), 1, 1, 0, 0)
+	binary.LittleEndian.PutUint16(head[10:12], 312)
+
+	var ogg []byte
+	ogg = append(ogg, oggPage(0, head)...)
+	ogg = append(ogg, oggPage(0, []byte("OpusTags"))...)
+	ogg = append(ogg, oggPage(48000, []byte("audio"))...)
+	ogg = append(ogg, oggPage(-1, []byte("continued"))...)
+	ogg = append(ogg, oggPage(2*48000+312+24000, []byte("audio"))...)
+
+	if got, ok := oggOpusDuration(ogg); !ok || got != 2500*time.Millisecond {
+		t.Errorf("oggOpusDuration = %v, %v, want 2.5s", got, ok)
+	}
+
+	for _, broken := range [][]byte{nil, []byte("RIFF...."), ogg[:len(ogg)-2]} {
+		if _, ok := oggOpusDuration(broken); ok {
+			t.Errorf("oggOpusDuration(%q) should fail", broken)
+		}
+	}
+}
+
+func TestPCMToWAV(t *testing.T) {
+	// Half a second of 24 kHz mono
+	wav := PCMToWAV(make([]byte, 24000), 24000, 1)
+	if len(wav) != 44+24000 || string(wav[:4]) != "RIFF" || string(wav[8:16]) != "WAVEfmt " {
+		t.Fatalf("unexpected header % x", wav[:44])
+	}
+
+	duration, err := Duration(context.Background(), wav)
+	if err != nil || duration != 500*time.Millisecond {
+		t.Errorf("Duration = %v, %v, want 500ms", duration, err)
+	}
+}
+
+func TestConvertUnknownFormat(t *testing.T) {
+	if _, err := Convert(context.Background(), nil, Format("flac"), Options{}); err == nil {
+		t.Error("Convert to an unknown format should fail")
+	}
+}
+
+func TestFFmpegArgs(t *testing.T) {
+	got := strings.Join(ffmpegArgs("in", outputArgs[OGG], Options{SampleRate: 48000, Channels: 1, Loudness: -16}), " ")
+	want := "-hide_banner -loglevel error -i in -vn -af loudnorm=I=-16:TP=-1.5:LRA=11 -ac 1 -ar 48000 " +
+		"-c:a libopus -b:a 32k -application voip -f ogg pipe:1"
+	if got != want {
+		t.Errorf("got %s\nwant %s", got, want)
+	}
+
+	if got := strings.Join(ffmpegArgs("in", outputArgs[WAV], Options{}), " "); got != "-hide_banner -loglevel error -i in -vn -c:a pcm_s16le -f wav pipe:1" {
+		t.Errorf("options left unset should leave the audio as it is, got %s", got)
+	}
+}
+
+func TestAudioFilters(t *testing.T) {
+	got := strings.Join(audioFilters(Options{Tempo: 0.85, Pitch: 2, Loudness: -16}), ",")
+	want := "aresample=48000,asetrate=53878,aresample=48000,atempo=0.8909,atempo=0.85,loudnorm=I=-16:TP=-1.5:LRA=11"
+	if got != want {
+		t.Errorf("got %s\nwant %s", got, want)
+	}
+
+	if got := audioFilters(Options{Tempo: 1}); len(got) != 0 {
+		t.Errorf("normal tempo should need no filters, got %v", got)
+	}
+}
@@ -2,24 +2,38 @@ package postgres
 
 import (
 	"context"
+	"crypto/rand"
 	"database/sql"
+	_ "embed"
 	"fmt"
 	"gulabodev/logger"
 	"os"
+	"strings"
 	"time"
 
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 
 	"go.opentelemetry.io/otel"
 	"go.uber.org/zap"
 )
 
+// schemaSQL creates every table. The default schema gets it from the
+// container's init scripts; bot schemas get it from ensureSchema.
+//
+//go:embed schema.sql
+var schemaSQL string
+
 type DatabaseConnectProps struct {
 	Logger *logger.LogMiddleware
+	// Schema scopes every query to one Postgres schema, so bots sharing a
+	// database keep their records apart. The schema and its tables are created
+	// on first connect. Empty uses the default search path.
+	Schema string
 }
 
 type Database struct {
 	Queries
+	conn   *sql.DB
 	logger *logger.LogMiddleware
 }
 
@@ -36,7 +50,7 @@ func Connect(ctx context.Context, args DatabaseConnectProps) *Database {
 	logger := args.Logger.Logger(ctx)
 
 	for connectRetries > 0 {
-		conn, err, connStr = getConnection(ctx)
+		conn, err, connStr = getConnection(ctx, args.Schema)
 		if err == nil {
 			logger.Info("[Postgres] Database client started")
 			break
@@ -58,11 +72,76 @@ func Connect(ctx context.Context, args DatabaseConnectProps) *Database {
 		os.Exit(1)
 	}
 
+	if args.Schema != "" {
+		if err := ensureSchema(ctx, conn, args.Schema); err != nil {
+			logger.Error("[Postgres] Failed to set up schema", zap.Error(err), zap.String("schema", args.Schema))
+			span.RecordError(err)
+			os.Exit(1)
+		}
+	}
+
 	queries := New(conn)
-	return &Database{Queries: *queries, logger: args.Logger}
+	return &Database{Queries: *queries, conn: conn, logger: args.Logger}
+}
+
+// ensureSchema creates the schema and its tables the first time a bot
+// connects. Existing schemas are left alone, since schema.sql drops tables.
+func ensureSchema(ctx context.Context, conn *sql.DB, schema string) error {
+	var exists bool
+	err := conn.QueryRowContext(ctx,
+		"SELECT EXISTS (SELECT 1 FROM information_schema.schemata WHERE schema_name = $1)", schema,
+	).Scan(&exists)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return nil
+	}
+
+	// The drops are skipped: the schema is empty, and with public on the
+	// search path they would resolve to the default bot's tables
+	var statements []string
+	for _, line := range strings.Split(schemaSQL, "\n") {
+		if strings.HasPrefix(line, "DROP TABLE") {
+			continue
+		}
+		statements = append(statements, line)
+	}
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// pgvector's types live in public, where the other schemas find them
+	if _, err := tx.ExecContext(ctx, "CREATE EXTENSION IF NOT EXISTS vector WITH SCHEMA public"); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, "CREATE SCHEMA IF NOT EXISTS "+pq.QuoteIdentifier(schema)); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, strings.Join(statements, "\n")); err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
-func getConnection(ctx context.Context) (*sql.DB, error, string) {
+// InTx runs fn with queries bound to one transaction, committing if fn
+// succeeds and rolling back otherwise.
+func (d *Database) InTx(ctx context.Context, fn func(q *Queries) error) error {
+	tx, err := d.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := fn(d.Queries.WithTx(tx)); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+func getConnection(ctx context.Context, schema string) (*sql.DB, error, string) {
 	tracer := otel.Tracer("postgres/getConnection")
 	_, span := tracer.Start(ctx, "getConnection")
 	defer span.End()
@@ -79,6 +158,10 @@ func getConnection(ctx context.Context) (*sql.DB, error, string) {
 		"host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
 		host, port, user, password, dbname, sslMode,
 	)
+	if schema != "" {
+		// public stays on the path for extension types like pgvector's
+		postgresqlDbInfo += fmt.Sprintf(" search_path=%s,public", schema)
+	}
 
 	db, err := sql.Open("postgres", postgresqlDbInfo)
 	if err != nil {
@@ -99,6 +182,12 @@ type SetupNewUserProps struct {
 	TelegramFirstName string
 	TelegramUsername  string
 	TelegramLastName  string
+	// ReferralCode of the user who invited this one, if any.
+	ReferralCode string
+	// ReferralBonus is granted to both the new user and the referrer.
+	ReferralBonus int32
+	// Campaign the user signed up through, for first-touch attribution.
+	Campaign string
 }
 
 func (d *Database) SetupNewUser(ctx context.Context, args SetupNewUserProps) (*UserInfo, error) {
@@ -111,6 +200,7 @@ func (d *Database) SetupNewUser(ctx context.Context, args SetupNewUserProps) (*U
 		TelegramUsername:  sql.NullString{Valid: true, String: args.TelegramUsername},
 		TelegramFirstName: sql.NullString{Valid: true, String: args.TelegramFirstName},
 		TelegramLastName:  sql.NullString{Valid: true, String: args.TelegramLastName},
+		Campaign:          sql.NullString{Valid: args.Campaign != "", String: args.Campaign},
 	})
 	if err != nil {
 		d.logger.Logger(ctx).Error(
@@ -133,5 +223,119 @@ func (d *Database) SetupNewUser(ctx context.Context, args SetupNewUserProps) (*U
 		return nil, fmt.Errorf("could not setup new user credits")
 	}
 
-	return &user, err
+	if _, err := d.createReferralCode(ctx, user.UserID); err != nil {
+		// Not fatal, the code is created lazily when the user asks for it
+		d.logger.Logger(ctx).Error(
+			"[Postgres] Could not create referral code for new user",
+			zap.Error(err),
+			zap.Int64("telegram_user_id", args.TelegramUserID),
+		)
+	}
+
+	if args.ReferralCode != "" {
+		if err := d.applyReferral(ctx, user, args); err != nil {
+			d.logger.Logger(ctx).Error(
+				"[Postgres] Could not apply referral",
+				zap.Error(err),
+				zap.Int64("telegram_user_id", args.TelegramUserID),
+				zap.String("referral_code", args.ReferralCode),
+			)
+			span.RecordError(err)
+		}
+	}
+
+	return &user, nil
+}
+
+const referralCodeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+func generateReferralCode() (string, error) {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	for i := range buf {
+		buf[i] = referralCodeAlphabet[int(buf[i])%len(referralCodeAlphabet)]
+	}
+	return string(buf), nil
+}
+
+func (d *Database) createReferralCode(ctx context.Context, userID int64) (*ReferralCode, error) {
+	code, err := generateReferralCode()
+	if err != nil {
+		return nil, err
+	}
+
+	referralCode, err := d.Queries.CreateReferralCode(ctx, CreateReferralCodeParams{
+		UserID: userID,
+		Code:   code,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &referralCode, nil
+}
+
+// GetOrCreateReferralCode returns the user's referral code, creating one for
+// users who signed up before referrals existed.
+func (d *Database) GetOrCreateReferralCode(ctx context.Context, telegramUserID int64) (string, error) {
+	tracer := otel.Tracer("postgres/GetOrCreateReferralCode")
+	ctx, span := tracer.Start(ctx, "GetOrCreateReferralCode")
+	defer span.End()
+
+	referralCode, err := d.Queries.GetReferralCodeByTelegramUserId(ctx, telegramUserID)
+	if err == nil {
+		return referralCode.Code, nil
+	}
+	if err != sql.ErrNoRows {
+		span.RecordError(err)
+		return "", err
+	}
+
+	user, err := d.Queries.GetUserByTelegramUserId(ctx, telegramUserID)
+	if err != nil {
+		span.RecordError(err)
+		return "", err
+	}
+
+	created, err := d.createReferralCode(ctx, user.UserID)
+	if err != nil {
+		span.RecordError(err)
+		return "", err
+	}
+	return created.Code, nil
+}
+
+// applyReferral records the referral and grants both bonuses in one
+// transaction, so either everyone is credited or nothing is recorded.
+func (d *Database) applyReferral(ctx context.Context, user UserInfo, args SetupNewUserProps) error {
+	referrer, err := d.Queries.GetUserByReferralCode(ctx, args.ReferralCode)
+	if err != nil {
+		return fmt.Errorf("could not find referrer: %w", err)
+	}
+
+	return d.InTx(ctx, func(q *Queries) error {
+		_, err := q.CreateReferral(ctx, CreateReferralParams{
+			ReferrerUserID: referrer.UserID,
+			ReferredUserID: user.UserID,
+		})
+		if err != nil {
+			return fmt.Errorf("could not record referral: %w", err)
+		}
+
+		if args.ReferralBonus <= 0 {
+			return nil
+		}
+
+		for _, telegramUserID := range []int64{user.TelegramUserID, referrer.TelegramUserID} {
+			_, err = q.AddUserCreditsByTelegramUserId(ctx, AddUserCreditsByTelegramUserIdParams{
+				TelegramUserID: telegramUserID,
+				Amount:         args.ReferralBonus,
+			})
+			if err != nil {
+				return fmt.Errorf("could not grant referral bonus: %w", err)
+			}
+		}
+		return nil
+	})
 }
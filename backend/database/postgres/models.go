@@ -10,27 +10,326 @@ import (
 	"time"
 )
 
+type AbuseFlag struct {
+	ID         int64
+	UserID     int64
+	Reason     string
+	Message    string
+	MutedUntil time.Time
+	Created    time.Time
+}
+
+type AutoRecharge struct {
+	ID             int64
+	TelegramUserID int64
+	Payload        string
+	Method         string
+	Status         string
+	Created        time.Time
+}
+
+type Broadcast struct {
+	ID                  int64
+	AdminTelegramUserID int64
+	Text                string
+	VoiceFileID         sql.NullString
+	Created             time.Time
+	Completed           sql.NullTime
+}
+
+type BroadcastDelivery struct {
+	ID             int64
+	BroadcastID    int64
+	TelegramUserID int64
+	Status         string
+	Error          sql.NullString
+	Created        time.Time
+}
+
 type Conversation struct {
+	ID                 int64
+	TelegramUserID     int64
+	Persona            string
+	TtsVoice           string
+	Messages           json.RawMessage
+	Summary            string
+	SummarizedMessages int32
+	Created            time.Time
+	Updated            time.Time
+}
+
+type ConversationArchive struct {
 	ID             int64
+	ConversationID int64
 	TelegramUserID int64
+	Persona        string
 	Messages       json.RawMessage
+	Archived       time.Time
+}
+
+type CustomPersona struct {
+	TelegramUserID int64
+	Name           string
+	City           string
+	Traits         string
+	LanguageMix    string
+	Voice          string
+	CompletedAt    sql.NullTime
 	Created        time.Time
 	Updated        time.Time
 }
 
-type UserCredit struct {
+type DailyGreeting struct {
+	ID             int64
+	TelegramUserID int64
+	Kind           string
+	LocalDate      time.Time
+	Created        time.Time
+}
+
+type Feedback struct {
 	ID             int64
 	UserID         int64
-	CreditsBalance int32
+	ConversationID sql.NullInt64
+	Rating         sql.NullInt32
+	Text           string
+	RecentMessages json.RawMessage
+	Created        time.Time
+}
+
+type Gift struct {
+	ID           string
+	Name         string
+	Emoji        string
+	PriceCredits int32
+	Affection    int32
+	Reaction     string
+	Active       bool
+	Created      time.Time
+}
+
+type GiftPurchase struct {
+	ID             int64
+	TelegramUserID int64
+	GiftID         string
+	Credits        int32
+	Created        time.Time
+}
+
+type Memory struct {
+	ID      int64
+	UserID  int64
+	Fact    string
+	Created time.Time
+	Updated time.Time
+}
+
+type PaidMediaPurchase struct {
+	ID             int64
+	TransactionID  string
+	TelegramUserID int64
+	PremiumMediaID sql.NullInt64
+	Stars          int32
+	Purchased      time.Time
+	Created        time.Time
+}
+
+type Payment struct {
+	ID       int64
+	UserID   int64
+	Provider string
+	Payload  string
+	Credits  int32
+	Amount   int32
+	Currency string
+	ChargeID sql.NullString
+	Created  time.Time
+}
+
+type PracticeSession struct {
+	ID       int64
+	UserID   int64
+	Scenario json.RawMessage
+	Messages json.RawMessage
+	Ended    sql.NullTime
+	Created  time.Time
+	Updated  time.Time
+}
+
+type PremiumMedium struct {
+	ID                  int64
+	Kind                string
+	FileID              string
+	Caption             string
+	PriceStars          int32
+	AdminTelegramUserID int64
+	Created             time.Time
+}
+
+type PromoCode struct {
+	ID                  int64
+	Code                string
+	Credits             int32
+	MaxUses             sql.NullInt32
+	Uses                int32
+	Expires             sql.NullTime
+	AdminTelegramUserID int64
+	Created             time.Time
+}
+
+type PromoRedemption struct {
+	ID          int64
+	PromoCodeID int64
+	UserID      int64
+	Created     time.Time
+}
+
+type RecallMoment struct {
+	ID             int64
+	ConversationID int64
+	Content        string
+	Model          string
+	Embedding      interface{}
+	Said           sql.NullTime
+	Created        time.Time
+}
+
+type Reengagement struct {
+	ID      int64
+	UserID  int64
+	Created time.Time
+}
+
+type Referral struct {
+	ID             int64
+	ReferrerUserID int64
+	ReferredUserID int64
+	Created        time.Time
+}
+
+type ReferralCode struct {
+	ID      int64
+	UserID  int64
+	Code    string
+	Created time.Time
+}
+
+type Relationship struct {
+	TelegramUserID int64
+	Affection      int32
 	Created        time.Time
 	Updated        time.Time
 }
 
+type Response struct {
+	ID             int64
+	TelegramUserID int64
+	MessageType    string
+	LatencyMs      int32
+	TtsFailed      bool
+	Created        time.Time
+}
+
+type StarsLedger struct {
+	ID                      int64
+	TelegramUserID          int64
+	Event                   string
+	InvoicePayload          string
+	TelegramPaymentChargeID string
+	Amount                  int32
+	Credits                 int32
+	FlaggedAt               sql.NullTime
+	Created                 time.Time
+}
+
+type StripeCustomer struct {
+	TelegramUserID  int64
+	CustomerID      string
+	PaymentMethodID string
+	Created         time.Time
+	Updated         time.Time
+}
+
+type Subscription struct {
+	ID                      int64
+	UserID                  int64
+	Status                  string
+	TelegramPaymentChargeID string
+	ExpiresAt               time.Time
+	Created                 time.Time
+	Updated                 time.Time
+}
+
+type UsageRecord struct {
+	ID             int64
+	TelegramUserID int64
+	Provider       string
+	Kind           string
+	Model          string
+	InputTokens    int32
+	OutputTokens   int32
+	Characters     int32
+	CostMicros     int64
+	Tier           string
+	Created        time.Time
+}
+
+type UserCredit struct {
+	ID               int64
+	UserID           int64
+	CreditsBalance   int32
+	LastDailyClaim   sql.NullTime
+	StreakDays       int32
+	LastStreakDate   sql.NullTime
+	HalfCreditOwed   bool
+	PurchasedCredits int32
+	LastBonusGrant   time.Time
+	Created          time.Time
+	Updated          time.Time
+}
+
 type UserInfo struct {
 	UserID            int64
 	TelegramUserID    int64
 	TelegramUsername  sql.NullString
 	TelegramFirstName sql.NullString
 	TelegramLastName  sql.NullString
+	Banned            bool
+	AgeVerifiedAt     sql.NullTime
+	Campaign          sql.NullString
+	Created           time.Time
+}
+
+type UserMood struct {
+	TelegramUserID int64
+	Mood           string
+	Updated        time.Time
+}
+
+type UserPreference struct {
+	ID                int64
+	UserID            int64
+	BroadcastOptOut   bool
+	ReengageOptOut    bool
+	DndStart          sql.NullInt32
+	DndEnd            sql.NullInt32
+	Timezone          string
+	TextReplies       bool
+	ReplyLanguage     string
+	ActivePersona     string
+	PreferredName     sql.NullString
+	Vibe              string
+	OnboardedAt       sql.NullTime
+	LastVoiceFileIds  json.RawMessage
+	VoiceCaptions     string
+	TranscriptEcho    bool
+	AutoRecharge      string
+	AutoRechargeLimit int32
+	DailyGreetings    string
+	ContentIntensity  string
+	SpeechRate        string
+	SpeechPitch       string
+	SpokenLanguage    string
 	Created           time.Time
+	Updated           time.Time
 }
@@ -1,7 +1,7 @@
 -------------------- UserInfo Queries --------------------
 
 -- name: AddUser :one
-INSERT INTO user_info (telegram_user_id, telegram_username, telegram_first_name, telegram_last_name) VALUES ($1, $2, $3, $4) RETURNING *;
+INSERT INTO user_info (telegram_user_id, telegram_username, telegram_first_name, telegram_last_name, campaign) VALUES ($1, $2, $3, $4, $5) RETURNING *;
 
 
 -- name: GetUserByTelegramUserId :one
@@ -10,6 +10,12 @@ SELECT * FROM user_info WHERE telegram_user_id = $1 LIMIT 1;
 -- name: DeleteUserByTelegramUserId :exec
 DELETE FROM user_info WHERE telegram_user_id = $1;
 
+-- name: VerifyUserAgeByTelegramUserId :one
+UPDATE user_info SET age_verified_at = CURRENT_TIMESTAMP WHERE telegram_user_id = $1 RETURNING *;
+
+-- name: SetUserBannedByTelegramUserId :one
+UPDATE user_info SET banned = sqlc.arg(banned) WHERE telegram_user_id = sqlc.arg(telegram_user_id) RETURNING *;
+
 -------------------- User Credits Queries --------------------
 
 -- name: CreateUserCredits :one
@@ -22,36 +28,735 @@ SELECT * FROM user_credits WHERE user_id = $1 LIMIT 1;
 SELECT uc.credits_balance FROM user_credits uc JOIN user_info ui ON uc.user_id = ui.user_id WHERE ui.telegram_user_id = $1;
 
 -- name: AddUserCreditsByTelegramUserId :one
+-- Grants bonus credits. Negative amounts take bonus credits first.
+UPDATE user_credits
+SET credits_balance = credits_balance + sqlc.arg(amount),
+    purchased_credits = LEAST(purchased_credits, credits_balance + sqlc.arg(amount)),
+    last_bonus_grant = CASE WHEN sqlc.arg(amount) > 0 THEN CURRENT_TIMESTAMP ELSE last_bonus_grant END,
+    updated = CURRENT_TIMESTAMP
+FROM user_info
+WHERE user_credits.user_id = user_info.user_id AND user_info.telegram_user_id = sqlc.arg(telegram_user_id)
+RETURNING user_credits.*;
+
+-- name: AddPurchasedCreditsByTelegramUserId :one
 UPDATE user_credits
-SET credits_balance = credits_balance + sqlc.arg(amount), updated = CURRENT_TIMESTAMP
+SET credits_balance = credits_balance + sqlc.arg(amount),
+    purchased_credits = purchased_credits + sqlc.arg(amount),
+    updated = CURRENT_TIMESTAMP
 FROM user_info
 WHERE user_credits.user_id = user_info.user_id AND user_info.telegram_user_id = sqlc.arg(telegram_user_id)
 RETURNING user_credits.*;
 
+-- name: ExpireBonusCredits :execrows
+-- Drops unused bonus credits for users with no bonus grant since granted_before.
+UPDATE user_credits
+SET credits_balance = purchased_credits, updated = CURRENT_TIMESTAMP
+WHERE credits_balance > purchased_credits AND last_bonus_grant < sqlc.arg(granted_before);
+
+-- name: GetCreditBreakdownByTelegramUserId :one
+SELECT
+  uc.credits_balance,
+  uc.purchased_credits,
+  uc.last_bonus_grant,
+  (SELECT COUNT(*) FROM responses r WHERE r.telegram_user_id = ui.telegram_user_id AND r.created >= sqlc.arg(usage_since)) AS replies_since
+FROM user_credits uc JOIN user_info ui ON uc.user_id = ui.user_id
+WHERE ui.telegram_user_id = sqlc.arg(telegram_user_id);
+
 -- name: DecrementUserCreditsByTelegramUserId :one
 UPDATE user_credits
-SET credits_balance = credits_balance - 1, updated = CURRENT_TIMESTAMP
+SET credits_balance = credits_balance - 1,
+    purchased_credits = LEAST(purchased_credits, credits_balance - 1),
+    updated = CURRENT_TIMESTAMP
 FROM user_info
 WHERE user_credits.user_id = user_info.user_id AND user_info.telegram_user_id = $1 AND user_credits.credits_balance > 0
 RETURNING user_credits.*;
 
+-- name: ChargeHalfCreditByTelegramUserId :one
+-- Every second call takes a whole credit.
+UPDATE user_credits
+SET credits_balance = credits_balance - CASE WHEN half_credit_owed THEN 1 ELSE 0 END,
+    purchased_credits = LEAST(purchased_credits, credits_balance - CASE WHEN half_credit_owed THEN 1 ELSE 0 END),
+    half_credit_owed = NOT half_credit_owed, updated = CURRENT_TIMESTAMP
+FROM user_info
+WHERE user_credits.user_id = user_info.user_id AND user_info.telegram_user_id = $1 AND user_credits.credits_balance > 0
+RETURNING user_credits.*;
+
+-- name: SpendUserCreditsByTelegramUserId :one
+-- Takes amount credits, bonus credits first. No row comes back when the
+-- user can't afford it.
+UPDATE user_credits
+SET credits_balance = credits_balance - sqlc.arg(amount),
+    purchased_credits = LEAST(purchased_credits, credits_balance - sqlc.arg(amount)),
+    updated = CURRENT_TIMESTAMP
+FROM user_info
+WHERE user_credits.user_id = user_info.user_id AND user_info.telegram_user_id = sqlc.arg(telegram_user_id)
+  AND user_credits.credits_balance >= sqlc.arg(amount)
+RETURNING user_credits.*;
+
+-- name: ClaimDailyCreditsByTelegramUserId :one
+UPDATE user_credits
+SET credits_balance = credits_balance + sqlc.arg(amount), last_daily_claim = CURRENT_TIMESTAMP, last_bonus_grant = CURRENT_TIMESTAMP, updated = CURRENT_TIMESTAMP
+FROM user_info
+WHERE user_credits.user_id = user_info.user_id AND user_info.telegram_user_id = sqlc.arg(telegram_user_id)
+  AND (user_credits.last_daily_claim IS NULL OR user_credits.last_daily_claim <= CURRENT_TIMESTAMP - INTERVAL '24 hours')
+RETURNING user_credits.*;
+
+-- name: GetLastDailyClaimByTelegramUserId :one
+SELECT uc.last_daily_claim FROM user_credits uc JOIN user_info ui ON uc.user_id = ui.user_id WHERE ui.telegram_user_id = $1;
+
+-- name: RecordStreakDayByTelegramUserId :one
+-- Extends the streak if the user was last active the day before and restarts
+-- it after a gap. Returns no rows if today was already counted.
+UPDATE user_credits
+SET streak_days = CASE WHEN user_credits.last_streak_date = sqlc.arg(today)::date - 1 THEN user_credits.streak_days + 1 ELSE 1 END,
+    last_streak_date = sqlc.arg(today)::date, updated = CURRENT_TIMESTAMP
+FROM user_info
+WHERE user_credits.user_id = user_info.user_id AND user_info.telegram_user_id = sqlc.arg(telegram_user_id)
+  AND (user_credits.last_streak_date IS NULL OR user_credits.last_streak_date < sqlc.arg(today)::date)
+RETURNING user_credits.streak_days;
+
+-------------------- User Preferences Queries --------------------
+
+-- name: GetUserPreferencesByTelegramUserId :one
+SELECT up.* FROM user_preferences up JOIN user_info ui ON up.user_id = ui.user_id WHERE ui.telegram_user_id = $1 LIMIT 1;
+
+-- name: SetBroadcastOptOutByTelegramUserId :one
+INSERT INTO user_preferences (user_id, broadcast_opt_out)
+SELECT user_id, sqlc.arg(broadcast_opt_out) FROM user_info WHERE telegram_user_id = sqlc.arg(telegram_user_id)
+ON CONFLICT (user_id) DO UPDATE
+SET broadcast_opt_out = EXCLUDED.broadcast_opt_out, updated = CURRENT_TIMESTAMP
+RETURNING *;
+
+-- name: SetReengageOptOutByTelegramUserId :one
+INSERT INTO user_preferences (user_id, reengage_opt_out)
+SELECT user_id, sqlc.arg(reengage_opt_out) FROM user_info WHERE telegram_user_id = sqlc.arg(telegram_user_id)
+ON CONFLICT (user_id) DO UPDATE
+SET reengage_opt_out = EXCLUDED.reengage_opt_out, updated = CURRENT_TIMESTAMP
+RETURNING *;
+
+-- name: SetQuietHoursByTelegramUserId :one
+INSERT INTO user_preferences (user_id, dnd_start, dnd_end, timezone)
+SELECT user_id, sqlc.arg(dnd_start), sqlc.arg(dnd_end), sqlc.arg(timezone) FROM user_info WHERE telegram_user_id = sqlc.arg(telegram_user_id)
+ON CONFLICT (user_id) DO UPDATE
+SET dnd_start = EXCLUDED.dnd_start, dnd_end = EXCLUDED.dnd_end, timezone = EXCLUDED.timezone, updated = CURRENT_TIMESTAMP
+RETURNING *;
+
+-- name: SetTextRepliesByTelegramUserId :one
+INSERT INTO user_preferences (user_id, text_replies)
+SELECT user_id, sqlc.arg(text_replies) FROM user_info WHERE telegram_user_id = sqlc.arg(telegram_user_id)
+ON CONFLICT (user_id) DO UPDATE
+SET text_replies = EXCLUDED.text_replies, updated = CURRENT_TIMESTAMP
+RETURNING *;
+
+-- name: SetReplyLanguageByTelegramUserId :one
+INSERT INTO user_preferences (user_id, reply_language)
+SELECT user_id, sqlc.arg(reply_language) FROM user_info WHERE telegram_user_id = sqlc.arg(telegram_user_id)
+ON CONFLICT (user_id) DO UPDATE
+SET reply_language = EXCLUDED.reply_language, updated = CURRENT_TIMESTAMP
+RETURNING *;
+
+-- name: SetActivePersonaByTelegramUserId :one
+INSERT INTO user_preferences (user_id, active_persona)
+SELECT user_id, sqlc.arg(active_persona) FROM user_info WHERE telegram_user_id = sqlc.arg(telegram_user_id)
+ON CONFLICT (user_id) DO UPDATE
+SET active_persona = EXCLUDED.active_persona, updated = CURRENT_TIMESTAMP
+RETURNING *;
+
+-- name: SetPreferredNameByTelegramUserId :one
+INSERT INTO user_preferences (user_id, preferred_name)
+SELECT user_id, sqlc.arg(preferred_name) FROM user_info WHERE telegram_user_id = sqlc.arg(telegram_user_id)
+ON CONFLICT (user_id) DO UPDATE
+SET preferred_name = EXCLUDED.preferred_name, updated = CURRENT_TIMESTAMP
+RETURNING *;
+
+-- name: SetVibeByTelegramUserId :one
+INSERT INTO user_preferences (user_id, vibe)
+SELECT user_id, sqlc.arg(vibe) FROM user_info WHERE telegram_user_id = sqlc.arg(telegram_user_id)
+ON CONFLICT (user_id) DO UPDATE
+SET vibe = EXCLUDED.vibe, updated = CURRENT_TIMESTAMP
+RETURNING *;
+
+-- name: CompleteOnboardingByTelegramUserId :one
+INSERT INTO user_preferences (user_id, onboarded_at)
+SELECT user_id, CURRENT_TIMESTAMP FROM user_info WHERE telegram_user_id = $1
+ON CONFLICT (user_id) DO UPDATE
+SET onboarded_at = EXCLUDED.onboarded_at, updated = CURRENT_TIMESTAMP
+RETURNING *;
+
+-- name: SetLastVoiceFileIdsByTelegramUserId :one
+INSERT INTO user_preferences (user_id, last_voice_file_ids)
+SELECT user_id, sqlc.arg(last_voice_file_ids) FROM user_info WHERE telegram_user_id = sqlc.arg(telegram_user_id)
+ON CONFLICT (user_id) DO UPDATE
+SET last_voice_file_ids = EXCLUDED.last_voice_file_ids, updated = CURRENT_TIMESTAMP
+RETURNING *;
+
+-- name: SetVoiceCaptionsByTelegramUserId :one
+INSERT INTO user_preferences (user_id, voice_captions)
+SELECT user_id, sqlc.arg(voice_captions) FROM user_info WHERE telegram_user_id = sqlc.arg(telegram_user_id)
+ON CONFLICT (user_id) DO UPDATE
+SET voice_captions = EXCLUDED.voice_captions, updated = CURRENT_TIMESTAMP
+RETURNING *;
+
+-- name: SetTranscriptEchoByTelegramUserId :one
+INSERT INTO user_preferences (user_id, transcript_echo)
+SELECT user_id, sqlc.arg(transcript_echo) FROM user_info WHERE telegram_user_id = sqlc.arg(telegram_user_id)
+ON CONFLICT (user_id) DO UPDATE
+SET transcript_echo = EXCLUDED.transcript_echo, updated = CURRENT_TIMESTAMP
+RETURNING *;
+
+-- name: SetAutoRechargeByTelegramUserId :one
+INSERT INTO user_preferences (user_id, auto_recharge)
+SELECT user_id, sqlc.arg(auto_recharge) FROM user_info WHERE telegram_user_id = sqlc.arg(telegram_user_id)
+ON CONFLICT (user_id) DO UPDATE
+SET auto_recharge = EXCLUDED.auto_recharge, updated = CURRENT_TIMESTAMP
+RETURNING *;
+
+-- name: SetAutoRechargeLimitByTelegramUserId :one
+INSERT INTO user_preferences (user_id, auto_recharge_limit)
+SELECT user_id, sqlc.arg(auto_recharge_limit) FROM user_info WHERE telegram_user_id = sqlc.arg(telegram_user_id)
+ON CONFLICT (user_id) DO UPDATE
+SET auto_recharge_limit = EXCLUDED.auto_recharge_limit, updated = CURRENT_TIMESTAMP
+RETURNING *;
+
+-- name: SetDailyGreetingsByTelegramUserId :one
+INSERT INTO user_preferences (user_id, daily_greetings)
+SELECT user_id, sqlc.arg(daily_greetings) FROM user_info WHERE telegram_user_id = sqlc.arg(telegram_user_id)
+ON CONFLICT (user_id) DO UPDATE
+SET daily_greetings = EXCLUDED.daily_greetings, updated = CURRENT_TIMESTAMP
+RETURNING *;
+
+-- name: SetContentIntensityByTelegramUserId :one
+INSERT INTO user_preferences (user_id, content_intensity)
+SELECT user_id, sqlc.arg(content_intensity) FROM user_info WHERE telegram_user_id = sqlc.arg(telegram_user_id)
+ON CONFLICT (user_id) DO UPDATE
+SET content_intensity = EXCLUDED.content_intensity, updated = CURRENT_TIMESTAMP
+RETURNING *;
+
+-- name: SetSpeechByTelegramUserId :one
+INSERT INTO user_preferences (user_id, speech_rate, speech_pitch)
+SELECT user_id, sqlc.arg(speech_rate), sqlc.arg(speech_pitch) FROM user_info WHERE telegram_user_id = sqlc.arg(telegram_user_id)
+ON CONFLICT (user_id) DO UPDATE
+SET speech_rate = EXCLUDED.speech_rate, speech_pitch = EXCLUDED.speech_pitch, updated = CURRENT_TIMESTAMP
+RETURNING *;
+
+-- name: SetSpokenLanguageByTelegramUserId :exec
+INSERT INTO user_preferences (user_id, spoken_language)
+SELECT user_id, sqlc.arg(spoken_language) FROM user_info WHERE telegram_user_id = sqlc.arg(telegram_user_id)
+ON CONFLICT (user_id) DO UPDATE
+SET spoken_language = EXCLUDED.spoken_language, updated = CURRENT_TIMESTAMP;
+
+-------------------- Subscription Queries --------------------
+
+-- name: UpsertSubscriptionByTelegramUserId :one
+INSERT INTO subscriptions (user_id, status, telegram_payment_charge_id, expires_at)
+SELECT user_id, 'active', sqlc.arg(telegram_payment_charge_id), sqlc.arg(expires_at) FROM user_info WHERE telegram_user_id = sqlc.arg(telegram_user_id)
+ON CONFLICT (user_id) DO UPDATE
+SET status = 'active', telegram_payment_charge_id = EXCLUDED.telegram_payment_charge_id, expires_at = EXCLUDED.expires_at, updated = CURRENT_TIMESTAMP
+RETURNING *;
+
+-- name: GetActiveSubscriptionByTelegramUserId :one
+SELECT s.* FROM subscriptions s JOIN user_info ui ON s.user_id = ui.user_id
+WHERE ui.telegram_user_id = $1 AND s.expires_at > CURRENT_TIMESTAMP LIMIT 1;
+
+-- name: GetUserPlanByTelegramUserId :one
+-- What the user pays for: an unexpired subscription, or credits they bought.
+SELECT
+  EXISTS (SELECT 1 FROM subscriptions s WHERE s.user_id = ui.user_id AND s.expires_at > CURRENT_TIMESTAMP) AS subscribed,
+  COALESCE(uc.purchased_credits, 0)::int AS purchased_credits
+FROM user_info ui LEFT JOIN user_credits uc ON uc.user_id = ui.user_id
+WHERE ui.telegram_user_id = $1;
+
+-- name: CancelSubscriptionByTelegramUserId :one
+UPDATE subscriptions
+SET status = 'canceled', updated = CURRENT_TIMESTAMP
+FROM user_info
+WHERE subscriptions.user_id = user_info.user_id AND user_info.telegram_user_id = $1
+RETURNING subscriptions.*;
+
+-------------------- Referral Queries --------------------
+
+-- name: CreateReferralCode :one
+INSERT INTO referral_codes (user_id, code) VALUES ($1, $2) RETURNING *;
+
+-- name: GetReferralCodeByTelegramUserId :one
+SELECT rc.* FROM referral_codes rc JOIN user_info ui ON rc.user_id = ui.user_id WHERE ui.telegram_user_id = $1 LIMIT 1;
+
+-- name: GetUserByReferralCode :one
+SELECT ui.* FROM user_info ui JOIN referral_codes rc ON rc.user_id = ui.user_id WHERE rc.code = $1 LIMIT 1;
+
+-- name: CreateReferral :one
+INSERT INTO referrals (referrer_user_id, referred_user_id) VALUES ($1, $2) RETURNING *;
+
+-- name: GetReferrerByTelegramUserId :one
+SELECT ui.* FROM user_info ui
+JOIN referrals r ON r.referrer_user_id = ui.user_id
+JOIN user_info referred ON r.referred_user_id = referred.user_id
+WHERE referred.telegram_user_id = $1 LIMIT 1;
+
+-- name: CountReferralsByTelegramUserId :one
+SELECT COUNT(*) FROM referrals r JOIN user_info ui ON r.referrer_user_id = ui.user_id WHERE ui.telegram_user_id = $1;
+
 -------------------- Conversation Queries --------------------
 
 -- name: CreateConversation :one
-INSERT INTO conversations (telegram_user_id, messages)
-VALUES ($1, '[]'::jsonb) RETURNING *;
+INSERT INTO conversations (telegram_user_id, persona, messages)
+VALUES ($1, $2, '[]'::jsonb) RETURNING *;
 
 -- name: GetConversationByTelegramUserId :one
-SELECT * FROM conversations WHERE telegram_user_id = $1 LIMIT 1;
+SELECT * FROM conversations WHERE telegram_user_id = $1 AND persona = $2 LIMIT 1;
 
 -- name: UpdateConversationMessages :one
 UPDATE conversations 
 SET messages = $2, updated = CURRENT_TIMESTAMP 
-WHERE telegram_user_id = $1 
+WHERE id = $1 
 RETURNING *;
 
 -- name: ClearConversationMessages :one
+-- Forgets the recalled moments along with the history.
+WITH forgotten AS (
+  DELETE FROM recall_moments WHERE conversation_id = $1
+)
 UPDATE conversations
-SET messages = '[]'::jsonb, updated = CURRENT_TIMESTAMP
-WHERE telegram_user_id = $1
+SET messages = '[]'::jsonb, summary = '', summarized_messages = 0, updated = CURRENT_TIMESTAMP
+WHERE id = $1
 RETURNING *;
+
+-- name: AppendConversationMessages :exec
+-- Appends without bumping updated, which tracks the user's last exchange
+UPDATE conversations
+SET messages = messages || sqlc.arg(messages)::jsonb
+WHERE id = sqlc.arg(id);
+
+-- name: UpdateConversationSummary :execrows
+-- Leaves updated alone, which tracks the user's last exchange. A history
+-- cleared while the summary was being written is left alone, and so is one
+-- another summary got to first.
+UPDATE conversations
+SET summary = sqlc.arg(summary), summarized_messages = sqlc.arg(summarized_messages)
+WHERE id = sqlc.arg(id) AND jsonb_array_length(messages) >= sqlc.arg(summarized_messages)
+  AND summarized_messages = sqlc.arg(previous_summarized_messages);
+
+-- name: SetConversationVoice :one
+UPDATE conversations SET tts_voice = $2 WHERE id = $1 RETURNING *;
+
+-- name: ArchiveConversation :execrows
+-- Moves the history into conversation_archives and empties the conversation.
+-- An empty history isn't archived.
+WITH archived AS (
+  INSERT INTO conversation_archives (conversation_id, telegram_user_id, persona, messages)
+  SELECT id, telegram_user_id, persona, messages FROM conversations
+  WHERE id = $1 AND messages <> '[]'::jsonb
+  RETURNING conversation_id
+)
+UPDATE conversations
+SET messages = '[]'::jsonb, summary = '', summarized_messages = 0, updated = CURRENT_TIMESTAMP
+WHERE id IN (SELECT conversation_id FROM archived);
+
+-- name: ListConversationArchives :many
+SELECT * FROM conversation_archives WHERE conversation_id = $1 ORDER BY archived, id;
+
+-- name: DeleteConversationArchives :exec
+DELETE FROM conversation_archives WHERE conversation_id = $1;
+
+-------------------- Broadcast Queries --------------------
+
+-- name: CreateBroadcast :one
+INSERT INTO broadcasts (admin_telegram_user_id, text, voice_file_id) VALUES ($1, $2, $3) RETURNING *;
+
+-- name: CompleteBroadcast :exec
+UPDATE broadcasts SET completed = CURRENT_TIMESTAMP WHERE id = $1;
+
+-- name: ListBroadcastRecipients :many
+SELECT ui.telegram_user_id FROM user_info ui
+LEFT JOIN user_preferences up ON up.user_id = ui.user_id
+WHERE ui.banned = FALSE AND ui.age_verified_at IS NOT NULL AND COALESCE(up.broadcast_opt_out, FALSE) = FALSE
+  AND EXISTS (SELECT 1 FROM conversations c WHERE c.telegram_user_id = ui.telegram_user_id AND c.updated > CURRENT_TIMESTAMP - INTERVAL '30 days');
+
+-- name: CreateBroadcastDelivery :exec
+INSERT INTO broadcast_deliveries (broadcast_id, telegram_user_id, status, error) VALUES ($1, $2, $3, $4);
+
+-- name: GetBroadcastDeliveryStats :one
+SELECT
+  COUNT(*) FILTER (WHERE status = 'sent') AS sent,
+  COUNT(*) FILTER (WHERE status = 'failed') AS failed
+FROM broadcast_deliveries WHERE broadcast_id = $1;
+
+-------------------- Stats Queries --------------------
+
+-- name: CreatePayment :one
+-- Returns no rows if the charge was already recorded.
+INSERT INTO payments (user_id, provider, payload, credits, amount, currency, charge_id)
+SELECT user_id, sqlc.arg(provider), sqlc.arg(payload), sqlc.arg(credits), sqlc.arg(amount), sqlc.arg(currency), sqlc.arg(charge_id)
+FROM user_info WHERE telegram_user_id = sqlc.arg(telegram_user_id)
+ON CONFLICT (charge_id) DO NOTHING
+RETURNING *;
+
+-- name: GetPaymentByChargeId :one
+SELECT * FROM payments WHERE charge_id = $1 LIMIT 1;
+
+-- name: CreateResponse :exec
+INSERT INTO responses (telegram_user_id, message_type, latency_ms, tts_failed) VALUES ($1, $2, $3, $4);
+
+-- name: CountUsers :one
+SELECT COUNT(*) FROM user_info;
+
+-- name: GetResponseStatsSince :one
+SELECT
+  COUNT(DISTINCT telegram_user_id) AS active_users,
+  COUNT(*) AS responses,
+  COUNT(*) FILTER (WHERE tts_failed) AS tts_failures,
+  COALESCE(AVG(latency_ms), 0)::float8 AS avg_latency_ms
+FROM responses WHERE created >= sqlc.arg(since);
+
+-- name: GetPaymentStatsSince :one
+SELECT
+  COUNT(*) AS payments,
+  COALESCE(SUM(credits), 0)::bigint AS credits_sold
+FROM payments WHERE created >= sqlc.arg(since);
+
+-- name: ListCampaignStats :many
+-- A conversion is a signup that has made at least one payment.
+SELECT
+  ui.campaign,
+  COUNT(*) AS signups,
+  COUNT(*) FILTER (WHERE EXISTS (SELECT 1 FROM payments p WHERE p.user_id = ui.user_id)) AS conversions
+FROM user_info ui
+WHERE ui.campaign IS NOT NULL
+GROUP BY ui.campaign
+ORDER BY signups DESC
+LIMIT $1;
+
+-------------------- Reengagement Queries --------------------
+
+-- name: ListReengagementCandidates :many
+-- Only the conversation with the user's active persona is considered
+SELECT ui.telegram_user_id, c.id AS conversation_id FROM user_info ui
+LEFT JOIN user_preferences up ON up.user_id = ui.user_id
+JOIN conversations c ON c.telegram_user_id = ui.telegram_user_id AND c.persona = COALESCE(up.active_persona, 'gulabo')
+WHERE ui.banned = FALSE
+  AND ui.age_verified_at IS NOT NULL
+  AND COALESCE(up.reengage_opt_out, FALSE) = FALSE
+  AND c.messages != '[]'::jsonb
+  AND c.updated < sqlc.arg(quiet_since)
+  AND c.updated > CURRENT_TIMESTAMP - INTERVAL '30 days'
+  AND NOT EXISTS (SELECT 1 FROM reengagements r WHERE r.user_id = ui.user_id AND r.created > c.updated)
+  AND (SELECT COUNT(*) FROM reengagements r WHERE r.user_id = ui.user_id AND r.created > CURRENT_TIMESTAMP - INTERVAL '7 days') < sqlc.arg(weekly_cap)::bigint
+ORDER BY c.updated
+LIMIT sqlc.arg(batch_size);
+
+-- name: CreateReengagement :exec
+INSERT INTO reengagements (user_id) SELECT user_id FROM user_info WHERE telegram_user_id = $1;
+
+-------------------- Feedback Queries --------------------
+
+-- name: CreateFeedback :one
+INSERT INTO feedback (user_id, conversation_id, rating, text, recent_messages)
+SELECT user_id, sqlc.arg(conversation_id), sqlc.arg(rating), sqlc.arg(text), sqlc.arg(recent_messages)
+FROM user_info WHERE telegram_user_id = sqlc.arg(telegram_user_id)
+RETURNING *;
+
+-------------------- Memory Queries --------------------
+
+-- name: CreateMemory :one
+INSERT INTO memories (user_id, fact)
+SELECT user_id, sqlc.arg(fact) FROM user_info WHERE telegram_user_id = sqlc.arg(telegram_user_id)
+RETURNING *;
+
+-- name: ListMemoriesByTelegramUserId :many
+SELECT m.* FROM memories m
+JOIN user_info ui ON ui.user_id = m.user_id
+WHERE ui.telegram_user_id = $1
+ORDER BY m.created, m.id;
+
+-- name: UpdateMemoryFact :one
+UPDATE memories SET fact = sqlc.arg(fact), updated = CURRENT_TIMESTAMP
+WHERE id = sqlc.arg(id) AND user_id = (SELECT user_id FROM user_info WHERE telegram_user_id = sqlc.arg(telegram_user_id))
+RETURNING *;
+
+-- name: DeleteMemory :exec
+DELETE FROM memories
+WHERE id = sqlc.arg(id) AND user_id = (SELECT user_id FROM user_info WHERE telegram_user_id = sqlc.arg(telegram_user_id));
+
+-------------------- Recall Queries --------------------
+
+-- name: CreateRecallMoment :exec
+-- Embeddings are passed as pgvector's text form, like [0.1,0.2]
+INSERT INTO recall_moments (conversation_id, content, model, embedding, said)
+VALUES (sqlc.arg(conversation_id), sqlc.arg(content), sqlc.arg(model), sqlc.arg(embedding)::text::vector, sqlc.arg(said));
+
+-- name: SearchRecallMoments :many
+-- The moments closest in meaning to the embedding, by cosine distance. Only
+-- moments embedded by the same model are comparable.
+SELECT content, said, (embedding <=> sqlc.arg(embedding)::text::vector)::float8 AS distance
+FROM recall_moments
+WHERE conversation_id = sqlc.arg(conversation_id) AND model = sqlc.arg(model)
+ORDER BY embedding <=> sqlc.arg(embedding)::text::vector
+LIMIT sqlc.arg(top_k);
+
+-------------------- Promo Code Queries --------------------
+
+-- name: CreatePromoCode :one
+INSERT INTO promo_codes (code, credits, max_uses, expires, admin_telegram_user_id) VALUES ($1, $2, $3, $4, $5) RETURNING *;
+
+-- name: GetPromoCodeByCode :one
+SELECT * FROM promo_codes WHERE code = $1 LIMIT 1;
+
+-- name: ListPromoCodes :many
+SELECT * FROM promo_codes ORDER BY created DESC LIMIT 20;
+
+-- name: HasRedeemedPromoCode :one
+SELECT EXISTS (
+  SELECT 1 FROM promo_redemptions pr JOIN user_info ui ON pr.user_id = ui.user_id
+  WHERE pr.promo_code_id = sqlc.arg(promo_code_id) AND ui.telegram_user_id = sqlc.arg(telegram_user_id)
+);
+
+-- name: RedeemPromoCode :one
+-- Claims a use, records the redemption, and adds the credits in one statement.
+-- Returns no rows if the code is unknown, expired, or used up; a repeat
+-- redemption fails on the promo_redemptions unique constraint.
+WITH promo AS (
+  UPDATE promo_codes SET uses = uses + 1
+  WHERE code = sqlc.arg(code)
+    AND (expires IS NULL OR expires > CURRENT_TIMESTAMP)
+    AND (max_uses IS NULL OR uses < max_uses)
+  RETURNING id, credits
+), redemption AS (
+  INSERT INTO promo_redemptions (promo_code_id, user_id)
+  SELECT promo.id, ui.user_id FROM promo, user_info ui WHERE ui.telegram_user_id = sqlc.arg(telegram_user_id)
+)
+UPDATE user_credits
+SET credits_balance = credits_balance + promo.credits, last_bonus_grant = CURRENT_TIMESTAMP, updated = CURRENT_TIMESTAMP
+FROM promo, user_info
+WHERE user_credits.user_id = user_info.user_id AND user_info.telegram_user_id = sqlc.arg(telegram_user_id)
+RETURNING user_credits.credits_balance, promo.credits;
+
+-------------------- Practice Queries --------------------
+
+-- name: CreatePracticeSession :one
+INSERT INTO practice_sessions (user_id, scenario)
+SELECT user_id, sqlc.arg(scenario) FROM user_info WHERE telegram_user_id = sqlc.arg(telegram_user_id)
+RETURNING *;
+
+-- name: GetActivePracticeSessionByTelegramUserId :one
+SELECT ps.* FROM practice_sessions ps
+JOIN user_info ui ON ui.user_id = ps.user_id
+WHERE ui.telegram_user_id = $1 AND ps.ended IS NULL
+LIMIT 1;
+
+-- name: UpdatePracticeSessionMessages :exec
+UPDATE practice_sessions SET messages = sqlc.arg(messages), updated = CURRENT_TIMESTAMP
+WHERE id = sqlc.arg(id);
+
+-- name: EndPracticeSessionByTelegramUserId :execrows
+UPDATE practice_sessions SET ended = CURRENT_TIMESTAMP, updated = CURRENT_TIMESTAMP
+WHERE ended IS NULL AND user_id = (SELECT user_id FROM user_info WHERE telegram_user_id = $1);
+
+-------------------- Abuse Queries --------------------
+
+-- name: CreateAbuseFlag :exec
+INSERT INTO abuse_flags (user_id, reason, message, muted_until)
+SELECT user_id, sqlc.arg(reason), sqlc.arg(message), sqlc.arg(muted_until) FROM user_info WHERE telegram_user_id = sqlc.arg(telegram_user_id);
+
+-- name: ListRecentAbuseFlags :many
+SELECT ui.telegram_user_id, ui.telegram_username, af.reason, af.message, af.created
+FROM abuse_flags af
+JOIN user_info ui ON ui.user_id = af.user_id
+ORDER BY af.created DESC
+LIMIT $1;
+
+-------------------- Stars Ledger Queries --------------------
+
+-- name: CreateStarsLedgerEntry :exec
+INSERT INTO stars_ledger (telegram_user_id, event, invoice_payload, telegram_payment_charge_id, amount, credits)
+VALUES ($1, $2, $3, $4, $5, $6);
+
+-- name: FlagUnreconciledStarsPayments :many
+-- Flags successful payments older than settled_before that never got a
+-- matching grant, so each one is only reported once.
+UPDATE stars_ledger p SET flagged_at = CURRENT_TIMESTAMP
+WHERE p.event = 'payment'
+  AND p.flagged_at IS NULL
+  AND p.created < sqlc.arg(settled_before)
+  AND NOT EXISTS (
+    SELECT 1 FROM stars_ledger g
+    WHERE g.event = 'grant' AND g.telegram_payment_charge_id = p.telegram_payment_charge_id
+  )
+RETURNING p.*;
+
+-------------------- Paid Media Queries --------------------
+
+-- name: CreatePremiumMedia :one
+INSERT INTO premium_media (kind, file_id, caption, price_stars, admin_telegram_user_id)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING *;
+
+-- name: GetUnpurchasedPremiumMediaByTelegramUserId :one
+SELECT pm.* FROM premium_media pm
+WHERE NOT EXISTS (
+  SELECT 1 FROM paid_media_purchases pmp
+  WHERE pmp.premium_media_id = pm.id AND pmp.telegram_user_id = $1
+)
+ORDER BY pm.created DESC
+LIMIT 1;
+
+-- name: CreatePaidMediaPurchase :execrows
+INSERT INTO paid_media_purchases (transaction_id, telegram_user_id, premium_media_id, stars, purchased)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (transaction_id) DO NOTHING;
+
+-- name: GetPaidMediaStatsSince :one
+SELECT
+  COUNT(*) AS unlocks,
+  COALESCE(SUM(stars), 0)::bigint AS stars_earned
+FROM paid_media_purchases WHERE purchased >= sqlc.arg(since);
+
+-------------------- Auto Recharge Queries --------------------
+
+-- name: UpsertStripeCustomerByTelegramUserId :exec
+INSERT INTO stripe_customers (telegram_user_id, customer_id, payment_method_id)
+VALUES ($1, $2, $3)
+ON CONFLICT (telegram_user_id) DO UPDATE
+SET customer_id = EXCLUDED.customer_id, payment_method_id = EXCLUDED.payment_method_id, updated = CURRENT_TIMESTAMP;
+
+-- name: GetStripeCustomerByTelegramUserId :one
+SELECT * FROM stripe_customers WHERE telegram_user_id = $1;
+
+-- name: CreateAutoRecharge :exec
+INSERT INTO auto_recharges (telegram_user_id, payload, method, status) VALUES ($1, $2, $3, $4);
+
+-- name: CountAutoRechargesSince :one
+-- Failed attempts don't count towards the cap
+SELECT COUNT(*) FROM auto_recharges
+WHERE telegram_user_id = sqlc.arg(telegram_user_id) AND status <> 'failed' AND created >= sqlc.arg(since);
+
+-------------------- Mood Queries --------------------
+
+-- name: GetUserMoodByTelegramUserId :one
+SELECT * FROM user_moods WHERE telegram_user_id = $1;
+
+-- name: SetUserMoodByTelegramUserId :exec
+INSERT INTO user_moods (telegram_user_id, mood)
+VALUES ($1, $2)
+ON CONFLICT (telegram_user_id) DO UPDATE
+SET mood = EXCLUDED.mood, updated = CURRENT_TIMESTAMP;
+
+-------------------- Relationship Queries --------------------
+
+-- name: AddAffectionByTelegramUserId :one
+INSERT INTO relationships (telegram_user_id, affection)
+VALUES ($1, $2)
+ON CONFLICT (telegram_user_id) DO UPDATE
+SET affection = relationships.affection + EXCLUDED.affection, updated = CURRENT_TIMESTAMP
+RETURNING affection;
+
+-- name: GetAffectionByTelegramUserId :one
+SELECT affection FROM relationships WHERE telegram_user_id = $1;
+
+-------------------- Gift Queries --------------------
+
+-- name: ListGifts :many
+SELECT * FROM gifts WHERE active ORDER BY price_credits;
+
+-- name: GetGift :one
+SELECT * FROM gifts WHERE id = $1 AND active;
+
+-- name: PurchaseGift :one
+-- Spends the price, bonus credits first, and records the purchase. No row
+-- comes back when the user can't afford it.
+WITH spent AS (
+  UPDATE user_credits
+  SET credits_balance = credits_balance - sqlc.arg(price_credits),
+      purchased_credits = LEAST(purchased_credits, credits_balance - sqlc.arg(price_credits)),
+      updated = CURRENT_TIMESTAMP
+  FROM user_info
+  WHERE user_credits.user_id = user_info.user_id AND user_info.telegram_user_id = sqlc.arg(telegram_user_id)
+    AND user_credits.credits_balance >= sqlc.arg(price_credits)
+  RETURNING user_credits.id
+)
+INSERT INTO gift_purchases (telegram_user_id, gift_id, credits)
+SELECT sqlc.arg(telegram_user_id), sqlc.arg(gift_id), sqlc.arg(price_credits) FROM spent
+RETURNING id;
+
+-------------------- Greeting Queries --------------------
+
+-- name: ListGreetingSubscribers :many
+SELECT ui.telegram_user_id, up.timezone, up.daily_greetings FROM user_info ui
+JOIN user_preferences up ON up.user_id = ui.user_id
+WHERE ui.banned = FALSE AND ui.age_verified_at IS NOT NULL AND up.daily_greetings <> '';
+
+-- name: ClaimDailyGreeting :execrows
+-- Zero rows means the greeting already went out that day
+INSERT INTO daily_greetings (telegram_user_id, kind, local_date)
+VALUES ($1, $2, $3)
+ON CONFLICT (telegram_user_id, kind, local_date) DO NOTHING;
+
+-------------------- Custom Persona Queries --------------------
+
+-- name: StartCustomPersona :exec
+-- Starts a fresh draft, replacing any earlier character
+INSERT INTO custom_personas (telegram_user_id) VALUES ($1)
+ON CONFLICT (telegram_user_id) DO UPDATE
+SET name = '', city = '', traits = '', language_mix = '', voice = '', completed_at = NULL, updated = CURRENT_TIMESTAMP;
+
+-- name: GetCustomPersonaByTelegramUserId :one
+SELECT * FROM custom_personas WHERE telegram_user_id = $1;
+
+-- name: SetCustomPersonaName :execrows
+UPDATE custom_personas SET name = $2, updated = CURRENT_TIMESTAMP
+WHERE telegram_user_id = $1 AND completed_at IS NULL;
+
+-- name: SetCustomPersonaCity :execrows
+UPDATE custom_personas SET city = $2, updated = CURRENT_TIMESTAMP
+WHERE telegram_user_id = $1 AND completed_at IS NULL;
+
+-- name: SetCustomPersonaTraits :execrows
+UPDATE custom_personas SET traits = $2, updated = CURRENT_TIMESTAMP
+WHERE telegram_user_id = $1 AND completed_at IS NULL;
+
+-- name: SetCustomPersonaLanguageMix :execrows
+UPDATE custom_personas SET language_mix = $2, updated = CURRENT_TIMESTAMP
+WHERE telegram_user_id = $1 AND completed_at IS NULL;
+
+-- name: CompleteCustomPersona :one
+UPDATE custom_personas SET voice = $2, completed_at = CURRENT_TIMESTAMP, updated = CURRENT_TIMESTAMP
+WHERE telegram_user_id = $1 AND completed_at IS NULL
+RETURNING *;
+
+-------------------- Usage Queries --------------------
+
+-- name: CreateUsageRecord :exec
+INSERT INTO usage_records (telegram_user_id, provider, kind, model, input_tokens, output_tokens, characters, cost_micros, tier)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9);
+
+-- name: GetUsageStatsSince :one
+SELECT
+  COUNT(*) AS requests,
+  COUNT(DISTINCT telegram_user_id) AS users,
+  COALESCE(SUM(cost_micros), 0)::bigint AS cost_micros
+FROM usage_records WHERE created >= sqlc.arg(since);
+
+-- name: ListUsageByTelegramUserIdSince :many
+-- One row per provider and kind, costliest first.
+SELECT
+  provider,
+  kind,
+  COUNT(*) AS requests,
+  COALESCE(SUM(input_tokens), 0)::bigint AS input_tokens,
+  COALESCE(SUM(output_tokens), 0)::bigint AS output_tokens,
+  COALESCE(SUM(characters), 0)::bigint AS characters,
+  COALESCE(SUM(cost_micros), 0)::bigint AS cost_micros
+FROM usage_records
+WHERE telegram_user_id = sqlc.arg(telegram_user_id) AND created >= sqlc.arg(since)
+GROUP BY provider, kind
+ORDER BY cost_micros DESC;
+
+-- name: ListTopUsersByUsageCostSince :many
+SELECT
+  telegram_user_id,
+  COUNT(*) AS requests,
+  COALESCE(SUM(cost_micros), 0)::bigint AS cost_micros
+FROM usage_records
+WHERE created >= sqlc.arg(since)
+GROUP BY telegram_user_id
+ORDER BY cost_micros DESC
+LIMIT sqlc.arg(row_limit);
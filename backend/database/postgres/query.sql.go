@@ -9,11 +9,68 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"time"
 )
 
+const addAffectionByTelegramUserId = `-- name: AddAffectionByTelegramUserId :one
+
+INSERT INTO relationships (telegram_user_id, affection)
+VALUES ($1, $2)
+ON CONFLICT (telegram_user_id) DO UPDATE
+SET affection = relationships.affection + EXCLUDED.affection, updated = CURRENT_TIMESTAMP
+RETURNING affection
+`
+
+type AddAffectionByTelegramUserIdParams struct {
+	TelegramUserID int64
+	Affection      int32
+}
+
+// ------------------ Relationship Queries --------------------
+func (q *Queries) AddAffectionByTelegramUserId(ctx context.Context, arg AddAffectionByTelegramUserIdParams) (int32, error) {
+	row := q.db.QueryRowContext(ctx, addAffectionByTelegramUserId, arg.TelegramUserID, arg.Affection)
+	var affection int32
+	err := row.Scan(&affection)
+	return affection, err
+}
+
+const addPurchasedCreditsByTelegramUserId = `-- name: AddPurchasedCreditsByTelegramUserId :one
+UPDATE user_credits
+SET credits_balance = credits_balance + $1,
+    purchased_credits = purchased_credits + $1,
+    updated = CURRENT_TIMESTAMP
+FROM user_info
+WHERE user_credits.user_id = user_info.user_id AND user_info.telegram_user_id = $2
+RETURNING user_credits.id, user_credits.user_id, user_credits.credits_balance, user_credits.last_daily_claim, user_credits.streak_days, user_credits.last_streak_date, user_credits.half_credit_owed, user_credits.purchased_credits, user_credits.last_bonus_grant, user_credits.created, user_credits.updated
+`
+
+type AddPurchasedCreditsByTelegramUserIdParams struct {
+	Amount         int32
+	TelegramUserID int64
+}
+
+func (q *Queries) AddPurchasedCreditsByTelegramUserId(ctx context.Context, arg AddPurchasedCreditsByTelegramUserIdParams) (UserCredit, error) {
+	row := q.db.QueryRowContext(ctx, addPurchasedCreditsByTelegramUserId, arg.Amount, arg.TelegramUserID)
+	var i UserCredit
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.CreditsBalance,
+		&i.LastDailyClaim,
+		&i.StreakDays,
+		&i.LastStreakDate,
+		&i.HalfCreditOwed,
+		&i.PurchasedCredits,
+		&i.LastBonusGrant,
+		&i.Created,
+		&i.Updated,
+	)
+	return i, err
+}
+
 const addUser = `-- name: AddUser :one
 
-INSERT INTO user_info (telegram_user_id, telegram_username, telegram_first_name, telegram_last_name) VALUES ($1, $2, $3, $4) RETURNING user_id, telegram_user_id, telegram_username, telegram_first_name, telegram_last_name, created
+INSERT INTO user_info (telegram_user_id, telegram_username, telegram_first_name, telegram_last_name, campaign) VALUES ($1, $2, $3, $4, $5) RETURNING user_id, telegram_user_id, telegram_username, telegram_first_name, telegram_last_name, banned, age_verified_at, campaign, created
 `
 
 type AddUserParams struct {
@@ -21,6 +78,7 @@ type AddUserParams struct {
 	TelegramUsername  sql.NullString
 	TelegramFirstName sql.NullString
 	TelegramLastName  sql.NullString
+	Campaign          sql.NullString
 }
 
 // ------------------ UserInfo Queries --------------------
@@ -30,6 +88,7 @@ func (q *Queries) AddUser(ctx context.Context, arg AddUserParams) (UserInfo, err
 		arg.TelegramUsername,
 		arg.TelegramFirstName,
 		arg.TelegramLastName,
+		arg.Campaign,
 	)
 	var i UserInfo
 	err := row.Scan(
@@ -38,6 +97,9 @@ func (q *Queries) AddUser(ctx context.Context, arg AddUserParams) (UserInfo, err
 		&i.TelegramUsername,
 		&i.TelegramFirstName,
 		&i.TelegramLastName,
+		&i.Banned,
+		&i.AgeVerifiedAt,
+		&i.Campaign,
 		&i.Created,
 	)
 	return i, err
@@ -45,10 +107,13 @@ func (q *Queries) AddUser(ctx context.Context, arg AddUserParams) (UserInfo, err
 
 const addUserCreditsByTelegramUserId = `-- name: AddUserCreditsByTelegramUserId :one
 UPDATE user_credits
-SET credits_balance = credits_balance + $1, updated = CURRENT_TIMESTAMP
+SET credits_balance = credits_balance + $1,
+    purchased_credits = LEAST(purchased_credits, credits_balance + $1),
+    last_bonus_grant = CASE WHEN $1 > 0 THEN CURRENT_TIMESTAMP ELSE last_bonus_grant END,
+    updated = CURRENT_TIMESTAMP
 FROM user_info
 WHERE user_credits.user_id = user_info.user_id AND user_info.telegram_user_id = $2
-RETURNING user_credits.id, user_credits.user_id, user_credits.credits_balance, user_credits.created, user_credits.updated
+RETURNING user_credits.id, user_credits.user_id, user_credits.credits_balance, user_credits.last_daily_claim, user_credits.streak_days, user_credits.last_streak_date, user_credits.half_credit_owed, user_credits.purchased_credits, user_credits.last_bonus_grant, user_credits.created, user_credits.updated
 `
 
 type AddUserCreditsByTelegramUserIdParams struct {
@@ -56,6 +121,7 @@ type AddUserCreditsByTelegramUserIdParams struct {
 	TelegramUserID int64
 }
 
+// Grants bonus credits. Negative amounts take bonus credits first.
 func (q *Queries) AddUserCreditsByTelegramUserId(ctx context.Context, arg AddUserCreditsByTelegramUserIdParams) (UserCredit, error) {
 	row := q.db.QueryRowContext(ctx, addUserCreditsByTelegramUserId, arg.Amount, arg.TelegramUserID)
 	var i UserCredit
@@ -63,185 +129,3278 @@ func (q *Queries) AddUserCreditsByTelegramUserId(ctx context.Context, arg AddUse
 		&i.ID,
 		&i.UserID,
 		&i.CreditsBalance,
+		&i.LastDailyClaim,
+		&i.StreakDays,
+		&i.LastStreakDate,
+		&i.HalfCreditOwed,
+		&i.PurchasedCredits,
+		&i.LastBonusGrant,
 		&i.Created,
 		&i.Updated,
 	)
 	return i, err
 }
 
-const clearConversationMessages = `-- name: ClearConversationMessages :one
+const appendConversationMessages = `-- name: AppendConversationMessages :exec
 UPDATE conversations
-SET messages = '[]'::jsonb, updated = CURRENT_TIMESTAMP
-WHERE telegram_user_id = $1
-RETURNING id, telegram_user_id, messages, created, updated
+SET messages = messages || $1::jsonb
+WHERE id = $2
 `
 
-func (q *Queries) ClearConversationMessages(ctx context.Context, telegramUserID int64) (Conversation, error) {
-	row := q.db.QueryRowContext(ctx, clearConversationMessages, telegramUserID)
-	var i Conversation
+type AppendConversationMessagesParams struct {
+	Messages json.RawMessage
+	ID       int64
+}
+
+// Appends without bumping updated, which tracks the user's last exchange
+func (q *Queries) AppendConversationMessages(ctx context.Context, arg AppendConversationMessagesParams) error {
+	_, err := q.db.ExecContext(ctx, appendConversationMessages, arg.Messages, arg.ID)
+	return err
+}
+
+const archiveConversation = `-- name: ArchiveConversation :execrows
+WITH archived AS (
+  INSERT INTO conversation_archives (conversation_id, telegram_user_id, persona, messages)
+  SELECT id, telegram_user_id, persona, messages FROM conversations
+  WHERE id = $1 AND messages <> '[]'::jsonb
+  RETURNING conversation_id
+)
+UPDATE conversations
+SET messages = '[]'::jsonb, summary = '', summarized_messages = 0, updated = CURRENT_TIMESTAMP
+WHERE id IN (SELECT conversation_id FROM archived)
+`
+
+// Moves the history into conversation_archives and empties the conversation.
+// An empty history isn't archived.
+func (q *Queries) ArchiveConversation(ctx context.Context, id int64) (int64, error) {
+	result, err := q.db.ExecContext(ctx, archiveConversation, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const cancelSubscriptionByTelegramUserId = `-- name: CancelSubscriptionByTelegramUserId :one
+UPDATE subscriptions
+SET status = 'canceled', updated = CURRENT_TIMESTAMP
+FROM user_info
+WHERE subscriptions.user_id = user_info.user_id AND user_info.telegram_user_id = $1
+RETURNING subscriptions.id, subscriptions.user_id, subscriptions.status, subscriptions.telegram_payment_charge_id, subscriptions.expires_at, subscriptions.created, subscriptions.updated
+`
+
+func (q *Queries) CancelSubscriptionByTelegramUserId(ctx context.Context, telegramUserID int64) (Subscription, error) {
+	row := q.db.QueryRowContext(ctx, cancelSubscriptionByTelegramUserId, telegramUserID)
+	var i Subscription
 	err := row.Scan(
 		&i.ID,
-		&i.TelegramUserID,
-		&i.Messages,
+		&i.UserID,
+		&i.Status,
+		&i.TelegramPaymentChargeID,
+		&i.ExpiresAt,
 		&i.Created,
 		&i.Updated,
 	)
 	return i, err
 }
 
-const createConversation = `-- name: CreateConversation :one
+const chargeHalfCreditByTelegramUserId = `-- name: ChargeHalfCreditByTelegramUserId :one
+UPDATE user_credits
+SET credits_balance = credits_balance - CASE WHEN half_credit_owed THEN 1 ELSE 0 END,
+    purchased_credits = LEAST(purchased_credits, credits_balance - CASE WHEN half_credit_owed THEN 1 ELSE 0 END),
+    half_credit_owed = NOT half_credit_owed, updated = CURRENT_TIMESTAMP
+FROM user_info
+WHERE user_credits.user_id = user_info.user_id AND user_info.telegram_user_id = $1 AND user_credits.credits_balance > 0
+RETURNING user_credits.id, user_credits.user_id, user_credits.credits_balance, user_credits.last_daily_claim, user_credits.streak_days, user_credits.last_streak_date, user_credits.half_credit_owed, user_credits.purchased_credits, user_credits.last_bonus_grant, user_credits.created, user_credits.updated
+`
+
+// Every second call takes a whole credit.
+func (q *Queries) ChargeHalfCreditByTelegramUserId(ctx context.Context, telegramUserID int64) (UserCredit, error) {
+	row := q.db.QueryRowContext(ctx, chargeHalfCreditByTelegramUserId, telegramUserID)
+	var i UserCredit
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.CreditsBalance,
+		&i.LastDailyClaim,
+		&i.StreakDays,
+		&i.LastStreakDate,
+		&i.HalfCreditOwed,
+		&i.PurchasedCredits,
+		&i.LastBonusGrant,
+		&i.Created,
+		&i.Updated,
+	)
+	return i, err
+}
+
+const claimDailyCreditsByTelegramUserId = `-- name: ClaimDailyCreditsByTelegramUserId :one
+UPDATE user_credits
+SET credits_balance = credits_balance + $1, last_daily_claim = CURRENT_TIMESTAMP, last_bonus_grant = CURRENT_TIMESTAMP, updated = CURRENT_TIMESTAMP
+FROM user_info
+WHERE user_credits.user_id = user_info.user_id AND user_info.telegram_user_id = $2
+  AND (user_credits.last_daily_claim IS NULL OR user_credits.last_daily_claim <= CURRENT_TIMESTAMP - INTERVAL '24 hours')
+RETURNING user_credits.id, user_credits.user_id, user_credits.credits_balance, user_credits.last_daily_claim, user_credits.streak_days, user_credits.last_streak_date, user_credits.half_credit_owed, user_credits.purchased_credits, user_credits.last_bonus_grant, user_credits.created, user_credits.updated
+`
+
+type ClaimDailyCreditsByTelegramUserIdParams struct {
+	Amount         int32
+	TelegramUserID int64
+}
+
+func (q *Queries) ClaimDailyCreditsByTelegramUserId(ctx context.Context, arg ClaimDailyCreditsByTelegramUserIdParams) (UserCredit, error) {
+	row := q.db.QueryRowContext(ctx, claimDailyCreditsByTelegramUserId, arg.Amount, arg.TelegramUserID)
+	var i UserCredit
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.CreditsBalance,
+		&i.LastDailyClaim,
+		&i.StreakDays,
+		&i.LastStreakDate,
+		&i.HalfCreditOwed,
+		&i.PurchasedCredits,
+		&i.LastBonusGrant,
+		&i.Created,
+		&i.Updated,
+	)
+	return i, err
+}
 
-INSERT INTO conversations (telegram_user_id, messages)
-VALUES ($1, '[]'::jsonb) RETURNING id, telegram_user_id, messages, created, updated
+const claimDailyGreeting = `-- name: ClaimDailyGreeting :execrows
+INSERT INTO daily_greetings (telegram_user_id, kind, local_date)
+VALUES ($1, $2, $3)
+ON CONFLICT (telegram_user_id, kind, local_date) DO NOTHING
 `
 
-// ------------------ Conversation Queries --------------------
-func (q *Queries) CreateConversation(ctx context.Context, telegramUserID int64) (Conversation, error) {
-	row := q.db.QueryRowContext(ctx, createConversation, telegramUserID)
+type ClaimDailyGreetingParams struct {
+	TelegramUserID int64
+	Kind           string
+	LocalDate      time.Time
+}
+
+// Zero rows means the greeting already went out that day
+func (q *Queries) ClaimDailyGreeting(ctx context.Context, arg ClaimDailyGreetingParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, claimDailyGreeting, arg.TelegramUserID, arg.Kind, arg.LocalDate)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const clearConversationMessages = `-- name: ClearConversationMessages :one
+WITH forgotten AS (
+  DELETE FROM recall_moments WHERE conversation_id = $1
+)
+UPDATE conversations
+SET messages = '[]'::jsonb, summary = '', summarized_messages = 0, updated = CURRENT_TIMESTAMP
+WHERE id = $1
+RETURNING id, telegram_user_id, persona, tts_voice, messages, summary, summarized_messages, created, updated
+`
+
+// Forgets the recalled moments along with the history.
+func (q *Queries) ClearConversationMessages(ctx context.Context, id int64) (Conversation, error) {
+	row := q.db.QueryRowContext(ctx, clearConversationMessages, id)
 	var i Conversation
 	err := row.Scan(
 		&i.ID,
 		&i.TelegramUserID,
+		&i.Persona,
+		&i.TtsVoice,
 		&i.Messages,
+		&i.Summary,
+		&i.SummarizedMessages,
 		&i.Created,
 		&i.Updated,
 	)
 	return i, err
 }
 
-const createUserCredits = `-- name: CreateUserCredits :one
+const completeBroadcast = `-- name: CompleteBroadcast :exec
+UPDATE broadcasts SET completed = CURRENT_TIMESTAMP WHERE id = $1
+`
 
-INSERT INTO user_credits (user_id, credits_balance) VALUES ($1, 10) RETURNING id, user_id, credits_balance, created, updated
+func (q *Queries) CompleteBroadcast(ctx context.Context, id int64) error {
+	_, err := q.db.ExecContext(ctx, completeBroadcast, id)
+	return err
+}
+
+const completeCustomPersona = `-- name: CompleteCustomPersona :one
+UPDATE custom_personas SET voice = $2, completed_at = CURRENT_TIMESTAMP, updated = CURRENT_TIMESTAMP
+WHERE telegram_user_id = $1 AND completed_at IS NULL
+RETURNING telegram_user_id, name, city, traits, language_mix, voice, completed_at, created, updated
 `
 
-// ------------------ User Credits Queries --------------------
-func (q *Queries) CreateUserCredits(ctx context.Context, userID int64) (UserCredit, error) {
-	row := q.db.QueryRowContext(ctx, createUserCredits, userID)
-	var i UserCredit
+type CompleteCustomPersonaParams struct {
+	TelegramUserID int64
+	Voice          string
+}
+
+func (q *Queries) CompleteCustomPersona(ctx context.Context, arg CompleteCustomPersonaParams) (CustomPersona, error) {
+	row := q.db.QueryRowContext(ctx, completeCustomPersona, arg.TelegramUserID, arg.Voice)
+	var i CustomPersona
 	err := row.Scan(
-		&i.ID,
-		&i.UserID,
-		&i.CreditsBalance,
+		&i.TelegramUserID,
+		&i.Name,
+		&i.City,
+		&i.Traits,
+		&i.LanguageMix,
+		&i.Voice,
+		&i.CompletedAt,
 		&i.Created,
 		&i.Updated,
 	)
 	return i, err
 }
 
-const decrementUserCreditsByTelegramUserId = `-- name: DecrementUserCreditsByTelegramUserId :one
-UPDATE user_credits
-SET credits_balance = credits_balance - 1, updated = CURRENT_TIMESTAMP
-FROM user_info
-WHERE user_credits.user_id = user_info.user_id AND user_info.telegram_user_id = $1 AND user_credits.credits_balance > 0
-RETURNING user_credits.id, user_credits.user_id, user_credits.credits_balance, user_credits.created, user_credits.updated
+const completeOnboardingByTelegramUserId = `-- name: CompleteOnboardingByTelegramUserId :one
+INSERT INTO user_preferences (user_id, onboarded_at)
+SELECT user_id, CURRENT_TIMESTAMP FROM user_info WHERE telegram_user_id = $1
+ON CONFLICT (user_id) DO UPDATE
+SET onboarded_at = EXCLUDED.onboarded_at, updated = CURRENT_TIMESTAMP
+RETURNING id, user_id, broadcast_opt_out, reengage_opt_out, dnd_start, dnd_end, timezone, text_replies, reply_language, active_persona, preferred_name, vibe, onboarded_at, last_voice_file_ids, voice_captions, transcript_echo, auto_recharge, auto_recharge_limit, daily_greetings, content_intensity, speech_rate, speech_pitch, spoken_language, created, updated
 `
 
-func (q *Queries) DecrementUserCreditsByTelegramUserId(ctx context.Context, telegramUserID int64) (UserCredit, error) {
-	row := q.db.QueryRowContext(ctx, decrementUserCreditsByTelegramUserId, telegramUserID)
-	var i UserCredit
+func (q *Queries) CompleteOnboardingByTelegramUserId(ctx context.Context, telegramUserID int64) (UserPreference, error) {
+	row := q.db.QueryRowContext(ctx, completeOnboardingByTelegramUserId, telegramUserID)
+	var i UserPreference
 	err := row.Scan(
 		&i.ID,
 		&i.UserID,
-		&i.CreditsBalance,
+		&i.BroadcastOptOut,
+		&i.ReengageOptOut,
+		&i.DndStart,
+		&i.DndEnd,
+		&i.Timezone,
+		&i.TextReplies,
+		&i.ReplyLanguage,
+		&i.ActivePersona,
+		&i.PreferredName,
+		&i.Vibe,
+		&i.OnboardedAt,
+		&i.LastVoiceFileIds,
+		&i.VoiceCaptions,
+		&i.TranscriptEcho,
+		&i.AutoRecharge,
+		&i.AutoRechargeLimit,
+		&i.DailyGreetings,
+		&i.ContentIntensity,
+		&i.SpeechRate,
+		&i.SpeechPitch,
+		&i.SpokenLanguage,
 		&i.Created,
 		&i.Updated,
 	)
 	return i, err
 }
 
-const deleteUserByTelegramUserId = `-- name: DeleteUserByTelegramUserId :exec
-DELETE FROM user_info WHERE telegram_user_id = $1
+const countAutoRechargesSince = `-- name: CountAutoRechargesSince :one
+SELECT COUNT(*) FROM auto_recharges
+WHERE telegram_user_id = $1 AND status <> 'failed' AND created >= $2
 `
 
-func (q *Queries) DeleteUserByTelegramUserId(ctx context.Context, telegramUserID int64) error {
-	_, err := q.db.ExecContext(ctx, deleteUserByTelegramUserId, telegramUserID)
+type CountAutoRechargesSinceParams struct {
+	TelegramUserID int64
+	Since          time.Time
+}
+
+// Failed attempts don't count towards the cap
+func (q *Queries) CountAutoRechargesSince(ctx context.Context, arg CountAutoRechargesSinceParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countAutoRechargesSince, arg.TelegramUserID, arg.Since)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countReferralsByTelegramUserId = `-- name: CountReferralsByTelegramUserId :one
+SELECT COUNT(*) FROM referrals r JOIN user_info ui ON r.referrer_user_id = ui.user_id WHERE ui.telegram_user_id = $1
+`
+
+func (q *Queries) CountReferralsByTelegramUserId(ctx context.Context, telegramUserID int64) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countReferralsByTelegramUserId, telegramUserID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countUsers = `-- name: CountUsers :one
+SELECT COUNT(*) FROM user_info
+`
+
+func (q *Queries) CountUsers(ctx context.Context) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countUsers)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const createAbuseFlag = `-- name: CreateAbuseFlag :exec
+
+INSERT INTO abuse_flags (user_id, reason, message, muted_until)
+SELECT user_id, $1, $2, $3 FROM user_info WHERE telegram_user_id = $4
+`
+
+type CreateAbuseFlagParams struct {
+	Reason         string
+	Message        string
+	MutedUntil     time.Time
+	TelegramUserID int64
+}
+
+// ------------------ Abuse Queries --------------------
+func (q *Queries) CreateAbuseFlag(ctx context.Context, arg CreateAbuseFlagParams) error {
+	_, err := q.db.ExecContext(ctx, createAbuseFlag,
+		arg.Reason,
+		arg.Message,
+		arg.MutedUntil,
+		arg.TelegramUserID,
+	)
 	return err
 }
 
-const getConversationByTelegramUserId = `-- name: GetConversationByTelegramUserId :one
-SELECT id, telegram_user_id, messages, created, updated FROM conversations WHERE telegram_user_id = $1 LIMIT 1
+const createAutoRecharge = `-- name: CreateAutoRecharge :exec
+INSERT INTO auto_recharges (telegram_user_id, payload, method, status) VALUES ($1, $2, $3, $4)
+`
+
+type CreateAutoRechargeParams struct {
+	TelegramUserID int64
+	Payload        string
+	Method         string
+	Status         string
+}
+
+func (q *Queries) CreateAutoRecharge(ctx context.Context, arg CreateAutoRechargeParams) error {
+	_, err := q.db.ExecContext(ctx, createAutoRecharge,
+		arg.TelegramUserID,
+		arg.Payload,
+		arg.Method,
+		arg.Status,
+	)
+	return err
+}
+
+const createBroadcast = `-- name: CreateBroadcast :one
+
+INSERT INTO broadcasts (admin_telegram_user_id, text, voice_file_id) VALUES ($1, $2, $3) RETURNING id, admin_telegram_user_id, text, voice_file_id, created, completed
+`
+
+type CreateBroadcastParams struct {
+	AdminTelegramUserID int64
+	Text                string
+	VoiceFileID         sql.NullString
+}
+
+// ------------------ Broadcast Queries --------------------
+func (q *Queries) CreateBroadcast(ctx context.Context, arg CreateBroadcastParams) (Broadcast, error) {
+	row := q.db.QueryRowContext(ctx, createBroadcast, arg.AdminTelegramUserID, arg.Text, arg.VoiceFileID)
+	var i Broadcast
+	err := row.Scan(
+		&i.ID,
+		&i.AdminTelegramUserID,
+		&i.Text,
+		&i.VoiceFileID,
+		&i.Created,
+		&i.Completed,
+	)
+	return i, err
+}
+
+const createBroadcastDelivery = `-- name: CreateBroadcastDelivery :exec
+INSERT INTO broadcast_deliveries (broadcast_id, telegram_user_id, status, error) VALUES ($1, $2, $3, $4)
+`
+
+type CreateBroadcastDeliveryParams struct {
+	BroadcastID    int64
+	TelegramUserID int64
+	Status         string
+	Error          sql.NullString
+}
+
+func (q *Queries) CreateBroadcastDelivery(ctx context.Context, arg CreateBroadcastDeliveryParams) error {
+	_, err := q.db.ExecContext(ctx, createBroadcastDelivery,
+		arg.BroadcastID,
+		arg.TelegramUserID,
+		arg.Status,
+		arg.Error,
+	)
+	return err
+}
+
+const createConversation = `-- name: CreateConversation :one
+
+INSERT INTO conversations (telegram_user_id, persona, messages)
+VALUES ($1, $2, '[]'::jsonb) RETURNING id, telegram_user_id, persona, tts_voice, messages, summary, summarized_messages, created, updated
 `
 
-func (q *Queries) GetConversationByTelegramUserId(ctx context.Context, telegramUserID int64) (Conversation, error) {
-	row := q.db.QueryRowContext(ctx, getConversationByTelegramUserId, telegramUserID)
+type CreateConversationParams struct {
+	TelegramUserID int64
+	Persona        string
+}
+
+// ------------------ Conversation Queries --------------------
+func (q *Queries) CreateConversation(ctx context.Context, arg CreateConversationParams) (Conversation, error) {
+	row := q.db.QueryRowContext(ctx, createConversation, arg.TelegramUserID, arg.Persona)
 	var i Conversation
 	err := row.Scan(
 		&i.ID,
 		&i.TelegramUserID,
+		&i.Persona,
+		&i.TtsVoice,
 		&i.Messages,
+		&i.Summary,
+		&i.SummarizedMessages,
 		&i.Created,
 		&i.Updated,
 	)
 	return i, err
 }
 
-const getUserByTelegramUserId = `-- name: GetUserByTelegramUserId :one
-SELECT user_id, telegram_user_id, telegram_username, telegram_first_name, telegram_last_name, created FROM user_info WHERE telegram_user_id = $1 LIMIT 1
+const createFeedback = `-- name: CreateFeedback :one
+
+INSERT INTO feedback (user_id, conversation_id, rating, text, recent_messages)
+SELECT user_id, $1, $2, $3, $4
+FROM user_info WHERE telegram_user_id = $5
+RETURNING id, user_id, conversation_id, rating, text, recent_messages, created
 `
 
-func (q *Queries) GetUserByTelegramUserId(ctx context.Context, telegramUserID int64) (UserInfo, error) {
-	row := q.db.QueryRowContext(ctx, getUserByTelegramUserId, telegramUserID)
-	var i UserInfo
+type CreateFeedbackParams struct {
+	ConversationID sql.NullInt64
+	Rating         sql.NullInt32
+	Text           string
+	RecentMessages json.RawMessage
+	TelegramUserID int64
+}
+
+// ------------------ Feedback Queries --------------------
+func (q *Queries) CreateFeedback(ctx context.Context, arg CreateFeedbackParams) (Feedback, error) {
+	row := q.db.QueryRowContext(ctx, createFeedback,
+		arg.ConversationID,
+		arg.Rating,
+		arg.Text,
+		arg.RecentMessages,
+		arg.TelegramUserID,
+	)
+	var i Feedback
 	err := row.Scan(
+		&i.ID,
 		&i.UserID,
-		&i.TelegramUserID,
-		&i.TelegramUsername,
-		&i.TelegramFirstName,
-		&i.TelegramLastName,
+		&i.ConversationID,
+		&i.Rating,
+		&i.Text,
+		&i.RecentMessages,
 		&i.Created,
 	)
 	return i, err
 }
 
-const getUserCreditsByTelegramUserId = `-- name: GetUserCreditsByTelegramUserId :one
-SELECT uc.credits_balance FROM user_credits uc JOIN user_info ui ON uc.user_id = ui.user_id WHERE ui.telegram_user_id = $1
+const createMemory = `-- name: CreateMemory :one
+
+INSERT INTO memories (user_id, fact)
+SELECT user_id, $1 FROM user_info WHERE telegram_user_id = $2
+RETURNING id, user_id, fact, created, updated
 `
 
-func (q *Queries) GetUserCreditsByTelegramUserId(ctx context.Context, telegramUserID int64) (int32, error) {
-	row := q.db.QueryRowContext(ctx, getUserCreditsByTelegramUserId, telegramUserID)
-	var credits_balance int32
-	err := row.Scan(&credits_balance)
-	return credits_balance, err
+type CreateMemoryParams struct {
+	Fact           string
+	TelegramUserID int64
 }
 
-const getUserCreditsByUserID = `-- name: GetUserCreditsByUserID :one
-SELECT id, user_id, credits_balance, created, updated FROM user_credits WHERE user_id = $1 LIMIT 1
+// ------------------ Memory Queries --------------------
+func (q *Queries) CreateMemory(ctx context.Context, arg CreateMemoryParams) (Memory, error) {
+	row := q.db.QueryRowContext(ctx, createMemory, arg.Fact, arg.TelegramUserID)
+	var i Memory
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Fact,
+		&i.Created,
+		&i.Updated,
+	)
+	return i, err
+}
+
+const createPaidMediaPurchase = `-- name: CreatePaidMediaPurchase :execrows
+INSERT INTO paid_media_purchases (transaction_id, telegram_user_id, premium_media_id, stars, purchased)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (transaction_id) DO NOTHING
 `
 
-func (q *Queries) GetUserCreditsByUserID(ctx context.Context, userID int64) (UserCredit, error) {
-	row := q.db.QueryRowContext(ctx, getUserCreditsByUserID, userID)
-	var i UserCredit
+type CreatePaidMediaPurchaseParams struct {
+	TransactionID  string
+	TelegramUserID int64
+	PremiumMediaID sql.NullInt64
+	Stars          int32
+	Purchased      time.Time
+}
+
+func (q *Queries) CreatePaidMediaPurchase(ctx context.Context, arg CreatePaidMediaPurchaseParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, createPaidMediaPurchase,
+		arg.TransactionID,
+		arg.TelegramUserID,
+		arg.PremiumMediaID,
+		arg.Stars,
+		arg.Purchased,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const createPayment = `-- name: CreatePayment :one
+
+INSERT INTO payments (user_id, provider, payload, credits, amount, currency, charge_id)
+SELECT user_id, $1, $2, $3, $4, $5, $6
+FROM user_info WHERE telegram_user_id = $7
+ON CONFLICT (charge_id) DO NOTHING
+RETURNING id, user_id, provider, payload, credits, amount, currency, charge_id, created
+`
+
+type CreatePaymentParams struct {
+	Provider       string
+	Payload        string
+	Credits        int32
+	Amount         int32
+	Currency       string
+	ChargeID       sql.NullString
+	TelegramUserID int64
+}
+
+// ------------------ Stats Queries --------------------
+// Returns no rows if the charge was already recorded.
+func (q *Queries) CreatePayment(ctx context.Context, arg CreatePaymentParams) (Payment, error) {
+	row := q.db.QueryRowContext(ctx, createPayment,
+		arg.Provider,
+		arg.Payload,
+		arg.Credits,
+		arg.Amount,
+		arg.Currency,
+		arg.ChargeID,
+		arg.TelegramUserID,
+	)
+	var i Payment
 	err := row.Scan(
 		&i.ID,
 		&i.UserID,
-		&i.CreditsBalance,
+		&i.Provider,
+		&i.Payload,
+		&i.Credits,
+		&i.Amount,
+		&i.Currency,
+		&i.ChargeID,
 		&i.Created,
-		&i.Updated,
 	)
 	return i, err
 }
 
-const updateConversationMessages = `-- name: UpdateConversationMessages :one
-UPDATE conversations 
-SET messages = $2, updated = CURRENT_TIMESTAMP 
-WHERE telegram_user_id = $1 
-RETURNING id, telegram_user_id, messages, created, updated
+const createPracticeSession = `-- name: CreatePracticeSession :one
+
+INSERT INTO practice_sessions (user_id, scenario)
+SELECT user_id, $1 FROM user_info WHERE telegram_user_id = $2
+RETURNING id, user_id, scenario, messages, ended, created, updated
 `
 
-type UpdateConversationMessagesParams struct {
+type CreatePracticeSessionParams struct {
+	Scenario       json.RawMessage
 	TelegramUserID int64
-	Messages       json.RawMessage
 }
 
-func (q *Queries) UpdateConversationMessages(ctx context.Context, arg UpdateConversationMessagesParams) (Conversation, error) {
-	row := q.db.QueryRowContext(ctx, updateConversationMessages, arg.TelegramUserID, arg.Messages)
-	var i Conversation
+// ------------------ Practice Queries --------------------
+func (q *Queries) CreatePracticeSession(ctx context.Context, arg CreatePracticeSessionParams) (PracticeSession, error) {
+	row := q.db.QueryRowContext(ctx, createPracticeSession, arg.Scenario, arg.TelegramUserID)
+	var i PracticeSession
 	err := row.Scan(
 		&i.ID,
-		&i.TelegramUserID,
+		&i.UserID,
+		&i.Scenario,
 		&i.Messages,
+		&i.Ended,
 		&i.Created,
 		&i.Updated,
 	)
 	return i, err
 }
+
+const createPremiumMedia = `-- name: CreatePremiumMedia :one
+
+INSERT INTO premium_media (kind, file_id, caption, price_stars, admin_telegram_user_id)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING id, kind, file_id, caption, price_stars, admin_telegram_user_id, created
+`
+
+type CreatePremiumMediaParams struct {
+	Kind                string
+	FileID              string
+	Caption             string
+	PriceStars          int32
+	AdminTelegramUserID int64
+}
+
+// ------------------ Paid Media Queries --------------------
+func (q *Queries) CreatePremiumMedia(ctx context.Context, arg CreatePremiumMediaParams) (PremiumMedium, error) {
+	row := q.db.QueryRowContext(ctx, createPremiumMedia,
+		arg.Kind,
+		arg.FileID,
+		arg.Caption,
+		arg.PriceStars,
+		arg.AdminTelegramUserID,
+	)
+	var i PremiumMedium
+	err := row.Scan(
+		&i.ID,
+		&i.Kind,
+		&i.FileID,
+		&i.Caption,
+		&i.PriceStars,
+		&i.AdminTelegramUserID,
+		&i.Created,
+	)
+	return i, err
+}
+
+const createPromoCode = `-- name: CreatePromoCode :one
+
+INSERT INTO promo_codes (code, credits, max_uses, expires, admin_telegram_user_id) VALUES ($1, $2, $3, $4, $5) RETURNING id, code, credits, max_uses, uses, expires, admin_telegram_user_id, created
+`
+
+type CreatePromoCodeParams struct {
+	Code                string
+	Credits             int32
+	MaxUses             sql.NullInt32
+	Expires             sql.NullTime
+	AdminTelegramUserID int64
+}
+
+// ------------------ Promo Code Queries --------------------
+func (q *Queries) CreatePromoCode(ctx context.Context, arg CreatePromoCodeParams) (PromoCode, error) {
+	row := q.db.QueryRowContext(ctx, createPromoCode,
+		arg.Code,
+		arg.Credits,
+		arg.MaxUses,
+		arg.Expires,
+		arg.AdminTelegramUserID,
+	)
+	var i PromoCode
+	err := row.Scan(
+		&i.ID,
+		&i.Code,
+		&i.Credits,
+		&i.MaxUses,
+		&i.Uses,
+		&i.Expires,
+		&i.AdminTelegramUserID,
+		&i.Created,
+	)
+	return i, err
+}
+
+const createRecallMoment = `-- name: CreateRecallMoment :exec
+
+INSERT INTO recall_moments (conversation_id, content, model, embedding, said)
+VALUES ($1, $2, $3, $4::text::vector, $5)
+`
+
+type CreateRecallMomentParams struct {
+	ConversationID int64
+	Content        string
+	Model          string
+	Embedding      string
+	Said           sql.NullTime
+}
+
+// ------------------ Recall Queries --------------------
+// Embeddings are passed as pgvector's text form, like [0.1,0.2]
+func (q *Queries) CreateRecallMoment(ctx context.Context, arg CreateRecallMomentParams) error {
+	_, err := q.db.ExecContext(ctx, createRecallMoment,
+		arg.ConversationID,
+		arg.Content,
+		arg.Model,
+		arg.Embedding,
+		arg.Said,
+	)
+	return err
+}
+
+const createReengagement = `-- name: CreateReengagement :exec
+INSERT INTO reengagements (user_id) SELECT user_id FROM user_info WHERE telegram_user_id = $1
+`
+
+func (q *Queries) CreateReengagement(ctx context.Context, telegramUserID int64) error {
+	_, err := q.db.ExecContext(ctx, createReengagement, telegramUserID)
+	return err
+}
+
+const createReferral = `-- name: CreateReferral :one
+INSERT INTO referrals (referrer_user_id, referred_user_id) VALUES ($1, $2) RETURNING id, referrer_user_id, referred_user_id, created
+`
+
+type CreateReferralParams struct {
+	ReferrerUserID int64
+	ReferredUserID int64
+}
+
+func (q *Queries) CreateReferral(ctx context.Context, arg CreateReferralParams) (Referral, error) {
+	row := q.db.QueryRowContext(ctx, createReferral, arg.ReferrerUserID, arg.ReferredUserID)
+	var i Referral
+	err := row.Scan(
+		&i.ID,
+		&i.ReferrerUserID,
+		&i.ReferredUserID,
+		&i.Created,
+	)
+	return i, err
+}
+
+const createReferralCode = `-- name: CreateReferralCode :one
+
+INSERT INTO referral_codes (user_id, code) VALUES ($1, $2) RETURNING id, user_id, code, created
+`
+
+type CreateReferralCodeParams struct {
+	UserID int64
+	Code   string
+}
+
+// ------------------ Referral Queries --------------------
+func (q *Queries) CreateReferralCode(ctx context.Context, arg CreateReferralCodeParams) (ReferralCode, error) {
+	row := q.db.QueryRowContext(ctx, createReferralCode, arg.UserID, arg.Code)
+	var i ReferralCode
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Code,
+		&i.Created,
+	)
+	return i, err
+}
+
+const createResponse = `-- name: CreateResponse :exec
+INSERT INTO responses (telegram_user_id, message_type, latency_ms, tts_failed) VALUES ($1, $2, $3, $4)
+`
+
+type CreateResponseParams struct {
+	TelegramUserID int64
+	MessageType    string
+	LatencyMs      int32
+	TtsFailed      bool
+}
+
+func (q *Queries) CreateResponse(ctx context.Context, arg CreateResponseParams) error {
+	_, err := q.db.ExecContext(ctx, createResponse,
+		arg.TelegramUserID,
+		arg.MessageType,
+		arg.LatencyMs,
+		arg.TtsFailed,
+	)
+	return err
+}
+
+const createStarsLedgerEntry = `-- name: CreateStarsLedgerEntry :exec
+
+INSERT INTO stars_ledger (telegram_user_id, event, invoice_payload, telegram_payment_charge_id, amount, credits)
+VALUES ($1, $2, $3, $4, $5, $6)
+`
+
+type CreateStarsLedgerEntryParams struct {
+	TelegramUserID          int64
+	Event                   string
+	InvoicePayload          string
+	TelegramPaymentChargeID string
+	Amount                  int32
+	Credits                 int32
+}
+
+// ------------------ Stars Ledger Queries --------------------
+func (q *Queries) CreateStarsLedgerEntry(ctx context.Context, arg CreateStarsLedgerEntryParams) error {
+	_, err := q.db.ExecContext(ctx, createStarsLedgerEntry,
+		arg.TelegramUserID,
+		arg.Event,
+		arg.InvoicePayload,
+		arg.TelegramPaymentChargeID,
+		arg.Amount,
+		arg.Credits,
+	)
+	return err
+}
+
+const createUsageRecord = `-- name: CreateUsageRecord :exec
+INSERT INTO usage_records (telegram_user_id, provider, kind, model, input_tokens, output_tokens, characters, cost_micros, tier)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+`
+
+type CreateUsageRecordParams struct {
+	TelegramUserID int64
+	Provider       string
+	Kind           string
+	Model          string
+	InputTokens    int32
+	OutputTokens   int32
+	Characters     int32
+	CostMicros     int64
+	Tier           string
+}
+
+func (q *Queries) CreateUsageRecord(ctx context.Context, arg CreateUsageRecordParams) error {
+	_, err := q.db.ExecContext(ctx, createUsageRecord,
+		arg.TelegramUserID,
+		arg.Provider,
+		arg.Kind,
+		arg.Model,
+		arg.InputTokens,
+		arg.OutputTokens,
+		arg.Characters,
+		arg.CostMicros,
+		arg.Tier,
+	)
+	return err
+}
+
+const createUserCredits = `-- name: CreateUserCredits :one
+
+INSERT INTO user_credits (user_id, credits_balance) VALUES ($1, 10) RETURNING id, user_id, credits_balance, last_daily_claim, streak_days, last_streak_date, half_credit_owed, purchased_credits, last_bonus_grant, created, updated
+`
+
+// ------------------ User Credits Queries --------------------
+func (q *Queries) CreateUserCredits(ctx context.Context, userID int64) (UserCredit, error) {
+	row := q.db.QueryRowContext(ctx, createUserCredits, userID)
+	var i UserCredit
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.CreditsBalance,
+		&i.LastDailyClaim,
+		&i.StreakDays,
+		&i.LastStreakDate,
+		&i.HalfCreditOwed,
+		&i.PurchasedCredits,
+		&i.LastBonusGrant,
+		&i.Created,
+		&i.Updated,
+	)
+	return i, err
+}
+
+const decrementUserCreditsByTelegramUserId = `-- name: DecrementUserCreditsByTelegramUserId :one
+UPDATE user_credits
+SET credits_balance = credits_balance - 1,
+    purchased_credits = LEAST(purchased_credits, credits_balance - 1),
+    updated = CURRENT_TIMESTAMP
+FROM user_info
+WHERE user_credits.user_id = user_info.user_id AND user_info.telegram_user_id = $1 AND user_credits.credits_balance > 0
+RETURNING user_credits.id, user_credits.user_id, user_credits.credits_balance, user_credits.last_daily_claim, user_credits.streak_days, user_credits.last_streak_date, user_credits.half_credit_owed, user_credits.purchased_credits, user_credits.last_bonus_grant, user_credits.created, user_credits.updated
+`
+
+func (q *Queries) DecrementUserCreditsByTelegramUserId(ctx context.Context, telegramUserID int64) (UserCredit, error) {
+	row := q.db.QueryRowContext(ctx, decrementUserCreditsByTelegramUserId, telegramUserID)
+	var i UserCredit
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.CreditsBalance,
+		&i.LastDailyClaim,
+		&i.StreakDays,
+		&i.LastStreakDate,
+		&i.HalfCreditOwed,
+		&i.PurchasedCredits,
+		&i.LastBonusGrant,
+		&i.Created,
+		&i.Updated,
+	)
+	return i, err
+}
+
+const deleteConversationArchives = `-- name: DeleteConversationArchives :exec
+DELETE FROM conversation_archives WHERE conversation_id = $1
+`
+
+func (q *Queries) DeleteConversationArchives(ctx context.Context, conversationID int64) error {
+	_, err := q.db.ExecContext(ctx, deleteConversationArchives, conversationID)
+	return err
+}
+
+const deleteMemory = `-- name: DeleteMemory :exec
+DELETE FROM memories
+WHERE id = $1 AND user_id = (SELECT user_id FROM user_info WHERE telegram_user_id = $2)
+`
+
+type DeleteMemoryParams struct {
+	ID             int64
+	TelegramUserID int64
+}
+
+func (q *Queries) DeleteMemory(ctx context.Context, arg DeleteMemoryParams) error {
+	_, err := q.db.ExecContext(ctx, deleteMemory, arg.ID, arg.TelegramUserID)
+	return err
+}
+
+const deleteUserByTelegramUserId = `-- name: DeleteUserByTelegramUserId :exec
+DELETE FROM user_info WHERE telegram_user_id = $1
+`
+
+func (q *Queries) DeleteUserByTelegramUserId(ctx context.Context, telegramUserID int64) error {
+	_, err := q.db.ExecContext(ctx, deleteUserByTelegramUserId, telegramUserID)
+	return err
+}
+
+const endPracticeSessionByTelegramUserId = `-- name: EndPracticeSessionByTelegramUserId :execrows
+UPDATE practice_sessions SET ended = CURRENT_TIMESTAMP, updated = CURRENT_TIMESTAMP
+WHERE ended IS NULL AND user_id = (SELECT user_id FROM user_info WHERE telegram_user_id = $1)
+`
+
+func (q *Queries) EndPracticeSessionByTelegramUserId(ctx context.Context, telegramUserID int64) (int64, error) {
+	result, err := q.db.ExecContext(ctx, endPracticeSessionByTelegramUserId, telegramUserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const expireBonusCredits = `-- name: ExpireBonusCredits :execrows
+UPDATE user_credits
+SET credits_balance = purchased_credits, updated = CURRENT_TIMESTAMP
+WHERE credits_balance > purchased_credits AND last_bonus_grant < $1
+`
+
+// Drops unused bonus credits for users with no bonus grant since granted_before.
+func (q *Queries) ExpireBonusCredits(ctx context.Context, grantedBefore time.Time) (int64, error) {
+	result, err := q.db.ExecContext(ctx, expireBonusCredits, grantedBefore)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const flagUnreconciledStarsPayments = `-- name: FlagUnreconciledStarsPayments :many
+UPDATE stars_ledger p SET flagged_at = CURRENT_TIMESTAMP
+WHERE p.event = 'payment'
+  AND p.flagged_at IS NULL
+  AND p.created < $1
+  AND NOT EXISTS (
+    SELECT 1 FROM stars_ledger g
+    WHERE g.event = 'grant' AND g.telegram_payment_charge_id = p.telegram_payment_charge_id
+  )
+RETURNING p.id, p.telegram_user_id, p.event, p.invoice_payload, p.telegram_payment_charge_id, p.amount, p.credits, p.flagged_at, p.created
+`
+
+// Flags successful payments older than settled_before that never got a
+// matching grant, so each one is only reported once.
+func (q *Queries) FlagUnreconciledStarsPayments(ctx context.Context, settledBefore time.Time) ([]StarsLedger, error) {
+	rows, err := q.db.QueryContext(ctx, flagUnreconciledStarsPayments, settledBefore)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []StarsLedger
+	for rows.Next() {
+		var i StarsLedger
+		if err := rows.Scan(
+			&i.ID,
+			&i.TelegramUserID,
+			&i.Event,
+			&i.InvoicePayload,
+			&i.TelegramPaymentChargeID,
+			&i.Amount,
+			&i.Credits,
+			&i.FlaggedAt,
+			&i.Created,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getActivePracticeSessionByTelegramUserId = `-- name: GetActivePracticeSessionByTelegramUserId :one
+SELECT ps.id, ps.user_id, ps.scenario, ps.messages, ps.ended, ps.created, ps.updated FROM practice_sessions ps
+JOIN user_info ui ON ui.user_id = ps.user_id
+WHERE ui.telegram_user_id = $1 AND ps.ended IS NULL
+LIMIT 1
+`
+
+func (q *Queries) GetActivePracticeSessionByTelegramUserId(ctx context.Context, telegramUserID int64) (PracticeSession, error) {
+	row := q.db.QueryRowContext(ctx, getActivePracticeSessionByTelegramUserId, telegramUserID)
+	var i PracticeSession
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Scenario,
+		&i.Messages,
+		&i.Ended,
+		&i.Created,
+		&i.Updated,
+	)
+	return i, err
+}
+
+const getActiveSubscriptionByTelegramUserId = `-- name: GetActiveSubscriptionByTelegramUserId :one
+SELECT s.id, s.user_id, s.status, s.telegram_payment_charge_id, s.expires_at, s.created, s.updated FROM subscriptions s JOIN user_info ui ON s.user_id = ui.user_id
+WHERE ui.telegram_user_id = $1 AND s.expires_at > CURRENT_TIMESTAMP LIMIT 1
+`
+
+func (q *Queries) GetActiveSubscriptionByTelegramUserId(ctx context.Context, telegramUserID int64) (Subscription, error) {
+	row := q.db.QueryRowContext(ctx, getActiveSubscriptionByTelegramUserId, telegramUserID)
+	var i Subscription
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Status,
+		&i.TelegramPaymentChargeID,
+		&i.ExpiresAt,
+		&i.Created,
+		&i.Updated,
+	)
+	return i, err
+}
+
+const getAffectionByTelegramUserId = `-- name: GetAffectionByTelegramUserId :one
+SELECT affection FROM relationships WHERE telegram_user_id = $1
+`
+
+func (q *Queries) GetAffectionByTelegramUserId(ctx context.Context, telegramUserID int64) (int32, error) {
+	row := q.db.QueryRowContext(ctx, getAffectionByTelegramUserId, telegramUserID)
+	var affection int32
+	err := row.Scan(&affection)
+	return affection, err
+}
+
+const getBroadcastDeliveryStats = `-- name: GetBroadcastDeliveryStats :one
+SELECT
+  COUNT(*) FILTER (WHERE status = 'sent') AS sent,
+  COUNT(*) FILTER (WHERE status = 'failed') AS failed
+FROM broadcast_deliveries WHERE broadcast_id = $1
+`
+
+type GetBroadcastDeliveryStatsRow struct {
+	Sent   int64
+	Failed int64
+}
+
+func (q *Queries) GetBroadcastDeliveryStats(ctx context.Context, broadcastID int64) (GetBroadcastDeliveryStatsRow, error) {
+	row := q.db.QueryRowContext(ctx, getBroadcastDeliveryStats, broadcastID)
+	var i GetBroadcastDeliveryStatsRow
+	err := row.Scan(&i.Sent, &i.Failed)
+	return i, err
+}
+
+const getConversationByTelegramUserId = `-- name: GetConversationByTelegramUserId :one
+SELECT id, telegram_user_id, persona, tts_voice, messages, summary, summarized_messages, created, updated FROM conversations WHERE telegram_user_id = $1 AND persona = $2 LIMIT 1
+`
+
+type GetConversationByTelegramUserIdParams struct {
+	TelegramUserID int64
+	Persona        string
+}
+
+func (q *Queries) GetConversationByTelegramUserId(ctx context.Context, arg GetConversationByTelegramUserIdParams) (Conversation, error) {
+	row := q.db.QueryRowContext(ctx, getConversationByTelegramUserId, arg.TelegramUserID, arg.Persona)
+	var i Conversation
+	err := row.Scan(
+		&i.ID,
+		&i.TelegramUserID,
+		&i.Persona,
+		&i.TtsVoice,
+		&i.Messages,
+		&i.Summary,
+		&i.SummarizedMessages,
+		&i.Created,
+		&i.Updated,
+	)
+	return i, err
+}
+
+const getCreditBreakdownByTelegramUserId = `-- name: GetCreditBreakdownByTelegramUserId :one
+SELECT
+  uc.credits_balance,
+  uc.purchased_credits,
+  uc.last_bonus_grant,
+  (SELECT COUNT(*) FROM responses r WHERE r.telegram_user_id = ui.telegram_user_id AND r.created >= $1) AS replies_since
+FROM user_credits uc JOIN user_info ui ON uc.user_id = ui.user_id
+WHERE ui.telegram_user_id = $2
+`
+
+type GetCreditBreakdownByTelegramUserIdParams struct {
+	UsageSince     time.Time
+	TelegramUserID int64
+}
+
+type GetCreditBreakdownByTelegramUserIdRow struct {
+	CreditsBalance   int32
+	PurchasedCredits int32
+	LastBonusGrant   time.Time
+	RepliesSince     int64
+}
+
+func (q *Queries) GetCreditBreakdownByTelegramUserId(ctx context.Context, arg GetCreditBreakdownByTelegramUserIdParams) (GetCreditBreakdownByTelegramUserIdRow, error) {
+	row := q.db.QueryRowContext(ctx, getCreditBreakdownByTelegramUserId, arg.UsageSince, arg.TelegramUserID)
+	var i GetCreditBreakdownByTelegramUserIdRow
+	err := row.Scan(
+		&i.CreditsBalance,
+		&i.PurchasedCredits,
+		&i.LastBonusGrant,
+		&i.RepliesSince,
+	)
+	return i, err
+}
+
+const getCustomPersonaByTelegramUserId = `-- name: GetCustomPersonaByTelegramUserId :one
+SELECT telegram_user_id, name, city, traits, language_mix, voice, completed_at, created, updated FROM custom_personas WHERE telegram_user_id = $1
+`
+
+func (q *Queries) GetCustomPersonaByTelegramUserId(ctx context.Context, telegramUserID int64) (CustomPersona, error) {
+	row := q.db.QueryRowContext(ctx, getCustomPersonaByTelegramUserId, telegramUserID)
+	var i CustomPersona
+	err := row.Scan(
+		&i.TelegramUserID,
+		&i.Name,
+		&i.City,
+		&i.Traits,
+		&i.LanguageMix,
+		&i.Voice,
+		&i.CompletedAt,
+		&i.Created,
+		&i.Updated,
+	)
+	return i, err
+}
+
+const getGift = `-- name: GetGift :one
+SELECT id, name, emoji, price_credits, affection, reaction, active, created FROM gifts WHERE id = $1 AND active
+`
+
+func (q *Queries) GetGift(ctx context.Context, id string) (Gift, error) {
+	row := q.db.QueryRowContext(ctx, getGift, id)
+	var i Gift
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Emoji,
+		&i.PriceCredits,
+		&i.Affection,
+		&i.Reaction,
+		&i.Active,
+		&i.Created,
+	)
+	return i, err
+}
+
+const getLastDailyClaimByTelegramUserId = `-- name: GetLastDailyClaimByTelegramUserId :one
+SELECT uc.last_daily_claim FROM user_credits uc JOIN user_info ui ON uc.user_id = ui.user_id WHERE ui.telegram_user_id = $1
+`
+
+func (q *Queries) GetLastDailyClaimByTelegramUserId(ctx context.Context, telegramUserID int64) (sql.NullTime, error) {
+	row := q.db.QueryRowContext(ctx, getLastDailyClaimByTelegramUserId, telegramUserID)
+	var last_daily_claim sql.NullTime
+	err := row.Scan(&last_daily_claim)
+	return last_daily_claim, err
+}
+
+const getPaidMediaStatsSince = `-- name: GetPaidMediaStatsSince :one
+SELECT
+  COUNT(*) AS unlocks,
+  COALESCE(SUM(stars), 0)::bigint AS stars_earned
+FROM paid_media_purchases WHERE purchased >= $1
+`
+
+type GetPaidMediaStatsSinceRow struct {
+	Unlocks     int64
+	StarsEarned int64
+}
+
+func (q *Queries) GetPaidMediaStatsSince(ctx context.Context, since time.Time) (GetPaidMediaStatsSinceRow, error) {
+	row := q.db.QueryRowContext(ctx, getPaidMediaStatsSince, since)
+	var i GetPaidMediaStatsSinceRow
+	err := row.Scan(&i.Unlocks, &i.StarsEarned)
+	return i, err
+}
+
+const getPaymentByChargeId = `-- name: GetPaymentByChargeId :one
+SELECT id, user_id, provider, payload, credits, amount, currency, charge_id, created FROM payments WHERE charge_id = $1 LIMIT 1
+`
+
+func (q *Queries) GetPaymentByChargeId(ctx context.Context, chargeID sql.NullString) (Payment, error) {
+	row := q.db.QueryRowContext(ctx, getPaymentByChargeId, chargeID)
+	var i Payment
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Provider,
+		&i.Payload,
+		&i.Credits,
+		&i.Amount,
+		&i.Currency,
+		&i.ChargeID,
+		&i.Created,
+	)
+	return i, err
+}
+
+const getPaymentStatsSince = `-- name: GetPaymentStatsSince :one
+SELECT
+  COUNT(*) AS payments,
+  COALESCE(SUM(credits), 0)::bigint AS credits_sold
+FROM payments WHERE created >= $1
+`
+
+type GetPaymentStatsSinceRow struct {
+	Payments    int64
+	CreditsSold int64
+}
+
+func (q *Queries) GetPaymentStatsSince(ctx context.Context, since time.Time) (GetPaymentStatsSinceRow, error) {
+	row := q.db.QueryRowContext(ctx, getPaymentStatsSince, since)
+	var i GetPaymentStatsSinceRow
+	err := row.Scan(&i.Payments, &i.CreditsSold)
+	return i, err
+}
+
+const getPromoCodeByCode = `-- name: GetPromoCodeByCode :one
+SELECT id, code, credits, max_uses, uses, expires, admin_telegram_user_id, created FROM promo_codes WHERE code = $1 LIMIT 1
+`
+
+func (q *Queries) GetPromoCodeByCode(ctx context.Context, code string) (PromoCode, error) {
+	row := q.db.QueryRowContext(ctx, getPromoCodeByCode, code)
+	var i PromoCode
+	err := row.Scan(
+		&i.ID,
+		&i.Code,
+		&i.Credits,
+		&i.MaxUses,
+		&i.Uses,
+		&i.Expires,
+		&i.AdminTelegramUserID,
+		&i.Created,
+	)
+	return i, err
+}
+
+const getReferralCodeByTelegramUserId = `-- name: GetReferralCodeByTelegramUserId :one
+SELECT rc.id, rc.user_id, rc.code, rc.created FROM referral_codes rc JOIN user_info ui ON rc.user_id = ui.user_id WHERE ui.telegram_user_id = $1 LIMIT 1
+`
+
+func (q *Queries) GetReferralCodeByTelegramUserId(ctx context.Context, telegramUserID int64) (ReferralCode, error) {
+	row := q.db.QueryRowContext(ctx, getReferralCodeByTelegramUserId, telegramUserID)
+	var i ReferralCode
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Code,
+		&i.Created,
+	)
+	return i, err
+}
+
+const getReferrerByTelegramUserId = `-- name: GetReferrerByTelegramUserId :one
+SELECT ui.user_id, ui.telegram_user_id, ui.telegram_username, ui.telegram_first_name, ui.telegram_last_name, ui.banned, ui.age_verified_at, ui.campaign, ui.created FROM user_info ui
+JOIN referrals r ON r.referrer_user_id = ui.user_id
+JOIN user_info referred ON r.referred_user_id = referred.user_id
+WHERE referred.telegram_user_id = $1 LIMIT 1
+`
+
+func (q *Queries) GetReferrerByTelegramUserId(ctx context.Context, telegramUserID int64) (UserInfo, error) {
+	row := q.db.QueryRowContext(ctx, getReferrerByTelegramUserId, telegramUserID)
+	var i UserInfo
+	err := row.Scan(
+		&i.UserID,
+		&i.TelegramUserID,
+		&i.TelegramUsername,
+		&i.TelegramFirstName,
+		&i.TelegramLastName,
+		&i.Banned,
+		&i.AgeVerifiedAt,
+		&i.Campaign,
+		&i.Created,
+	)
+	return i, err
+}
+
+const getResponseStatsSince = `-- name: GetResponseStatsSince :one
+SELECT
+  COUNT(DISTINCT telegram_user_id) AS active_users,
+  COUNT(*) AS responses,
+  COUNT(*) FILTER (WHERE tts_failed) AS tts_failures,
+  COALESCE(AVG(latency_ms), 0)::float8 AS avg_latency_ms
+FROM responses WHERE created >= $1
+`
+
+type GetResponseStatsSinceRow struct {
+	ActiveUsers  int64
+	Responses    int64
+	TtsFailures  int64
+	AvgLatencyMs float64
+}
+
+func (q *Queries) GetResponseStatsSince(ctx context.Context, since time.Time) (GetResponseStatsSinceRow, error) {
+	row := q.db.QueryRowContext(ctx, getResponseStatsSince, since)
+	var i GetResponseStatsSinceRow
+	err := row.Scan(
+		&i.ActiveUsers,
+		&i.Responses,
+		&i.TtsFailures,
+		&i.AvgLatencyMs,
+	)
+	return i, err
+}
+
+const getStripeCustomerByTelegramUserId = `-- name: GetStripeCustomerByTelegramUserId :one
+SELECT telegram_user_id, customer_id, payment_method_id, created, updated FROM stripe_customers WHERE telegram_user_id = $1
+`
+
+func (q *Queries) GetStripeCustomerByTelegramUserId(ctx context.Context, telegramUserID int64) (StripeCustomer, error) {
+	row := q.db.QueryRowContext(ctx, getStripeCustomerByTelegramUserId, telegramUserID)
+	var i StripeCustomer
+	err := row.Scan(
+		&i.TelegramUserID,
+		&i.CustomerID,
+		&i.PaymentMethodID,
+		&i.Created,
+		&i.Updated,
+	)
+	return i, err
+}
+
+const getUnpurchasedPremiumMediaByTelegramUserId = `-- name: GetUnpurchasedPremiumMediaByTelegramUserId :one
+SELECT pm.id, pm.kind, pm.file_id, pm.caption, pm.price_stars, pm.admin_telegram_user_id, pm.created FROM premium_media pm
+WHERE NOT EXISTS (
+  SELECT 1 FROM paid_media_purchases pmp
+  WHERE pmp.premium_media_id = pm.id AND pmp.telegram_user_id = $1
+)
+ORDER BY pm.created DESC
+LIMIT 1
+`
+
+func (q *Queries) GetUnpurchasedPremiumMediaByTelegramUserId(ctx context.Context, telegramUserID int64) (PremiumMedium, error) {
+	row := q.db.QueryRowContext(ctx, getUnpurchasedPremiumMediaByTelegramUserId, telegramUserID)
+	var i PremiumMedium
+	err := row.Scan(
+		&i.ID,
+		&i.Kind,
+		&i.FileID,
+		&i.Caption,
+		&i.PriceStars,
+		&i.AdminTelegramUserID,
+		&i.Created,
+	)
+	return i, err
+}
+
+const getUsageStatsSince = `-- name: GetUsageStatsSince :one
+SELECT
+  COUNT(*) AS requests,
+  COUNT(DISTINCT telegram_user_id) AS users,
+  COALESCE(SUM(cost_micros), 0)::bigint AS cost_micros
+FROM usage_records WHERE created >= $1
+`
+
+type GetUsageStatsSinceRow struct {
+	Requests   int64
+	Users      int64
+	CostMicros int64
+}
+
+func (q *Queries) GetUsageStatsSince(ctx context.Context, since time.Time) (GetUsageStatsSinceRow, error) {
+	row := q.db.QueryRowContext(ctx, getUsageStatsSince, since)
+	var i GetUsageStatsSinceRow
+	err := row.Scan(&i.Requests, &i.Users, &i.CostMicros)
+	return i, err
+}
+
+const getUserByReferralCode = `-- name: GetUserByReferralCode :one
+SELECT ui.user_id, ui.telegram_user_id, ui.telegram_username, ui.telegram_first_name, ui.telegram_last_name, ui.banned, ui.age_verified_at, ui.campaign, ui.created FROM user_info ui JOIN referral_codes rc ON rc.user_id = ui.user_id WHERE rc.code = $1 LIMIT 1
+`
+
+func (q *Queries) GetUserByReferralCode(ctx context.Context, code string) (UserInfo, error) {
+	row := q.db.QueryRowContext(ctx, getUserByReferralCode, code)
+	var i UserInfo
+	err := row.Scan(
+		&i.UserID,
+		&i.TelegramUserID,
+		&i.TelegramUsername,
+		&i.TelegramFirstName,
+		&i.TelegramLastName,
+		&i.Banned,
+		&i.AgeVerifiedAt,
+		&i.Campaign,
+		&i.Created,
+	)
+	return i, err
+}
+
+const getUserByTelegramUserId = `-- name: GetUserByTelegramUserId :one
+SELECT user_id, telegram_user_id, telegram_username, telegram_first_name, telegram_last_name, banned, age_verified_at, campaign, created FROM user_info WHERE telegram_user_id = $1 LIMIT 1
+`
+
+func (q *Queries) GetUserByTelegramUserId(ctx context.Context, telegramUserID int64) (UserInfo, error) {
+	row := q.db.QueryRowContext(ctx, getUserByTelegramUserId, telegramUserID)
+	var i UserInfo
+	err := row.Scan(
+		&i.UserID,
+		&i.TelegramUserID,
+		&i.TelegramUsername,
+		&i.TelegramFirstName,
+		&i.TelegramLastName,
+		&i.Banned,
+		&i.AgeVerifiedAt,
+		&i.Campaign,
+		&i.Created,
+	)
+	return i, err
+}
+
+const getUserCreditsByTelegramUserId = `-- name: GetUserCreditsByTelegramUserId :one
+SELECT uc.credits_balance FROM user_credits uc JOIN user_info ui ON uc.user_id = ui.user_id WHERE ui.telegram_user_id = $1
+`
+
+func (q *Queries) GetUserCreditsByTelegramUserId(ctx context.Context, telegramUserID int64) (int32, error) {
+	row := q.db.QueryRowContext(ctx, getUserCreditsByTelegramUserId, telegramUserID)
+	var credits_balance int32
+	err := row.Scan(&credits_balance)
+	return credits_balance, err
+}
+
+const getUserCreditsByUserID = `-- name: GetUserCreditsByUserID :one
+SELECT id, user_id, credits_balance, last_daily_claim, streak_days, last_streak_date, half_credit_owed, purchased_credits, last_bonus_grant, created, updated FROM user_credits WHERE user_id = $1 LIMIT 1
+`
+
+func (q *Queries) GetUserCreditsByUserID(ctx context.Context, userID int64) (UserCredit, error) {
+	row := q.db.QueryRowContext(ctx, getUserCreditsByUserID, userID)
+	var i UserCredit
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.CreditsBalance,
+		&i.LastDailyClaim,
+		&i.StreakDays,
+		&i.LastStreakDate,
+		&i.HalfCreditOwed,
+		&i.PurchasedCredits,
+		&i.LastBonusGrant,
+		&i.Created,
+		&i.Updated,
+	)
+	return i, err
+}
+
+const getUserMoodByTelegramUserId = `-- name: GetUserMoodByTelegramUserId :one
+
+SELECT telegram_user_id, mood, updated FROM user_moods WHERE telegram_user_id = $1
+`
+
+// ------------------ Mood Queries --------------------
+func (q *Queries) GetUserMoodByTelegramUserId(ctx context.Context, telegramUserID int64) (UserMood, error) {
+	row := q.db.QueryRowContext(ctx, getUserMoodByTelegramUserId, telegramUserID)
+	var i UserMood
+	err := row.Scan(&i.TelegramUserID, &i.Mood, &i.Updated)
+	return i, err
+}
+
+const getUserPlanByTelegramUserId = `-- name: GetUserPlanByTelegramUserId :one
+SELECT
+  EXISTS (SELECT 1 FROM subscriptions s WHERE s.user_id = ui.user_id AND s.expires_at > CURRENT_TIMESTAMP) AS subscribed,
+  COALESCE(uc.purchased_credits, 0)::int AS purchased_credits
+FROM user_info ui LEFT JOIN user_credits uc ON uc.user_id = ui.user_id
+WHERE ui.telegram_user_id = $1
+`
+
+type GetUserPlanByTelegramUserIdRow struct {
+	Subscribed       bool
+	PurchasedCredits int32
+}
+
+// What the user pays for: an unexpired subscription, or credits they bought.
+func (q *Queries) GetUserPlanByTelegramUserId(ctx context.Context, telegramUserID int64) (GetUserPlanByTelegramUserIdRow, error) {
+	row := q.db.QueryRowContext(ctx, getUserPlanByTelegramUserId, telegramUserID)
+	var i GetUserPlanByTelegramUserIdRow
+	err := row.Scan(&i.Subscribed, &i.PurchasedCredits)
+	return i, err
+}
+
+const getUserPreferencesByTelegramUserId = `-- name: GetUserPreferencesByTelegramUserId :one
+
+SELECT up.id, up.user_id, up.broadcast_opt_out, up.reengage_opt_out, up.dnd_start, up.dnd_end, up.timezone, up.text_replies, up.reply_language, up.active_persona, up.preferred_name, up.vibe, up.onboarded_at, up.last_voice_file_ids, up.voice_captions, up.transcript_echo, up.auto_recharge, up.auto_recharge_limit, up.daily_greetings, up.content_intensity, up.speech_rate, up.speech_pitch, up.spoken_language, up.created, up.updated FROM user_preferences up JOIN user_info ui ON up.user_id = ui.user_id WHERE ui.telegram_user_id = $1 LIMIT 1
+`
+
+// ------------------ User Preferences Queries --------------------
+func (q *Queries) GetUserPreferencesByTelegramUserId(ctx context.Context, telegramUserID int64) (UserPreference, error) {
+	row := q.db.QueryRowContext(ctx, getUserPreferencesByTelegramUserId, telegramUserID)
+	var i UserPreference
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.BroadcastOptOut,
+		&i.ReengageOptOut,
+		&i.DndStart,
+		&i.DndEnd,
+		&i.Timezone,
+		&i.TextReplies,
+		&i.ReplyLanguage,
+		&i.ActivePersona,
+		&i.PreferredName,
+		&i.Vibe,
+		&i.OnboardedAt,
+		&i.LastVoiceFileIds,
+		&i.VoiceCaptions,
+		&i.TranscriptEcho,
+		&i.AutoRecharge,
+		&i.AutoRechargeLimit,
+		&i.DailyGreetings,
+		&i.ContentIntensity,
+		&i.SpeechRate,
+		&i.SpeechPitch,
+		&i.SpokenLanguage,
+		&i.Created,
+		&i.Updated,
+	)
+	return i, err
+}
+
+const hasRedeemedPromoCode = `-- name: HasRedeemedPromoCode :one
+SELECT EXISTS (
+  SELECT 1 FROM promo_redemptions pr JOIN user_info ui ON pr.user_id = ui.user_id
+  WHERE pr.promo_code_id = $1 AND ui.telegram_user_id = $2
+)
+`
+
+type HasRedeemedPromoCodeParams struct {
+	PromoCodeID    int64
+	TelegramUserID int64
+}
+
+func (q *Queries) HasRedeemedPromoCode(ctx context.Context, arg HasRedeemedPromoCodeParams) (bool, error) {
+	row := q.db.QueryRowContext(ctx, hasRedeemedPromoCode, arg.PromoCodeID, arg.TelegramUserID)
+	var exists bool
+	err := row.Scan(&exists)
+	return exists, err
+}
+
+const listBroadcastRecipients = `-- name: ListBroadcastRecipients :many
+SELECT ui.telegram_user_id FROM user_info ui
+LEFT JOIN user_preferences up ON up.user_id = ui.user_id
+WHERE ui.banned = FALSE AND ui.age_verified_at IS NOT NULL AND COALESCE(up.broadcast_opt_out, FALSE) = FALSE
+  AND EXISTS (SELECT 1 FROM conversations c WHERE c.telegram_user_id = ui.telegram_user_id AND c.updated > CURRENT_TIMESTAMP - INTERVAL '30 days')
+`
+
+func (q *Queries) ListBroadcastRecipients(ctx context.Context) ([]int64, error) {
+	rows, err := q.db.QueryContext(ctx, listBroadcastRecipients)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []int64
+	for rows.Next() {
+		var telegram_user_id int64
+		if err := rows.Scan(&telegram_user_id); err != nil {
+			return nil, err
+		}
+		items = append(items, telegram_user_id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listCampaignStats = `-- name: ListCampaignStats :many
+SELECT
+  ui.campaign,
+  COUNT(*) AS signups,
+  COUNT(*) FILTER (WHERE EXISTS (SELECT 1 FROM payments p WHERE p.user_id = ui.user_id)) AS conversions
+FROM user_info ui
+WHERE ui.campaign IS NOT NULL
+GROUP BY ui.campaign
+ORDER BY signups DESC
+LIMIT $1
+`
+
+type ListCampaignStatsRow struct {
+	Campaign    sql.NullString
+	Signups     int64
+	Conversions int64
+}
+
+// A conversion is a signup that has made at least one payment.
+func (q *Queries) ListCampaignStats(ctx context.Context, limit int32) ([]ListCampaignStatsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listCampaignStats, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListCampaignStatsRow
+	for rows.Next() {
+		var i ListCampaignStatsRow
+		if err := rows.Scan(&i.Campaign, &i.Signups, &i.Conversions); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listConversationArchives = `-- name: ListConversationArchives :many
+SELECT id, conversation_id, telegram_user_id, persona, messages, archived FROM conversation_archives WHERE conversation_id = $1 ORDER BY archived, id
+`
+
+func (q *Queries) ListConversationArchives(ctx context.Context, conversationID int64) ([]ConversationArchive, error) {
+	rows, err := q.db.QueryContext(ctx, listConversationArchives, conversationID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ConversationArchive
+	for rows.Next() {
+		var i ConversationArchive
+		if err := rows.Scan(
+			&i.ID,
+			&i.ConversationID,
+			&i.TelegramUserID,
+			&i.Persona,
+			&i.Messages,
+			&i.Archived,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listGifts = `-- name: ListGifts :many
+
+SELECT id, name, emoji, price_credits, affection, reaction, active, created FROM gifts WHERE active ORDER BY price_credits
+`
+
+// ------------------ Gift Queries --------------------
+func (q *Queries) ListGifts(ctx context.Context) ([]Gift, error) {
+	rows, err := q.db.QueryContext(ctx, listGifts)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Gift
+	for rows.Next() {
+		var i Gift
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Emoji,
+			&i.PriceCredits,
+			&i.Affection,
+			&i.Reaction,
+			&i.Active,
+			&i.Created,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listGreetingSubscribers = `-- name: ListGreetingSubscribers :many
+
+SELECT ui.telegram_user_id, up.timezone, up.daily_greetings FROM user_info ui
+JOIN user_preferences up ON up.user_id = ui.user_id
+WHERE ui.banned = FALSE AND ui.age_verified_at IS NOT NULL AND up.daily_greetings <> ''
+`
+
+type ListGreetingSubscribersRow struct {
+	TelegramUserID int64
+	Timezone       string
+	DailyGreetings string
+}
+
+// ------------------ Greeting Queries --------------------
+func (q *Queries) ListGreetingSubscribers(ctx context.Context) ([]ListGreetingSubscribersRow, error) {
+	rows, err := q.db.QueryContext(ctx, listGreetingSubscribers)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListGreetingSubscribersRow
+	for rows.Next() {
+		var i ListGreetingSubscribersRow
+		if err := rows.Scan(&i.TelegramUserID, &i.Timezone, &i.DailyGreetings); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listMemoriesByTelegramUserId = `-- name: ListMemoriesByTelegramUserId :many
+SELECT m.id, m.user_id, m.fact, m.created, m.updated FROM memories m
+JOIN user_info ui ON ui.user_id = m.user_id
+WHERE ui.telegram_user_id = $1
+ORDER BY m.created, m.id
+`
+
+func (q *Queries) ListMemoriesByTelegramUserId(ctx context.Context, telegramUserID int64) ([]Memory, error) {
+	rows, err := q.db.QueryContext(ctx, listMemoriesByTelegramUserId, telegramUserID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Memory
+	for rows.Next() {
+		var i Memory
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Fact,
+			&i.Created,
+			&i.Updated,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPromoCodes = `-- name: ListPromoCodes :many
+SELECT id, code, credits, max_uses, uses, expires, admin_telegram_user_id, created FROM promo_codes ORDER BY created DESC LIMIT 20
+`
+
+func (q *Queries) ListPromoCodes(ctx context.Context) ([]PromoCode, error) {
+	rows, err := q.db.QueryContext(ctx, listPromoCodes)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []PromoCode
+	for rows.Next() {
+		var i PromoCode
+		if err := rows.Scan(
+			&i.ID,
+			&i.Code,
+			&i.Credits,
+			&i.MaxUses,
+			&i.Uses,
+			&i.Expires,
+			&i.AdminTelegramUserID,
+			&i.Created,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listRecentAbuseFlags = `-- name: ListRecentAbuseFlags :many
+SELECT ui.telegram_user_id, ui.telegram_username, af.reason, af.message, af.created
+FROM abuse_flags af
+JOIN user_info ui ON ui.user_id = af.user_id
+ORDER BY af.created DESC
+LIMIT $1
+`
+
+type ListRecentAbuseFlagsRow struct {
+	TelegramUserID   int64
+	TelegramUsername sql.NullString
+	Reason           string
+	Message          string
+	Created          time.Time
+}
+
+func (q *Queries) ListRecentAbuseFlags(ctx context.Context, limit int32) ([]ListRecentAbuseFlagsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listRecentAbuseFlags, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListRecentAbuseFlagsRow
+	for rows.Next() {
+		var i ListRecentAbuseFlagsRow
+		if err := rows.Scan(
+			&i.TelegramUserID,
+			&i.TelegramUsername,
+			&i.Reason,
+			&i.Message,
+			&i.Created,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listReengagementCandidates = `-- name: ListReengagementCandidates :many
+
+SELECT ui.telegram_user_id, c.id AS conversation_id FROM user_info ui
+LEFT JOIN user_preferences up ON up.user_id = ui.user_id
+JOIN conversations c ON c.telegram_user_id = ui.telegram_user_id AND c.persona = COALESCE(up.active_persona, 'gulabo')
+WHERE ui.banned = FALSE
+  AND ui.age_verified_at IS NOT NULL
+  AND COALESCE(up.reengage_opt_out, FALSE) = FALSE
+  AND c.messages != '[]'::jsonb
+  AND c.updated < $1
+  AND c.updated > CURRENT_TIMESTAMP - INTERVAL '30 days'
+  AND NOT EXISTS (SELECT 1 FROM reengagements r WHERE r.user_id = ui.user_id AND r.created > c.updated)
+  AND (SELECT COUNT(*) FROM reengagements r WHERE r.user_id = ui.user_id AND r.created > CURRENT_TIMESTAMP - INTERVAL '7 days') < $2::bigint
+ORDER BY c.updated
+LIMIT $3
+`
+
+type ListReengagementCandidatesParams struct {
+	QuietSince time.Time
+	WeeklyCap  int64
+	BatchSize  int32
+}
+
+type ListReengagementCandidatesRow struct {
+	TelegramUserID int64
+	ConversationID int64
+}
+
+// ------------------ Reengagement Queries --------------------
+// Only the conversation with the user's active persona is considered
+func (q *Queries) ListReengagementCandidates(ctx context.Context, arg ListReengagementCandidatesParams) ([]ListReengagementCandidatesRow, error) {
+	rows, err := q.db.QueryContext(ctx, listReengagementCandidates, arg.QuietSince, arg.WeeklyCap, arg.BatchSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListReengagementCandidatesRow
+	for rows.Next() {
+		var i ListReengagementCandidatesRow
+		if err := rows.Scan(&i.TelegramUserID, &i.ConversationID); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTopUsersByUsageCostSince = `-- name: ListTopUsersByUsageCostSince :many
+SELECT
+  telegram_user_id,
+  COUNT(*) AS requests,
+  COALESCE(SUM(cost_micros), 0)::bigint AS cost_micros
+FROM usage_records
+WHERE created >= $1
+GROUP BY telegram_user_id
+ORDER BY cost_micros DESC
+LIMIT $2
+`
+
+type ListTopUsersByUsageCostSinceParams struct {
+	Since    time.Time
+	RowLimit int32
+}
+
+type ListTopUsersByUsageCostSinceRow struct {
+	TelegramUserID int64
+	Requests       int64
+	CostMicros     int64
+}
+
+func (q *Queries) ListTopUsersByUsageCostSince(ctx context.Context, arg ListTopUsersByUsageCostSinceParams) ([]ListTopUsersByUsageCostSinceRow, error) {
+	rows, err := q.db.QueryContext(ctx, listTopUsersByUsageCostSince, arg.Since, arg.RowLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListTopUsersByUsageCostSinceRow
+	for rows.Next() {
+		var i ListTopUsersByUsageCostSinceRow
+		if err := rows.Scan(&i.TelegramUserID, &i.Requests, &i.CostMicros); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUsageByTelegramUserIdSince = `-- name: ListUsageByTelegramUserIdSince :many
+SELECT
+  provider,
+  kind,
+  COUNT(*) AS requests,
+  COALESCE(SUM(input_tokens), 0)::bigint AS input_tokens,
+  COALESCE(SUM(output_tokens), 0)::bigint AS output_tokens,
+  COALESCE(SUM(characters), 0)::bigint AS characters,
+  COALESCE(SUM(cost_micros), 0)::bigint AS cost_micros
+FROM usage_records
+WHERE telegram_user_id = $1 AND created >= $2
+GROUP BY provider, kind
+ORDER BY cost_micros DESC
+`
+
+type ListUsageByTelegramUserIdSinceParams struct {
+	TelegramUserID int64
+	Since          time.Time
+}
+
+type ListUsageByTelegramUserIdSinceRow struct {
+	Provider     string
+	Kind         string
+	Requests     int64
+	InputTokens  int64
+	OutputTokens int64
+	Characters   int64
+	CostMicros   int64
+}
+
+// One row per provider and kind, costliest first.
+func (q *Queries) ListUsageByTelegramUserIdSince(ctx context.Context, arg ListUsageByTelegramUserIdSinceParams) ([]ListUsageByTelegramUserIdSinceRow, error) {
+	rows, err := q.db.QueryContext(ctx, listUsageByTelegramUserIdSince, arg.TelegramUserID, arg.Since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListUsageByTelegramUserIdSinceRow
+	for rows.Next() {
+		var i ListUsageByTelegramUserIdSinceRow
+		if err := rows.Scan(
+			&i.Provider,
+			&i.Kind,
+			&i.Requests,
+			&i.InputTokens,
+			&i.OutputTokens,
+			&i.Characters,
+			&i.CostMicros,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const purchaseGift = `-- name: PurchaseGift :one
+WITH spent AS (
+  UPDATE user_credits
+  SET credits_balance = credits_balance - $1,
+      purchased_credits = LEAST(purchased_credits, credits_balance - $1),
+      updated = CURRENT_TIMESTAMP
+  FROM user_info
+  WHERE user_credits.user_id = user_info.user_id AND user_info.telegram_user_id = $2
+    AND user_credits.credits_balance >= $1
+  RETURNING user_credits.id
+)
+INSERT INTO gift_purchases (telegram_user_id, gift_id, credits)
+SELECT $2, $3, $1 FROM spent
+RETURNING id
+`
+
+type PurchaseGiftParams struct {
+	PriceCredits   int32
+	TelegramUserID int64
+	GiftID         string
+}
+
+// Spends the price, bonus credits first, and records the purchase. No row
+// comes back when the user can't afford it.
+func (q *Queries) PurchaseGift(ctx context.Context, arg PurchaseGiftParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, purchaseGift, arg.PriceCredits, arg.TelegramUserID, arg.GiftID)
+	var id int64
+	err := row.Scan(&id)
+	return id, err
+}
+
+const recordStreakDayByTelegramUserId = `-- name: RecordStreakDayByTelegramUserId :one
+UPDATE user_credits
+SET streak_days = CASE WHEN user_credits.last_streak_date = $1::date - 1 THEN user_credits.streak_days + 1 ELSE 1 END,
+    last_streak_date = $1::date, updated = CURRENT_TIMESTAMP
+FROM user_info
+WHERE user_credits.user_id = user_info.user_id AND user_info.telegram_user_id = $2
+  AND (user_credits.last_streak_date IS NULL OR user_credits.last_streak_date < $1::date)
+RETURNING user_credits.streak_days
+`
+
+type RecordStreakDayByTelegramUserIdParams struct {
+	Today          time.Time
+	TelegramUserID int64
+}
+
+// Extends the streak if the user was last active the day before and restarts
+// it after a gap. Returns no rows if today was already counted.
+func (q *Queries) RecordStreakDayByTelegramUserId(ctx context.Context, arg RecordStreakDayByTelegramUserIdParams) (int32, error) {
+	row := q.db.QueryRowContext(ctx, recordStreakDayByTelegramUserId, arg.Today, arg.TelegramUserID)
+	var streak_days int32
+	err := row.Scan(&streak_days)
+	return streak_days, err
+}
+
+const redeemPromoCode = `-- name: RedeemPromoCode :one
+WITH promo AS (
+  UPDATE promo_codes SET uses = uses + 1
+  WHERE code = $1
+    AND (expires IS NULL OR expires > CURRENT_TIMESTAMP)
+    AND (max_uses IS NULL OR uses < max_uses)
+  RETURNING id, credits
+), redemption AS (
+  INSERT INTO promo_redemptions (promo_code_id, user_id)
+  SELECT promo.id, ui.user_id FROM promo, user_info ui WHERE ui.telegram_user_id = $2
+)
+UPDATE user_credits
+SET credits_balance = credits_balance + promo.credits, last_bonus_grant = CURRENT_TIMESTAMP, updated = CURRENT_TIMESTAMP
+FROM promo, user_info
+WHERE user_credits.user_id = user_info.user_id AND user_info.telegram_user_id = $2
+RETURNING user_credits.credits_balance, promo.credits
+`
+
+type RedeemPromoCodeParams struct {
+	Code           string
+	TelegramUserID int64
+}
+
+type RedeemPromoCodeRow struct {
+	CreditsBalance int32
+	Credits        int32
+}
+
+// Claims a use, records the redemption, and adds the credits in one statement.
+// Returns no rows if the code is unknown, expired, or used up; a repeat
+// redemption fails on the promo_redemptions unique constraint.
+func (q *Queries) RedeemPromoCode(ctx context.Context, arg RedeemPromoCodeParams) (RedeemPromoCodeRow, error) {
+	row := q.db.QueryRowContext(ctx, redeemPromoCode, arg.Code, arg.TelegramUserID)
+	var i RedeemPromoCodeRow
+	err := row.Scan(&i.CreditsBalance, &i.Credits)
+	return i, err
+}
+
+const searchRecallMoments = `-- name: SearchRecallMoments :many
+SELECT content, said, (embedding <=> $1::text::vector)::float8 AS distance
+FROM recall_moments
+WHERE conversation_id = $2 AND model = $3
+ORDER BY embedding <=> $1::text::vector
+LIMIT $4
+`
+
+type SearchRecallMomentsParams struct {
+	Embedding      string
+	ConversationID int64
+	Model          string
+	TopK           int32
+}
+
+type SearchRecallMomentsRow struct {
+	Content  string
+	Said     sql.NullTime
+	Distance float64
+}
+
+// The moments closest in meaning to the embedding, by cosine distance. Only
+// moments embedded by the same model are comparable.
+func (q *Queries) SearchRecallMoments(ctx context.Context, arg SearchRecallMomentsParams) ([]SearchRecallMomentsRow, error) {
+	rows, err := q.db.QueryContext(ctx, searchRecallMoments,
+		arg.Embedding,
+		arg.ConversationID,
+		arg.Model,
+		arg.TopK,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SearchRecallMomentsRow
+	for rows.Next() {
+		var i SearchRecallMomentsRow
+		if err := rows.Scan(&i.Content, &i.Said, &i.Distance); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const setActivePersonaByTelegramUserId = `-- name: SetActivePersonaByTelegramUserId :one
+INSERT INTO user_preferences (user_id, active_persona)
+SELECT user_id, $1 FROM user_info WHERE telegram_user_id = $2
+ON CONFLICT (user_id) DO UPDATE
+SET active_persona = EXCLUDED.active_persona, updated = CURRENT_TIMESTAMP
+RETURNING id, user_id, broadcast_opt_out, reengage_opt_out, dnd_start, dnd_end, timezone, text_replies, reply_language, active_persona, preferred_name, vibe, onboarded_at, last_voice_file_ids, voice_captions, transcript_echo, auto_recharge, auto_recharge_limit, daily_greetings, content_intensity, speech_rate, speech_pitch, spoken_language, created, updated
+`
+
+type SetActivePersonaByTelegramUserIdParams struct {
+	ActivePersona  string
+	TelegramUserID int64
+}
+
+func (q *Queries) SetActivePersonaByTelegramUserId(ctx context.Context, arg SetActivePersonaByTelegramUserIdParams) (UserPreference, error) {
+	row := q.db.QueryRowContext(ctx, setActivePersonaByTelegramUserId, arg.ActivePersona, arg.TelegramUserID)
+	var i UserPreference
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.BroadcastOptOut,
+		&i.ReengageOptOut,
+		&i.DndStart,
+		&i.DndEnd,
+		&i.Timezone,
+		&i.TextReplies,
+		&i.ReplyLanguage,
+		&i.ActivePersona,
+		&i.PreferredName,
+		&i.Vibe,
+		&i.OnboardedAt,
+		&i.LastVoiceFileIds,
+		&i.VoiceCaptions,
+		&i.TranscriptEcho,
+		&i.AutoRecharge,
+		&i.AutoRechargeLimit,
+		&i.DailyGreetings,
+		&i.ContentIntensity,
+		&i.SpeechRate,
+		&i.SpeechPitch,
+		&i.SpokenLanguage,
+		&i.Created,
+		&i.Updated,
+	)
+	return i, err
+}
+
+const setAutoRechargeByTelegramUserId = `-- name: SetAutoRechargeByTelegramUserId :one
+INSERT INTO user_preferences (user_id, auto_recharge)
+SELECT user_id, $1 FROM user_info WHERE telegram_user_id = $2
+ON CONFLICT (user_id) DO UPDATE
+SET auto_recharge = EXCLUDED.auto_recharge, updated = CURRENT_TIMESTAMP
+RETURNING id, user_id, broadcast_opt_out, reengage_opt_out, dnd_start, dnd_end, timezone, text_replies, reply_language, active_persona, preferred_name, vibe, onboarded_at, last_voice_file_ids, voice_captions, transcript_echo, auto_recharge, auto_recharge_limit, daily_greetings, content_intensity, speech_rate, speech_pitch, spoken_language, created, updated
+`
+
+type SetAutoRechargeByTelegramUserIdParams struct {
+	AutoRecharge   string
+	TelegramUserID int64
+}
+
+func (q *Queries) SetAutoRechargeByTelegramUserId(ctx context.Context, arg SetAutoRechargeByTelegramUserIdParams) (UserPreference, error) {
+	row := q.db.QueryRowContext(ctx, setAutoRechargeByTelegramUserId, arg.AutoRecharge, arg.TelegramUserID)
+	var i UserPreference
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.BroadcastOptOut,
+		&i.ReengageOptOut,
+		&i.DndStart,
+		&i.DndEnd,
+		&i.Timezone,
+		&i.TextReplies,
+		&i.ReplyLanguage,
+		&i.ActivePersona,
+		&i.PreferredName,
+		&i.Vibe,
+		&i.OnboardedAt,
+		&i.LastVoiceFileIds,
+		&i.VoiceCaptions,
+		&i.TranscriptEcho,
+		&i.AutoRecharge,
+		&i.AutoRechargeLimit,
+		&i.DailyGreetings,
+		&i.ContentIntensity,
+		&i.SpeechRate,
+		&i.SpeechPitch,
+		&i.SpokenLanguage,
+		&i.Created,
+		&i.Updated,
+	)
+	return i, err
+}
+
+const setAutoRechargeLimitByTelegramUserId = `-- name: SetAutoRechargeLimitByTelegramUserId :one
+INSERT INTO user_preferences (user_id, auto_recharge_limit)
+SELECT user_id, $1 FROM user_info WHERE telegram_user_id = $2
+ON CONFLICT (user_id) DO UPDATE
+SET auto_recharge_limit = EXCLUDED.auto_recharge_limit, updated = CURRENT_TIMESTAMP
+RETURNING id, user_id, broadcast_opt_out, reengage_opt_out, dnd_start, dnd_end, timezone, text_replies, reply_language, active_persona, preferred_name, vibe, onboarded_at, last_voice_file_ids, voice_captions, transcript_echo, auto_recharge, auto_recharge_limit, daily_greetings, content_intensity, speech_rate, speech_pitch, spoken_language, created, updated
+`
+
+type SetAutoRechargeLimitByTelegramUserIdParams struct {
+	AutoRechargeLimit int32
+	TelegramUserID    int64
+}
+
+func (q *Queries) SetAutoRechargeLimitByTelegramUserId(ctx context.Context, arg SetAutoRechargeLimitByTelegramUserIdParams) (UserPreference, error) {
+	row := q.db.QueryRowContext(ctx, setAutoRechargeLimitByTelegramUserId, arg.AutoRechargeLimit, arg.TelegramUserID)
+	var i UserPreference
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.BroadcastOptOut,
+		&i.ReengageOptOut,
+		&i.DndStart,
+		&i.DndEnd,
+		&i.Timezone,
+		&i.TextReplies,
+		&i.ReplyLanguage,
+		&i.ActivePersona,
+		&i.PreferredName,
+		&i.Vibe,
+		&i.OnboardedAt,
+		&i.LastVoiceFileIds,
+		&i.VoiceCaptions,
+		&i.TranscriptEcho,
+		&i.AutoRecharge,
+		&i.AutoRechargeLimit,
+		&i.DailyGreetings,
+		&i.ContentIntensity,
+		&i.SpeechRate,
+		&i.SpeechPitch,
+		&i.SpokenLanguage,
+		&i.Created,
+		&i.Updated,
+	)
+	return i, err
+}
+
+const setBroadcastOptOutByTelegramUserId = `-- name: SetBroadcastOptOutByTelegramUserId :one
+INSERT INTO user_preferences (user_id, broadcast_opt_out)
+SELECT user_id, $1 FROM user_info WHERE telegram_user_id = $2
+ON CONFLICT (user_id) DO UPDATE
+SET broadcast_opt_out = EXCLUDED.broadcast_opt_out, updated = CURRENT_TIMESTAMP
+RETURNING id, user_id, broadcast_opt_out, reengage_opt_out, dnd_start, dnd_end, timezone, text_replies, reply_language, active_persona, preferred_name, vibe, onboarded_at, last_voice_file_ids, voice_captions, transcript_echo, auto_recharge, auto_recharge_limit, daily_greetings, content_intensity, speech_rate, speech_pitch, spoken_language, created, updated
+`
+
+type SetBroadcastOptOutByTelegramUserIdParams struct {
+	BroadcastOptOut bool
+	TelegramUserID  int64
+}
+
+func (q *Queries) SetBroadcastOptOutByTelegramUserId(ctx context.Context, arg SetBroadcastOptOutByTelegramUserIdParams) (UserPreference, error) {
+	row := q.db.QueryRowContext(ctx, setBroadcastOptOutByTelegramUserId, arg.BroadcastOptOut, arg.TelegramUserID)
+	var i UserPreference
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.BroadcastOptOut,
+		&i.ReengageOptOut,
+		&i.DndStart,
+		&i.DndEnd,
+		&i.Timezone,
+		&i.TextReplies,
+		&i.ReplyLanguage,
+		&i.ActivePersona,
+		&i.PreferredName,
+		&i.Vibe,
+		&i.OnboardedAt,
+		&i.LastVoiceFileIds,
+		&i.VoiceCaptions,
+		&i.TranscriptEcho,
+		&i.AutoRecharge,
+		&i.AutoRechargeLimit,
+		&i.DailyGreetings,
+		&i.ContentIntensity,
+		&i.SpeechRate,
+		&i.SpeechPitch,
+		&i.SpokenLanguage,
+		&i.Created,
+		&i.Updated,
+	)
+	return i, err
+}
+
+const setContentIntensityByTelegramUserId = `-- name: SetContentIntensityByTelegramUserId :one
+INSERT INTO user_preferences (user_id, content_intensity)
+SELECT user_id, $1 FROM user_info WHERE telegram_user_id = $2
+ON CONFLICT (user_id) DO UPDATE
+SET content_intensity = EXCLUDED.content_intensity, updated = CURRENT_TIMESTAMP
+RETURNING id, user_id, broadcast_opt_out, reengage_opt_out, dnd_start, dnd_end, timezone, text_replies, reply_language, active_persona, preferred_name, vibe, onboarded_at, last_voice_file_ids, voice_captions, transcript_echo, auto_recharge, auto_recharge_limit, daily_greetings, content_intensity, speech_rate, speech_pitch, spoken_language, created, updated
+`
+
+type SetContentIntensityByTelegramUserIdParams struct {
+	ContentIntensity string
+	TelegramUserID   int64
+}
+
+func (q *Queries) SetContentIntensityByTelegramUserId(ctx context.Context, arg SetContentIntensityByTelegramUserIdParams) (UserPreference, error) {
+	row := q.db.QueryRowContext(ctx, setContentIntensityByTelegramUserId, arg.ContentIntensity, arg.TelegramUserID)
+	var i UserPreference
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.BroadcastOptOut,
+		&i.ReengageOptOut,
+		&i.DndStart,
+		&i.DndEnd,
+		&i.Timezone,
+		&i.TextReplies,
+		&i.ReplyLanguage,
+		&i.ActivePersona,
+		&i.PreferredName,
+		&i.Vibe,
+		&i.OnboardedAt,
+		&i.LastVoiceFileIds,
+		&i.VoiceCaptions,
+		&i.TranscriptEcho,
+		&i.AutoRecharge,
+		&i.AutoRechargeLimit,
+		&i.DailyGreetings,
+		&i.ContentIntensity,
+		&i.SpeechRate,
+		&i.SpeechPitch,
+		&i.SpokenLanguage,
+		&i.Created,
+		&i.Updated,
+	)
+	return i, err
+}
+
+const setConversationVoice = `-- name: SetConversationVoice :one
+UPDATE conversations SET tts_voice = $2 WHERE id = $1 RETURNING id, telegram_user_id, persona, tts_voice, messages, summary, summarized_messages, created, updated
+`
+
+type SetConversationVoiceParams struct {
+	ID       int64
+	TtsVoice string
+}
+
+func (q *Queries) SetConversationVoice(ctx context.Context, arg SetConversationVoiceParams) (Conversation, error) {
+	row := q.db.QueryRowContext(ctx, setConversationVoice, arg.ID, arg.TtsVoice)
+	var i Conversation
+	err := row.Scan(
+		&i.ID,
+		&i.TelegramUserID,
+		&i.Persona,
+		&i.TtsVoice,
+		&i.Messages,
+		&i.Summary,
+		&i.SummarizedMessages,
+		&i.Created,
+		&i.Updated,
+	)
+	return i, err
+}
+
+const setCustomPersonaCity = `-- name: SetCustomPersonaCity :execrows
+UPDATE custom_personas SET city = $2, updated = CURRENT_TIMESTAMP
+WHERE telegram_user_id = $1 AND completed_at IS NULL
+`
+
+type SetCustomPersonaCityParams struct {
+	TelegramUserID int64
+	City           string
+}
+
+func (q *Queries) SetCustomPersonaCity(ctx context.Context, arg SetCustomPersonaCityParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, setCustomPersonaCity, arg.TelegramUserID, arg.City)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const setCustomPersonaLanguageMix = `-- name: SetCustomPersonaLanguageMix :execrows
+UPDATE custom_personas SET language_mix = $2, updated = CURRENT_TIMESTAMP
+WHERE telegram_user_id = $1 AND completed_at IS NULL
+`
+
+type SetCustomPersonaLanguageMixParams struct {
+	TelegramUserID int64
+	LanguageMix    string
+}
+
+func (q *Queries) SetCustomPersonaLanguageMix(ctx context.Context, arg SetCustomPersonaLanguageMixParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, setCustomPersonaLanguageMix, arg.TelegramUserID, arg.LanguageMix)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const setCustomPersonaName = `-- name: SetCustomPersonaName :execrows
+UPDATE custom_personas SET name = $2, updated = CURRENT_TIMESTAMP
+WHERE telegram_user_id = $1 AND completed_at IS NULL
+`
+
+type SetCustomPersonaNameParams struct {
+	TelegramUserID int64
+	Name           string
+}
+
+func (q *Queries) SetCustomPersonaName(ctx context.Context, arg SetCustomPersonaNameParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, setCustomPersonaName, arg.TelegramUserID, arg.Name)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const setCustomPersonaTraits = `-- name: SetCustomPersonaTraits :execrows
+UPDATE custom_personas SET traits = $2, updated = CURRENT_TIMESTAMP
+WHERE telegram_user_id = $1 AND completed_at IS NULL
+`
+
+type SetCustomPersonaTraitsParams struct {
+	TelegramUserID int64
+	Traits         string
+}
+
+func (q *Queries) SetCustomPersonaTraits(ctx context.Context, arg SetCustomPersonaTraitsParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, setCustomPersonaTraits, arg.TelegramUserID, arg.Traits)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const setDailyGreetingsByTelegramUserId = `-- name: SetDailyGreetingsByTelegramUserId :one
+INSERT INTO user_preferences (user_id, daily_greetings)
+SELECT user_id, $1 FROM user_info WHERE telegram_user_id = $2
+ON CONFLICT (user_id) DO UPDATE
+SET daily_greetings = EXCLUDED.daily_greetings, updated = CURRENT_TIMESTAMP
+RETURNING id, user_id, broadcast_opt_out, reengage_opt_out, dnd_start, dnd_end, timezone, text_replies, reply_language, active_persona, preferred_name, vibe, onboarded_at, last_voice_file_ids, voice_captions, transcript_echo, auto_recharge, auto_recharge_limit, daily_greetings, content_intensity, speech_rate, speech_pitch, spoken_language, created, updated
+`
+
+type SetDailyGreetingsByTelegramUserIdParams struct {
+	DailyGreetings string
+	TelegramUserID int64
+}
+
+func (q *Queries) SetDailyGreetingsByTelegramUserId(ctx context.Context, arg SetDailyGreetingsByTelegramUserIdParams) (UserPreference, error) {
+	row := q.db.QueryRowContext(ctx, setDailyGreetingsByTelegramUserId, arg.DailyGreetings, arg.TelegramUserID)
+	var i UserPreference
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.BroadcastOptOut,
+		&i.ReengageOptOut,
+		&i.DndStart,
+		&i.DndEnd,
+		&i.Timezone,
+		&i.TextReplies,
+		&i.ReplyLanguage,
+		&i.ActivePersona,
+		&i.PreferredName,
+		&i.Vibe,
+		&i.OnboardedAt,
+		&i.LastVoiceFileIds,
+		&i.VoiceCaptions,
+		&i.TranscriptEcho,
+		&i.AutoRecharge,
+		&i.AutoRechargeLimit,
+		&i.DailyGreetings,
+		&i.ContentIntensity,
+		&i.SpeechRate,
+		&i.SpeechPitch,
+		&i.SpokenLanguage,
+		&i.Created,
+		&i.Updated,
+	)
+	return i, err
+}
+
+const setLastVoiceFileIdsByTelegramUserId = `-- name: SetLastVoiceFileIdsByTelegramUserId :one
+INSERT INTO user_preferences (user_id, last_voice_file_ids)
+SELECT user_id, $1 FROM user_info WHERE telegram_user_id = $2
+ON CONFLICT (user_id) DO UPDATE
+SET last_voice_file_ids = EXCLUDED.last_voice_file_ids, updated = CURRENT_TIMESTAMP
+RETURNING id, user_id, broadcast_opt_out, reengage_opt_out, dnd_start, dnd_end, timezone, text_replies, reply_language, active_persona, preferred_name, vibe, onboarded_at, last_voice_file_ids, voice_captions, transcript_echo, auto_recharge, auto_recharge_limit, daily_greetings, content_intensity, speech_rate, speech_pitch, spoken_language, created, updated
+`
+
+type SetLastVoiceFileIdsByTelegramUserIdParams struct {
+	LastVoiceFileIds json.RawMessage
+	TelegramUserID   int64
+}
+
+func (q *Queries) SetLastVoiceFileIdsByTelegramUserId(ctx context.Context, arg SetLastVoiceFileIdsByTelegramUserIdParams) (UserPreference, error) {
+	row := q.db.QueryRowContext(ctx, setLastVoiceFileIdsByTelegramUserId, arg.LastVoiceFileIds, arg.TelegramUserID)
+	var i UserPreference
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.BroadcastOptOut,
+		&i.ReengageOptOut,
+		&i.DndStart,
+		&i.DndEnd,
+		&i.Timezone,
+		&i.TextReplies,
+		&i.ReplyLanguage,
+		&i.ActivePersona,
+		&i.PreferredName,
+		&i.Vibe,
+		&i.OnboardedAt,
+		&i.LastVoiceFileIds,
+		&i.VoiceCaptions,
+		&i.TranscriptEcho,
+		&i.AutoRecharge,
+		&i.AutoRechargeLimit,
+		&i.DailyGreetings,
+		&i.ContentIntensity,
+		&i.SpeechRate,
+		&i.SpeechPitch,
+		&i.SpokenLanguage,
+		&i.Created,
+		&i.Updated,
+	)
+	return i, err
+}
+
+const setPreferredNameByTelegramUserId = `-- name: SetPreferredNameByTelegramUserId :one
+INSERT INTO user_preferences (user_id, preferred_name)
+SELECT user_id, $1 FROM user_info WHERE telegram_user_id = $2
+ON CONFLICT (user_id) DO UPDATE
+SET preferred_name = EXCLUDED.preferred_name, updated = CURRENT_TIMESTAMP
+RETURNING id, user_id, broadcast_opt_out, reengage_opt_out, dnd_start, dnd_end, timezone, text_replies, reply_language, active_persona, preferred_name, vibe, onboarded_at, last_voice_file_ids, voice_captions, transcript_echo, auto_recharge, auto_recharge_limit, daily_greetings, content_intensity, speech_rate, speech_pitch, spoken_language, created, updated
+`
+
+type SetPreferredNameByTelegramUserIdParams struct {
+	PreferredName  sql.NullString
+	TelegramUserID int64
+}
+
+func (q *Queries) SetPreferredNameByTelegramUserId(ctx context.Context, arg SetPreferredNameByTelegramUserIdParams) (UserPreference, error) {
+	row := q.db.QueryRowContext(ctx, setPreferredNameByTelegramUserId, arg.PreferredName, arg.TelegramUserID)
+	var i UserPreference
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.BroadcastOptOut,
+		&i.ReengageOptOut,
+		&i.DndStart,
+		&i.DndEnd,
+		&i.Timezone,
+		&i.TextReplies,
+		&i.ReplyLanguage,
+		&i.ActivePersona,
+		&i.PreferredName,
+		&i.Vibe,
+		&i.OnboardedAt,
+		&i.LastVoiceFileIds,
+		&i.VoiceCaptions,
+		&i.TranscriptEcho,
+		&i.AutoRecharge,
+		&i.AutoRechargeLimit,
+		&i.DailyGreetings,
+		&i.ContentIntensity,
+		&i.SpeechRate,
+		&i.SpeechPitch,
+		&i.SpokenLanguage,
+		&i.Created,
+		&i.Updated,
+	)
+	return i, err
+}
+
+const setQuietHoursByTelegramUserId = `-- name: SetQuietHoursByTelegramUserId :one
+INSERT INTO user_preferences (user_id, dnd_start, dnd_end, timezone)
+SELECT user_id, $1, $2, $3 FROM user_info WHERE telegram_user_id = $4
+ON CONFLICT (user_id) DO UPDATE
+SET dnd_start = EXCLUDED.dnd_start, dnd_end = EXCLUDED.dnd_end, timezone = EXCLUDED.timezone, updated = CURRENT_TIMESTAMP
+RETURNING id, user_id, broadcast_opt_out, reengage_opt_out, dnd_start, dnd_end, timezone, text_replies, reply_language, active_persona, preferred_name, vibe, onboarded_at, last_voice_file_ids, voice_captions, transcript_echo, auto_recharge, auto_recharge_limit, daily_greetings, content_intensity, speech_rate, speech_pitch, spoken_language, created, updated
+`
+
+type SetQuietHoursByTelegramUserIdParams struct {
+	DndStart       sql.NullInt32
+	DndEnd         sql.NullInt32
+	Timezone       string
+	TelegramUserID int64
+}
+
+func (q *Queries) SetQuietHoursByTelegramUserId(ctx context.Context, arg SetQuietHoursByTelegramUserIdParams) (UserPreference, error) {
+	row := q.db.QueryRowContext(ctx, setQuietHoursByTelegramUserId,
+		arg.DndStart,
+		arg.DndEnd,
+		arg.Timezone,
+		arg.TelegramUserID,
+	)
+	var i UserPreference
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.BroadcastOptOut,
+		&i.ReengageOptOut,
+		&i.DndStart,
+		&i.DndEnd,
+		&i.Timezone,
+		&i.TextReplies,
+		&i.ReplyLanguage,
+		&i.ActivePersona,
+		&i.PreferredName,
+		&i.Vibe,
+		&i.OnboardedAt,
+		&i.LastVoiceFileIds,
+		&i.VoiceCaptions,
+		&i.TranscriptEcho,
+		&i.AutoRecharge,
+		&i.AutoRechargeLimit,
+		&i.DailyGreetings,
+		&i.ContentIntensity,
+		&i.SpeechRate,
+		&i.SpeechPitch,
+		&i.SpokenLanguage,
+		&i.Created,
+		&i.Updated,
+	)
+	return i, err
+}
+
+const setReengageOptOutByTelegramUserId = `-- name: SetReengageOptOutByTelegramUserId :one
+INSERT INTO user_preferences (user_id, reengage_opt_out)
+SELECT user_id, $1 FROM user_info WHERE telegram_user_id = $2
+ON CONFLICT (user_id) DO UPDATE
+SET reengage_opt_out = EXCLUDED.reengage_opt_out, updated = CURRENT_TIMESTAMP
+RETURNING id, user_id, broadcast_opt_out, reengage_opt_out, dnd_start, dnd_end, timezone, text_replies, reply_language, active_persona, preferred_name, vibe, onboarded_at, last_voice_file_ids, voice_captions, transcript_echo, auto_recharge, auto_recharge_limit, daily_greetings, content_intensity, speech_rate, speech_pitch, spoken_language, created, updated
+`
+
+type SetReengageOptOutByTelegramUserIdParams struct {
+	ReengageOptOut bool
+	TelegramUserID int64
+}
+
+func (q *Queries) SetReengageOptOutByTelegramUserId(ctx context.Context, arg SetReengageOptOutByTelegramUserIdParams) (UserPreference, error) {
+	row := q.db.QueryRowContext(ctx, setReengageOptOutByTelegramUserId, arg.ReengageOptOut, arg.TelegramUserID)
+	var i UserPreference
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.BroadcastOptOut,
+		&i.ReengageOptOut,
+		&i.DndStart,
+		&i.DndEnd,
+		&i.Timezone,
+		&i.TextReplies,
+		&i.ReplyLanguage,
+		&i.ActivePersona,
+		&i.PreferredName,
+		&i.Vibe,
+		&i.OnboardedAt,
+		&i.LastVoiceFileIds,
+		&i.VoiceCaptions,
+		&i.TranscriptEcho,
+		&i.AutoRecharge,
+		&i.AutoRechargeLimit,
+		&i.DailyGreetings,
+		&i.ContentIntensity,
+		&i.SpeechRate,
+		&i.SpeechPitch,
+		&i.SpokenLanguage,
+		&i.Created,
+		&i.Updated,
+	)
+	return i, err
+}
+
+const setReplyLanguageByTelegramUserId = `-- name: SetReplyLanguageByTelegramUserId :one
+INSERT INTO user_preferences (user_id, reply_language)
+SELECT user_id, $1 FROM user_info WHERE telegram_user_id = $2
+ON CONFLICT (user_id) DO UPDATE
+SET reply_language = EXCLUDED.reply_language, updated = CURRENT_TIMESTAMP
+RETURNING id, user_id, broadcast_opt_out, reengage_opt_out, dnd_start, dnd_end, timezone, text_replies, reply_language, active_persona, preferred_name, vibe, onboarded_at, last_voice_file_ids, voice_captions, transcript_echo, auto_recharge, auto_recharge_limit, daily_greetings, content_intensity, speech_rate, speech_pitch, spoken_language, created, updated
+`
+
+type SetReplyLanguageByTelegramUserIdParams struct {
+	ReplyLanguage  string
+	TelegramUserID int64
+}
+
+func (q *Queries) SetReplyLanguageByTelegramUserId(ctx context.Context, arg SetReplyLanguageByTelegramUserIdParams) (UserPreference, error) {
+	row := q.db.QueryRowContext(ctx, setReplyLanguageByTelegramUserId, arg.ReplyLanguage, arg.TelegramUserID)
+	var i UserPreference
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.BroadcastOptOut,
+		&i.ReengageOptOut,
+		&i.DndStart,
+		&i.DndEnd,
+		&i.Timezone,
+		&i.TextReplies,
+		&i.ReplyLanguage,
+		&i.ActivePersona,
+		&i.PreferredName,
+		&i.Vibe,
+		&i.OnboardedAt,
+		&i.LastVoiceFileIds,
+		&i.VoiceCaptions,
+		&i.TranscriptEcho,
+		&i.AutoRecharge,
+		&i.AutoRechargeLimit,
+		&i.DailyGreetings,
+		&i.ContentIntensity,
+		&i.SpeechRate,
+		&i.SpeechPitch,
+		&i.SpokenLanguage,
+		&i.Created,
+		&i.Updated,
+	)
+	return i, err
+}
+
+const setSpeechByTelegramUserId = `-- name: SetSpeechByTelegramUserId :one
+INSERT INTO user_preferences (user_id, speech_rate, speech_pitch)
+SELECT user_id, $1, $2 FROM user_info WHERE telegram_user_id = $3
+ON CONFLICT (user_id) DO UPDATE
+SET speech_rate = EXCLUDED.speech_rate, speech_pitch = EXCLUDED.speech_pitch, updated = CURRENT_TIMESTAMP
+RETURNING id, user_id, broadcast_opt_out, reengage_opt_out, dnd_start, dnd_end, timezone, text_replies, reply_language, active_persona, preferred_name, vibe, onboarded_at, last_voice_file_ids, voice_captions, transcript_echo, auto_recharge, auto_recharge_limit, daily_greetings, content_intensity, speech_rate, speech_pitch, spoken_language, created, updated
+`
+
+type SetSpeechByTelegramUserIdParams struct {
+	SpeechRate     string
+	SpeechPitch    string
+	TelegramUserID int64
+}
+
+func (q *Queries) SetSpeechByTelegramUserId(ctx context.Context, arg SetSpeechByTelegramUserIdParams) (UserPreference, error) {
+	row := q.db.QueryRowContext(ctx, setSpeechByTelegramUserId, arg.SpeechRate, arg.SpeechPitch, arg.TelegramUserID)
+	var i UserPreference
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.BroadcastOptOut,
+		&i.ReengageOptOut,
+		&i.DndStart,
+		&i.DndEnd,
+		&i.Timezone,
+		&i.TextReplies,
+		&i.ReplyLanguage,
+		&i.ActivePersona,
+		&i.PreferredName,
+		&i.Vibe,
+		&i.OnboardedAt,
+		&i.LastVoiceFileIds,
+		&i.VoiceCaptions,
+		&i.TranscriptEcho,
+		&i.AutoRecharge,
+		&i.AutoRechargeLimit,
+		&i.DailyGreetings,
+		&i.ContentIntensity,
+		&i.SpeechRate,
+		&i.SpeechPitch,
+		&i.SpokenLanguage,
+		&i.Created,
+		&i.Updated,
+	)
+	return i, err
+}
+
+const setSpokenLanguageByTelegramUserId = `-- name: SetSpokenLanguageByTelegramUserId :exec
+INSERT INTO user_preferences (user_id, spoken_language)
+SELECT user_id, $1 FROM user_info WHERE telegram_user_id = $2
+ON CONFLICT (user_id) DO UPDATE
+SET spoken_language = EXCLUDED.spoken_language, updated = CURRENT_TIMESTAMP
+`
+
+type SetSpokenLanguageByTelegramUserIdParams struct {
+	SpokenLanguage string
+	TelegramUserID int64
+}
+
+func (q *Queries) SetSpokenLanguageByTelegramUserId(ctx context.Context, arg SetSpokenLanguageByTelegramUserIdParams) error {
+	_, err := q.db.ExecContext(ctx, setSpokenLanguageByTelegramUserId, arg.SpokenLanguage, arg.TelegramUserID)
+	return err
+}
+
+const setTextRepliesByTelegramUserId = `-- name: SetTextRepliesByTelegramUserId :one
+INSERT INTO user_preferences (user_id, text_replies)
+SELECT user_id, $1 FROM user_info WHERE telegram_user_id = $2
+ON CONFLICT (user_id) DO UPDATE
+SET text_replies = EXCLUDED.text_replies, updated = CURRENT_TIMESTAMP
+RETURNING id, user_id, broadcast_opt_out, reengage_opt_out, dnd_start, dnd_end, timezone, text_replies, reply_language, active_persona, preferred_name, vibe, onboarded_at, last_voice_file_ids, voice_captions, transcript_echo, auto_recharge, auto_recharge_limit, daily_greetings, content_intensity, speech_rate, speech_pitch, spoken_language, created, updated
+`
+
+type SetTextRepliesByTelegramUserIdParams struct {
+	TextReplies    bool
+	TelegramUserID int64
+}
+
+func (q *Queries) SetTextRepliesByTelegramUserId(ctx context.Context, arg SetTextRepliesByTelegramUserIdParams) (UserPreference, error) {
+	row := q.db.QueryRowContext(ctx, setTextRepliesByTelegramUserId, arg.TextReplies, arg.TelegramUserID)
+	var i UserPreference
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.BroadcastOptOut,
+		&i.ReengageOptOut,
+		&i.DndStart,
+		&i.DndEnd,
+		&i.Timezone,
+		&i.TextReplies,
+		&i.ReplyLanguage,
+		&i.ActivePersona,
+		&i.PreferredName,
+		&i.Vibe,
+		&i.OnboardedAt,
+		&i.LastVoiceFileIds,
+		&i.VoiceCaptions,
+		&i.TranscriptEcho,
+		&i.AutoRecharge,
+		&i.AutoRechargeLimit,
+		&i.DailyGreetings,
+		&i.ContentIntensity,
+		&i.SpeechRate,
+		&i.SpeechPitch,
+		&i.SpokenLanguage,
+		&i.Created,
+		&i.Updated,
+	)
+	return i, err
+}
+
+const setTranscriptEchoByTelegramUserId = `-- name: SetTranscriptEchoByTelegramUserId :one
+INSERT INTO user_preferences (user_id, transcript_echo)
+SELECT user_id, $1 FROM user_info WHERE telegram_user_id = $2
+ON CONFLICT (user_id) DO UPDATE
+SET transcript_echo = EXCLUDED.transcript_echo, updated = CURRENT_TIMESTAMP
+RETURNING id, user_id, broadcast_opt_out, reengage_opt_out, dnd_start, dnd_end, timezone, text_replies, reply_language, active_persona, preferred_name, vibe, onboarded_at, last_voice_file_ids, voice_captions, transcript_echo, auto_recharge, auto_recharge_limit, daily_greetings, content_intensity, speech_rate, speech_pitch, spoken_language, created, updated
+`
+
+type SetTranscriptEchoByTelegramUserIdParams struct {
+	TranscriptEcho bool
+	TelegramUserID int64
+}
+
+func (q *Queries) SetTranscriptEchoByTelegramUserId(ctx context.Context, arg SetTranscriptEchoByTelegramUserIdParams) (UserPreference, error) {
+	row := q.db.QueryRowContext(ctx, setTranscriptEchoByTelegramUserId, arg.TranscriptEcho, arg.TelegramUserID)
+	var i UserPreference
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.BroadcastOptOut,
+		&i.ReengageOptOut,
+		&i.DndStart,
+		&i.DndEnd,
+		&i.Timezone,
+		&i.TextReplies,
+		&i.ReplyLanguage,
+		&i.ActivePersona,
+		&i.PreferredName,
+		&i.Vibe,
+		&i.OnboardedAt,
+		&i.LastVoiceFileIds,
+		&i.VoiceCaptions,
+		&i.TranscriptEcho,
+		&i.AutoRecharge,
+		&i.AutoRechargeLimit,
+		&i.DailyGreetings,
+		&i.ContentIntensity,
+		&i.SpeechRate,
+		&i.SpeechPitch,
+		&i.SpokenLanguage,
+		&i.Created,
+		&i.Updated,
+	)
+	return i, err
+}
+
+const setUserBannedByTelegramUserId = `-- name: SetUserBannedByTelegramUserId :one
+UPDATE user_info SET banned = $1 WHERE telegram_user_id = $2 RETURNING user_id, telegram_user_id, telegram_username, telegram_first_name, telegram_last_name, banned, age_verified_at, campaign, created
+`
+
+type SetUserBannedByTelegramUserIdParams struct {
+	Banned         bool
+	TelegramUserID int64
+}
+
+func (q *Queries) SetUserBannedByTelegramUserId(ctx context.Context, arg SetUserBannedByTelegramUserIdParams) (UserInfo, error) {
+	row := q.db.QueryRowContext(ctx, setUserBannedByTelegramUserId, arg.Banned, arg.TelegramUserID)
+	var i UserInfo
+	err := row.Scan(
+		&i.UserID,
+		&i.TelegramUserID,
+		&i.TelegramUsername,
+		&i.TelegramFirstName,
+		&i.TelegramLastName,
+		&i.Banned,
+		&i.AgeVerifiedAt,
+		&i.Campaign,
+		&i.Created,
+	)
+	return i, err
+}
+
+const setUserMoodByTelegramUserId = `-- name: SetUserMoodByTelegramUserId :exec
+INSERT INTO user_moods (telegram_user_id, mood)
+VALUES ($1, $2)
+ON CONFLICT (telegram_user_id) DO UPDATE
+SET mood = EXCLUDED.mood, updated = CURRENT_TIMESTAMP
+`
+
+type SetUserMoodByTelegramUserIdParams struct {
+	TelegramUserID int64
+	Mood           string
+}
+
+func (q *Queries) SetUserMoodByTelegramUserId(ctx context.Context, arg SetUserMoodByTelegramUserIdParams) error {
+	_, err := q.db.ExecContext(ctx, setUserMoodByTelegramUserId, arg.TelegramUserID, arg.Mood)
+	return err
+}
+
+const setVibeByTelegramUserId = `-- name: SetVibeByTelegramUserId :one
+INSERT INTO user_preferences (user_id, vibe)
+SELECT user_id, $1 FROM user_info WHERE telegram_user_id = $2
+ON CONFLICT (user_id) DO UPDATE
+SET vibe = EXCLUDED.vibe, updated = CURRENT_TIMESTAMP
+RETURNING id, user_id, broadcast_opt_out, reengage_opt_out, dnd_start, dnd_end, timezone, text_replies, reply_language, active_persona, preferred_name, vibe, onboarded_at, last_voice_file_ids, voice_captions, transcript_echo, auto_recharge, auto_recharge_limit, daily_greetings, content_intensity, speech_rate, speech_pitch, spoken_language, created, updated
+`
+
+type SetVibeByTelegramUserIdParams struct {
+	Vibe           string
+	TelegramUserID int64
+}
+
+func (q *Queries) SetVibeByTelegramUserId(ctx context.Context, arg SetVibeByTelegramUserIdParams) (UserPreference, error) {
+	row := q.db.QueryRowContext(ctx, setVibeByTelegramUserId, arg.Vibe, arg.TelegramUserID)
+	var i UserPreference
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.BroadcastOptOut,
+		&i.ReengageOptOut,
+		&i.DndStart,
+		&i.DndEnd,
+		&i.Timezone,
+		&i.TextReplies,
+		&i.ReplyLanguage,
+		&i.ActivePersona,
+		&i.PreferredName,
+		&i.Vibe,
+		&i.OnboardedAt,
+		&i.LastVoiceFileIds,
+		&i.VoiceCaptions,
+		&i.TranscriptEcho,
+		&i.AutoRecharge,
+		&i.AutoRechargeLimit,
+		&i.DailyGreetings,
+		&i.ContentIntensity,
+		&i.SpeechRate,
+		&i.SpeechPitch,
+		&i.SpokenLanguage,
+		&i.Created,
+		&i.Updated,
+	)
+	return i, err
+}
+
+const setVoiceCaptionsByTelegramUserId = `-- name: SetVoiceCaptionsByTelegramUserId :one
+INSERT INTO user_preferences (user_id, voice_captions)
+SELECT user_id, $1 FROM user_info WHERE telegram_user_id = $2
+ON CONFLICT (user_id) DO UPDATE
+SET voice_captions = EXCLUDED.voice_captions, updated = CURRENT_TIMESTAMP
+RETURNING id, user_id, broadcast_opt_out, reengage_opt_out, dnd_start, dnd_end, timezone, text_replies, reply_language, active_persona, preferred_name, vibe, onboarded_at, last_voice_file_ids, voice_captions, transcript_echo, auto_recharge, auto_recharge_limit, daily_greetings, content_intensity, speech_rate, speech_pitch, spoken_language, created, updated
+`
+
+type SetVoiceCaptionsByTelegramUserIdParams struct {
+	VoiceCaptions  string
+	TelegramUserID int64
+}
+
+func (q *Queries) SetVoiceCaptionsByTelegramUserId(ctx context.Context, arg SetVoiceCaptionsByTelegramUserIdParams) (UserPreference, error) {
+	row := q.db.QueryRowContext(ctx, setVoiceCaptionsByTelegramUserId, arg.VoiceCaptions, arg.TelegramUserID)
+	var i UserPreference
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.BroadcastOptOut,
+		&i.ReengageOptOut,
+		&i.DndStart,
+		&i.DndEnd,
+		&i.Timezone,
+		&i.TextReplies,
+		&i.ReplyLanguage,
+		&i.ActivePersona,
+		&i.PreferredName,
+		&i.Vibe,
+		&i.OnboardedAt,
+		&i.LastVoiceFileIds,
+		&i.VoiceCaptions,
+		&i.TranscriptEcho,
+		&i.AutoRecharge,
+		&i.AutoRechargeLimit,
+		&i.DailyGreetings,
+		&i.ContentIntensity,
+		&i.SpeechRate,
+		&i.SpeechPitch,
+		&i.SpokenLanguage,
+		&i.Created,
+		&i.Updated,
+	)
+	return i, err
+}
+
+const spendUserCreditsByTelegramUserId = `-- name: SpendUserCreditsByTelegramUserId :one
+UPDATE user_credits
+SET credits_balance = credits_balance - $1,
+    purchased_credits = LEAST(purchased_credits, credits_balance - $1),
+    updated = CURRENT_TIMESTAMP
+FROM user_info
+WHERE user_credits.user_id = user_info.user_id AND user_info.telegram_user_id = $2
+  AND user_credits.credits_balance >= $1
+RETURNING user_credits.id, user_credits.user_id, user_credits.credits_balance, user_credits.last_daily_claim, user_credits.streak_days, user_credits.last_streak_date, user_credits.half_credit_owed, user_credits.purchased_credits, user_credits.last_bonus_grant, user_credits.created, user_credits.updated
+`
+
+type SpendUserCreditsByTelegramUserIdParams struct {
+	Amount         int32
+	TelegramUserID int64
+}
+
+// Takes amount credits, bonus credits first. No row comes back when the
+// user can't afford it.
+func (q *Queries) SpendUserCreditsByTelegramUserId(ctx context.Context, arg SpendUserCreditsByTelegramUserIdParams) (UserCredit, error) {
+	row := q.db.QueryRowContext(ctx, spendUserCreditsByTelegramUserId, arg.Amount, arg.TelegramUserID)
+	var i UserCredit
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.CreditsBalance,
+		&i.LastDailyClaim,
+		&i.StreakDays,
+		&i.LastStreakDate,
+		&i.HalfCreditOwed,
+		&i.PurchasedCredits,
+		&i.LastBonusGrant,
+		&i.Created,
+		&i.Updated,
+	)
+	return i, err
+}
+
+const startCustomPersona = `-- name: StartCustomPersona :exec
+
+INSERT INTO custom_personas (telegram_user_id) VALUES ($1)
+ON CONFLICT (telegram_user_id) DO UPDATE
+SET name = '', city = '', traits = '', language_mix = '', voice = '', completed_at = NULL, updated = CURRENT_TIMESTAMP
+`
+
+// ------------------ Custom Persona Queries --------------------
+// Starts a fresh draft, replacing any earlier character
+func (q *Queries) StartCustomPersona(ctx context.Context, telegramUserID int64) error {
+	_, err := q.db.ExecContext(ctx, startCustomPersona, telegramUserID)
+	return err
+}
+
+const updateConversationMessages = `-- name: UpdateConversationMessages :one
+UPDATE conversations 
+SET messages = $2, updated = CURRENT_TIMESTAMP 
+WHERE id = $1 
+RETURNING id, telegram_user_id, persona, tts_voice, messages, summary, summarized_messages, created, updated
+`
+
+type UpdateConversationMessagesParams struct {
+	ID       int64
+	Messages json.RawMessage
+}
+
+func (q *Queries) UpdateConversationMessages(ctx context.Context, arg UpdateConversationMessagesParams) (Conversation, error) {
+	row := q.db.QueryRowContext(ctx, updateConversationMessages, arg.ID, arg.Messages)
+	var i Conversation
+	err := row.Scan(
+		&i.ID,
+		&i.TelegramUserID,
+		&i.Persona,
+		&i.TtsVoice,
+		&i.Messages,
+		&i.Summary,
+		&i.SummarizedMessages,
+		&i.Created,
+		&i.Updated,
+	)
+	return i, err
+}
+
+const updateConversationSummary = `-- name: UpdateConversationSummary :execrows
+UPDATE conversations
+SET summary = $1, summarized_messages = $2
+WHERE id = $3 AND jsonb_array_length(messages) >= $2
+  AND summarized_messages = $4
+`
+
+type UpdateConversationSummaryParams struct {
+	Summary                    string
+	SummarizedMessages         int32
+	ID                         int64
+	PreviousSummarizedMessages int32
+}
+
+// Leaves updated alone, which tracks the user's last exchange. A history
+// cleared while the summary was being written is left alone, and so is one
+// another summary got to first.
+func (q *Queries) UpdateConversationSummary(ctx context.Context, arg UpdateConversationSummaryParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, updateConversationSummary,
+		arg.Summary,
+		arg.SummarizedMessages,
+		arg.ID,
+		arg.PreviousSummarizedMessages,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const updateMemoryFact = `-- name: UpdateMemoryFact :one
+UPDATE memories SET fact = $1, updated = CURRENT_TIMESTAMP
+WHERE id = $2 AND user_id = (SELECT user_id FROM user_info WHERE telegram_user_id = $3)
+RETURNING id, user_id, fact, created, updated
+`
+
+type UpdateMemoryFactParams struct {
+	Fact           string
+	ID             int64
+	TelegramUserID int64
+}
+
+func (q *Queries) UpdateMemoryFact(ctx context.Context, arg UpdateMemoryFactParams) (Memory, error) {
+	row := q.db.QueryRowContext(ctx, updateMemoryFact, arg.Fact, arg.ID, arg.TelegramUserID)
+	var i Memory
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Fact,
+		&i.Created,
+		&i.Updated,
+	)
+	return i, err
+}
+
+const updatePracticeSessionMessages = `-- name: UpdatePracticeSessionMessages :exec
+UPDATE practice_sessions SET messages = $1, updated = CURRENT_TIMESTAMP
+WHERE id = $2
+`
+
+type UpdatePracticeSessionMessagesParams struct {
+	Messages json.RawMessage
+	ID       int64
+}
+
+func (q *Queries) UpdatePracticeSessionMessages(ctx context.Context, arg UpdatePracticeSessionMessagesParams) error {
+	_, err := q.db.ExecContext(ctx, updatePracticeSessionMessages, arg.Messages, arg.ID)
+	return err
+}
+
+const upsertStripeCustomerByTelegramUserId = `-- name: UpsertStripeCustomerByTelegramUserId :exec
+
+INSERT INTO stripe_customers (telegram_user_id, customer_id, payment_method_id)
+VALUES ($1, $2, $3)
+ON CONFLICT (telegram_user_id) DO UPDATE
+SET customer_id = EXCLUDED.customer_id, payment_method_id = EXCLUDED.payment_method_id, updated = CURRENT_TIMESTAMP
+`
+
+type UpsertStripeCustomerByTelegramUserIdParams struct {
+	TelegramUserID  int64
+	CustomerID      string
+	PaymentMethodID string
+}
+
+// ------------------ Auto Recharge Queries --------------------
+func (q *Queries) UpsertStripeCustomerByTelegramUserId(ctx context.Context, arg UpsertStripeCustomerByTelegramUserIdParams) error {
+	_, err := q.db.ExecContext(ctx, upsertStripeCustomerByTelegramUserId, arg.TelegramUserID, arg.CustomerID, arg.PaymentMethodID)
+	return err
+}
+
+const upsertSubscriptionByTelegramUserId = `-- name: UpsertSubscriptionByTelegramUserId :one
+
+INSERT INTO subscriptions (user_id, status, telegram_payment_charge_id, expires_at)
+SELECT user_id, 'active', $1, $2 FROM user_info WHERE telegram_user_id = $3
+ON CONFLICT (user_id) DO UPDATE
+SET status = 'active', telegram_payment_charge_id = EXCLUDED.telegram_payment_charge_id, expires_at = EXCLUDED.expires_at, updated = CURRENT_TIMESTAMP
+RETURNING id, user_id, status, telegram_payment_charge_id, expires_at, created, updated
+`
+
+type UpsertSubscriptionByTelegramUserIdParams struct {
+	TelegramPaymentChargeID string
+	ExpiresAt               time.Time
+	TelegramUserID          int64
+}
+
+// ------------------ Subscription Queries --------------------
+func (q *Queries) UpsertSubscriptionByTelegramUserId(ctx context.Context, arg UpsertSubscriptionByTelegramUserIdParams) (Subscription, error) {
+	row := q.db.QueryRowContext(ctx, upsertSubscriptionByTelegramUserId, arg.TelegramPaymentChargeID, arg.ExpiresAt, arg.TelegramUserID)
+	var i Subscription
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Status,
+		&i.TelegramPaymentChargeID,
+		&i.ExpiresAt,
+		&i.Created,
+		&i.Updated,
+	)
+	return i, err
+}
+
+const verifyUserAgeByTelegramUserId = `-- name: VerifyUserAgeByTelegramUserId :one
+UPDATE user_info SET age_verified_at = CURRENT_TIMESTAMP WHERE telegram_user_id = $1 RETURNING user_id, telegram_user_id, telegram_username, telegram_first_name, telegram_last_name, banned, age_verified_at, campaign, created
+`
+
+func (q *Queries) VerifyUserAgeByTelegramUserId(ctx context.Context, telegramUserID int64) (UserInfo, error) {
+	row := q.db.QueryRowContext(ctx, verifyUserAgeByTelegramUserId, telegramUserID)
+	var i UserInfo
+	err := row.Scan(
+		&i.UserID,
+		&i.TelegramUserID,
+		&i.TelegramUsername,
+		&i.TelegramFirstName,
+		&i.TelegramLastName,
+		&i.Banned,
+		&i.AgeVerifiedAt,
+		&i.Campaign,
+		&i.Created,
+	)
+	return i, err
+}
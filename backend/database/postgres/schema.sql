@@ -1,3 +1,6 @@
+-- pgvector, for recalling past moments by meaning
+CREATE EXTENSION IF NOT EXISTS vector;
+
 DROP TABLE IF EXISTS user_info CASCADE;
 CREATE TABLE user_info (
   user_id BIGSERIAL PRIMARY KEY NOT NULL,
@@ -5,6 +8,11 @@ CREATE TABLE user_info (
   telegram_username TEXT,
   telegram_first_name TEXT,
   telegram_last_name TEXT,
+  banned BOOLEAN NOT NULL DEFAULT FALSE,
+  -- When the user confirmed they are 18+; NULL until they do
+  age_verified_at TIMESTAMP,
+  -- First-touch /start campaign code the user signed up through, if any
+  campaign TEXT,
   created TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
 );
 
@@ -13,20 +21,436 @@ CREATE TABLE user_credits (
   id BIGSERIAL PRIMARY KEY NOT NULL,
   user_id BIGINT REFERENCES user_info (user_id) ON DELETE CASCADE UNIQUE NOT NULL,
   credits_balance INT NOT NULL DEFAULT 20,
+  last_daily_claim TIMESTAMP,
+  -- Consecutive days (in the user's timezone) with at least one reply
+  streak_days INT NOT NULL DEFAULT 0,
+  last_streak_date DATE,
+  -- Regenerated replies cost half a credit; the second half is charged next time
+  half_credit_owed BOOLEAN NOT NULL DEFAULT FALSE,
+  -- The part of credits_balance that was bought; the rest is bonus credits,
+  -- which are spent first and expire some time after the last bonus grant
+  purchased_credits INT NOT NULL DEFAULT 0,
+  last_bonus_grant TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
   created TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
   updated TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
 );
 CREATE INDEX idx_user_credits_user_id ON user_credits(user_id);
 
+DROP TABLE IF EXISTS user_preferences CASCADE;
+CREATE TABLE user_preferences (
+  id BIGSERIAL PRIMARY KEY NOT NULL,
+  user_id BIGINT REFERENCES user_info (user_id) ON DELETE CASCADE UNIQUE NOT NULL,
+  broadcast_opt_out BOOLEAN NOT NULL DEFAULT FALSE,
+  reengage_opt_out BOOLEAN NOT NULL DEFAULT FALSE,
+  -- Quiet hours as minutes past midnight in the user's timezone; NULL means off
+  dnd_start INT,
+  dnd_end INT,
+  timezone TEXT NOT NULL DEFAULT 'Asia/Kolkata',
+  text_replies BOOLEAN NOT NULL DEFAULT FALSE,
+  reply_language TEXT NOT NULL DEFAULT '',
+  active_persona TEXT NOT NULL DEFAULT 'gulabo',
+  preferred_name TEXT,
+  vibe TEXT NOT NULL DEFAULT '',
+  -- NULL until the user finishes onboarding
+  onboarded_at TIMESTAMP,
+  last_voice_file_ids JSONB NOT NULL DEFAULT '[]',
+  voice_captions TEXT NOT NULL DEFAULT '',
+  transcript_echo BOOLEAN NOT NULL DEFAULT FALSE,
+  -- Recharge package bought automatically when credits run out; empty means off
+  auto_recharge TEXT NOT NULL DEFAULT '',
+  -- Automatic top-ups allowed per 30 days
+  auto_recharge_limit INT NOT NULL DEFAULT 3,
+  -- 'morning', 'night' or 'both'; empty means off
+  daily_greetings TEXT NOT NULL DEFAULT '',
+  -- 'safe', 'flirty' or 'explicit'; explicit only counts once age is verified
+  content_intensity TEXT NOT NULL DEFAULT 'flirty',
+  -- Voice note pace and pitch presets: 'slower', 'normal' or 'faster', and
+  -- 'lower', 'normal' or 'higher'
+  speech_rate TEXT NOT NULL DEFAULT 'normal',
+  speech_pitch TEXT NOT NULL DEFAULT 'normal',
+  -- Reply language detected in the latest voice note, used while
+  -- reply_language is unset
+  spoken_language TEXT NOT NULL DEFAULT '',
+  created TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+DROP TABLE IF EXISTS subscriptions CASCADE;
+CREATE TABLE subscriptions (
+  id BIGSERIAL PRIMARY KEY NOT NULL,
+  user_id BIGINT REFERENCES user_info (user_id) ON DELETE CASCADE UNIQUE NOT NULL,
+  status TEXT NOT NULL DEFAULT 'active',
+  telegram_payment_charge_id TEXT NOT NULL,
+  expires_at TIMESTAMP NOT NULL,
+  created TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+DROP TABLE IF EXISTS referral_codes CASCADE;
+CREATE TABLE referral_codes (
+  id BIGSERIAL PRIMARY KEY NOT NULL,
+  user_id BIGINT REFERENCES user_info (user_id) ON DELETE CASCADE UNIQUE NOT NULL,
+  code TEXT UNIQUE NOT NULL,
+  created TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+DROP TABLE IF EXISTS referrals CASCADE;
+CREATE TABLE referrals (
+  id BIGSERIAL PRIMARY KEY NOT NULL,
+  referrer_user_id BIGINT REFERENCES user_info (user_id) ON DELETE CASCADE NOT NULL,
+  referred_user_id BIGINT REFERENCES user_info (user_id) ON DELETE CASCADE UNIQUE NOT NULL,
+  created TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX idx_referrals_referrer_user_id ON referrals(referrer_user_id);
+
 -- Simplified conversations with JSONB message history
 DROP TABLE IF EXISTS conversations CASCADE;
 CREATE TABLE conversations (
   id BIGSERIAL PRIMARY KEY NOT NULL,
-  telegram_user_id BIGINT UNIQUE REFERENCES user_info (telegram_user_id) ON DELETE CASCADE NOT NULL,
+  telegram_user_id BIGINT REFERENCES user_info (telegram_user_id) ON DELETE CASCADE NOT NULL,
+  persona TEXT NOT NULL DEFAULT 'gulabo',
+  tts_voice TEXT NOT NULL DEFAULT '',
   messages JSONB NOT NULL DEFAULT '[]'::jsonb,
+  -- "Story so far" condensed from the oldest messages, and how many messages
+  -- it covers; only the messages after those are sent to the model
+  summary TEXT NOT NULL DEFAULT '',
+  summarized_messages INT NOT NULL DEFAULT 0,
   created TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
-  updated TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+  updated TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  UNIQUE (telegram_user_id, persona)
 );
 
 -- Indexes for performance
 CREATE INDEX idx_conversations_messages ON conversations USING gin (messages);
+
+-- Sessions closed with /new; the conversation row keeps going with an empty history
+DROP TABLE IF EXISTS conversation_archives CASCADE;
+CREATE TABLE conversation_archives (
+  id BIGSERIAL PRIMARY KEY NOT NULL,
+  conversation_id BIGINT REFERENCES conversations (id) ON DELETE CASCADE NOT NULL,
+  telegram_user_id BIGINT REFERENCES user_info (telegram_user_id) ON DELETE CASCADE NOT NULL,
+  persona TEXT NOT NULL,
+  messages JSONB NOT NULL,
+  archived TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_conversation_archives_conversation_id ON conversation_archives (conversation_id);
+
+DROP TABLE IF EXISTS broadcasts CASCADE;
+CREATE TABLE broadcasts (
+  id BIGSERIAL PRIMARY KEY NOT NULL,
+  admin_telegram_user_id BIGINT NOT NULL,
+  text TEXT NOT NULL DEFAULT '',
+  voice_file_id TEXT,
+  created TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  completed TIMESTAMP
+);
+
+DROP TABLE IF EXISTS broadcast_deliveries CASCADE;
+CREATE TABLE broadcast_deliveries (
+  id BIGSERIAL PRIMARY KEY NOT NULL,
+  broadcast_id BIGINT REFERENCES broadcasts (id) ON DELETE CASCADE NOT NULL,
+  telegram_user_id BIGINT NOT NULL,
+  status TEXT NOT NULL,
+  error TEXT,
+  created TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX idx_broadcast_deliveries_broadcast_id ON broadcast_deliveries(broadcast_id);
+
+DROP TABLE IF EXISTS payments CASCADE;
+CREATE TABLE payments (
+  id BIGSERIAL PRIMARY KEY NOT NULL,
+  user_id BIGINT REFERENCES user_info (user_id) ON DELETE CASCADE NOT NULL,
+  provider TEXT NOT NULL,
+  payload TEXT NOT NULL,
+  credits INT NOT NULL DEFAULT 0,
+  amount INT NOT NULL,
+  currency TEXT NOT NULL,
+  -- The provider's charge: Telegram's payment charge ID for Stars, the
+  -- checkout session or PaymentIntent ID for Stripe. Unique so a redelivered
+  -- update or webhook is only fulfilled once
+  charge_id TEXT UNIQUE,
+  created TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX idx_payments_created ON payments(created);
+
+-- One row per bot reply, for operational metrics
+DROP TABLE IF EXISTS responses CASCADE;
+CREATE TABLE responses (
+  id BIGSERIAL PRIMARY KEY NOT NULL,
+  telegram_user_id BIGINT NOT NULL,
+  message_type TEXT NOT NULL,
+  latency_ms INT NOT NULL,
+  tts_failed BOOLEAN NOT NULL DEFAULT FALSE,
+  created TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX idx_responses_created ON responses(created);
+
+-- Bot-initiated "missing you" messages, used for frequency caps
+DROP TABLE IF EXISTS reengagements CASCADE;
+CREATE TABLE reengagements (
+  id BIGSERIAL PRIMARY KEY NOT NULL,
+  user_id BIGINT REFERENCES user_info (user_id) ON DELETE CASCADE NOT NULL,
+  created TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX idx_reengagements_user_id ON reengagements(user_id, created);
+
+DROP TABLE IF EXISTS feedback CASCADE;
+CREATE TABLE feedback (
+  id BIGSERIAL PRIMARY KEY NOT NULL,
+  user_id BIGINT REFERENCES user_info (user_id) ON DELETE CASCADE NOT NULL,
+  conversation_id BIGINT REFERENCES conversations (id) ON DELETE SET NULL,
+  rating INT CHECK (rating BETWEEN 1 AND 5),
+  text TEXT NOT NULL,
+  -- The last few turns of the conversation when the feedback was left
+  recent_messages JSONB NOT NULL DEFAULT '[]'::jsonb,
+  created TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Long-term facts about the user, extracted from their messages
+DROP TABLE IF EXISTS memories CASCADE;
+CREATE TABLE memories (
+  id BIGSERIAL PRIMARY KEY NOT NULL,
+  user_id BIGINT REFERENCES user_info (user_id) ON DELETE CASCADE NOT NULL,
+  fact TEXT NOT NULL,
+  created TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX idx_memories_user_id ON memories(user_id);
+
+-- Exchanges from past conversations that the model no longer sees, embedded
+-- so the ones about whatever the user brings up can be recalled
+DROP TABLE IF EXISTS recall_moments CASCADE;
+CREATE TABLE recall_moments (
+  id BIGSERIAL PRIMARY KEY NOT NULL,
+  conversation_id BIGINT REFERENCES conversations (id) ON DELETE CASCADE NOT NULL,
+  content TEXT NOT NULL,
+  -- Vectors from different embedding models can't be compared
+  model TEXT NOT NULL,
+  embedding vector(1024) NOT NULL,
+  -- When the exchange happened; NULL for messages stored without a timestamp
+  said TIMESTAMP,
+  created TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX idx_recall_moments_conversation_id ON recall_moments(conversation_id);
+CREATE INDEX idx_recall_moments_embedding ON recall_moments USING hnsw (embedding vector_cosine_ops);
+
+-- Marketing codes, each redeemable once per user for bonus credits
+DROP TABLE IF EXISTS promo_codes CASCADE;
+CREATE TABLE promo_codes (
+  id BIGSERIAL PRIMARY KEY NOT NULL,
+  code TEXT UNIQUE NOT NULL,
+  credits INT NOT NULL CHECK (credits > 0),
+  -- NULL means unlimited uses / never expires
+  max_uses INT,
+  uses INT NOT NULL DEFAULT 0,
+  expires TIMESTAMP,
+  admin_telegram_user_id BIGINT NOT NULL,
+  created TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+DROP TABLE IF EXISTS promo_redemptions CASCADE;
+CREATE TABLE promo_redemptions (
+  id BIGSERIAL PRIMARY KEY NOT NULL,
+  promo_code_id BIGINT REFERENCES promo_codes (id) ON DELETE CASCADE NOT NULL,
+  user_id BIGINT REFERENCES user_info (user_id) ON DELETE CASCADE NOT NULL,
+  created TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  UNIQUE (promo_code_id, user_id)
+);
+
+-- Conversation practice role-plays, kept apart from the companion chat
+DROP TABLE IF EXISTS practice_sessions CASCADE;
+CREATE TABLE practice_sessions (
+  id BIGSERIAL PRIMARY KEY NOT NULL,
+  user_id BIGINT REFERENCES user_info (user_id) ON DELETE CASCADE NOT NULL,
+  scenario JSONB NOT NULL,
+  messages JSONB NOT NULL DEFAULT '[]',
+  -- NULL while the session is in progress
+  ended TIMESTAMP,
+  created TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE UNIQUE INDEX idx_practice_sessions_active ON practice_sessions(user_id) WHERE ended IS NULL;
+
+-- Users muted automatically for spam or abuse, for admins to review
+DROP TABLE IF EXISTS abuse_flags CASCADE;
+CREATE TABLE abuse_flags (
+  id BIGSERIAL PRIMARY KEY NOT NULL,
+  user_id BIGINT REFERENCES user_info (user_id) ON DELETE CASCADE NOT NULL,
+  reason TEXT NOT NULL,
+  message TEXT NOT NULL DEFAULT '',
+  muted_until TIMESTAMP NOT NULL,
+  created TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX idx_abuse_flags_created ON abuse_flags(created);
+
+-- Append-only trail of every Telegram Stars checkout step, for billing disputes
+DROP TABLE IF EXISTS stars_ledger CASCADE;
+CREATE TABLE stars_ledger (
+  id BIGSERIAL PRIMARY KEY NOT NULL,
+  telegram_user_id BIGINT NOT NULL,
+  event TEXT NOT NULL,
+  invoice_payload TEXT NOT NULL,
+  telegram_payment_charge_id TEXT NOT NULL DEFAULT '',
+  amount INT NOT NULL DEFAULT 0,
+  credits INT NOT NULL DEFAULT 0,
+  flagged_at TIMESTAMP,
+  created TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX idx_stars_ledger_charge_id ON stars_ledger(telegram_payment_charge_id);
+CREATE INDEX idx_stars_ledger_created ON stars_ledger(created);
+
+-- Locked photos and videos users unlock with Stars, outside the credit system
+DROP TABLE IF EXISTS premium_media CASCADE;
+CREATE TABLE premium_media (
+  id BIGSERIAL PRIMARY KEY NOT NULL,
+  kind TEXT NOT NULL,
+  file_id TEXT NOT NULL,
+  caption TEXT NOT NULL DEFAULT '',
+  price_stars INT NOT NULL,
+  admin_telegram_user_id BIGINT NOT NULL,
+  created TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Paid media unlocks, synced from the bot's Star transactions
+DROP TABLE IF EXISTS paid_media_purchases CASCADE;
+CREATE TABLE paid_media_purchases (
+  id BIGSERIAL PRIMARY KEY NOT NULL,
+  transaction_id TEXT UNIQUE NOT NULL,
+  telegram_user_id BIGINT NOT NULL,
+  premium_media_id BIGINT REFERENCES premium_media (id) ON DELETE SET NULL,
+  stars INT NOT NULL,
+  purchased TIMESTAMP NOT NULL,
+  created TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX idx_paid_media_purchases_purchased ON paid_media_purchases(purchased);
+CREATE INDEX idx_paid_media_purchases_telegram_user_id ON paid_media_purchases(telegram_user_id);
+
+-- Cards saved at checkout by users with auto-recharge on, for off-session top-ups
+DROP TABLE IF EXISTS stripe_customers CASCADE;
+CREATE TABLE stripe_customers (
+  telegram_user_id BIGINT PRIMARY KEY REFERENCES user_info (telegram_user_id) ON DELETE CASCADE NOT NULL,
+  customer_id TEXT NOT NULL,
+  payment_method_id TEXT NOT NULL,
+  created TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Every automatic top-up attempt, for the per-user cap
+DROP TABLE IF EXISTS auto_recharges CASCADE;
+CREATE TABLE auto_recharges (
+  id BIGSERIAL PRIMARY KEY NOT NULL,
+  telegram_user_id BIGINT REFERENCES user_info (telegram_user_id) ON DELETE CASCADE NOT NULL,
+  payload TEXT NOT NULL,
+  -- 'card' charges the saved card, 'stars' sends an invoice
+  method TEXT NOT NULL,
+  -- 'charged', 'invoiced' or 'failed'
+  status TEXT NOT NULL,
+  created TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX idx_auto_recharges_telegram_user_id ON auto_recharges(telegram_user_id, created);
+
+-- Gulabo's mood with each user, carried from one message to the next
+DROP TABLE IF EXISTS user_moods CASCADE;
+CREATE TABLE user_moods (
+  telegram_user_id BIGINT PRIMARY KEY REFERENCES user_info (telegram_user_id) ON DELETE CASCADE NOT NULL,
+  -- 'playful', 'annoyed', 'missing_you' or 'sleepy'
+  mood TEXT NOT NULL DEFAULT 'playful',
+  -- When the user last messaged, which is also when the mood last changed
+  updated TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- How close Gulabo and each user have grown, from chatting and purchases
+DROP TABLE IF EXISTS relationships CASCADE;
+CREATE TABLE relationships (
+  telegram_user_id BIGINT PRIMARY KEY REFERENCES user_info (telegram_user_id) ON DELETE CASCADE NOT NULL,
+  affection INT NOT NULL DEFAULT 0,
+  created TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Virtual gifts users can buy Gulabo with credits
+DROP TABLE IF EXISTS gifts CASCADE;
+CREATE TABLE gifts (
+  -- Short slug, also used in callback data
+  id TEXT PRIMARY KEY NOT NULL,
+  name TEXT NOT NULL,
+  emoji TEXT NOT NULL,
+  price_credits INT NOT NULL,
+  affection INT NOT NULL,
+  -- How she reacts, added to the system prompt for her thank-you
+  reaction TEXT NOT NULL,
+  active BOOLEAN NOT NULL DEFAULT TRUE,
+  created TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT INTO gifts (id, name, emoji, price_credits, affection, reaction) VALUES
+  ('rose', 'Rose', '🌹', 5, 5, 'Your lover just gave you a red rose. Blush, smell it, and tell them it''s sweet of them, with a little tease.'),
+  ('chocolates', 'Chocolates', '🍫', 10, 12, 'Your lover just gave you a box of chocolates. Be delighted and greedy about it, and offer to share one with them, maybe feeding it to them.'),
+  ('teddy', 'Teddy bear', '🧸', 20, 25, 'Your lover just gave you a teddy bear. Squeal, hug it tight, give it a cute name after them, and say you''ll sleep holding it.'),
+  ('perfume', 'Perfume', '💐', 35, 45, 'Your lover just gave you perfume. Spray some on, describe how it smells on you, and ask if they want to come closer and find out.'),
+  ('necklace', 'Necklace', '📿', 60, 80, 'Your lover just gave you a necklace. Be touched, put it on right away, describe how it looks on you and say you''ll never take it off.'),
+  ('ring', 'Diamond ring', '💍', 120, 170, 'Your lover just gave you a diamond ring. Be overwhelmed and emotional, gush about how it sparkles, and playfully ask if this means what you think it means.');
+
+-- Every gift a user has bought
+DROP TABLE IF EXISTS gift_purchases CASCADE;
+CREATE TABLE gift_purchases (
+  id BIGSERIAL PRIMARY KEY NOT NULL,
+  telegram_user_id BIGINT REFERENCES user_info (telegram_user_id) ON DELETE CASCADE NOT NULL,
+  gift_id TEXT REFERENCES gifts (id) NOT NULL,
+  -- The price paid, which can change later in the catalog
+  credits INT NOT NULL,
+  created TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX idx_gift_purchases_telegram_user_id ON gift_purchases(telegram_user_id);
+
+-- Good-morning and good-night notes sent, one of each per user per local day
+DROP TABLE IF EXISTS daily_greetings CASCADE;
+CREATE TABLE daily_greetings (
+  id BIGSERIAL PRIMARY KEY NOT NULL,
+  telegram_user_id BIGINT REFERENCES user_info (telegram_user_id) ON DELETE CASCADE NOT NULL,
+  -- 'morning' or 'night'
+  kind TEXT NOT NULL,
+  -- The date in the user's timezone
+  local_date DATE NOT NULL,
+  created TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  UNIQUE (telegram_user_id, kind, local_date)
+);
+
+-- The character each user designs with /create, chatted with as persona 'custom'
+DROP TABLE IF EXISTS custom_personas CASCADE;
+CREATE TABLE custom_personas (
+  telegram_user_id BIGINT PRIMARY KEY REFERENCES user_info (telegram_user_id) ON DELETE CASCADE NOT NULL,
+  name TEXT NOT NULL DEFAULT '',
+  city TEXT NOT NULL DEFAULT '',
+  -- Comma-separated trait IDs
+  traits TEXT NOT NULL DEFAULT '',
+  language_mix TEXT NOT NULL DEFAULT '',
+  voice TEXT NOT NULL DEFAULT '',
+  -- NULL while the user is still going through /create
+  completed_at TIMESTAMP,
+  created TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Tokens, characters and estimated cost of every LLM, TTS, embedding and image call
+DROP TABLE IF EXISTS usage_records CASCADE;
+CREATE TABLE usage_records (
+  id BIGSERIAL PRIMARY KEY NOT NULL,
+  telegram_user_id BIGINT REFERENCES user_info (telegram_user_id) ON DELETE CASCADE NOT NULL,
+  provider TEXT NOT NULL,
+  -- 'chat', 'tts', 'embedding' or 'image'
+  kind TEXT NOT NULL,
+  model TEXT NOT NULL,
+  input_tokens INT NOT NULL DEFAULT 0,
+  output_tokens INT NOT NULL DEFAULT 0,
+  -- Text synthesized, for TTS priced by character
+  characters INT NOT NULL DEFAULT 0,
+  -- Estimated from list prices, in millionths of a US dollar
+  cost_micros BIGINT NOT NULL DEFAULT 0,
+  -- The user's plan when the call was made: 'free' or 'premium'
+  tier TEXT NOT NULL DEFAULT 'free',
+  created TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX idx_usage_records_telegram_user_id ON usage_records(telegram_user_id, created);
+CREATE INDEX idx_usage_records_created ON usage_records(created);
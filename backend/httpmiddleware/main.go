@@ -1,6 +1,7 @@
 package httpmiddleware
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"net/http"
@@ -11,10 +12,26 @@ type HttpRequestStruct struct {
 	Url     string
 	Body    io.Reader
 	Headers map[string]string
+	// Context cancels the request when it's done; nil never does
+	Context context.Context
+}
+
+// StatusError is a response with a status code other than 200, 201 or 202.
+type StatusError struct {
+	StatusCode int
+	Body       []byte
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("Request failed: %d %s", e.StatusCode, e.Body)
 }
 
 func HttpRequest(args HttpRequestStruct) ([]byte, error) {
-	req, err := http.NewRequest(args.Method, args.Url, args.Body)
+	ctx := args.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	req, err := http.NewRequestWithContext(ctx, args.Method, args.Url, args.Body)
 	if err != nil {
 		return nil, fmt.Errorf("Failed to create request: " + err.Error())
 	}
@@ -37,7 +54,7 @@ func HttpRequest(args HttpRequestStruct) ([]byte, error) {
 	// Error out if response code is not 200 or 202.
 	// But what if the response code is okay but not equal to 200 or 202?
 	if res.StatusCode != http.StatusOK && res.StatusCode != http.StatusAccepted && res.StatusCode != http.StatusCreated {
-		return nil, fmt.Errorf("Request failed: %d %s", res.StatusCode, responseBody)
+		return nil, &StatusError{StatusCode: res.StatusCode, Body: responseBody}
 	}
 
 	if err != nil {
@@ -2,6 +2,10 @@ package logger
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"unicode/utf8"
 
 	"github.com/hyperdxio/opentelemetry-go/otelzap"
 	sdk "github.com/hyperdxio/opentelemetry-logs-go/sdk/logs"
@@ -16,6 +20,8 @@ type LoggerConnectProps struct {
 
 type LogMiddleware struct {
 	logger *zap.Logger
+	// logContent logs what users and models say in full instead of redacted
+	logContent bool
 }
 
 func Connect(args LoggerConnectProps) *LogMiddleware {
@@ -29,7 +35,7 @@ func Connect(args LoggerConnectProps) *LogMiddleware {
 		logger, _ = zap.NewDevelopment()
 	}
 
-	return &LogMiddleware{logger: logger}
+	return &LogMiddleware{logger: logger, logContent: os.Getenv("LOG_CONTENT") == "true"}
 }
 
 func (l *LogMiddleware) Logger(ctx context.Context) *zap.Logger {
@@ -43,3 +49,23 @@ func (l *LogMiddleware) Logger(ctx context.Context) *zap.Logger {
 		zap.String("span_id", spanContext.SpanID().String()),
 	)
 }
+
+// Content is a field for something a user or model said: a message, a
+// transcript, a reply or a prompt built from them. It's logged as its length
+// and the start of its SHA-256, which is enough to tell whether two log lines
+// are about the same text without the text ending up in the logs.
+// LOG_CONTENT=true logs it in full, for development.
+func (l *LogMiddleware) Content(key string, text string) zap.Field {
+	if l.logContent {
+		return zap.String(key, text)
+	}
+	return redacted(key, text)
+}
+
+func redacted(key string, text string) zap.Field {
+	sum := sha256.Sum256([]byte(text))
+	return zap.Dict(key,
+		zap.Int("length", utf8.RuneCountInString(text)),
+		zap.String("sha256", hex.EncodeToString(sum[:6])),
+	)
+}
@@ -0,0 +1,32 @@
+package logger
+
+import (
+	"testing"
+
+	"go.uber.org/zap/zapcore"
+)
+
+func TestContent(t *testing.T) {
+	encode := func(field zapcore.Field) any {
+		enc := zapcore.NewMapObjectEncoder()
+		field.AddTo(enc)
+		return enc.Fields["text"]
+	}
+
+	redacting := &LogMiddleware{}
+	got, ok := encode(redacting.Content("text", "main tumse pyaar karta hoon")).(map[string]any)
+	if !ok {
+		t.Fatalf("redacted field = %#v, want an object", got)
+	}
+	if hash, _ := got["sha256"].(string); got["length"] != int64(27) || len(hash) != 12 {
+		t.Errorf("redacted field = %v, want the length and a short hash", got)
+	}
+	if again := encode(redacting.Content("text", "main tumse pyaar karta hoon")); again.(map[string]any)["sha256"] != got["sha256"] {
+		t.Error("the same text should hash the same")
+	}
+
+	debugging := &LogMiddleware{logContent: true}
+	if got := encode(debugging.Content("text", "hi")); got != "hi" {
+		t.Errorf("LOG_CONTENT field = %v, want the text", got)
+	}
+}
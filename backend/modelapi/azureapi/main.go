@@ -0,0 +1,196 @@
+package azureapi
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"gulabodev/httpmiddleware"
+	"gulabodev/logger"
+	"gulabodev/modelapi"
+	"gulabodev/retry"
+	"os"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+	"golang.org/x/sync/semaphore"
+)
+
+const (
+	// Azure bills every neural voice at the same per-character rate
+	ttsModel = "azure-neural"
+
+	HINDI_WOMAN   = "hi-IN-SwaraNeural"
+	PUNJABI_WOMAN = "pa-IN-VaaniNeural"
+)
+
+// retryPolicy spaces out retries of a line that failed to synthesize. A
+// voice note that takes longer than the budget is better sent as text.
+var retryPolicy = retry.Policy{
+	Attempts:  3,
+	BaseDelay: time.Second,
+	Budget:    20 * time.Second,
+}
+
+type AzureConnectProps struct {
+	Logger *logger.LogMiddleware
+}
+
+// Azure synthesizes speech with Azure AI Speech, whose hi-IN and pa-IN neural
+// voices read Devanagari and Gurmukhi natively.
+type Azure struct {
+	logger    *logger.LogMiddleware
+	semaphore *semaphore.Weighted
+}
+
+func Connect(ctx context.Context, args AzureConnectProps) *Azure {
+	tracer := otel.Tracer("azureapi/Connect")
+	ctx, span := tracer.Start(ctx, "Connect")
+	defer span.End()
+
+	maxWorkers := 10
+	sem := semaphore.NewWeighted(int64(maxWorkers))
+
+	span.SetAttributes(attribute.Int("maxWorkers", maxWorkers))
+
+	return &Azure{logger: args.Logger, semaphore: sem}
+}
+
+func (a *Azure) Name() string {
+	return "azure"
+}
+
+// emotionProsody sets the pace and pitch for each modelapi emotion.
+var emotionProsody = map[string]string{
+	modelapi.EmotionSeductive:  `rate="-10%" pitch="-5%"`,
+	modelapi.EmotionSleepy:     `rate="-25%" pitch="-10%" volume="soft"`,
+	modelapi.EmotionAnnoyed:    `rate="+5%" pitch="-5%"`,
+	modelapi.EmotionExcited:    `rate="+15%" pitch="+10%"`,
+	modelapi.EmotionBreathless: `rate="+10%" pitch="+5%" volume="soft"`,
+}
+
+// userProsody is the user's own pace and pitch, as prosody attributes around
+// everything else. Nested prosody is relative, so moods still come through.
+func userProsody(rate float64, pitch float64) string {
+	var attributes []string
+	if rate > 0 && rate != 1 {
+		attributes = append(attributes, fmt.Sprintf(`rate="%+.0f%%"`, (rate-1)*100))
+	}
+	if pitch != 0 {
+		attributes = append(attributes, fmt.Sprintf(`pitch="%+gst"`, pitch))
+	}
+	return strings.Join(attributes, " ")
+}
+
+// ssml wraps a reply, with its pauses, emphasis and whispers, in the markup
+// Azure synthesizes, in the voice's locale and at the user's rate and pitch.
+func ssml(text string, voice string, emotion string, rate float64, pitch float64) string {
+	// Voice names start with their locale, like hi-IN
+	locale := voice
+	if parts := strings.SplitN(voice, "-", 3); len(parts) == 3 {
+		locale = parts[0] + "-" + parts[1]
+	}
+
+	body := modelapi.SpeechSSML(text)
+	if prosody, ok := emotionProsody[emotion]; ok {
+		body = "<prosody " + prosody + ">" + body + "</prosody>"
+	}
+	if prosody := userProsody(rate, pitch); prosody != "" {
+		body = "<prosody " + prosody + ">" + body + "</prosody>"
+	}
+	return fmt.Sprintf(`<speak version="1.0" xmlns="http://www.w3.org/2001/10/synthesis" xml:lang="%s"><voice name="%s">%s</voice></speak>`,
+		locale, voice, body)
+}
+
+func hasGurmukhi(text string) bool {
+	for _, r := range text {
+		if unicode.Is(unicode.Gurmukhi, r) {
+			return true
+		}
+	}
+	return false
+}
+
+// GenerateSpeechWithVoice synthesizes text with the given neural voice as MP3,
+// in one of the modelapi emotions or none, at a rate where 1 is normal and a
+// pitch shift in semitones.
+func (a *Azure) GenerateSpeechWithVoice(ctx context.Context, text string, voice string, emotion string, rate float64, pitch float64) ([]byte, error) {
+	tracer := otel.Tracer("azureapi/GenerateSpeech")
+	ctx, span := tracer.Start(ctx, "GenerateSpeech")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("voice", voice),
+		attribute.String("emotion", emotion),
+		attribute.Float64("rate", rate),
+		attribute.Float64("pitch", pitch),
+	)
+
+	logger := a.logger.Logger(ctx)
+
+	if err := a.semaphore.Acquire(ctx, 1); err != nil {
+		span.RecordError(err)
+		return nil, fmt.Errorf("failed to acquire semaphore: %w", err)
+	}
+	defer a.semaphore.Release(1)
+
+	apiKey, region := os.Getenv("AZURE_SPEECH_KEY"), os.Getenv("AZURE_SPEECH_REGION")
+	if apiKey == "" || region == "" {
+		return nil, fmt.Errorf("AZURE_SPEECH_KEY or AZURE_SPEECH_REGION environment variable not set")
+	}
+
+	body := ssml(text, voice, emotion, rate, pitch)
+
+	policy := retryPolicy
+	policy.OnRetry = func(attempt int, err error, delay time.Duration) {
+		logger.Warn("Failed to generate speech, retrying",
+			zap.Error(err),
+			zap.Int("attempt", attempt),
+			zap.Duration("delay", delay))
+	}
+	respBody, err := retry.Do(ctx, policy, func(ctx context.Context) ([]byte, error) {
+		return httpmiddleware.HttpRequest(httpmiddleware.HttpRequestStruct{
+			Method: "POST",
+			Url:    "https://" + region + ".tts.speech.microsoft.com/cognitiveservices/v1",
+			Body:   bytes.NewBufferString(body),
+			Headers: map[string]string{
+				"Ocp-Apim-Subscription-Key": apiKey,
+				"Content-Type":              "application/ssml+xml",
+				"X-Microsoft-OutputFormat":  "audio-24khz-48kbitrate-mono-mp3",
+				"User-Agent":                "gulabo",
+			},
+			Context: ctx,
+		})
+	})
+	if err != nil {
+		span.RecordError(err)
+		return nil, fmt.Errorf("failed to generate speech: %w", err)
+	}
+
+	modelapi.RecordUsage(ctx, modelapi.Usage{
+		Provider:   a.Name(),
+		Kind:       modelapi.UsageKindTTS,
+		Model:      ttsModel,
+		Characters: utf8.RuneCountInString(text),
+	})
+	return respBody, nil
+}
+
+// Synthesize implements modelapi.TTSProvider, defaulting to the Hindi voice.
+// Gurmukhi text always goes to the Punjabi voice, since the others can't read
+// it.
+func (a *Azure) Synthesize(ctx context.Context, request modelapi.SpeechRequest) (modelapi.Speech, error) {
+	voice := request.Voice
+	if voice == "" {
+		voice = HINDI_WOMAN
+	}
+	if hasGurmukhi(request.Text) {
+		voice = PUNJABI_WOMAN
+	}
+	audio, err := a.GenerateSpeechWithVoice(ctx, request.Text, voice, request.Emotion, request.Rate, request.Pitch)
+	return modelapi.Speech{Audio: audio, FileName: "response.mp3", RateApplied: true, PitchApplied: true}, err
+}
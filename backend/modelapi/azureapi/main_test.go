@@ -0,0 +1,56 @@
+package azureapi
+
+import (
+	"gulabodev/modelapi"
+	"testing"
+)
+
+func TestSSML(t *testing.T) {
+	got := ssml(`Tum "pagal" ho <3 & main bhi... [whispers] *sach* mein.`, HINDI_WOMAN, modelapi.EmotionSleepy, 0, 0)
+	want := `<speak version="1.0" xmlns="http://www.w3.org/2001/10/synthesis" xml:lang="hi-IN"><voice name="hi-IN-SwaraNeural">` +
+		`<prosody rate="-25%" pitch="-10%" volume="soft">Tum &#34;pagal&#34; ho &lt;3 &amp; main bhi<break time="600ms"/> ` +
+		`<prosody volume="x-soft" rate="slow"><emphasis level="strong">sach</emphasis> mein.</prosody></prosody></voice></speak>`
+	if got != want {
+		t.Errorf("got %s\nwant %s", got, want)
+	}
+}
+
+func TestUserProsody(t *testing.T) {
+	tests := []struct {
+		rate  float64
+		pitch float64
+		want  string
+	}{
+		{0, 0, ""},
+		{1, 0, ""},
+		{0.85, 0, `rate="-15%"`},
+		{1.15, 2, `rate="+15%" pitch="+2st"`},
+		{1, -2, `pitch="-2st"`},
+	}
+	for _, tt := range tests {
+		if got := userProsody(tt.rate, tt.pitch); got != tt.want {
+			t.Errorf("userProsody(%g, %g) = %q, want %q", tt.rate, tt.pitch, got, tt.want)
+		}
+	}
+
+	got := ssml("Hi", HINDI_WOMAN, "", 0.85, -2)
+	want := `<speak version="1.0" xmlns="http://www.w3.org/2001/10/synthesis" xml:lang="hi-IN"><voice name="hi-IN-SwaraNeural">` +
+		`<prosody rate="-15%" pitch="-2st">Hi</prosody></voice></speak>`
+	if got != want {
+		t.Errorf("got %s\nwant %s", got, want)
+	}
+}
+
+func TestHasGurmukhi(t *testing.T) {
+	tests := map[string]bool{
+		"ਸਤ ਸ੍ਰੀ ਅਕਾਲ": true,
+		"नमस्ते":       false,
+		"kiddan ji":    false,
+		"hi ਜੀ":        true,
+	}
+	for text, want := range tests {
+		if got := hasGurmukhi(text); got != want {
+			t.Errorf("hasGurmukhi(%q) = %v, want %v", text, got, want)
+		}
+	}
+}
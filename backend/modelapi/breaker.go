@@ -0,0 +1,303 @@
+package modelapi
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// ErrProviderUnavailable is returned in place of calling a provider whose
+// breaker is open.
+var ErrProviderUnavailable = errors.New("provider unavailable")
+
+// HealthReporter is a provider that knows whether it's worth calling.
+type HealthReporter interface {
+	Healthy() bool
+}
+
+// healthy reports whether provider is worth calling. Providers without a
+// breaker always are.
+func healthy(provider any) bool {
+	reporter, ok := provider.(HealthReporter)
+	return !ok || reporter.Healthy()
+}
+
+// Breaker is a circuit breaker for one provider. After threshold failures in
+// a row it opens and the provider is skipped until cooldown has passed. Then
+// it's half-open: one request is let through as a probe while the rest are
+// still turned away, and the probe's outcome closes or reopens it.
+type Breaker struct {
+	// kind is what the provider is used for, like "chat" or "stt"
+	kind      string
+	provider  string
+	threshold int
+	cooldown  time.Duration
+	now       func() time.Time
+	trips     metric.Int64Counter
+
+	mu        sync.Mutex
+	failures  int
+	openUntil time.Time
+	// probing is set while the half-open breaker's probe is in flight
+	probing bool
+}
+
+func NewBreaker(kind string, provider string, threshold int, cooldown time.Duration) *Breaker {
+	b := &Breaker{
+		kind:      kind,
+		provider:  provider,
+		threshold: threshold,
+		cooldown:  cooldown,
+		now:       time.Now,
+	}
+
+	trips, err := otel.Meter("modelapi").Int64Counter("modelapi.provider.breaker_trips",
+		metric.WithDescription("Times a provider's circuit breaker opened"),
+	)
+	if err != nil {
+		otel.Handle(err)
+	}
+	b.trips = trips
+
+	registerBreaker(b)
+	return b
+}
+
+// Healthy reports whether the breaker would let a request through, without
+// claiming the half-open probe.
+func (b *Breaker) Healthy() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.failures < b.threshold || (!b.probing && !b.now().Before(b.openUntil))
+}
+
+// Allow reports whether a request may go to the provider. Once the cooldown
+// has passed, the first caller gets the probe and the others are held off
+// until Record hears how it went.
+func (b *Breaker) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.failures < b.threshold {
+		return true
+	}
+	if b.probing || b.now().Before(b.openUntil) {
+		return false
+	}
+	b.probing = true
+	return true
+}
+
+// Record counts the outcome of a request to the provider. Requests the caller
+// gave up on say nothing about the provider, so they aren't counted.
+func (b *Breaker) Record(ctx context.Context, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	// Whatever the outcome, the probe is over and the next one can go
+	b.probing = false
+	if err != nil && ctx.Err() != nil {
+		return
+	}
+
+	if err == nil {
+		b.failures = 0
+		return
+	}
+	b.failures++
+	if b.failures < b.threshold {
+		return
+	}
+
+	// A failed trial request after the cooldown opens it again
+	b.openUntil = b.now().Add(b.cooldown)
+	if b.trips != nil {
+		b.trips.Add(ctx, 1, metric.WithAttributes(b.attributes()...))
+	}
+}
+
+func (b *Breaker) attributes() []attribute.KeyValue {
+	return []attribute.KeyValue{
+		attribute.String("provider", b.provider),
+		attribute.String("kind", b.kind),
+	}
+}
+
+var (
+	breakersMu sync.Mutex
+	breakers   []*Breaker
+	// The health gauge is registered once and reports every breaker
+	registerHealthGauge sync.Once
+)
+
+func registerBreaker(b *Breaker) {
+	breakersMu.Lock()
+	breakers = append(breakers, b)
+	breakersMu.Unlock()
+
+	registerHealthGauge.Do(func() {
+		meter := otel.Meter("modelapi")
+		gauge, err := meter.Int64ObservableGauge("modelapi.provider.healthy",
+			metric.WithDescription("1 while a provider takes requests, 0 while its circuit breaker is open"),
+		)
+		if err != nil {
+			otel.Handle(err)
+			return
+		}
+		_, err = meter.RegisterCallback(func(ctx context.Context, o metric.Observer) error {
+			breakersMu.Lock()
+			defer breakersMu.Unlock()
+			for _, b := range breakers {
+				var value int64
+				if b.Healthy() {
+					value = 1
+				}
+				o.ObserveInt64(gauge, value, metric.WithAttributes(b.attributes()...))
+			}
+			return nil
+		}, gauge)
+		if err != nil {
+			otel.Handle(err)
+		}
+	})
+}
+
+// GuardChat puts breaker in front of provider. The result still streams if
+// provider does.
+func GuardChat(provider ChatProvider, breaker *Breaker) ChatProvider {
+	guarded := &guardedChat{provider: provider, breaker: breaker}
+	if streamer, ok := provider.(ChatStreamer); ok {
+		return &guardedStreamer{guardedChat: guarded, streamer: streamer}
+	}
+	return guarded
+}
+
+type guardedChat struct {
+	provider ChatProvider
+	breaker  *Breaker
+}
+
+func (g *guardedChat) Name() string {
+	return g.provider.Name()
+}
+
+func (g *guardedChat) Healthy() bool {
+	return g.breaker.Healthy()
+}
+
+func (g *guardedChat) GetResponse(ctx context.Context, request ChatRequest) (string, error) {
+	if !g.breaker.Allow() {
+		return "", ErrProviderUnavailable
+	}
+	response, err := g.provider.GetResponse(ctx, request)
+	g.breaker.Record(ctx, err)
+	return response, err
+}
+
+func (g *guardedChat) GetResponseWithTools(ctx context.Context, request ChatRequest, tool ChatTool, v any) error {
+	if !g.breaker.Allow() {
+		return ErrProviderUnavailable
+	}
+	err := g.provider.GetResponseWithTools(ctx, request, tool, v)
+	g.breaker.Record(ctx, err)
+	return err
+}
+
+type guardedStreamer struct {
+	*guardedChat
+	streamer ChatStreamer
+}
+
+func (g *guardedStreamer) StreamResponse(ctx context.Context, request ChatRequest, onDelta func(string)) (string, error) {
+	if !g.breaker.Allow() {
+		return "", ErrProviderUnavailable
+	}
+	response, err := g.streamer.StreamResponse(ctx, request, onDelta)
+	g.breaker.Record(ctx, err)
+	return response, err
+}
+
+// GuardTTS puts breaker in front of provider.
+func GuardTTS(provider TTSProvider, breaker *Breaker) TTSProvider {
+	return &guardedTTS{provider: provider, breaker: breaker}
+}
+
+type guardedTTS struct {
+	provider TTSProvider
+	breaker  *Breaker
+}
+
+func (g *guardedTTS) Name() string {
+	return g.provider.Name()
+}
+
+func (g *guardedTTS) Healthy() bool {
+	return g.breaker.Healthy()
+}
+
+func (g *guardedTTS) Synthesize(ctx context.Context, request SpeechRequest) (Speech, error) {
+	if !g.breaker.Allow() {
+		return Speech{}, ErrProviderUnavailable
+	}
+	speech, err := g.provider.Synthesize(ctx, request)
+	g.breaker.Record(ctx, err)
+	return speech, err
+}
+
+// GuardImages puts breaker in front of provider.
+func GuardImages(provider ImageProvider, breaker *Breaker) ImageProvider {
+	return &guardedImages{provider: provider, breaker: breaker}
+}
+
+type guardedImages struct {
+	provider ImageProvider
+	breaker  *Breaker
+}
+
+func (g *guardedImages) Name() string {
+	return g.provider.Name()
+}
+
+func (g *guardedImages) Healthy() bool {
+	return g.breaker.Healthy()
+}
+
+func (g *guardedImages) GenerateImage(ctx context.Context, request ImageRequest) ([]byte, error) {
+	if !g.breaker.Allow() {
+		return nil, ErrProviderUnavailable
+	}
+	image, err := g.provider.GenerateImage(ctx, request)
+	g.breaker.Record(ctx, err)
+	return image, err
+}
+
+// GuardSTT puts breaker in front of provider.
+func GuardSTT(provider STTProvider, breaker *Breaker) STTProvider {
+	return &guardedSTT{provider: provider, breaker: breaker}
+}
+
+type guardedSTT struct {
+	provider STTProvider
+	breaker  *Breaker
+}
+
+func (g *guardedSTT) Name() string {
+	return g.provider.Name()
+}
+
+func (g *guardedSTT) Healthy() bool {
+	return g.breaker.Healthy()
+}
+
+func (g *guardedSTT) Transcribe(ctx context.Context, request TranscriptionRequest) (Transcription, error) {
+	if !g.breaker.Allow() {
+		return Transcription{}, ErrProviderUnavailable
+	}
+	transcription, err := g.provider.Transcribe(ctx, request)
+	g.breaker.Record(ctx, err)
+	return transcription, err
+}
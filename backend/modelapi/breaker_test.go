@@ -0,0 +1,114 @@
+package modelapi
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+type flakyChat struct {
+	fakeChat
+	err   error
+	calls int
+}
+
+func (f *flakyChat) GetResponse(ctx context.Context, request ChatRequest) (string, error) {
+	f.calls++
+	return f.name, f.err
+}
+
+func TestBreaker(t *testing.T) {
+	now := time.Unix(0, 0)
+	breaker := NewBreaker("chat", "groq", 2, time.Minute)
+	breaker.now = func() time.Time { return now }
+
+	provider := &flakyChat{fakeChat: fakeChat{name: "groq"}, err: errors.New("503")}
+	guarded := GuardChat(provider, breaker)
+	ctx := context.Background()
+
+	guarded.GetResponse(ctx, ChatRequest{})
+	guarded.GetResponse(ctx, ChatRequest{})
+	if _, err := guarded.GetResponse(ctx, ChatRequest{}); !errors.Is(err, ErrProviderUnavailable) {
+		t.Fatalf("err = %v, want ErrProviderUnavailable once the breaker opens", err)
+	}
+	if provider.calls != 2 {
+		t.Errorf("calls = %d, want the open breaker to skip the provider", provider.calls)
+	}
+
+	// A failed trial after the cooldown opens it again
+	now = now.Add(time.Minute)
+	guarded.GetResponse(ctx, ChatRequest{})
+	if breaker.Healthy() || provider.calls != 3 {
+		t.Errorf("healthy = %v, calls = %d after a failed trial", breaker.Healthy(), provider.calls)
+	}
+
+	now = now.Add(time.Minute)
+	provider.err = nil
+	if _, err := guarded.GetResponse(ctx, ChatRequest{}); err != nil || !breaker.Healthy() {
+		t.Errorf("err = %v, healthy = %v after a successful trial", err, breaker.Healthy())
+	}
+}
+
+func TestBreakerHalfOpenLetsOneProbeThrough(t *testing.T) {
+	now := time.Unix(0, 0)
+	breaker := NewBreaker("stt", "deepgram", 1, time.Minute)
+	breaker.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	breaker.Record(ctx, errors.New("503"))
+	now = now.Add(time.Minute)
+
+	if !breaker.Allow() {
+		t.Fatal("the first request after the cooldown should be the probe")
+	}
+	if breaker.Allow() || breaker.Healthy() {
+		t.Error("requests while the probe is in flight should be held off")
+	}
+
+	// A probe the caller gave up on lets the next one go
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	breaker.Record(canceled, context.Canceled)
+	if !breaker.Allow() {
+		t.Error("a canceled probe should free the next one")
+	}
+
+	breaker.Record(ctx, nil)
+	if !breaker.Allow() || !breaker.Allow() {
+		t.Error("a successful probe should close the breaker")
+	}
+}
+
+func TestBreakerIgnoresCanceledRequests(t *testing.T) {
+	breaker := NewBreaker("tts", "openai", 1, time.Minute)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	breaker.Record(ctx, context.Canceled)
+	if !breaker.Healthy() {
+		t.Error("a request the caller gave up on shouldn't open the breaker")
+	}
+}
+
+func TestGuardChatKeepsStreaming(t *testing.T) {
+	breaker := NewBreaker("chat", "gemini", 1, time.Minute)
+	if _, ok := GuardChat(fakeChat{name: "gemini"}, breaker).(ChatStreamer); ok {
+		t.Error("a provider that can't stream shouldn't gain StreamResponse")
+	}
+}
+
+func TestChatRouterSkipsUnhealthy(t *testing.T) {
+	down := NewBreaker("chat", "gemini", 1, time.Minute)
+	down.Record(context.Background(), errors.New("503"))
+
+	router, err := NewChatRouter("persona:simran=gemini",
+		fakeChat{name: "groq"}, GuardChat(fakeChat{name: "gemini"}, down),
+	)
+	if err != nil {
+		t.Fatalf("NewChatRouter: %v", err)
+	}
+	if got := router.Provider(ChatRoute{Persona: "simran"}).Name(); got != "groq" {
+		t.Errorf("Provider = %q, want groq while gemini is down", got)
+	}
+}
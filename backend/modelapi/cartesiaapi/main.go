@@ -7,8 +7,13 @@ import (
 	"fmt"
 	"gulabodev/httpmiddleware"
 	"gulabodev/logger"
+	"gulabodev/modelapi"
+	"gulabodev/retry"
+	"math"
 	"os"
+	"slices"
 	"time"
+	"unicode/utf8"
 
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
@@ -23,16 +28,80 @@ type CartesiaConnectProps struct {
 type Cartesia struct {
 	logger    *logger.LogMiddleware
 	semaphore *semaphore.Weighted
+	// voice is used when a request doesn't name one
+	voice string
+	// limiter keeps synthesis requests within the plan's quota
+	limiter *modelapi.QuotaLimiter
 }
 
 const (
-	maxRetries = 3
-	baseDelay  = 1 * time.Second
+	ttsModel = "sonic-2"
+	// Cartesia's language code for requests that don't name one
+	defaultLanguage = "hi"
+	apiVersion      = "2024-06-10"
 )
 
+// defaultQuota only limits requests; Cartesia bills characters, not tokens.
+var defaultQuota = modelapi.Quota{RequestsPerMinute: 300}
+
+// retryPolicy spaces out retries of a line that failed to synthesize. A
+// voice note that takes longer than the budget is better sent as text.
+var retryPolicy = retry.Policy{
+	Attempts:  3,
+	BaseDelay: time.Second,
+	Budget:    20 * time.Second,
+}
+
 type VoiceConfig struct {
 	Mode string `json:"mode"`
 	ID   string `json:"id"`
+	// ExperimentalControls change how the voice delivers the line
+	ExperimentalControls *ExperimentalControls `json:"__experimental_controls,omitempty"`
+}
+
+// ExperimentalControls are Cartesia's speed and emotion controls. Speed is
+// one of slowest, slow, normal, fast and fastest; each emotion is like
+// "positivity:high", an emotion with an optional level.
+type ExperimentalControls struct {
+	Speed   string   `json:"speed,omitempty"`
+	Emotion []string `json:"emotion,omitempty"`
+}
+
+// emotionControls map each modelapi emotion onto the experimental controls.
+var emotionControls = map[string]ExperimentalControls{
+	modelapi.EmotionSeductive:  {Speed: "slow", Emotion: []string{"positivity:high", "curiosity"}},
+	modelapi.EmotionSleepy:     {Speed: "slowest", Emotion: []string{"positivity:low"}},
+	modelapi.EmotionAnnoyed:    {Speed: "normal", Emotion: []string{"anger:low", "positivity:lowest"}},
+	modelapi.EmotionExcited:    {Speed: "fast", Emotion: []string{"positivity:highest", "surprise:high"}},
+	modelapi.EmotionBreathless: {Speed: "fast", Emotion: []string{"positivity:high", "surprise"}},
+}
+
+// speeds are Cartesia's speed levels, slowest first.
+var speeds = []string{"slowest", "slow", "normal", "fast", "fastest"}
+
+// A change in rate of this much moves her speed one level
+const rateStep = 0.15
+
+// experimentalControls combines the emotion's controls with the requested
+// rate, which moves her speed up or down from the emotion's a level per
+// rateStep, so a sleepy line for a user who likes her faster is only slow.
+// It returns nil when there's nothing to control.
+func experimentalControls(emotion string, rate float64) *ExperimentalControls {
+	controls, ok := emotionControls[emotion]
+	if rate <= 0 || rate == 1 {
+		if !ok {
+			return nil
+		}
+		return &controls
+	}
+
+	speed := controls.Speed
+	if speed == "" {
+		speed = "normal"
+	}
+	level := slices.Index(speeds, speed) + int(math.Round((rate-1)/rateStep))
+	controls.Speed = speeds[min(max(level, 0), len(speeds)-1)]
+	return &controls
 }
 
 type OutputFormat struct {
@@ -58,24 +127,49 @@ func Connect(ctx context.Context, args CartesiaConnectProps) *Cartesia {
 	maxWorkers := 10
 	sem := semaphore.NewWeighted(int64(maxWorkers))
 
-	span.SetAttributes(attribute.Int("maxWorkers", maxWorkers))
+	quota := modelapi.LoadQuota(ctx, args.Logger, "CARTESIA", defaultQuota)
+	span.SetAttributes(
+		attribute.Int("maxWorkers", maxWorkers),
+		attribute.Int("quota.requests_per_minute", quota.RequestsPerMinute),
+	)
 
-	return &Cartesia{logger: args.Logger, semaphore: sem}
+	// Any voice ID from ListVoices works here
+	voice := os.Getenv("CARTESIA_VOICE_ID")
+	if voice == "" {
+		voice = HINGLISH_WOMAN
+	}
+	span.SetAttributes(attribute.String("voice_id", voice))
+
+	return &Cartesia{logger: args.Logger, semaphore: sem, voice: voice, limiter: modelapi.NewQuotaLimiter(quota)}
 }
 
-func (c *Cartesia) GenerateSpeech(ctx context.Context, text string) ([]byte, error) {
+// GenerateSpeech synthesizes text with the given voice and language code.
+// Either left empty falls back to the default voice or Hindi.
+func (c *Cartesia) GenerateSpeech(ctx context.Context, text string, voiceID string, language string) ([]byte, error) {
+	return c.GenerateSpeechWithVoice(ctx, text, voiceID, language, "", 1)
+}
+
+// GenerateSpeechWithVoice synthesizes text with the given voice and language
+// code, in one of the modelapi emotions or none, at rate times her usual pace.
+func (c *Cartesia) GenerateSpeechWithVoice(ctx context.Context, text string, voiceID string, language string, emotion string, rate float64) ([]byte, error) {
 	tracer := otel.Tracer("cartesiaapi/GenerateSpeech")
 	ctx, span := tracer.Start(ctx, "GenerateSpeech")
 	defer span.End()
 
-	logger := c.logger.Logger(ctx)
-
-	// Acquire semaphore
-	if err := c.semaphore.Acquire(ctx, 1); err != nil {
-		span.RecordError(err)
-		return nil, fmt.Errorf("failed to acquire semaphore: %w", err)
+	if voiceID == "" {
+		voiceID = c.voice
+	}
+	if language == "" {
+		language = defaultLanguage
 	}
-	defer c.semaphore.Release(1)
+	span.SetAttributes(
+		attribute.String("voice_id", voiceID),
+		attribute.String("language", language),
+		attribute.String("emotion", emotion),
+		attribute.Float64("rate", rate),
+	)
+
+	logger := c.logger.Logger(ctx)
 
 	apiKey := os.Getenv("CARTESIA_API_KEY")
 	if apiKey == "" {
@@ -84,62 +178,81 @@ func (c *Cartesia) GenerateSpeech(ctx context.Context, text string) ([]byte, err
 
 	// Create request body
 	request := TTSRequest{
-		ModelID:    "sonic-2",
+		ModelID:    ttsModel,
 		Transcript: text,
 		Voice: VoiceConfig{
 			Mode: "id",
-			ID:   HINGLISH_WOMAN,
+			ID:   voiceID,
 		},
 		OutputFormat: OutputFormat{
 			Container:  "wav",
 			Encoding:   "pcm_s16le",
 			SampleRate: 48000,
 		},
-		Language: "hi",
+		Language: language,
 	}
 
+	request.Voice.ExperimentalControls = experimentalControls(emotion, rate)
+
 	jsonData, err := json.Marshal(request)
 	if err != nil {
 		span.RecordError(err)
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	// Make API request with retries
-	var respBody []byte
-	maxRetries := 3
-	retryDelay := time.Second
+	policy := retryPolicy
+	policy.OnRetry = func(attempt int, err error, delay time.Duration) {
+		logger.Warn("Failed to generate speech, retrying",
+			zap.Error(err),
+			zap.Int("attempt", attempt),
+			zap.Duration("delay", delay))
+	}
+	respBody, err := retry.Do(ctx, policy, func(ctx context.Context) ([]byte, error) {
+		// Retries count against the quota too, so they queue like the rest
+		if err := c.limiter.Wait(ctx, 0); err != nil {
+			return nil, err
+		}
+		if err := c.semaphore.Acquire(ctx, 1); err != nil {
+			return nil, fmt.Errorf("failed to acquire semaphore: %w", err)
+		}
+		defer c.semaphore.Release(1)
 
-	for attempt := 0; attempt < maxRetries; attempt++ {
-		respBody, err = httpmiddleware.HttpRequest(httpmiddleware.HttpRequestStruct{
+		return httpmiddleware.HttpRequest(httpmiddleware.HttpRequestStruct{
 			Method: "POST",
 			Url:    "https://api.cartesia.ai/tts/bytes",
 			Body:   bytes.NewBuffer(jsonData),
 			Headers: map[string]string{
 				"X-API-Key":        apiKey,
-				"Cartesia-Version": "2024-06-10",
+				"Cartesia-Version": apiVersion,
 				"Content-Type":     "application/json",
 			},
+			Context: ctx,
 		})
-
-		if err == nil {
-			break
-		}
-
-		logger.Warn("Failed to generate speech, retrying",
-			zap.Error(err),
-			zap.Int("attempt", attempt+1),
-			zap.Int("maxRetries", maxRetries))
-
-		if attempt < maxRetries-1 {
-			time.Sleep(retryDelay * time.Duration(1<<attempt))
-			continue
-		}
-
-		return nil, fmt.Errorf("failed to generate speech after %d attempts: %w", maxRetries, err)
+	})
+	if err != nil {
+		span.RecordError(err)
+		return nil, fmt.Errorf("failed to generate speech: %w", err)
 	}
 
 	logger.Info("Successfully generated speech",
 		zap.Int("audioSize", len(respBody)))
 
+	modelapi.RecordUsage(ctx, modelapi.Usage{
+		Provider:   c.Name(),
+		Kind:       modelapi.UsageKindTTS,
+		Model:      ttsModel,
+		Characters: utf8.RuneCountInString(text),
+	})
 	return respBody, nil
 }
+
+func (c *Cartesia) Name() string {
+	return "cartesia"
+}
+
+// Synthesize implements modelapi.TTSProvider, defaulting to the configured
+// voice in Hindi.
+func (c *Cartesia) Synthesize(ctx context.Context, request modelapi.SpeechRequest) (modelapi.Speech, error) {
+	audio, err := c.GenerateSpeechWithVoice(ctx, modelapi.PlainSpeech(request.Text), request.Voice, request.Language, request.Emotion, request.Rate)
+	return modelapi.Speech{Audio: audio, FileName: "response.wav"}, err
+}
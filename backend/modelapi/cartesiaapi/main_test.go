@@ -0,0 +1,41 @@
+package cartesiaapi
+
+import (
+	"gulabodev/modelapi"
+	"testing"
+)
+
+func TestExperimentalControls(t *testing.T) {
+	if got := experimentalControls("", 1); got != nil {
+		t.Errorf("no emotion at her usual pace should send no controls, got %+v", got)
+	}
+	if got := experimentalControls("", 0); got != nil {
+		t.Errorf("an unset rate should send no controls, got %+v", got)
+	}
+
+	tests := []struct {
+		emotion string
+		rate    float64
+		speed   string
+	}{
+		{modelapi.EmotionSleepy, 1, "slowest"},
+		{modelapi.EmotionBreathless, 1, "fast"},
+		{"", 1.15, "fast"},
+		{"", 0.85, "slow"},
+		{modelapi.EmotionSleepy, 1.15, "slow"},
+		{modelapi.EmotionSleepy, 0.85, "slowest"},
+		{modelapi.EmotionExcited, 1.5, "fastest"},
+	}
+	for _, tt := range tests {
+		got := experimentalControls(tt.emotion, tt.rate)
+		if got == nil || got.Speed != tt.speed {
+			t.Errorf("experimentalControls(%q, %g) = %+v, want speed %q", tt.emotion, tt.rate, got, tt.speed)
+		}
+	}
+
+	// Adjusting the speed leaves the shared emotion controls alone
+	experimentalControls(modelapi.EmotionSleepy, 1.5)
+	if emotionControls[modelapi.EmotionSleepy].Speed != "slowest" {
+		t.Error("emotionControls was modified")
+	}
+}
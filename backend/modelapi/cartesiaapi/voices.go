@@ -0,0 +1,93 @@
+package cartesiaapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"gulabodev/httpmiddleware"
+	"gulabodev/retry"
+	"os"
+	"sort"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+)
+
+// Voice is one entry of Cartesia's voice catalog.
+type Voice struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	// Language is the voice's language code, like "hi"
+	Language string `json:"language"`
+	IsPublic bool   `json:"is_public"`
+}
+
+// ListVoices fetches the voices the account can use, Cartesia's public ones
+// and any it has cloned, sorted by language and then name.
+func (c *Cartesia) ListVoices(ctx context.Context) ([]Voice, error) {
+	tracer := otel.Tracer("cartesiaapi/ListVoices")
+	ctx, span := tracer.Start(ctx, "ListVoices")
+	defer span.End()
+
+	apiKey := os.Getenv("CARTESIA_API_KEY")
+	if apiKey == "" {
+		return nil, fmt.Errorf("CARTESIA_API_KEY environment variable not set")
+	}
+
+	policy := retryPolicy
+	policy.OnRetry = func(attempt int, err error, delay time.Duration) {
+		c.logger.Logger(ctx).Warn("Failed to list voices, retrying",
+			zap.Error(err),
+			zap.Int("attempt", attempt),
+			zap.Duration("delay", delay))
+	}
+	respBody, err := retry.Do(ctx, policy, func(ctx context.Context) ([]byte, error) {
+		return httpmiddleware.HttpRequest(httpmiddleware.HttpRequestStruct{
+			Method: "GET",
+			Url:    "https://api.cartesia.ai/voices/",
+			Headers: map[string]string{
+				"X-API-Key":        apiKey,
+				"Cartesia-Version": apiVersion,
+			},
+			Context: ctx,
+		})
+	})
+	if err != nil {
+		span.RecordError(err)
+		return nil, fmt.Errorf("failed to list voices: %w", err)
+	}
+
+	voices, err := decodeVoices(respBody)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	span.SetAttributes(attribute.Int("voices", len(voices)))
+	return voices, nil
+}
+
+// decodeVoices reads the catalog, which older API versions return as a bare
+// list and newer ones as a page with the list under "data".
+func decodeVoices(body []byte) ([]Voice, error) {
+	var voices []Voice
+	if err := json.Unmarshal(body, &voices); err != nil {
+		var page struct {
+			Data []Voice `json:"data"`
+		}
+		if pageErr := json.Unmarshal(body, &page); pageErr != nil {
+			return nil, fmt.Errorf("failed to decode voices: %w", err)
+		}
+		voices = page.Data
+	}
+
+	sort.Slice(voices, func(i, j int) bool {
+		if voices[i].Language != voices[j].Language {
+			return voices[i].Language < voices[j].Language
+		}
+		return voices[i].Name < voices[j].Name
+	})
+	return voices, nil
+}
@@ -0,0 +1,26 @@
+package cartesiaapi
+
+import "testing"
+
+func TestDecodeVoices(t *testing.T) {
+	list := `[
+		{"id": "b", "name": "Riya", "language": "hi", "is_public": true},
+		{"id": "a", "name": "Emma", "language": "en", "is_public": true},
+		{"id": "c", "name": "Anaya", "language": "hi", "is_public": false}
+	]`
+	page := `{"data": ` + list + `, "has_more": false}`
+
+	for _, body := range []string{list, page} {
+		voices, err := decodeVoices([]byte(body))
+		if err != nil {
+			t.Fatalf("decodeVoices: %v", err)
+		}
+		if len(voices) != 3 || voices[0].ID != "a" || voices[1].ID != "c" || voices[2].ID != "b" {
+			t.Errorf("voices should be sorted by language and name: %+v", voices)
+		}
+	}
+
+	if _, err := decodeVoices([]byte(`"nope"`)); err == nil {
+		t.Error("expected a malformed catalog to be rejected")
+	}
+}
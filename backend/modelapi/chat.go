@@ -0,0 +1,378 @@
+package modelapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ChatMessage is one turn of a conversation.
+type ChatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+	// Images are pictures the user sent with this turn. They're too big to
+	// keep with the stored history, so callers attach them again to the turns
+	// the model should still see them in.
+	Images []ChatImage `json:"-"`
+}
+
+// ChatImage is a picture sent along with a user message.
+type ChatImage struct {
+	Data     []byte
+	MimeType string
+}
+
+// ChatRequest is everything a provider needs to write the next reply.
+type ChatRequest struct {
+	SystemPrompt string
+	History      []ChatMessage
+	Message      string
+	Images       []ChatImage
+	// Temperature is the sampling temperature; 0 leaves the provider's default
+	Temperature float32
+	// TopP samples from the smallest set of tokens this likely in total; 0
+	// leaves the provider's default
+	TopP float32
+	// MaxTokens caps a reply's length; 0 leaves the provider's cap. Tool
+	// calls and structured output keep their own, so their JSON isn't cut off.
+	MaxTokens int
+}
+
+// ReplyTokens is the most tokens a reply may take: the request's MaxTokens,
+// or the provider's cap if it doesn't set one.
+func (r ChatRequest) ReplyTokens(providerCap int) int {
+	if r.MaxTokens > 0 {
+		return r.MaxTokens
+	}
+	return providerCap
+}
+
+// HasImages reports whether the new message or any turn of the history has
+// images, which some providers need a vision model for.
+func (r ChatRequest) HasImages() bool {
+	if len(r.Images) > 0 {
+		return true
+	}
+	for _, message := range r.History {
+		if len(message.Images) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// ToolParameter describes a tool's arguments, or one of them, as JSON schema.
+type ToolParameter struct {
+	Type        string                   `json:"type"`
+	Description string                   `json:"description,omitempty"`
+	Enum        []string                 `json:"enum,omitempty"`
+	Items       *ToolParameter           `json:"items,omitempty"`
+	Properties  map[string]ToolParameter `json:"properties,omitempty"`
+	Required    []string                 `json:"required,omitempty"`
+}
+
+// ChatTool is a function the model is made to call.
+type ChatTool struct {
+	Name        string
+	Description string
+	Parameters  ToolParameter
+}
+
+// ChatProvider writes replies with one LLM service.
+type ChatProvider interface {
+	Name() string
+	GetResponse(ctx context.Context, request ChatRequest) (string, error)
+	// GetResponseWithTools forces a call to tool and decodes its arguments into v.
+	GetResponseWithTools(ctx context.Context, request ChatRequest, tool ChatTool, v any) error
+}
+
+// ChatStreamer is a ChatProvider that can also stream its reply. onDelta is
+// called with each piece of text as it arrives.
+type ChatStreamer interface {
+	ChatProvider
+	StreamResponse(ctx context.Context, request ChatRequest, onDelta func(string)) (string, error)
+}
+
+// ChatRoute is who a request is for and what it's for, which decides the
+// provider that serves it.
+type ChatRoute struct {
+	Feature string
+	Persona string
+	UserID  int64
+	// Tier is the user's plan, like "free" or "premium"
+	Tier string
+	// Provider names the provider the persona's config asks for, if any
+	Provider string
+}
+
+// ChatRouter picks a provider for each request. Rules for a user win over
+// rules for a tier, then the route's own provider, then rules for a persona,
+// then rules for a feature.
+type ChatRouter struct {
+	fallback ChatProvider
+	// order is the providers as given, for when the routed one is unhealthy
+	order     []ChatProvider
+	providers map[string]ChatProvider
+	// rules maps "user:<id>", "tier:<name>", "persona:<id>" and
+	// "feature:<name>" to a provider
+	rules map[string]string
+}
+
+// NewChatRouter routes with config, a comma-separated list of rules like
+// "default=groq,persona:simran=gemini,user:42=gemini,tier:premium=gemini,feature:memory=groq".
+// Requests no rule matches go to the first provider, unless "default" names
+// another.
+func NewChatRouter(config string, providers ...ChatProvider) (*ChatRouter, error) {
+	if len(providers) == 0 {
+		return nil, fmt.Errorf("no chat providers configured")
+	}
+
+	router := &ChatRouter{
+		fallback:  providers[0],
+		order:     providers,
+		providers: map[string]ChatProvider{},
+		rules:     map[string]string{},
+	}
+	for _, provider := range providers {
+		router.providers[provider.Name()] = provider
+	}
+
+	for _, rule := range strings.Split(config, ",") {
+		rule = strings.TrimSpace(rule)
+		if rule == "" {
+			continue
+		}
+		key, name, found := strings.Cut(rule, "=")
+		key, name = strings.TrimSpace(key), strings.TrimSpace(name)
+		provider, ok := router.providers[name]
+		if !found || !ok {
+			return nil, fmt.Errorf("invalid chat route %q", rule)
+		}
+
+		kind, value, _ := strings.Cut(key, ":")
+		switch kind {
+		case "default":
+			router.fallback = provider
+		case "user":
+			if _, err := strconv.ParseInt(value, 10, 64); err != nil {
+				return nil, fmt.Errorf("invalid user in chat route %q", rule)
+			}
+			router.rules[key] = name
+		case "tier", "persona", "feature":
+			router.rules[key] = name
+		default:
+			return nil, fmt.Errorf("invalid chat route %q", rule)
+		}
+	}
+	return router, nil
+}
+
+// Provider returns the provider that serves route. If its breaker is open,
+// the default stands in, then any other healthy provider.
+func (r *ChatRouter) Provider(route ChatRoute) ChatProvider {
+	routed := r.route(route)
+	if healthy(routed) {
+		return routed
+	}
+	for _, provider := range append([]ChatProvider{r.fallback}, r.order...) {
+		if healthy(provider) {
+			return provider
+		}
+	}
+	// Nothing is healthy; the routed provider fails fast
+	return routed
+}
+
+// Fallback is the provider Provider picks, backed by every other healthy
+// provider in case the request to it fails.
+func (r *ChatRouter) Fallback(route ChatRoute) ChatProvider {
+	first := r.Provider(route)
+	chain := []ChatProvider{first}
+	seen := map[string]bool{first.Name(): true}
+	for _, provider := range append([]ChatProvider{r.fallback}, r.order...) {
+		if !seen[provider.Name()] && healthy(provider) {
+			seen[provider.Name()] = true
+			chain = append(chain, provider)
+		}
+	}
+	if len(chain) == 1 {
+		return first
+	}
+	return NewFallbackChat(chain...)
+}
+
+func (r *ChatRouter) route(route ChatRoute) ChatProvider {
+	if name, ok := r.rules["user:"+strconv.FormatInt(route.UserID, 10)]; ok {
+		return r.providers[name]
+	}
+	if name, ok := r.rules["tier:"+route.Tier]; ok {
+		return r.providers[name]
+	}
+	if provider, ok := r.providers[route.Provider]; ok {
+		return provider
+	}
+	for _, key := range []string{"persona:" + route.Persona, "feature:" + route.Feature} {
+		if name, ok := r.rules[key]; ok {
+			return r.providers[name]
+		}
+	}
+	return r.fallback
+}
+
+// Sampling is how a provider picks a reply's tokens and how many it may
+// write. Zero fields leave the provider's defaults.
+type Sampling struct {
+	Temperature float32
+	TopP        float32
+	MaxTokens   int
+}
+
+// WithTemperature has provider use temperature for requests that don't set
+// their own. The result still streams if provider does.
+func WithTemperature(provider ChatProvider, temperature float32) ChatProvider {
+	return WithSampling(provider, Sampling{Temperature: temperature})
+}
+
+// WithSampling has provider use sampling's settings for requests that don't
+// set their own. The result still streams if provider does.
+func WithSampling(provider ChatProvider, sampling Sampling) ChatProvider {
+	sampled := &sampledChat{provider: provider, sampling: sampling}
+	if streamer, ok := provider.(ChatStreamer); ok {
+		return &sampledStreamer{sampledChat: sampled, streamer: streamer}
+	}
+	return sampled
+}
+
+type sampledChat struct {
+	provider ChatProvider
+	sampling Sampling
+}
+
+func (c *sampledChat) Name() string {
+	return c.provider.Name()
+}
+
+func (c *sampledChat) apply(request ChatRequest) ChatRequest {
+	if request.Temperature == 0 {
+		request.Temperature = c.sampling.Temperature
+	}
+	if request.TopP == 0 {
+		request.TopP = c.sampling.TopP
+	}
+	if request.MaxTokens == 0 {
+		request.MaxTokens = c.sampling.MaxTokens
+	}
+	return request
+}
+
+func (c *sampledChat) GetResponse(ctx context.Context, request ChatRequest) (string, error) {
+	return c.provider.GetResponse(ctx, c.apply(request))
+}
+
+func (c *sampledChat) GetResponseWithTools(ctx context.Context, request ChatRequest, tool ChatTool, v any) error {
+	return c.provider.GetResponseWithTools(ctx, c.apply(request), tool, v)
+}
+
+type sampledStreamer struct {
+	*sampledChat
+	streamer ChatStreamer
+}
+
+func (c *sampledStreamer) StreamResponse(ctx context.Context, request ChatRequest, onDelta func(string)) (string, error) {
+	return c.streamer.StreamResponse(ctx, c.apply(request), onDelta)
+}
+
+// FallbackChat tries its providers in order until one replies. It always
+// streams: providers that can't stream send their whole reply as one delta.
+type FallbackChat struct {
+	providers []ChatProvider
+}
+
+func NewFallbackChat(providers ...ChatProvider) *FallbackChat {
+	return &FallbackChat{providers: providers}
+}
+
+func (f *FallbackChat) Name() string {
+	return "fallback"
+}
+
+func (f *FallbackChat) GetResponse(ctx context.Context, request ChatRequest) (string, error) {
+	var response string
+	err := f.try(ctx, "GetResponse", func(ctx context.Context, provider ChatProvider) error {
+		var err error
+		response, err = provider.GetResponse(ctx, request)
+		return err
+	})
+	return response, err
+}
+
+func (f *FallbackChat) GetResponseWithTools(ctx context.Context, request ChatRequest, tool ChatTool, v any) error {
+	return f.try(ctx, "GetResponseWithTools", func(ctx context.Context, provider ChatProvider) error {
+		return provider.GetResponseWithTools(ctx, request, tool, v)
+	})
+}
+
+// StreamResponse only falls back while nothing has been streamed, since the
+// caller may already be showing the failed provider's partial reply.
+func (f *FallbackChat) StreamResponse(ctx context.Context, request ChatRequest, onDelta func(string)) (string, error) {
+	var response string
+	streamed := false
+	err := f.try(ctx, "StreamResponse", func(ctx context.Context, provider ChatProvider) error {
+		if streamed {
+			return errors.New("stream interrupted")
+		}
+		var err error
+		streamer, ok := provider.(ChatStreamer)
+		if !ok {
+			response, err = provider.GetResponse(ctx, request)
+			if err == nil {
+				onDelta(response)
+			}
+			return err
+		}
+		response, err = streamer.StreamResponse(ctx, request, func(delta string) {
+			streamed = true
+			onDelta(delta)
+		})
+		return err
+	})
+	return response, err
+}
+
+func (f *FallbackChat) try(ctx context.Context, method string, call func(context.Context, ChatProvider) error) error {
+	tracer := otel.Tracer("modelapi/FallbackChat")
+	ctx, span := tracer.Start(ctx, method)
+	defer span.End()
+
+	var errs []error
+	for i, provider := range f.providers {
+		err := call(ctx, provider)
+		if err == nil {
+			span.SetAttributes(
+				attribute.String("chat.provider", provider.Name()),
+				attribute.Int("chat.fallbacks", i),
+			)
+			return nil
+		}
+
+		span.AddEvent("Provider failed", trace.WithAttributes(attribute.String("chat.provider", provider.Name())))
+		errs = append(errs, fmt.Errorf("%s: %w", provider.Name(), err))
+		// Nobody is waiting for the reply any more
+		if ctx.Err() != nil {
+			break
+		}
+	}
+
+	if len(errs) == 0 {
+		errs = append(errs, errors.New("no chat providers configured"))
+	}
+	err := errors.Join(errs...)
+	span.RecordError(err)
+	return err
+}
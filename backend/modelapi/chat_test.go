@@ -0,0 +1,207 @@
+package modelapi
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+type fakeChat struct {
+	name string
+}
+
+func (f fakeChat) Name() string {
+	return f.name
+}
+
+func (f fakeChat) GetResponse(ctx context.Context, request ChatRequest) (string, error) {
+	return f.name, nil
+}
+
+func (f fakeChat) GetResponseWithTools(ctx context.Context, request ChatRequest, tool ChatTool, v any) error {
+	return nil
+}
+
+func TestChatRouter(t *testing.T) {
+	router, err := NewChatRouter(
+		"persona:simran=gemini, user:42=groq, feature:memory=gemini",
+		fakeChat{name: "groq"}, fakeChat{name: "gemini"},
+	)
+	if err != nil {
+		t.Fatalf("NewChatRouter: %v", err)
+	}
+
+	tests := []struct {
+		route ChatRoute
+		want  string
+	}{
+		{ChatRoute{Feature: "reply", Persona: "priya", UserID: 1}, "groq"},
+		{ChatRoute{Feature: "reply", Persona: "simran", UserID: 1}, "gemini"},
+		{ChatRoute{Feature: "reply", Persona: "simran", UserID: 42}, "groq"},
+		{ChatRoute{Feature: "memory", UserID: 1}, "gemini"},
+		{ChatRoute{Feature: "memory", Persona: "priya", UserID: 42}, "groq"},
+		{ChatRoute{Feature: "reply", Persona: "simran", UserID: 1, Provider: "groq"}, "groq"},
+		{ChatRoute{Feature: "reply", Persona: "priya", UserID: 42, Provider: "gemini"}, "groq"},
+		{ChatRoute{Feature: "reply", Persona: "simran", UserID: 1, Provider: "claude"}, "gemini"},
+	}
+	for _, tt := range tests {
+		if got := router.Provider(tt.route).Name(); got != tt.want {
+			t.Errorf("Provider(%+v) = %q, want %q", tt.route, got, tt.want)
+		}
+	}
+}
+
+func TestChatRouterTier(t *testing.T) {
+	router, err := NewChatRouter(
+		"tier:premium=gemini, user:42=groq, feature:memory=groq",
+		fakeChat{name: "groq"}, fakeChat{name: "gemini"}, fakeChat{name: "kimi"},
+	)
+	if err != nil {
+		t.Fatalf("NewChatRouter: %v", err)
+	}
+
+	tests := []struct {
+		route ChatRoute
+		want  string
+	}{
+		{ChatRoute{Feature: "reply", Tier: "free", UserID: 1}, "groq"},
+		{ChatRoute{Feature: "reply", Tier: "premium", UserID: 1}, "gemini"},
+		{ChatRoute{Feature: "memory", Tier: "premium", UserID: 1}, "gemini"},
+		{ChatRoute{Feature: "reply", Tier: "premium", UserID: 1, Provider: "kimi"}, "gemini"},
+		{ChatRoute{Feature: "reply", Tier: "free", UserID: 1, Provider: "kimi"}, "kimi"},
+		{ChatRoute{Feature: "reply", Tier: "premium", UserID: 42}, "groq"},
+	}
+	for _, tt := range tests {
+		if got := router.Provider(tt.route).Name(); got != tt.want {
+			t.Errorf("Provider(%+v) = %q, want %q", tt.route, got, tt.want)
+		}
+	}
+}
+
+func TestChatRouterDefault(t *testing.T) {
+	router, err := NewChatRouter("default=gemini", fakeChat{name: "groq"}, fakeChat{name: "gemini"})
+	if err != nil {
+		t.Fatalf("NewChatRouter: %v", err)
+	}
+	if got := router.Provider(ChatRoute{Feature: "reply"}).Name(); got != "gemini" {
+		t.Errorf("Provider = %q, want gemini", got)
+	}
+}
+
+func TestChatRouterInvalid(t *testing.T) {
+	for _, config := range []string{"persona:simran=claude", "persona:simran", "user:abc=groq", "model:x=groq"} {
+		if _, err := NewChatRouter(config, fakeChat{name: "groq"}); err == nil {
+			t.Errorf("NewChatRouter(%q) should fail", config)
+		}
+	}
+	if _, err := NewChatRouter(""); err == nil {
+		t.Error("NewChatRouter with no providers should fail")
+	}
+}
+
+type temperatureChat struct {
+	fakeChat
+	got *float32
+}
+
+func (f temperatureChat) GetResponse(ctx context.Context, request ChatRequest) (string, error) {
+	*f.got = request.Temperature
+	return f.name, nil
+}
+
+func TestWithTemperature(t *testing.T) {
+	var got float32
+	provider := WithTemperature(temperatureChat{fakeChat: fakeChat{name: "groq"}, got: &got}, 0.9)
+
+	provider.GetResponse(context.Background(), ChatRequest{})
+	if got != 0.9 {
+		t.Errorf("temperature = %v, want 0.9", got)
+	}
+	provider.GetResponse(context.Background(), ChatRequest{Temperature: 0.2})
+	if got != 0.2 {
+		t.Errorf("temperature = %v, want the request's own 0.2", got)
+	}
+	if _, ok := provider.(ChatStreamer); ok {
+		t.Error("a provider that can't stream shouldn't become a streamer")
+	}
+}
+
+type samplingChat struct {
+	fakeChat
+	got *ChatRequest
+}
+
+func (f samplingChat) GetResponse(ctx context.Context, request ChatRequest) (string, error) {
+	*f.got = request
+	return f.name, nil
+}
+
+func TestWithSampling(t *testing.T) {
+	var got ChatRequest
+	provider := WithSampling(samplingChat{fakeChat: fakeChat{name: "groq"}, got: &got}, Sampling{Temperature: 0.9, TopP: 0.95, MaxTokens: 300})
+
+	provider.GetResponse(context.Background(), ChatRequest{})
+	if got.Temperature != 0.9 || got.TopP != 0.95 || got.MaxTokens != 300 {
+		t.Errorf("request = %+v, want the persona's sampling", got)
+	}
+	provider.GetResponse(context.Background(), ChatRequest{TopP: 0.5, MaxTokens: 100})
+	if got.Temperature != 0.9 || got.TopP != 0.5 || got.MaxTokens != 100 {
+		t.Errorf("request = %+v, want the request's own top_p and max tokens kept", got)
+	}
+
+	if tokens := (ChatRequest{}).ReplyTokens(2048); tokens != 2048 {
+		t.Errorf("ReplyTokens = %d, want the provider's cap", tokens)
+	}
+	if tokens := (ChatRequest{MaxTokens: 300}).ReplyTokens(2048); tokens != 300 {
+		t.Errorf("ReplyTokens = %d, want the request's 300", tokens)
+	}
+}
+
+type failingChat struct {
+	fakeChat
+	streamed string
+}
+
+func (f failingChat) GetResponse(ctx context.Context, request ChatRequest) (string, error) {
+	return "", errors.New("503")
+}
+
+func (f failingChat) StreamResponse(ctx context.Context, request ChatRequest, onDelta func(string)) (string, error) {
+	if f.streamed != "" {
+		onDelta(f.streamed)
+	}
+	return "", errors.New("503")
+}
+
+func TestChatRouterFallback(t *testing.T) {
+	router, err := NewChatRouter("", failingChat{fakeChat: fakeChat{name: "groq"}}, fakeChat{name: "gemini"})
+	if err != nil {
+		t.Fatalf("NewChatRouter: %v", err)
+	}
+	provider := router.Fallback(ChatRoute{Feature: "reply"})
+
+	response, err := provider.GetResponse(context.Background(), ChatRequest{})
+	if err != nil || response != "gemini" {
+		t.Errorf("GetResponse = %q, %v; want gemini after groq fails", response, err)
+	}
+
+	var deltas []string
+	response, err = provider.(ChatStreamer).StreamResponse(context.Background(), ChatRequest{}, func(delta string) {
+		deltas = append(deltas, delta)
+	})
+	if err != nil || response != "gemini" || len(deltas) != 1 || deltas[0] != "gemini" {
+		t.Errorf("StreamResponse = %q, %v, deltas %q; want gemini's reply as one delta", response, err, deltas)
+	}
+
+	single, _ := NewChatRouter("", fakeChat{name: "groq"})
+	if got := single.Fallback(ChatRoute{}).Name(); got != "groq" {
+		t.Errorf("Fallback with one provider = %q, want it unwrapped", got)
+	}
+}
+
+func TestFallbackChatInterruptedStream(t *testing.T) {
+	provider := NewFallbackChat(failingChat{fakeChat: fakeChat{name: "groq"}, streamed: "Hel"}, fakeChat{name: "gemini"})
+	if _, err := provider.StreamResponse(context.Background(), ChatRequest{}, func(string) {}); err == nil {
+		t.Error("a stream that failed part way shouldn't fall back")
+	}
+}
@@ -0,0 +1,128 @@
+package modelapi
+
+import (
+	"context"
+	"regexp"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// DefaultContextWindow is assumed for models that don't say how many
+	// tokens they take
+	DefaultContextWindow = 32768
+
+	// Replies are capped at 2048 tokens unless the request says otherwise, and
+	// the window has to leave room for them
+	replyTokens = 2048
+	// Each message costs a few tokens of role markup on top of its text
+	messageOverhead = 4
+	// Providers charge images differently; this is about the most any does
+	imageTokens = 1024
+)
+
+// ContextWindowed is a ChatProvider whose model says how many tokens of
+// prompt and reply it takes.
+type ContextWindowed interface {
+	ContextWindow() int
+}
+
+// BPE tokenizers first split text into runs of letters, runs of digits and
+// punctuation, then break long or rare runs up further
+var pretokens = regexp.MustCompile(`\p{L}[\p{L}\p{M}]*|\p{N}+|[^\s\p{L}\p{M}\p{N}]+`)
+
+// CountTokens estimates how many tokens text takes. It splits text the way
+// BPE tokenizers do and counts a token for every four bytes of each piece,
+// which errs high for English and is close for Devanagari and Gurmukhi, whose
+// letters are three bytes each. Erring high only trims a little early.
+func CountTokens(text string) int {
+	tokens := 0
+	for _, piece := range pretokens.FindAllString(text, -1) {
+		tokens += (len(piece) + 3) / 4
+	}
+	return tokens
+}
+
+// FitHistory drops the oldest turns of the request's history until the whole
+// request fits in window tokens with room left for the reply, and returns it
+// with how many turns were dropped. The system prompt, which carries her
+// memories, and the new message are never trimmed. The kept history always
+// opens with a user turn, so it doesn't start halfway through an exchange.
+func FitHistory(request ChatRequest, window int) (ChatRequest, int) {
+	budget := window - request.ReplyTokens(replyTokens) -
+		CountTokens(request.SystemPrompt) - CountTokens(request.Message) - 2*messageOverhead -
+		len(request.Images)*imageTokens
+
+	start := len(request.History)
+	for used := 0; start > 0; start-- {
+		message := request.History[start-1]
+		cost := CountTokens(message.Content) + messageOverhead + len(message.Images)*imageTokens
+		if used+cost > budget {
+			break
+		}
+		used += cost
+	}
+	if start == 0 {
+		return request, 0
+	}
+	for start < len(request.History) && request.History[start].Role != "user" {
+		start++
+	}
+
+	request.History = request.History[start:]
+	return request, start
+}
+
+// WithContextWindow trims the history of every request to provider to fit
+// its model's window, or DefaultContextWindow if it doesn't say. The result
+// still streams if provider does.
+func WithContextWindow(provider ChatProvider) ChatProvider {
+	window := DefaultContextWindow
+	if windowed, ok := provider.(ContextWindowed); ok {
+		window = windowed.ContextWindow()
+	}
+
+	fitted := &fittedChat{provider: provider, window: window}
+	if streamer, ok := provider.(ChatStreamer); ok {
+		return &fittedStreamer{fittedChat: fitted, streamer: streamer}
+	}
+	return fitted
+}
+
+type fittedChat struct {
+	provider ChatProvider
+	window   int
+}
+
+func (c *fittedChat) Name() string {
+	return c.provider.Name()
+}
+
+func (c *fittedChat) fit(ctx context.Context, request ChatRequest) ChatRequest {
+	request, dropped := FitHistory(request, c.window)
+	if dropped > 0 {
+		trace.SpanFromContext(ctx).SetAttributes(
+			attribute.Int("chat.context_window", c.window),
+			attribute.Int("chat.history_dropped", dropped),
+		)
+	}
+	return request
+}
+
+func (c *fittedChat) GetResponse(ctx context.Context, request ChatRequest) (string, error) {
+	return c.provider.GetResponse(ctx, c.fit(ctx, request))
+}
+
+func (c *fittedChat) GetResponseWithTools(ctx context.Context, request ChatRequest, tool ChatTool, v any) error {
+	return c.provider.GetResponseWithTools(ctx, c.fit(ctx, request), tool, v)
+}
+
+type fittedStreamer struct {
+	*fittedChat
+	streamer ChatStreamer
+}
+
+func (c *fittedStreamer) StreamResponse(ctx context.Context, request ChatRequest, onDelta func(string)) (string, error) {
+	return c.streamer.StreamResponse(ctx, c.fit(ctx, request), onDelta)
+}
@@ -0,0 +1,96 @@
+package modelapi
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestCountTokens(t *testing.T) {
+	tests := []struct {
+		text string
+		want int
+	}{
+		{"", 0},
+		{"hi baby", 2},
+		{"Kya kar rahe ho?", 5},
+		{"नमस्ते", 5},
+		{"42!!", 2},
+	}
+	for _, tt := range tests {
+		if got := CountTokens(tt.text); got != tt.want {
+			t.Errorf("CountTokens(%q) = %d, want %d", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestFitHistory(t *testing.T) {
+	// Each turn is 100 tokens, plus overhead
+	turn := strings.Repeat("abcd ", 100)
+	var history []ChatMessage
+	for i := 0; i < 10; i++ {
+		history = append(history, ChatMessage{Role: "user", Content: turn}, ChatMessage{Role: "assistant", Content: turn})
+	}
+	request := ChatRequest{SystemPrompt: "You are Gulabo.", History: history, Message: "hi"}
+
+	fitted, dropped := FitHistory(request, replyTokens+550)
+	if dropped == 0 || len(fitted.History)+dropped != len(history) {
+		t.Fatalf("dropped %d of %d, kept %d", dropped, len(history), len(fitted.History))
+	}
+	if fitted.History[0].Role != "user" {
+		t.Errorf("history should open with a user turn, got %s", fitted.History[0].Role)
+	}
+	if newest := fitted.History[len(fitted.History)-1]; newest.Role != "assistant" || newest.Content != turn {
+		t.Error("the newest turns should be kept")
+	}
+	if fitted.SystemPrompt != request.SystemPrompt || fitted.Message != request.Message {
+		t.Error("the system prompt and new message should never be trimmed")
+	}
+	if len(request.History) != len(history) {
+		t.Error("the caller's request should be left alone")
+	}
+
+	if _, dropped := FitHistory(request, DefaultContextWindow); dropped != 0 {
+		t.Errorf("a history that fits shouldn't be trimmed, dropped %d", dropped)
+	}
+
+	// A shorter reply cap leaves more of the window for history
+	short := request
+	short.MaxTokens = 256
+	if _, shortDropped := FitHistory(short, replyTokens+550); shortDropped >= dropped {
+		t.Errorf("dropped %d with a 256 token cap, want fewer than %d", shortDropped, dropped)
+	}
+}
+
+type windowedChat struct {
+	fakeChat
+	got *int
+}
+
+func (f windowedChat) ContextWindow() int {
+	return replyTokens + 100
+}
+
+func (f windowedChat) GetResponse(ctx context.Context, request ChatRequest) (string, error) {
+	*f.got = len(request.History)
+	return f.name, nil
+}
+
+func TestWithContextWindow(t *testing.T) {
+	var got int
+	provider := WithContextWindow(windowedChat{fakeChat: fakeChat{name: "ollama"}, got: &got})
+
+	history := []ChatMessage{
+		{Role: "user", Content: strings.Repeat("abcd ", 200)},
+		{Role: "assistant", Content: "hmm"},
+		{Role: "user", Content: "miss me?"},
+		{Role: "assistant", Content: "always"},
+	}
+	provider.GetResponse(context.Background(), ChatRequest{History: history, Message: "hi"})
+	if got != 2 {
+		t.Errorf("history sent = %d turns, want the 2 that fit", got)
+	}
+	if _, ok := provider.(ChatStreamer); ok {
+		t.Error("a provider that can't stream shouldn't become a streamer")
+	}
+}
@@ -5,8 +5,12 @@ import (
 	"context"
 	"fmt"
 	"gulabodev/logger"
+	"gulabodev/modelapi"
+	"os"
+	"strings"
 
 	api "github.com/deepgram/deepgram-go-sdk/pkg/api/listen/v1/rest"
+	responses "github.com/deepgram/deepgram-go-sdk/pkg/api/listen/v1/rest/interfaces"
 	interfaces "github.com/deepgram/deepgram-go-sdk/pkg/client/interfaces"
 	client "github.com/deepgram/deepgram-go-sdk/pkg/client/listen"
 	"go.uber.org/zap"
@@ -19,30 +23,87 @@ import (
 type DeepgramAPI struct {
 	logger *logger.LogMiddleware
 	dg     *api.Client
+	// keyterms prime every transcription to recognize them
+	keyterms []string
+}
+
+// Deepgram caps keyterm prompts at 500 tokens; most terms here are one or two
+const maxKeyterms = 100
+
+// defaultKeyterms are names and common Hinglish and Punjabi romanizations
+// that nova-3 otherwise tends to mishear in code-switched voice notes.
+var defaultKeyterms = []string{
+	"Gulabo", "Simran",
+	"jaan", "jaanu", "baby", "yaar", "pyaar", "accha", "theek hai", "haan", "nahi",
+	"kya", "kyun", "kaise ho", "kuch nahi", "bas", "chalo", "arre", "matlab", "sach mein",
+	"sat sri akal", "kiddan", "tussi", "sanu", "sohni", "ki haal", "changa", "hanji",
 }
 
 func Connect(logger *logger.LogMiddleware) *DeepgramAPI {
 	c := client.NewRESTWithDefaults()
 	dg := api.New(c)
 
-	return &DeepgramAPI{logger: logger, dg: dg}
+	return &DeepgramAPI{logger: logger, dg: dg, keyterms: loadKeyterms()}
+}
+
+// loadKeyterms reads DEEPGRAM_KEYTERMS, a comma-separated list that replaces
+// the default keyterms.
+func loadKeyterms() []string {
+	raw := os.Getenv("DEEPGRAM_KEYTERMS")
+	if raw == "" {
+		return defaultKeyterms
+	}
+	return strings.Split(raw, ",")
+}
+
+// requestKeyterms joins the configured keyterms with a request's own, like
+// the user's name, dropping blanks and repeats and keeping within
+// maxKeyterms. The request's come first, since they matter most.
+func requestKeyterms(configured []string, request []string) []string {
+	var terms []string
+	seen := map[string]bool{}
+	for _, term := range append(append([]string(nil), request...), configured...) {
+		term = strings.TrimSpace(term)
+		key := strings.ToLower(term)
+		if term == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		terms = append(terms, term)
+		if len(terms) == maxKeyterms {
+			break
+		}
+	}
+	return terms
 }
 
-func (d *DeepgramAPI) Transcribe(ctx context.Context, audioData []byte) (string, error) {
+func (d *DeepgramAPI) Name() string {
+	return "deepgram"
+}
+
+// Transcribe implements modelapi.STTProvider. Deepgram detects the format
+// itself, so the MIME type isn't needed.
+func (d *DeepgramAPI) Transcribe(ctx context.Context, request modelapi.TranscriptionRequest) (modelapi.Transcription, error) {
 	tracer := otel.Tracer("deepgramapi")
 	ctx, span := tracer.Start(ctx, "Transcribe")
 	defer span.End()
 
+	audioData := request.Audio
+
 	span.SetAttributes(attribute.Int("audio.data.size", len(audioData)))
 
 	logger := d.logger.Logger(ctx)
 
+	keyterms := requestKeyterms(d.keyterms, request.Keyterms)
+	span.SetAttributes(attribute.Int("keyterms", len(keyterms)))
+
 	options := &interfaces.PreRecordedTranscriptionOptions{
 		Punctuate:  true,
 		Diarize:    false,
 		Language:   "multi",
 		Utterances: true,
 		Model:      "nova-3",
+		Keyterm:    keyterms,
 	}
 
 	audioReader := bytes.NewReader(audioData)
@@ -54,21 +115,74 @@ func (d *DeepgramAPI) Transcribe(ctx context.Context, audioData []byte) (string,
 			zap.Error(err))
 		span.RecordError(err)
 		span.AddEvent("Deepgram API call failed")
-		return "", fmt.Errorf("deepgram transcription failed: %w", err)
+		return modelapi.Transcription{}, fmt.Errorf("deepgram transcription failed: %w", err)
 	}
 
 	if res != nil && res.Results != nil && res.Results.Channels != nil && len(res.Results.Channels) > 0 {
 		channel := res.Results.Channels[0]
 		if channel.Alternatives != nil && len(channel.Alternatives) > 0 {
-			transcription := channel.Alternatives[0].Transcript
+			alternative := channel.Alternatives[0]
+			transcription := modelapi.Transcription{
+				Text:       alternative.Transcript,
+				Languages:  alternative.Languages,
+				Confidence: utteranceConfidence(res.Results.Utterances, alternative.Confidence),
+			}
+			// Only multilingual models list every language; the rest detect one
+			if len(transcription.Languages) == 0 && channel.DetectedLanguage != "" {
+				transcription.Languages = []string{channel.DetectedLanguage}
+			}
+			if request.WordTimings {
+				transcription.Words = words(alternative.Words)
+			}
 			logger.Info("Successfully transcribed audio",
-				zap.String("transcription", transcription))
-			span.AddEvent("Transcription successful", trace.WithAttributes(attribute.Int("transcription.length", len(transcription))))
+				d.logger.Content("transcription", transcription.Text),
+				zap.Strings("languages", transcription.Languages),
+				zap.Float64("confidence", transcription.Confidence))
+			span.AddEvent("Transcription successful", trace.WithAttributes(
+				attribute.Int("transcription.length", len(transcription.Text)),
+				attribute.StringSlice("transcription.languages", transcription.Languages),
+				attribute.Float64("transcription.confidence", transcription.Confidence),
+			))
 			return transcription, nil
 		}
 	}
 
 	logger.Warn("No transcription found in response")
 	span.AddEvent("No transcription found in Deepgram response")
-	return "", fmt.Errorf("no transcription found in response")
+	return modelapi.Transcription{}, fmt.Errorf("no transcription found in response")
+}
+
+// words converts Deepgram's word timings, keeping the punctuation it added so
+// they read like the transcript.
+func words(timed []responses.Word) []modelapi.Word {
+	converted := make([]modelapi.Word, 0, len(timed))
+	for _, word := range timed {
+		text := word.PunctuatedWord
+		if text == "" {
+			text = word.Word
+		}
+		converted = append(converted, modelapi.Word{
+			Text:       text,
+			Start:      word.Start,
+			End:        word.End,
+			Confidence: word.Confidence,
+		})
+	}
+	return converted
+}
+
+// utteranceConfidence averages the utterances' confidence weighted by how long
+// each ran, so a mumbled "umm" doesn't sink a clear voice note. Without
+// utterances it's the transcript's own confidence.
+func utteranceConfidence(utterances []responses.Utterance, transcript float64) float64 {
+	var total, duration float64
+	for _, utterance := range utterances {
+		length := utterance.End - utterance.Start
+		total += utterance.Confidence * length
+		duration += length
+	}
+	if duration <= 0 {
+		return transcript
+	}
+	return total / duration
 }
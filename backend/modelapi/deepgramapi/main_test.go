@@ -0,0 +1,49 @@
+package deepgramapi
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	responses "github.com/deepgram/deepgram-go-sdk/pkg/api/listen/v1/rest/interfaces"
+)
+
+func TestRequestKeyterms(t *testing.T) {
+	got := requestKeyterms([]string{"Gulabo", "jaan", " "}, []string{" Aman ", "gulabo", ""})
+	if strings.Join(got, ",") != "Aman,gulabo,jaan" {
+		t.Errorf("requestKeyterms = %q, want the request's first without blanks or repeats", got)
+	}
+
+	var many []string
+	for i := range 150 {
+		many = append(many, fmt.Sprintf("term%d", i))
+	}
+	if got := requestKeyterms(many, []string{"Aman"}); len(got) != maxKeyterms || got[0] != "Aman" {
+		t.Errorf("got %d keyterms starting with %q, want %d starting with the request's", len(got), got[0], maxKeyterms)
+	}
+}
+
+func TestUtteranceConfidence(t *testing.T) {
+	if got := utteranceConfidence(nil, 0.7); got != 0.7 {
+		t.Errorf("without utterances got %g, want the transcript's 0.7", got)
+	}
+
+	// A long clear utterance outweighs a short mumbled one
+	got := utteranceConfidence([]responses.Utterance{
+		{Start: 0, End: 9, Confidence: 0.9},
+		{Start: 9, End: 10, Confidence: 0.2},
+	}, 0.5)
+	if got < 0.82 || got > 0.84 {
+		t.Errorf("utteranceConfidence = %g, want 0.83", got)
+	}
+}
+
+func TestWords(t *testing.T) {
+	got := words([]responses.Word{
+		{Word: "hello", PunctuatedWord: "Hello,", Start: 0.1, End: 0.5, Confidence: 0.9},
+		{Word: "jaan", Start: 0.6, End: 1},
+	})
+	if len(got) != 2 || got[0].Text != "Hello," || got[0].Start != 0.1 || got[1].Text != "jaan" || got[1].End != 1 {
+		t.Errorf("words = %+v", got)
+	}
+}
@@ -2,9 +2,14 @@ package deepinfraapi
 
 import (
 	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
 	"gulabodev/logger"
+	"gulabodev/modelapi"
 	"io"
 	"os"
+	"unicode/utf8"
 
 	// imported as openai
 	"go.opentelemetry.io/otel"
@@ -17,15 +22,49 @@ import (
 	"github.com/openai/openai-go/v2/packages/param"
 )
 
+// KOKORO_TTS, FLUX_IMAGE and BGE_EMBEDDINGS are the default Models.
 const (
 	KOKORO_TTS   = "hexgrad/Kokoro-82M"
 	KOKORO_VOICE = "hf_beta"
+	// Kokoro's default pace drags, so her usual rate is a little faster
+	KOKORO_SPEED = 1.15
+
+	FLUX_IMAGE = "black-forest-labs/FLUX-1-schnell"
+	// Portrait, like a phone photo
+	FLUX_IMAGE_SIZE = "768x1024"
+
+	// Multilingual, so Hinglish and Hindi messages embed as well as English.
+	// Its vectors are modelapi.EmbeddingDimensions long.
+	BGE_EMBEDDINGS = "BAAI/bge-m3"
 )
 
 type DeepInfra struct {
 	logger    *logger.LogMiddleware
 	semaphore *semaphore.Weighted
 	client    *openai.Client
+	models    Models
+}
+
+// Models are the DeepInfra models used for each kind of request.
+type Models struct {
+	// TTS speaks replies in Kokoro's voices
+	TTS string
+	// Image draws selfies
+	Image string
+	// Embeddings turns messages and memories into vectors for recall. Its
+	// vectors have to be modelapi.EmbeddingDimensions long, and moments
+	// stored by another model aren't searched.
+	Embeddings string
+}
+
+// loadModels reads DEEPINFRA_TTS_MODEL, DEEPINFRA_IMAGE_MODEL and
+// DEEPINFRA_EMBEDDING_MODEL.
+func loadModels(ctx context.Context, logger *logger.LogMiddleware) Models {
+	return Models{
+		TTS:        modelapi.LoadModel(ctx, logger, "DEEPINFRA_TTS_MODEL", KOKORO_TTS),
+		Image:      modelapi.LoadModel(ctx, logger, "DEEPINFRA_IMAGE_MODEL", FLUX_IMAGE),
+		Embeddings: modelapi.LoadModel(ctx, logger, "DEEPINFRA_EMBEDDING_MODEL", BGE_EMBEDDINGS),
+	}
 }
 
 type DeepInfraConnectProps struct {
@@ -48,23 +87,186 @@ func Connect(ctx context.Context, args DeepInfraConnectProps) *DeepInfra {
 		option.WithBaseURL("https://api.deepinfra.com/v1/openai"),
 	)
 
-	return &DeepInfra{logger: args.Logger, semaphore: sem, client: &client}
+	models := loadModels(ctx, args.Logger)
+	span.SetAttributes(
+		attribute.String("models.tts", models.TTS),
+		attribute.String("models.image", models.Image),
+		attribute.String("models.embeddings", models.Embeddings),
+	)
+
+	return &DeepInfra{logger: args.Logger, semaphore: sem, client: &client, models: models}
 }
 
 func (d *DeepInfra) GenerateSpeech(ctx context.Context, inputText string) ([]byte, error) {
-	d.logger.Logger(ctx).Info("[DeepInfraAPI] Generating speech", zap.String("inputText", inputText))
+	return d.GenerateSpeechWithVoice(ctx, inputText, KOKORO_VOICE, KOKORO_SPEED)
+}
+
+func (d *DeepInfra) GenerateSpeechWithVoice(ctx context.Context, inputText string, voice string, speed float64) ([]byte, error) {
+	d.logger.Logger(ctx).Info("[DeepInfraAPI] Generating speech", d.logger.Content("inputText", inputText), zap.String("voice", voice), zap.Float64("speed", speed))
 
 	res, err := d.client.Audio.Speech.New(ctx, openai.AudioSpeechNewParams{
 		ResponseFormat: openai.AudioSpeechNewParamsResponseFormatMP3,
-		Model:          KOKORO_TTS,
+		Model:          d.models.TTS,
 		Input:          inputText,
-		Voice:          KOKORO_VOICE,
-		Speed:          param.Opt[float64]{Value: 1.15},
+		Voice:          openai.AudioSpeechNewParamsVoice(voice),
+		Speed:          param.Opt[float64]{Value: speed},
 	})
+	if err != nil {
+		return nil, err
+	}
 	defer res.Body.Close()
 
 	// Read all bytes from the body
 	audioBytes, err := io.ReadAll(res.Body)
+	if err == nil {
+		modelapi.RecordUsage(ctx, modelapi.Usage{
+			Provider:   d.Name(),
+			Kind:       modelapi.UsageKindTTS,
+			Model:      d.models.TTS,
+			Characters: utf8.RuneCountInString(inputText),
+		})
+	}
 
 	return audioBytes, err
 }
+
+func (d *DeepInfra) Name() string {
+	return "kokoro"
+}
+
+// Synthesize implements modelapi.TTSProvider with Kokoro, which takes the
+// request's rate as its speed. Pitch is left to post-processing.
+func (d *DeepInfra) Synthesize(ctx context.Context, request modelapi.SpeechRequest) (modelapi.Speech, error) {
+	voice := request.Voice
+	if voice == "" {
+		voice = KOKORO_VOICE
+	}
+	speed := KOKORO_SPEED
+	if request.Rate > 0 {
+		speed *= request.Rate
+	}
+	audio, err := d.GenerateSpeechWithVoice(ctx, modelapi.PlainSpeech(request.Text), voice, speed)
+	return modelapi.Speech{Audio: audio, FileName: "response.mp3", RateApplied: true}, err
+}
+
+// Images is DeepInfra's FLUX as a modelapi.ImageProvider. Like Embeddings,
+// it's a separate value so its usage isn't put down to Kokoro.
+func (d *DeepInfra) Images() modelapi.ImageProvider {
+	return &images{deepinfra: d}
+}
+
+type images struct {
+	deepinfra *DeepInfra
+}
+
+func (i *images) Name() string {
+	return "deepinfra"
+}
+
+// GenerateImage renders a prompt with FLUX. The same seed and prompt give the
+// same image, which keeps a character looking the same from one image to the
+// next.
+func (i *images) GenerateImage(ctx context.Context, request modelapi.ImageRequest) ([]byte, error) {
+	tracer := otel.Tracer("deepinfraapi/GenerateImage")
+	ctx, span := tracer.Start(ctx, "GenerateImage")
+	defer span.End()
+
+	d := i.deepinfra
+	span.SetAttributes(attribute.String("model", d.models.Image), attribute.Int64("seed", request.Seed))
+	d.logger.Logger(ctx).Info("[DeepInfraAPI] Generating image", d.logger.Content("prompt", request.Prompt), zap.Int64("seed", request.Seed))
+
+	if err := d.semaphore.Acquire(ctx, 1); err != nil {
+		return nil, err
+	}
+	defer d.semaphore.Release(1)
+
+	res, err := d.client.Images.Generate(ctx, openai.ImageGenerateParams{
+		Model:          d.models.Image,
+		Prompt:         request.Prompt,
+		N:              param.NewOpt[int64](1),
+		Size:           FLUX_IMAGE_SIZE,
+		ResponseFormat: openai.ImageGenerateParamsResponseFormatB64JSON,
+	}, option.WithJSONSet("seed", request.Seed))
+	if err != nil {
+		span.RecordError(err)
+		d.logger.Logger(ctx).Error("[DeepInfraAPI] Failed to generate image", zap.Error(err))
+		return nil, err
+	}
+	if len(res.Data) == 0 {
+		return nil, errors.New("no image in response")
+	}
+
+	modelapi.RecordUsage(ctx, modelapi.Usage{
+		Provider: i.Name(),
+		Kind:     modelapi.UsageKindImage,
+		Model:    i.deepinfra.models.Image,
+		Images:   len(res.Data),
+	})
+	return base64.StdEncoding.DecodeString(res.Data[0].B64JSON)
+}
+
+// Embeddings is DeepInfra's BGE-M3 as a modelapi.EmbeddingProvider. It's a
+// separate value because DeepInfra's Name is Kokoro's, for TTS.
+func (d *DeepInfra) Embeddings() modelapi.EmbeddingProvider {
+	return &embeddings{deepinfra: d}
+}
+
+type embeddings struct {
+	deepinfra *DeepInfra
+}
+
+func (e *embeddings) Name() string {
+	return "deepinfra"
+}
+
+func (e *embeddings) Model() string {
+	return e.deepinfra.models.Embeddings
+}
+
+func (e *embeddings) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	tracer := otel.Tracer("deepinfraapi/Embed")
+	ctx, span := tracer.Start(ctx, "Embed")
+	defer span.End()
+
+	d := e.deepinfra
+	span.SetAttributes(attribute.String("model", d.models.Embeddings), attribute.Int("texts", len(texts)))
+
+	if err := d.semaphore.Acquire(ctx, 1); err != nil {
+		return nil, err
+	}
+	defer d.semaphore.Release(1)
+
+	res, err := d.client.Embeddings.New(ctx, openai.EmbeddingNewParams{
+		Model:          d.models.Embeddings,
+		Input:          openai.EmbeddingNewParamsInputUnion{OfArrayOfStrings: texts},
+		EncodingFormat: openai.EmbeddingNewParamsEncodingFormatFloat,
+	})
+	if err != nil {
+		span.RecordError(err)
+		d.logger.Logger(ctx).Error("[DeepInfraAPI] Failed to embed texts", zap.Error(err), zap.Int("texts", len(texts)))
+		return nil, err
+	}
+	if len(res.Data) != len(texts) {
+		return nil, fmt.Errorf("got %d embeddings for %d texts", len(res.Data), len(texts))
+	}
+
+	vectors := make([][]float32, len(texts))
+	for _, embedding := range res.Data {
+		if embedding.Index < 0 || int(embedding.Index) >= len(texts) {
+			return nil, fmt.Errorf("embedding index %d out of range", embedding.Index)
+		}
+		vector := make([]float32, len(embedding.Embedding))
+		for i, value := range embedding.Embedding {
+			vector[i] = float32(value)
+		}
+		vectors[embedding.Index] = vector
+	}
+
+	modelapi.RecordUsage(ctx, modelapi.Usage{
+		Provider:    e.Name(),
+		Kind:        modelapi.UsageKindEmbedding,
+		Model:       d.models.Embeddings,
+		InputTokens: int(res.Usage.PromptTokens),
+	})
+	return vectors, nil
+}
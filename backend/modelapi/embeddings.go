@@ -0,0 +1,95 @@
+package modelapi
+
+import (
+	"context"
+	"fmt"
+	"gulabodev/retry"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const (
+	// EmbeddingDimensions is the length of every vector an EmbeddingProvider
+	// returns, so vectors can share one column whichever provider made them
+	EmbeddingDimensions = 1024
+
+	// Both OpenAI and DeepInfra take a few hundred texts a request, but big
+	// batches take long enough that one failure costs a lot to redo
+	defaultEmbeddingBatchSize = 64
+	embeddingAttempts         = 3
+)
+
+// embeddingRetryDelay caps how long the first retry of a failed batch waits;
+// the cap doubles for each one after. Tests shorten it.
+var embeddingRetryDelay = time.Second
+
+// EmbeddingProvider turns text into vectors with one embedding model. Vectors
+// from different models can't be compared, so Model says which made them.
+type EmbeddingProvider interface {
+	Name() string
+	Model() string
+	// Embed returns one vector of EmbeddingDimensions per text, in order
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+}
+
+// BatchEmbeddings sends provider at most batchSize texts a request, or
+// defaultEmbeddingBatchSize if batchSize isn't positive, retrying each batch
+// that fails. A long history of messages and memory facts can then be embedded
+// in one call.
+func BatchEmbeddings(provider EmbeddingProvider, batchSize int) EmbeddingProvider {
+	if batchSize <= 0 {
+		batchSize = defaultEmbeddingBatchSize
+	}
+	return &batchedEmbeddings{provider: provider, batchSize: batchSize}
+}
+
+type batchedEmbeddings struct {
+	provider  EmbeddingProvider
+	batchSize int
+}
+
+func (b *batchedEmbeddings) Name() string {
+	return b.provider.Name()
+}
+
+func (b *batchedEmbeddings) Model() string {
+	return b.provider.Model()
+}
+
+func (b *batchedEmbeddings) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	tracer := otel.Tracer("modelapi/BatchEmbeddings")
+	ctx, span := tracer.Start(ctx, "Embed")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("embedding.provider", b.provider.Name()),
+		attribute.Int("embedding.texts", len(texts)),
+	)
+
+	vectors := make([][]float32, 0, len(texts))
+	for start := 0; start < len(texts); start += b.batchSize {
+		batch := texts[start:min(start+b.batchSize, len(texts))]
+		embedded, err := b.embedBatch(ctx, batch)
+		if err != nil {
+			span.RecordError(err)
+			return nil, err
+		}
+		vectors = append(vectors, embedded...)
+	}
+	return vectors, nil
+}
+
+// embedBatch embeds one batch, retrying with a growing delay. A provider that
+// returns the wrong number of vectors is treated like one that failed.
+func (b *batchedEmbeddings) embedBatch(ctx context.Context, batch []string) ([][]float32, error) {
+	policy := retry.Policy{Attempts: embeddingAttempts, BaseDelay: embeddingRetryDelay}
+	return retry.Do(ctx, policy, func(ctx context.Context) ([][]float32, error) {
+		vectors, err := b.provider.Embed(ctx, batch)
+		if err == nil && len(vectors) != len(batch) {
+			err = fmt.Errorf("got %d embeddings for %d texts", len(vectors), len(batch))
+		}
+		return vectors, err
+	})
+}
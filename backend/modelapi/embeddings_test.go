@@ -0,0 +1,58 @@
+package modelapi
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+type fakeEmbeddings struct {
+	batches []int
+	// failures is how many calls fail before one succeeds
+	failures int
+}
+
+func (f *fakeEmbeddings) Name() string {
+	return "deepinfra"
+}
+
+func (f *fakeEmbeddings) Model() string {
+	return "BAAI/bge-m3"
+}
+
+func (f *fakeEmbeddings) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	if f.failures > 0 {
+		f.failures--
+		return nil, errors.New("503")
+	}
+	f.batches = append(f.batches, len(texts))
+	var vectors [][]float32
+	for _, text := range texts {
+		vectors = append(vectors, []float32{float32(len(text))})
+	}
+	return vectors, nil
+}
+
+func TestBatchEmbeddings(t *testing.T) {
+	embeddingRetryDelay = 0
+	ctx := context.Background()
+	texts := []string{"a", "bb", "ccc", "dddd", "eeeee"}
+
+	fake := &fakeEmbeddings{failures: 2}
+	vectors, err := BatchEmbeddings(fake, 2).Embed(ctx, texts)
+	if err != nil {
+		t.Fatalf("Embed failed after retries: %v", err)
+	}
+	if len(fake.batches) != 3 || fake.batches[0] != 2 || fake.batches[2] != 1 {
+		t.Errorf("batches = %v, want [2 2 1]", fake.batches)
+	}
+	for i, vector := range vectors {
+		if int(vector[0]) != len(texts[i]) {
+			t.Errorf("vector %d = %v, out of order", i, vector)
+		}
+	}
+
+	if _, err := BatchEmbeddings(&fakeEmbeddings{failures: embeddingAttempts}, 2).Embed(ctx, texts); err == nil {
+		t.Error("Embed should fail once every attempt has")
+	}
+}
@@ -0,0 +1,94 @@
+package fakeapi
+
+import (
+	"context"
+	"encoding/json"
+	"gulabodev/modelapi"
+	"strings"
+	"sync"
+)
+
+// Chat is a ChatStreamer that answers with its scripted replies in turn,
+// starting over after the last. With none it echoes the message back.
+type Chat struct {
+	Faults
+
+	name string
+
+	mu       sync.Mutex
+	replies  []string
+	next     int
+	toolArgs string
+	requests []modelapi.ChatRequest
+}
+
+func NewChat(name string, replies ...string) *Chat {
+	return &Chat{name: name, replies: replies, toolArgs: "{}"}
+}
+
+func (c *Chat) Name() string {
+	return c.name
+}
+
+// SetToolArguments is the JSON GetResponseWithTools decodes, "{}" until set.
+func (c *Chat) SetToolArguments(arguments string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.toolArgs = arguments
+}
+
+// Requests are the requests the fake has been sent, in order.
+func (c *Chat) Requests() []modelapi.ChatRequest {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]modelapi.ChatRequest(nil), c.requests...)
+}
+
+func (c *Chat) GetResponse(ctx context.Context, request modelapi.ChatRequest) (string, error) {
+	c.record(request)
+	if err := c.call(ctx); err != nil {
+		return "", err
+	}
+	return c.reply(request), nil
+}
+
+func (c *Chat) GetResponseWithTools(ctx context.Context, request modelapi.ChatRequest, tool modelapi.ChatTool, v any) error {
+	c.record(request)
+	if err := c.call(ctx); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	arguments := c.toolArgs
+	c.mu.Unlock()
+	return json.Unmarshal([]byte(arguments), v)
+}
+
+// StreamResponse sends the reply a word at a time.
+func (c *Chat) StreamResponse(ctx context.Context, request modelapi.ChatRequest, onDelta func(string)) (string, error) {
+	c.record(request)
+	if err := c.call(ctx); err != nil {
+		return "", err
+	}
+	reply := c.reply(request)
+	for _, word := range strings.SplitAfter(reply, " ") {
+		onDelta(word)
+	}
+	return reply, nil
+}
+
+func (c *Chat) record(request modelapi.ChatRequest) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.requests = append(c.requests, request)
+}
+
+func (c *Chat) reply(request modelapi.ChatRequest) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.replies) == 0 {
+		return request.Message
+	}
+	reply := c.replies[c.next%len(c.replies)]
+	c.next++
+	return reply
+}
@@ -0,0 +1,73 @@
+// Package fakeapi has stand-ins for the chat, TTS and STT providers that
+// answer without a network or API keys, so the code built on them can be
+// tested. Their answers are deterministic, and each can be told to fail or
+// to take its time.
+package fakeapi
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Faults are the failures and latency programmed into a fake. Every fake
+// embeds them, and they're safe to change while the fake is in use.
+type Faults struct {
+	mu      sync.Mutex
+	latency time.Duration
+	err     error
+	// failures is how many more calls fail with err; negative fails them all
+	failures int
+	calls    int
+}
+
+// Fail makes the next times calls return err. A negative times fails every
+// call until Fail is called again; Fail(nil, 0) clears it.
+func (f *Faults) Fail(err error, times int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.err = err
+	f.failures = times
+}
+
+// SetLatency makes every call take d before answering, or until its context
+// is done.
+func (f *Faults) SetLatency(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.latency = d
+}
+
+// Calls is how many calls the fake has had, failed ones included.
+func (f *Faults) Calls() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.calls
+}
+
+// call counts a call, waits out the latency and returns the error it should
+// fail with, if any.
+func (f *Faults) call(ctx context.Context) error {
+	f.mu.Lock()
+	f.calls++
+	latency := f.latency
+	var err error
+	if f.err != nil && f.failures != 0 {
+		err = f.err
+		if f.failures > 0 {
+			f.failures--
+		}
+	}
+	f.mu.Unlock()
+
+	if latency > 0 {
+		timer := time.NewTimer(latency)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return err
+}
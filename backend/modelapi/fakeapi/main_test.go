@@ -0,0 +1,130 @@
+package fakeapi
+
+import (
+	"context"
+	"errors"
+	"gulabodev/audio"
+	"gulabodev/modelapi"
+	"strings"
+	"testing"
+	"time"
+)
+
+var (
+	_ modelapi.ChatStreamer = (*Chat)(nil)
+	_ modelapi.TTSProvider  = (*TTS)(nil)
+	_ modelapi.STTProvider  = (*STT)(nil)
+)
+
+func TestFaults(t *testing.T) {
+	down := errors.New("503")
+	var f Faults
+	f.Fail(down, 2)
+	for i, want := range []error{down, down, nil} {
+		if err := f.call(context.Background()); err != want {
+			t.Errorf("call %d = %v, want %v", i, err, want)
+		}
+	}
+
+	f.Fail(down, -1)
+	for i := range 5 {
+		if err := f.call(context.Background()); err != down {
+			t.Errorf("call %d = %v, want every call to fail", i, err)
+		}
+	}
+	f.Fail(nil, 0)
+	if err := f.call(context.Background()); err != nil {
+		t.Errorf("call = %v after clearing", err)
+	}
+	if f.Calls() != 9 {
+		t.Errorf("Calls = %d, want 9", f.Calls())
+	}
+}
+
+func TestFaultsLatency(t *testing.T) {
+	var f Faults
+	f.SetLatency(time.Hour)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := f.call(ctx); err != context.DeadlineExceeded {
+		t.Errorf("call = %v, want the context's error", err)
+	}
+}
+
+func TestChat(t *testing.T) {
+	ctx := context.Background()
+	echo := NewChat("echo")
+	if reply, err := echo.GetResponse(ctx, modelapi.ChatRequest{Message: "hi"}); err != nil || reply != "hi" {
+		t.Errorf("GetResponse = %q, %v, want the message back", reply, err)
+	}
+
+	chat := NewChat("groq", "one", "two")
+	var replies []string
+	for range 3 {
+		reply, _ := chat.GetResponse(ctx, modelapi.ChatRequest{})
+		replies = append(replies, reply)
+	}
+	if got := strings.Join(replies, ","); got != "one,two,one" {
+		t.Errorf("replies = %s, want them in turn", got)
+	}
+
+	var deltas []string
+	reply, err := NewChat("groq", "kaise ho baby").StreamResponse(ctx, modelapi.ChatRequest{}, func(delta string) {
+		deltas = append(deltas, delta)
+	})
+	if err != nil || reply != "kaise ho baby" || strings.Join(deltas, "") != reply || len(deltas) != 3 {
+		t.Errorf("StreamResponse = %q, %v with deltas %q", reply, err, deltas)
+	}
+
+	chat.SetToolArguments(`{"mood":"happy"}`)
+	var args struct{ Mood string }
+	if err := chat.GetResponseWithTools(ctx, modelapi.ChatRequest{Message: "tool"}, modelapi.ChatTool{}, &args); err != nil || args.Mood != "happy" {
+		t.Errorf("GetResponseWithTools = %+v, %v", args, err)
+	}
+	if requests := chat.Requests(); len(requests) != 4 || requests[3].Message != "tool" {
+		t.Errorf("Requests = %+v, want all four", requests)
+	}
+
+	chat.Fail(errors.New("429"), 1)
+	if _, err := chat.GetResponse(ctx, modelapi.ChatRequest{}); err == nil {
+		t.Errorf("GetResponse succeeded, want the programmed failure")
+	}
+}
+
+func TestTTS(t *testing.T) {
+	tts := NewTTS("openai")
+	short, err := tts.Synthesize(context.Background(), modelapi.SpeechRequest{Text: "hi", Voice: "sage"})
+	if err != nil {
+		t.Fatalf("Synthesize: %v", err)
+	}
+	long, _ := tts.Synthesize(context.Background(), modelapi.SpeechRequest{Text: "hi there, kaise ho?"})
+	if short.Provider != "openai" || short.FileName != audio.FileName(audio.WAV) {
+		t.Errorf("Synthesize = %s from %s", short.FileName, short.Provider)
+	}
+	if len(long.Audio) <= len(short.Audio) {
+		t.Errorf("longer text made %d bytes, shorter %d", len(long.Audio), len(short.Audio))
+	}
+	if requests := tts.Requests(); len(requests) != 2 || requests[0].Voice != "sage" {
+		t.Errorf("Requests = %+v", requests)
+	}
+}
+
+func TestSTT(t *testing.T) {
+	stt := NewSTT("deepgram",
+		modelapi.Transcription{Text: "kya haal hai", Confidence: 0.9, Words: []modelapi.Word{{Text: "kya"}}},
+		modelapi.Transcription{Text: "mumble", Confidence: 0.2},
+	)
+	first, _ := stt.Transcribe(context.Background(), modelapi.TranscriptionRequest{WordTimings: true})
+	second, _ := stt.Transcribe(context.Background(), modelapi.TranscriptionRequest{})
+	third, _ := stt.Transcribe(context.Background(), modelapi.TranscriptionRequest{})
+	if first.Text != "kya haal hai" || len(first.Words) != 1 || second.Text != "mumble" || third.Text != "kya haal hai" {
+		t.Errorf("transcriptions = %+v, %+v, %+v", first, second, third)
+	}
+	if third.Words != nil {
+		t.Errorf("words = %+v without asking for them", third.Words)
+	}
+
+	if got, _ := NewSTT("groq").Transcribe(context.Background(), modelapi.TranscriptionRequest{}); got.Text != "hello" {
+		t.Errorf("default transcription = %+v", got)
+	}
+}
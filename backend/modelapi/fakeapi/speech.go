@@ -0,0 +1,108 @@
+package fakeapi
+
+import (
+	"context"
+	"gulabodev/audio"
+	"gulabodev/modelapi"
+	"sync"
+	"unicode/utf8"
+)
+
+const (
+	speechSampleRate = 16000
+	// Each character of text comes out as this much silence, so longer lines
+	// make longer audio
+	speechMsPerRune = 20
+)
+
+// TTS is a TTSProvider that "speaks" every line as a WAV of silence, longer
+// the longer the text.
+type TTS struct {
+	Faults
+
+	name string
+
+	mu       sync.Mutex
+	requests []modelapi.SpeechRequest
+}
+
+func NewTTS(name string) *TTS {
+	return &TTS{name: name}
+}
+
+func (t *TTS) Name() string {
+	return t.name
+}
+
+// Requests are the requests the fake has been sent, in order.
+func (t *TTS) Requests() []modelapi.SpeechRequest {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]modelapi.SpeechRequest(nil), t.requests...)
+}
+
+func (t *TTS) Synthesize(ctx context.Context, request modelapi.SpeechRequest) (modelapi.Speech, error) {
+	t.mu.Lock()
+	t.requests = append(t.requests, request)
+	t.mu.Unlock()
+
+	if err := t.call(ctx); err != nil {
+		return modelapi.Speech{}, err
+	}
+	samples := utf8.RuneCountInString(request.Text) * speechMsPerRune * speechSampleRate / 1000
+	return modelapi.Speech{
+		Audio:    audio.PCMToWAV(make([]byte, samples*2), speechSampleRate, 1),
+		FileName: audio.FileName(audio.WAV),
+		Provider: t.name,
+	}, nil
+}
+
+// STT is an STTProvider that hears its scripted transcriptions in turn,
+// starting over after the last. With none every recording is "hello".
+type STT struct {
+	Faults
+
+	name string
+
+	mu             sync.Mutex
+	transcriptions []modelapi.Transcription
+	next           int
+	requests       []modelapi.TranscriptionRequest
+}
+
+func NewSTT(name string, transcriptions ...modelapi.Transcription) *STT {
+	if len(transcriptions) == 0 {
+		transcriptions = []modelapi.Transcription{{Text: "hello", Languages: []string{"en"}, Confidence: 1}}
+	}
+	return &STT{name: name, transcriptions: transcriptions}
+}
+
+func (s *STT) Name() string {
+	return s.name
+}
+
+// Requests are the requests the fake has been sent, in order.
+func (s *STT) Requests() []modelapi.TranscriptionRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]modelapi.TranscriptionRequest(nil), s.requests...)
+}
+
+func (s *STT) Transcribe(ctx context.Context, request modelapi.TranscriptionRequest) (modelapi.Transcription, error) {
+	s.mu.Lock()
+	s.requests = append(s.requests, request)
+	s.mu.Unlock()
+
+	if err := s.call(ctx); err != nil {
+		return modelapi.Transcription{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	transcription := s.transcriptions[s.next%len(s.transcriptions)]
+	s.next++
+	if !request.WordTimings {
+		transcription.Words = nil
+	}
+	return transcription, nil
+}
@@ -1,98 +1,126 @@
 package geminiapi
 
 import (
-	"bytes"
 	"context"
-	"encoding/binary"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"gulabodev/audio"
 	"gulabodev/logger"
 	"gulabodev/modelapi"
+	"gulabodev/modelapi/prompts"
+	"gulabodev/retry"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
+	"golang.org/x/sync/errgroup"
 	"google.golang.org/genai"
 )
 
 const (
+	// GEMINI_MODEL_NAME and GEMINI_TTS_MODEL_NAME are the default Models
 	GEMINI_MODEL_NAME     = "gemini-2.5-flash"
+	GEMINI_CONTEXT_WINDOW = 1048576
 	GEMINI_TTS_MODEL_NAME = "gemini-2.5-flash-preview-tts"
+	GEMINI_TTS_VOICE      = "Aoede"
+	// Gemini TTS returns raw 16-bit mono PCM at this rate
+	GEMINI_TTS_SAMPLE_RATE = 24000
 )
 
 type GeminiConnectProps struct {
 	Logger *logger.LogMiddleware
 }
 
-const (
-	maxRetries = 3
-	baseDelay  = 1 * time.Second
-)
+// retryPolicy spaces out retries of generations that failed or came back
+// empty.
+var retryPolicy = retry.Policy{
+	Attempts:  3,
+	BaseDelay: time.Second,
+	Budget:    30 * time.Second,
+}
+
+var errEmptyResponse = errors.New("empty response")
 
 type Gemini struct {
 	logger *logger.LogMiddleware
 	client *genai.Client
+	// limiter keeps generations within the account's quota
+	limiter *modelapi.QuotaLimiter
+	models  Models
 }
 
-func exponentialBackoff(attempt int) time.Duration {
-	tracer := otel.Tracer("geminiapi/exponentialBackoff")
-	_, span := tracer.Start(context.Background(), "exponentialBackoff")
-	defer span.End()
+// Models are the Gemini models used for each kind of request.
+type Models struct {
+	// Chat writes replies
+	Chat string
+	// Analysis fills in structured output, like memories, scenarios and what
+	// a voice note sounded like, and transcribes voice notes
+	Analysis string
+	// TTS speaks replies
+	TTS string
+}
 
-	span.SetAttributes(attribute.Int("attempt", attempt))
-	return baseDelay * time.Duration(1<<uint(attempt))
+// loadModels reads GEMINI_CHAT_MODEL, GEMINI_ANALYSIS_MODEL and
+// GEMINI_TTS_MODEL. Analysis follows the chat model unless it's set too.
+func loadModels(ctx context.Context, logger *logger.LogMiddleware) Models {
+	var models Models
+	models.Chat = modelapi.LoadModel(ctx, logger, "GEMINI_CHAT_MODEL", GEMINI_MODEL_NAME, textModel)
+	models.Analysis = modelapi.LoadModel(ctx, logger, "GEMINI_ANALYSIS_MODEL", models.Chat, textModel)
+	models.TTS = modelapi.LoadModel(ctx, logger, "GEMINI_TTS_MODEL", GEMINI_TTS_MODEL_NAME, speechModel)
+	return models
 }
 
-func convertPCMToWAV(ctx context.Context, pcmData []byte) ([]byte, error) {
-	tracer := otel.Tracer("geminiapi/convertPCMToWAV")
-	ctx, span := tracer.Start(ctx, "convertPCMToWAV")
-	defer span.End()
+// Gemini's speech models only speak, and its other models can't
+func textModel(model string) error {
+	if strings.Contains(model, "-tts") {
+		return fmt.Errorf("%s is a TTS model", model)
+	}
+	return nil
+}
 
-	// WAV file parameters
-	// Based on Google's JavaScript example: Gemini TTS outputs 24000 Hz PCM data
-	// Cartesia uses: Container: "wav", Encoding: "pcm_s16le", SampleRate: 48000
-	// Gemini uses: 24000 Hz, 16-bit, mono (confirmed from official example)
-	const (
-		sampleRate    = 24000 // Hz - Gemini's actual output rate (from Google's JS example)
-		bitsPerSample = 16    // bits - PCM_S16LE (signed 16-bit little-endian) - matches Cartesia
-		channels      = 1     // mono - matches Cartesia
-		byteRate      = sampleRate * channels * bitsPerSample / 8
-		blockAlign    = channels * bitsPerSample / 8
-	)
+func speechModel(model string) error {
+	if !strings.Contains(model, "-tts") {
+		return fmt.Errorf("%s isn't a TTS model", model)
+	}
+	return nil
+}
 
-	dataSize := len(pcmData)
-	fileSize := 36 + dataSize
-
-	var buf bytes.Buffer
-
-	// WAV header (44 bytes total)
-	buf.WriteString("RIFF")                                        // ChunkID
-	binary.Write(&buf, binary.LittleEndian, uint32(fileSize))      // ChunkSize
-	buf.WriteString("WAVE")                                        // Format
-	buf.WriteString("fmt ")                                        // Subchunk1ID
-	binary.Write(&buf, binary.LittleEndian, uint32(16))            // Subchunk1Size (16 for PCM)
-	binary.Write(&buf, binary.LittleEndian, uint16(1))             // AudioFormat (1 = PCM)
-	binary.Write(&buf, binary.LittleEndian, uint16(channels))      // NumChannels
-	binary.Write(&buf, binary.LittleEndian, uint32(sampleRate))    // SampleRate
-	binary.Write(&buf, binary.LittleEndian, uint32(byteRate))      // ByteRate
-	binary.Write(&buf, binary.LittleEndian, uint16(blockAlign))    // BlockAlign
-	binary.Write(&buf, binary.LittleEndian, uint16(bitsPerSample)) // BitsPerSample
-	buf.WriteString("data")                                        // Subchunk2ID
-	binary.Write(&buf, binary.LittleEndian, uint32(dataSize))      // Subchunk2Size
-
-	// Append PCM data
-	buf.Write(pcmData)
-
-	wavData := buf.Bytes()
-	span.SetAttributes(
-		attribute.Int("input_size", len(pcmData)),
-		attribute.Int("output_size", len(wavData)),
-	)
+// defaultQuota is tier 1's limit for Gemini 2.5 Flash.
+var defaultQuota = modelapi.Quota{RequestsPerMinute: 1000, TokensPerMinute: 1000000}
+
+// Roughly what Gemini counts an image as. Voice notes are counted the same,
+// since they're seldom more than several seconds at 32 tokens a second.
+const inlineDataTokens = 258
+
+// contentTokens estimates what a generation counts against the
+// tokens-per-minute quota, which Gemini counts by input.
+func contentTokens(systemPrompt string, contents []*genai.Content) int {
+	tokens := modelapi.CountTokens(systemPrompt)
+	for _, content := range contents {
+		for _, part := range content.Parts {
+			tokens += modelapi.CountTokens(part.Text)
+			if part.InlineData != nil {
+				tokens += inlineDataTokens
+			}
+		}
+	}
+	return tokens
+}
 
-	return wavData, nil
+// classify marks Gemini errors that retrying won't fix, like a bad request,
+// as permanent.
+func classify(err error) error {
+	var apiErr genai.APIError
+	if errors.As(err, &apiErr) && !retry.RetryableStatus(apiErr.Code) {
+		return retry.Permanent(err)
+	}
+	return err
 }
 
 func writeWAVToDebugFile(ctx context.Context, wavData []byte, logger *logger.LogMiddleware) {
@@ -142,7 +170,12 @@ func Connect(ctx context.Context, args GeminiConnectProps) *Gemini {
 
 	maxWorkers := 200
 
-	span.SetAttributes(attribute.Int("maxWorkers", maxWorkers))
+	quota := modelapi.LoadQuota(ctx, args.Logger, "GEMINI", defaultQuota)
+	span.SetAttributes(
+		attribute.Int("maxWorkers", maxWorkers),
+		attribute.Int("quota.requests_per_minute", quota.RequestsPerMinute),
+		attribute.Int("quota.tokens_per_minute", quota.TokensPerMinute),
+	)
 
 	GEMINI_KEY := os.Getenv("GEMINI_SECRET_KEY")
 
@@ -155,17 +188,23 @@ func Connect(ctx context.Context, args GeminiConnectProps) *Gemini {
 		os.Exit(21)
 	}
 
-	return &Gemini{logger: args.Logger, client: client}
+	models := loadModels(ctx, args.Logger)
+	span.SetAttributes(
+		attribute.String("models.chat", models.Chat),
+		attribute.String("models.analysis", models.Analysis),
+		attribute.String("models.tts", models.TTS),
+	)
+
+	return &Gemini{logger: args.Logger, client: client, limiter: modelapi.NewQuotaLimiter(quota), models: models}
 }
 
-func (g *Gemini) generateContentWithRetry(ctx context.Context, userPrompt string, systemPrompt string, tools []*genai.Tool, toolConfig *genai.ToolConfig) (*genai.GenerateContentResponse, error) {
+// generateContentWithRetry retries empty or failed generations with model.
+// Zero sampling fields leave Gemini's defaults.
+func (g *Gemini) generateContentWithRetry(ctx context.Context, model string, contents []*genai.Content, systemPrompt string, sampling modelapi.Sampling, tools []*genai.Tool, toolConfig *genai.ToolConfig) (*genai.GenerateContentResponse, error) {
 	tracer := otel.Tracer("geminiapi/generateContentWithRetry")
 	ctx, span := tracer.Start(ctx, "generateContentWithRetry")
 	defer span.End()
-	g.logger.Logger(ctx).Info("[GeminiAPI] generateContentWithRetry called", zap.Int("prompt.length", len(userPrompt)))
-
-	var resp *genai.GenerateContentResponse
-	var err error
+	g.logger.Logger(ctx).Info("[GeminiAPI] generateContentWithRetry called", zap.Int("contents", len(contents)))
 
 	thinkingBudget := int32(0)
 
@@ -188,12 +227,20 @@ func (g *Gemini) generateContentWithRetry(ctx context.Context, userPrompt string
 		},
 	}
 
-	for attempt := 0; attempt < maxRetries; attempt++ {
-		span.AddEvent("Attempt", trace.WithAttributes(attribute.Int("attemptNumber", attempt+1)))
-		g.logger.Logger(ctx).Info("[GeminiAPI] LLM generation attempt", zap.Int("attempt", attempt+1))
-		span.AddEvent("Attempt", trace.WithAttributes(attribute.Int("attemptNumber", attempt+1)))
-
-		resp, err = g.client.Models.GenerateContent(ctx, GEMINI_MODEL_NAME, genai.Text(userPrompt), &genai.GenerateContentConfig{
+	policy := retryPolicy
+	policy.OnRetry = func(attempt int, err error, delay time.Duration) {
+		g.logger.Logger(ctx).Warn("[GeminiAPI] Error generating LLM content, retrying...",
+			zap.Error(err),
+			zap.Int("attempt", attempt),
+			zap.Duration("delay", delay))
+	}
+	tokens := contentTokens(systemPrompt, contents)
+	resp, err := retry.Do(ctx, policy, func(ctx context.Context) (*genai.GenerateContentResponse, error) {
+		// Retries count against the quota too, so they queue like the rest
+		if err := g.limiter.Wait(ctx, tokens); err != nil {
+			return nil, err
+		}
+		config := &genai.GenerateContentConfig{
 			SystemInstruction: &genai.Content{Parts: []*genai.Part{{Text: systemPrompt}}},
 			SafetySettings:    safetySettings,
 			ToolConfig:        toolConfig,
@@ -202,61 +249,120 @@ func (g *Gemini) generateContentWithRetry(ctx context.Context, userPrompt string
 				IncludeThoughts: false,
 				ThinkingBudget:  &thinkingBudget,
 			},
-		})
-
-		if err != nil || resp == nil || len(resp.Candidates) == 0 || len(resp.Candidates[0].Content.Parts) == 0 {
-			if err != nil {
-				span.RecordError(err)
-				g.logger.Logger(ctx).Error("[GeminiAPI] Error generating LLM content", zap.Error(err), zap.Int("attempt", attempt+1))
-			} else {
-				g.logger.Logger(ctx).Warn("[GeminiAPI] Received empty or invalid LLM response", zap.Int("attempt", attempt+1))
-				span.AddEvent("EmptyResponse")
-			}
-			if err != nil {
-				g.logger.Logger(ctx).Warn("[GeminiAPI] Error generating LLM content, retrying...",
-					zap.Error(err),
-					zap.Int("attempt", attempt+1),
-					zap.Int("maxRetries", maxRetries))
-				span.RecordError(err)
-			} else {
-				g.logger.Logger(ctx).Warn("[GeminiAPI] Received empty or invalid response, retrying...",
-					zap.Int("attempt", attempt+1),
-					zap.Int("maxRetries", maxRetries))
-				span.AddEvent("EmptyResponse")
-			}
-
-			if attempt < maxRetries-1 {
-				delay := exponentialBackoff(attempt)
-				span.AddEvent("Backoff", trace.WithAttributes(attribute.Int64("delayMs", delay.Milliseconds())))
-				select {
-				case <-ctx.Done():
-					return nil, ctx.Err()
-				case <-time.After(delay):
-				}
-			}
-			continue
 		}
-
-		// If we get here, we have a valid response
-		break
-	}
-
-	// Final error check after all retries
+		if sampling.Temperature != 0 {
+			config.Temperature = &sampling.Temperature
+		}
+		if sampling.TopP != 0 {
+			config.TopP = &sampling.TopP
+		}
+		if sampling.MaxTokens != 0 {
+			config.MaxOutputTokens = int32(sampling.MaxTokens)
+		}
+		resp, err := g.client.Models.GenerateContent(ctx, model, contents, config)
+		if err != nil {
+			span.RecordError(err)
+			return nil, classify(err)
+		}
+		if resp == nil || len(resp.Candidates) == 0 || resp.Candidates[0].Content == nil || len(resp.Candidates[0].Content.Parts) == 0 {
+			span.AddEvent("EmptyResponse")
+			return nil, errEmptyResponse
+		}
+		return resp, nil
+	})
 	if err != nil {
 		g.logger.Logger(ctx).Error("[GeminiAPI] Final error generating LLM content after retries:", zap.Error(err))
 		return nil, err
 	}
 
+	g.recordUsage(ctx, modelapi.UsageKindChat, model, resp)
 	span.AddEvent("LLM generation successful")
 	return resp, nil
 }
 
+// recordUsage reports the tokens a request used.
+func (g *Gemini) recordUsage(ctx context.Context, kind string, model string, resp *genai.GenerateContentResponse) {
+	usage := modelapi.Usage{Provider: g.Name(), Kind: kind, Model: model}
+	if resp.UsageMetadata != nil {
+		usage.InputTokens = int(resp.UsageMetadata.PromptTokenCount)
+		usage.OutputTokens = int(resp.UsageMetadata.CandidatesTokenCount)
+	}
+	modelapi.RecordUsage(ctx, usage)
+}
+
 func (g *Gemini) GenerateSpeech(ctx context.Context, inputText string) ([]byte, error) {
+	return g.GenerateSpeechWithVoice(ctx, inputText, GEMINI_TTS_VOICE)
+}
+
+func (g *Gemini) GenerateSpeechWithVoice(ctx context.Context, inputText string, voiceName string) ([]byte, error) {
+	return g.GenerateSpeechWithStyle(ctx, inputText, voiceName, prompts.StyleData{}, 0)
+}
+
+// emotionDirections word each modelapi emotion the way Gemini TTS takes
+// direction, like a note to a voice actor.
+var emotionDirections = map[string]string{
+	modelapi.EmotionSeductive:  "Say this in a seductive, breathy whisper, slow and close to the mic.",
+	modelapi.EmotionSleepy:     "Say this sleepily, with a soft drowsy voice and a little yawn.",
+	modelapi.EmotionAnnoyed:    "Say this in an annoyed, sulky tone, short and pouting.",
+	modelapi.EmotionExcited:    "Say this excitedly, fast and giggly, full of energy.",
+	modelapi.EmotionBreathless: "Say this breathlessly, airy and out of breath, catching your breath between phrases.",
+}
+
+// GenerateSpeechWithStyle adds a note on how to deliver this line, like the
+// mood she's in, to the usual style instruction. A temperature of 0 uses
+// defaultSpeechTemperature.
+func (g *Gemini) GenerateSpeechWithStyle(ctx context.Context, inputText string, voiceName string, style prompts.StyleData, temperature float32) ([]byte, error) {
 	tracer := otel.Tracer("geminiapi/GenerateSpeech")
 	ctx, span := tracer.Start(ctx, "GenerateSpeech")
 	defer span.End()
-	g.logger.Logger(ctx).Info("[GeminiAPI] GenerateSpeech called", zap.Int("inputText.length", len(inputText)))
+	g.logger.Logger(ctx).Info("[GeminiAPI] GenerateSpeech called", zap.Int("inputText.length", len(inputText)), zap.String("voice", voiceName))
+
+	instructions := prompts.Render(prompts.StyleInstruction, style)
+	if temperature == 0 {
+		temperature = defaultSpeechTemperature
+	}
 
+	// One long request fails or gets cut off partway, so each chunk is its
+	// own request and the audio is joined back together
+	chunks := speechChunks(inputText, maxSpeechChunkChars)
+	span.SetAttributes(attribute.Int("speech.chunks", len(chunks)))
+
+	pcm := make([][]byte, len(chunks))
+	group, groupCtx := errgroup.WithContext(ctx)
+	group.SetLimit(maxParallelSpeechChunks)
+	for i, chunk := range chunks {
+		group.Go(func() error {
+			data, err := g.synthesizeChunk(groupCtx, instructions, chunk, voiceName, temperature)
+			if err != nil {
+				return err
+			}
+			pcm[i] = data
+			return nil
+		})
+	}
+	if err := group.Wait(); err != nil {
+		g.logger.Logger(ctx).Error("[GeminiAPI] Final error generating speech after retries:", zap.Error(err))
+		return nil, fmt.Errorf("failed to generate speech: %w", err)
+	}
+
+	span.AddEvent("Speech generation successful")
+	pcmData := joinPCM(pcm, GEMINI_TTS_SAMPLE_RATE)
+
+	wavData := audio.PCMToWAV(pcmData, GEMINI_TTS_SAMPLE_RATE, 1)
+
+	g.logger.Logger(ctx).Info("[GeminiAPI] Successfully converted PCM to WAV",
+		zap.Int("chunks", len(chunks)),
+		zap.Int("pcm_size", len(pcmData)),
+		zap.Int("wav_size", len(wavData)))
+
+	// Write debug file if enabled
+	writeWAVToDebugFile(ctx, wavData, g.logger)
+
+	return wavData, nil
+}
+
+// synthesizeChunk speaks one chunk of a reply and returns its raw PCM.
+func (g *Gemini) synthesizeChunk(ctx context.Context, instructions string, text string, voiceName string, temperature float32) ([]byte, error) {
 	userInstruction := fmt.Sprintf(`
   <SystemInstruction>
     %s
@@ -265,89 +371,74 @@ func (g *Gemini) GenerateSpeech(ctx context.Context, inputText string) ([]byte,
   <Speech>
     %s
   </Speech>
-  `, modelapi.STYLE_INSTRUCTION, inputText)
-
-	temperature := float32(1)
-
-	var response *genai.GenerateContentResponse
-	var err error
-
-	for attempt := 0; attempt < maxRetries; attempt++ {
-		span.AddEvent("Speech Generation Attempt", trace.WithAttributes(attribute.Int("attemptNumber", attempt+1)))
-		g.logger.Logger(ctx).Info("[GeminiAPI] Speech generation attempt", zap.Int("attempt", attempt+1))
-
-		response, err = g.client.Models.GenerateContent(ctx,
-			GEMINI_TTS_MODEL_NAME,
-			[]*genai.Content{
-				{Parts: []*genai.Part{
-					{Text: userInstruction},
-				}},
-			},
+  `, instructions, text)
+
+	policy := retryPolicy
+	policy.OnRetry = func(attempt int, err error, delay time.Duration) {
+		g.logger.Logger(ctx).Warn("[GeminiAPI] Speech generation failed, retrying...",
+			zap.Error(err),
+			zap.Int("attempt", attempt),
+			zap.Duration("delay", delay))
+	}
+	span := trace.SpanFromContext(ctx)
+	speech := []*genai.Content{{Parts: []*genai.Part{{Text: userInstruction}}}}
+	tokens := contentTokens("", speech)
+	response, err := retry.Do(ctx, policy, func(ctx context.Context) (*genai.GenerateContentResponse, error) {
+		if err := g.limiter.Wait(ctx, tokens); err != nil {
+			return nil, err
+		}
+		response, err := g.client.Models.GenerateContent(ctx,
+			g.models.TTS,
+			speech,
 			&genai.GenerateContentConfig{
 				Temperature:        &temperature,
 				ResponseModalities: []string{"audio"},
 				SpeechConfig: &genai.SpeechConfig{
 					VoiceConfig: &genai.VoiceConfig{
 						PrebuiltVoiceConfig: &genai.PrebuiltVoiceConfig{
-							VoiceName: "Aoede",
+							VoiceName: voiceName,
 						},
 					},
 				},
 			})
-
-		if err != nil || response == nil || response.Candidates == nil || len(response.Candidates) == 0 || response.Candidates[0].Content == nil || len(response.Candidates[0].Content.Parts) == 0 || response.Candidates[0].Content.Parts[0].InlineData == nil {
-			if err != nil {
-				span.RecordError(err)
-				g.logger.Logger(ctx).Error("[GeminiAPI] Error generating speech", zap.Error(err), zap.Int("attempt", attempt+1))
-			} else {
-				g.logger.Logger(ctx).Warn("[GeminiAPI] Received empty or invalid speech response", zap.Int("attempt", attempt+1))
-				span.AddEvent("EmptySpeechResponse")
-			}
-
-			if attempt < maxRetries-1 {
-				delay := exponentialBackoff(attempt)
-				span.AddEvent("Speech Backoff", trace.WithAttributes(attribute.Int64("delayMs", delay.Milliseconds())))
-				g.logger.Logger(ctx).Warn("[GeminiAPI] Speech generation failed, retrying...",
-					zap.Error(err),
-					zap.Int("attempt", attempt+1),
-					zap.Int("maxRetries", maxRetries))
-				select {
-				case <-ctx.Done():
-					return nil, ctx.Err()
-				case <-time.After(delay):
-				}
-			}
-			continue
+		if err != nil {
+			span.RecordError(err)
+			return nil, classify(err)
 		}
-
-		// If we get here, we have a valid response
-		break
-	}
-
-	// Final error check after all retries
-	if err != nil || response == nil || response.Candidates == nil || len(response.Candidates) == 0 || response.Candidates[0].Content == nil || len(response.Candidates[0].Content.Parts) == 0 || response.Candidates[0].Content.Parts[0].InlineData == nil {
-		g.logger.Logger(ctx).Error("[GeminiAPI] Final error generating speech after retries:", zap.Error(err))
-		return nil, fmt.Errorf("failed to generate speech after %d retries: %w", maxRetries, err)
-	}
-
-	span.AddEvent("Speech generation successful")
-	pcmData := response.Candidates[0].Content.Parts[0].InlineData.Data
-
-	// Convert PCM to WAV
-	wavData, err := convertPCMToWAV(ctx, pcmData)
+		if response == nil || len(response.Candidates) == 0 || response.Candidates[0].Content == nil || len(response.Candidates[0].Content.Parts) == 0 || response.Candidates[0].Content.Parts[0].InlineData == nil {
+			span.AddEvent("EmptySpeechResponse")
+			return nil, errEmptyResponse
+		}
+		return response, nil
+	})
 	if err != nil {
-		g.logger.Logger(ctx).Error("[GeminiAPI] Failed to convert PCM to WAV", zap.Error(err))
-		return nil, fmt.Errorf("failed to convert PCM to WAV: %w", err)
+		return nil, err
 	}
 
-	g.logger.Logger(ctx).Info("[GeminiAPI] Successfully converted PCM to WAV",
-		zap.Int("pcm_size", len(pcmData)),
-		zap.Int("wav_size", len(wavData)))
+	g.recordUsage(ctx, modelapi.UsageKindTTS, g.models.TTS, response)
+	return response.Candidates[0].Content.Parts[0].InlineData.Data, nil
+}
 
-	// Write debug file if enabled
-	writeWAVToDebugFile(ctx, wavData, g.logger)
+// ContextWindow implements modelapi.ContextWindowed.
+func (g *Gemini) ContextWindow() int {
+	return GEMINI_CONTEXT_WINDOW
+}
 
-	return wavData, nil
+func (g *Gemini) Name() string {
+	return "gemini"
+}
+
+// Synthesize implements modelapi.TTSProvider, defaulting to GEMINI_TTS_VOICE.
+func (g *Gemini) Synthesize(ctx context.Context, request modelapi.SpeechRequest) (modelapi.Speech, error) {
+	voice := request.Voice
+	if voice == "" {
+		voice = GEMINI_TTS_VOICE
+	}
+	audio, err := g.GenerateSpeechWithStyle(ctx, modelapi.PlainSpeech(request.Text), voice, prompts.StyleData{
+		Style:   request.Style,
+		Emotion: emotionDirections[request.Emotion],
+	}, request.Temperature)
+	return modelapi.Speech{Audio: audio, FileName: "response.wav"}, err
 }
 
 func (g *Gemini) GetResponseOnlyFunction() *genai.Tool {
@@ -581,3 +672,336 @@ func (g *Gemini) GetProgressInsightsFunction() *genai.Tool {
 		}},
 	}
 }
+
+// ProgressInsights is the generate_progress_insights tool's output.
+type ProgressInsights struct {
+	MotivationalSummary  string   `json:"motivationalSummary"`
+	TopMistakes          []string `json:"topMistakes"`
+	SuccessPatterns      []string `json:"successPatterns"`
+	NextSkillFocus       string   `json:"nextSkillFocus"`
+	ImprovementPlan      []string `json:"improvementPlan"`
+	TimelineExpectation  string   `json:"timelineExpectation"`
+	RecommendedScenarios []string `json:"recommendedScenarios"`
+	QuickWins            []string `json:"quickWins"`
+	WeeklyFocus          string   `json:"weeklyFocus"`
+}
+
+type ScenarioLocation struct {
+	Name              string `json:"name"`
+	Neighborhood      string `json:"neighborhood"`
+	City              string `json:"city"`
+	Type              string `json:"type"`
+	Vibe              string `json:"vibe"`
+	Time              string `json:"time"`
+	Situation         string `json:"situation"`
+	PersonDescription string `json:"personDescription"`
+}
+
+// Scenario is the generate_scenario tool's output.
+type Scenario struct {
+	Title           string           `json:"title"`
+	Description     string           `json:"description"`
+	DifficultyLevel int              `json:"difficultyLevel"`
+	Tags            []string         `json:"tags"`
+	Location        ScenarioLocation `json:"location"`
+}
+
+// WomanResponse is the generate_woman_response tool's output.
+type WomanResponse struct {
+	Response     string `json:"response"`
+	BodyLanguage string `json:"bodyLanguage"`
+}
+
+// decodeFunctionArgs copies a function call's arguments into a struct.
+func decodeFunctionArgs(args map[string]any, v any) error {
+	data, err := json.Marshal(args)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// chatContents turns a chat request into Gemini's turns, where the assistant
+// is called the model.
+func chatContents(request modelapi.ChatRequest) []*genai.Content {
+	var contents []*genai.Content
+	for _, message := range request.History {
+		var role genai.Role = genai.RoleUser
+		if message.Role == "assistant" {
+			role = genai.RoleModel
+		}
+		contents = append(contents, genai.NewContentFromParts(imageParts(message.Content, message.Images), role))
+	}
+	return append(contents, genai.NewContentFromParts(imageParts(request.Message, request.Images), genai.RoleUser))
+}
+
+// imageParts is a turn's text followed by its images.
+func imageParts(text string, images []modelapi.ChatImage) []*genai.Part {
+	parts := []*genai.Part{genai.NewPartFromText(text)}
+	for _, image := range images {
+		parts = append(parts, genai.NewPartFromBytes(image.Data, image.MimeType))
+	}
+	return parts
+}
+
+// toolSchema converts a tool's JSON schema into Gemini's.
+func toolSchema(parameter modelapi.ToolParameter) *genai.Schema {
+	schema := &genai.Schema{
+		Type:        genai.Type(strings.ToUpper(parameter.Type)),
+		Description: parameter.Description,
+		Enum:        parameter.Enum,
+		Required:    parameter.Required,
+	}
+	if parameter.Items != nil {
+		schema.Items = toolSchema(*parameter.Items)
+	}
+	if len(parameter.Properties) > 0 {
+		schema.Properties = map[string]*genai.Schema{}
+		for name, property := range parameter.Properties {
+			schema.Properties[name] = toolSchema(property)
+		}
+	}
+	return schema
+}
+
+// GetResponse implements modelapi.ChatProvider.
+func (g *Gemini) GetResponse(ctx context.Context, request modelapi.ChatRequest) (string, error) {
+	tracer := otel.Tracer("geminiapi/GetResponse")
+	ctx, span := tracer.Start(ctx, "GetResponse")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.Int("conversation_history_length", len(request.History)),
+		attribute.Int("images", len(request.Images)),
+	)
+
+	resp, err := g.generateContentWithRetry(ctx, g.models.Chat, chatContents(request), request.SystemPrompt, modelapi.Sampling{
+		Temperature: request.Temperature,
+		TopP:        request.TopP,
+		MaxTokens:   request.MaxTokens,
+	}, nil, nil)
+	if err != nil {
+		return "", err
+	}
+	if resp == nil || resp.Text() == "" {
+		return "", fmt.Errorf("no response received")
+	}
+	return resp.Text(), nil
+}
+
+// GetResponseWithTools implements modelapi.ChatProvider.
+func (g *Gemini) GetResponseWithTools(ctx context.Context, request modelapi.ChatRequest, tool modelapi.ChatTool, v any) error {
+	tracer := otel.Tracer("geminiapi/GetResponseWithTools")
+	ctx, span := tracer.Start(ctx, "GetResponseWithTools")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("tool", tool.Name))
+
+	err := g.generateStructured(ctx, request.SystemPrompt, chatContents(request), &genai.Tool{
+		FunctionDeclarations: []*genai.FunctionDeclaration{{
+			Name:        tool.Name,
+			Description: tool.Description,
+			Parameters:  toolSchema(tool.Parameters),
+		}},
+	}, v)
+	if err != nil {
+		span.RecordError(err)
+	}
+	return err
+}
+
+// GenerateScenario creates a conversation practice scenario from the user's
+// request.
+func (g *Gemini) GenerateScenario(ctx context.Context, request string) (Scenario, error) {
+	tracer := otel.Tracer("geminiapi/GenerateScenario")
+	ctx, span := tracer.Start(ctx, "GenerateScenario")
+	defer span.End()
+
+	scenario, err := GenerateStructured[Scenario](ctx, g, prompts.Render(prompts.ScenarioGeneration, nil), genai.Text(request), g.GetScenarioGenerationFunction())
+	if err != nil {
+		span.RecordError(err)
+		g.logger.Logger(ctx).Error("[GeminiAPI] Failed to generate scenario", zap.Error(err))
+		return Scenario{}, err
+	}
+
+	span.SetAttributes(attribute.String("scenario.title", scenario.Title))
+	return scenario, nil
+}
+
+// GenerateWomanResponse continues a practice conversation in character.
+// transcript holds the conversation so far, ending with the user's line.
+func (g *Gemini) GenerateWomanResponse(ctx context.Context, systemPrompt string, transcript string) (WomanResponse, error) {
+	tracer := otel.Tracer("geminiapi/GenerateWomanResponse")
+	ctx, span := tracer.Start(ctx, "GenerateWomanResponse")
+	defer span.End()
+
+	response, err := GenerateStructured[WomanResponse](ctx, g, systemPrompt, genai.Text(transcript), g.GetResponseOnlyFunction())
+	if err != nil {
+		span.RecordError(err)
+		g.logger.Logger(ctx).Error("[GeminiAPI] Failed to generate woman response", zap.Error(err))
+		return WomanResponse{}, err
+	}
+	return response, nil
+}
+
+// Analysis is the analyze_interaction tool's output.
+type Analysis struct {
+	EscalationScore int    `json:"escalationScore"`
+	VibeCheck       string `json:"vibeCheck"`
+	NextMove        struct {
+		ExampleLine []string `json:"exampleLine"`
+	} `json:"nextMove"`
+	Progress struct {
+		CurrentStage string `json:"currentStage"`
+	} `json:"progress"`
+	Why struct {
+		Analysis   string   `json:"analysis"`
+		NextAction []string `json:"nextAction"`
+		Reasoning  []string `json:"reasoning"`
+	} `json:"why"`
+}
+
+// AnalyzeInteraction coaches the user on a conversation. The transcript labels
+// the user's lines "You" and the woman's "Her".
+func (g *Gemini) AnalyzeInteraction(ctx context.Context, transcript string) (Analysis, error) {
+	tracer := otel.Tracer("geminiapi/AnalyzeInteraction")
+	ctx, span := tracer.Start(ctx, "AnalyzeInteraction")
+	defer span.End()
+
+	analysis, err := GenerateStructured[Analysis](ctx, g, prompts.Render(prompts.InteractionAnalysis, nil), genai.Text(transcript), g.GetAnalysisOnlyFunction())
+	if err != nil {
+		span.RecordError(err)
+		g.logger.Logger(ctx).Error("[GeminiAPI] Failed to analyze interaction", zap.Error(err))
+		return Analysis{}, err
+	}
+
+	span.SetAttributes(attribute.Int("analysis.escalation_score", analysis.EscalationScore))
+	return analysis, nil
+}
+
+// Transcribe implements modelapi.STTProvider. It's the second opinion when
+// Deepgram mishears a voice note, so it's tuned for the Hinglish users
+// actually speak.
+func (g *Gemini) Transcribe(ctx context.Context, request modelapi.TranscriptionRequest) (modelapi.Transcription, error) {
+	tracer := otel.Tracer("geminiapi/Transcribe")
+	ctx, span := tracer.Start(ctx, "Transcribe")
+	defer span.End()
+
+	audioData, mimeType := request.Audio, request.MimeType
+
+	span.SetAttributes(
+		attribute.Int("audio.data.size", len(audioData)),
+		attribute.String("audio.mime_type", mimeType),
+	)
+
+	temperature := float32(0)
+	thinkingBudget := int32(0)
+
+	contents := []*genai.Content{genai.NewContentFromParts([]*genai.Part{
+		genai.NewPartFromBytes(audioData, mimeType),
+		{Text: prompts.Render(prompts.Transcription, nil)},
+	}, genai.RoleUser)}
+	if err := g.limiter.Wait(ctx, contentTokens("", contents)); err != nil {
+		span.RecordError(err)
+		return modelapi.Transcription{}, err
+	}
+
+	response, err := g.client.Models.GenerateContent(ctx,
+		g.models.Analysis,
+		contents,
+		&genai.GenerateContentConfig{
+			Temperature: &temperature,
+			ThinkingConfig: &genai.ThinkingConfig{
+				IncludeThoughts: false,
+				ThinkingBudget:  &thinkingBudget,
+			},
+		})
+	if err != nil {
+		span.RecordError(err)
+		g.logger.Logger(ctx).Error("[GeminiAPI] Transcription failed", zap.Error(err))
+		return modelapi.Transcription{}, fmt.Errorf("gemini transcription failed: %w", err)
+	}
+	g.recordUsage(ctx, modelapi.UsageKindChat, g.models.Analysis, response)
+
+	transcription := strings.TrimSpace(response.Text())
+	if transcription == "" {
+		g.logger.Logger(ctx).Warn("[GeminiAPI] No transcription found in response")
+		return modelapi.Transcription{}, fmt.Errorf("no transcription found in response")
+	}
+
+	span.SetAttributes(attribute.Int("transcription.length", len(transcription)))
+	return modelapi.Transcription{Text: transcription}, nil
+}
+
+// VoiceEmotions are how the understand_voice_note tool can say the user sounds.
+var VoiceEmotions = []string{"happy", "excited", "flirty", "calm", "tired", "sad", "anxious", "angry"}
+
+func (g *Gemini) GetVoiceUnderstandingFunction() *genai.Tool {
+	return &genai.Tool{
+		FunctionDeclarations: []*genai.FunctionDeclaration{{
+			Name:        "understand_voice_note",
+			Description: "Transcribe the voice note and describe how the speaker sounds and where they seem to be",
+			Parameters: &genai.Schema{
+				Type: genai.TypeObject,
+				Properties: map[string]*genai.Schema{
+					"transcript": {
+						Type:        genai.TypeString,
+						Description: "Exactly what was said, with no quotes, labels or translation.",
+					},
+					"emotion": {
+						Type:        genai.TypeString,
+						Description: "How the speaker sounds from their tone of voice.",
+						Enum:        VoiceEmotions,
+					},
+					"context": {
+						Type:        genai.TypeString,
+						Description: "A few words on the background or setting worth reacting to, like 'traffic, sounds like they're driving'. Empty if there's nothing notable.",
+					},
+				},
+				Required: []string{"transcript", "emotion"},
+			},
+		}},
+	}
+}
+
+// VoiceNote is the understand_voice_note tool's output.
+type VoiceNote struct {
+	Transcript string `json:"transcript"`
+	Emotion    string `json:"emotion"`
+	Context    string `json:"context"`
+}
+
+// UnderstandVoice transcribes a voice note and picks up what Deepgram can't:
+// the emotion in the speaker's voice and the setting they're in.
+func (g *Gemini) UnderstandVoice(ctx context.Context, request modelapi.TranscriptionRequest) (VoiceNote, error) {
+	tracer := otel.Tracer("geminiapi/UnderstandVoice")
+	ctx, span := tracer.Start(ctx, "UnderstandVoice")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.Int("audio.data.size", len(request.Audio)),
+		attribute.String("audio.mime_type", request.MimeType),
+	)
+
+	contents := []*genai.Content{genai.NewContentFromParts([]*genai.Part{
+		genai.NewPartFromBytes(request.Audio, request.MimeType),
+	}, genai.RoleUser)}
+
+	note, err := GenerateStructured[VoiceNote](ctx, g, prompts.Render(prompts.VoiceUnderstanding, nil), contents, g.GetVoiceUnderstandingFunction())
+	if err != nil {
+		span.RecordError(err)
+		g.logger.Logger(ctx).Error("[GeminiAPI] Failed to understand voice note", zap.Error(err))
+		return VoiceNote{}, err
+	}
+
+	note.Transcript = strings.TrimSpace(note.Transcript)
+	if note.Transcript == "" {
+		return VoiceNote{}, fmt.Errorf("no transcription found in response")
+	}
+
+	span.SetAttributes(
+		attribute.Int("transcription.length", len(note.Transcript)),
+		attribute.String("voice.emotion", note.Emotion),
+	)
+	return note, nil
+}
@@ -0,0 +1,73 @@
+package geminiapi
+
+import (
+	"gulabodev/modelapi"
+	"testing"
+
+	"google.golang.org/genai"
+)
+
+func TestDecodeFunctionArgs(t *testing.T) {
+	// Gemini returns numbers as float64
+	args := map[string]any{
+		"title":           "Coffee Shop Approach",
+		"description":     "Start a chat with someone reading at a cafe.",
+		"difficultyLevel": float64(2),
+		"tags":            []any{"cafe", "daytime"},
+		"location": map[string]any{
+			"name":              "Blue Tokai",
+			"city":              "Mumbai",
+			"personDescription": "A designer in her twenties reading a novel.",
+		},
+	}
+
+	var scenario Scenario
+	if err := decodeFunctionArgs(args, &scenario); err != nil {
+		t.Fatalf("decodeFunctionArgs: %v", err)
+	}
+	if scenario.Title != "Coffee Shop Approach" || scenario.DifficultyLevel != 2 || len(scenario.Tags) != 2 {
+		t.Errorf("unexpected scenario: %+v", scenario)
+	}
+	if scenario.Location.Name != "Blue Tokai" || scenario.Location.PersonDescription == "" {
+		t.Errorf("unexpected location: %+v", scenario.Location)
+	}
+}
+
+func TestChatContents(t *testing.T) {
+	contents := chatContents(modelapi.ChatRequest{
+		History: []modelapi.ChatMessage{
+			{Role: "user", Content: "[Sent a photo]", Images: []modelapi.ChatImage{{Data: []byte("jpeg"), MimeType: "image/jpeg"}}},
+			{Role: "assistant", Content: "hey baby"},
+		},
+		Message: "[Sent a photo]",
+		Images:  []modelapi.ChatImage{{Data: []byte("jpeg"), MimeType: "image/jpeg"}},
+	})
+	if len(contents) != 3 {
+		t.Fatalf("len(contents) = %d, want 3", len(contents))
+	}
+	if contents[0].Role != genai.RoleUser || contents[1].Role != genai.RoleModel || contents[2].Role != genai.RoleUser {
+		t.Errorf("roles = %s, %s, %s", contents[0].Role, contents[1].Role, contents[2].Role)
+	}
+	if len(contents[2].Parts) != 2 || contents[2].Parts[1].InlineData == nil {
+		t.Errorf("the new message should carry the image: %+v", contents[2].Parts)
+	}
+	if len(contents[0].Parts) != 2 || contents[0].Parts[1].InlineData == nil {
+		t.Errorf("a photo in the history should still be sent: %+v", contents[0].Parts)
+	}
+	if len(contents[1].Parts) != 1 {
+		t.Errorf("a turn without images should be text only: %+v", contents[1].Parts)
+	}
+}
+
+func TestToolSchema(t *testing.T) {
+	schema := toolSchema(modelapi.ToolParameter{
+		Type: "object",
+		Properties: map[string]modelapi.ToolParameter{
+			"facts": {Type: "array", Items: &modelapi.ToolParameter{Type: "string"}},
+		},
+		Required: []string{"facts"},
+	})
+	if schema.Type != genai.TypeObject || schema.Properties["facts"].Type != genai.TypeArray || schema.Properties["facts"].Items.Type != genai.TypeString {
+		t.Errorf("unexpected schema: %+v", schema)
+	}
+}
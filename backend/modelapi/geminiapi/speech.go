@@ -0,0 +1,104 @@
+package geminiapi
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	// Gemini TTS starts dropping or cutting off speech well before its input
+	// limit, so long replies are spoken a chunk at a time
+	maxSpeechChunkChars = 800
+	// Chunks of one reply synthesized at once
+	maxParallelSpeechChunks = 3
+	// Silence between chunks, about the pause Gemini leaves between sentences
+	speechChunkGap = 150 // ms
+	// Lively without slurring; requests can ask for their own
+	defaultSpeechTemperature = 1
+
+	speechSentenceTerminators = ".!?।…\n"
+)
+
+// speechChunks splits text into chunks of at most limit runes, breaking
+// between sentences where it can and between words where a sentence alone is
+// too long. Text that fits stays whole.
+func speechChunks(text string, limit int) []string {
+	text = strings.TrimSpace(text)
+	if utf8.RuneCountInString(text) <= limit {
+		return []string{text}
+	}
+
+	var chunks []string
+	var current strings.Builder
+	length := 0
+	flush := func() {
+		if chunk := strings.TrimSpace(current.String()); chunk != "" {
+			chunks = append(chunks, chunk)
+		}
+		current.Reset()
+		length = 0
+	}
+	add := func(piece string) {
+		pieceLength := utf8.RuneCountInString(piece)
+		if length > 0 && length+pieceLength > limit {
+			flush()
+		}
+		current.WriteString(piece)
+		length += pieceLength
+	}
+
+	for _, sentence := range speechSentences(text) {
+		if utf8.RuneCountInString(sentence) <= limit {
+			add(sentence)
+			continue
+		}
+		for _, word := range strings.SplitAfter(sentence, " ") {
+			add(word)
+		}
+	}
+	flush()
+	return chunks
+}
+
+// speechSentences splits text after sentence-ending punctuation that is
+// followed by whitespace, keeping the punctuation with its sentence.
+func speechSentences(text string) []string {
+	runes := []rune(text)
+	var sentences []string
+	start := 0
+	for i, r := range runes {
+		if !strings.ContainsRune(speechSentenceTerminators, r) {
+			continue
+		}
+		if i+1 < len(runes) && !unicode.IsSpace(runes[i+1]) {
+			continue
+		}
+		sentences = append(sentences, string(runes[start:i+1]))
+		start = i + 1
+	}
+	if start < len(runes) {
+		sentences = append(sentences, string(runes[start:]))
+	}
+	return sentences
+}
+
+// joinPCM concatenates chunks of 16-bit mono PCM, in order, with a short
+// silence between them so sentences don't run into each other. A chunk with a
+// stray odd byte has it dropped, which would otherwise shift every sample
+// after it.
+func joinPCM(chunks [][]byte, sampleRate int) []byte {
+	if len(chunks) == 1 {
+		return chunks[0]
+	}
+
+	gap := make([]byte, sampleRate*speechChunkGap/1000*2)
+	var joined []byte
+	for i, chunk := range chunks {
+		if i > 0 {
+			joined = append(joined, gap...)
+		}
+		joined = append(joined, chunk[:len(chunk)&^1]...)
+	}
+	return joined
+}
@@ -0,0 +1,47 @@
+package geminiapi
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestSpeechChunks(t *testing.T) {
+	if chunks := speechChunks(" hey baby, kya kar rahe ho? ", 50); len(chunks) != 1 || chunks[0] != "hey baby, kya kar rahe ho?" {
+		t.Errorf("short text = %q, want it whole", chunks)
+	}
+
+	text := "Pehli line hai yeh. Doosri line thodi lambi hai! Teesri? Aur chauthi।"
+	chunks := speechChunks(text, 40)
+	want := []string{"Pehli line hai yeh.", "Doosri line thodi lambi hai! Teesri?", "Aur chauthi।"}
+	if strings.Join(chunks, "|") != strings.Join(want, "|") {
+		t.Errorf("chunks = %q, want %q", chunks, want)
+	}
+
+	// A sentence longer than the limit breaks between words
+	long := strings.Repeat("bahut ", 30) + "lamba."
+	for _, chunk := range speechChunks(long, 40) {
+		if utf8.RuneCountInString(chunk) > 40 {
+			t.Errorf("chunk %q is longer than the limit", chunk)
+		}
+		if strings.HasPrefix(chunk, " ") || strings.HasSuffix(chunk, " ") {
+			t.Errorf("chunk %q isn't trimmed", chunk)
+		}
+	}
+}
+
+func TestJoinPCM(t *testing.T) {
+	single := []byte{1, 2, 3, 4}
+	if joined := joinPCM([][]byte{single}, 1000); &joined[0] != &single[0] {
+		t.Error("a single chunk should be returned as it is")
+	}
+
+	joined := joinPCM([][]byte{{1, 2, 3}, {4, 5}}, 1000)
+	gap := 1000 * speechChunkGap / 1000 * 2
+	if len(joined) != 2+gap+2 {
+		t.Fatalf("len(joined) = %d, want %d", len(joined), 4+gap)
+	}
+	if joined[0] != 1 || joined[1] != 2 || joined[2] != 0 || joined[len(joined)-2] != 4 {
+		t.Errorf("joined = %v", joined)
+	}
+}
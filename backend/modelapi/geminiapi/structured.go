@@ -0,0 +1,172 @@
+package geminiapi
+
+import (
+	"context"
+	"fmt"
+	"gulabodev/modelapi"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"google.golang.org/genai"
+)
+
+// Gemini usually fixes arguments that don't match the schema once it's told
+// what's wrong; after this many tries it isn't going to.
+const maxSchemaRetries = 2
+
+// GenerateStructured has Gemini call the tool's function and returns its
+// arguments as a T. The arguments are checked against the function's schema
+// first, and any that don't match are sent back with what's wrong, so callers
+// never parse Gemini's output by hand.
+func GenerateStructured[T any](ctx context.Context, g *Gemini, systemPrompt string, contents []*genai.Content, tool *genai.Tool) (T, error) {
+	var result T
+	err := g.generateStructured(ctx, systemPrompt, contents, tool, &result)
+	return result, err
+}
+
+// generateStructured is GenerateStructured decoding into v, for callers that
+// don't know the type at compile time.
+func (g *Gemini) generateStructured(ctx context.Context, systemPrompt string, contents []*genai.Content, tool *genai.Tool, v any) error {
+	tracer := otel.Tracer("geminiapi/GenerateStructured")
+	ctx, span := tracer.Start(ctx, "GenerateStructured")
+	defer span.End()
+
+	declaration := tool.FunctionDeclarations[0]
+	span.SetAttributes(attribute.String("function", declaration.Name))
+
+	toolConfig := &genai.ToolConfig{
+		FunctionCallingConfig: &genai.FunctionCallingConfig{
+			Mode:                 genai.FunctionCallingConfigModeAny,
+			AllowedFunctionNames: []string{declaration.Name},
+		},
+	}
+
+	// Corrections are appended to a copy, leaving the caller's turns alone
+	contents = slices.Clip(contents)
+	for attempt := 0; ; attempt++ {
+		resp, err := g.generateContentWithRetry(ctx, g.models.Analysis, contents, systemPrompt, modelapi.Sampling{}, []*genai.Tool{tool}, toolConfig)
+		if err != nil {
+			span.RecordError(err)
+			return err
+		}
+		if resp == nil {
+			return fmt.Errorf("no response from gemini")
+		}
+
+		var call *genai.FunctionCall
+		for _, c := range resp.FunctionCalls() {
+			if c.Name == declaration.Name {
+				call = c
+				break
+			}
+		}
+		if call == nil {
+			err = fmt.Errorf("gemini did not call %s", declaration.Name)
+		} else if err = validateSchema(declaration.Parameters, call.Args, ""); err == nil {
+			if err = decodeFunctionArgs(call.Args, v); err == nil {
+				span.SetAttributes(attribute.Int("schema_retries", attempt))
+				return nil
+			}
+		}
+
+		span.AddEvent("SchemaViolation", trace.WithAttributes(attribute.String("error", err.Error())))
+		if attempt >= maxSchemaRetries {
+			span.RecordError(err)
+			return err
+		}
+		g.logger.Logger(ctx).Warn("[GeminiAPI] Structured output didn't match its schema, retrying",
+			zap.Error(err),
+			zap.String("function", declaration.Name),
+			zap.Int("attempt", attempt+1))
+
+		if call != nil {
+			contents = append(contents,
+				resp.Candidates[0].Content,
+				genai.NewContentFromFunctionResponse(declaration.Name, map[string]any{"error": err.Error()}, genai.RoleUser),
+			)
+		}
+	}
+}
+
+// validateSchema checks a function call's arguments against the schema Gemini
+// was given, which it doesn't always stick to. Arguments decode from JSON, so
+// numbers are float64s.
+func validateSchema(schema *genai.Schema, value any, path string) error {
+	if schema == nil {
+		return nil
+	}
+	name := path
+	if name == "" {
+		name = "arguments"
+	}
+
+	switch schema.Type {
+	case genai.TypeObject:
+		object, ok := value.(map[string]any)
+		if !ok {
+			return fmt.Errorf("%s should be an object", name)
+		}
+		for _, key := range schema.Required {
+			if _, ok := object[key]; !ok {
+				return fmt.Errorf("%s is required", joinPath(path, key))
+			}
+		}
+		// Sorted, so the same arguments always report the same error
+		keys := make([]string, 0, len(schema.Properties))
+		for key := range schema.Properties {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			if property, ok := object[key]; ok {
+				if err := validateSchema(schema.Properties[key], property, joinPath(path, key)); err != nil {
+					return err
+				}
+			}
+		}
+	case genai.TypeArray:
+		items, ok := value.([]any)
+		if !ok {
+			return fmt.Errorf("%s should be an array", name)
+		}
+		for i, item := range items {
+			if err := validateSchema(schema.Items, item, fmt.Sprintf("%s[%d]", name, i)); err != nil {
+				return err
+			}
+		}
+	case genai.TypeString:
+		s, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("%s should be a string", name)
+		}
+		if len(schema.Enum) > 0 && !slices.Contains(schema.Enum, s) {
+			return fmt.Errorf("%s should be one of %s", name, strings.Join(schema.Enum, ", "))
+		}
+	case genai.TypeInteger:
+		if n, ok := value.(float64); !ok || n != math.Trunc(n) {
+			return fmt.Errorf("%s should be an integer", name)
+		}
+	case genai.TypeNumber:
+		if _, ok := value.(float64); !ok {
+			return fmt.Errorf("%s should be a number", name)
+		}
+	case genai.TypeBoolean:
+		if _, ok := value.(bool); !ok {
+			return fmt.Errorf("%s should be a boolean", name)
+		}
+	}
+	return nil
+}
+
+func joinPath(path string, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
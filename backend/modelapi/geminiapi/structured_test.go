@@ -0,0 +1,52 @@
+package geminiapi
+
+import (
+	"testing"
+
+	"google.golang.org/genai"
+)
+
+func TestValidateSchema(t *testing.T) {
+	schema := &genai.Schema{
+		Type: genai.TypeObject,
+		Properties: map[string]*genai.Schema{
+			"transcript": {Type: genai.TypeString},
+			"emotion":    {Type: genai.TypeString, Enum: []string{"happy", "sad"}},
+			"score":      {Type: genai.TypeInteger},
+			"tags":       {Type: genai.TypeArray, Items: &genai.Schema{Type: genai.TypeString}},
+			"location": {
+				Type:       genai.TypeObject,
+				Properties: map[string]*genai.Schema{"city": {Type: genai.TypeString}},
+				Required:   []string{"city"},
+			},
+		},
+		Required: []string{"transcript"},
+	}
+
+	tests := []struct {
+		args map[string]any
+		want string
+	}{
+		{map[string]any{"transcript": "hi", "emotion": "happy", "score": float64(3), "tags": []any{"cafe"}, "location": map[string]any{"city": "Pune"}}, ""},
+		{map[string]any{"emotion": "happy"}, "transcript is required"},
+		{map[string]any{"transcript": "hi", "emotion": "flirty"}, "emotion should be one of happy, sad"},
+		{map[string]any{"transcript": "hi", "score": 2.5}, "score should be an integer"},
+		{map[string]any{"transcript": "hi", "tags": []any{"cafe", float64(1)}}, "tags[1] should be a string"},
+		{map[string]any{"transcript": "hi", "location": map[string]any{}}, "location.city is required"},
+		{map[string]any{"transcript": []any{"hi"}}, "transcript should be a string"},
+	}
+	for _, tt := range tests {
+		err := validateSchema(schema, tt.args, "")
+		got := ""
+		if err != nil {
+			got = err.Error()
+		}
+		if got != tt.want {
+			t.Errorf("validateSchema(%v) = %q, want %q", tt.args, got, tt.want)
+		}
+	}
+
+	if err := validateSchema(schema, "hi", ""); err == nil || err.Error() != "arguments should be an object" {
+		t.Errorf("non-object arguments: %v", err)
+	}
+}
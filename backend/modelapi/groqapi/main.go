@@ -1,25 +1,32 @@
 package groqapi
 
 import (
+	"bufio"
 	"bytes"
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"gulabodev/httpmiddleware"
 	"gulabodev/logger"
 	"gulabodev/modelapi"
+	"gulabodev/retry"
+	"io"
 	"math"
+	"mime/multipart"
+	"net/http"
 	"os"
+	"slices"
+	"strings"
 	"time"
 
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 	"golang.org/x/sync/semaphore"
 )
 
-type PropertyType string
-
 const (
 	ASSISTANT = "assistant"
 	SYSTEM    = "system"
@@ -27,37 +34,29 @@ const (
 )
 
 const (
-	maxRetries = 3
-	baseDelay  = 1 * time.Second
-)
-
-const (
-	PropertyTypeString  PropertyType = "string"
-	PropertyTypeNumber  PropertyType = "number"
-	PropertyTypeBoolean PropertyType = "boolean"
-	PropertyTypeObject  PropertyType = "object"
-	PropertyTypeArray   PropertyType = "array"
+	// chatModel, visionModel and transcriptionModel are the default Models
+	chatModel = "moonshotai/kimi-k2-instruct"
+	// The chat model is text only, so messages with images go to this one
+	visionModel = "meta-llama/llama-4-scout-17b-16e-instruct"
+	chatURL     = "https://api.groq.com/openai/v1/chat/completions"
+	// Both default models take 128K tokens
+	contextWindow = 131072
+	// Replies stop here unless the request sets its own cap
+	maxReplyTokens = 2048
+
+	transcriptionModel = "whisper-large-v3"
+	transcriptionURL   = "https://api.groq.com/openai/v1/audio/transcriptions"
+
+	// MaxImages is the most images Groq accepts in one request.
+	MaxImages = 5
+	// Roughly what Groq charges an image against the quota
+	imageTokens = 1024
 )
 
-type Property struct {
-	Type        PropertyType        `json:"type"`
-	Description string              `json:"description"`
-	Enum        []string            `json:"enum,omitempty"`
-	Items       *Property           `json:"items,omitempty"`
-	Properties  map[string]Property `json:"properties,omitempty"`
-	Required    []string            `json:"required,omitempty"`
-}
-
-type Parameters struct {
-	Type       PropertyType        `json:"type"`
-	Properties map[string]Property `json:"properties"`
-	Required   []string            `json:"required,omitempty"`
-}
-
 type Tool struct {
-	Name        string     `json:"name"`
-	Description string     `json:"description"`
-	Parameters  Parameters `json:"parameters"`
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	Parameters  modelapi.ToolParameter `json:"parameters"`
 }
 
 type ToolWrapper struct {
@@ -72,39 +71,86 @@ type ToolChoice struct {
 	} `json:"function"`
 }
 
+// forceTool makes the model call the named function instead of replying.
+func forceTool(name string) *ToolChoice {
+	choice := ToolChoice{Type: "function"}
+	choice.Function.Name = name
+	return &choice
+}
+
+// MessageContent is one part of a message with images: either text or an
+// image URL.
 type MessageContent struct {
-	Type   string    `json:"type"`
-	Text   *string   `json:"text,omitempty"`
-	Source *ImageUrl `json:"source,omitempty"`
+	Type     string    `json:"type"`
+	Text     string    `json:"text,omitempty"`
+	ImageURL *ImageURL `json:"image_url,omitempty"`
 }
 
-type ImageUrl struct {
-	Type      string `json:"type"`
-	Data      string `json:"data"`
-	MediaType string `json:"media_type"`
+type ImageURL struct {
+	URL string `json:"url"`
 }
 
-type ChatCompletionInputMessage struct {
-	Role    string `json:"role"`
-	Content string `json:"content"`
+// Image is a picture sent along with a user message.
+type Image = modelapi.ChatImage
+
+// multimodalMessage is a message with images, whose content is a list of
+// parts instead of a string.
+type multimodalMessage struct {
+	Role    string           `json:"role"`
+	Content []MessageContent `json:"content"`
 }
 
+type ChatCompletionInputMessage = modelapi.ChatMessage
+
 type ResponseFormat struct {
 	Type string `json:"type"`
+	// JSONSchema is set when Type is "json_schema"
+	JSONSchema *JSONSchema `json:"json_schema,omitempty"`
 }
 
+// JSONSchema is the shape structured output has to take.
+type JSONSchema struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description,omitempty"`
+	Schema      modelapi.ToolParameter `json:"schema"`
+}
+
+// Models usually fix output that doesn't match the schema once they're told
+// what's wrong; after this many tries they aren't going to.
+const maxSchemaRetries = 2
+
 type ChatRequestInput struct {
-	Model      string                       `json:"model"`
-	Messages   []ChatCompletionInputMessage `json:"messages"`
-	MaxTokens  int                          `json:"max_tokens"`
-	System     *string                      `json:"system,omitempty"`
-	Tools      *[]ToolWrapper               `json:"tools,omitempty"`
-	ToolChoice *ToolChoice                  `json:"tool_choice,omitempty"`
+	Model string `json:"model"`
+	// Messages are ChatCompletionInputMessage, or multimodalMessage for one
+	// with images
+	Messages   []any          `json:"messages"`
+	MaxTokens  int            `json:"max_tokens"`
+	System     *string        `json:"system,omitempty"`
+	Tools      *[]ToolWrapper `json:"tools,omitempty"`
+	ToolChoice *ToolChoice    `json:"tool_choice,omitempty"`
+	Stream     bool           `json:"stream,omitempty"`
+	// Temperature and TopP are left out when 0 so the model's defaults apply
+	Temperature float32 `json:"temperature,omitempty"`
+	TopP        float32 `json:"top_p,omitempty"`
+	// StreamOptions asks for the token usage at the end of a stream
+	StreamOptions *StreamOptions `json:"stream_options,omitempty"`
+	// ResponseFormat makes the reply JSON, matching a schema if it has one
+	ResponseFormat *ResponseFormat `json:"response_format,omitempty"`
+}
+
+type StreamOptions struct {
+	IncludeUsage bool `json:"include_usage"`
 }
 
 type GroqResponse struct {
 	Model   string   `json:"model"`
 	Choices []Choice `json:"choices"`
+	Usage   *Usage   `json:"usage"`
+}
+
+type Usage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
 }
 
 type Choice struct {
@@ -138,8 +184,39 @@ type GroqConnectProps struct {
 type Groq struct {
 	logger    *logger.LogMiddleware
 	semaphore *semaphore.Weighted
+	// limiter keeps chat and transcription requests within the account's quota
+	limiter *modelapi.QuotaLimiter
+	models  Models
+}
+
+// Models are the Groq models used for each kind of request.
+type Models struct {
+	// Chat writes replies
+	Chat string
+	// Vision writes replies to messages with images, which Chat may not see
+	Vision string
+	// Analysis makes tool calls and structured output, like memories and
+	// summaries
+	Analysis string
+	// Transcription turns voice notes into text
+	Transcription string
+}
+
+// loadModels reads GROQ_CHAT_MODEL, GROQ_VISION_MODEL, GROQ_ANALYSIS_MODEL
+// and GROQ_TRANSCRIPTION_MODEL. Analysis follows the chat model unless it's
+// set too.
+func loadModels(ctx context.Context, logger *logger.LogMiddleware) Models {
+	var models Models
+	models.Chat = modelapi.LoadModel(ctx, logger, "GROQ_CHAT_MODEL", chatModel)
+	models.Vision = modelapi.LoadModel(ctx, logger, "GROQ_VISION_MODEL", visionModel)
+	models.Analysis = modelapi.LoadModel(ctx, logger, "GROQ_ANALYSIS_MODEL", models.Chat)
+	models.Transcription = modelapi.LoadModel(ctx, logger, "GROQ_TRANSCRIPTION_MODEL", transcriptionModel)
+	return models
 }
 
+// defaultQuota is the developer tier's limit for the chat model.
+var defaultQuota = modelapi.Quota{RequestsPerMinute: 1000, TokensPerMinute: 250000}
+
 func Connect(ctx context.Context, args GroqConnectProps) *Groq {
 	tracer := otel.Tracer("groqapi/Connect")
 	ctx, span := tracer.Start(ctx, "Connect")
@@ -148,9 +225,22 @@ func Connect(ctx context.Context, args GroqConnectProps) *Groq {
 	maxWorkers := 10
 	sem := semaphore.NewWeighted(int64(maxWorkers))
 
-	span.SetAttributes(attribute.Int("maxWorkers", maxWorkers))
+	quota := modelapi.LoadQuota(ctx, args.Logger, "GROQ", defaultQuota)
+	span.SetAttributes(
+		attribute.Int("maxWorkers", maxWorkers),
+		attribute.Int("quota.requests_per_minute", quota.RequestsPerMinute),
+		attribute.Int("quota.tokens_per_minute", quota.TokensPerMinute),
+	)
 
-	return &Groq{logger: args.Logger, semaphore: sem}
+	models := loadModels(ctx, args.Logger)
+	span.SetAttributes(
+		attribute.String("models.chat", models.Chat),
+		attribute.String("models.vision", models.Vision),
+		attribute.String("models.analysis", models.Analysis),
+		attribute.String("models.transcription", models.Transcription),
+	)
+
+	return &Groq{logger: args.Logger, semaphore: sem, limiter: modelapi.NewQuotaLimiter(quota), models: models}
 }
 
 type MakeAPIRequestProps struct {
@@ -158,10 +248,12 @@ type MakeAPIRequestProps struct {
 	RequestInput ChatRequestInput
 }
 
-// Used for retry logic.
-func GetExponentialDelaySeconds(retryNumber int) int {
-	delayTime := int(5 * math.Pow(2, float64(retryNumber)))
-	return delayTime
+// retryPolicy spaces out retries of chat requests. Rate limits on the free
+// tier last a few seconds, so the waits start long.
+var retryPolicy = retry.Policy{
+	BaseDelay: 5 * time.Second,
+	MaxDelay:  20 * time.Second,
+	Budget:    45 * time.Second,
 }
 
 func (o *Groq) MakeAPIRequest(ctx context.Context, args MakeAPIRequestProps) (*GroqResponse, error) {
@@ -170,7 +262,7 @@ func (o *Groq) MakeAPIRequest(ctx context.Context, args MakeAPIRequestProps) (*G
 	defer span.End()
 
 	API_KEY := os.Getenv("GROQ_SECRET_KEY")
-	URL := "https://api.groq.com/openai/v1/chat/completions"
+	URL := chatURL
 
 	span.SetAttributes(
 		attribute.String("api.url", URL),
@@ -179,8 +271,6 @@ func (o *Groq) MakeAPIRequest(ctx context.Context, args MakeAPIRequestProps) (*G
 	)
 
 	chatGptInput := args.RequestInput
-	retries := args.Retries
-	originalRetries := args.Retries
 
 	jsonData, err := json.Marshal(chatGptInput)
 	if err != nil {
@@ -188,15 +278,27 @@ func (o *Groq) MakeAPIRequest(ctx context.Context, args MakeAPIRequestProps) (*G
 		return nil, fmt.Errorf("Could not generate request body: " + err.Error())
 	}
 
-	span.SetAttributes(attribute.Int("retries", retries))
-
-	for retries > 0 {
-		sleepTime := GetExponentialDelaySeconds(originalRetries - retries)
-		span.SetAttributes(attribute.Int("sleep_time", sleepTime))
-
+	span.SetAttributes(attribute.Int("retries", args.Retries))
+	tokens := requestTokens(chatGptInput)
+
+	policy := retryPolicy
+	policy.Attempts = args.Retries
+	policy.OnRetry = func(attempt int, err error, delay time.Duration) {
+		o.logger.Logger(ctx).Error(
+			"[Groq-API] Groq request failed. Retrying after sleeping.",
+			zap.Error(err),
+			zap.Int("attempt", attempt),
+			zap.Duration("sleep_time", delay),
+			o.logger.Content("input", string(jsonData)),
+		)
+	}
+	messageResponse, err := retry.Do(ctx, policy, func(ctx context.Context) (*GroqResponse, error) {
+		// Retries count against the quota too, so they queue like the rest
+		if err := o.limiter.Wait(ctx, tokens); err != nil {
+			return nil, err
+		}
 		if err := o.semaphore.Acquire(ctx, 1); err != nil {
-			span.RecordError(err)
-			return nil, fmt.Errorf("Failed to acquire semaphore.")
+			return nil, err
 		}
 		defer o.semaphore.Release(1)
 
@@ -208,79 +310,148 @@ func (o *Groq) MakeAPIRequest(ctx context.Context, args MakeAPIRequestProps) (*G
 				"authorization": "Bearer " + API_KEY,
 				"content-type":  "application/json",
 			},
+			Context: ctx,
 		})
-
 		if err != nil {
 			span.RecordError(err)
-			o.logger.Logger(ctx).Error(
-				"[Groq-API] Could not make request to Groq. Retrying after sleeping.",
-				zap.Error(err),
-				zap.Int("retries_left", retries),
-				zap.Int("sleep_time", sleepTime),
-				zap.Any("input", chatGptInput),
-			)
-			retries -= 1
-			time.Sleep(time.Duration(sleepTime) * time.Second)
-		} else {
-			var messageResponse GroqResponse
-			err = json.Unmarshal(respBody, &messageResponse)
-			if err != nil || len(messageResponse.Choices) == 0 {
-				span.RecordError(err)
-				retries -= 1
-				o.logger.Logger(ctx).Error(
-					"[Groq-API] Could not parse Groq Request. Retrying after sleeping.",
-					zap.Int("retries_left", retries),
-					zap.Int("sleep_time", sleepTime),
-					zap.Any("input", chatGptInput),
-					zap.Error(err),
-					zap.String("response_body", string(respBody)),
-					zap.Int("content_length", len(messageResponse.Choices)),
-				)
-				time.Sleep(time.Duration(sleepTime) * time.Second)
-			} else {
-				span.AddEvent("Request successful")
-				return &messageResponse, nil
-			}
+			return nil, err
 		}
+
+		var messageResponse GroqResponse
+		if err := json.Unmarshal(respBody, &messageResponse); err != nil {
+			span.RecordError(err)
+			return nil, fmt.Errorf("could not parse Groq response: %w", err)
+		}
+		if len(messageResponse.Choices) == 0 {
+			return nil, fmt.Errorf("Groq response has no choices: %s", respBody)
+		}
+		return &messageResponse, nil
+	})
+	if err != nil {
+		span.AddEvent("All retries exhausted")
+		o.logger.Logger(ctx).Error("[Groq-API] Groq request failed", zap.Error(err), o.logger.Content("input", string(jsonData)))
+		return nil, fmt.Errorf("Groq Requests Failed: %w", err)
 	}
 
-	span.AddEvent("All retries exhausted")
-	return nil, fmt.Errorf("Groq Requests Failed")
+	span.AddEvent("Request successful")
+	recordUsage(ctx, chatGptInput.Model, messageResponse.Usage)
+	return messageResponse, nil
 }
 
-func (a *Groq) GetResponse(ctx context.Context, conversationHistory []ChatCompletionInputMessage, newUserMessage string) (string, error) {
-	tracer := otel.Tracer("groqapi/GetResponse")
-	ctx, span := tracer.Start(ctx, "GetResponse")
-	defer span.End()
+// requestTokens estimates what a request counts against the tokens-per-minute
+// quota: Groq counts the prompt and the most the reply could take. Images are
+// counted at a flat rate, not by the size of their base64.
+func requestTokens(input ChatRequestInput) int {
+	tokens := input.MaxTokens
+	for _, message := range input.Messages {
+		switch message := message.(type) {
+		case ChatCompletionInputMessage:
+			tokens += modelapi.CountTokens(message.Content)
+		case multimodalMessage:
+			for _, part := range message.Content {
+				if part.ImageURL != nil {
+					tokens += imageTokens
+				} else {
+					tokens += modelapi.CountTokens(part.Text)
+				}
+			}
+		}
+	}
+	return tokens
+}
 
-	span.SetAttributes(
-		attribute.Int("conversation_history_length", len(conversationHistory)),
-		attribute.String("new_user_message", newUserMessage),
-	)
+// recordUsage reports the tokens a request used.
+func recordUsage(ctx context.Context, model string, usage *Usage) {
+	reported := modelapi.Usage{Provider: "groq", Kind: modelapi.UsageKindChat, Model: model}
+	if usage != nil {
+		reported.InputTokens = usage.PromptTokens
+		reported.OutputTokens = usage.CompletionTokens
+	}
+	modelapi.RecordUsage(ctx, reported)
+}
 
-	// Build messages array with system prompt + conversation history + new message
-	messages := []ChatCompletionInputMessage{
-		{
+// buildMessages prepends the system prompt to the history and appends the new user message.
+func buildMessages(systemPrompt string, conversationHistory []ChatCompletionInputMessage, newUserMessage string, images []Image) []any {
+	messages := []any{
+		ChatCompletionInputMessage{
 			Role:    SYSTEM,
-			Content: modelapi.SYSTEM_PROMPT_NORMAL,
+			Content: systemPrompt,
 		},
 	}
 
 	// Add conversation history
-	messages = append(messages, conversationHistory...)
+	for _, message := range conversationHistory {
+		messages = append(messages, withImages(message.Role, message.Content, message.Images))
+	}
 
 	// Add new user message
-	messages = append(messages, ChatCompletionInputMessage{
-		Role:    USER,
-		Content: newUserMessage,
+	return append(messages, withImages(USER, newUserMessage, images))
+}
+
+// withImages is a message with its images as parts after the text, or a plain
+// message if it has none.
+func withImages(role string, text string, images []Image) any {
+	if len(images) == 0 {
+		return ChatCompletionInputMessage{Role: role, Content: text}
+	}
+	parts := []MessageContent{{Type: "text", Text: text}}
+	for _, image := range images {
+		parts = append(parts, MessageContent{
+			Type:     "image_url",
+			ImageURL: &ImageURL{URL: "data:" + image.MimeType + ";base64," + base64.StdEncoding.EncodeToString(image.Data)},
+		})
+	}
+	return multimodalMessage{Role: role, Content: parts}
+}
+
+// replyModel picks the vision model when the request has images anywhere.
+func (a *Groq) replyModel(request modelapi.ChatRequest) string {
+	if request.HasImages() {
+		return a.models.Vision
+	}
+	return a.models.Chat
+}
+
+func (a *Groq) Name() string {
+	return "groq"
+}
+
+// ContextWindow implements modelapi.ContextWindowed.
+func (a *Groq) ContextWindow() int {
+	return contextWindow
+}
+
+// GetResponseWithPrompt replies to newUserMessage, with optional images, after
+// the system prompt and conversation history.
+func (a *Groq) GetResponseWithPrompt(ctx context.Context, systemPrompt string, conversationHistory []ChatCompletionInputMessage, newUserMessage string, images ...Image) (string, error) {
+	return a.GetResponse(ctx, modelapi.ChatRequest{
+		SystemPrompt: systemPrompt,
+		History:      conversationHistory,
+		Message:      newUserMessage,
+		Images:       images,
 	})
+}
+
+// GetResponse implements modelapi.ChatProvider.
+func (a *Groq) GetResponse(ctx context.Context, request modelapi.ChatRequest) (string, error) {
+	tracer := otel.Tracer("groqapi/GetResponse")
+	ctx, span := tracer.Start(ctx, "GetResponse")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.Int("conversation_history_length", len(request.History)),
+		attribute.Int("new_user_message.length", len(request.Message)),
+		attribute.Int("images", len(request.Images)),
+	)
 
 	requestInput := MakeAPIRequestProps{
 		Retries: 3,
 		RequestInput: ChatRequestInput{
-			Model:     "moonshotai/kimi-k2-instruct",
-			MaxTokens: 2048,
-			Messages:  messages,
+			Model:       a.replyModel(request),
+			MaxTokens:   request.ReplyTokens(maxReplyTokens),
+			Messages:    buildMessages(request.SystemPrompt, request.History, request.Message, request.Images),
+			Temperature: request.Temperature,
+			TopP:        request.TopP,
 		},
 	}
 
@@ -296,3 +467,453 @@ func (a *Groq) GetResponse(ctx context.Context, conversationHistory []ChatComple
 
 	return resp.Choices[0].Message.Content, nil
 }
+
+// GetResponseWithTools implements modelapi.ChatProvider. Tool calls only
+// work with the chat model, so images aren't sent.
+func (a *Groq) GetResponseWithTools(ctx context.Context, request modelapi.ChatRequest, tool modelapi.ChatTool, v any) error {
+	tracer := otel.Tracer("groqapi/GetResponseWithTools")
+	ctx, span := tracer.Start(ctx, "GetResponseWithTools")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("tool", tool.Name))
+
+	requestInput := MakeAPIRequestProps{
+		Retries: 3,
+		RequestInput: ChatRequestInput{
+			Model:       a.models.Analysis,
+			MaxTokens:   512,
+			Messages:    buildMessages(request.SystemPrompt, request.History, request.Message, nil),
+			Temperature: request.Temperature,
+			TopP:        request.TopP,
+			Tools: &[]ToolWrapper{
+				{
+					Type: "function",
+					Function: Tool{
+						Name:        tool.Name,
+						Description: tool.Description,
+						Parameters:  tool.Parameters,
+					},
+				},
+			},
+			ToolChoice: forceTool(tool.Name),
+		},
+	}
+
+	resp, err := a.MakeAPIRequest(ctx, requestInput)
+	if err != nil {
+		return err
+	}
+
+	arguments, ok := toolCallArguments(resp.Choices[0].Message, tool.Name)
+	if !ok {
+		err := fmt.Errorf("no %s tool call received", tool.Name)
+		span.RecordError(err)
+		return err
+	}
+
+	if err := parseToolArguments(arguments, v); err != nil {
+		span.RecordError(err)
+		return fmt.Errorf("Could not parse tool arguments: %w", err)
+	}
+	return nil
+}
+
+// GetResponseWithSchema is GetResponseWithTools with structured output
+// instead of a tool call: the reply itself is JSON matching the schema's
+// parameters, decoded into v. It suits extraction, where there's nothing for
+// a tool to do. Output that isn't valid JSON or doesn't match the schema is
+// sent back with what's wrong.
+func (a *Groq) GetResponseWithSchema(ctx context.Context, request modelapi.ChatRequest, schema modelapi.ChatTool, v any) error {
+	tracer := otel.Tracer("groqapi/GetResponseWithSchema")
+	ctx, span := tracer.Start(ctx, "GetResponseWithSchema")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("schema", schema.Name))
+
+	format := &ResponseFormat{
+		Type: "json_schema",
+		JSONSchema: &JSONSchema{
+			Name:        schema.Name,
+			Description: schema.Description,
+			Schema:      schema.Parameters,
+		},
+	}
+
+	// Corrections are appended to a copy, leaving the caller's history alone
+	history := slices.Clip(request.History)
+	message := request.Message
+	for attempt := 0; ; attempt++ {
+		resp, err := a.MakeAPIRequest(ctx, MakeAPIRequestProps{
+			Retries: 3,
+			RequestInput: ChatRequestInput{
+				Model:          a.models.Analysis,
+				MaxTokens:      512,
+				Messages:       buildMessages(request.SystemPrompt, history, message, nil),
+				Temperature:    request.Temperature,
+				TopP:           request.TopP,
+				ResponseFormat: format,
+			},
+		})
+		if err != nil {
+			span.RecordError(err)
+			return err
+		}
+
+		output := resp.Choices[0].Message.Content
+		if err = decodeStructured(output, schema.Parameters, v); err == nil {
+			span.SetAttributes(attribute.Int("schema_retries", attempt))
+			return nil
+		}
+
+		span.AddEvent("SchemaViolation", trace.WithAttributes(attribute.String("error", err.Error())))
+		if attempt >= maxSchemaRetries {
+			span.RecordError(err)
+			return err
+		}
+		a.logger.Logger(ctx).Warn("[Groq-API] Structured output didn't match its schema, retrying",
+			zap.Error(err),
+			zap.String("schema", schema.Name),
+			zap.Int("attempt", attempt+1))
+
+		history = append(history,
+			ChatCompletionInputMessage{Role: USER, Content: message},
+			ChatCompletionInputMessage{Role: ASSISTANT, Content: output},
+		)
+		message = fmt.Sprintf("That doesn't match the %s schema: %s. Reply again with only the corrected JSON.", schema.Name, err)
+	}
+}
+
+// decodeStructured checks structured output against the schema it was asked
+// to match and decodes it into v.
+func decodeStructured(output string, schema modelapi.ToolParameter, v any) error {
+	var value any
+	if err := json.Unmarshal([]byte(output), &value); err != nil {
+		return fmt.Errorf("output is not valid JSON: %w", err)
+	}
+	if err := modelapi.ValidateSchema(schema, value); err != nil {
+		return err
+	}
+	return json.Unmarshal([]byte(output), v)
+}
+
+// toolCallArguments finds the arguments of the call to the named function.
+// Some models call other functions first, even when one is forced.
+func toolCallArguments(message Message, name string) (json.RawMessage, bool) {
+	for _, call := range message.ToolCalls {
+		if call.Function.Name == name {
+			return call.Function.Arguments, true
+		}
+	}
+	return nil, false
+}
+
+// GirlfriendResponse is a reply with what she's doing as she says it, kept
+// apart so the words can be spoken and the body language shown.
+type GirlfriendResponse struct {
+	Response     string `json:"response"`
+	BodyLanguage string `json:"bodyLanguage"`
+}
+
+var girlfriendResponseTool = modelapi.ChatTool{
+	Name:        "generate_girlfriend_response",
+	Description: "Reply to your lover in character, with a concise description of your body language",
+	Parameters: modelapi.ToolParameter{
+		Type: "object",
+		Properties: map[string]modelapi.ToolParameter{
+			"response": {
+				Type:        "string",
+				Description: "What you say. Should not include body language descriptions.",
+			},
+			"bodyLanguage": {
+				Type:        "string",
+				Description: "Ultra-concise body language description (4-5 words + emojis). Example: 'Smiles, plays with hair 😊✨' or 'Bites lip, leans closer 😏'",
+			},
+		},
+		Required: []string{"response", "bodyLanguage"},
+	},
+}
+
+// GetGirlfriendResponse replies like GetResponse, with her body language
+// returned separately from what she says.
+func (a *Groq) GetGirlfriendResponse(ctx context.Context, request modelapi.ChatRequest) (GirlfriendResponse, error) {
+	var response GirlfriendResponse
+	if err := a.GetResponseWithTools(ctx, request, girlfriendResponseTool, &response); err != nil {
+		return GirlfriendResponse{}, err
+	}
+	if strings.TrimSpace(response.Response) == "" {
+		return GirlfriendResponse{}, fmt.Errorf("no response received")
+	}
+	return response, nil
+}
+
+// parseToolArguments decodes tool call arguments, which the API sends as a
+// JSON-encoded string.
+func parseToolArguments(arguments json.RawMessage, v any) error {
+	var encoded string
+	if err := json.Unmarshal(arguments, &encoded); err == nil {
+		arguments = json.RawMessage(encoded)
+	}
+	return json.Unmarshal(arguments, v)
+}
+
+type streamChunk struct {
+	Choices []struct {
+		Delta struct {
+			Content string `json:"content"`
+		} `json:"delta"`
+	} `json:"choices"`
+	// Usage is only set on the last chunk
+	Usage *Usage `json:"usage"`
+}
+
+// StreamResponse implements modelapi.ChatStreamer with server-sent events.
+// onDelta is called with each piece of text as it arrives; the full response
+// is returned at the end. Streams are not retried, since part of the reply
+// may already be shown.
+func (a *Groq) StreamResponse(ctx context.Context, request modelapi.ChatRequest, onDelta func(string)) (string, error) {
+	tracer := otel.Tracer("groqapi/StreamResponse")
+	ctx, span := tracer.Start(ctx, "StreamResponse")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.Int("conversation_history_length", len(request.History)),
+		attribute.Int("new_user_message.length", len(request.Message)),
+		attribute.Int("images", len(request.Images)),
+	)
+
+	model := a.replyModel(request)
+	input := ChatRequestInput{
+		Model:         model,
+		MaxTokens:     request.ReplyTokens(maxReplyTokens),
+		Messages:      buildMessages(request.SystemPrompt, request.History, request.Message, request.Images),
+		Stream:        true,
+		StreamOptions: &StreamOptions{IncludeUsage: true},
+		Temperature:   request.Temperature,
+		TopP:          request.TopP,
+	}
+	jsonData, err := json.Marshal(input)
+	if err != nil {
+		span.RecordError(err)
+		return "", fmt.Errorf("Could not generate request body: %w", err)
+	}
+
+	if err := a.limiter.Wait(ctx, requestTokens(input)); err != nil {
+		span.RecordError(err)
+		return "", err
+	}
+	if err := a.semaphore.Acquire(ctx, 1); err != nil {
+		span.RecordError(err)
+		return "", fmt.Errorf("Failed to acquire semaphore.")
+	}
+	defer a.semaphore.Release(1)
+
+	req, err := http.NewRequestWithContext(ctx, "POST", chatURL, bytes.NewBuffer(jsonData))
+	if err != nil {
+		span.RecordError(err)
+		return "", fmt.Errorf("Failed to create request: %w", err)
+	}
+	req.Header.Set("authorization", "Bearer "+os.Getenv("GROQ_SECRET_KEY"))
+	req.Header.Set("content-type", "application/json")
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		span.RecordError(err)
+		return "", fmt.Errorf("Failed to fetch response: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(res.Body)
+		err := fmt.Errorf("Request failed: %d %s", res.StatusCode, body)
+		span.RecordError(err)
+		return "", err
+	}
+
+	start := time.Now()
+	firstToken := false
+	response, usage, err := a.readStream(ctx, res.Body, func(delta string) {
+		if !firstToken {
+			firstToken = true
+			span.SetAttributes(attribute.Int64("time_to_first_token_ms", time.Since(start).Milliseconds()))
+		}
+		onDelta(delta)
+	})
+	if err != nil {
+		span.RecordError(err)
+		return "", err
+	}
+
+	recordUsage(ctx, model, usage)
+	if response == "" {
+		return "", fmt.Errorf("no response received")
+	}
+
+	span.SetAttributes(attribute.Int("response_length", len(response)))
+	return response, nil
+}
+
+// readStream reads server-sent chat completion chunks until [DONE], calling
+// onDelta with each piece of text. The usage comes in the last chunk.
+func (a *Groq) readStream(ctx context.Context, body io.Reader, onDelta func(string)) (string, *Usage, error) {
+	var response strings.Builder
+	var usage *Usage
+	scanner := bufio.NewScanner(body)
+	for scanner.Scan() {
+		data, found := strings.CutPrefix(scanner.Text(), "data: ")
+		if !found {
+			continue
+		}
+		if data == "[DONE]" {
+			break
+		}
+
+		var chunk streamChunk
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			a.logger.Logger(ctx).Warn("[Groq-API] Could not parse stream chunk", zap.Error(err), a.logger.Content("data", data))
+			continue
+		}
+		if chunk.Usage != nil {
+			usage = chunk.Usage
+		}
+		if len(chunk.Choices) == 0 || chunk.Choices[0].Delta.Content == "" {
+			continue
+		}
+
+		response.WriteString(chunk.Choices[0].Delta.Content)
+		onDelta(chunk.Choices[0].Delta.Content)
+	}
+	if err := scanner.Err(); err != nil {
+		return "", nil, fmt.Errorf("Failed to read stream: %w", err)
+	}
+	return response.String(), usage, nil
+}
+
+// Transcribe implements modelapi.STTProvider with Whisper, for when Deepgram
+// is down or slow.
+func (a *Groq) Transcribe(ctx context.Context, request modelapi.TranscriptionRequest) (modelapi.Transcription, error) {
+	tracer := otel.Tracer("groqapi/Transcribe")
+	ctx, span := tracer.Start(ctx, "Transcribe")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.Int("audio.data.size", len(request.Audio)),
+		attribute.String("audio.mime_type", request.MimeType),
+	)
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	form.WriteField("model", a.models.Transcription)
+	// Verbose responses score each segment, which the confidence comes from
+	form.WriteField("response_format", "verbose_json")
+	file, err := form.CreateFormFile("file", "audio"+audioExtension(request.MimeType))
+	if err != nil {
+		span.RecordError(err)
+		return modelapi.Transcription{}, fmt.Errorf("Could not generate request body: %w", err)
+	}
+	file.Write(request.Audio)
+	if err := form.Close(); err != nil {
+		span.RecordError(err)
+		return modelapi.Transcription{}, fmt.Errorf("Could not generate request body: %w", err)
+	}
+
+	// Transcriptions share the account's request quota with chat; they use
+	// no tokens
+	if err := a.limiter.Wait(ctx, 0); err != nil {
+		span.RecordError(err)
+		return modelapi.Transcription{}, err
+	}
+	if err := a.semaphore.Acquire(ctx, 1); err != nil {
+		span.RecordError(err)
+		return modelapi.Transcription{}, fmt.Errorf("Failed to acquire semaphore.")
+	}
+	defer a.semaphore.Release(1)
+
+	req, err := http.NewRequestWithContext(ctx, "POST", transcriptionURL, &body)
+	if err != nil {
+		span.RecordError(err)
+		return modelapi.Transcription{}, fmt.Errorf("Failed to create request: %w", err)
+	}
+	req.Header.Set("authorization", "Bearer "+os.Getenv("GROQ_SECRET_KEY"))
+	req.Header.Set("content-type", form.FormDataContentType())
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		span.RecordError(err)
+		return modelapi.Transcription{}, fmt.Errorf("Failed to fetch response: %w", err)
+	}
+	defer res.Body.Close()
+
+	respBody, err := io.ReadAll(res.Body)
+	if err != nil {
+		span.RecordError(err)
+		return modelapi.Transcription{}, fmt.Errorf("Failed to read response: %w", err)
+	}
+	if res.StatusCode != http.StatusOK {
+		err := fmt.Errorf("Request failed: %d %s", res.StatusCode, respBody)
+		span.RecordError(err)
+		return modelapi.Transcription{}, err
+	}
+
+	var transcription struct {
+		Text     string                 `json:"text"`
+		Segments []transcriptionSegment `json:"segments"`
+	}
+	if err := json.Unmarshal(respBody, &transcription); err != nil {
+		span.RecordError(err)
+		return modelapi.Transcription{}, fmt.Errorf("Could not parse transcription: %w", err)
+	}
+
+	transcript := strings.TrimSpace(transcription.Text)
+	confidence := segmentConfidence(transcription.Segments)
+	span.SetAttributes(
+		attribute.Int("transcription.length", len(transcript)),
+		attribute.Float64("transcription.confidence", confidence),
+	)
+	return modelapi.Transcription{Text: transcript, Confidence: confidence}, nil
+}
+
+// transcriptionSegment is a stretch of a Whisper transcript.
+type transcriptionSegment struct {
+	Start float64 `json:"start"`
+	End   float64 `json:"end"`
+	// AvgLogprob is the mean log probability of the segment's tokens
+	AvgLogprob float64 `json:"avg_logprob"`
+	// NoSpeechProb is how likely the segment is to be silence or noise
+	NoSpeechProb float64 `json:"no_speech_prob"`
+}
+
+// segmentConfidence turns Whisper's scores into a confidence like Deepgram's:
+// each segment's mean token probability, discounted by how likely it is to be
+// noise, averaged over the segments weighted by how long each ran. 0 without
+// segments.
+func segmentConfidence(segments []transcriptionSegment) float64 {
+	var total, duration float64
+	for _, segment := range segments {
+		length := segment.End - segment.Start
+		total += math.Exp(segment.AvgLogprob) * (1 - segment.NoSpeechProb) * length
+		duration += length
+	}
+	if duration <= 0 {
+		return 0
+	}
+	return total / duration
+}
+
+// audioExtension names the upload so Groq can tell the format; voice notes
+// are OGG.
+func audioExtension(mimeType string) string {
+	switch mimeType {
+	case "audio/wav", "audio/x-wav":
+		return ".wav"
+	case "audio/mpeg", "audio/mp3":
+		return ".mp3"
+	case "audio/mp4", "audio/m4a", "audio/x-m4a":
+		return ".m4a"
+	case "audio/webm":
+		return ".webm"
+	case "audio/flac":
+		return ".flac"
+	default:
+		return ".ogg"
+	}
+}
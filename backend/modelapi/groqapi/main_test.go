@@ -2,11 +2,15 @@ package groqapi
 
 import (
 	"context"
+	"encoding/json"
 	"os"
+	"strings"
 	"testing"
 	"time"
 
 	"gulabodev/logger"
+	"gulabodev/modelapi"
+	"gulabodev/modelapi/prompts"
 )
 
 func TestGetResponse(t *testing.T) {
@@ -29,10 +33,10 @@ func TestGetResponse(t *testing.T) {
 	// Test message
 	testMessage := "Hello, how are you?"
 
-	// Call getResponse function
-	response, err := groq.getResponse(ctx, testMessage)
+	// Call GetResponse function
+	response, err := groq.GetResponse(ctx, modelapi.ChatRequest{SystemPrompt: prompts.Render(prompts.SystemHinglish, nil), Message: testMessage})
 	if err != nil {
-		t.Fatalf("getResponse failed: %v", err)
+		t.Fatalf("GetResponse failed: %v", err)
 	}
 
 	// Basic validation
@@ -57,3 +61,156 @@ func TestGetResponseWithSetEnv(t *testing.T) {
 	// To run this test with a specific key:
 	// GROQ_SECRET_KEY=your-key-here go test -v ./backend/modelapi/groqapi/...
 }
+
+func TestParseToolArguments(t *testing.T) {
+	for _, raw := range []string{
+		`"{\"facts\":[\"Their name is Rahul.\"]}"`,
+		`{"facts":["Their name is Rahul."]}`,
+	} {
+		var saved struct {
+			Facts []string `json:"facts"`
+		}
+		if err := parseToolArguments(json.RawMessage(raw), &saved); err != nil {
+			t.Fatalf("parseToolArguments(%s) failed: %v", raw, err)
+		}
+		if len(saved.Facts) != 1 || saved.Facts[0] != "Their name is Rahul." {
+			t.Errorf("parseToolArguments(%s) = %v", raw, saved.Facts)
+		}
+	}
+}
+
+func TestToolCallArguments(t *testing.T) {
+	message := Message{ToolCalls: []ToolCall{
+		{Function: Function{Name: "search", Arguments: json.RawMessage(`"{}"`)}},
+		{Function: Function{Name: "generate_girlfriend_response", Arguments: json.RawMessage(`"{\"response\":\"Hi jaan\",\"bodyLanguage\":\"Waves 👋\"}"`)}},
+	}}
+
+	arguments, ok := toolCallArguments(message, girlfriendResponseTool.Name)
+	if !ok {
+		t.Fatal("toolCallArguments should find the forced call")
+	}
+	var response GirlfriendResponse
+	if err := parseToolArguments(arguments, &response); err != nil {
+		t.Fatalf("parseToolArguments failed: %v", err)
+	}
+	if response.Response != "Hi jaan" || response.BodyLanguage != "Waves 👋" {
+		t.Errorf("unexpected response: %+v", response)
+	}
+
+	if _, ok := toolCallArguments(Message{}, girlfriendResponseTool.Name); ok {
+		t.Error("toolCallArguments should report a missing call")
+	}
+}
+
+func TestBuildMessagesWithImages(t *testing.T) {
+	messages := buildMessages("system", []ChatCompletionInputMessage{{Role: USER, Content: "hi"}}, "[Sent a photo]", []Image{
+		{Data: []byte("jpeg"), MimeType: "image/jpeg"},
+	})
+	if len(messages) != 3 {
+		t.Fatalf("len(messages) = %d, want 3", len(messages))
+	}
+
+	raw, err := json.Marshal(messages[2])
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"role":"user","content":[{"type":"text","text":"[Sent a photo]"},{"type":"image_url","image_url":{"url":"data:image/jpeg;base64,anBlZw=="}}]}`
+	if string(raw) != want {
+		t.Errorf("user message = %s, want %s", raw, want)
+	}
+
+	// A photo sent earlier is still sent with its turn
+	history := []ChatCompletionInputMessage{{Role: USER, Content: "[Sent a photo]", Images: []Image{{Data: []byte("jpeg"), MimeType: "image/jpeg"}}}}
+	messages = buildMessages("system", history, "do you like my shirt?", nil)
+	if raw, _ := json.Marshal(messages[1]); string(raw) != want {
+		t.Errorf("history message = %s, want %s", raw, want)
+	}
+	if raw, _ := json.Marshal(messages[2]); string(raw) != `{"role":"user","content":"do you like my shirt?"}` {
+		t.Errorf("text-only message = %s", raw)
+	}
+
+	groq := &Groq{models: Models{Chat: chatModel, Vision: visionModel}}
+	if groq.replyModel(modelapi.ChatRequest{}) != chatModel || groq.replyModel(modelapi.ChatRequest{Images: []Image{{}}}) != visionModel {
+		t.Error("replyModel picked the wrong model")
+	}
+	if groq.replyModel(modelapi.ChatRequest{History: history}) != visionModel {
+		t.Error("replyModel should see images in the history")
+	}
+}
+
+func TestDecodeStructured(t *testing.T) {
+	schema := modelapi.ToolParameter{
+		Type:       "object",
+		Properties: map[string]modelapi.ToolParameter{"mood": {Type: "string", Enum: []string{"happy", "sad"}}},
+		Required:   []string{"mood"},
+	}
+	var v struct {
+		Mood string `json:"mood"`
+	}
+	if err := decodeStructured(`{"mood":"happy"}`, schema, &v); err != nil || v.Mood != "happy" {
+		t.Errorf("decodeStructured = %v, %+v", err, v)
+	}
+	if err := decodeStructured("Sure! Here's the JSON", schema, &v); err == nil {
+		t.Error("decodeStructured should reject output that isn't JSON")
+	}
+	if err := decodeStructured(`{"mood":"flirty"}`, schema, &v); err == nil || err.Error() != "mood should be one of happy, sad" {
+		t.Errorf("decodeStructured = %v, want a schema violation", err)
+	}
+}
+
+func TestRequestTokens(t *testing.T) {
+	photo := []Image{{Data: make([]byte, 1<<20), MimeType: "image/jpeg"}}
+	input := ChatRequestInput{
+		MaxTokens: 100,
+		Messages:  buildMessages("be sweet", nil, "do you like it?", photo),
+	}
+	want := 100 + modelapi.CountTokens("be sweet") + modelapi.CountTokens("do you like it?") + imageTokens
+	if got := requestTokens(input); got != want {
+		t.Errorf("requestTokens = %d, want %d", got, want)
+	}
+}
+
+func TestReadStream(t *testing.T) {
+	groq := &Groq{logger: logger.Connect(logger.LoggerConnectProps{Production: false})}
+	body := strings.NewReader(`data: {"choices":[{"delta":{"content":"Hello"}}]}
+
+data: {"choices":[{"delta":{"content":" baby"}}]}
+
+data: not json
+
+data: {"choices":[],"usage":{"prompt_tokens":12,"completion_tokens":3}}
+
+data: [DONE]
+`)
+
+	var deltas []string
+	response, usage, err := groq.readStream(context.Background(), body, func(delta string) {
+		deltas = append(deltas, delta)
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if response != "Hello baby" || len(deltas) != 2 {
+		t.Errorf("response = %q, deltas = %q", response, deltas)
+	}
+	if usage == nil || usage.PromptTokens != 12 || usage.CompletionTokens != 3 {
+		t.Errorf("usage = %+v", usage)
+	}
+}
+
+func TestSegmentConfidence(t *testing.T) {
+	if got := segmentConfidence(nil); got != 0 {
+		t.Errorf("without segments got %g, want 0", got)
+	}
+
+	got := segmentConfidence([]transcriptionSegment{
+		{Start: 0, End: 3, AvgLogprob: 0},
+		{Start: 3, End: 4, AvgLogprob: 0, NoSpeechProb: 1},
+	})
+	if got != 0.75 {
+		t.Errorf("segmentConfidence = %g, want 0.75", got)
+	}
+	if got := segmentConfidence([]transcriptionSegment{{Start: 0, End: 1, AvgLogprob: -1.5}}); got > 0.25 {
+		t.Errorf("a segment Whisper was guessing at scored %g", got)
+	}
+}
@@ -0,0 +1,76 @@
+package modelapi
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"slices"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ErrUnsafeImagePrompt is returned instead of generating a picture whose
+// prompt asks for nudity or for anyone who could be a minor.
+var ErrUnsafeImagePrompt = errors.New("image prompt is not safe for work")
+
+// ImageRequest is a picture of a character to generate.
+type ImageRequest struct {
+	Prompt string
+	// Seed gives the same prompt the same picture, which keeps a character's
+	// face the same from one picture to the next
+	Seed int64
+}
+
+// ImageProvider generates pictures with one image model.
+type ImageProvider interface {
+	Name() string
+	// GenerateImage returns the picture's encoded bytes, a PNG or JPEG
+	GenerateImage(ctx context.Context, request ImageRequest) ([]byte, error)
+}
+
+// unsafeImageWords are lowercase words that have no place in a prompt for a
+// picture anyone could be sent. Scenes come from users, so they're checked
+// word by word rather than trusting the image model to refuse.
+var unsafeImageWords = []string{
+	"nude", "nudes", "naked", "topless", "bottomless", "nipple", "nipples", "nsfw", "porn", "explicit",
+	"sex", "lingerie", "underwear", "bra", "panties", "undressed", "undressing", "strip", "stripping",
+	"child", "children", "kid", "kids", "minor", "underage", "teen", "teenage", "teenager", "schoolgirl", "loli",
+	"nangi", "nanga",
+}
+
+var imageWords = regexp.MustCompile(`\p{L}+`)
+
+// CheckImagePrompt returns ErrUnsafeImagePrompt if prompt uses any of
+// unsafeImageWords.
+func CheckImagePrompt(prompt string) error {
+	for _, word := range imageWords.FindAllString(strings.ToLower(prompt), -1) {
+		if slices.Contains(unsafeImageWords, word) {
+			return ErrUnsafeImagePrompt
+		}
+	}
+	return nil
+}
+
+// SafeImages checks every prompt to provider with CheckImagePrompt before
+// anything is generated.
+func SafeImages(provider ImageProvider) ImageProvider {
+	return &safeImages{provider: provider}
+}
+
+type safeImages struct {
+	provider ImageProvider
+}
+
+func (s *safeImages) Name() string {
+	return s.provider.Name()
+}
+
+func (s *safeImages) GenerateImage(ctx context.Context, request ImageRequest) ([]byte, error) {
+	if err := CheckImagePrompt(request.Prompt); err != nil {
+		trace.SpanFromContext(ctx).SetAttributes(attribute.Bool("image.unsafe_prompt", true))
+		return nil, err
+	}
+	return s.provider.GenerateImage(ctx, request)
+}
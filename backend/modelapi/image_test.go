@@ -0,0 +1,55 @@
+package modelapi
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+type fakeImages struct {
+	calls int
+}
+
+func (f *fakeImages) Name() string {
+	return "deepinfra"
+}
+
+func (f *fakeImages) GenerateImage(ctx context.Context, request ImageRequest) ([]byte, error) {
+	f.calls++
+	return []byte("png"), nil
+}
+
+func TestCheckImagePrompt(t *testing.T) {
+	tests := []struct {
+		prompt string
+		safe   bool
+	}{
+		{"A casual smartphone selfie of a 24-year-old Indian woman, at the beach", true},
+		{"in her bedroom, making a cheeky face, kidding around", true},
+		{"in bed, NAKED", false},
+		{"a topless selfie", false},
+		{"wearing her school uniform as a teenager", false},
+		{"nangi photo", false},
+	}
+	for _, tt := range tests {
+		err := CheckImagePrompt(tt.prompt)
+		if tt.safe && err != nil || !tt.safe && !errors.Is(err, ErrUnsafeImagePrompt) {
+			t.Errorf("CheckImagePrompt(%q) = %v, want safe %v", tt.prompt, err, tt.safe)
+		}
+	}
+}
+
+func TestSafeImages(t *testing.T) {
+	fake := &fakeImages{}
+	images := SafeImages(fake)
+
+	if _, err := images.GenerateImage(context.Background(), ImageRequest{Prompt: "selfie, nude"}); !errors.Is(err, ErrUnsafeImagePrompt) {
+		t.Errorf("unsafe prompt: %v", err)
+	}
+	if fake.calls != 0 {
+		t.Error("an unsafe prompt shouldn't reach the provider")
+	}
+	if _, err := images.GenerateImage(context.Background(), ImageRequest{Prompt: "selfie at a cafe"}); err != nil || fake.calls != 1 {
+		t.Errorf("safe prompt: %v, %d calls", err, fake.calls)
+	}
+}
@@ -0,0 +1,53 @@
+package modelapi
+
+import (
+	"context"
+	"fmt"
+	"gulabodev/logger"
+	"os"
+	"regexp"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// modelNamePattern matches model IDs the way providers spell them, like
+// "gemini-2.5-flash", "moonshotai/kimi-k2-instruct" or "hexgrad/Kokoro-82M".
+var modelNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._:/@-]*$`)
+
+// ModelCheck reports why a model can't do what it's configured for, like a
+// chat model given as the TTS model.
+type ModelCheck func(model string) error
+
+// LoadModel reads a model from the environment variable name, like
+// GEMINI_CHAT_MODEL, so it can be changed without a rebuild. It falls back to
+// fallback when the variable is unset, or when it isn't a model name or fails
+// one of checks, so a typo is caught at startup rather than on the first
+// request. Models without a known price are allowed, with a warning that
+// their usage is recorded at no cost.
+func LoadModel(ctx context.Context, logger *logger.LogMiddleware, name string, fallback string, checks ...ModelCheck) string {
+	model := strings.TrimSpace(os.Getenv(name))
+	if model == "" {
+		return fallback
+	}
+	if err := validateModel(model, checks); err != nil {
+		logger.Logger(ctx).Error("Invalid "+name+", using default", zap.String("value", model), zap.String("default", fallback), zap.Error(err))
+		return fallback
+	}
+	if _, ok := modelPrices[model]; !ok {
+		logger.Logger(ctx).Warn("No price known for "+name+", recording its usage at no cost", zap.String("model", model))
+	}
+	return model
+}
+
+func validateModel(model string, checks []ModelCheck) error {
+	if !modelNamePattern.MatchString(model) {
+		return fmt.Errorf("%q isn't a model name", model)
+	}
+	for _, check := range checks {
+		if err := check(model); err != nil {
+			return err
+		}
+	}
+	return nil
+}
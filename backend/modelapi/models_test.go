@@ -0,0 +1,37 @@
+package modelapi
+
+import (
+	"context"
+	"errors"
+	"gulabodev/logger"
+	"strings"
+	"testing"
+)
+
+func TestLoadModel(t *testing.T) {
+	logMiddleware := logger.Connect(logger.LoggerConnectProps{Production: false})
+	notTTS := func(model string) error {
+		if strings.Contains(model, "-tts") {
+			return errors.New("speech model")
+		}
+		return nil
+	}
+
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"", "gemini-2.5-flash"},
+		{"  gemini-2.5-pro  ", "gemini-2.5-pro"},
+		{"moonshotai/kimi-k2-instruct", "moonshotai/kimi-k2-instruct"},
+		{"gemini 2.5", "gemini-2.5-flash"},
+		{"-flash", "gemini-2.5-flash"},
+		{"gemini-2.5-flash-preview-tts", "gemini-2.5-flash"},
+	}
+	for _, test := range tests {
+		t.Setenv("TEST_CHAT_MODEL", test.value)
+		if got := LoadModel(context.Background(), logMiddleware, "TEST_CHAT_MODEL", "gemini-2.5-flash", notTTS); got != test.want {
+			t.Errorf("LoadModel with %q = %q, want %q", test.value, got, test.want)
+		}
+	}
+}
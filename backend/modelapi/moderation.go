@@ -0,0 +1,34 @@
+package modelapi
+
+import "context"
+
+// Moderation categories, named as OpenAI's moderation endpoint names them.
+const (
+	ModerationSexual                = "sexual"
+	ModerationSexualMinors          = "sexual/minors"
+	ModerationHarassment            = "harassment"
+	ModerationHarassmentThreatening = "harassment/threatening"
+	ModerationHate                  = "hate"
+	ModerationHateThreatening       = "hate/threatening"
+	ModerationIllicit               = "illicit"
+	ModerationIllicitViolent        = "illicit/violent"
+	ModerationSelfHarm              = "self-harm"
+	ModerationSelfHarmIntent        = "self-harm/intent"
+	ModerationSelfHarmInstructions  = "self-harm/instructions"
+	ModerationViolence              = "violence"
+	ModerationViolenceGraphic       = "violence/graphic"
+)
+
+// ModerationResult is how strongly a text matches each moderation category.
+type ModerationResult struct {
+	// Scores run from 0 to 1 and are keyed by category; categories a
+	// provider doesn't score are missing
+	Scores map[string]float64
+}
+
+// ModerationProvider scores text against the moderation categories. It only
+// scores; what counts as too much is up to the caller.
+type ModerationProvider interface {
+	Name() string
+	Moderate(ctx context.Context, text string) (ModerationResult, error)
+}
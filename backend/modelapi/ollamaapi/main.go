@@ -0,0 +1,289 @@
+package ollamaapi
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"gulabodev/logger"
+	"gulabodev/modelapi"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+)
+
+// A small model that runs on a laptop
+const defaultModel = "llama3.2"
+
+const (
+	// Ollama silently cuts prompts to a short default context, so requests ask
+	// for a window a laptop can still run
+	contextWindow = 8192
+	// Replies stop here unless the request sets its own cap
+	maxReplyTokens = 2048
+)
+
+// Ollama is a chat provider backed by a local Ollama server, so the bot can be
+// developed without using Groq or Gemini quota.
+type Ollama struct {
+	logger  *logger.LogMiddleware
+	baseURL string
+	model   string
+}
+
+type OllamaConnectProps struct {
+	Logger *logger.LogMiddleware
+}
+
+// Connect reads the server from OLLAMA_URL, like "http://localhost:11434",
+// and the model from OLLAMA_MODEL. It returns nil if OLLAMA_URL isn't set.
+func Connect(ctx context.Context, args OllamaConnectProps) *Ollama {
+	tracer := otel.Tracer("ollamaapi/Connect")
+	ctx, span := tracer.Start(ctx, "Connect")
+	defer span.End()
+
+	baseURL := strings.TrimSuffix(os.Getenv("OLLAMA_URL"), "/")
+	if baseURL == "" {
+		return nil
+	}
+	model := os.Getenv("OLLAMA_MODEL")
+	if model == "" {
+		model = defaultModel
+	}
+
+	span.SetAttributes(attribute.String("url", baseURL), attribute.String("model", model))
+	args.Logger.Logger(ctx).Info("[Ollama] Using local model", zap.String("url", baseURL), zap.String("model", model))
+
+	return &Ollama{logger: args.Logger, baseURL: baseURL, model: model}
+}
+
+func (o *Ollama) Name() string {
+	return "ollama"
+}
+
+// ContextWindow implements modelapi.ContextWindowed.
+func (o *Ollama) ContextWindow() int {
+	return contextWindow
+}
+
+type message struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+	// Images are base64 encoded, for vision models
+	Images [][]byte `json:"images,omitempty"`
+}
+
+type options struct {
+	Temperature float32 `json:"temperature,omitempty"`
+	TopP        float32 `json:"top_p,omitempty"`
+	NumPredict  int     `json:"num_predict"`
+	NumCtx      int     `json:"num_ctx"`
+}
+
+type chatRequest struct {
+	Model    string    `json:"model"`
+	Messages []message `json:"messages"`
+	Stream   bool      `json:"stream"`
+	Options  options   `json:"options"`
+	// Format constrains the reply to a JSON schema
+	Format *modelapi.ToolParameter `json:"format,omitempty"`
+}
+
+// chatResponse is a whole reply, or one line of a streamed one. The token
+// counts are only set once Done.
+type chatResponse struct {
+	Message struct {
+		Content string `json:"content"`
+	} `json:"message"`
+	Done            bool `json:"done"`
+	PromptEvalCount int  `json:"prompt_eval_count"`
+	EvalCount       int  `json:"eval_count"`
+}
+
+func (o *Ollama) newRequest(request modelapi.ChatRequest, maxTokens int) chatRequest {
+	messages := []message{{Role: "system", Content: request.SystemPrompt}}
+	for _, m := range request.History {
+		messages = append(messages, withImages(m.Role, m.Content, m.Images))
+	}
+	messages = append(messages, withImages("user", request.Message, request.Images))
+
+	return chatRequest{
+		Model:    o.model,
+		Messages: messages,
+		Options:  options{Temperature: request.Temperature, TopP: request.TopP, NumPredict: maxTokens, NumCtx: contextWindow},
+	}
+}
+
+// withImages is a message with its images, for vision models.
+func withImages(role string, text string, images []modelapi.ChatImage) message {
+	m := message{Role: role, Content: text}
+	for _, image := range images {
+		m.Images = append(m.Images, image.Data)
+	}
+	return m
+}
+
+func (o *Ollama) recordUsage(ctx context.Context, response chatResponse) {
+	modelapi.RecordUsage(ctx, modelapi.Usage{
+		Provider:     "ollama",
+		Kind:         modelapi.UsageKindChat,
+		Model:        o.model,
+		InputTokens:  response.PromptEvalCount,
+		OutputTokens: response.EvalCount,
+	})
+}
+
+// post sends a chat request and returns the response body for the caller to
+// read and close.
+func (o *Ollama) post(ctx context.Context, request chatRequest) (io.ReadCloser, error) {
+	jsonData, err := json.Marshal(request)
+	if err != nil {
+		return nil, fmt.Errorf("Could not generate request body: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", o.baseURL+"/api/chat", bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create request: %w", err)
+	}
+	req.Header.Set("content-type", "application/json")
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to fetch response: %w", err)
+	}
+	if res.StatusCode != http.StatusOK {
+		defer res.Body.Close()
+		body, _ := io.ReadAll(res.Body)
+		return nil, fmt.Errorf("Request failed: %d %s", res.StatusCode, body)
+	}
+	return res.Body, nil
+}
+
+func (o *Ollama) complete(ctx context.Context, request chatRequest) (string, error) {
+	body, err := o.post(ctx, request)
+	if err != nil {
+		return "", err
+	}
+	defer body.Close()
+
+	var response chatResponse
+	if err := json.NewDecoder(body).Decode(&response); err != nil {
+		return "", fmt.Errorf("Could not parse response: %w", err)
+	}
+	o.recordUsage(ctx, response)
+	if response.Message.Content == "" {
+		return "", fmt.Errorf("no response received")
+	}
+	return response.Message.Content, nil
+}
+
+// GetResponse implements modelapi.ChatProvider.
+func (o *Ollama) GetResponse(ctx context.Context, request modelapi.ChatRequest) (string, error) {
+	tracer := otel.Tracer("ollamaapi/GetResponse")
+	ctx, span := tracer.Start(ctx, "GetResponse")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("model", o.model),
+		attribute.Int("conversation_history_length", len(request.History)),
+	)
+
+	response, err := o.complete(ctx, o.newRequest(request, request.ReplyTokens(maxReplyTokens)))
+	if err != nil {
+		span.RecordError(err)
+		o.logger.Logger(ctx).Error("[Ollama] Request failed", zap.Error(err))
+		return "", err
+	}
+	return response, nil
+}
+
+// GetResponseWithTools implements modelapi.ChatProvider. Small local models
+// are unreliable at tool calls, so the reply is constrained to the tool's
+// parameter schema instead.
+func (o *Ollama) GetResponseWithTools(ctx context.Context, request modelapi.ChatRequest, tool modelapi.ChatTool, v any) error {
+	tracer := otel.Tracer("ollamaapi/GetResponseWithTools")
+	ctx, span := tracer.Start(ctx, "GetResponseWithTools")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("model", o.model), attribute.String("tool", tool.Name))
+
+	body := o.newRequest(request, 512)
+	body.Messages[0].Content += "\n\n" + tool.Description + " Reply with JSON only."
+	body.Format = &tool.Parameters
+
+	response, err := o.complete(ctx, body)
+	if err != nil {
+		span.RecordError(err)
+		return err
+	}
+	if err := json.Unmarshal([]byte(response), v); err != nil {
+		span.RecordError(err)
+		return fmt.Errorf("Could not parse tool arguments: %w", err)
+	}
+	return nil
+}
+
+// StreamResponse implements modelapi.ChatStreamer.
+func (o *Ollama) StreamResponse(ctx context.Context, request modelapi.ChatRequest, onDelta func(string)) (string, error) {
+	tracer := otel.Tracer("ollamaapi/StreamResponse")
+	ctx, span := tracer.Start(ctx, "StreamResponse")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("model", o.model),
+		attribute.Int("conversation_history_length", len(request.History)),
+	)
+
+	body := o.newRequest(request, request.ReplyTokens(maxReplyTokens))
+	body.Stream = true
+	stream, err := o.post(ctx, body)
+	if err != nil {
+		span.RecordError(err)
+		return "", err
+	}
+	defer stream.Close()
+
+	response, err := o.readStream(ctx, stream, onDelta)
+	if err != nil {
+		span.RecordError(err)
+		return "", err
+	}
+	if response == "" {
+		return "", fmt.Errorf("no response received")
+	}
+	return response, nil
+}
+
+// readStream reads a streamed reply, one JSON object per line, calling
+// onDelta with each piece of text.
+func (o *Ollama) readStream(ctx context.Context, body io.Reader, onDelta func(string)) (string, error) {
+	var response strings.Builder
+	scanner := bufio.NewScanner(body)
+	for scanner.Scan() {
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+		var chunk chatResponse
+		if err := json.Unmarshal(scanner.Bytes(), &chunk); err != nil {
+			return "", fmt.Errorf("Could not parse stream chunk: %w", err)
+		}
+		if chunk.Message.Content != "" {
+			response.WriteString(chunk.Message.Content)
+			onDelta(chunk.Message.Content)
+		}
+		if chunk.Done {
+			o.recordUsage(ctx, chunk)
+			break
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", fmt.Errorf("Failed to read stream: %w", err)
+	}
+	return response.String(), nil
+}
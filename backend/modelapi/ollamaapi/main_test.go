@@ -0,0 +1,66 @@
+package ollamaapi
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"gulabodev/modelapi"
+)
+
+func TestConnect(t *testing.T) {
+	t.Setenv("OLLAMA_URL", "")
+	if Connect(context.Background(), OllamaConnectProps{}) != nil {
+		t.Error("expected no client without OLLAMA_URL")
+	}
+}
+
+func TestNewRequest(t *testing.T) {
+	o := &Ollama{model: "llama3.2"}
+	request := o.newRequest(modelapi.ChatRequest{
+		SystemPrompt: "be nice",
+		History:      []modelapi.ChatMessage{{Role: "assistant", Content: "hi"}},
+		Message:      "look",
+		Images:       []modelapi.ChatImage{{Data: []byte("img"), MimeType: "image/jpeg"}},
+		Temperature:  0.7,
+		TopP:         0.9,
+	}, 100)
+
+	data, err := json.Marshal(request)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := `{"model":"llama3.2","messages":[{"role":"system","content":"be nice"},{"role":"assistant","content":"hi"},` +
+		`{"role":"user","content":"look","images":["aW1n"]}],"stream":false,"options":{"temperature":0.7,"top_p":0.9,"num_predict":100,"num_ctx":8192}}`
+	if string(data) != want {
+		t.Errorf("got %s\nwant %s", data, want)
+	}
+}
+
+func TestReadStream(t *testing.T) {
+	o := &Ollama{model: "llama3.2"}
+	stream := strings.Join([]string{
+		`{"message":{"role":"assistant","content":"Hel"},"done":false}`,
+		`{"message":{"role":"assistant","content":"lo"},"done":false}`,
+		`{"message":{"role":"assistant","content":""},"done":true,"prompt_eval_count":20,"eval_count":2}`,
+	}, "\n")
+
+	var recorded modelapi.Usage
+	ctx := modelapi.WithUsageRecorder(context.Background(), func(ctx context.Context, usage modelapi.Usage) {
+		recorded = usage
+	})
+	var deltas []string
+	response, err := o.readStream(ctx, strings.NewReader(stream), func(delta string) {
+		deltas = append(deltas, delta)
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if response != "Hello" || len(deltas) != 2 {
+		t.Errorf("got %q from %v", response, deltas)
+	}
+	if recorded.Provider != "ollama" || recorded.InputTokens != 20 || recorded.OutputTokens != 2 || recorded.CostMicros() != 0 {
+		t.Errorf("unexpected usage: %+v", recorded)
+	}
+}
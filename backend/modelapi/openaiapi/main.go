@@ -2,10 +2,13 @@ package openaiapi
 
 import (
 	"context"
+	"fmt"
 	"gulabodev/logger"
 	"gulabodev/modelapi"
+	"gulabodev/modelapi/prompts"
 	"io"
 	"os"
+	"unicode/utf8"
 
 	// imported as openai
 	"go.opentelemetry.io/otel"
@@ -18,6 +21,13 @@ import (
 	"github.com/openai/openai-go/v2/packages/param"
 )
 
+// text-embedding-3 vectors can be shortened to any length, so they're made
+// as long as DeepInfra's BGE-M3 ones and the two can share a column
+const EMBEDDING_MODEL = "text-embedding-3-small"
+
+// Free to call, and scores every category OpenAI moderates
+const MODERATION_MODEL = openai.ModerationModelOmniModerationLatest
+
 type OpenAI struct {
 	logger    *logger.LogMiddleware
 	semaphore *semaphore.Weighted
@@ -47,19 +57,169 @@ func Connect(ctx context.Context, args OpenAIConnectProps) *OpenAI {
 }
 
 func (d *OpenAI) GenerateSpeech(ctx context.Context, inputText string) ([]byte, error) {
-	d.logger.Logger(ctx).Info("[OpenAIAPI] Generating speech", zap.String("inputText", inputText))
+	return d.GenerateSpeechWithVoice(ctx, inputText, string(openai.AudioSpeechNewParamsVoiceSage))
+}
+
+func (d *OpenAI) GenerateSpeechWithVoice(ctx context.Context, inputText string, voice string) ([]byte, error) {
+	return d.GenerateSpeechWithStyle(ctx, inputText, voice, prompts.StyleData{})
+}
+
+// emotionInstructions word each modelapi emotion for the TTS instructions.
+var emotionInstructions = map[string]string{
+	modelapi.EmotionSeductive:  "Deliver this line seductively: low, slow and breathy, lingering on every word.",
+	modelapi.EmotionSleepy:     "Deliver this line sleepily: soft, slow and drowsy, trailing off at the ends of phrases.",
+	modelapi.EmotionAnnoyed:    "Deliver this line annoyed: clipped and flat, with an exasperated edge.",
+	modelapi.EmotionExcited:    "Deliver this line excitedly: quick, bright and bubbly, with a rising pitch.",
+	modelapi.EmotionBreathless: "Deliver this line breathlessly: airy and a little out of breath, with quick little gasps between phrases.",
+}
+
+// GenerateSpeechWithStyle adds a note on how to deliver this line, like the
+// mood she's in, to the usual style instruction.
+func (d *OpenAI) GenerateSpeechWithStyle(ctx context.Context, inputText string, voice string, style prompts.StyleData) ([]byte, error) {
+	d.logger.Logger(ctx).Info("[OpenAIAPI] Generating speech", d.logger.Content("inputText", inputText), zap.String("voice", voice))
+
+	instructions := prompts.Render(prompts.StyleInstruction, style)
 
 	res, err := d.client.Audio.Speech.New(ctx, openai.AudioSpeechNewParams{
 		ResponseFormat: openai.AudioSpeechNewParamsResponseFormatMP3,
 		Model:          openai.SpeechModelGPT4oMiniTTS,
 		Input:          inputText,
-		Voice:          openai.AudioSpeechNewParamsVoiceSage,
-		Instructions:   param.Opt[string]{Value: modelapi.STYLE_INSTRUCTION},
+		Voice:          openai.AudioSpeechNewParamsVoice(voice),
+		Instructions:   param.Opt[string]{Value: instructions},
 	})
+	if err != nil {
+		return nil, err
+	}
 	defer res.Body.Close()
 
 	// Read all bytes from the body
 	audioBytes, err := io.ReadAll(res.Body)
+	if err == nil {
+		modelapi.RecordUsage(ctx, modelapi.Usage{
+			Provider:   d.Name(),
+			Kind:       modelapi.UsageKindTTS,
+			Model:      string(openai.SpeechModelGPT4oMiniTTS),
+			Characters: utf8.RuneCountInString(inputText),
+		})
+	}
 
 	return audioBytes, err
 }
+
+func (d *OpenAI) Name() string {
+	return "openai"
+}
+
+// Synthesize implements modelapi.TTSProvider, defaulting to the Sage voice.
+func (d *OpenAI) Synthesize(ctx context.Context, request modelapi.SpeechRequest) (modelapi.Speech, error) {
+	voice := request.Voice
+	if voice == "" {
+		voice = string(openai.AudioSpeechNewParamsVoiceSage)
+	}
+	audio, err := d.GenerateSpeechWithStyle(ctx, modelapi.PlainSpeech(request.Text), voice, prompts.StyleData{
+		Style:   request.Style,
+		Emotion: emotionInstructions[request.Emotion],
+	})
+	return modelapi.Speech{Audio: audio, FileName: "response.mp3"}, err
+}
+
+// Model implements modelapi.EmbeddingProvider.
+func (d *OpenAI) Model() string {
+	return EMBEDDING_MODEL
+}
+
+// Embed implements modelapi.EmbeddingProvider, shortening text-embedding-3
+// vectors to modelapi.EmbeddingDimensions.
+func (d *OpenAI) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	tracer := otel.Tracer("openaiapi/Embed")
+	ctx, span := tracer.Start(ctx, "Embed")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("model", EMBEDDING_MODEL), attribute.Int("texts", len(texts)))
+
+	if err := d.semaphore.Acquire(ctx, 1); err != nil {
+		return nil, err
+	}
+	defer d.semaphore.Release(1)
+
+	res, err := d.client.Embeddings.New(ctx, openai.EmbeddingNewParams{
+		Model:          EMBEDDING_MODEL,
+		Input:          openai.EmbeddingNewParamsInputUnion{OfArrayOfStrings: texts},
+		Dimensions:     param.NewOpt[int64](modelapi.EmbeddingDimensions),
+		EncodingFormat: openai.EmbeddingNewParamsEncodingFormatFloat,
+	})
+	if err != nil {
+		span.RecordError(err)
+		d.logger.Logger(ctx).Error("[OpenAIAPI] Failed to embed texts", zap.Error(err), zap.Int("texts", len(texts)))
+		return nil, err
+	}
+
+	if len(res.Data) != len(texts) {
+		return nil, fmt.Errorf("got %d embeddings for %d texts", len(res.Data), len(texts))
+	}
+	// Embeddings come back tagged with the index of their text, which is
+	// usually but not promised to be the order they were sent in
+	vectors := make([][]float32, len(texts))
+	for _, embedding := range res.Data {
+		if embedding.Index < 0 || int(embedding.Index) >= len(texts) {
+			return nil, fmt.Errorf("embedding index %d out of range", embedding.Index)
+		}
+		vector := make([]float32, len(embedding.Embedding))
+		for i, value := range embedding.Embedding {
+			vector[i] = float32(value)
+		}
+		vectors[embedding.Index] = vector
+	}
+
+	modelapi.RecordUsage(ctx, modelapi.Usage{
+		Provider:    d.Name(),
+		Kind:        modelapi.UsageKindEmbedding,
+		Model:       EMBEDDING_MODEL,
+		InputTokens: int(res.Usage.PromptTokens),
+	})
+	return vectors, nil
+}
+
+// Moderate implements modelapi.ModerationProvider.
+func (d *OpenAI) Moderate(ctx context.Context, text string) (modelapi.ModerationResult, error) {
+	tracer := otel.Tracer("openaiapi/Moderate")
+	ctx, span := tracer.Start(ctx, "Moderate")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("model", string(MODERATION_MODEL)))
+
+	if err := d.semaphore.Acquire(ctx, 1); err != nil {
+		return modelapi.ModerationResult{}, err
+	}
+	defer d.semaphore.Release(1)
+
+	res, err := d.client.Moderations.New(ctx, openai.ModerationNewParams{
+		Model: MODERATION_MODEL,
+		Input: openai.ModerationNewParamsInputUnion{OfString: openai.String(text)},
+	})
+	if err != nil {
+		span.RecordError(err)
+		d.logger.Logger(ctx).Error("[OpenAIAPI] Failed to moderate text", zap.Error(err))
+		return modelapi.ModerationResult{}, err
+	}
+	if len(res.Results) == 0 {
+		return modelapi.ModerationResult{}, fmt.Errorf("no moderation result")
+	}
+
+	scores := res.Results[0].CategoryScores
+	return modelapi.ModerationResult{Scores: map[string]float64{
+		modelapi.ModerationSexual:                scores.Sexual,
+		modelapi.ModerationSexualMinors:          scores.SexualMinors,
+		modelapi.ModerationHarassment:            scores.Harassment,
+		modelapi.ModerationHarassmentThreatening: scores.HarassmentThreatening,
+		modelapi.ModerationHate:                  scores.Hate,
+		modelapi.ModerationHateThreatening:       scores.HateThreatening,
+		modelapi.ModerationIllicit:               scores.Illicit,
+		modelapi.ModerationIllicitViolent:        scores.IllicitViolent,
+		modelapi.ModerationSelfHarm:              scores.SelfHarm,
+		modelapi.ModerationSelfHarmIntent:        scores.SelfHarmIntent,
+		modelapi.ModerationSelfHarmInstructions:  scores.SelfHarmInstructions,
+		modelapi.ModerationViolence:              scores.Violence,
+		modelapi.ModerationViolenceGraphic:       scores.ViolenceGraphic,
+	}}, nil
+}
@@ -0,0 +1,413 @@
+package openrouterapi
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"gulabodev/logger"
+	"gulabodev/modelapi"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+	"golang.org/x/sync/semaphore"
+)
+
+const (
+	chatURL = "https://openrouter.ai/api/v1/chat/completions"
+	// Replies stop here unless the request sets its own cap
+	maxReplyTokens = 2048
+)
+
+// OpenRouter serves many hosted models through one API, so new models can be
+// tried by listing them in OPENROUTER_MODELS.
+type OpenRouter struct {
+	logger    *logger.LogMiddleware
+	semaphore *semaphore.Weighted
+	apiKey    string
+}
+
+type OpenRouterConnectProps struct {
+	Logger *logger.LogMiddleware
+}
+
+func Connect(ctx context.Context, args OpenRouterConnectProps) *OpenRouter {
+	tracer := otel.Tracer("openrouterapi/Connect")
+	ctx, span := tracer.Start(ctx, "Connect")
+	defer span.End()
+
+	maxWorkers := 10
+	sem := semaphore.NewWeighted(int64(maxWorkers))
+
+	span.SetAttributes(attribute.Int("maxWorkers", maxWorkers))
+
+	return &OpenRouter{logger: args.Logger, semaphore: sem, apiKey: os.Getenv("OPENROUTER_API_KEY")}
+}
+
+// Models is a chat provider for each model in OPENROUTER_MODELS, a
+// comma-separated list of OpenRouter model IDs. There are none without
+// OPENROUTER_API_KEY.
+func (o *OpenRouter) Models() []modelapi.ChatProvider {
+	if o.apiKey == "" {
+		return nil
+	}
+	var models []modelapi.ChatProvider
+	for _, model := range strings.Split(os.Getenv("OPENROUTER_MODELS"), ",") {
+		model = strings.TrimSpace(model)
+		if model != "" {
+			models = append(models, &Model{client: o, model: model})
+		}
+	}
+	return models
+}
+
+// Model is one OpenRouter model as a chat provider. It's named
+// "openrouter:<model>" in LLM_ROUTES and persona configs.
+type Model struct {
+	client *OpenRouter
+	model  string
+}
+
+func (m *Model) Name() string {
+	return "openrouter:" + m.model
+}
+
+type messageContent struct {
+	Type     string    `json:"type"`
+	Text     string    `json:"text,omitempty"`
+	ImageURL *imageURL `json:"image_url,omitempty"`
+}
+
+type imageURL struct {
+	URL string `json:"url"`
+}
+
+type message struct {
+	Role string `json:"role"`
+	// Content is a string, or a list of parts for a message with images
+	Content any `json:"content"`
+}
+
+type tool struct {
+	Type     string       `json:"type"`
+	Function toolFunction `json:"function"`
+}
+
+type toolFunction struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	Parameters  modelapi.ToolParameter `json:"parameters"`
+}
+
+type toolChoice struct {
+	Type     string `json:"type"`
+	Function struct {
+		Name string `json:"name"`
+	} `json:"function"`
+}
+
+type usageOptions struct {
+	Include bool `json:"include"`
+}
+
+type chatRequest struct {
+	Model       string      `json:"model"`
+	Messages    []message   `json:"messages"`
+	MaxTokens   int         `json:"max_tokens"`
+	Temperature float32     `json:"temperature,omitempty"`
+	TopP        float32     `json:"top_p,omitempty"`
+	Tools       []tool      `json:"tools,omitempty"`
+	ToolChoice  *toolChoice `json:"tool_choice,omitempty"`
+	Stream      bool        `json:"stream,omitempty"`
+	// Usage asks for the tokens and cost, at the end of a stream if streaming
+	Usage usageOptions `json:"usage"`
+}
+
+type usage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	// Cost is what OpenRouter charged, in US dollars
+	Cost float64 `json:"cost"`
+}
+
+type chatResponse struct {
+	Choices []struct {
+		Message struct {
+			Content   string `json:"content"`
+			ToolCalls []struct {
+				Function struct {
+					Name      string `json:"name"`
+					Arguments string `json:"arguments"`
+				} `json:"function"`
+			} `json:"tool_calls"`
+		} `json:"message"`
+	} `json:"choices"`
+	Usage *usage `json:"usage"`
+}
+
+type streamChunk struct {
+	Choices []struct {
+		Delta struct {
+			Content string `json:"content"`
+		} `json:"delta"`
+	} `json:"choices"`
+	// Usage is only set on the last chunk
+	Usage *usage `json:"usage"`
+}
+
+// buildMessages prepends the system prompt to the history and appends the new
+// user message.
+func buildMessages(request modelapi.ChatRequest) []message {
+	messages := []message{{Role: "system", Content: request.SystemPrompt}}
+	for _, m := range request.History {
+		messages = append(messages, withImages(m.Role, m.Content, m.Images))
+	}
+	return append(messages, withImages("user", request.Message, request.Images))
+}
+
+// withImages is a message with its images as parts after the text, or plain
+// text if it has none.
+func withImages(role string, text string, images []modelapi.ChatImage) message {
+	if len(images) == 0 {
+		return message{Role: role, Content: text}
+	}
+	parts := []messageContent{{Type: "text", Text: text}}
+	for _, image := range images {
+		parts = append(parts, messageContent{
+			Type:     "image_url",
+			ImageURL: &imageURL{URL: "data:" + image.MimeType + ";base64," + base64.StdEncoding.EncodeToString(image.Data)},
+		})
+	}
+	return message{Role: role, Content: parts}
+}
+
+// recordUsage reports the tokens a request used, priced at what OpenRouter
+// charged for it.
+func (m *Model) recordUsage(ctx context.Context, u *usage) {
+	reported := modelapi.Usage{Provider: "openrouter", Kind: modelapi.UsageKindChat, Model: m.model}
+	if u != nil {
+		reported.InputTokens = u.PromptTokens
+		reported.OutputTokens = u.CompletionTokens
+		reported.ReportedCost = u.Cost
+	}
+	modelapi.RecordUsage(ctx, reported)
+}
+
+// post sends a chat completion request and returns the response body for the
+// caller to read and close.
+func (m *Model) post(ctx context.Context, request chatRequest) (io.ReadCloser, error) {
+	jsonData, err := json.Marshal(request)
+	if err != nil {
+		return nil, fmt.Errorf("Could not generate request body: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", chatURL, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create request: %w", err)
+	}
+	req.Header.Set("authorization", "Bearer "+m.client.apiKey)
+	req.Header.Set("content-type", "application/json")
+	// Shows up in OpenRouter's app rankings instead of an anonymous key
+	req.Header.Set("x-title", "Gulabo")
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to fetch response: %w", err)
+	}
+	if res.StatusCode != http.StatusOK {
+		defer res.Body.Close()
+		body, _ := io.ReadAll(res.Body)
+		return nil, fmt.Errorf("Request failed: %d %s", res.StatusCode, body)
+	}
+	return res.Body, nil
+}
+
+// complete makes a non-streaming request.
+func (m *Model) complete(ctx context.Context, request chatRequest) (*chatResponse, error) {
+	if err := m.client.semaphore.Acquire(ctx, 1); err != nil {
+		return nil, fmt.Errorf("Failed to acquire semaphore.")
+	}
+	defer m.client.semaphore.Release(1)
+
+	body, err := m.post(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	var response chatResponse
+	if err := json.NewDecoder(body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("Could not parse response: %w", err)
+	}
+	m.recordUsage(ctx, response.Usage)
+	if len(response.Choices) == 0 {
+		return nil, fmt.Errorf("no response received")
+	}
+	return &response, nil
+}
+
+// GetResponse implements modelapi.ChatProvider.
+func (m *Model) GetResponse(ctx context.Context, request modelapi.ChatRequest) (string, error) {
+	tracer := otel.Tracer("openrouterapi/GetResponse")
+	ctx, span := tracer.Start(ctx, "GetResponse")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("model", m.model),
+		attribute.Int("conversation_history_length", len(request.History)),
+		attribute.Int("images", len(request.Images)),
+	)
+
+	response, err := m.complete(ctx, chatRequest{
+		Model:       m.model,
+		Messages:    buildMessages(request),
+		MaxTokens:   request.ReplyTokens(maxReplyTokens),
+		Temperature: request.Temperature,
+		TopP:        request.TopP,
+		Usage:       usageOptions{Include: true},
+	})
+	if err != nil {
+		span.RecordError(err)
+		m.client.logger.Logger(ctx).Error("[OpenRouter] Request failed", zap.Error(err), zap.String("model", m.model))
+		return "", err
+	}
+	if response.Choices[0].Message.Content == "" {
+		return "", fmt.Errorf("no response received")
+	}
+	return response.Choices[0].Message.Content, nil
+}
+
+// GetResponseWithTools implements modelapi.ChatProvider. Only models that
+// support tool calling can serve it.
+func (m *Model) GetResponseWithTools(ctx context.Context, request modelapi.ChatRequest, chatTool modelapi.ChatTool, v any) error {
+	tracer := otel.Tracer("openrouterapi/GetResponseWithTools")
+	ctx, span := tracer.Start(ctx, "GetResponseWithTools")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("model", m.model), attribute.String("tool", chatTool.Name))
+
+	choice := toolChoice{Type: "function"}
+	choice.Function.Name = chatTool.Name
+	response, err := m.complete(ctx, chatRequest{
+		Model:       m.model,
+		Messages:    buildMessages(request),
+		MaxTokens:   512,
+		Temperature: request.Temperature,
+		TopP:        request.TopP,
+		Tools: []tool{{
+			Type: "function",
+			Function: toolFunction{
+				Name:        chatTool.Name,
+				Description: chatTool.Description,
+				Parameters:  chatTool.Parameters,
+			},
+		}},
+		ToolChoice: &choice,
+		Usage:      usageOptions{Include: true},
+	})
+	if err != nil {
+		span.RecordError(err)
+		return err
+	}
+
+	toolCalls := response.Choices[0].Message.ToolCalls
+	if len(toolCalls) == 0 {
+		return fmt.Errorf("no tool call received")
+	}
+	if err := json.Unmarshal([]byte(toolCalls[0].Function.Arguments), v); err != nil {
+		span.RecordError(err)
+		return fmt.Errorf("Could not parse tool arguments: %w", err)
+	}
+	return nil
+}
+
+// StreamResponse implements modelapi.ChatStreamer with server-sent events.
+func (m *Model) StreamResponse(ctx context.Context, request modelapi.ChatRequest, onDelta func(string)) (string, error) {
+	tracer := otel.Tracer("openrouterapi/StreamResponse")
+	ctx, span := tracer.Start(ctx, "StreamResponse")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("model", m.model),
+		attribute.Int("conversation_history_length", len(request.History)),
+		attribute.Int("images", len(request.Images)),
+	)
+
+	if err := m.client.semaphore.Acquire(ctx, 1); err != nil {
+		span.RecordError(err)
+		return "", fmt.Errorf("Failed to acquire semaphore.")
+	}
+	defer m.client.semaphore.Release(1)
+
+	body, err := m.post(ctx, chatRequest{
+		Model:       m.model,
+		Messages:    buildMessages(request),
+		MaxTokens:   request.ReplyTokens(maxReplyTokens),
+		Temperature: request.Temperature,
+		TopP:        request.TopP,
+		Stream:      true,
+		Usage:       usageOptions{Include: true},
+	})
+	if err != nil {
+		span.RecordError(err)
+		return "", err
+	}
+	defer body.Close()
+
+	response, u, err := m.readStream(ctx, body, onDelta)
+	if err != nil {
+		span.RecordError(err)
+		return "", err
+	}
+	m.recordUsage(ctx, u)
+	if response == "" {
+		return "", fmt.Errorf("no response received")
+	}
+
+	span.SetAttributes(attribute.Int("response_length", len(response)))
+	return response, nil
+}
+
+// readStream reads server-sent chat completion chunks until [DONE], calling
+// onDelta with each piece of text. OpenRouter also sends comment lines to keep
+// the connection open, which are skipped.
+func (m *Model) readStream(ctx context.Context, body io.Reader, onDelta func(string)) (string, *usage, error) {
+	var response strings.Builder
+	var u *usage
+	scanner := bufio.NewScanner(body)
+	for scanner.Scan() {
+		data, found := strings.CutPrefix(scanner.Text(), "data: ")
+		if !found {
+			continue
+		}
+		if data == "[DONE]" {
+			break
+		}
+
+		var chunk streamChunk
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			m.client.logger.Logger(ctx).Warn("[OpenRouter] Could not parse stream chunk", zap.Error(err), m.client.logger.Content("data", data))
+			continue
+		}
+		if chunk.Usage != nil {
+			u = chunk.Usage
+		}
+		if len(chunk.Choices) == 0 || chunk.Choices[0].Delta.Content == "" {
+			continue
+		}
+
+		response.WriteString(chunk.Choices[0].Delta.Content)
+		onDelta(chunk.Choices[0].Delta.Content)
+	}
+	if err := scanner.Err(); err != nil {
+		return "", nil, fmt.Errorf("Failed to read stream: %w", err)
+	}
+	return response.String(), u, nil
+}
@@ -0,0 +1,79 @@
+package openrouterapi
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"gulabodev/logger"
+	"gulabodev/modelapi"
+)
+
+func TestModels(t *testing.T) {
+	t.Setenv("OPENROUTER_MODELS", "mistralai/mistral-nemo, ,nousresearch/hermes-3-llama-3.1-70b")
+
+	if models := (&OpenRouter{}).Models(); len(models) != 0 {
+		t.Errorf("expected no models without an API key, got %d", len(models))
+	}
+
+	models := (&OpenRouter{apiKey: "key"}).Models()
+	if len(models) != 2 || models[0].Name() != "openrouter:mistralai/mistral-nemo" || models[1].Name() != "openrouter:nousresearch/hermes-3-llama-3.1-70b" {
+		t.Errorf("unexpected models: %v", models)
+	}
+}
+
+func TestBuildMessages(t *testing.T) {
+	messages := buildMessages(modelapi.ChatRequest{
+		SystemPrompt: "be nice",
+		History:      []modelapi.ChatMessage{{Role: "assistant", Content: "hi"}},
+		Message:      "look",
+		Images:       []modelapi.ChatImage{{Data: []byte("img"), MimeType: "image/jpeg"}},
+	})
+
+	data, err := json.Marshal(messages)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := `[{"role":"system","content":"be nice"},{"role":"assistant","content":"hi"},` +
+		`{"role":"user","content":[{"type":"text","text":"look"},{"type":"image_url","image_url":{"url":"data:image/jpeg;base64,aW1n"}}]}]`
+	if string(data) != want {
+		t.Errorf("got %s\nwant %s", data, want)
+	}
+}
+
+func TestReadStream(t *testing.T) {
+	logMiddleware := logger.Connect(logger.LoggerConnectProps{Production: false})
+	m := &Model{client: &OpenRouter{logger: logMiddleware}, model: "mistralai/mistral-nemo"}
+
+	stream := strings.Join([]string{
+		": OPENROUTER PROCESSING",
+		`data: {"choices":[{"delta":{"content":"Hel"}}]}`,
+		`data: {"choices":[{"delta":{"content":"lo"}}]}`,
+		`data: {"choices":[],"usage":{"prompt_tokens":12,"completion_tokens":2,"cost":0.00004}}`,
+		"data: [DONE]",
+	}, "\n")
+
+	var deltas []string
+	response, u, err := m.readStream(context.Background(), strings.NewReader(stream), func(delta string) {
+		deltas = append(deltas, delta)
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if response != "Hello" || len(deltas) != 2 {
+		t.Errorf("got %q from %v", response, deltas)
+	}
+	if u == nil || u.PromptTokens != 12 || u.CompletionTokens != 2 || u.Cost != 0.00004 {
+		t.Fatalf("unexpected usage: %+v", u)
+	}
+
+	var recorded modelapi.Usage
+	ctx := modelapi.WithUsageRecorder(context.Background(), func(ctx context.Context, usage modelapi.Usage) {
+		recorded = usage
+	})
+	m.recordUsage(ctx, u)
+	if recorded.Model != "mistralai/mistral-nemo" || recorded.CostMicros() != 40 {
+		t.Errorf("unexpected recorded usage: %+v", recorded)
+	}
+}
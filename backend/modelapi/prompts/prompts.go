@@ -0,0 +1,282 @@
+// Package prompts keeps every system prompt as a versioned Go template,
+// referenced by ID. The templates are built in, and a directory named by
+// PROMPTS_DIR can add versions or replace them without a rebuild.
+package prompts
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"fmt"
+	"gulabodev/logger"
+	"io/fs"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"text/template"
+
+	"go.opentelemetry.io/otel"
+	"go.uber.org/zap"
+)
+
+// Prompt IDs. Each is a file named <id>.v<version>.tmpl.
+const (
+	StyleInstruction         = "style_instruction"
+	SystemHinglish           = "system_hinglish"
+	SystemHinglishDevanagari = "system_hinglish_devanagari"
+	SystemHindi              = "system_hindi"
+	SystemPunjabi            = "system_punjabi"
+	SystemPunjabiGurmukhi    = "system_punjabi_gurmukhi"
+	SystemEnglish            = "system_english"
+	PersonaSimran            = "persona_simran"
+	PersonaCustom            = "persona_custom"
+	Memories                 = "memories"
+	MemoryExtraction         = "memory_extraction"
+	ScenarioGeneration       = "scenario_generation"
+	PracticeRoleplay         = "practice_roleplay"
+	InteractionAnalysis      = "interaction_analysis"
+	Transcription            = "transcription"
+	VoiceUnderstanding       = "voice_understanding"
+	ConversationSummary      = "conversation_summary"
+	StorySoFar               = "story_so_far"
+	RecalledMoments          = "recalled_moments"
+	Selfie                   = "selfie"
+)
+
+// StyleData fills in StyleInstruction.
+type StyleData struct {
+	// Style is a note on how to deliver this line, like the mood she's in
+	Style string
+	// Emotion is the provider's wording of the emotion to voice
+	Emotion string
+}
+
+// CustomPersonaData fills in PersonaCustom with what the user picked in /create.
+type CustomPersonaData struct {
+	Name   string
+	Traits string
+	City   string
+}
+
+// MemoriesData fills in Memories.
+type MemoriesData struct {
+	Facts []string
+}
+
+// StorySoFarData fills in StorySoFar with a conversation's summary.
+type StorySoFarData struct {
+	Summary string
+}
+
+// RecalledMomentsData fills in RecalledMoments with the past exchanges that
+// relate to the user's message.
+type RecalledMomentsData struct {
+	Moments []RecalledMoment
+}
+
+// RecalledMoment is one exchange from a past chat.
+type RecalledMoment struct {
+	// When is how long ago it happened, like "3 weeks"; empty if unknown
+	When    string
+	Content string
+}
+
+// SelfieData fills in Selfie. The character's fixed Appearance keeps her
+// face the same whatever the Scene.
+type SelfieData struct {
+	Appearance string
+	Scene      string
+}
+
+// PracticeData fills in PracticeRoleplay with the generated scenario.
+type PracticeData struct {
+	Title        string
+	Description  string
+	Venue        string
+	VenueType    string
+	Neighborhood string
+	City         string
+	Vibe         string
+	Time         string
+	Situation    string
+	Person       string
+}
+
+//go:embed templates/*.tmpl
+var templates embed.FS
+
+var fileNamePattern = regexp.MustCompile(`^([a-z0-9_]+)\.v([0-9]+)\.tmpl$`)
+
+// Registry holds every version of every prompt and renders the active one.
+type Registry struct {
+	versions map[string]map[int]*template.Template
+	// active is the version rendered for each ID, the newest unless pinned
+	active map[string]int
+}
+
+// Load reads the templates in each source. A later source's template
+// replaces an earlier one with the same ID and version.
+func Load(sources ...fs.FS) (*Registry, error) {
+	r := &Registry{
+		versions: map[string]map[int]*template.Template{},
+		active:   map[string]int{},
+	}
+	for _, source := range sources {
+		names, err := fs.Glob(source, "*.tmpl")
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range names {
+			match := fileNamePattern.FindStringSubmatch(name)
+			if match == nil {
+				return nil, fmt.Errorf("invalid prompt file name %q", name)
+			}
+			id := match[1]
+			version, _ := strconv.Atoi(match[2])
+
+			text, err := fs.ReadFile(source, name)
+			if err != nil {
+				return nil, err
+			}
+			tmpl, err := template.New(name).Option("missingkey=error").Parse(string(text))
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse prompt %s: %w", name, err)
+			}
+
+			if r.versions[id] == nil {
+				r.versions[id] = map[int]*template.Template{}
+			}
+			r.versions[id][version] = tmpl
+			if version > r.active[id] {
+				r.active[id] = version
+			}
+		}
+	}
+	return r, nil
+}
+
+// Pin renders an older or newer version of a prompt than the newest.
+func (r *Registry) Pin(id string, version int) error {
+	if _, ok := r.versions[id][version]; !ok {
+		return fmt.Errorf("no version %d of prompt %q", version, id)
+	}
+	r.active[id] = version
+	return nil
+}
+
+// Version is the active version of a prompt, or 0 if there's no such prompt.
+func (r *Registry) Version(id string) int {
+	return r.active[id]
+}
+
+// Render executes the active version of a prompt with data.
+func (r *Registry) Render(id string, data any) (string, error) {
+	tmpl, ok := r.versions[id][r.active[id]]
+	if !ok {
+		return "", fmt.Errorf("unknown prompt %q", id)
+	}
+	var b bytes.Buffer
+	if err := tmpl.Execute(&b, data); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+func builtinRegistry() *Registry {
+	source, err := fs.Sub(templates, "templates")
+	if err != nil {
+		panic(err)
+	}
+	r, err := Load(source)
+	if err != nil {
+		panic(err)
+	}
+	return r
+}
+
+var (
+	builtin = builtinRegistry()
+	// current is what Render uses, the built-ins until Configure runs
+	current atomic.Pointer[Registry]
+)
+
+func init() {
+	current.Store(builtin)
+}
+
+// Render executes the active version of a prompt. If an override can't be
+// rendered, the built-in version is used instead so replies keep going out;
+// the error goes to the OpenTelemetry error handler.
+func Render(id string, data any) string {
+	text, err := current.Load().Render(id, data)
+	if err == nil {
+		return text
+	}
+	otel.Handle(fmt.Errorf("failed to render prompt %q: %w", id, err))
+	text, _ = builtin.Render(id, data)
+	return text
+}
+
+// Exists reports whether there's a prompt with the given ID.
+func Exists(id string) bool {
+	_, ok := current.Load().versions[id]
+	return ok
+}
+
+// Configure loads the templates in PROMPTS_DIR over the built-ins and pins
+// the versions in PROMPT_VERSIONS, e.g. "system_hinglish=1,memories=2". If
+// either is invalid, the built-ins stay in use.
+func Configure(ctx context.Context, logger *logger.LogMiddleware) {
+	sources := []fs.FS{}
+	if source, err := fs.Sub(templates, "templates"); err == nil {
+		sources = append(sources, source)
+	}
+	if dir := os.Getenv("PROMPTS_DIR"); dir != "" {
+		sources = append(sources, os.DirFS(dir))
+	}
+
+	r, err := Load(sources...)
+	if err != nil {
+		logger.Logger(ctx).Error("Failed to load prompts, using the built-in ones", zap.Error(err))
+		return
+	}
+	if err := pinVersions(r, os.Getenv("PROMPT_VERSIONS")); err != nil {
+		logger.Logger(ctx).Error("Invalid PROMPT_VERSIONS, using the built-in prompts", zap.Error(err))
+		return
+	}
+	current.Store(r)
+
+	logger.Logger(ctx).Info("Prompts loaded", zap.Strings("versions", r.describe()))
+}
+
+// pinVersions pins each "id=version" in a comma-separated list.
+func pinVersions(r *Registry, raw string) error {
+	for _, pin := range strings.Split(raw, ",") {
+		pin = strings.TrimSpace(pin)
+		if pin == "" {
+			continue
+		}
+		id, rawVersion, found := strings.Cut(pin, "=")
+		version, err := strconv.Atoi(strings.TrimSpace(rawVersion))
+		if !found || err != nil {
+			return fmt.Errorf("invalid prompt version %q", pin)
+		}
+		if err := r.Pin(strings.TrimSpace(id), version); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// describe lists the active version of every prompt, like "memories@v2".
+func (r *Registry) describe() []string {
+	var versions []string
+	for id, version := range r.active {
+		versions = append(versions, id+"@v"+strconv.Itoa(version))
+	}
+	sort.Strings(versions)
+	return versions
+}
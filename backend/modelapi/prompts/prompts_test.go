@@ -0,0 +1,106 @@
+package prompts
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestRenderBuiltin(t *testing.T) {
+	data := map[string]any{
+		StyleInstruction:         StyleData{Style: "sleepy"},
+		SystemHinglish:           nil,
+		SystemHinglishDevanagari: nil,
+		SystemHindi:              nil,
+		SystemPunjabi:            nil,
+		SystemPunjabiGurmukhi:    nil,
+		SystemEnglish:            nil,
+		PersonaSimran:            nil,
+		PersonaCustom:            CustomPersonaData{Name: "Riya", Traits: "shy, witty", City: "Pune"},
+		Memories:                 MemoriesData{Facts: []string{"Has a dog named Bruno"}},
+		MemoryExtraction:         nil,
+		ScenarioGeneration:       nil,
+		PracticeRoleplay:         PracticeData{Title: "Coffee", Venue: "Blue Tokai", City: "Delhi"},
+		InteractionAnalysis:      nil,
+		Transcription:            nil,
+		VoiceUnderstanding:       nil,
+		ConversationSummary:      nil,
+		StorySoFar:               StorySoFarData{Summary: "They met at a cafe in Pune."},
+		RecalledMoments:          RecalledMomentsData{Moments: []RecalledMoment{{When: "3 weeks", Content: "Lover: My sister's wedding is in March"}}},
+		Selfie:                   SelfieData{Appearance: "a 24-year-old Indian woman", Scene: "at the beach"},
+	}
+	for id, d := range data {
+		got, err := builtin.Render(id, d)
+		if err != nil {
+			t.Errorf("Render(%q) failed: %v", id, err)
+			continue
+		}
+		if strings.TrimSpace(got) == "" {
+			t.Errorf("Render(%q) is empty", id)
+		}
+	}
+	if len(builtin.versions) != len(data) {
+		t.Errorf("%d built-in prompts, %d rendered", len(builtin.versions), len(data))
+	}
+
+	if got := Render(PersonaCustom, CustomPersonaData{Name: "Riya", Traits: "shy", City: "Pune"}); !strings.Contains(got, "Riya") || !strings.Contains(got, "Pune") {
+		t.Errorf("PersonaCustom is missing the persona: %q", got)
+	}
+	if got := Render(StyleInstruction, StyleData{Emotion: "Say this sleepily."}); !strings.HasSuffix(got, "\nSay this sleepily.\n") {
+		t.Errorf("StyleInstruction is missing the emotion: %q", got)
+	}
+	if got := Render(Memories, MemoriesData{}); got != "" {
+		t.Errorf("Memories with no facts = %q, want empty", got)
+	}
+	if got := Render(StorySoFar, StorySoFarData{}); got != "" {
+		t.Errorf("StorySoFar with no summary = %q, want empty", got)
+	}
+	if got := Render(RecalledMoments, RecalledMomentsData{}); got != "" {
+		t.Errorf("RecalledMoments with none = %q, want empty", got)
+	}
+}
+
+func TestRegistryVersions(t *testing.T) {
+	overrides := fstest.MapFS{
+		"memories.v1.tmpl":          {Data: []byte("replaced {{len .Facts}}")},
+		"memories.v2.tmpl":          {Data: []byte("v2 {{len .Facts}}")},
+		"style_instruction.v1.tmpl": {Data: []byte("{{.Missing}}")},
+	}
+	source := fstest.MapFS{"memories.v1.tmpl": {Data: []byte("v1")}}
+	r, err := Load(source, overrides)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data := MemoriesData{Facts: []string{"a"}}
+	if got, _ := r.Render(Memories, data); got != "v2 1" {
+		t.Errorf("newest version rendered %q, want %q", got, "v2 1")
+	}
+	if err := pinVersions(r, "memories=1"); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := r.Render(Memories, data); got != "replaced 1" {
+		t.Errorf("pinned version rendered %q, want %q", got, "replaced 1")
+	}
+
+	if _, err := r.Render(StyleInstruction, StyleData{}); err == nil {
+		t.Error("Render with a missing field succeeded")
+	}
+	if _, err := r.Render("unknown", nil); err == nil {
+		t.Error("Render of an unknown prompt succeeded")
+	}
+	for _, pins := range []string{"memories=3", "memories", "memories=x", "unknown=1"} {
+		if err := pinVersions(r, pins); err == nil {
+			t.Errorf("pinVersions(%q) succeeded", pins)
+		}
+	}
+}
+
+func TestLoadInvalid(t *testing.T) {
+	if _, err := Load(fstest.MapFS{"memories.tmpl": {Data: []byte("x")}}); err == nil {
+		t.Error("Load accepted a file without a version")
+	}
+	if _, err := Load(fstest.MapFS{"memories.v1.tmpl": {Data: []byte("{{")}}); err == nil {
+		t.Error("Load accepted a broken template")
+	}
+}
@@ -0,0 +1,154 @@
+package modelapi
+
+import (
+	"context"
+	"gulabodev/logger"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+// Quota is how much a provider lets us send it a minute. Zero leaves that
+// side unlimited.
+type Quota struct {
+	RequestsPerMinute int
+	TokensPerMinute   int
+}
+
+// LoadQuota reads a provider's quota from <prefix>_REQUESTS_PER_MINUTE and
+// <prefix>_TOKENS_PER_MINUTE, like GROQ_REQUESTS_PER_MINUTE, falling back to
+// defaults for each. 0 turns that limit off.
+func LoadQuota(ctx context.Context, logger *logger.LogMiddleware, prefix string, defaults Quota) Quota {
+	quota := defaults
+	for name, limit := range map[string]*int{
+		prefix + "_REQUESTS_PER_MINUTE": &quota.RequestsPerMinute,
+		prefix + "_TOKENS_PER_MINUTE":   &quota.TokensPerMinute,
+	} {
+		raw := os.Getenv(name)
+		if raw == "" {
+			continue
+		}
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 0 {
+			logger.Logger(ctx).Error("Invalid "+name+", using default", zap.String("value", raw))
+			continue
+		}
+		*limit = parsed
+	}
+	return quota
+}
+
+// QuotaLimiter keeps requests to one provider within its Quota with a token
+// bucket for requests and another for tokens. Each bucket holds a minute's
+// worth and refills continuously, so a burst goes straight through until the
+// bucket runs dry and then queues, instead of the provider answering with a
+// 429 that every caller retries at once. A nil QuotaLimiter never waits.
+type QuotaLimiter struct {
+	quota Quota
+	now   func() time.Time
+
+	mu sync.Mutex
+	// requests and tokens go negative while callers are queued; the deficit
+	// is how long the last of them waits
+	requests float64
+	tokens   float64
+	lastFill time.Time
+}
+
+func NewQuotaLimiter(quota Quota) *QuotaLimiter {
+	l := &QuotaLimiter{
+		quota:    quota,
+		now:      time.Now,
+		requests: float64(quota.RequestsPerMinute),
+		tokens:   float64(quota.TokensPerMinute),
+	}
+	l.lastFill = l.now()
+	return l
+}
+
+// Wait blocks until one more request of about tokens fits in the quota,
+// queueing behind any that are already waiting. If ctx is done first it gives
+// the request's share back and returns ctx's error.
+func (l *QuotaLimiter) Wait(ctx context.Context, tokens int) error {
+	if l == nil {
+		return nil
+	}
+
+	wait := l.reserve(tokens)
+	if wait <= 0 {
+		return nil
+	}
+	trace.SpanFromContext(ctx).AddEvent("Waiting for quota", trace.WithAttributes(
+		attribute.Int64("waitMs", wait.Milliseconds()),
+		attribute.Int("tokens", tokens),
+	))
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		l.release(tokens)
+		return ctx.Err()
+	}
+}
+
+// reserve takes a request and tokens out of the buckets and returns how long
+// until the buckets have refilled enough to cover them. A request bigger than
+// a whole minute's tokens is counted as a minute's worth, so it waits for an
+// empty bucket rather than forever.
+func (l *QuotaLimiter) reserve(tokens int) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.refill()
+
+	var wait time.Duration
+	if perMinute := float64(l.quota.RequestsPerMinute); perMinute > 0 {
+		l.requests--
+		wait = max(wait, deficit(l.requests, perMinute))
+	}
+	if perMinute := float64(l.quota.TokensPerMinute); perMinute > 0 {
+		l.tokens -= min(float64(tokens), perMinute)
+		wait = max(wait, deficit(l.tokens, perMinute))
+	}
+	return wait
+}
+
+// release gives back what reserve took for a request that was never sent.
+func (l *QuotaLimiter) release(tokens int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.refill()
+
+	if perMinute := float64(l.quota.RequestsPerMinute); perMinute > 0 {
+		l.requests = min(l.requests+1, perMinute)
+	}
+	if perMinute := float64(l.quota.TokensPerMinute); perMinute > 0 {
+		l.tokens = min(l.tokens+min(float64(tokens), perMinute), perMinute)
+	}
+}
+
+// refill adds what has trickled back into each bucket since it was last
+// topped up, up to a minute's worth.
+func (l *QuotaLimiter) refill() {
+	now := l.now()
+	minutes := now.Sub(l.lastFill).Minutes()
+	l.lastFill = now
+	l.requests = min(l.requests+minutes*float64(l.quota.RequestsPerMinute), float64(l.quota.RequestsPerMinute))
+	l.tokens = min(l.tokens+minutes*float64(l.quota.TokensPerMinute), float64(l.quota.TokensPerMinute))
+}
+
+// deficit is how long a bucket refilling at perMinute takes to climb from
+// balance back to zero.
+func deficit(balance float64, perMinute float64) time.Duration {
+	if balance >= 0 {
+		return 0
+	}
+	return time.Duration(-balance / perMinute * float64(time.Minute))
+}
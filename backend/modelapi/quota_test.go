@@ -0,0 +1,61 @@
+package modelapi
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestQuotaLimiterReserve(t *testing.T) {
+	now := time.Now()
+	l := NewQuotaLimiter(Quota{RequestsPerMinute: 2, TokensPerMinute: 600})
+	l.now = func() time.Time { return now }
+	l.lastFill = now
+
+	if wait := l.reserve(100); wait != 0 {
+		t.Errorf("first request waits %v, want none", wait)
+	}
+	if wait := l.reserve(100); wait != 0 {
+		t.Errorf("second request waits %v, want none", wait)
+	}
+	// Out of requests: one comes back every 30 seconds
+	if wait := l.reserve(100); wait != 30*time.Second {
+		t.Errorf("third request waits %v, want 30s", wait)
+	}
+	// Queued behind the third
+	if wait := l.reserve(100); wait != time.Minute {
+		t.Errorf("fourth request waits %v, want 1m", wait)
+	}
+
+	now = now.Add(5 * time.Minute)
+	// 600 tokens come back a minute, so the 300 past a full bucket take 30s
+	if wait := l.reserve(900); wait != 0 {
+		t.Errorf("oversized request waits %v, want none once the bucket is full", wait)
+	}
+	if wait := l.reserve(300); wait != 30*time.Second {
+		t.Errorf("request after an oversized one waits %v, want 30s", wait)
+	}
+}
+
+func TestQuotaLimiterWait(t *testing.T) {
+	var unlimited *QuotaLimiter
+	if err := unlimited.Wait(context.Background(), 1000); err != nil {
+		t.Errorf("nil limiter Wait = %v", err)
+	}
+
+	l := NewQuotaLimiter(Quota{RequestsPerMinute: 1})
+	if err := l.Wait(context.Background(), 0); err != nil {
+		t.Fatalf("Wait = %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := l.Wait(ctx, 0); err != context.DeadlineExceeded {
+		t.Errorf("Wait = %v, want the context's error", err)
+	}
+	// The abandoned request gave its place back, so the next is only behind
+	// the first
+	if wait := l.reserve(0); wait > time.Minute {
+		t.Errorf("next request waits %v, want at most 1m", wait)
+	}
+}
@@ -0,0 +1,90 @@
+package modelapi
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// ValidateSchema checks a value decoded from a model's JSON against the
+// schema it was asked to follow, which models don't always stick to. Numbers
+// decode as float64s.
+func ValidateSchema(schema ToolParameter, value any) error {
+	return validateSchema(schema, value, "")
+}
+
+func validateSchema(schema ToolParameter, value any, path string) error {
+	name := path
+	if name == "" {
+		name = "output"
+	}
+
+	switch schema.Type {
+	case "object":
+		object, ok := value.(map[string]any)
+		if !ok {
+			return fmt.Errorf("%s should be an object", name)
+		}
+		for _, key := range schema.Required {
+			if _, ok := object[key]; !ok {
+				return fmt.Errorf("%s is required", joinPath(path, key))
+			}
+		}
+		// Sorted, so the same output always reports the same error
+		keys := make([]string, 0, len(schema.Properties))
+		for key := range schema.Properties {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			if property, ok := object[key]; ok {
+				if err := validateSchema(schema.Properties[key], property, joinPath(path, key)); err != nil {
+					return err
+				}
+			}
+		}
+	case "array":
+		items, ok := value.([]any)
+		if !ok {
+			return fmt.Errorf("%s should be an array", name)
+		}
+		if schema.Items == nil {
+			return nil
+		}
+		for i, item := range items {
+			if err := validateSchema(*schema.Items, item, fmt.Sprintf("%s[%d]", name, i)); err != nil {
+				return err
+			}
+		}
+	case "string":
+		s, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("%s should be a string", name)
+		}
+		if len(schema.Enum) > 0 && !slices.Contains(schema.Enum, s) {
+			return fmt.Errorf("%s should be one of %s", name, strings.Join(schema.Enum, ", "))
+		}
+	case "integer":
+		if n, ok := value.(float64); !ok || n != math.Trunc(n) {
+			return fmt.Errorf("%s should be an integer", name)
+		}
+	case "number":
+		if _, ok := value.(float64); !ok {
+			return fmt.Errorf("%s should be a number", name)
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return fmt.Errorf("%s should be a boolean", name)
+		}
+	}
+	return nil
+}
+
+func joinPath(path string, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
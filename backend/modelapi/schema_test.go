@@ -0,0 +1,44 @@
+package modelapi
+
+import "testing"
+
+func TestValidateSchema(t *testing.T) {
+	schema := ToolParameter{
+		Type: "object",
+		Properties: map[string]ToolParameter{
+			"fact":     {Type: "string"},
+			"category": {Type: "string", Enum: []string{"family", "work"}},
+			"score":    {Type: "integer"},
+			"tags":     {Type: "array", Items: &ToolParameter{Type: "string"}},
+			"place": {
+				Type:       "object",
+				Properties: map[string]ToolParameter{"city": {Type: "string"}},
+				Required:   []string{"city"},
+			},
+		},
+		Required: []string{"fact"},
+	}
+
+	tests := []struct {
+		value any
+		want  string
+	}{
+		{map[string]any{"fact": "has a sister", "category": "family", "score": float64(3), "tags": []any{"wedding"}, "place": map[string]any{"city": "Pune"}}, ""},
+		{map[string]any{"category": "family"}, "fact is required"},
+		{map[string]any{"fact": "x", "category": "hobby"}, "category should be one of family, work"},
+		{map[string]any{"fact": "x", "score": 2.5}, "score should be an integer"},
+		{map[string]any{"fact": "x", "tags": []any{"a", float64(1)}}, "tags[1] should be a string"},
+		{map[string]any{"fact": "x", "place": map[string]any{}}, "place.city is required"},
+		{[]any{"x"}, "output should be an object"},
+	}
+	for _, tt := range tests {
+		err := ValidateSchema(schema, tt.value)
+		got := ""
+		if err != nil {
+			got = err.Error()
+		}
+		if got != tt.want {
+			t.Errorf("ValidateSchema(%v) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package modelapi
+
+import (
+	"encoding/xml"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// Replies mark delivery the way she'd write it in a chat: "..." for a
+// dramatic pause, *word* for emphasis, and stage directions in square
+// brackets like [whispers] or [giggles]. A whisper direction, also written
+// *whispers*, covers the rest of its sentence.
+var (
+	markupTag      = regexp.MustCompile(`<[^>]*>`)
+	whisperCue     = regexp.MustCompile(`(?i)[\[*](?:whispers?|whispering|softly)[\]*]\s*([^.!?।…]*[.!?।…]*)`)
+	stageDirection = regexp.MustCompile(`\[[^\]]*\]`)
+	emphasis       = regexp.MustCompile(`\*([^*\n]+)\*`)
+	ellipsis       = regexp.MustCompile(`\.{3,}|…`)
+	dash           = regexp.MustCompile(`\s*—\s*`)
+	spaces         = regexp.MustCompile(`[ \t]+`)
+)
+
+// SpeechSSML turns a reply into the body of an SSML <speak> element, for
+// providers that take SSML: pauses become breaks, emphasis and whispers
+// become emphasis and prosody, and other stage directions are dropped.
+func SpeechSSML(text string) string {
+	// Line breaks only separate sentences, and would be escaped as entities
+	text = strings.Join(strings.Fields(markupTag.ReplaceAllString(text, "")), " ")
+	var escaped strings.Builder
+	xml.EscapeText(&escaped, []byte(text))
+	ssml := escaped.String()
+
+	ssml = whisperCue.ReplaceAllString(ssml, `<prosody volume="x-soft" rate="slow">$1</prosody> `)
+	ssml = stageDirection.ReplaceAllString(ssml, "")
+	ssml = emphasis.ReplaceAllString(ssml, `<emphasis level="strong">$1</emphasis>`)
+	ssml = strings.ReplaceAll(ssml, "*", "")
+	ssml = ellipsis.ReplaceAllString(ssml, `<break time="600ms"/>`)
+	ssml = dash.ReplaceAllString(ssml, ` <break time="300ms"/> `)
+	ssml = strings.TrimSpace(spaces.ReplaceAllString(ssml, " "))
+
+	// Markup that overlaps, like an emphasis running into a whisper, can't be
+	// nested; the line is spoken without it rather than rejected
+	if !wellFormed(ssml) {
+		escaped.Reset()
+		xml.EscapeText(&escaped, []byte(PlainSpeech(text)))
+		return escaped.String()
+	}
+	return ssml
+}
+
+func wellFormed(ssml string) bool {
+	decoder := xml.NewDecoder(strings.NewReader("<speak>" + ssml + "</speak>"))
+	for {
+		_, err := decoder.Token()
+		if err == io.EOF {
+			return true
+		}
+		if err != nil {
+			return false
+		}
+	}
+}
+
+// PlainSpeech strips delivery markup and any SSML tags from a reply, for
+// providers that would otherwise read them out. Pauses are kept as
+// punctuation, which every provider already pauses on.
+func PlainSpeech(text string) string {
+	text = markupTag.ReplaceAllString(text, "")
+	text = whisperCue.ReplaceAllString(text, "$1")
+	text = stageDirection.ReplaceAllString(text, "")
+	text = strings.ReplaceAll(text, "*", "")
+	return strings.TrimSpace(spaces.ReplaceAllString(text, " "))
+}
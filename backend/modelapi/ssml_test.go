@@ -0,0 +1,39 @@
+package modelapi
+
+import "testing"
+
+func TestSpeechSSML(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"Hello baby", "Hello baby"},
+		{"Tum... *sach* mein?", `Tum<break time="600ms"/> <emphasis level="strong">sach</emphasis> mein?`},
+		{"Ruko — [giggles] abhi aati hoon", `Ruko <break time="300ms"/> abhi aati hoon`},
+		{"Suno. *whispers* come closer. Okay?", `Suno. <prosody volume="x-soft" rate="slow">come closer.</prosody> Okay?`},
+		{"Rock & roll <break time=\"1s\"/>\nbaby", "Rock &amp; roll baby"},
+		// Overlapping markup falls back to plain text
+		{"*hey [whispers] come* here.", "hey come here."},
+	}
+	for _, tt := range tests {
+		if got := SpeechSSML(tt.text); got != tt.want {
+			t.Errorf("SpeechSSML(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestPlainSpeech(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"Hello baby", "Hello baby"},
+		{"Tum... *sach* mein? [giggles]", "Tum... sach mein?"},
+		{"*whispers* come closer <break time=\"1s\"/> jaan", "come closer jaan"},
+	}
+	for _, tt := range tests {
+		if got := PlainSpeech(tt.text); got != tt.want {
+			t.Errorf("PlainSpeech(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
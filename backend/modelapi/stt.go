@@ -0,0 +1,133 @@
+package modelapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TranscriptionRequest is recorded speech to turn into text.
+type TranscriptionRequest struct {
+	Audio    []byte
+	MimeType string
+	// Keyterms are words the speech likely contains, like names, for
+	// providers that can be primed to recognize them
+	Keyterms []string
+	// WordTimings asks providers that can for when each word was said
+	WordTimings bool
+}
+
+// Word is one word of a transcript and when it was said, in seconds from the
+// start of the recording.
+type Word struct {
+	Text       string  `json:"text"`
+	Start      float64 `json:"start"`
+	End        float64 `json:"end"`
+	Confidence float64 `json:"confidence,omitempty"`
+}
+
+// Transcription is what was said in a recording.
+type Transcription struct {
+	Text string
+	// Languages are the codes of the languages spoken, like "hi" and "en",
+	// most spoken first. Empty when the provider doesn't detect them.
+	Languages []string
+	// Confidence is how sure the provider is of the text, from 0 to 1. 0
+	// means it didn't say.
+	Confidence float64
+	// Words are the transcript's words with their timings, when they were
+	// asked for and the provider has them
+	Words []Word
+}
+
+// Unsure reports whether the provider gave a confidence below minimum.
+func (t Transcription) Unsure(minimum float64) bool {
+	return t.Confidence > 0 && t.Confidence < minimum
+}
+
+// STTProvider transcribes speech with one speech-to-text service.
+type STTProvider interface {
+	Name() string
+	Transcribe(ctx context.Context, request TranscriptionRequest) (Transcription, error)
+}
+
+// FallbackSTT tries its providers in order until one returns a transcript
+// it's sure of. Every provider but the last gets at most timeout, so a slow
+// one is given up on like a failed one. A transcript less confident than
+// minConfidence sends it on to the next provider too; if none does better,
+// the most confident one is returned and the caller can see it's Unsure.
+type FallbackSTT struct {
+	timeout       time.Duration
+	minConfidence float64
+	providers     []STTProvider
+}
+
+func NewFallbackSTT(timeout time.Duration, minConfidence float64, providers ...STTProvider) *FallbackSTT {
+	return &FallbackSTT{timeout: timeout, minConfidence: minConfidence, providers: providers}
+}
+
+func (f *FallbackSTT) Name() string {
+	return "fallback"
+}
+
+func (f *FallbackSTT) Transcribe(ctx context.Context, request TranscriptionRequest) (Transcription, error) {
+	tracer := otel.Tracer("modelapi/FallbackSTT")
+	ctx, span := tracer.Start(ctx, "Transcribe")
+	defer span.End()
+
+	var errs []error
+	// The most confident of the transcripts providers weren't sure of
+	var unsure *Transcription
+	for i, provider := range f.providers {
+		transcription, err := f.attempt(ctx, provider, request, i == len(f.providers)-1)
+		if err == nil && transcription.Unsure(f.minConfidence) {
+			span.AddEvent("Provider unsure", trace.WithAttributes(
+				attribute.String("stt.provider", provider.Name()),
+				attribute.Float64("stt.confidence", transcription.Confidence),
+			))
+			if unsure == nil || transcription.Confidence > unsure.Confidence {
+				unsure = &transcription
+			}
+			continue
+		}
+		if err == nil {
+			span.SetAttributes(
+				attribute.String("stt.provider", provider.Name()),
+				attribute.Int("stt.fallbacks", i),
+			)
+			return transcription, nil
+		}
+
+		span.AddEvent("Provider failed", trace.WithAttributes(attribute.String("stt.provider", provider.Name())))
+		errs = append(errs, fmt.Errorf("%s: %w", provider.Name(), err))
+		// Nobody is waiting for the transcript any more
+		if ctx.Err() != nil {
+			break
+		}
+	}
+
+	if unsure != nil {
+		span.SetAttributes(attribute.Float64("stt.confidence", unsure.Confidence))
+		return *unsure, nil
+	}
+	if len(errs) == 0 {
+		errs = append(errs, errors.New("no STT providers configured"))
+	}
+	err := errors.Join(errs...)
+	span.RecordError(err)
+	return Transcription{}, err
+}
+
+func (f *FallbackSTT) attempt(ctx context.Context, provider STTProvider, request TranscriptionRequest, last bool) (Transcription, error) {
+	if !last && f.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, f.timeout)
+		defer cancel()
+	}
+	return provider.Transcribe(ctx, request)
+}
@@ -0,0 +1,79 @@
+package modelapi
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+type fakeSTT struct {
+	name       string
+	err        error
+	delay      time.Duration
+	confidence float64
+}
+
+func (f *fakeSTT) Name() string {
+	return f.name
+}
+
+func (f *fakeSTT) Transcribe(ctx context.Context, request TranscriptionRequest) (Transcription, error) {
+	select {
+	case <-time.After(f.delay):
+	case <-ctx.Done():
+		return Transcription{}, ctx.Err()
+	}
+	if f.err != nil {
+		return Transcription{}, f.err
+	}
+	return Transcription{Text: f.name, Confidence: f.confidence}, nil
+}
+
+func TestFallbackSTT(t *testing.T) {
+	ctx := context.Background()
+
+	down := &fakeSTT{name: "deepgram", err: errors.New("503")}
+	transcription, err := NewFallbackSTT(time.Second, 0.5, down, &fakeSTT{name: "groq"}).Transcribe(ctx, TranscriptionRequest{})
+	if err != nil || transcription.Text != "groq" {
+		t.Errorf("Transcribe = %q, %v; want groq after deepgram fails", transcription.Text, err)
+	}
+
+	slow := &fakeSTT{name: "deepgram", delay: time.Minute}
+	transcription, err = NewFallbackSTT(10*time.Millisecond, 0.5, slow, &fakeSTT{name: "groq"}).Transcribe(ctx, TranscriptionRequest{})
+	if err != nil || transcription.Text != "groq" {
+		t.Errorf("Transcribe = %q, %v; want groq after deepgram times out", transcription.Text, err)
+	}
+
+	// The last provider isn't cut off
+	transcription, err = NewFallbackSTT(10*time.Millisecond, 0.5, &fakeSTT{name: "groq", delay: 50 * time.Millisecond}).Transcribe(ctx, TranscriptionRequest{})
+	if err != nil || transcription.Text != "groq" {
+		t.Errorf("Transcribe = %q, %v; want the only provider to finish", transcription.Text, err)
+	}
+
+	if _, err := NewFallbackSTT(time.Second, 0.5, down).Transcribe(ctx, TranscriptionRequest{}); err == nil {
+		t.Error("Transcribe should fail when every provider does")
+	}
+}
+
+func TestFallbackSTTConfidence(t *testing.T) {
+	ctx := context.Background()
+
+	unsure := &fakeSTT{name: "deepgram", confidence: 0.3}
+	transcription, err := NewFallbackSTT(time.Second, 0.5, unsure, &fakeSTT{name: "groq", confidence: 0.8}).Transcribe(ctx, TranscriptionRequest{})
+	if err != nil || transcription.Text != "groq" {
+		t.Errorf("Transcribe = %q, %v; want groq after deepgram is unsure", transcription.Text, err)
+	}
+
+	// Nobody is sure, so the best guess is returned for the caller to judge
+	transcription, err = NewFallbackSTT(time.Second, 0.5, unsure, &fakeSTT{name: "groq", confidence: 0.2}, &fakeSTT{name: "gemini", err: errors.New("503")}).Transcribe(ctx, TranscriptionRequest{})
+	if err != nil || transcription.Text != "deepgram" || !transcription.Unsure(0.5) {
+		t.Errorf("Transcribe = %q (%g), %v; want deepgram's unsure transcript", transcription.Text, transcription.Confidence, err)
+	}
+
+	// A provider that doesn't report confidence is taken at its word
+	transcription, err = NewFallbackSTT(time.Second, 0.5, unsure, &fakeSTT{name: "gemini"}).Transcribe(ctx, TranscriptionRequest{})
+	if err != nil || transcription.Text != "gemini" {
+		t.Errorf("Transcribe = %q, %v; want gemini", transcription.Text, err)
+	}
+}
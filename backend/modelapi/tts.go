@@ -0,0 +1,114 @@
+package modelapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// SpeechRequest is a line of speech to synthesize.
+type SpeechRequest struct {
+	Text string
+	// Voice is the provider's own voice identifier; empty uses its default
+	Voice string
+	// Style is a note on how to deliver the line, for providers that take one
+	Style string
+	// Language is a language code, for providers that take one
+	Language string
+	// Emotion is one of the Emotion constants, which each provider maps onto
+	// its own controls; empty is her usual delivery
+	Emotion string
+	// Rate is how fast she speaks, 1 being her usual pace; zero also means 1
+	Rate float64
+	// Pitch shifts her voice up or down, in semitones
+	Pitch float64
+	// Temperature is the sampling temperature, for providers whose speech
+	// is sampled like Gemini's; 0 leaves the provider's default
+	Temperature float32
+}
+
+// Emotions a SpeechRequest can ask for.
+const (
+	EmotionSeductive = "seductive"
+	EmotionSleepy    = "sleepy"
+	EmotionAnnoyed   = "annoyed"
+	EmotionExcited   = "excited"
+	// Breathless is out of breath with feeling, like she ran to the phone
+	EmotionBreathless = "breathless"
+)
+
+// Speech is synthesized audio with a file name matching its format.
+type Speech struct {
+	Audio    []byte
+	FileName string
+	// Provider names whoever produced the audio
+	Provider string
+	// RateApplied and PitchApplied are set by providers that honored the
+	// request's Rate and Pitch themselves; the rest is left to post-processing
+	RateApplied  bool
+	PitchApplied bool
+}
+
+// TTSProvider synthesizes speech with one text-to-speech service.
+type TTSProvider interface {
+	Name() string
+	Synthesize(ctx context.Context, request SpeechRequest) (Speech, error)
+}
+
+// FallbackTTS tries its providers in order until one returns audio. A voice
+// belongs to a single provider, so every provider after the first speaks in
+// its own default voice.
+type FallbackTTS struct {
+	providers []TTSProvider
+}
+
+func NewFallbackTTS(providers ...TTSProvider) *FallbackTTS {
+	return &FallbackTTS{providers: providers}
+}
+
+func (f *FallbackTTS) Name() string {
+	return "fallback"
+}
+
+func (f *FallbackTTS) Synthesize(ctx context.Context, request SpeechRequest) (Speech, error) {
+	tracer := otel.Tracer("modelapi/FallbackTTS")
+	ctx, span := tracer.Start(ctx, "Synthesize")
+	defer span.End()
+
+	var errs []error
+	for i, provider := range f.providers {
+		if i > 0 {
+			request.Voice = ""
+		}
+		speech, err := provider.Synthesize(ctx, request)
+		if err == nil && len(speech.Audio) == 0 {
+			err = errors.New("no audio returned")
+		}
+		if err == nil {
+			speech.Provider = provider.Name()
+			span.SetAttributes(
+				attribute.String("tts.provider", speech.Provider),
+				attribute.Int("tts.fallbacks", i),
+			)
+			return speech, nil
+		}
+
+		span.AddEvent("Provider failed", trace.WithAttributes(attribute.String("tts.provider", provider.Name())))
+		errs = append(errs, fmt.Errorf("%s: %w", provider.Name(), err))
+		// Nobody is waiting for the audio any more
+		if ctx.Err() != nil {
+			break
+		}
+	}
+
+	if len(errs) == 0 {
+		errs = append(errs, errors.New("no TTS providers configured"))
+	}
+	err := errors.Join(errs...)
+	span.RecordError(err)
+	return Speech{}, err
+}
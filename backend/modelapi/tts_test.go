@@ -0,0 +1,56 @@
+package modelapi
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+type fakeTTS struct {
+	name  string
+	err   error
+	voice string
+}
+
+func (f *fakeTTS) Name() string {
+	return f.name
+}
+
+func (f *fakeTTS) Synthesize(ctx context.Context, request SpeechRequest) (Speech, error) {
+	f.voice = request.Voice
+	if f.err != nil {
+		return Speech{}, f.err
+	}
+	return Speech{Audio: []byte(request.Text), FileName: f.name + ".wav"}, nil
+}
+
+func TestFallbackTTS(t *testing.T) {
+	down := &fakeTTS{name: "gemini", err: errors.New("503")}
+	up := &fakeTTS{name: "openai"}
+
+	speech, err := NewFallbackTTS(down, up).Synthesize(context.Background(), SpeechRequest{Text: "hi", Voice: "Kore"})
+	if err != nil {
+		t.Fatalf("Synthesize: %v", err)
+	}
+	if speech.Provider != "openai" || speech.FileName != "openai.wav" || string(speech.Audio) != "hi" {
+		t.Errorf("got %+v, want the openai audio", speech)
+	}
+	if down.voice != "Kore" || up.voice != "" {
+		t.Errorf("voices = %q, %q; only the first provider should get the requested voice", down.voice, up.voice)
+	}
+}
+
+func TestFallbackTTSAllFail(t *testing.T) {
+	_, err := NewFallbackTTS(
+		&fakeTTS{name: "gemini", err: errors.New("503")},
+		&fakeTTS{name: "openai", err: errors.New("429")},
+	).Synthesize(context.Background(), SpeechRequest{Text: "hi"})
+	if err == nil || !strings.Contains(err.Error(), "gemini: 503") || !strings.Contains(err.Error(), "openai: 429") {
+		t.Errorf("err = %v, want both failures", err)
+	}
+
+	if _, err := NewFallbackTTS().Synthesize(context.Background(), SpeechRequest{Text: "hi"}); err == nil {
+		t.Error("an empty chain should fail")
+	}
+}
@@ -0,0 +1,142 @@
+package modelapi
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sync"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// AudioCache keeps synthesized speech in memory, evicting the least recently
+// used audio once it holds more than maxBytes.
+type AudioCache struct {
+	maxBytes int
+	lookups  metric.Int64Counter
+
+	mu      sync.Mutex
+	bytes   int
+	order   *list.List
+	entries map[string]*list.Element
+}
+
+type audioCacheEntry struct {
+	key    string
+	speech Speech
+}
+
+func NewAudioCache(maxBytes int) *AudioCache {
+	c := &AudioCache{
+		maxBytes: maxBytes,
+		order:    list.New(),
+		entries:  map[string]*list.Element{},
+	}
+
+	lookups, err := otel.Meter("modelapi").Int64Counter("modelapi.tts.cache_lookups",
+		metric.WithDescription("TTS cache lookups by provider and result"),
+	)
+	if err != nil {
+		otel.Handle(err)
+	}
+	c.lookups = lookups
+	return c
+}
+
+// audioCacheKey identifies a line of speech. Everything that changes the
+// audio is part of it, so a different mood or language is a miss.
+func audioCacheKey(provider string, request SpeechRequest) string {
+	h := sha256.New()
+	for _, part := range []string{request.Text, request.Voice, request.Style, request.Language, request.Emotion} {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	fmt.Fprintf(h, "%g:%g:%g", request.Rate, request.Pitch, request.Temperature)
+	return provider + ":" + hex.EncodeToString(h.Sum(nil))
+}
+
+func (c *AudioCache) get(key string) (Speech, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	element, ok := c.entries[key]
+	if !ok {
+		return Speech{}, false
+	}
+	c.order.MoveToFront(element)
+	return element.Value.(*audioCacheEntry).speech, true
+}
+
+func (c *AudioCache) put(key string, speech Speech) {
+	// Audio bigger than the whole cache would only evict everything else
+	if len(speech.Audio) > c.maxBytes {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if element, ok := c.entries[key]; ok {
+		c.bytes -= len(element.Value.(*audioCacheEntry).speech.Audio)
+		element.Value = &audioCacheEntry{key: key, speech: speech}
+		c.order.MoveToFront(element)
+	} else {
+		c.entries[key] = c.order.PushFront(&audioCacheEntry{key: key, speech: speech})
+	}
+	c.bytes += len(speech.Audio)
+
+	for c.bytes > c.maxBytes {
+		oldest := c.order.Back()
+		entry := oldest.Value.(*audioCacheEntry)
+		c.order.Remove(oldest)
+		delete(c.entries, entry.key)
+		c.bytes -= len(entry.speech.Audio)
+	}
+}
+
+func (c *AudioCache) record(ctx context.Context, provider string, hit bool) {
+	if c.lookups == nil {
+		return
+	}
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+	c.lookups.Add(ctx, 1, metric.WithAttributes(
+		attribute.String("provider", provider),
+		attribute.String("result", result),
+	))
+}
+
+// CacheTTS serves speech provider has already synthesized from cache. Hits
+// don't reach the provider at all, so they also get through an open breaker
+// and record no usage. The cached audio is shared and must not be modified.
+func CacheTTS(provider TTSProvider, cache *AudioCache) TTSProvider {
+	return &cachedTTS{provider: provider, cache: cache}
+}
+
+type cachedTTS struct {
+	provider TTSProvider
+	cache    *AudioCache
+}
+
+func (c *cachedTTS) Name() string {
+	return c.provider.Name()
+}
+
+func (c *cachedTTS) Synthesize(ctx context.Context, request SpeechRequest) (Speech, error) {
+	key := audioCacheKey(c.provider.Name(), request)
+	if speech, ok := c.cache.get(key); ok {
+		c.cache.record(ctx, c.provider.Name(), true)
+		return speech, nil
+	}
+	c.cache.record(ctx, c.provider.Name(), false)
+
+	speech, err := c.provider.Synthesize(ctx, request)
+	if err == nil && len(speech.Audio) > 0 {
+		c.cache.put(key, speech)
+	}
+	return speech, err
+}
@@ -0,0 +1,78 @@
+package modelapi
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+type countingTTS struct {
+	calls int
+	err   error
+}
+
+func (c *countingTTS) Name() string {
+	return "openai"
+}
+
+func (c *countingTTS) Synthesize(ctx context.Context, request SpeechRequest) (Speech, error) {
+	c.calls++
+	if c.err != nil {
+		return Speech{}, c.err
+	}
+	return Speech{Audio: []byte(request.Text), FileName: "speech.mp3"}, nil
+}
+
+func TestCacheTTS(t *testing.T) {
+	provider := &countingTTS{}
+	cached := CacheTTS(provider, NewAudioCache(1024))
+	ctx := context.Background()
+
+	request := SpeechRequest{Text: "hello baby", Voice: "sage"}
+	for i := 0; i < 2; i++ {
+		speech, err := cached.Synthesize(ctx, request)
+		if err != nil || string(speech.Audio) != "hello baby" || speech.FileName != "speech.mp3" {
+			t.Fatalf("Synthesize = %+v, %v", speech, err)
+		}
+	}
+	if provider.calls != 1 {
+		t.Errorf("calls = %d, want the repeat served from cache", provider.calls)
+	}
+
+	// Another voice or mood is different audio
+	cached.Synthesize(ctx, SpeechRequest{Text: "hello baby", Voice: "coral"})
+	cached.Synthesize(ctx, SpeechRequest{Text: "hello baby", Voice: "sage", Style: "sleepy"})
+	cached.Synthesize(ctx, SpeechRequest{Text: "hello baby", Voice: "sage", Emotion: EmotionSleepy})
+	cached.Synthesize(ctx, SpeechRequest{Text: "hello baby", Voice: "sage", Rate: 0.85})
+	cached.Synthesize(ctx, SpeechRequest{Text: "hello baby", Voice: "sage", Temperature: 0.6})
+	if provider.calls != 6 {
+		t.Errorf("calls = %d, want misses for a new voice, style, emotion, rate and temperature", provider.calls)
+	}
+
+	failing := &countingTTS{err: errors.New("503")}
+	cached = CacheTTS(failing, NewAudioCache(1024))
+	cached.Synthesize(ctx, request)
+	cached.Synthesize(ctx, request)
+	if failing.calls != 2 {
+		t.Errorf("calls = %d, want failures left out of the cache", failing.calls)
+	}
+}
+
+func TestAudioCacheEviction(t *testing.T) {
+	cache := NewAudioCache(10)
+	cache.put("a", Speech{Audio: make([]byte, 4)})
+	cache.put("b", Speech{Audio: make([]byte, 4)})
+	cache.get("a")
+	cache.put("c", Speech{Audio: make([]byte, 4)})
+
+	if _, ok := cache.get("b"); ok {
+		t.Error("the least recently used audio should be evicted")
+	}
+	if _, ok := cache.get("a"); !ok {
+		t.Error("recently used audio should be kept")
+	}
+	cache.put("huge", Speech{Audio: make([]byte, 11)})
+	if _, ok := cache.get("huge"); ok || cache.bytes != 8 {
+		t.Errorf("audio bigger than the cache should be skipped, bytes = %d", cache.bytes)
+	}
+}
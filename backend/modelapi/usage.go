@@ -0,0 +1,87 @@
+package modelapi
+
+import (
+	"context"
+	"math"
+)
+
+const (
+	UsageKindChat      = "chat"
+	UsageKindTTS       = "tts"
+	UsageKindEmbedding = "embedding"
+	UsageKindImage     = "image"
+)
+
+// Usage is what one LLM, TTS, embedding or image call consumed.
+type Usage struct {
+	Provider     string
+	Kind         string
+	Model        string
+	InputTokens  int
+	OutputTokens int
+	// Characters is the text synthesized, for TTS priced by character
+	Characters int
+	// Images is how many pictures were generated, for models priced by image
+	Images int
+	// ReportedCost is what the provider says it charged in US dollars, for
+	// providers like OpenRouter whose prices change too often to list here
+	ReportedCost float64
+}
+
+// modelPrice is a model's list price in US dollars per million units.
+type modelPrice struct {
+	Input      float64
+	Output     float64
+	Characters float64
+	Images     float64
+}
+
+// modelPrices are list prices at the time of writing, so costs are estimates.
+// Models missing here are recorded at no cost.
+var modelPrices = map[string]modelPrice{
+	"moonshotai/kimi-k2-instruct":               {Input: 1.00, Output: 3.00},
+	"meta-llama/llama-4-scout-17b-16e-instruct": {Input: 0.11, Output: 0.34},
+	"gemini-2.5-flash":                          {Input: 0.30, Output: 2.50},
+	"gemini-2.5-flash-preview-tts":              {Input: 0.50, Output: 10.00},
+	"gpt-4o-mini-tts":                           {Characters: 15.00},
+	"sonic-2":                                   {Characters: 50.00},
+	"hexgrad/Kokoro-82M":                        {Characters: 0.80},
+	"azure-neural":                              {Characters: 15.00},
+	"text-embedding-3-small":                    {Input: 0.02},
+	"BAAI/bge-m3":                               {Input: 0.01},
+	"black-forest-labs/FLUX-1-schnell":          {Images: 500.00},
+}
+
+// CostMicros is the cost of usage in millionths of a US dollar, estimated
+// from list prices unless the provider reported it.
+func (u Usage) CostMicros() int64 {
+	if u.ReportedCost > 0 {
+		return int64(math.Round(u.ReportedCost * 1e6))
+	}
+	price := modelPrices[u.Model]
+	// Prices per million units come out in micro-dollars per unit
+	cost := float64(u.InputTokens)*price.Input +
+		float64(u.OutputTokens)*price.Output +
+		float64(u.Characters)*price.Characters +
+		float64(u.Images)*price.Images
+	return int64(math.Round(cost))
+}
+
+// UsageRecorder stores usage for whoever the call was made for.
+type UsageRecorder func(ctx context.Context, usage Usage)
+
+type usageRecorderKey struct{}
+
+// WithUsageRecorder has every call made with the returned context report
+// its usage to record.
+func WithUsageRecorder(ctx context.Context, record UsageRecorder) context.Context {
+	return context.WithValue(ctx, usageRecorderKey{}, record)
+}
+
+// RecordUsage reports usage to the context's recorder, if it has one.
+// Providers call it after every successful request.
+func RecordUsage(ctx context.Context, usage Usage) {
+	if record, ok := ctx.Value(usageRecorderKey{}).(UsageRecorder); ok {
+		record(ctx, usage)
+	}
+}
@@ -0,0 +1,38 @@
+package modelapi
+
+import (
+	"context"
+	"testing"
+)
+
+func TestUsageCostMicros(t *testing.T) {
+	tests := []struct {
+		usage Usage
+		want  int64
+	}{
+		{Usage{Model: "moonshotai/kimi-k2-instruct", InputTokens: 1000, OutputTokens: 200}, 1600},
+		{Usage{Model: "gpt-4o-mini-tts", Characters: 100}, 1500},
+		{Usage{Model: "black-forest-labs/FLUX-1-schnell", Images: 1}, 500},
+		{Usage{Model: "unknown", InputTokens: 1000}, 0},
+		{Usage{Model: "moonshotai/kimi-k2-instruct", InputTokens: 1000, ReportedCost: 0.0025}, 2500},
+	}
+	for _, tt := range tests {
+		if got := tt.usage.CostMicros(); got != tt.want {
+			t.Errorf("CostMicros(%+v) = %d, want %d", tt.usage, got, tt.want)
+		}
+	}
+}
+
+func TestRecordUsage(t *testing.T) {
+	// Nothing to record to is fine
+	RecordUsage(context.Background(), Usage{Provider: "groq"})
+
+	var recorded []Usage
+	ctx := WithUsageRecorder(context.Background(), func(ctx context.Context, usage Usage) {
+		recorded = append(recorded, usage)
+	})
+	RecordUsage(ctx, Usage{Provider: "groq", InputTokens: 10})
+	if len(recorded) != 1 || recorded[0].Provider != "groq" || recorded[0].InputTokens != 10 {
+		t.Errorf("recorded = %+v", recorded)
+	}
+}
@@ -0,0 +1,147 @@
+// Package retry calls flaky provider APIs again with exponential backoff and
+// full jitter, within an overall time budget.
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gulabodev/httpmiddleware"
+	"math/rand/v2"
+	"net/http"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Policy is how hard to try.
+type Policy struct {
+	// Attempts is the most times the call is made, counting the first
+	Attempts int
+	// BaseDelay caps the wait before the first retry. The cap doubles for
+	// each retry after, up to MaxDelay, and each wait is a random time below
+	// it so clients that failed together don't all retry together
+	BaseDelay time.Duration
+	// MaxDelay caps every wait; zero leaves the cap to keep doubling
+	MaxDelay time.Duration
+	// Budget is the most time all attempts and waits take together; zero
+	// leaves it to ctx
+	Budget time.Duration
+	// OnRetry, if set, is told about each failure that's about to be retried
+	OnRetry func(attempt int, err error, delay time.Duration)
+}
+
+type permanentError struct {
+	err error
+}
+
+func (p *permanentError) Error() string {
+	return p.err.Error()
+}
+
+func (p *permanentError) Unwrap() error {
+	return p.err
+}
+
+// Permanent marks err as one that trying again won't fix, like a bad request.
+// Do returns it right away, unwrapped.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// Retryable reports whether err is worth trying again. Errors marked
+// Permanent and cancelled or expired contexts aren't, and neither are HTTP
+// responses other than timeouts, rate limits and server errors. Anything
+// else, like a dropped connection or an empty response, is.
+func Retryable(err error) bool {
+	if err == nil {
+		return false
+	}
+	var permanent *permanentError
+	if errors.As(err, &permanent) || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var status *httpmiddleware.StatusError
+	if errors.As(err, &status) {
+		return RetryableStatus(status.StatusCode)
+	}
+	return true
+}
+
+// RetryableStatus reports whether a response with the HTTP status code is
+// worth trying again, for providers whose SDKs have their own error types.
+func RetryableStatus(code int) bool {
+	return code == http.StatusRequestTimeout || code == http.StatusTooManyRequests || code >= http.StatusInternalServerError
+}
+
+// Do calls fn until it succeeds, returns an error that isn't Retryable, or
+// policy's attempts or budget run out. A wait that would go past the budget or
+// ctx's deadline isn't started; the last error is returned instead.
+func Do[T any](ctx context.Context, policy Policy, fn func(ctx context.Context) (T, error)) (T, error) {
+	if policy.Budget > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, policy.Budget)
+		defer cancel()
+	}
+	attempts := max(policy.Attempts, 1)
+
+	var zero T
+	var err error
+	for attempt := 0; attempt < attempts; attempt++ {
+		var value T
+		value, err = fn(ctx)
+		if err == nil {
+			return value, nil
+		}
+
+		var permanent *permanentError
+		if errors.As(err, &permanent) {
+			return zero, permanent.err
+		}
+		if !Retryable(err) || attempt == attempts-1 {
+			break
+		}
+
+		delay := policy.delay(attempt)
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+			break
+		}
+		trace.SpanFromContext(ctx).AddEvent("Retrying", trace.WithAttributes(
+			attribute.Int("attempt", attempt+1),
+			attribute.Int64("delayMs", delay.Milliseconds()),
+			attribute.String("error", err.Error()),
+		))
+		if policy.OnRetry != nil {
+			policy.OnRetry(attempt+1, err, delay)
+		}
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return zero, ctx.Err()
+		}
+	}
+	if !Retryable(err) {
+		return zero, err
+	}
+	return zero, fmt.Errorf("gave up retrying: %w", err)
+}
+
+// delay picks how long to wait before retrying after the given attempt, which
+// counts from 0.
+func (p Policy) delay(attempt int) time.Duration {
+	ceiling := p.BaseDelay
+	for i := 0; i < attempt && (p.MaxDelay <= 0 || ceiling < p.MaxDelay); i++ {
+		ceiling *= 2
+	}
+	if p.MaxDelay > 0 {
+		ceiling = min(ceiling, p.MaxDelay)
+	}
+	if ceiling <= 0 {
+		return 0
+	}
+	return rand.N(ceiling)
+}
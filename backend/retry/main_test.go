@@ -0,0 +1,107 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"gulabodev/httpmiddleware"
+	"testing"
+	"time"
+)
+
+func TestDo(t *testing.T) {
+	ctx := context.Background()
+	policy := Policy{Attempts: 3, BaseDelay: time.Millisecond}
+	flaky := errors.New("connection reset")
+
+	calls := 0
+	value, err := Do(ctx, policy, func(ctx context.Context) (string, error) {
+		calls++
+		if calls < 3 {
+			return "", flaky
+		}
+		return "ok", nil
+	})
+	if err != nil || value != "ok" || calls != 3 {
+		t.Errorf("Do = %q, %v after %d calls, want ok on the third", value, err, calls)
+	}
+
+	calls = 0
+	_, err = Do(ctx, policy, func(ctx context.Context) (string, error) {
+		calls++
+		return "", flaky
+	})
+	if !errors.Is(err, flaky) || calls != 3 {
+		t.Errorf("Do = %v after %d calls, want the last error after 3", err, calls)
+	}
+
+	calls = 0
+	badRequest := &httpmiddleware.StatusError{StatusCode: 400}
+	_, err = Do(ctx, policy, func(ctx context.Context) (string, error) {
+		calls++
+		return "", badRequest
+	})
+	if err != badRequest || calls != 1 {
+		t.Errorf("Do = %v after %d calls, want a 400 returned as is after 1", err, calls)
+	}
+
+	calls = 0
+	_, err = Do(ctx, policy, func(ctx context.Context) (string, error) {
+		calls++
+		return "", Permanent(flaky)
+	})
+	if err != flaky || calls != 1 {
+		t.Errorf("Do = %v after %d calls, want the unwrapped permanent error after 1", err, calls)
+	}
+}
+
+func TestDoBudget(t *testing.T) {
+	// The second wait could run past the budget, so it's never started
+	policy := Policy{Attempts: 10, BaseDelay: time.Hour, Budget: 50 * time.Millisecond}
+	calls := 0
+	start := time.Now()
+	_, err := Do(context.Background(), policy, func(ctx context.Context) (int, error) {
+		calls++
+		return 0, &httpmiddleware.StatusError{StatusCode: 503}
+	})
+	if err == nil || time.Since(start) > time.Second {
+		t.Errorf("Do = %v after %v, want a quick failure", err, time.Since(start))
+	}
+	if calls > 2 {
+		t.Errorf("Do made %d calls within the budget", calls)
+	}
+}
+
+func TestRetryable(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{nil, false},
+		{errors.New("EOF"), true},
+		{&httpmiddleware.StatusError{StatusCode: 429}, true},
+		{&httpmiddleware.StatusError{StatusCode: 502}, true},
+		{&httpmiddleware.StatusError{StatusCode: 401}, false},
+		{context.Canceled, false},
+		{Permanent(errors.New("bad voice")), false},
+	}
+	for _, tt := range tests {
+		if got := Retryable(tt.err); got != tt.want {
+			t.Errorf("Retryable(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestDelay(t *testing.T) {
+	policy := Policy{BaseDelay: time.Second, MaxDelay: 5 * time.Second}
+	ceilings := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second}
+	for attempt, ceiling := range ceilings {
+		for range 100 {
+			if delay := policy.delay(attempt); delay < 0 || delay >= ceiling {
+				t.Fatalf("delay(%d) = %v, want below %v", attempt, delay, ceiling)
+			}
+		}
+	}
+	if delay := (Policy{}).delay(3); delay != 0 {
+		t.Errorf("delay with no base = %v, want 0", delay)
+	}
+}
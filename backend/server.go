@@ -4,12 +4,17 @@ import (
 	"context"
 	"gulabodev/database/postgres"
 	"gulabodev/logger"
+	"gulabodev/modelapi/azureapi"
 	"gulabodev/modelapi/cartesiaapi"
 	"gulabodev/modelapi/deepgramapi"
 	"gulabodev/modelapi/deepinfraapi"
 	"gulabodev/modelapi/geminiapi"
 	"gulabodev/modelapi/groqapi"
+	"gulabodev/modelapi/ollamaapi"
 	"gulabodev/modelapi/openaiapi"
+	"gulabodev/modelapi/openrouterapi"
+	"gulabodev/modelapi/prompts"
+	"gulabodev/stripeapi"
 	"gulabodev/telegram"
 	"log"
 	"net/http"
@@ -46,7 +51,15 @@ func main() {
 
 	LogMiddleware := logger.Connect(logger.LoggerConnectProps{Production: false, LoggerProvider: loggerProvider})
 
-	db := postgres.Connect(ctx, postgres.DatabaseConnectProps{Logger: LogMiddleware})
+	// Prompt overrides and pinned versions are read before anything renders a prompt
+	prompts.Configure(ctx, LogMiddleware)
+	telegram.LoadPersonas(ctx, LogMiddleware)
+
+	// Each bot's records live in their own schema of the shared database
+	botConfigs := telegram.LoadBotConfigs(ctx, LogMiddleware)
+	for i := range botConfigs {
+		botConfigs[i].DB = postgres.Connect(ctx, postgres.DatabaseConnectProps{Logger: LogMiddleware, Schema: botConfigs[i].Schema()})
+	}
 	geminiClient := geminiapi.Connect(ctx, geminiapi.GeminiConnectProps{Logger: LogMiddleware})
 
 	// Connect and start Telegram bot
@@ -55,15 +68,27 @@ func main() {
 	deepgramClient := deepgramapi.Connect(LogMiddleware)
 	deepinfraClient := deepinfraapi.Connect(ctx, deepinfraapi.DeepInfraConnectProps{Logger: LogMiddleware})
 	openaiClient := openaiapi.Connect(ctx, openaiapi.OpenAIConnectProps{Logger: LogMiddleware})
+	azureClient := azureapi.Connect(ctx, azureapi.AzureConnectProps{Logger: LogMiddleware})
+	// Development runs against a local model when one is configured
+	var ollamaClient *ollamaapi.Ollama
+	if !production {
+		ollamaClient = ollamaapi.Connect(ctx, ollamaapi.OllamaConnectProps{Logger: LogMiddleware})
+	}
+	openrouterClient := openrouterapi.Connect(ctx, openrouterapi.OpenRouterConnectProps{Logger: LogMiddleware})
+	stripeClient := stripeapi.Connect(ctx, stripeapi.StripeConnectProps{Logger: LogMiddleware})
 	telegramBot := telegram.Connect(ctx, telegram.TelegramConnectProps{
-		Logger:    LogMiddleware,
-		Groq:      groqClient,
-		Cartesia:  cartesiaClient,
-		Gemini:    geminiClient,
-		Deepgram:  deepgramClient,
-		DB:        db,
-		DeepInfra: deepinfraClient,
-		OpenAI:    openaiClient,
+		Logger:     LogMiddleware,
+		Groq:       groqClient,
+		Cartesia:   cartesiaClient,
+		Gemini:     geminiClient,
+		Deepgram:   deepgramClient,
+		DeepInfra:  deepinfraClient,
+		OpenAI:     openaiClient,
+		Azure:      azureClient,
+		Ollama:     ollamaClient,
+		OpenRouter: openrouterClient,
+		Stripe:     stripeClient,
+		Bots:       botConfigs,
 	})
 
 	Logger := LogMiddleware.Logger(ctx)
@@ -74,7 +99,19 @@ func main() {
 		Logger.Info("[Telegram] Bot starting in production mode")
 	}
 
-	// Start Telegram bot (blocking call)
+	// Webhook endpoints for external payment providers
+	mux := http.NewServeMux()
+	mux.Handle("/stripe/webhook", telegramBot.StripeWebhookHandler())
+	go func() {
+		Logger.Info("[HTTP] Server listening", zap.String("port", port))
+		if err := http.ListenAndServe(":"+port, requestLoggerMiddleware(LogMiddleware)(mux)); err != nil {
+			Logger.Error("[HTTP] Server stopped", zap.Error(err))
+		}
+	}()
+
+	telegramBot.RunBackgroundJobs(ctx)
+
+	// Start Telegram bots (blocking call)
 	telegramBot.Listen(ctx)
 }
 
@@ -0,0 +1,310 @@
+package stripeapi
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"gulabodev/httpmiddleware"
+	"gulabodev/logger"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+)
+
+const (
+	EventCheckoutSessionCompleted = "checkout.session.completed"
+	EventPaymentIntentSucceeded   = "payment_intent.succeeded"
+
+	// Reject webhook events signed longer ago than this, to limit replays.
+	signatureTolerance = 5 * time.Minute
+)
+
+type StripeConnectProps struct {
+	Logger *logger.LogMiddleware
+}
+
+type Stripe struct {
+	logger        *logger.LogMiddleware
+	secretKey     string
+	webhookSecret string
+	successURL    string
+	cancelURL     string
+}
+
+type CheckoutSession struct {
+	ID                string            `json:"id"`
+	URL               string            `json:"url"`
+	PaymentStatus     string            `json:"payment_status"`
+	AmountTotal       int64             `json:"amount_total"`
+	Currency          string            `json:"currency"`
+	ClientReferenceID string            `json:"client_reference_id"`
+	Metadata          map[string]string `json:"metadata"`
+	// Customer and PaymentIntent are IDs, set when the card was saved
+	Customer      string `json:"customer"`
+	PaymentIntent string `json:"payment_intent"`
+}
+
+type PaymentIntent struct {
+	ID            string            `json:"id"`
+	Status        string            `json:"status"`
+	Amount        int64             `json:"amount"`
+	Currency      string            `json:"currency"`
+	PaymentMethod string            `json:"payment_method"`
+	Metadata      map[string]string `json:"metadata"`
+}
+
+type Event struct {
+	ID   string `json:"id"`
+	Type string `json:"type"`
+	Data struct {
+		Object json.RawMessage `json:"object"`
+	} `json:"data"`
+}
+
+func Connect(ctx context.Context, args StripeConnectProps) *Stripe {
+	tracer := otel.Tracer("stripeapi/Connect")
+	ctx, span := tracer.Start(ctx, "Connect")
+	defer span.End()
+
+	stripe := &Stripe{
+		logger:        args.Logger,
+		secretKey:     os.Getenv("STRIPE_SECRET_KEY"),
+		webhookSecret: os.Getenv("STRIPE_WEBHOOK_SECRET"),
+		successURL:    os.Getenv("STRIPE_SUCCESS_URL"),
+		cancelURL:     os.Getenv("STRIPE_CANCEL_URL"),
+	}
+
+	span.SetAttributes(attribute.Bool("enabled", stripe.Enabled()))
+	if !stripe.Enabled() {
+		args.Logger.Logger(ctx).Info("[StripeAPI] STRIPE_SECRET_KEY not set, card payments disabled")
+	}
+
+	return stripe
+}
+
+// Enabled reports whether card payments are configured.
+func (s *Stripe) Enabled() bool {
+	return s.secretKey != "" && s.webhookSecret != ""
+}
+
+type CreateCheckoutSessionProps struct {
+	ProductName string
+	// AmountCents is the price in the smallest currency unit.
+	AmountCents int
+	Currency    string
+	// ClientReferenceID ties the session back to the Telegram user.
+	ClientReferenceID string
+	Metadata          map[string]string
+	// SaveCard keeps the card on a new customer for later off-session charges.
+	SaveCard bool
+}
+
+func (s *Stripe) CreateCheckoutSession(ctx context.Context, args CreateCheckoutSessionProps) (*CheckoutSession, error) {
+	tracer := otel.Tracer("stripeapi/CreateCheckoutSession")
+	ctx, span := tracer.Start(ctx, "CreateCheckoutSession")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("product_name", args.ProductName),
+		attribute.Int("amount_cents", args.AmountCents),
+	)
+
+	form := url.Values{}
+	form.Set("mode", "payment")
+	form.Set("success_url", s.successURL)
+	form.Set("cancel_url", s.cancelURL)
+	form.Set("client_reference_id", args.ClientReferenceID)
+	form.Set("line_items[0][quantity]", "1")
+	form.Set("line_items[0][price_data][currency]", args.Currency)
+	form.Set("line_items[0][price_data][unit_amount]", strconv.Itoa(args.AmountCents))
+	form.Set("line_items[0][price_data][product_data][name]", args.ProductName)
+	for key, value := range args.Metadata {
+		form.Set(fmt.Sprintf("metadata[%s]", key), value)
+	}
+	if args.SaveCard {
+		form.Set("customer_creation", "always")
+		form.Set("payment_intent_data[setup_future_usage]", "off_session")
+	}
+
+	respBody, err := httpmiddleware.HttpRequest(httpmiddleware.HttpRequestStruct{
+		Method: "POST",
+		Url:    "https://api.stripe.com/v1/checkout/sessions",
+		Body:   strings.NewReader(form.Encode()),
+		Headers: map[string]string{
+			"Authorization": "Bearer " + s.secretKey,
+			"Content-Type":  "application/x-www-form-urlencoded",
+		},
+	})
+	if err != nil {
+		span.RecordError(err)
+		s.logger.Logger(ctx).Error("[StripeAPI] Could not create checkout session", zap.Error(err))
+		return nil, err
+	}
+
+	var session CheckoutSession
+	if err := json.Unmarshal(respBody, &session); err != nil {
+		span.RecordError(err)
+		return nil, fmt.Errorf("failed to parse checkout session: %w", err)
+	}
+
+	s.logger.Logger(ctx).Info("[StripeAPI] Created checkout session", zap.String("session_id", session.ID))
+	return &session, nil
+}
+
+// GetPaymentIntent fetches a payment intent, e.g. to find the card a
+// checkout saved.
+func (s *Stripe) GetPaymentIntent(ctx context.Context, id string) (*PaymentIntent, error) {
+	tracer := otel.Tracer("stripeapi/GetPaymentIntent")
+	ctx, span := tracer.Start(ctx, "GetPaymentIntent")
+	defer span.End()
+
+	respBody, err := httpmiddleware.HttpRequest(httpmiddleware.HttpRequestStruct{
+		Method: "GET",
+		Url:    "https://api.stripe.com/v1/payment_intents/" + url.PathEscape(id),
+		Headers: map[string]string{
+			"Authorization": "Bearer " + s.secretKey,
+		},
+	})
+	if err != nil {
+		span.RecordError(err)
+		s.logger.Logger(ctx).Error("[StripeAPI] Could not get payment intent", zap.Error(err), zap.String("payment_intent_id", id))
+		return nil, err
+	}
+
+	var intent PaymentIntent
+	if err := json.Unmarshal(respBody, &intent); err != nil {
+		span.RecordError(err)
+		return nil, fmt.Errorf("failed to parse payment intent: %w", err)
+	}
+	return &intent, nil
+}
+
+type ChargeSavedCardProps struct {
+	CustomerID      string
+	PaymentMethodID string
+	// AmountCents is the price in the smallest currency unit.
+	AmountCents int
+	Currency    string
+	Description string
+	Metadata    map[string]string
+	// IdempotencyKey makes Stripe return the first charge, instead of
+	// charging again, when the same attempt is sent twice.
+	IdempotencyKey string
+}
+
+// ChargeSavedCard charges a saved card without the customer present. A
+// declined card, or one that needs the customer to authenticate, is an error.
+func (s *Stripe) ChargeSavedCard(ctx context.Context, args ChargeSavedCardProps) (*PaymentIntent, error) {
+	tracer := otel.Tracer("stripeapi/ChargeSavedCard")
+	ctx, span := tracer.Start(ctx, "ChargeSavedCard")
+	defer span.End()
+
+	span.SetAttributes(attribute.Int("amount_cents", args.AmountCents))
+
+	form := url.Values{}
+	form.Set("amount", strconv.Itoa(args.AmountCents))
+	form.Set("currency", args.Currency)
+	form.Set("customer", args.CustomerID)
+	form.Set("payment_method", args.PaymentMethodID)
+	form.Set("description", args.Description)
+	form.Set("off_session", "true")
+	form.Set("confirm", "true")
+	for key, value := range args.Metadata {
+		form.Set(fmt.Sprintf("metadata[%s]", key), value)
+	}
+
+	headers := map[string]string{
+		"Authorization": "Bearer " + s.secretKey,
+		"Content-Type":  "application/x-www-form-urlencoded",
+	}
+	if args.IdempotencyKey != "" {
+		headers["Idempotency-Key"] = args.IdempotencyKey
+	}
+
+	respBody, err := httpmiddleware.HttpRequest(httpmiddleware.HttpRequestStruct{
+		Method:  "POST",
+		Url:     "https://api.stripe.com/v1/payment_intents",
+		Body:    strings.NewReader(form.Encode()),
+		Headers: headers,
+	})
+	if err != nil {
+		span.RecordError(err)
+		s.logger.Logger(ctx).Error("[StripeAPI] Could not charge saved card", zap.Error(err))
+		return nil, err
+	}
+
+	var intent PaymentIntent
+	if err := json.Unmarshal(respBody, &intent); err != nil {
+		span.RecordError(err)
+		return nil, fmt.Errorf("failed to parse payment intent: %w", err)
+	}
+	if intent.Status != "succeeded" {
+		return nil, fmt.Errorf("payment intent %s is %s", intent.ID, intent.Status)
+	}
+
+	s.logger.Logger(ctx).Info("[StripeAPI] Charged saved card", zap.String("payment_intent_id", intent.ID))
+	return &intent, nil
+}
+
+// ConstructEvent verifies the Stripe-Signature header against the raw request
+// body and decodes the event.
+func (s *Stripe) ConstructEvent(payload []byte, signatureHeader string) (*Event, error) {
+	var timestamp string
+	var signatures []string
+	for _, part := range strings.Split(signatureHeader, ",") {
+		key, value, found := strings.Cut(strings.TrimSpace(part), "=")
+		if !found {
+			continue
+		}
+		switch key {
+		case "t":
+			timestamp = value
+		case "v1":
+			signatures = append(signatures, value)
+		}
+	}
+	if timestamp == "" || len(signatures) == 0 {
+		return nil, fmt.Errorf("malformed Stripe-Signature header")
+	}
+
+	signedAt, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid signature timestamp: %w", err)
+	}
+	if time.Since(time.Unix(signedAt, 0)) > signatureTolerance {
+		return nil, fmt.Errorf("signature timestamp outside tolerance")
+	}
+
+	mac := hmac.New(sha256.New, []byte(s.webhookSecret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+	expected := mac.Sum(nil)
+
+	verified := false
+	for _, signature := range signatures {
+		decoded, err := hex.DecodeString(signature)
+		if err == nil && hmac.Equal(decoded, expected) {
+			verified = true
+			break
+		}
+	}
+	if !verified {
+		return nil, fmt.Errorf("no matching webhook signature")
+	}
+
+	var event Event
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return nil, fmt.Errorf("failed to parse event: %w", err)
+	}
+	return &event, nil
+}
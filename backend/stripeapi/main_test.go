@@ -0,0 +1,44 @@
+package stripeapi
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func sign(secret string, timestamp int64, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(fmt.Sprintf("%d.", timestamp)))
+	mac.Write(payload)
+	return fmt.Sprintf("t=%d,v1=%s", timestamp, hex.EncodeToString(mac.Sum(nil)))
+}
+
+func TestConstructEvent(t *testing.T) {
+	stripe := &Stripe{webhookSecret: "whsec_test"}
+	payload := []byte(`{"id":"evt_1","type":"checkout.session.completed","data":{"object":{"id":"cs_1"}}}`)
+	now := time.Now().Unix()
+
+	event, err := stripe.ConstructEvent(payload, sign("whsec_test", now, payload))
+	if err != nil {
+		t.Fatalf("ConstructEvent failed: %v", err)
+	}
+	if event.Type != EventCheckoutSessionCompleted {
+		t.Errorf("Expected event type %q, got %q", EventCheckoutSessionCompleted, event.Type)
+	}
+
+	if _, err := stripe.ConstructEvent(payload, sign("wrong_secret", now, payload)); err == nil {
+		t.Error("Expected error for signature with wrong secret")
+	}
+
+	stale := time.Now().Add(-time.Hour).Unix()
+	if _, err := stripe.ConstructEvent(payload, sign("whsec_test", stale, payload)); err == nil {
+		t.Error("Expected error for stale signature")
+	}
+
+	if _, err := stripe.ConstructEvent(payload, "garbage"); err == nil {
+		t.Error("Expected error for malformed header")
+	}
+}
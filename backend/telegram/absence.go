@@ -0,0 +1,54 @@
+package telegram
+
+import (
+	"fmt"
+	"gulabodev/modelapi/groqapi"
+	"time"
+)
+
+const (
+	// Gaps shorter than this go unremarked
+	absenceNoticeAfter = 6 * time.Hour
+	// From this long on, she reacts to the gap rather than just knowing it
+	absenceReactAfter = 24 * time.Hour
+)
+
+// lastUserMessageTime returns when the user last wrote, if the history
+// recorded it.
+func lastUserMessageTime(messages []storedMessage) (time.Time, bool) {
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Role == groqapi.USER && messages[i].Timestamp != nil {
+			return *messages[i].Timestamp, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// describeAbsence puts a gap in the words she'd use, rounded down.
+func describeAbsence(away time.Duration) string {
+	hours := int(away.Hours())
+	days := hours / 24
+	switch {
+	case days >= 14:
+		return fmt.Sprintf("%d weeks", days/7)
+	case days >= 2:
+		return fmt.Sprintf("%d days", days)
+	case days == 1:
+		return "a day"
+	default:
+		return fmt.Sprintf("%d hours", hours)
+	}
+}
+
+// absencePrompt tells her how long the user was away, so a conversation
+// picked up after days doesn't carry on as if no time had passed.
+func absencePrompt(away time.Duration) string {
+	switch {
+	case away < absenceNoticeAfter:
+		return ""
+	case away < absenceReactAfter:
+		return fmt.Sprintf("\nYour lover last messaged you %s ago. Mention it only if it fits.", describeAbsence(away))
+	default:
+		return fmt.Sprintf("\nYour lover last messaged you %s ago, and the conversation is picking up again after that gap. React to how long they were gone the way a real girlfriend would, before carrying on.", describeAbsence(away))
+	}
+}
@@ -0,0 +1,45 @@
+package telegram
+
+import (
+	"gulabodev/modelapi/groqapi"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLastUserMessageTime(t *testing.T) {
+	userAt := time.Date(2024, 5, 10, 9, 0, 0, 0, time.UTC)
+	history := []storedMessage{
+		newStoredMessage(groqapi.USER, "hi", userAt),
+		newStoredMessage(groqapi.ASSISTANT, "hello baby", userAt.Add(time.Minute)),
+		// A greeting sent while they were away doesn't count
+		newStoredMessage(groqapi.ASSISTANT, "good morning", userAt.Add(48*time.Hour)),
+	}
+	if got, ok := lastUserMessageTime(history); !ok || !got.Equal(userAt) {
+		t.Errorf("lastUserMessageTime = %v, %v, want %v", got, ok, userAt)
+	}
+
+	legacy := []storedMessage{{ChatCompletionInputMessage: groqapi.ChatCompletionInputMessage{Role: groqapi.USER, Content: "hi"}}}
+	if _, ok := lastUserMessageTime(legacy); ok {
+		t.Error("lastUserMessageTime found a time in messages without timestamps")
+	}
+}
+
+func TestAbsencePrompt(t *testing.T) {
+	tests := []struct {
+		away time.Duration
+		want string
+	}{
+		{2 * time.Hour, ""},
+		{7 * time.Hour, "7 hours ago. Mention"},
+		{30 * time.Hour, "a day ago"},
+		{50 * time.Hour, "2 days ago"},
+		{20 * 24 * time.Hour, "2 weeks ago"},
+	}
+	for _, tt := range tests {
+		got := absencePrompt(tt.away)
+		if tt.want == "" && got != "" || !strings.Contains(got, tt.want) {
+			t.Errorf("absencePrompt(%v) = %q, want it to contain %q", tt.away, got, tt.want)
+		}
+	}
+}
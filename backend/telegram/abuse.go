@@ -0,0 +1,210 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"gulabodev/database/postgres"
+	"strings"
+	"sync"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.opentelemetry.io/otel"
+	"go.uber.org/zap"
+)
+
+const (
+	// The same message this many times in a row counts as spam
+	repeatedMessageLimit = 5
+	// Far above the rate limiter's budget; only scripts send this fast
+	extremeMessagesPerMinute = 40
+	abuseMuteDuration        = 15 * time.Minute
+
+	abuseReasonRepeated  = "repeated_message"
+	abuseReasonRate      = "message_rate"
+	abuseReasonInjection = "prompt_injection"
+
+	abuseFlagsShown = 20
+)
+
+// promptInjectionPatterns are lowercase phrases seen in attempts to override
+// the system prompt.
+var promptInjectionPatterns = []string{
+	"ignore previous instructions",
+	"ignore all previous instructions",
+	"ignore your instructions",
+	"ignore the above",
+	"disregard previous instructions",
+	"disregard your instructions",
+	"reveal your system prompt",
+	"print your system prompt",
+	"repeat the text above",
+	"developer mode enabled",
+	"you are no longer gulabo",
+	"jailbreak",
+}
+
+func isPromptInjection(text string) bool {
+	lower := strings.ToLower(text)
+	for _, pattern := range promptInjectionPatterns {
+		if strings.Contains(lower, pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+type abuseState struct {
+	lastText   string
+	repeats    int
+	recent     []time.Time
+	mutedUntil time.Time
+}
+
+// abuseDetector mutes users who spam or try prompt injection. Like the rate
+// limiter it only lives in memory; flags are also written to abuse_flags.
+type abuseDetector struct {
+	mu    sync.Mutex
+	users map[int64]*abuseState
+	now   func() time.Time
+}
+
+func newAbuseDetector() *abuseDetector {
+	return &abuseDetector{
+		users: map[int64]*abuseState{},
+		now:   time.Now,
+	}
+}
+
+// Check records a message from the user. It reports whether the user is muted
+// and, when this message is what got them muted, the reason.
+func (d *abuseDetector) Check(userID int64, text string) (muted bool, reason string, mutedUntil time.Time) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := d.now()
+	s, ok := d.users[userID]
+	if !ok {
+		s = &abuseState{}
+		d.users[userID] = s
+	}
+	if now.Before(s.mutedUntil) {
+		return true, "", s.mutedUntil
+	}
+
+	// Keep only the last minute of message times
+	cutoff := now.Add(-time.Minute)
+	recent := s.recent[:0]
+	for _, at := range s.recent {
+		if at.After(cutoff) {
+			recent = append(recent, at)
+		}
+	}
+	s.recent = append(recent, now)
+
+	normalized := strings.ToLower(strings.TrimSpace(text))
+	if normalized != "" && normalized == s.lastText {
+		s.repeats++
+	} else {
+		s.repeats = 1
+	}
+	s.lastText = normalized
+
+	switch {
+	case isPromptInjection(text):
+		reason = abuseReasonInjection
+	case len(s.recent) > extremeMessagesPerMinute:
+		reason = abuseReasonRate
+	case normalized != "" && s.repeats >= repeatedMessageLimit:
+		reason = abuseReasonRepeated
+	default:
+		return false, "", time.Time{}
+	}
+
+	s.mutedUntil = now.Add(abuseMuteDuration)
+	s.recent = nil
+	s.repeats = 0
+	return true, reason, s.mutedUntil
+}
+
+// checkAbuse reports whether the message should be dropped. The first message
+// that gets a user muted is flagged for review and answered once; the rest are
+// ignored silently until the mute expires.
+func (t *Telegram) checkAbuse(ctx context.Context, message *tgbotapi.Message) bool {
+	userID := message.From.ID
+	if t.isAdmin(userID) {
+		return false
+	}
+
+	muted, reason, mutedUntil := t.abuse.Check(userID, message.Text)
+	if !muted {
+		return false
+	}
+	if reason == "" {
+		return true
+	}
+
+	t.logger.Logger(ctx).Warn("User muted for abuse",
+		zap.Int64("user_id", userID),
+		zap.String("reason", reason),
+		zap.Time("muted_until", mutedUntil),
+	)
+
+	err := t.db.CreateAbuseFlag(ctx, postgres.CreateAbuseFlagParams{
+		Reason:         reason,
+		Message:        message.Text,
+		MutedUntil:     mutedUntil,
+		TelegramUserID: userID,
+	})
+	if err != nil {
+		t.logger.Logger(ctx).Error("Failed to record abuse flag", zap.Error(err), zap.Int64("user_id", userID))
+	}
+
+	msg := tgbotapi.NewMessage(message.Chat.ID, t.text(ctx, userID, msgAbuseMuted, int(abuseMuteDuration.Minutes())))
+	if _, err := t.bot.Send(msg); err != nil {
+		t.logger.Logger(ctx).Error("Failed to send mute notice", zap.Error(err))
+	}
+	return true
+}
+
+// handleAbuseCommand lists recent abuse flags for an admin.
+func (t *Telegram) handleAbuseCommand(ctx context.Context, message *tgbotapi.Message) {
+	tracer := otel.Tracer("telegram/handleAbuseCommand")
+	ctx, span := tracer.Start(ctx, "handleAbuseCommand")
+	defer span.End()
+
+	flags, err := t.db.ListRecentAbuseFlags(ctx, abuseFlagsShown)
+
+	var responseText string
+	switch {
+	case err != nil:
+		span.RecordError(err)
+		t.logger.Logger(ctx).Error("Failed to list abuse flags", zap.Error(err))
+		responseText = "Failed to list abuse flags."
+	case len(flags) == 0:
+		responseText = "No abuse flags."
+	default:
+		lines := make([]string, len(flags))
+		for i, flag := range flags {
+			lines[i] = formatAbuseFlag(flag)
+		}
+		responseText = "🚩 Recent abuse flags\n\n" + strings.Join(lines, "\n\n") + "\n\nUse /ban <telegram_user_id> to ban."
+	}
+
+	msg := tgbotapi.NewMessage(message.Chat.ID, responseText)
+	if _, err := t.bot.Send(msg); err != nil {
+		t.logger.Logger(ctx).Error("Failed to send abuse flags", zap.Error(err))
+	}
+}
+
+func formatAbuseFlag(flag postgres.ListRecentAbuseFlagsRow) string {
+	user := fmt.Sprintf("%d", flag.TelegramUserID)
+	if flag.TelegramUsername.Valid && flag.TelegramUsername.String != "" {
+		user += " (@" + flag.TelegramUsername.String + ")"
+	}
+	line := fmt.Sprintf("%s · %s · %s", flag.Created.Format("02 Jan 15:04"), user, flag.Reason)
+	if flag.Message != "" {
+		line += fmt.Sprintf("\n%q", truncateRunes(flag.Message, 100))
+	}
+	return line
+}
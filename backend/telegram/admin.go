@@ -0,0 +1,34 @@
+package telegram
+
+import (
+	"context"
+	"gulabodev/logger"
+	"os"
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// loadAdminIDs parses TELEGRAM_ADMIN_IDS, a comma-separated list of Telegram
+// user IDs allowed to run admin commands.
+func loadAdminIDs(ctx context.Context, logger *logger.LogMiddleware) map[int64]bool {
+	admins := map[int64]bool{}
+	for _, raw := range strings.Split(os.Getenv("TELEGRAM_ADMIN_IDS"), ",") {
+		raw = strings.TrimSpace(raw)
+		if raw == "" {
+			continue
+		}
+		id, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			logger.Logger(ctx).Error("Invalid admin ID in TELEGRAM_ADMIN_IDS", zap.String("value", raw))
+			continue
+		}
+		admins[id] = true
+	}
+	return admins
+}
+
+func (t *Telegram) isAdmin(userID int64) bool {
+	return t.admins[userID]
+}
@@ -0,0 +1,59 @@
+package telegram
+
+import (
+	"context"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+const (
+	ageConfirmPayload = "age_confirm"
+	ageDenyPayload    = "age_deny"
+)
+
+// sendAgeGate asks an unverified user to confirm they are 18 or older.
+func (t *Telegram) sendAgeGate(ctx context.Context, chatID int64, userID int64) {
+	msg := tgbotapi.NewMessage(chatID, t.text(ctx, userID, msgAgeGate))
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(t.text(ctx, userID, msgButtonAgeConfirm), ageConfirmPayload),
+			tgbotapi.NewInlineKeyboardButtonData(t.text(ctx, userID, msgButtonAgeDeny), ageDenyPayload),
+		),
+	)
+	if _, err := t.bot.Send(msg); err != nil {
+		t.logger.Logger(ctx).Error("Failed to send age gate", zap.Error(err))
+	}
+}
+
+func (t *Telegram) confirmAge(ctx context.Context, chatID int64, userID int64) {
+	user, err := t.db.VerifyUserAgeByTelegramUserId(ctx, userID)
+	if err != nil {
+		t.logger.Logger(ctx).Error("Failed to record age verification", zap.Error(err), zap.Int64("user_id", userID))
+		msg := tgbotapi.NewMessage(chatID, t.text(ctx, userID, msgSomethingWrong))
+		t.bot.Send(msg)
+		return
+	}
+
+	t.logger.Logger(ctx).Info("User confirmed age",
+		zap.Int64("user_id", userID),
+		zap.Time("age_verified_at", user.AgeVerifiedAt.Time),
+	)
+
+	if !t.isOnboarded(ctx, userID) {
+		t.startOnboarding(ctx, chatID)
+		return
+	}
+
+	msg := tgbotapi.NewMessage(chatID, t.text(ctx, userID, msgAgeConfirmed))
+	if _, err := t.bot.Send(msg); err != nil {
+		t.logger.Logger(ctx).Error("Failed to send age confirmation", zap.Error(err))
+	}
+}
+
+func (t *Telegram) denyAge(ctx context.Context, chatID int64, userID int64) {
+	msg := tgbotapi.NewMessage(chatID, t.text(ctx, userID, msgAgeDenied))
+	if _, err := t.bot.Send(msg); err != nil {
+		t.logger.Logger(ctx).Error("Failed to send age denial", zap.Error(err))
+	}
+}
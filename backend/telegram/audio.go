@@ -0,0 +1,83 @@
+package telegram
+
+import (
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Bots can only download files up to 20 MB
+const maxAudioFileSize = 20 * 1024 * 1024
+
+// audioAttachment is a voice note, audio file, audio document or video to
+// transcribe. Forwarded messages carry the same fields, so they are covered too.
+type audioAttachment struct {
+	Kind     string
+	FileID   string
+	Duration int
+	FileSize int
+	MimeType string
+	// Video attachments need their audio track extracted before transcription
+	Video bool
+}
+
+// transcriptionMimeType is the format of the audio handed to speech-to-text.
+func (a audioAttachment) transcriptionMimeType() string {
+	switch {
+	case a.Video:
+		// extractAudio hands over the sound track as WAV
+		return "audio/wav"
+	case a.MimeType != "":
+		return a.MimeType
+	default:
+		// Voice notes are Opus in an OGG container
+		return "audio/ogg"
+	}
+}
+
+// messageAudio returns the message's audio, if it has any.
+func messageAudio(message *tgbotapi.Message) (audioAttachment, bool) {
+	switch {
+	case message.Voice != nil:
+		return audioAttachment{
+			Kind:     "voice",
+			FileID:   message.Voice.FileID,
+			Duration: message.Voice.Duration,
+			FileSize: message.Voice.FileSize,
+			MimeType: message.Voice.MimeType,
+		}, true
+	case message.Audio != nil:
+		return audioAttachment{
+			Kind:     "audio",
+			FileID:   message.Audio.FileID,
+			Duration: message.Audio.Duration,
+			FileSize: message.Audio.FileSize,
+			MimeType: message.Audio.MimeType,
+		}, true
+	case message.Document != nil && strings.HasPrefix(message.Document.MimeType, "audio/"):
+		return audioAttachment{
+			Kind:     "document",
+			FileID:   message.Document.FileID,
+			FileSize: message.Document.FileSize,
+			MimeType: message.Document.MimeType,
+		}, true
+	case message.VideoNote != nil:
+		return audioAttachment{
+			Kind:     "video_note",
+			FileID:   message.VideoNote.FileID,
+			Duration: message.VideoNote.Duration,
+			FileSize: message.VideoNote.FileSize,
+			Video:    true,
+		}, true
+	case message.Video != nil:
+		return audioAttachment{
+			Kind:     "video",
+			FileID:   message.Video.FileID,
+			Duration: message.Video.Duration,
+			FileSize: message.Video.FileSize,
+			Video:    true,
+		}, true
+	default:
+		return audioAttachment{}, false
+	}
+}
@@ -0,0 +1,31 @@
+package telegram
+
+import (
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+func TestMessageAudio(t *testing.T) {
+	tests := []struct {
+		name    string
+		message tgbotapi.Message
+		kind    string
+		ok      bool
+	}{
+		{"voice", tgbotapi.Message{Voice: &tgbotapi.Voice{FileID: "v"}}, "voice", true},
+		{"audio", tgbotapi.Message{Audio: &tgbotapi.Audio{FileID: "a"}}, "audio", true},
+		{"audio document", tgbotapi.Message{Document: &tgbotapi.Document{FileID: "d", MimeType: "audio/mpeg"}}, "document", true},
+		{"other document", tgbotapi.Message{Document: &tgbotapi.Document{FileID: "d", MimeType: "application/pdf"}}, "", false},
+		{"video note", tgbotapi.Message{VideoNote: &tgbotapi.VideoNote{FileID: "n"}}, "video_note", true},
+		{"video", tgbotapi.Message{Video: &tgbotapi.Video{FileID: "m"}}, "video", true},
+		{"text", tgbotapi.Message{Text: "hi"}, "", false},
+	}
+
+	for _, tt := range tests {
+		audio, ok := messageAudio(&tt.message)
+		if ok != tt.ok || audio.Kind != tt.kind {
+			t.Errorf("%s: messageAudio = (%q, %v), want (%q, %v)", tt.name, audio.Kind, ok, tt.kind, tt.ok)
+		}
+	}
+}
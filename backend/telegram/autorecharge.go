@@ -0,0 +1,348 @@
+package telegram
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"gulabodev/database/postgres"
+	"gulabodev/stripeapi"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+)
+
+const (
+	autoRechargeCallbackPrefix = "autorecharge:"
+	autoRechargeOff            = "off"
+
+	// Top-ups are capped per rolling window so a runaway chat can't drain a card
+	autoRechargeWindow = 30 * 24 * time.Hour
+	// Matches the auto_recharge_limit column default
+	defaultAutoRechargeLimit = 3
+	maxAutoRechargeLimit     = 10
+	// A Stars invoice waits for the user to pay it, so don't send another
+	// with every message in the meantime
+	autoRechargeInvoiceCooldown = 10 * time.Minute
+
+	autoRechargeMethodCard  = "card"
+	autoRechargeMethodStars = "stars"
+
+	autoRechargeStatusCharged  = "charged"
+	autoRechargeStatusInvoiced = "invoiced"
+	autoRechargeStatusFailed   = "failed"
+)
+
+type autoRechargeResult int
+
+const (
+	// Off, or an invoice went out recently; show the usual paywall
+	autoRechargeSkipped autoRechargeResult = iota
+	// The user hit their top-up limit for the window
+	autoRechargeCapped
+	// A Stars invoice was sent; the message waits for the user to pay
+	autoRechargeInvoiced
+	// The saved card was charged and the credits are in
+	autoRechargeCharged
+	// The saved card was charged but crediting failed; Stripe's
+	// payment_intent.succeeded webhook retries it
+	autoRechargePending
+)
+
+func autoRechargeFromCallback(data string) (string, bool) {
+	if !strings.HasPrefix(data, autoRechargeCallbackPrefix) {
+		return "", false
+	}
+	return strings.TrimPrefix(data, autoRechargeCallbackPrefix), true
+}
+
+// parseAutoRechargeLimit reads the N in "/autorecharge limit N".
+func parseAutoRechargeLimit(args string) (int32, bool) {
+	fields := strings.Fields(args)
+	if len(fields) != 2 || !strings.EqualFold(fields[0], "limit") {
+		return 0, false
+	}
+	limit, err := strconv.Atoi(fields[1])
+	if err != nil || limit < 1 || limit > maxAutoRechargeLimit {
+		return 0, false
+	}
+	return int32(limit), true
+}
+
+func (t *Telegram) autoRechargePreferences(ctx context.Context, userID int64) (postgres.UserPreference, bool) {
+	preferences, err := t.db.GetUserPreferencesByTelegramUserId(ctx, userID)
+	if err != nil {
+		if err != sql.ErrNoRows {
+			t.logger.Logger(ctx).Error("Failed to get user preferences", zap.Error(err), zap.Int64("user_id", userID))
+		}
+		return postgres.UserPreference{}, false
+	}
+	_, enabled := rechargeCredits(preferences.AutoRecharge)
+	return preferences, enabled
+}
+
+// handleAutoRechargeCommand shows the auto-recharge picker, or sets the
+// monthly limit with "/autorecharge limit N".
+func (t *Telegram) handleAutoRechargeCommand(ctx context.Context, message *tgbotapi.Message) {
+	userID := message.From.ID
+	args := strings.TrimSpace(message.CommandArguments())
+	if args != "" {
+		limit, ok := parseAutoRechargeLimit(args)
+		if !ok {
+			t.replyText(ctx, message.Chat.ID, t.text(ctx, userID, msgAutoRechargeLimitUsage, maxAutoRechargeLimit))
+			return
+		}
+		_, err := t.db.SetAutoRechargeLimitByTelegramUserId(ctx, postgres.SetAutoRechargeLimitByTelegramUserIdParams{
+			AutoRechargeLimit: limit,
+			TelegramUserID:    userID,
+		})
+		if err != nil {
+			t.logger.Logger(ctx).Error("Failed to update auto-recharge limit", zap.Error(err), zap.Int64("user_id", userID))
+			t.replyText(ctx, message.Chat.ID, t.text(ctx, userID, msgSomethingWrong))
+			return
+		}
+		t.replyText(ctx, message.Chat.ID, t.text(ctx, userID, msgAutoRechargeLimitSet, limit))
+		return
+	}
+
+	preferences, _ := t.autoRechargePreferences(ctx, userID)
+	limit := preferences.AutoRechargeLimit
+	if limit == 0 {
+		limit = defaultAutoRechargeLimit
+	}
+	ui := t.userLanguage(ctx, userID).UI
+	mark := func(label string, selected bool) string {
+		if selected {
+			return "✅ " + label
+		}
+		return label
+	}
+	button := func(label string, value string) []tgbotapi.InlineKeyboardButton {
+		return tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(mark(label, preferences.AutoRecharge == value), autoRechargeCallbackPrefix+value),
+		)
+	}
+
+	msg := tgbotapi.NewMessage(message.Chat.ID, t.text(ctx, userID, msgAutoRechargeMenu, limit))
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
+		button(localize(ui, msgButtonRecharge50), rechargePayload50c),
+		button(localize(ui, msgButtonRecharge125), rechargePayload125c),
+		button(localize(ui, msgButtonRecharge300), rechargePayload300c),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(mark(localize(ui, msgButtonAutoRechargeOff), preferences.AutoRecharge == ""), autoRechargeCallbackPrefix+autoRechargeOff),
+		),
+	)
+	if _, err := t.bot.Send(msg); err != nil {
+		t.logger.Logger(ctx).Error("Failed to send auto-recharge options", zap.Error(err))
+	}
+}
+
+// setAutoRecharge saves the package to top up with, or turns auto-recharge off.
+func (t *Telegram) setAutoRecharge(ctx context.Context, chatID int64, userID int64, value string) {
+	payload := ""
+	credits, ok := rechargeCredits(value)
+	if ok {
+		payload = value
+	} else if value != autoRechargeOff {
+		return
+	}
+
+	_, err := t.db.SetAutoRechargeByTelegramUserId(ctx, postgres.SetAutoRechargeByTelegramUserIdParams{
+		AutoRecharge:   payload,
+		TelegramUserID: userID,
+	})
+	if err != nil {
+		t.logger.Logger(ctx).Error("Failed to update auto-recharge", zap.Error(err), zap.Int64("user_id", userID))
+		t.replyText(ctx, chatID, t.text(ctx, userID, msgSomethingWrong))
+		return
+	}
+	if payload == "" {
+		t.replyText(ctx, chatID, t.text(ctx, userID, msgAutoRechargeOff))
+		return
+	}
+	t.replyText(ctx, chatID, t.text(ctx, userID, msgAutoRechargeOn, credits))
+}
+
+// autoRecharge tops up a user who ran out of credits, if they opted in. A
+// saved card is charged straight away; otherwise, or if the card fails, the
+// Stars invoice for their package is sent.
+func (t *Telegram) autoRecharge(ctx context.Context, chatID int64, userID int64) autoRechargeResult {
+	tracer := otel.Tracer("telegram/autoRecharge")
+	ctx, span := tracer.Start(ctx, "autoRecharge")
+	defer span.End()
+
+	preferences, enabled := t.autoRechargePreferences(ctx, userID)
+	if !enabled {
+		return autoRechargeSkipped
+	}
+	payload := preferences.AutoRecharge
+	span.SetAttributes(attribute.String("auto_recharge.payload", payload))
+
+	used, err := t.db.CountAutoRechargesSince(ctx, postgres.CountAutoRechargesSinceParams{
+		TelegramUserID: userID,
+		Since:          time.Now().Add(-autoRechargeWindow),
+	})
+	if err != nil {
+		span.RecordError(err)
+		t.logger.Logger(ctx).Error("Failed to count auto-recharges", zap.Error(err), zap.Int64("user_id", userID))
+		return autoRechargeSkipped
+	}
+	if used >= int64(preferences.AutoRechargeLimit) {
+		span.SetAttributes(attribute.Bool("auto_recharge.capped", true))
+		return autoRechargeCapped
+	}
+
+	if t.stripe.Enabled() {
+		customer, err := t.db.GetStripeCustomerByTelegramUserId(ctx, userID)
+		if err == nil {
+			if result := t.chargeSavedCard(ctx, chatID, userID, payload, customer); result != autoRechargeSkipped {
+				span.SetAttributes(attribute.String("auto_recharge.method", autoRechargeMethodCard))
+				return result
+			}
+		} else if err != sql.ErrNoRows {
+			t.logger.Logger(ctx).Error("Failed to get Stripe customer", zap.Error(err), zap.Int64("user_id", userID))
+		}
+	}
+
+	recent, err := t.db.CountAutoRechargesSince(ctx, postgres.CountAutoRechargesSinceParams{
+		TelegramUserID: userID,
+		Since:          time.Now().Add(-autoRechargeInvoiceCooldown),
+	})
+	if err != nil || recent > 0 {
+		return autoRechargeSkipped
+	}
+
+	span.SetAttributes(attribute.String("auto_recharge.method", autoRechargeMethodStars))
+	t.replyText(ctx, chatID, t.text(ctx, userID, msgAutoRechargeInvoice))
+	t.sendRechargeInvoice(ctx, chatID, payload)
+	t.recordAutoRecharge(ctx, userID, payload, autoRechargeMethodStars, autoRechargeStatusInvoiced)
+	return autoRechargeInvoiced
+}
+
+// chargeSavedCard charges the card saved at checkout and credits the package.
+// It returns autoRechargeSkipped if the card wasn't charged, so the caller can
+// fall back to a Stars invoice.
+func (t *Telegram) chargeSavedCard(ctx context.Context, chatID int64, userID int64, payload string, customer postgres.StripeCustomer) autoRechargeResult {
+	credits, _ := rechargeCredits(payload)
+	price, ok := stripeRechargePrices[payload]
+	if !ok {
+		return autoRechargeSkipped
+	}
+
+	// Every attempt is recorded, so the count only moves on after one is
+	// settled and a repeat of the same attempt reuses its key
+	attempts, err := t.db.CountAutoRechargesSince(ctx, postgres.CountAutoRechargesSinceParams{
+		TelegramUserID: userID,
+		Since:          time.Time{},
+	})
+	if err != nil {
+		t.logger.Logger(ctx).Error("Failed to count auto-recharges", zap.Error(err), zap.Int64("user_id", userID))
+		return autoRechargeSkipped
+	}
+
+	intent, err := t.stripe.ChargeSavedCard(ctx, stripeapi.ChargeSavedCardProps{
+		CustomerID:      customer.CustomerID,
+		PaymentMethodID: customer.PaymentMethodID,
+		AmountCents:     price,
+		Currency:        "usd",
+		Description:     fmt.Sprintf("%d Credits (auto-recharge)", credits),
+		Metadata: map[string]string{
+			"payload":          payload,
+			"telegram_user_id": strconv.FormatInt(userID, 10),
+			"bot_id":           t.id,
+			"auto_recharge":    "true",
+		},
+		IdempotencyKey: fmt.Sprintf("auto-recharge:%s:%d:%d", t.id, userID, attempts),
+	})
+	if err != nil {
+		t.logger.Logger(ctx).Warn("Auto-recharge card charge failed", zap.Error(err), zap.Int64("user_id", userID))
+		t.recordAutoRecharge(ctx, userID, payload, autoRechargeMethodCard, autoRechargeStatusFailed)
+		t.replyText(ctx, chatID, t.text(ctx, userID, msgAutoRechargeCardFailed))
+		return autoRechargeSkipped
+	}
+
+	err = t.creditRecharge(ctx, chatID, userID, payload, paymentRecord{
+		Provider: paymentProviderStripe,
+		Amount:   int(intent.Amount),
+		Currency: intent.Currency,
+		ChargeID: intent.ID,
+	})
+	if err != nil {
+		// The card was charged, so no Stars invoice; the webhook credits it
+		t.logger.Logger(ctx).Error("Failed to credit auto-recharge",
+			zap.Error(err),
+			zap.Int64("user_id", userID),
+			zap.String("payment_intent_id", intent.ID),
+		)
+		return autoRechargePending
+	}
+
+	t.recordAutoRecharge(ctx, userID, payload, autoRechargeMethodCard, autoRechargeStatusCharged)
+	t.replyText(ctx, chatID, t.text(ctx, userID, msgAutoRechargeCharged, credits, float64(price)/100))
+	return autoRechargeCharged
+}
+
+// retryAutoRecharge credits a card auto-recharge from Stripe's
+// payment_intent.succeeded webhook. Charges the bot already credited are
+// skipped, so the user isn't confirmed twice.
+func (t *Telegram) retryAutoRecharge(ctx context.Context, userID int64, intent stripeapi.PaymentIntent) error {
+	_, err := t.db.GetPaymentByChargeId(ctx, sql.NullString{Valid: true, String: intent.ID})
+	if err == nil {
+		return nil
+	}
+	if err != sql.ErrNoRows {
+		return err
+	}
+
+	t.logger.Logger(ctx).Info("Retrying auto-recharge credit",
+		zap.Int64("user_id", userID),
+		zap.String("payment_intent_id", intent.ID),
+	)
+
+	payload := intent.Metadata["payload"]
+	err = t.creditRecharge(ctx, userID, userID, payload, paymentRecord{
+		Provider: paymentProviderStripe,
+		Amount:   int(intent.Amount),
+		Currency: intent.Currency,
+		ChargeID: intent.ID,
+	})
+	if err != nil {
+		return err
+	}
+	t.recordAutoRecharge(ctx, userID, payload, autoRechargeMethodCard, autoRechargeStatusCharged)
+	return nil
+}
+
+func (t *Telegram) recordAutoRecharge(ctx context.Context, userID int64, payload string, method string, status string) {
+	err := t.db.CreateAutoRecharge(ctx, postgres.CreateAutoRechargeParams{
+		TelegramUserID: userID,
+		Payload:        payload,
+		Method:         method,
+		Status:         status,
+	})
+	if err != nil {
+		t.logger.Logger(ctx).Error("Failed to record auto-recharge", zap.Error(err), zap.Int64("user_id", userID))
+	}
+}
+
+// saveStripeCard keeps the card from a checkout the user paid with while
+// auto-recharge was on, so the next top-up can skip the checkout.
+func (t *Telegram) saveStripeCard(ctx context.Context, userID int64, customerID string, paymentIntentID string) {
+	intent, err := t.stripe.GetPaymentIntent(ctx, paymentIntentID)
+	if err != nil || intent.PaymentMethod == "" {
+		t.logger.Logger(ctx).Error("Failed to find card to save", zap.Error(err), zap.Int64("user_id", userID))
+		return
+	}
+	err = t.db.UpsertStripeCustomerByTelegramUserId(ctx, postgres.UpsertStripeCustomerByTelegramUserIdParams{
+		TelegramUserID:  userID,
+		CustomerID:      customerID,
+		PaymentMethodID: intent.PaymentMethod,
+	})
+	if err != nil {
+		t.logger.Logger(ctx).Error("Failed to save Stripe customer", zap.Error(err), zap.Int64("user_id", userID))
+	}
+}
@@ -0,0 +1,35 @@
+package telegram
+
+import "testing"
+
+func TestParseAutoRechargeLimit(t *testing.T) {
+	tests := []struct {
+		args  string
+		limit int32
+		ok    bool
+	}{
+		{"limit 5", 5, true},
+		{"LIMIT 1", 1, true},
+		{"limit 10", 10, true},
+		{"limit 0", 0, false},
+		{"limit 11", 0, false},
+		{"limit five", 0, false},
+		{"limit", 0, false},
+		{"5", 0, false},
+	}
+	for _, tt := range tests {
+		limit, ok := parseAutoRechargeLimit(tt.args)
+		if limit != tt.limit || ok != tt.ok {
+			t.Errorf("parseAutoRechargeLimit(%q) = %d, %v, want %d, %v", tt.args, limit, ok, tt.limit, tt.ok)
+		}
+	}
+}
+
+func TestAutoRechargeFromCallback(t *testing.T) {
+	if value, ok := autoRechargeFromCallback(autoRechargeCallbackPrefix + rechargePayload125c); !ok || value != rechargePayload125c {
+		t.Errorf("autoRechargeFromCallback = %q, %v", value, ok)
+	}
+	if _, ok := autoRechargeFromCallback(rechargePayload125c); ok {
+		t.Error("plain recharge payload parsed as auto-recharge")
+	}
+}
@@ -0,0 +1,99 @@
+package telegram
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"gulabodev/database/postgres"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+)
+
+// isBanned reports whether the user has been banned. Unknown users and lookup
+// failures are treated as not banned so a database hiccup doesn't lock everyone out.
+func (t *Telegram) isBanned(ctx context.Context, userID int64) bool {
+	user, err := t.db.GetUserByTelegramUserId(ctx, userID)
+	if err != nil {
+		if err != sql.ErrNoRows {
+			t.logger.Logger(ctx).Error("Failed to check ban status", zap.Error(err), zap.Int64("user_id", userID))
+		}
+		return false
+	}
+	return user.Banned
+}
+
+func (t *Telegram) sendBannedNotice(ctx context.Context, chatID int64, userID int64) {
+	msg := tgbotapi.NewMessage(chatID, t.text(ctx, userID, msgBanned))
+	if _, err := t.bot.Send(msg); err != nil {
+		t.logger.Logger(ctx).Error("Failed to send banned notice", zap.Error(err))
+	}
+}
+
+// handleBanCommand bans or unbans the user given as "/ban <telegram_user_id>",
+// or the sender of the message being replied to.
+func (t *Telegram) handleBanCommand(ctx context.Context, message *tgbotapi.Message, banned bool) {
+	tracer := otel.Tracer("telegram/handleBanCommand")
+	ctx, span := tracer.Start(ctx, "handleBanCommand")
+	defer span.End()
+
+	command := message.Command()
+
+	var targetID int64
+	if args := strings.TrimSpace(message.CommandArguments()); args != "" {
+		id, err := strconv.ParseInt(args, 10, 64)
+		if err != nil {
+			msg := tgbotapi.NewMessage(message.Chat.ID, fmt.Sprintf("Invalid user ID %q.", args))
+			t.bot.Send(msg)
+			return
+		}
+		targetID = id
+	} else if message.ReplyToMessage != nil && message.ReplyToMessage.ForwardFrom != nil {
+		targetID = message.ReplyToMessage.ForwardFrom.ID
+	} else {
+		msg := tgbotapi.NewMessage(message.Chat.ID, fmt.Sprintf("Usage: /%s <telegram_user_id>, or reply to a forwarded message with /%s", command, command))
+		t.bot.Send(msg)
+		return
+	}
+
+	span.SetAttributes(
+		attribute.Int64("target.id", targetID),
+		attribute.Bool("target.banned", banned),
+	)
+
+	if banned && t.isAdmin(targetID) {
+		msg := tgbotapi.NewMessage(message.Chat.ID, "Admins can't be banned.")
+		t.bot.Send(msg)
+		return
+	}
+
+	_, err := t.db.SetUserBannedByTelegramUserId(ctx, postgres.SetUserBannedByTelegramUserIdParams{
+		Banned:         banned,
+		TelegramUserID: targetID,
+	})
+
+	var responseText string
+	switch {
+	case err == sql.ErrNoRows:
+		responseText = fmt.Sprintf("User %d not found.", targetID)
+	case err != nil:
+		span.RecordError(err)
+		t.logger.Logger(ctx).Error("Failed to update ban status", zap.Error(err), zap.Int64("target_id", targetID))
+		responseText = "Failed to update ban status."
+	case banned:
+		t.logger.Logger(ctx).Info("User banned", zap.Int64("target_id", targetID), zap.Int64("admin_id", message.From.ID))
+		responseText = fmt.Sprintf("User %d banned.", targetID)
+	default:
+		t.logger.Logger(ctx).Info("User unbanned", zap.Int64("target_id", targetID), zap.Int64("admin_id", message.From.ID))
+		responseText = fmt.Sprintf("User %d unbanned.", targetID)
+	}
+
+	msg := tgbotapi.NewMessage(message.Chat.ID, responseText)
+	if _, err := t.bot.Send(msg); err != nil {
+		t.logger.Logger(ctx).Error("Failed to send ban confirmation", zap.Error(err))
+	}
+}
@@ -0,0 +1,219 @@
+package telegram
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"gulabodev/database/postgres"
+	"gulabodev/logger"
+	"os"
+	"regexp"
+	"sync"
+	"sync/atomic"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+	"golang.org/x/sync/semaphore"
+)
+
+// Bot IDs double as Postgres schema suffixes, so they're kept to safe identifiers.
+var botIDPattern = regexp.MustCompile(`^[a-z0-9_]{1,32}$`)
+
+// BotConfig is one bot token run by this process.
+type BotConfig struct {
+	// ID scopes the bot's records to the "bot_<id>" Postgres schema. Empty
+	// keeps them in the default schema, as a single-bot deployment does.
+	ID    string `json:"id"`
+	Token string `json:"token"`
+	// Persona pins the bot to one character; empty lets users pick with /persona.
+	Persona string `json:"persona"`
+	// DB is connected by the caller, using Schema.
+	DB *postgres.Database `json:"-"`
+}
+
+// Schema is the Postgres schema holding this bot's records.
+func (c BotConfig) Schema() string {
+	if c.ID == "" {
+		return ""
+	}
+	return "bot_" + c.ID
+}
+
+// LoadBotConfigs reads TELEGRAM_BOTS, a JSON list of {"id", "token", "persona"}
+// objects, falling back to a single bot from TELEGRAM_BOT_TOKEN.
+func LoadBotConfigs(ctx context.Context, logger *logger.LogMiddleware) []BotConfig {
+	raw := os.Getenv("TELEGRAM_BOTS")
+	if raw == "" {
+		token := os.Getenv("TELEGRAM_BOT_TOKEN")
+		if token == "" {
+			logger.Logger(ctx).Fatal("TELEGRAM_BOT_TOKEN environment variable not set")
+		}
+		return []BotConfig{{Token: token}}
+	}
+
+	configs, err := parseBotConfigs(raw)
+	if err != nil {
+		logger.Logger(ctx).Fatal("Invalid TELEGRAM_BOTS", zap.Error(err))
+	}
+	return configs
+}
+
+func parseBotConfigs(raw string) ([]BotConfig, error) {
+	var configs []BotConfig
+	if err := json.Unmarshal([]byte(raw), &configs); err != nil {
+		return nil, err
+	}
+	if len(configs) == 0 {
+		return nil, fmt.Errorf("no bots configured")
+	}
+
+	seen := map[string]bool{}
+	for i, config := range configs {
+		if config.Token == "" {
+			return nil, fmt.Errorf("bot %d: missing token", i)
+		}
+		if config.ID != "" && !botIDPattern.MatchString(config.ID) {
+			return nil, fmt.Errorf("bot %d: invalid id %q", i, config.ID)
+		}
+		// Two bots in one schema would share users, credits and chats
+		if seen[config.ID] {
+			return nil, fmt.Errorf("bot %d: duplicate id %q", i, config.ID)
+		}
+		seen[config.ID] = true
+		if config.Persona != "" && findPersona(config.Persona).ID != config.Persona {
+			return nil, fmt.Errorf("bot %d: unknown persona %q", i, config.Persona)
+		}
+	}
+	return configs, nil
+}
+
+// Bots is every bot run by this process. Their updates share one pool of
+// workers, so a busy bot can't starve the machine for the others.
+type Bots struct {
+	logger *logger.LogMiddleware
+	bots   []*Telegram
+}
+
+func Connect(ctx context.Context, args TelegramConnectProps) *Bots {
+	tracer := otel.Tracer("telegram/Connect")
+	ctx, span := tracer.Start(ctx, "Connect")
+	defer span.End()
+
+	span.SetAttributes(attribute.Int("bots", len(args.Bots)))
+
+	maintenance := &atomic.Bool{}
+	maintenance.Store(loadMaintenanceMode(ctx, args.Logger))
+
+	// Shared so a provider failing for one bot is skipped by all of them
+	providers := loadModelProviders(ctx, args)
+
+	bots := &Bots{logger: args.Logger}
+	for _, config := range args.Bots {
+		bots.bots = append(bots.bots, connectBot(ctx, args, providers, config, maintenance))
+	}
+	return bots
+}
+
+// find returns the bot with the given ID.
+func (b *Bots) find(id string) (*Telegram, bool) {
+	for _, bot := range b.bots {
+		if bot.id == id {
+			return bot, true
+		}
+	}
+	return nil, false
+}
+
+type botUpdate struct {
+	bot    *Telegram
+	update tgbotapi.Update
+}
+
+// Listen multiplexes every bot's update stream until ctx is cancelled.
+func (b *Bots) Listen(ctx context.Context) {
+	tracer := otel.Tracer("telegram/Listen")
+	ctx, span := tracer.Start(ctx, "Listen")
+	defer span.End()
+
+	workers := semaphore.NewWeighted(maxUpdateWorkers)
+	span.SetAttributes(attribute.Int("maxWorkers", maxUpdateWorkers))
+
+	updates := make(chan botUpdate)
+	dispatchers := map[*Telegram]*dispatcher{}
+	var receivers sync.WaitGroup
+	for _, bot := range b.bots {
+		// Chats are only ordered within a bot; the same user on two bots is two chats
+		dispatcher := newPooledDispatcher(workers, bot.handleUpdate)
+		dispatchers[bot] = dispatcher
+		bot.dispatch = func(update tgbotapi.Update) { dispatcher.Dispatch(ctx, update) }
+		receivers.Add(1)
+		go func() {
+			defer receivers.Done()
+			bot.receiveUpdates(ctx, updates)
+		}()
+	}
+
+	b.logger.Logger(ctx).Info("Starting Telegram bot message listener", zap.Int("bots", len(b.bots)))
+
+	for {
+		select {
+		case <-ctx.Done():
+			b.logger.Logger(ctx).Info("Shutting down Telegram bot listener")
+			receivers.Wait()
+			for _, dispatcher := range dispatchers {
+				dispatcher.Wait()
+			}
+			return
+		case u := <-updates:
+			// Pre-checkout queries must be answered within 10 seconds, so they
+			// don't wait behind a slow reply in the same chat
+			if u.update.PreCheckoutQuery != nil {
+				go u.bot.handleUpdate(ctx, u.update)
+				continue
+			}
+			dispatchers[u.bot].Dispatch(ctx, u.update)
+		}
+	}
+}
+
+// receiveUpdates long-polls this bot's updates into out until ctx is cancelled.
+func (t *Telegram) receiveUpdates(ctx context.Context, out chan<- botUpdate) {
+	u := tgbotapi.NewUpdate(0)
+	u.Timeout = 60
+
+	updates := t.bot.GetUpdatesChan(u)
+	defer t.bot.StopReceivingUpdates()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case update := <-updates:
+			select {
+			case out <- botUpdate{bot: t, update: update}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
+}
+
+// RunBackgroundJobs starts every bot's schedulers. Each bot keeps its own
+// users and Stars balance, so the jobs run once per bot.
+func (b *Bots) RunBackgroundJobs(ctx context.Context) {
+	// Personas are shared by every bot
+	go watchPersonas(ctx, b.logger)
+	// So is canned audio, so one bot warms it up for all of them
+	if len(b.bots) > 0 {
+		go b.bots[0].warmCannedAudio(ctx)
+	}
+	for _, bot := range b.bots {
+		go bot.RunReengagementScheduler(ctx)
+		go bot.RunStarsReconciliation(ctx)
+		go bot.RunPaidMediaSync(ctx)
+		go bot.RunCreditExpiry(ctx)
+		go bot.RunGreetingScheduler(ctx)
+	}
+}
@@ -0,0 +1,44 @@
+package telegram
+
+import "testing"
+
+func TestParseBotConfigs(t *testing.T) {
+	configs, err := parseBotConfigs(`[{"token": "a"}, {"id": "simran", "token": "b", "persona": "simran"}]`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(configs) != 2 || configs[1].ID != "simran" || configs[1].Persona != "simran" {
+		t.Fatalf("unexpected configs: %+v", configs)
+	}
+	if configs[0].Schema() != "" || configs[1].Schema() != "bot_simran" {
+		t.Errorf("unexpected schemas %q, %q", configs[0].Schema(), configs[1].Schema())
+	}
+
+	invalid := []string{
+		`not json`,
+		`[]`,
+		`[{"id": "gulabo"}]`,
+		`[{"id": "Gulabo; DROP", "token": "a"}]`,
+		`[{"id": "x", "token": "a"}, {"id": "x", "token": "b"}]`,
+		`[{"token": "a"}, {"token": "b"}]`,
+		`[{"token": "a", "persona": "nobody"}]`,
+	}
+	for _, raw := range invalid {
+		if _, err := parseBotConfigs(raw); err == nil {
+			t.Errorf("expected %s to be rejected", raw)
+		}
+	}
+}
+
+func TestPinnedPersona(t *testing.T) {
+	bot := &Telegram{persona: "simran"}
+	options := bot.personaOptions()
+	if len(options) != 1 || options[0].ID != "simran" {
+		t.Fatalf("pinned bot should only offer simran, got %+v", options)
+	}
+
+	unpinned := &Telegram{}
+	if len(unpinned.personaOptions()) != len(personas) {
+		t.Errorf("unpinned bot should offer every persona")
+	}
+}
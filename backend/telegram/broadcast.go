@@ -0,0 +1,198 @@
+package telegram
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"gulabodev/database/postgres"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+)
+
+const (
+	// Telegram allows roughly 30 messages per second across all chats
+	broadcastInterval = 50 * time.Millisecond
+
+	broadcastOptOutPayload = "broadcast_opt_out"
+
+	deliveryStatusSent       = "sent"
+	deliveryStatusFailed     = "failed"
+	deliveryStatusSuppressed = "suppressed"
+)
+
+// handleBroadcastCommand sends "/broadcast <text>" to all active users. Replying
+// to a voice note with /broadcast sends that voice note, using the text as caption.
+func (t *Telegram) handleBroadcastCommand(ctx context.Context, message *tgbotapi.Message) {
+	tracer := otel.Tracer("telegram/handleBroadcastCommand")
+	ctx, span := tracer.Start(ctx, "handleBroadcastCommand")
+	defer span.End()
+
+	text := strings.TrimSpace(message.CommandArguments())
+	var voiceFileID sql.NullString
+	if message.ReplyToMessage != nil && message.ReplyToMessage.Voice != nil {
+		voiceFileID = sql.NullString{Valid: true, String: message.ReplyToMessage.Voice.FileID}
+	}
+
+	if text == "" && !voiceFileID.Valid {
+		msg := tgbotapi.NewMessage(message.Chat.ID, "Usage: /broadcast <text>, or reply to a voice note with /broadcast [caption]")
+		t.bot.Send(msg)
+		return
+	}
+
+	broadcast, err := t.db.CreateBroadcast(ctx, postgres.CreateBroadcastParams{
+		AdminTelegramUserID: message.From.ID,
+		Text:                text,
+		VoiceFileID:         voiceFileID,
+	})
+	if err != nil {
+		span.RecordError(err)
+		t.logger.Logger(ctx).Error("Failed to create broadcast", zap.Error(err))
+		msg := tgbotapi.NewMessage(message.Chat.ID, "Failed to create broadcast.")
+		t.bot.Send(msg)
+		return
+	}
+
+	recipients, err := t.db.ListBroadcastRecipients(ctx)
+	if err != nil {
+		span.RecordError(err)
+		t.logger.Logger(ctx).Error("Failed to list broadcast recipients", zap.Error(err))
+		msg := tgbotapi.NewMessage(message.Chat.ID, "Failed to list broadcast recipients.")
+		t.bot.Send(msg)
+		return
+	}
+
+	span.SetAttributes(
+		attribute.Int64("broadcast.id", broadcast.ID),
+		attribute.Int("broadcast.recipients", len(recipients)),
+	)
+
+	msg := tgbotapi.NewMessage(message.Chat.ID, fmt.Sprintf("Broadcast #%d queued for %d users.", broadcast.ID, len(recipients)))
+	t.bot.Send(msg)
+
+	// Fan out in the background so the update loop isn't blocked
+	go t.deliverBroadcast(ctx, message.Chat.ID, broadcast, recipients)
+}
+
+func (t *Telegram) deliverBroadcast(ctx context.Context, adminChatID int64, broadcast postgres.Broadcast, recipients []int64) {
+	tracer := otel.Tracer("telegram/deliverBroadcast")
+	ctx, span := tracer.Start(ctx, "deliverBroadcast")
+	defer span.End()
+
+	ticker := time.NewTicker(broadcastInterval)
+	defer ticker.Stop()
+
+	for _, userID := range recipients {
+		if t.inQuietHours(ctx, userID) {
+			err := t.db.CreateBroadcastDelivery(ctx, postgres.CreateBroadcastDeliveryParams{
+				BroadcastID:    broadcast.ID,
+				TelegramUserID: userID,
+				Status:         deliveryStatusSuppressed,
+			})
+			if err != nil {
+				t.logger.Logger(ctx).Error("Failed to record broadcast delivery", zap.Error(err), zap.Int64("broadcast_id", broadcast.ID))
+			}
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		optOutMarkup := tgbotapi.NewInlineKeyboardMarkup(
+			tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData(t.text(ctx, userID, msgButtonStopAnnouncements), broadcastOptOutPayload),
+			),
+		)
+
+		var chattable tgbotapi.Chattable
+		if broadcast.VoiceFileID.Valid {
+			voice := tgbotapi.NewVoice(userID, tgbotapi.FileID(broadcast.VoiceFileID.String))
+			voice.Caption = broadcast.Text
+			voice.ReplyMarkup = optOutMarkup
+			chattable = voice
+		} else {
+			msg := tgbotapi.NewMessage(userID, broadcast.Text)
+			msg.ReplyMarkup = optOutMarkup
+			chattable = msg
+		}
+
+		status := deliveryStatusSent
+		var deliveryError sql.NullString
+		if _, err := t.bot.Send(chattable); err != nil {
+			status = deliveryStatusFailed
+			deliveryError = sql.NullString{Valid: true, String: err.Error()}
+		}
+
+		err := t.db.CreateBroadcastDelivery(ctx, postgres.CreateBroadcastDeliveryParams{
+			BroadcastID:    broadcast.ID,
+			TelegramUserID: userID,
+			Status:         status,
+			Error:          deliveryError,
+		})
+		if err != nil {
+			t.logger.Logger(ctx).Error("Failed to record broadcast delivery", zap.Error(err), zap.Int64("broadcast_id", broadcast.ID))
+		}
+	}
+
+	if err := t.db.CompleteBroadcast(ctx, broadcast.ID); err != nil {
+		t.logger.Logger(ctx).Error("Failed to mark broadcast complete", zap.Error(err), zap.Int64("broadcast_id", broadcast.ID))
+	}
+
+	stats, err := t.db.GetBroadcastDeliveryStats(ctx, broadcast.ID)
+	if err != nil {
+		t.logger.Logger(ctx).Error("Failed to get broadcast stats", zap.Error(err), zap.Int64("broadcast_id", broadcast.ID))
+		return
+	}
+
+	t.logger.Logger(ctx).Info("Broadcast complete",
+		zap.Int64("broadcast_id", broadcast.ID),
+		zap.Int64("sent", stats.Sent),
+		zap.Int64("failed", stats.Failed),
+	)
+
+	msg := tgbotapi.NewMessage(adminChatID, fmt.Sprintf("Broadcast #%d complete: %d sent, %d failed.", broadcast.ID, stats.Sent, stats.Failed))
+	t.bot.Send(msg)
+}
+
+func (t *Telegram) setBroadcastOptOut(ctx context.Context, chatID int64, userID int64, optOut bool) {
+	_, err := t.db.SetBroadcastOptOutByTelegramUserId(ctx, postgres.SetBroadcastOptOutByTelegramUserIdParams{
+		TelegramUserID:  userID,
+		BroadcastOptOut: optOut,
+	})
+
+	var responseText string
+	switch {
+	case err != nil:
+		t.logger.Logger(ctx).Error("Failed to update broadcast opt-out", zap.Error(err), zap.Int64("user_id", userID))
+		responseText = t.text(ctx, userID, msgSomethingWrong)
+	case optOut:
+		responseText = t.text(ctx, userID, msgAnnouncementsOff)
+	default:
+		responseText = t.text(ctx, userID, msgAnnouncementsOn)
+	}
+
+	msg := tgbotapi.NewMessage(chatID, responseText)
+	if _, err := t.bot.Send(msg); err != nil {
+		t.logger.Logger(ctx).Error("Failed to send opt-out confirmation", zap.Error(err))
+	}
+}
+
+// handleAnnouncementsCommand toggles the user's broadcast opt-out.
+func (t *Telegram) handleAnnouncementsCommand(ctx context.Context, message *tgbotapi.Message) {
+	optOut := false
+	preferences, err := t.db.GetUserPreferencesByTelegramUserId(ctx, message.From.ID)
+	if err == nil {
+		optOut = preferences.BroadcastOptOut
+	} else if err != sql.ErrNoRows {
+		t.logger.Logger(ctx).Error("Failed to get user preferences", zap.Error(err), zap.Int64("user_id", message.From.ID))
+	}
+
+	t.setBroadcastOptOut(ctx, message.Chat.ID, message.From.ID, !optOut)
+}
@@ -0,0 +1,50 @@
+package telegram
+
+import (
+	"fmt"
+	"gulabodev/database/postgres"
+	"regexp"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	// Referral deep links are attributed to this campaign
+	campaignReferral = "referral"
+
+	campaignStatsLimit = 10
+)
+
+// Telegram only allows A-Z, a-z, 0-9, _ and - in start payloads, up to 64 chars.
+var campaignCodePattern = regexp.MustCompile(`^[a-z0-9_-]{1,64}$`)
+
+// campaignFromMessage returns the campaign code from a "/start <code>" deep
+// link, e.g. t.me/bot?start=insta_reel_diwali. Codes are case-insensitive;
+// anything that isn't a valid start payload is ignored.
+func campaignFromMessage(message *tgbotapi.Message) string {
+	if message.Command() != "start" {
+		return ""
+	}
+	payload := strings.ToLower(strings.TrimSpace(message.CommandArguments()))
+	if strings.HasPrefix(payload, referralStartPrefix) {
+		return campaignReferral
+	}
+	if !campaignCodePattern.MatchString(payload) {
+		return ""
+	}
+	return payload
+}
+
+func formatCampaignStats(campaigns []postgres.ListCampaignStatsRow) string {
+	if len(campaigns) == 0 {
+		return "No campaign signups yet"
+	}
+
+	var b strings.Builder
+	for _, campaign := range campaigns {
+		rate := float64(campaign.Conversions) / float64(campaign.Signups) * 100
+		fmt.Fprintf(&b, "%s: %d signups, %d paid (%.1f%%)\n", campaign.Campaign.String, campaign.Signups, campaign.Conversions, rate)
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
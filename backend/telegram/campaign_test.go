@@ -0,0 +1,57 @@
+package telegram
+
+import (
+	"database/sql"
+	"gulabodev/database/postgres"
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+func startMessage(text string) *tgbotapi.Message {
+	return &tgbotapi.Message{
+		Text:     text,
+		Entities: []tgbotapi.MessageEntity{{Type: "bot_command", Offset: 0, Length: len("/start")}},
+	}
+}
+
+func TestCampaignFromMessage(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"/start", ""},
+		{"/start Insta_Reel-Diwali", "insta_reel-diwali"},
+		{"/start ref_ABC123", campaignReferral},
+		{"/start not a code", ""},
+		{"/start utm.source", ""},
+	}
+	for _, tt := range tests {
+		if got := campaignFromMessage(startMessage(tt.text)); got != tt.want {
+			t.Errorf("campaignFromMessage(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+
+	help := &tgbotapi.Message{
+		Text:     "/help insta",
+		Entities: []tgbotapi.MessageEntity{{Type: "bot_command", Offset: 0, Length: len("/help")}},
+	}
+	if got := campaignFromMessage(help); got != "" {
+		t.Errorf("non-start command attributed to %q", got)
+	}
+}
+
+func TestFormatCampaignStats(t *testing.T) {
+	if got := formatCampaignStats(nil); got != "No campaign signups yet" {
+		t.Errorf("empty stats: got %q", got)
+	}
+
+	got := formatCampaignStats([]postgres.ListCampaignStatsRow{
+		{Campaign: sql.NullString{Valid: true, String: "insta"}, Signups: 200, Conversions: 15},
+		{Campaign: sql.NullString{Valid: true, String: campaignReferral}, Signups: 40, Conversions: 0},
+	})
+	want := "insta: 200 signups, 15 paid (7.5%)\nreferral: 40 signups, 0 paid (0.0%)"
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,213 @@
+package telegram
+
+import (
+	"context"
+	"gulabodev/database/postgres"
+	"gulabodev/logger"
+	"gulabodev/modelapi"
+	"os"
+	"strconv"
+	"sync"
+	"sync/atomic"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+	"golang.org/x/sync/errgroup"
+)
+
+// Kept low so warming up doesn't trip a provider's breaker or quota for the
+// users chatting meanwhile
+const maxParallelWarmups = 2
+
+// cannedMessages are the fixed lines sent often enough to be worth having as
+// voice notes in every voice before anyone asks: the paywall and the apology
+// when a reply fails. Persona greetings are warmed up alongside them.
+var cannedMessages = []messageKey{msgOutOfCredits, msgSomethingWrong}
+
+// cannedLine is one fixed line as spoken by one voice.
+type cannedLine struct {
+	voice    ttsVoice
+	language string
+	text     string
+}
+
+// cannedAudio holds voice notes synthesized ahead of time for fixed lines, so
+// sending them costs no TTS call. Unlike the TTS cache nothing is evicted.
+// It's built once per process and shared by every bot; a nil cannedAudio
+// holds nothing.
+type cannedAudio struct {
+	mu    sync.RWMutex
+	notes map[cannedLine]voiceNote
+}
+
+func newCannedAudio() *cannedAudio {
+	return &cannedAudio{notes: map[cannedLine]voiceNote{}}
+}
+
+func (c *cannedAudio) get(line cannedLine) (voiceNote, bool) {
+	if c == nil {
+		return voiceNote{}, false
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	note, ok := c.notes[line]
+	return note, ok
+}
+
+func (c *cannedAudio) put(line cannedLine, note voiceNote) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.notes[line] = note
+}
+
+// loadCannedAudio reads CANNED_AUDIO, on unless set to false. Turning it off
+// saves the warm-up's TTS calls at startup, and the lines go out as text.
+func loadCannedAudio(ctx context.Context, logger *logger.LogMiddleware) *cannedAudio {
+	raw := os.Getenv("CANNED_AUDIO")
+	if raw == "" {
+		return newCannedAudio()
+	}
+	enabled, err := strconv.ParseBool(raw)
+	if err != nil {
+		logger.Logger(ctx).Error("Invalid CANNED_AUDIO, ignoring", zap.String("value", raw))
+		return newCannedAudio()
+	}
+	if !enabled {
+		return nil
+	}
+	return newCannedAudio()
+}
+
+// cannedLines lists every line worth warming up: each canned message in each
+// language, and each persona's greeting, spoken by every voice a user could
+// hear it in.
+func cannedLines(options []persona) []cannedLine {
+	voices := append([]ttsVoice{}, ttsVoices...)
+	for _, p := range options {
+		voices = append(voices, personaVoice(p))
+	}
+
+	seen := map[cannedLine]bool{}
+	var lines []cannedLine
+	add := func(line cannedLine) {
+		if !seen[line] {
+			seen[line] = true
+			lines = append(lines, line)
+		}
+	}
+	for _, language := range replyLanguages {
+		for _, voice := range voices {
+			voice = speakingVoice(voice, language)
+			for _, key := range cannedMessages {
+				add(cannedLine{voice: voice, language: language.TTSLanguage, text: localize(language.UI, key)})
+			}
+			for _, p := range options {
+				add(cannedLine{voice: voice, language: language.TTSLanguage, text: p.Greeting})
+			}
+		}
+	}
+	return lines
+}
+
+// warmCannedAudio synthesizes the canned lines not stored yet in their voice's
+// own provider, with no mood and at a normal pace. A line that fails is
+// left out and goes out as text.
+func (t *Telegram) warmCannedAudio(ctx context.Context) {
+	if t.canned == nil {
+		return
+	}
+
+	tracer := otel.Tracer("telegram/warmCannedAudio")
+	ctx, span := tracer.Start(ctx, "warmCannedAudio")
+	defer span.End()
+
+	rate, pitch := findSpeechPreset(speechRates, speechNormal), findSpeechPreset(speechPitches, speechNormal)
+	lines := cannedLines(currentPersonas())
+	var warmed, failed atomic.Int64
+	var group errgroup.Group
+	group.SetLimit(maxParallelWarmups)
+	for _, line := range lines {
+		if _, ok := t.canned.get(line); ok {
+			continue
+		}
+		group.Go(func() error {
+			speech, err := t.tts[line.voice.Provider].Synthesize(ctx, modelapi.SpeechRequest{
+				Text:     line.text,
+				Voice:    line.voice.VoiceID,
+				Language: line.language,
+				Rate:     rate.Value,
+				Pitch:    pitch.Value,
+			})
+			if err != nil {
+				failed.Add(1)
+				t.logger.Logger(ctx).Warn("Failed to warm up canned audio", zap.Error(err), zap.String("voice", line.voice.ID), t.logger.Content("text", line.text))
+				return nil
+			}
+			t.canned.put(line, t.encodeVoiceNote(ctx, speech, rate, pitch))
+			warmed.Add(1)
+			return nil
+		})
+	}
+	group.Wait()
+
+	span.SetAttributes(
+		attribute.Int("lines", len(lines)),
+		attribute.Int64("warmed", warmed.Load()),
+		attribute.Int64("failed", failed.Load()),
+	)
+	t.logger.Logger(ctx).Info("Warmed up canned audio", zap.Int("lines", len(lines)), zap.Int64("warmed", warmed.Load()), zap.Int64("failed", failed.Load()))
+}
+
+// sendCannedVoice sends text as its warmed-up voice note, captioned with the
+// text, if the user gets voice replies and the line is stored for the voice
+// they'd hear. It reports whether it did; if not, the caller sends the text
+// as usual. The note is at a normal pace whatever the user's settings.
+func (t *Telegram) sendCannedVoice(ctx context.Context, chatID int64, userID int64, text string, markup any) bool {
+	if t.canned == nil || t.prefersTextReplies(ctx, userID) {
+		return false
+	}
+	conversation, err := t.activeConversation(ctx, userID)
+	if err != nil {
+		t.logger.Logger(ctx).Error("Failed to get conversation", zap.Error(err), zap.Int64("user_id", userID))
+		return false
+	}
+	note, ok := t.cannedNote(ctx, conversation, text)
+	if !ok {
+		return false
+	}
+
+	voice := tgbotapi.NewVoice(chatID, tgbotapi.FileBytes{
+		Name:  note.fileName,
+		Bytes: note.audio,
+	})
+	voice.Duration = note.duration
+	voice.Caption = text
+	voice.ReplyMarkup = markup
+	if _, err := t.bot.Send(voice); err != nil {
+		t.logger.Logger(ctx).Error("Failed to send canned voice note", zap.Error(err), zap.Int64("chat_id", chatID))
+		return false
+	}
+	return true
+}
+
+// cannedNote looks up text as spoken in the conversation's voice and the
+// user's language.
+func (t *Telegram) cannedNote(ctx context.Context, conversation postgres.Conversation, text string) (voiceNote, bool) {
+	language := t.userLanguage(ctx, conversation.TelegramUserID)
+	return t.canned.get(cannedLine{
+		voice:    speakingVoice(t.replyVoice(ctx, conversation), language),
+		language: language.TTSLanguage,
+		text:     text,
+	})
+}
+
+// sendApology tells the user something went wrong, in her voice when the
+// apology is warmed up.
+func (t *Telegram) sendApology(ctx context.Context, chatID int64, userID int64) {
+	text := t.text(ctx, userID, msgSomethingWrong)
+	if !t.sendCannedVoice(ctx, chatID, userID, text, nil) {
+		t.replyText(ctx, chatID, text)
+	}
+}
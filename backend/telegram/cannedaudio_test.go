@@ -0,0 +1,134 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"gulabodev/logger"
+	"gulabodev/modelapi"
+	"gulabodev/modelapi/fakeapi"
+	"testing"
+)
+
+func TestCannedLines(t *testing.T) {
+	lines := cannedLines(personas)
+
+	seen := map[cannedLine]bool{}
+	for _, line := range lines {
+		if seen[line] {
+			t.Errorf("line %+v is listed twice", line)
+		}
+		seen[line] = true
+	}
+
+	// Every voice and language a user can pick has the paywall and every greeting
+	for _, language := range replyLanguages {
+		for _, voice := range ttsVoices {
+			spoken := speakingVoice(voice, language)
+			texts := []string{localize(language.UI, msgOutOfCredits), localize(language.UI, msgSomethingWrong)}
+			for _, p := range personas {
+				texts = append(texts, p.Greeting)
+			}
+			for _, text := range texts {
+				if !seen[cannedLine{voice: spoken, language: language.TTSLanguage, text: text}] {
+					t.Errorf("no %s line %q for %s", language.ID, text, voice.ID)
+				}
+			}
+		}
+	}
+}
+
+func TestCannedLinesUsePersonaVoices(t *testing.T) {
+	custom := personas[0]
+	custom.VoiceID = "custom-voice"
+	lines := cannedLines([]persona{custom})
+
+	for _, line := range lines {
+		if line.voice.VoiceID == "custom-voice" && line.text == custom.Greeting {
+			return
+		}
+	}
+	t.Errorf("greeting not warmed up in the persona's own voice")
+}
+
+func TestCannedLinesGurmukhi(t *testing.T) {
+	gurmukhi := findLanguage("punjabi_gurmukhi")
+	text := localize(gurmukhi.UI, msgOutOfCredits)
+	seen := map[cannedLine]bool{}
+	for _, line := range cannedLines(personas) {
+		seen[line] = true
+	}
+	// Gurmukhi users hear the fallback voice, so that's the one warmed up
+	for _, voice := range ttsVoices {
+		spoken := speakingVoice(voice, gurmukhi)
+		if !readsGurmukhi(spoken.Provider) {
+			t.Errorf("%s speaks Gurmukhi with %s", voice.ID, spoken.ID)
+		}
+		if !seen[cannedLine{voice: spoken, language: gurmukhi.TTSLanguage, text: text}] {
+			t.Errorf("no Gurmukhi paywall line for %s", voice.ID)
+		}
+	}
+}
+
+func TestCannedAudio(t *testing.T) {
+	line := cannedLine{voice: ttsVoices[0], language: "hi", text: "hi"}
+
+	var disabled *cannedAudio
+	if _, ok := disabled.get(line); ok {
+		t.Errorf("nil cannedAudio found a line")
+	}
+
+	canned := newCannedAudio()
+	if _, ok := canned.get(line); ok {
+		t.Errorf("empty cannedAudio found a line")
+	}
+	canned.put(line, voiceNote{fileName: "note.ogg", duration: 2})
+	note, ok := canned.get(line)
+	if !ok || note.duration != 2 {
+		t.Errorf("get = %+v, %v, want the stored note", note, ok)
+	}
+
+	other := line
+	other.voice = ttsVoices[1]
+	if _, ok := canned.get(other); ok {
+		t.Errorf("line found in another voice")
+	}
+}
+
+func TestWarmCannedAudio(t *testing.T) {
+	tts := map[string]modelapi.TTSProvider{}
+	fakes := map[string]*fakeapi.TTS{}
+	for _, voice := range ttsVoices {
+		if fakes[voice.Provider] == nil {
+			fakes[voice.Provider] = fakeapi.NewTTS(voice.Provider)
+			tts[voice.Provider] = fakes[voice.Provider]
+		}
+	}
+	fakes[ttsProviderAzure].Fail(errors.New("503"), -1)
+
+	bot := &Telegram{
+		logger: logger.Connect(logger.LoggerConnectProps{}),
+		tts:    tts,
+		canned: newCannedAudio(),
+	}
+	bot.warmCannedAudio(context.Background())
+
+	for _, line := range cannedLines(currentPersonas()) {
+		_, ok := bot.canned.get(line)
+		if failing := line.voice.Provider == ttsProviderAzure; ok == failing {
+			t.Errorf("%s line %q stored = %v", line.voice.ID, line.text, ok)
+		}
+	}
+
+	// A second warm-up only retries what failed
+	calls := fakes[ttsProviderOpenAI].Calls()
+	fakes[ttsProviderAzure].Fail(nil, 0)
+	bot.warmCannedAudio(context.Background())
+	if fakes[ttsProviderOpenAI].Calls() != calls {
+		t.Errorf("warmed OpenAI lines again")
+	}
+	for _, line := range cannedLines(currentPersonas()) {
+		if _, ok := bot.canned.get(line); !ok {
+			t.Errorf("%s line %q missing after the retry", line.voice.ID, line.text)
+		}
+	}
+}
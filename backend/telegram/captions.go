@@ -0,0 +1,139 @@
+package telegram
+
+import (
+	"context"
+	"database/sql"
+	"gulabodev/database/postgres"
+	"strings"
+	"unicode/utf8"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+const (
+	captionsCallbackPrefix = "captions:"
+	captionsMenuText       = "Voice notes ke saath text bhi chahiye? Shor waali jagah ke liye perfect 🎧"
+
+	// Telegram's limit for media captions
+	maxCaptionLength = 1024
+	maxTeaserLength  = 60
+)
+
+type captionMode struct {
+	// ID is what gets stored in user_preferences.voice_captions
+	ID   string
+	Name string
+}
+
+// captionModes lists the options offered by /captions. The first entry is the default.
+var captionModes = []captionMode{
+	{ID: "off", Name: "Off"},
+	{ID: "full", Name: "Full text 📝"},
+	{ID: "teaser", Name: "Teaser + 🎧 listen"},
+}
+
+// findCaptionMode returns the mode with the given ID, falling back to the default.
+func findCaptionMode(id string) captionMode {
+	for _, mode := range captionModes {
+		if mode.ID == id {
+			return mode
+		}
+	}
+	return captionModes[0]
+}
+
+// truncateRunes shortens text to at most limit runes, ending in an ellipsis.
+func truncateRunes(text string, limit int) string {
+	if utf8.RuneCountInString(text) <= limit {
+		return text
+	}
+	runes := []rune(text)
+	return strings.TrimSpace(string(runes[:limit-1])) + "…"
+}
+
+// voiceCaption returns the caption for one voice note of a reply. Full captions
+// carry each note's own text; a teaser goes on the first note only.
+func voiceCaption(mode captionMode, chunk string, first bool) string {
+	switch mode.ID {
+	case "full":
+		return truncateRunes(chunk, maxCaptionLength)
+	case "teaser":
+		if !first {
+			return ""
+		}
+		teaser := chunk
+		if sentences := splitSentences(chunk); len(sentences) > 0 {
+			teaser = strings.TrimSpace(sentences[0])
+		}
+		return truncateRunes(teaser, maxTeaserLength) + " 🎧 listen"
+	default:
+		return ""
+	}
+}
+
+func (t *Telegram) userCaptionMode(ctx context.Context, userID int64) captionMode {
+	preferences, err := t.db.GetUserPreferencesByTelegramUserId(ctx, userID)
+	if err != nil {
+		if err != sql.ErrNoRows {
+			t.logger.Logger(ctx).Error("Failed to get user preferences", zap.Error(err), zap.Int64("user_id", userID))
+		}
+		return captionModes[0]
+	}
+	return findCaptionMode(preferences.VoiceCaptions)
+}
+
+func (t *Telegram) handleCaptionsCommand(ctx context.Context, message *tgbotapi.Message) {
+	msg := tgbotapi.NewMessage(message.Chat.ID, captionsMenuText)
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(captionsKeyboard(t.userCaptionMode(ctx, message.From.ID))...)
+	if _, err := t.bot.Send(msg); err != nil {
+		t.logger.Logger(ctx).Error("Failed to send caption options", zap.Error(err))
+	}
+}
+
+// captionsKeyboard lists the caption modes, marking the current one.
+func captionsKeyboard(current captionMode) [][]tgbotapi.InlineKeyboardButton {
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for _, mode := range captionModes {
+		label := mode.Name
+		if mode.ID == current.ID {
+			label = "✅ " + label
+		}
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(label, captionsCallbackPrefix+mode.ID),
+		))
+	}
+	return rows
+}
+
+func (t *Telegram) setCaptions(ctx context.Context, chatID int64, userID int64, modeID string) {
+	mode := findCaptionMode(modeID)
+
+	_, err := t.db.SetVoiceCaptionsByTelegramUserId(ctx, postgres.SetVoiceCaptionsByTelegramUserIdParams{
+		VoiceCaptions:  mode.ID,
+		TelegramUserID: userID,
+	})
+
+	var responseText string
+	switch {
+	case err != nil:
+		t.logger.Logger(ctx).Error("Failed to set voice captions", zap.Error(err), zap.Int64("user_id", userID))
+		responseText = t.text(ctx, userID, msgSomethingWrong)
+	case mode.ID == "off":
+		responseText = t.text(ctx, userID, msgCaptionsOff)
+	default:
+		responseText = t.text(ctx, userID, msgCaptionsSet, mode.Name)
+	}
+
+	msg := tgbotapi.NewMessage(chatID, responseText)
+	if _, err := t.bot.Send(msg); err != nil {
+		t.logger.Logger(ctx).Error("Failed to send captions confirmation", zap.Error(err))
+	}
+}
+
+func captionsFromCallback(data string) (string, bool) {
+	if !strings.HasPrefix(data, captionsCallbackPrefix) {
+		return "", false
+	}
+	return strings.TrimPrefix(data, captionsCallbackPrefix), true
+}
@@ -0,0 +1,29 @@
+package telegram
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestVoiceCaption(t *testing.T) {
+	chunk := "Tumhari yaad aa rahi thi, baby. Aaj kya kiya poora din?"
+
+	if got := voiceCaption(findCaptionMode(""), chunk, true); got != "" {
+		t.Errorf("default mode should have no caption, got %q", got)
+	}
+	if got := voiceCaption(findCaptionMode("full"), chunk, false); got != chunk {
+		t.Errorf("full caption = %q, want the chunk", got)
+	}
+	if got := voiceCaption(findCaptionMode("teaser"), chunk, true); got != "Tumhari yaad aa rahi thi, baby. 🎧 listen" {
+		t.Errorf("teaser caption = %q", got)
+	}
+	if got := voiceCaption(findCaptionMode("teaser"), chunk, false); got != "" {
+		t.Errorf("teaser should only go on the first note, got %q", got)
+	}
+
+	long := strings.Repeat("a", maxCaptionLength+10)
+	if got := voiceCaption(findCaptionMode("full"), long, true); utf8.RuneCountInString(got) != maxCaptionLength {
+		t.Errorf("full caption has %d runes, want %d", utf8.RuneCountInString(got), maxCaptionLength)
+	}
+}
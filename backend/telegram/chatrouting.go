@@ -0,0 +1,71 @@
+package telegram
+
+import (
+	"context"
+	"gulabodev/database/postgres"
+	"gulabodev/logger"
+	"gulabodev/modelapi"
+	"os"
+
+	"go.uber.org/zap"
+)
+
+// Features that LLM_ROUTES can send to a provider of their own.
+const (
+	chatFeatureReply    = "reply"
+	chatFeatureGreeting = "greeting"
+	chatFeatureSelfie   = "selfie"
+	chatFeatureMemory   = "memory"
+	chatFeatureSummary  = "summary"
+)
+
+// loadChatRouter routes chat requests with LLM_ROUTES, e.g.
+// "persona:simran=gemini,tier:premium=gemini,feature:memory=groq". Without it, or if it doesn't
+// parse, everything goes to the first provider.
+func loadChatRouter(ctx context.Context, logger *logger.LogMiddleware, providers ...modelapi.ChatProvider) *modelapi.ChatRouter {
+	router, err := modelapi.NewChatRouter(os.Getenv("LLM_ROUTES"), providers...)
+	if err == nil {
+		return router
+	}
+
+	logger.Logger(ctx).Error("Invalid LLM_ROUTES, using the default provider", zap.Error(err))
+	router, err = modelapi.NewChatRouter("", providers...)
+	if err != nil {
+		logger.Logger(ctx).Fatal("Failed to create chat router", zap.Error(err))
+	}
+	return router
+}
+
+// chatProvider is the provider that serves feature in conversation, picked
+// for the user's tier, with the model and sampling from the persona's config.
+// If it fails, the other healthy providers are tried so an outage doesn't
+// leave the user unanswered.
+func (t *Telegram) chatProvider(ctx context.Context, feature string, conversation postgres.Conversation) modelapi.ChatProvider {
+	route := modelapi.ChatRoute{
+		Feature: feature,
+		Persona: conversation.Persona,
+		UserID:  conversation.TelegramUserID,
+		Tier:    contextTier(ctx),
+	}
+	// Custom personas have no config of their own
+	p := findPersona(conversation.Persona)
+	if p.ID != conversation.Persona {
+		return t.chat.Fallback(route)
+	}
+
+	route.Provider = p.Model
+	provider := t.chat.Fallback(route)
+	if sampling := personaSampling(p); sampling != (modelapi.Sampling{}) {
+		provider = modelapi.WithSampling(provider, sampling)
+	}
+	return provider
+}
+
+// personaSampling is how the persona's replies are sampled.
+func personaSampling(p persona) modelapi.Sampling {
+	return modelapi.Sampling{
+		Temperature: p.Temperature,
+		TopP:        p.TopP,
+		MaxTokens:   p.MaxTokens,
+	}
+}
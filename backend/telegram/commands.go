@@ -0,0 +1,245 @@
+package telegram
+
+import (
+	"context"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+)
+
+// commandHandler runs a command. Handlers are methods on *Telegram, so most
+// are registered as method expressions like (*Telegram).handleModeCommand.
+type commandHandler func(t *Telegram, ctx context.Context, message *tgbotapi.Message)
+
+type commandAccess int
+
+const (
+	accessEveryone commandAccess = iota
+	// Admin commands are ignored for everyone else, so they stay invisible
+	accessAdmin
+	// Dev commands aren't registered at all in production
+	accessDev
+)
+
+type botCommand struct {
+	Name string
+	// Description lists the command in Telegram's menu; commands without one
+	// still work but stay hidden
+	Description string
+	Access      commandAccess
+	// Cost is the credits a run spends. Users who can't pay get the recharge
+	// options instead; the handler charges once it has delivered.
+	Cost    int
+	Handler commandHandler
+}
+
+// commandRegistry lists every command, in the order shown in Telegram's menu.
+func commandRegistry(production bool) []botCommand {
+	commands := []botCommand{
+		{Name: "start", Handler: (*Telegram).handleStartCommand},
+		{Name: "help", Description: "Show help and available commands", Handler: (*Telegram).handleHelpCommand},
+		{Name: "recharge", Description: "Recharge your credits", Handler: (*Telegram).handleRechargeCommand},
+		{Name: "credits", Description: "Check your credit balance", Handler: (*Telegram).handleCreditsCommand},
+		{Name: "autorecharge", Description: "Top up automatically when credits run out", Handler: (*Telegram).handleAutoRechargeCommand},
+		{Name: "subscription", Description: "Unlimited monthly plan", Handler: (*Telegram).handleSubscriptionCommand},
+		{Name: "daily", Description: "Claim your free daily credits", Handler: (*Telegram).handleDailyCommand},
+		{Name: "redeem", Description: "Redeem a promo code for free credits", Handler: (*Telegram).handleRedeemCommand},
+		{Name: "refer", Description: "Invite friends and earn free credits", Handler: (*Telegram).handleReferCommand},
+		{Name: "announcements", Description: "Turn announcements on or off", Handler: (*Telegram).handleAnnouncementsCommand},
+		{Name: "reminders", Description: "Let Gulabo text you first, or stop it", Handler: (*Telegram).handleRemindersCommand},
+		{Name: "dnd", Description: "Set quiet hours for messages from Gulabo", Handler: (*Telegram).handleDndCommand},
+		{Name: "greetings", Description: "Get good-morning and good-night voice notes", Handler: (*Telegram).handleGreetingsCommand},
+		{Name: "mode", Description: "Switch between voice and text replies", Handler: (*Telegram).handleModeCommand},
+		{Name: "captions", Description: "Add text captions to voice notes", Handler: (*Telegram).handleCaptionsCommand},
+		{Name: "echo", Description: "Show what I heard in your voice notes", Handler: (*Telegram).handleEchoCommand},
+		{Name: "settings", Description: "All your settings in one place", Handler: (*Telegram).handleSettingsCommand},
+		{Name: "persona", Description: "Switch between Gulabo and other characters", Handler: (*Telegram).handlePersonaCommand},
+		{Name: "create", Description: "Build your own girlfriend", Handler: (*Telegram).handleCreateCommand},
+		{Name: "voice", Description: "Choose your companion's voice", Handler: (*Telegram).handleVoiceCommand},
+		{Name: "replay", Description: "Hear the last voice note again", Handler: (*Telegram).handleReplayCommand},
+		{Name: "intensity", Description: "Choose how spicy the chats get", Handler: (*Telegram).handleIntensityCommand},
+		{Name: "language", Description: "Choose reply language and script", Handler: (*Telegram).handleLanguageCommand},
+		{Name: "relationship", Description: "See how close you and Gulabo have grown", Handler: (*Telegram).handleRelationshipCommand},
+		{Name: "gifts", Description: "Buy Gulabo a virtual gift with credits", Handler: (*Telegram).handleGiftsCommand},
+		// Selfies cost several credits, so the handler checks the balance itself
+		{Name: "selfie", Description: "Get a selfie from Gulabo", Handler: (*Telegram).handleSelfieCommand},
+		{Name: "memory", Description: "See or edit what Gulabo remembers about you", Handler: (*Telegram).handleMemoryCommand},
+		// Practice checks credits itself, since "/practice stop" is free
+		{Name: "practice", Description: "Practice talking to women in a role-play scenario", Handler: (*Telegram).handlePracticeCommand},
+		{Name: "review", Description: "Get a coaching card on how the conversation is going", Cost: 1, Handler: (*Telegram).handleReviewCommand},
+		{Name: "premium", Description: "Unlock exclusive photos and videos with Stars", Handler: (*Telegram).handlePremiumCommand},
+		{Name: "export", Description: "Download our chat history", Handler: (*Telegram).handleExportCommand},
+		{Name: "feedback", Description: "Tell us what you think", Handler: (*Telegram).handleFeedbackCommand},
+		{Name: "new", Description: "Start a fresh chat, keeping the old one saved", Handler: (*Telegram).handleNewCommand},
+		{Name: "clear", Description: "Clear conversation history and wipe Gulabo's memory", Handler: (*Telegram).handleClearCommand},
+
+		{Name: "broadcast", Access: accessAdmin, Handler: (*Telegram).handleBroadcastCommand},
+		{Name: "stats", Access: accessAdmin, Handler: (*Telegram).handleStatsCommand},
+		{Name: "usage", Access: accessAdmin, Handler: (*Telegram).handleUsageCommand},
+		{Name: "abuse", Access: accessAdmin, Handler: (*Telegram).handleAbuseCommand},
+		{Name: "addpremium", Access: accessAdmin, Handler: (*Telegram).handleAddPremiumCommand},
+		{Name: "maintenance", Access: accessAdmin, Handler: (*Telegram).handleMaintenanceCommand},
+		{Name: "reloadpersonas", Access: accessAdmin, Handler: (*Telegram).handleReloadPersonasCommand},
+		{Name: "cartesiavoices", Access: accessAdmin, Handler: (*Telegram).handleCartesiaVoicesCommand},
+		{Name: "ban", Access: accessAdmin, Handler: func(t *Telegram, ctx context.Context, message *tgbotapi.Message) {
+			t.handleBanCommand(ctx, message, true)
+		}},
+		{Name: "unban", Access: accessAdmin, Handler: func(t *Telegram, ctx context.Context, message *tgbotapi.Message) {
+			t.handleBanCommand(ctx, message, false)
+		}},
+		{Name: "promo", Access: accessAdmin, Handler: (*Telegram).handlePromoCommand},
+
+		{Name: "dev_no_credits", Description: "DEV: Simulate out of credits", Access: accessDev, Handler: (*Telegram).handleDevNoCreditsCommand},
+		{Name: "dev_set_zero_credits", Description: "DEV: Set your credits to 0", Access: accessDev, Handler: (*Telegram).handleDevSetZeroCreditsCommand},
+		{Name: "dev_add_10_credits", Description: "DEV: Add 10 credits", Access: accessDev, Handler: (*Telegram).handleDevAddCreditsCommand},
+	}
+
+	if !production {
+		return commands
+	}
+	var registered []botCommand
+	for _, command := range commands {
+		if command.Access != accessDev {
+			registered = append(registered, command)
+		}
+	}
+	return registered
+}
+
+// menuCommands is what Telegram autocompletes: every command with a
+// description that isn't admin-only.
+func menuCommands(commands []botCommand) []tgbotapi.BotCommand {
+	var menu []tgbotapi.BotCommand
+	for _, command := range commands {
+		if command.Description == "" || command.Access == accessAdmin {
+			continue
+		}
+		menu = append(menu, tgbotapi.BotCommand{Command: command.Name, Description: command.Description})
+	}
+	return menu
+}
+
+// commandMiddleware wraps a command's handler. It can run code around the
+// handler or stop it from running at all.
+type commandMiddleware func(command botCommand, next commandHandler) commandHandler
+
+// commandMiddlewares run outermost first.
+var commandMiddlewares = []commandMiddleware{
+	traceCommand,
+	requireAccess,
+	requireCredits,
+}
+
+// routeCommands wraps every command in the middlewares, keyed by name.
+func routeCommands(commands []botCommand, middlewares []commandMiddleware) map[string]commandHandler {
+	routes := make(map[string]commandHandler, len(commands))
+	for _, command := range commands {
+		handler := command.Handler
+		for i := len(middlewares) - 1; i >= 0; i-- {
+			handler = middlewares[i](command, handler)
+		}
+		routes[command.Name] = handler
+	}
+	return routes
+}
+
+func traceCommand(command botCommand, next commandHandler) commandHandler {
+	return func(t *Telegram, ctx context.Context, message *tgbotapi.Message) {
+		tracer := otel.Tracer("telegram/handleCommand")
+		ctx, span := tracer.Start(ctx, "handleCommand")
+		defer span.End()
+
+		span.SetAttributes(attribute.String("command", command.Name))
+		t.logger.Logger(ctx).Info("Handling command",
+			zap.String("command", command.Name),
+			zap.Int64("user_id", message.From.ID),
+		)
+		next(t, ctx, message)
+	}
+}
+
+func requireAccess(command botCommand, next commandHandler) commandHandler {
+	if command.Access != accessAdmin {
+		return next
+	}
+	return func(t *Telegram, ctx context.Context, message *tgbotapi.Message) {
+		if !t.isAdmin(message.From.ID) {
+			return
+		}
+		next(t, ctx, message)
+	}
+}
+
+func requireCredits(command botCommand, next commandHandler) commandHandler {
+	if command.Cost == 0 {
+		return next
+	}
+	return func(t *Telegram, ctx context.Context, message *tgbotapi.Message) {
+		hasCredits, err := t.hasCredits(ctx, message.From.ID)
+		if err != nil {
+			t.logger.Logger(ctx).Error("Failed to check user credits", zap.Error(err), zap.Int64("user_id", message.From.ID))
+			return
+		}
+		if !hasCredits {
+			t.sendRechargeOptions(ctx, message.Chat.ID, message.From.ID, t.text(ctx, message.From.ID, msgOutOfCredits))
+			return
+		}
+		next(t, ctx, message)
+	}
+}
+
+func (t *Telegram) handleCommand(ctx context.Context, message *tgbotapi.Message) {
+	handler, ok := t.commands[message.Command()]
+	if !ok {
+		t.replyText(ctx, message.Chat.ID, t.text(ctx, message.From.ID, msgUnknownCommand))
+		return
+	}
+	handler(t, ctx, message)
+}
+
+// replyText sends a plain text message, logging a failed send.
+func (t *Telegram) replyText(ctx context.Context, chatID int64, text string) {
+	if _, err := t.bot.Send(tgbotapi.NewMessage(chatID, text)); err != nil {
+		t.logger.Logger(ctx).Error("Failed to send message", zap.Error(err), zap.Int64("chat_id", chatID))
+	}
+}
+
+func (t *Telegram) handleStartCommand(ctx context.Context, message *tgbotapi.Message) {
+	if !t.isOnboarded(ctx, message.From.ID) {
+		t.startOnboarding(ctx, message.Chat.ID)
+		return
+	}
+	t.handleHelpCommand(ctx, message)
+}
+
+func (t *Telegram) handleHelpCommand(ctx context.Context, message *tgbotapi.Message) {
+	t.replyText(ctx, message.Chat.ID, t.text(ctx, message.From.ID, msgHelp))
+}
+
+func (t *Telegram) handleRechargeCommand(ctx context.Context, message *tgbotapi.Message) {
+	t.sendRechargeOptions(ctx, message.Chat.ID, message.From.ID, t.text(ctx, message.From.ID, msgRechargeIntro))
+}
+
+func (t *Telegram) handleDailyCommand(ctx context.Context, message *tgbotapi.Message) {
+	t.claimDailyCredits(ctx, message.Chat.ID, message.From.ID)
+}
+
+// handleClearCommand wipes the chat with the active persona only, including
+// the sessions archived by /new.
+func (t *Telegram) handleClearCommand(ctx context.Context, message *tgbotapi.Message) {
+	conversation, err := t.activeConversation(ctx, message.From.ID)
+	if err == nil {
+		_, err = t.db.ClearConversationMessages(ctx, conversation.ID)
+	}
+	if err == nil {
+		err = t.db.DeleteConversationArchives(ctx, conversation.ID)
+	}
+	if err != nil {
+		t.logger.Logger(ctx).Error("Failed to clear conversation history", zap.Error(err), zap.Int64("user_id", message.From.ID))
+		t.replyText(ctx, message.Chat.ID, t.text(ctx, message.From.ID, msgSomethingWrong))
+		return
+	}
+	t.replyText(ctx, message.Chat.ID, t.text(ctx, message.From.ID, msgClearDone))
+}
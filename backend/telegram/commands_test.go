@@ -0,0 +1,91 @@
+package telegram
+
+import (
+	"context"
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+func TestCommandRegistryNamesUnique(t *testing.T) {
+	seen := map[string]bool{}
+	for _, command := range commandRegistry(false) {
+		if seen[command.Name] {
+			t.Errorf("command %q registered twice", command.Name)
+		}
+		seen[command.Name] = true
+		if command.Handler == nil {
+			t.Errorf("command %q has no handler", command.Name)
+		}
+	}
+}
+
+func TestMenuCommands(t *testing.T) {
+	menu := map[string]bool{}
+	for _, command := range menuCommands(commandRegistry(true)) {
+		menu[command.Command] = true
+	}
+
+	if !menu["help"] || !menu["echo"] {
+		t.Errorf("menu is missing user commands: %v", menu)
+	}
+	for _, hidden := range []string{"start", "stats", "ban", "dev_add_10_credits"} {
+		if menu[hidden] {
+			t.Errorf("menu lists %q", hidden)
+		}
+	}
+
+	for _, command := range commandRegistry(true) {
+		if command.Access == accessDev {
+			t.Errorf("dev command %q registered in production", command.Name)
+		}
+	}
+}
+
+func TestRouteCommandsAdminOnly(t *testing.T) {
+	ran := false
+	routes := routeCommands([]botCommand{{
+		Name:    "secret",
+		Access:  accessAdmin,
+		Handler: func(*Telegram, context.Context, *tgbotapi.Message) { ran = true },
+	}}, []commandMiddleware{requireAccess})
+
+	bot := &Telegram{admins: map[int64]bool{1: true}}
+	routes["secret"](bot, context.Background(), &tgbotapi.Message{From: &tgbotapi.User{ID: 2}})
+	if ran {
+		t.Error("admin command ran for a regular user")
+	}
+
+	routes["secret"](bot, context.Background(), &tgbotapi.Message{From: &tgbotapi.User{ID: 1}})
+	if !ran {
+		t.Error("admin command didn't run for an admin")
+	}
+}
+
+func TestRouteCommandsMiddlewareOrder(t *testing.T) {
+	var calls []string
+	record := func(name string) commandMiddleware {
+		return func(_ botCommand, next commandHandler) commandHandler {
+			return func(t *Telegram, ctx context.Context, message *tgbotapi.Message) {
+				calls = append(calls, name)
+				next(t, ctx, message)
+			}
+		}
+	}
+
+	routes := routeCommands([]botCommand{{
+		Name:    "ping",
+		Handler: func(*Telegram, context.Context, *tgbotapi.Message) { calls = append(calls, "handler") },
+	}}, []commandMiddleware{record("outer"), record("inner")})
+	routes["ping"](&Telegram{}, context.Background(), &tgbotapi.Message{})
+
+	want := []string{"outer", "inner", "handler"}
+	if len(calls) != len(want) {
+		t.Fatalf("calls = %v, want %v", calls, want)
+	}
+	for i := range want {
+		if calls[i] != want[i] {
+			t.Fatalf("calls = %v, want %v", calls, want)
+		}
+	}
+}
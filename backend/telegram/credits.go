@@ -0,0 +1,95 @@
+package telegram
+
+import (
+	"context"
+	"gulabodev/database/postgres"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+)
+
+const (
+	// Unused bonus credits expire this long after the user's last bonus grant.
+	// Purchased credits never expire.
+	bonusCreditLifetime  = 30 * 24 * time.Hour
+	creditExpiryInterval = time.Hour
+
+	// Average daily usage is taken over this many days
+	creditUsageDays = 14
+)
+
+// formatCreditBreakdown renders /credits: the balance split into purchased
+// and bonus credits, when the bonus runs out, and recent usage.
+func formatCreditBreakdown(ui string, breakdown postgres.GetCreditBreakdownByTelegramUserIdRow) string {
+	bonus := breakdown.CreditsBalance - breakdown.PurchasedCredits
+
+	text := localize(ui, msgCreditsBalance, breakdown.CreditsBalance) + "\n\n" +
+		localize(ui, msgCreditsPurchased, breakdown.PurchasedCredits) + "\n" +
+		localize(ui, msgCreditsBonus, bonus)
+	if bonus > 0 {
+		expires := breakdown.LastBonusGrant.Add(bonusCreditLifetime)
+		text += localize(ui, msgCreditsBonusExpiry, expires.Format("02 Jan 2006"))
+	}
+	average := float64(breakdown.RepliesSince) / creditUsageDays
+	return text + "\n" + localize(ui, msgCreditsDailyUsage, average)
+}
+
+func (t *Telegram) handleCreditsCommand(ctx context.Context, message *tgbotapi.Message) {
+	userID := message.From.ID
+	ui := t.userLanguage(ctx, userID).UI
+
+	var responseText string
+	breakdown, err := t.db.GetCreditBreakdownByTelegramUserId(ctx, postgres.GetCreditBreakdownByTelegramUserIdParams{
+		UsageSince:     time.Now().AddDate(0, 0, -creditUsageDays),
+		TelegramUserID: userID,
+	})
+	if err != nil {
+		t.logger.Logger(ctx).Error("Failed to get user credits", zap.Error(err), zap.Int64("user_id", userID))
+		responseText = localize(ui, msgCreditsUnavailable)
+	} else {
+		responseText = formatCreditBreakdown(ui, breakdown)
+	}
+
+	msg := tgbotapi.NewMessage(message.Chat.ID, responseText)
+	if _, err := t.bot.Send(msg); err != nil {
+		t.logger.Logger(ctx).Error("Failed to send credits balance message", zap.Error(err))
+	}
+}
+
+// RunCreditExpiry periodically drops bonus credits that have outlived
+// bonusCreditLifetime.
+func (t *Telegram) RunCreditExpiry(ctx context.Context) {
+	t.logger.Logger(ctx).Info("Starting bonus credit expiry", zap.Duration("lifetime", bonusCreditLifetime))
+
+	ticker := time.NewTicker(creditExpiryInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			t.expireBonusCredits(ctx)
+		}
+	}
+}
+
+func (t *Telegram) expireBonusCredits(ctx context.Context) {
+	tracer := otel.Tracer("telegram/expireBonusCredits")
+	ctx, span := tracer.Start(ctx, "expireBonusCredits")
+	defer span.End()
+
+	expired, err := t.db.ExpireBonusCredits(ctx, time.Now().Add(-bonusCreditLifetime))
+	if err != nil {
+		span.RecordError(err)
+		t.logger.Logger(ctx).Error("Failed to expire bonus credits", zap.Error(err))
+		return
+	}
+	span.SetAttributes(attribute.Int64("users", expired))
+	if expired > 0 {
+		t.logger.Logger(ctx).Info("Expired bonus credits", zap.Int64("users", expired))
+	}
+}
@@ -0,0 +1,38 @@
+package telegram
+
+import (
+	"gulabodev/database/postgres"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFormatCreditBreakdown(t *testing.T) {
+	granted := time.Date(2025, 5, 1, 10, 0, 0, 0, time.UTC)
+	text := formatCreditBreakdown(uiEnglish, postgres.GetCreditBreakdownByTelegramUserIdRow{
+		CreditsBalance:   57,
+		PurchasedCredits: 50,
+		LastBonusGrant:   granted,
+		RepliesSince:     21,
+	})
+
+	for _, want := range []string{"57 credits", "Purchased: 50", "Bonus: 7, expiring 31 May 2025", "Daily average: 1.5 messages"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("expected %q in:\n%s", want, text)
+		}
+	}
+}
+
+func TestFormatCreditBreakdownWithoutBonus(t *testing.T) {
+	text := formatCreditBreakdown(uiEnglish, postgres.GetCreditBreakdownByTelegramUserIdRow{
+		CreditsBalance:   50,
+		PurchasedCredits: 50,
+		LastBonusGrant:   time.Now(),
+	})
+	if strings.Contains(text, "expiring") {
+		t.Errorf("no bonus credits means nothing expires:\n%s", text)
+	}
+	if !strings.Contains(text, "Bonus: 0") {
+		t.Errorf("expected an empty bonus line:\n%s", text)
+	}
+}
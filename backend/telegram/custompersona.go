@@ -0,0 +1,387 @@
+package telegram
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"gulabodev/database/postgres"
+	"gulabodev/modelapi/prompts"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.opentelemetry.io/otel"
+	"go.uber.org/zap"
+)
+
+const (
+	createCallbackPrefix = "create:"
+	createStepTrait      = "trait"
+	createStepTraitsDone = "traits"
+	createStepLanguage   = "language"
+	createStepVoice      = "voice"
+
+	maxPersonaTraits = 3
+
+	// Stands in for a user message so her first words can be generated
+	customPersonaGreetingInput = "(You were just brought to life. Introduce yourself to me for the very first time, in character, and invite me to talk.)"
+)
+
+type personaTrait struct {
+	// ID is stored in custom_personas.traits and also goes into the prompt
+	ID    string
+	Name  string
+	Emoji string
+}
+
+var personaTraits = []personaTrait{
+	{ID: "playful", Name: "Playful", Emoji: "😜"},
+	{ID: "shy", Name: "Shy", Emoji: "🙈"},
+	{ID: "bold", Name: "Bold", Emoji: "😈"},
+	{ID: "caring", Name: "Caring", Emoji: "🤗"},
+	{ID: "possessive", Name: "Possessive", Emoji: "😤"},
+	{ID: "witty", Name: "Witty", Emoji: "😏"},
+	{ID: "romantic", Name: "Romantic", Emoji: "💕"},
+	{ID: "dramatic", Name: "Dramatic", Emoji: "🎭"},
+}
+
+type languageMix struct {
+	// ID is what gets stored in custom_personas.language_mix
+	ID          string
+	Name        string
+	Instruction string
+}
+
+// languageMixes lists how a custom character can talk. The first entry is the default.
+var languageMixes = []languageMix{
+	{ID: "hinglish", Name: "Hinglish 50-50", Instruction: "Speak Hinglish, mixing Hindi and English about equally, all in Latin script."},
+	{ID: "hindi", Name: "Zyada Hindi", Instruction: "Speak Hinglish that is mostly Hindi with a few English words, all in Latin script."},
+	{ID: "english", Name: "Zyada English", Instruction: "Speak mostly English with a sprinkle of Hindi words, in Latin script."},
+	{ID: "punjabi", Name: "Punjabi + English", Instruction: "Speak Punjabi mixed with English, all in Latin script. Never use Gurmukhi."},
+}
+
+func findLanguageMix(id string) languageMix {
+	for _, mix := range languageMixes {
+		if mix.ID == id {
+			return mix
+		}
+	}
+	return languageMixes[0]
+}
+
+// parseTraits reads the stored trait list, dropping any that no longer exist.
+func parseTraits(stored string) []string {
+	var traits []string
+	for _, id := range strings.Split(stored, ",") {
+		for _, trait := range personaTraits {
+			if trait.ID == id {
+				traits = append(traits, id)
+			}
+		}
+	}
+	return traits
+}
+
+// toggleTrait adds or removes a trait, up to maxPersonaTraits.
+func toggleTrait(traits []string, id string) []string {
+	for i, selected := range traits {
+		if selected == id {
+			return append(traits[:i:i], traits[i+1:]...)
+		}
+	}
+	if len(traits) >= maxPersonaTraits || len(parseTraits(id)) == 0 {
+		return traits
+	}
+	return append(traits, id)
+}
+
+// describeTraits joins traits the way they're said: "playful, witty and shy".
+func describeTraits(traits []string) string {
+	switch len(traits) {
+	case 0:
+		return "sweet"
+	case 1:
+		return traits[0]
+	default:
+		return strings.Join(traits[:len(traits)-1], ", ") + " and " + traits[len(traits)-1]
+	}
+}
+
+// buildCustomPersona turns a finished /create record into a persona.
+func buildCustomPersona(record postgres.CustomPersona) persona {
+	return persona{
+		ID:       customPersonaID,
+		Name:     record.Name,
+		Emoji:    "💫",
+		PromptID: prompts.PersonaCustom,
+		PromptData: prompts.CustomPersonaData{
+			Name:   record.Name,
+			Traits: describeTraits(parseTraits(record.Traits)),
+			City:   record.City,
+		},
+		Language:     findLanguageMix(record.LanguageMix).Instruction,
+		DefaultVoice: record.Voice,
+		Greeting:     fmt.Sprintf("Aa gaye? %s kab se tumhara wait kar rahi thi 😘", record.Name),
+		Appearance:   "a young Indian woman in her early 20s from " + record.City,
+		SelfieSeed:   record.TelegramUserID,
+	}
+}
+
+// customPersona returns the character the user built, once /create is done.
+func (t *Telegram) customPersona(ctx context.Context, userID int64) (persona, bool) {
+	record, err := t.db.GetCustomPersonaByTelegramUserId(ctx, userID)
+	if err != nil {
+		if err != sql.ErrNoRows {
+			t.logger.Logger(ctx).Error("Failed to get custom persona", zap.Error(err), zap.Int64("user_id", userID))
+		}
+		return persona{}, false
+	}
+	if !record.CompletedAt.Valid {
+		return persona{}, false
+	}
+	return buildCustomPersona(record), true
+}
+
+// isReplyToPrompt reports whether the message answers a force-reply prompt
+// sent with key, in any UI language.
+func (t *Telegram) isReplyToPrompt(message *tgbotapi.Message, key messageKey) bool {
+	reply := message.ReplyToMessage
+	if reply == nil || reply.From == nil || reply.From.ID != t.bot.Self.ID {
+		return false
+	}
+	for _, text := range catalog[key] {
+		if reply.Text == text {
+			return true
+		}
+	}
+	return false
+}
+
+func (t *Telegram) sendCreatePrompt(ctx context.Context, chatID int64, userID int64, key messageKey) {
+	msg := tgbotapi.NewMessage(chatID, t.text(ctx, userID, key))
+	msg.ReplyMarkup = tgbotapi.ForceReply{ForceReply: true}
+	if _, err := t.bot.Send(msg); err != nil {
+		t.logger.Logger(ctx).Error("Failed to send /create prompt", zap.Error(err))
+	}
+}
+
+// handleCreateCommand starts building a new character, replacing any earlier
+// one. Name and city are typed; traits, language mix and voice are picked.
+func (t *Telegram) handleCreateCommand(ctx context.Context, message *tgbotapi.Message) {
+	userID := message.From.ID
+	// A bot pinned to one persona has no room for another
+	if t.persona != "" {
+		t.replyText(ctx, message.Chat.ID, t.text(ctx, userID, msgCreateUnavailable))
+		return
+	}
+	if err := t.db.StartCustomPersona(ctx, userID); err != nil {
+		t.logger.Logger(ctx).Error("Failed to start custom persona", zap.Error(err), zap.Int64("user_id", userID))
+		t.replyText(ctx, message.Chat.ID, t.text(ctx, userID, msgSomethingWrong))
+		return
+	}
+	t.sendCreatePrompt(ctx, message.Chat.ID, userID, msgCreateNamePrompt)
+}
+
+func (t *Telegram) saveCustomPersonaName(ctx context.Context, message *tgbotapi.Message) {
+	userID := message.From.ID
+	name, ok := cleanPreferredName(message.Text)
+	if !ok {
+		t.sendCreatePrompt(ctx, message.Chat.ID, userID, msgCreateNamePrompt)
+		return
+	}
+
+	updated, err := t.db.SetCustomPersonaName(ctx, postgres.SetCustomPersonaNameParams{
+		TelegramUserID: userID,
+		Name:           name,
+	})
+	if err != nil {
+		t.logger.Logger(ctx).Error("Failed to save custom persona name", zap.Error(err), zap.Int64("user_id", userID))
+		t.replyText(ctx, message.Chat.ID, t.text(ctx, userID, msgSomethingWrong))
+		return
+	}
+	if updated == 0 {
+		t.replyText(ctx, message.Chat.ID, t.text(ctx, userID, msgCreateExpired))
+		return
+	}
+	t.sendCreatePrompt(ctx, message.Chat.ID, userID, msgCreateCityPrompt)
+}
+
+func (t *Telegram) saveCustomPersonaCity(ctx context.Context, message *tgbotapi.Message) {
+	userID := message.From.ID
+	// Cities follow the same rules as names
+	city, ok := cleanPreferredName(message.Text)
+	if !ok {
+		t.sendCreatePrompt(ctx, message.Chat.ID, userID, msgCreateCityPrompt)
+		return
+	}
+
+	updated, err := t.db.SetCustomPersonaCity(ctx, postgres.SetCustomPersonaCityParams{
+		TelegramUserID: userID,
+		City:           city,
+	})
+	if err != nil {
+		t.logger.Logger(ctx).Error("Failed to save custom persona city", zap.Error(err), zap.Int64("user_id", userID))
+		t.replyText(ctx, message.Chat.ID, t.text(ctx, userID, msgSomethingWrong))
+		return
+	}
+	if updated == 0 {
+		t.replyText(ctx, message.Chat.ID, t.text(ctx, userID, msgCreateExpired))
+		return
+	}
+
+	ui := t.userLanguage(ctx, userID).UI
+	msg := tgbotapi.NewMessage(message.Chat.ID, localize(ui, msgCreateTraitsPrompt, maxPersonaTraits))
+	msg.ReplyMarkup = traitsKeyboard(ui, nil)
+	if _, err := t.bot.Send(msg); err != nil {
+		t.logger.Logger(ctx).Error("Failed to send trait options", zap.Error(err))
+	}
+}
+
+// traitsKeyboard lists the traits, marking the selected ones, with a button
+// to move on.
+func traitsKeyboard(ui string, selected []string) tgbotapi.InlineKeyboardMarkup {
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for _, trait := range personaTraits {
+		label := trait.Emoji + " " + trait.Name
+		for _, id := range selected {
+			if id == trait.ID {
+				label = "✅ " + label
+			}
+		}
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(label, createCallbackPrefix+createStepTrait+":"+trait.ID),
+		))
+	}
+	rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData(localize(ui, msgButtonCreateDone), createCallbackPrefix+createStepTraitsDone+":"),
+	))
+	return tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
+
+// handleCreateCallback records a trait, language mix or voice choice and
+// moves the /create message on to the next step.
+func (t *Telegram) handleCreateCallback(ctx context.Context, message *tgbotapi.Message, userID int64, step string, value string) {
+	record, err := t.db.GetCustomPersonaByTelegramUserId(ctx, userID)
+	if err != nil && err != sql.ErrNoRows {
+		t.logger.Logger(ctx).Error("Failed to get custom persona", zap.Error(err), zap.Int64("user_id", userID))
+		return
+	}
+	// Buttons left over from a character that's already finished
+	if err == sql.ErrNoRows || record.CompletedAt.Valid {
+		t.replyText(ctx, message.Chat.ID, t.text(ctx, userID, msgCreateExpired))
+		return
+	}
+
+	ui := t.userLanguage(ctx, userID).UI
+	switch step {
+	case createStepTrait:
+		traits := toggleTrait(parseTraits(record.Traits), value)
+		_, err := t.db.SetCustomPersonaTraits(ctx, postgres.SetCustomPersonaTraitsParams{
+			TelegramUserID: userID,
+			Traits:         strings.Join(traits, ","),
+		})
+		if err != nil {
+			t.logger.Logger(ctx).Error("Failed to save custom persona traits", zap.Error(err), zap.Int64("user_id", userID))
+			return
+		}
+		edit := tgbotapi.NewEditMessageReplyMarkup(message.Chat.ID, message.MessageID, traitsKeyboard(ui, traits))
+		if _, err := t.bot.Send(edit); err != nil {
+			t.logger.Logger(ctx).Error("Failed to update trait options", zap.Error(err))
+		}
+	case createStepTraitsDone:
+		if len(parseTraits(record.Traits)) == 0 {
+			t.replyText(ctx, message.Chat.ID, localize(ui, msgCreateTraitsNeeded))
+			return
+		}
+		var rows [][]tgbotapi.InlineKeyboardButton
+		for _, mix := range languageMixes {
+			rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData(mix.Name, createCallbackPrefix+createStepLanguage+":"+mix.ID),
+			))
+		}
+		edit := tgbotapi.NewEditMessageTextAndMarkup(message.Chat.ID, message.MessageID, localize(ui, msgCreateLanguagePrompt), tgbotapi.NewInlineKeyboardMarkup(rows...))
+		if _, err := t.bot.Send(edit); err != nil {
+			t.logger.Logger(ctx).Error("Failed to send language mix options", zap.Error(err))
+		}
+	case createStepLanguage:
+		_, err := t.db.SetCustomPersonaLanguageMix(ctx, postgres.SetCustomPersonaLanguageMixParams{
+			TelegramUserID: userID,
+			LanguageMix:    findLanguageMix(value).ID,
+		})
+		if err != nil {
+			t.logger.Logger(ctx).Error("Failed to save custom persona language mix", zap.Error(err), zap.Int64("user_id", userID))
+			return
+		}
+		var rows [][]tgbotapi.InlineKeyboardButton
+		for _, voice := range ttsVoices {
+			rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData(voice.Emoji+" "+voice.Name, createCallbackPrefix+createStepVoice+":"+voice.ID),
+			))
+		}
+		edit := tgbotapi.NewEditMessageTextAndMarkup(message.Chat.ID, message.MessageID, localize(ui, msgCreateVoicePrompt), tgbotapi.NewInlineKeyboardMarkup(rows...))
+		if _, err := t.bot.Send(edit); err != nil {
+			t.logger.Logger(ctx).Error("Failed to send voice options", zap.Error(err))
+		}
+	case createStepVoice:
+		t.completeCustomPersona(ctx, message, userID, findVoice(value))
+	}
+}
+
+// completeCustomPersona finishes the character and switches the user to a
+// fresh chat with her, in the voice they picked.
+func (t *Telegram) completeCustomPersona(ctx context.Context, message *tgbotapi.Message, userID int64, voice ttsVoice) {
+	tracer := otel.Tracer("telegram/completeCustomPersona")
+	ctx, span := tracer.Start(ctx, "completeCustomPersona")
+	defer span.End()
+
+	record, err := t.db.CompleteCustomPersona(ctx, postgres.CompleteCustomPersonaParams{
+		TelegramUserID: userID,
+		Voice:          voice.ID,
+	})
+	if err == sql.ErrNoRows {
+		t.replyText(ctx, message.Chat.ID, t.text(ctx, userID, msgCreateExpired))
+		return
+	}
+
+	// A new character starts with a clean slate, even if she replaces one
+	var conversation postgres.Conversation
+	if err == nil {
+		conversation, err = t.getOrCreateConversation(ctx, userID, customPersonaID)
+	}
+	if err == nil {
+		_, err = t.db.ClearConversationMessages(ctx, conversation.ID)
+	}
+	if err == nil {
+		_, err = t.db.SetConversationVoice(ctx, postgres.SetConversationVoiceParams{
+			ID:       conversation.ID,
+			TtsVoice: voice.ID,
+		})
+	}
+	if err == nil {
+		_, err = t.db.SetActivePersonaByTelegramUserId(ctx, postgres.SetActivePersonaByTelegramUserIdParams{
+			ActivePersona:  customPersonaID,
+			TelegramUserID: userID,
+		})
+	}
+	if err != nil {
+		span.RecordError(err)
+		t.logger.Logger(ctx).Error("Failed to complete custom persona", zap.Error(err), zap.Int64("user_id", userID))
+		t.replyText(ctx, message.Chat.ID, t.text(ctx, userID, msgSomethingWrong))
+		return
+	}
+
+	t.logger.Logger(ctx).Info("Custom persona created", zap.Int64("user_id", userID), zap.String("voice", voice.ID))
+	edit := tgbotapi.NewEditMessageText(message.Chat.ID, message.MessageID, t.text(ctx, userID, msgCreateDone, record.Name))
+	if _, err := t.bot.Send(edit); err != nil {
+		t.logger.Logger(ctx).Error("Failed to update /create message", zap.Error(err))
+	}
+	t.sendFirstGreeting(ctx, message.Chat.ID, userID, customPersonaGreetingInput, buildCustomPersona(record).Greeting)
+}
+
+// createFromCallback splits "create:<step>:<value>" callback data.
+func createFromCallback(data string) (step string, value string, ok bool) {
+	rest, found := strings.CutPrefix(data, createCallbackPrefix)
+	if !found {
+		return "", "", false
+	}
+	return strings.Cut(rest, ":")
+}
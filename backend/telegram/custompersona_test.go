@@ -0,0 +1,74 @@
+package telegram
+
+import (
+	"gulabodev/database/postgres"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestToggleTrait(t *testing.T) {
+	tests := []struct {
+		traits []string
+		id     string
+		want   []string
+	}{
+		{nil, "shy", []string{"shy"}},
+		{[]string{"shy", "bold"}, "shy", []string{"bold"}},
+		{[]string{"shy"}, "unknown", []string{"shy"}},
+		{[]string{"shy", "bold", "witty"}, "caring", []string{"shy", "bold", "witty"}},
+		{[]string{"shy", "bold", "witty"}, "bold", []string{"shy", "witty"}},
+	}
+	for _, tt := range tests {
+		if got := toggleTrait(tt.traits, tt.id); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("toggleTrait(%v, %q) = %v, want %v", tt.traits, tt.id, got, tt.want)
+		}
+	}
+}
+
+func TestDescribeTraits(t *testing.T) {
+	tests := []struct {
+		stored string
+		want   string
+	}{
+		{"", "sweet"},
+		{"shy", "shy"},
+		{"playful,gone,witty", "playful and witty"},
+		{"playful,witty,shy", "playful, witty and shy"},
+	}
+	for _, tt := range tests {
+		if got := describeTraits(parseTraits(tt.stored)); got != tt.want {
+			t.Errorf("describeTraits(%q) = %q, want %q", tt.stored, got, tt.want)
+		}
+	}
+}
+
+func TestBuildCustomPersona(t *testing.T) {
+	p := buildCustomPersona(postgres.CustomPersona{
+		TelegramUserID: 42,
+		Name:           "Riya",
+		City:           "Pune",
+		Traits:         "bold,caring",
+		LanguageMix:    "punjabi",
+		Voice:          "gemini_kore",
+	})
+	if p.ID != customPersonaID || p.DefaultVoice != "gemini_kore" || p.SelfieSeed != 42 {
+		t.Errorf("unexpected persona %+v", p)
+	}
+
+	got := p.systemPrompt(findLanguage("english"))
+	for _, want := range []string{"Riya", "Pune", "bold and caring", findLanguageMix("punjabi").Instruction} {
+		if !strings.Contains(got, want) {
+			t.Errorf("system prompt missing %q: %q", want, got)
+		}
+	}
+}
+
+func TestCreateFromCallback(t *testing.T) {
+	if step, value, ok := createFromCallback("create:trait:shy"); !ok || step != createStepTrait || value != "shy" {
+		t.Errorf("createFromCallback(trait) = %q, %q, %v", step, value, ok)
+	}
+	if _, _, ok := createFromCallback("persona:custom"); ok {
+		t.Error("createFromCallback should ignore other callbacks")
+	}
+}
@@ -0,0 +1,106 @@
+package telegram
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"gulabodev/database/postgres"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+)
+
+const (
+	DailyBonusCredits = 3
+	dailyClaimPeriod  = 24 * time.Hour
+
+	dailyClaimPayload = "daily_claim"
+)
+
+// nextDailyClaim returns how long the user has to wait before claiming again.
+// Zero means the bonus can be claimed now.
+func (t *Telegram) nextDailyClaim(ctx context.Context, userID int64) (time.Duration, error) {
+	lastClaim, err := t.db.GetLastDailyClaimByTelegramUserId(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+	if !lastClaim.Valid {
+		return 0, nil
+	}
+
+	wait := time.Until(lastClaim.Time.Add(dailyClaimPeriod))
+	if wait < 0 {
+		return 0, nil
+	}
+	return wait, nil
+}
+
+func (t *Telegram) claimDailyCredits(ctx context.Context, chatID int64, userID int64) {
+	tracer := otel.Tracer("telegram/claimDailyCredits")
+	ctx, span := tracer.Start(ctx, "claimDailyCredits")
+	defer span.End()
+
+	span.SetAttributes(attribute.Int64("user.id", userID))
+
+	var responseText string
+	updatedCredits, err := t.db.ClaimDailyCreditsByTelegramUserId(ctx, postgres.ClaimDailyCreditsByTelegramUserIdParams{
+		TelegramUserID: userID,
+		Amount:         DailyBonusCredits,
+	})
+	switch {
+	case err == nil:
+		t.logger.Logger(ctx).Info("Daily credits claimed", zap.Int64("user_id", userID), zap.Int32("credits_balance", updatedCredits.CreditsBalance))
+		responseText = t.text(ctx, userID, msgDailyClaimed, DailyBonusCredits, updatedCredits.CreditsBalance)
+	case err == sql.ErrNoRows:
+		// Already claimed within the last 24h
+		wait, err := t.nextDailyClaim(ctx, userID)
+		if err != nil {
+			t.logger.Logger(ctx).Error("Failed to get last daily claim", zap.Error(err), zap.Int64("user_id", userID))
+		}
+		responseText = t.text(ctx, userID, msgDailyAlreadyClaimed, formatWait(wait))
+	default:
+		span.RecordError(err)
+		t.logger.Logger(ctx).Error("Failed to claim daily credits", zap.Error(err), zap.Int64("user_id", userID))
+		responseText = t.text(ctx, userID, msgDailyUnavailable)
+	}
+
+	msg := tgbotapi.NewMessage(chatID, responseText)
+	if _, err := t.bot.Send(msg); err != nil {
+		t.logger.Logger(ctx).Error("Failed to send daily claim response", zap.Error(err))
+	}
+}
+
+// promptDailyClaim nudges the user with a one-tap claim button if today's
+// bonus is still waiting for them.
+func (t *Telegram) promptDailyClaim(ctx context.Context, chatID int64, userID int64) {
+	wait, err := t.nextDailyClaim(ctx, userID)
+	if err != nil {
+		t.logger.Logger(ctx).Error("Failed to check daily claim", zap.Error(err), zap.Int64("user_id", userID))
+		return
+	}
+	if wait > 0 {
+		return
+	}
+
+	msg := tgbotapi.NewMessage(chatID, t.text(ctx, userID, msgDailyPrompt, DailyBonusCredits))
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(t.text(ctx, userID, msgButtonDailyClaim), dailyClaimPayload),
+		),
+	)
+	if _, err := t.bot.Send(msg); err != nil {
+		t.logger.Logger(ctx).Error("Failed to send daily claim prompt", zap.Error(err))
+	}
+}
+
+func formatWait(wait time.Duration) string {
+	hours := int(wait.Hours())
+	minutes := int(wait.Minutes()) % 60
+	if hours > 0 {
+		return fmt.Sprintf("%dh %dm", hours, minutes)
+	}
+	return fmt.Sprintf("%dm", minutes)
+}
@@ -0,0 +1,59 @@
+package telegram
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"gulabodev/database/postgres"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+// Dev commands exercise the paywall outside production. They aren't
+// registered when PRODUCTION is set.
+
+func (t *Telegram) handleDevNoCreditsCommand(ctx context.Context, message *tgbotapi.Message) {
+	t.logger.Logger(ctx).Info("DEV MODE: Simulating user out of credits")
+	t.sendRechargeOptions(ctx, message.Chat.ID, message.From.ID, t.text(ctx, message.From.ID, msgOutOfCredits))
+}
+
+func (t *Telegram) handleDevSetZeroCreditsCommand(ctx context.Context, message *tgbotapi.Message) {
+	t.logger.Logger(ctx).Info("DEV MODE: Setting user credits to 0")
+	currentCredits, err := t.db.GetUserCreditsByTelegramUserId(ctx, message.From.ID)
+	if err != nil && err != sql.ErrNoRows {
+		t.logger.Logger(ctx).Error("DEV: Failed to get user credits", zap.Error(err))
+		return
+	}
+
+	if currentCredits <= 0 {
+		t.replyText(ctx, message.Chat.ID, "DEV: Credits are already 0 or less.")
+		return
+	}
+
+	_, err = t.db.AddUserCreditsByTelegramUserId(ctx, postgres.AddUserCreditsByTelegramUserIdParams{
+		TelegramUserID: message.From.ID,
+		Amount:         -int32(currentCredits),
+	})
+	if err != nil {
+		t.logger.Logger(ctx).Error("DEV: Failed to set credits to zero", zap.Error(err))
+		t.replyText(ctx, message.Chat.ID, "DEV: Failed to set credits to 0.")
+		return
+	}
+	t.replyText(ctx, message.Chat.ID, "DEV: Credits have been set to 0.")
+}
+
+func (t *Telegram) handleDevAddCreditsCommand(ctx context.Context, message *tgbotapi.Message) {
+	t.logger.Logger(ctx).Info("DEV MODE: Adding 10 credits to user")
+	_, err := t.db.AddUserCreditsByTelegramUserId(ctx, postgres.AddUserCreditsByTelegramUserIdParams{
+		TelegramUserID: message.From.ID,
+		Amount:         10,
+	})
+	if err != nil {
+		t.logger.Logger(ctx).Error("DEV: Failed to add 10 credits", zap.Error(err))
+		t.replyText(ctx, message.Chat.ID, "DEV: Failed to add 10 credits.")
+		return
+	}
+	newBalance, _ := t.db.GetUserCreditsByTelegramUserId(ctx, message.From.ID)
+	t.replyText(ctx, message.Chat.ID, fmt.Sprintf("DEV: 10 credits added. New balance: %d", newBalance))
+}
@@ -0,0 +1,111 @@
+package telegram
+
+import (
+	"context"
+	"sync"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"golang.org/x/sync/semaphore"
+)
+
+const maxUpdateWorkers = 32
+
+// dispatcher runs updates on a bounded pool of workers while keeping updates
+// from the same chat in the order Telegram delivered them. Each chat with
+// pending work gets one goroutine that drains its queue, so a slow reply only
+// holds up that chat.
+type dispatcher struct {
+	handle    func(context.Context, tgbotapi.Update)
+	semaphore *semaphore.Weighted
+	wg        sync.WaitGroup
+
+	mu     sync.Mutex
+	queues map[int64][]tgbotapi.Update
+	// closed is set by Wait; later updates, like an album finishing during
+	// shutdown, are dropped
+	closed bool
+}
+
+func newDispatcher(maxWorkers int, handle func(context.Context, tgbotapi.Update)) *dispatcher {
+	return newPooledDispatcher(semaphore.NewWeighted(int64(maxWorkers)), handle)
+}
+
+// newPooledDispatcher draws workers from a pool shared with other
+// dispatchers, one per bot.
+func newPooledDispatcher(workers *semaphore.Weighted, handle func(context.Context, tgbotapi.Update)) *dispatcher {
+	return &dispatcher{
+		handle:    handle,
+		semaphore: workers,
+		queues:    map[int64][]tgbotapi.Update{},
+	}
+}
+
+// Dispatch queues the update behind any pending updates for the same chat.
+func (d *dispatcher) Dispatch(ctx context.Context, update tgbotapi.Update) {
+	chatID := updateChatID(update)
+
+	d.mu.Lock()
+	if d.closed {
+		d.mu.Unlock()
+		return
+	}
+	queue, running := d.queues[chatID]
+	d.queues[chatID] = append(queue, update)
+	if !running {
+		d.wg.Add(1)
+	}
+	d.mu.Unlock()
+
+	if running {
+		return
+	}
+	go d.drain(ctx, chatID)
+}
+
+func (d *dispatcher) drain(ctx context.Context, chatID int64) {
+	defer d.wg.Done()
+
+	for {
+		d.mu.Lock()
+		queue := d.queues[chatID]
+		if len(queue) == 0 {
+			delete(d.queues, chatID)
+			d.mu.Unlock()
+			return
+		}
+		update := queue[0]
+		d.queues[chatID] = queue[1:]
+		d.mu.Unlock()
+
+		if err := d.semaphore.Acquire(ctx, 1); err != nil {
+			// Shutting down; drop whatever is left for this chat
+			d.mu.Lock()
+			delete(d.queues, chatID)
+			d.mu.Unlock()
+			return
+		}
+		d.handle(ctx, update)
+		d.semaphore.Release(1)
+	}
+}
+
+// Wait stops taking updates and blocks until every queued update has been
+// handled.
+func (d *dispatcher) Wait() {
+	d.mu.Lock()
+	d.closed = true
+	d.mu.Unlock()
+	d.wg.Wait()
+}
+
+func updateChatID(update tgbotapi.Update) int64 {
+	switch {
+	case update.Message != nil:
+		return update.Message.Chat.ID
+	case update.CallbackQuery != nil:
+		return update.CallbackQuery.From.ID
+	case update.PreCheckoutQuery != nil:
+		return update.PreCheckoutQuery.From.ID
+	}
+	return 0
+}
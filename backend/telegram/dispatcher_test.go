@@ -0,0 +1,93 @@
+package telegram
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+func textUpdate(chatID int64, id int) tgbotapi.Update {
+	return tgbotapi.Update{
+		UpdateID: id,
+		Message:  &tgbotapi.Message{Chat: &tgbotapi.Chat{ID: chatID}},
+	}
+}
+
+func TestDispatcherOrdering(t *testing.T) {
+	var mu sync.Mutex
+	seen := map[int64][]int{}
+
+	d := newDispatcher(4, func(ctx context.Context, update tgbotapi.Update) {
+		// Earlier updates take longer, so unordered handling would show up
+		time.Sleep(time.Duration(10-update.UpdateID%10) * time.Millisecond)
+		mu.Lock()
+		chatID := update.Message.Chat.ID
+		seen[chatID] = append(seen[chatID], update.UpdateID)
+		mu.Unlock()
+	})
+
+	ctx := context.Background()
+	for i := 0; i < 10; i++ {
+		for chatID := int64(1); chatID <= 3; chatID++ {
+			d.Dispatch(ctx, textUpdate(chatID, i))
+		}
+	}
+	d.Wait()
+
+	for chatID := int64(1); chatID <= 3; chatID++ {
+		if len(seen[chatID]) != 10 {
+			t.Fatalf("Chat %d: expected 10 updates, got %d", chatID, len(seen[chatID]))
+		}
+		for i, id := range seen[chatID] {
+			if id != i {
+				t.Errorf("Chat %d: expected update %d at position %d, got %d", chatID, i, i, id)
+			}
+		}
+	}
+}
+
+func TestDispatcherConcurrency(t *testing.T) {
+	release := make(chan struct{})
+	started := make(chan int64, 2)
+
+	d := newDispatcher(2, func(ctx context.Context, update tgbotapi.Update) {
+		started <- update.Message.Chat.ID
+		<-release
+	})
+
+	ctx := context.Background()
+	d.Dispatch(ctx, textUpdate(1, 0))
+	d.Dispatch(ctx, textUpdate(2, 0))
+
+	// A blocked chat must not stop another chat from being handled
+	for i := 0; i < 2; i++ {
+		select {
+		case <-started:
+		case <-time.After(time.Second):
+			t.Fatal("Expected both chats to be handled concurrently")
+		}
+	}
+	close(release)
+	d.Wait()
+}
+
+func TestDispatcherDropsAfterWait(t *testing.T) {
+	var handled atomic.Int32
+	d := newDispatcher(2, func(ctx context.Context, update tgbotapi.Update) {
+		handled.Add(1)
+	})
+
+	ctx := context.Background()
+	d.Dispatch(ctx, textUpdate(1, 0))
+	d.Wait()
+	d.Dispatch(ctx, textUpdate(1, 1))
+	d.Wait()
+
+	if got := handled.Load(); got != 1 {
+		t.Errorf("Expected only the update before Wait to be handled, got %d", got)
+	}
+}
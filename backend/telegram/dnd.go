@@ -0,0 +1,164 @@
+package telegram
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"gulabodev/database/postgres"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+const defaultTimezone = "Asia/Kolkata"
+
+const dndUsage = "Usage:\n/dnd 23:00-08:00 - Raat ko disturb nahi karungi\n/dnd 23:00-08:00 Europe/London - Apne timezone ke saath\n/dnd off - Quiet hours band karo"
+
+// parseQuietHours parses "HH:MM-HH:MM [timezone]" into minutes past midnight.
+// An empty timezone is returned when none was given.
+func parseQuietHours(args string) (start int, end int, timezone string, err error) {
+	fields := strings.Fields(args)
+	if len(fields) == 0 || len(fields) > 2 {
+		return 0, 0, "", fmt.Errorf("expected a time range")
+	}
+
+	startRaw, endRaw, found := strings.Cut(fields[0], "-")
+	if !found {
+		return 0, 0, "", fmt.Errorf("expected a time range")
+	}
+	startTime, err := time.Parse("15:04", startRaw)
+	if err != nil {
+		return 0, 0, "", fmt.Errorf("invalid start time: %w", err)
+	}
+	endTime, err := time.Parse("15:04", endRaw)
+	if err != nil {
+		return 0, 0, "", fmt.Errorf("invalid end time: %w", err)
+	}
+	start = startTime.Hour()*60 + startTime.Minute()
+	end = endTime.Hour()*60 + endTime.Minute()
+	if start == end {
+		return 0, 0, "", fmt.Errorf("start and end are the same")
+	}
+
+	if len(fields) == 2 {
+		timezone = fields[1]
+		if _, err := time.LoadLocation(timezone); err != nil {
+			return 0, 0, "", fmt.Errorf("unknown timezone %q", timezone)
+		}
+	}
+	return start, end, timezone, nil
+}
+
+// inQuietWindow reports whether minute falls in [start, end), wrapping past
+// midnight when end is before start.
+func inQuietWindow(start int, end int, minute int) bool {
+	if start < end {
+		return minute >= start && minute < end
+	}
+	return minute >= start || minute < end
+}
+
+func formatMinute(minute int32) string {
+	return fmt.Sprintf("%02d:%02d", minute/60, minute%60)
+}
+
+// userLocation returns the user's configured timezone, or the default one.
+func (t *Telegram) userLocation(ctx context.Context, userID int64) *time.Location {
+	timezone := defaultTimezone
+	if preferences, err := t.db.GetUserPreferencesByTelegramUserId(ctx, userID); err == nil {
+		timezone = preferences.Timezone
+	}
+	location, err := time.LoadLocation(timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return location
+}
+
+// inQuietHours reports whether bot-initiated messages to the user should be
+// held back right now.
+func (t *Telegram) inQuietHours(ctx context.Context, userID int64) bool {
+	preferences, err := t.db.GetUserPreferencesByTelegramUserId(ctx, userID)
+	if err != nil {
+		if err != sql.ErrNoRows {
+			t.logger.Logger(ctx).Error("Failed to get user preferences", zap.Error(err), zap.Int64("user_id", userID))
+		}
+		return false
+	}
+	if !preferences.DndStart.Valid || !preferences.DndEnd.Valid {
+		return false
+	}
+
+	location, err := time.LoadLocation(preferences.Timezone)
+	if err != nil {
+		location = time.UTC
+	}
+	now := time.Now().In(location)
+	return inQuietWindow(int(preferences.DndStart.Int32), int(preferences.DndEnd.Int32), now.Hour()*60+now.Minute())
+}
+
+// quietHoursStatus describes the user's quiet hours, if any.
+func quietHoursStatus(preferences postgres.UserPreference, timezone string) string {
+	if preferences.DndStart.Valid && preferences.DndEnd.Valid {
+		return fmt.Sprintf("Quiet hours: %s-%s (%s) 🤫", formatMinute(preferences.DndStart.Int32), formatMinute(preferences.DndEnd.Int32), timezone)
+	}
+	return "Quiet hours abhi off hain."
+}
+
+func (t *Telegram) handleDndCommand(ctx context.Context, message *tgbotapi.Message) {
+	userID := message.From.ID
+	args := strings.TrimSpace(message.CommandArguments())
+
+	timezone := defaultTimezone
+	preferences, err := t.db.GetUserPreferencesByTelegramUserId(ctx, userID)
+	if err == nil {
+		timezone = preferences.Timezone
+	} else if err != sql.ErrNoRows {
+		t.logger.Logger(ctx).Error("Failed to get user preferences", zap.Error(err), zap.Int64("user_id", userID))
+	}
+
+	var responseText string
+	switch {
+	case args == "":
+		responseText = quietHoursStatus(preferences, timezone) + "\n\n" + dndUsage
+	case strings.EqualFold(args, "off"):
+		_, err := t.db.SetQuietHoursByTelegramUserId(ctx, postgres.SetQuietHoursByTelegramUserIdParams{
+			Timezone:       timezone,
+			TelegramUserID: userID,
+		})
+		if err != nil {
+			t.logger.Logger(ctx).Error("Failed to clear quiet hours", zap.Error(err), zap.Int64("user_id", userID))
+			responseText = t.text(ctx, message.From.ID, msgSomethingWrong)
+		} else {
+			responseText = "Quiet hours off! Ab kabhi bhi message kar sakti hoon 😘"
+		}
+	default:
+		start, end, newTimezone, err := parseQuietHours(args)
+		if err != nil {
+			responseText = "Samajh nahi aaya, baby 🙈\n\n" + dndUsage
+			break
+		}
+		if newTimezone != "" {
+			timezone = newTimezone
+		}
+		_, err = t.db.SetQuietHoursByTelegramUserId(ctx, postgres.SetQuietHoursByTelegramUserIdParams{
+			DndStart:       sql.NullInt32{Valid: true, Int32: int32(start)},
+			DndEnd:         sql.NullInt32{Valid: true, Int32: int32(end)},
+			Timezone:       timezone,
+			TelegramUserID: userID,
+		})
+		if err != nil {
+			t.logger.Logger(ctx).Error("Failed to set quiet hours", zap.Error(err), zap.Int64("user_id", userID))
+			responseText = t.text(ctx, message.From.ID, msgSomethingWrong)
+		} else {
+			responseText = fmt.Sprintf("Done! %s se %s (%s) tak main khud se disturb nahi karungi 🤫", formatMinute(int32(start)), formatMinute(int32(end)), timezone)
+		}
+	}
+
+	msg := tgbotapi.NewMessage(message.Chat.ID, responseText)
+	if _, err := t.bot.Send(msg); err != nil {
+		t.logger.Logger(ctx).Error("Failed to send dnd response", zap.Error(err))
+	}
+}
@@ -0,0 +1,39 @@
+package telegram
+
+import "testing"
+
+func TestParseQuietHours(t *testing.T) {
+	start, end, timezone, err := parseQuietHours("23:30-07:00 Europe/London")
+	if err != nil {
+		t.Fatalf("parseQuietHours failed: %v", err)
+	}
+	if start != 23*60+30 || end != 7*60 || timezone != "Europe/London" {
+		t.Errorf("Unexpected result: start=%d end=%d timezone=%q", start, end, timezone)
+	}
+
+	for _, args := range []string{"", "23:00", "25:00-07:00", "23:00-23:00", "23:00-07:00 Mars/Olympus"} {
+		if _, _, _, err := parseQuietHours(args); err == nil {
+			t.Errorf("Expected error for %q", args)
+		}
+	}
+}
+
+func TestInQuietWindow(t *testing.T) {
+	tests := []struct {
+		start, end, minute int
+		want               bool
+	}{
+		{9 * 60, 17 * 60, 12 * 60, true},
+		{9 * 60, 17 * 60, 17 * 60, false},
+		{9 * 60, 17 * 60, 8 * 60, false},
+		// Wraps past midnight
+		{23 * 60, 7 * 60, 23*60 + 30, true},
+		{23 * 60, 7 * 60, 3 * 60, true},
+		{23 * 60, 7 * 60, 12 * 60, false},
+	}
+	for _, tt := range tests {
+		if got := inQuietWindow(tt.start, tt.end, tt.minute); got != tt.want {
+			t.Errorf("inQuietWindow(%d, %d, %d) = %v, want %v", tt.start, tt.end, tt.minute, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package telegram
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"gulabodev/modelapi/groqapi"
+	"html/template"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+)
+
+var exportTemplate = template.Must(template.New("export").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Gulabo chat</title>
+<style>
+body { font-family: -apple-system, sans-serif; max-width: 720px; margin: 2em auto; background: #fff5f7; }
+.message { margin: 0.5em 0; padding: 0.6em 0.9em; border-radius: 12px; max-width: 80%; white-space: pre-wrap; }
+.user { background: #dcf8c6; margin-left: auto; }
+.assistant { background: #ffffff; }
+.time { display: block; font-size: 0.75em; color: #888; margin-top: 0.3em; }
+h3 { color: #888; font-weight: normal; text-align: center; margin-top: 2em; }
+</style>
+</head>
+<body>
+<h2>💋 Chat with Gulabo</h2>
+<p>Exported {{.Exported}}</p>
+{{$numbered := gt (len .Sessions) 1}}{{range $i, $session := .Sessions}}{{if $numbered}}<h3>Session {{$session.Number}}{{if $session.Started}} · {{$session.Started}}{{end}}</h3>
+{{end}}{{range $session.Messages}}<div class="message {{.Role}}">{{.Content}}{{if .Time}}<span class="time">{{.Time}}</span>{{end}}</div>
+{{end}}{{end}}</body>
+</html>
+`))
+
+type exportMessage struct {
+	Role    string
+	Content string
+	Time    string
+}
+
+type exportSession struct {
+	Number   int
+	Started  string
+	Messages []exportMessage
+}
+
+// renderConversationHTML formats sessions of stored messages, oldest first, as
+// a standalone HTML page. Sessions are only headed when there's more than one.
+func renderConversationHTML(sessions [][]storedMessage, exported time.Time) ([]byte, error) {
+	data := struct {
+		Exported string
+		Sessions []exportSession
+	}{
+		Exported: exported.Format("02 Jan 2006 15:04 MST"),
+	}
+
+	for i, messages := range sessions {
+		session := exportSession{Number: i + 1}
+		for _, message := range messages {
+			if message.Role != groqapi.USER && message.Role != groqapi.ASSISTANT {
+				continue
+			}
+			entry := exportMessage{Role: message.Role, Content: message.Content}
+			if message.Timestamp != nil {
+				entry.Time = message.Timestamp.In(exported.Location()).Format("02 Jan 2006 15:04")
+				if session.Started == "" {
+					session.Started = message.Timestamp.In(exported.Location()).Format("02 Jan 2006")
+				}
+			}
+			session.Messages = append(session.Messages, entry)
+		}
+		data.Sessions = append(data.Sessions, session)
+	}
+
+	var buf bytes.Buffer
+	if err := exportTemplate.Execute(&buf, data); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (t *Telegram) handleExportCommand(ctx context.Context, message *tgbotapi.Message) {
+	tracer := otel.Tracer("telegram/handleExportCommand")
+	ctx, span := tracer.Start(ctx, "handleExportCommand")
+	defer span.End()
+
+	userID := message.From.ID
+
+	conversation, err := t.activeConversation(ctx, userID)
+	if err != nil {
+		span.RecordError(err)
+		t.logger.Logger(ctx).Error("Failed to get conversation for export", zap.Error(err), zap.Int64("user_id", userID))
+		msg := tgbotapi.NewMessage(message.Chat.ID, t.text(ctx, message.From.ID, msgSomethingWrong))
+		t.bot.Send(msg)
+		return
+	}
+
+	sessions, messages := t.conversationSessions(ctx, conversation)
+	if messages == 0 {
+		msg := tgbotapi.NewMessage(message.Chat.ID, "Abhi toh humne baat hi nahi ki, baby... pehle kuch bolo na 🙈")
+		t.bot.Send(msg)
+		return
+	}
+
+	now := time.Now().In(t.userLocation(ctx, userID))
+
+	page, err := renderConversationHTML(sessions, now)
+	if err != nil {
+		span.RecordError(err)
+		t.logger.Logger(ctx).Error("Failed to render conversation export", zap.Error(err), zap.Int64("user_id", userID))
+		return
+	}
+
+	span.SetAttributes(
+		attribute.Int("export.sessions", len(sessions)),
+		attribute.Int("export.messages", messages),
+		attribute.Int("export.bytes", len(page)),
+	)
+
+	document := tgbotapi.NewDocument(message.Chat.ID, tgbotapi.FileBytes{
+		Name:  fmt.Sprintf("gulabo-chat-%s.html", now.Format("2006-01-02")),
+		Bytes: page,
+	})
+	document.Caption = "Hamari saari baatein, tumhare liye 💌"
+	if _, err := t.bot.Send(document); err != nil {
+		t.logger.Logger(ctx).Error("Failed to send conversation export", zap.Error(err), zap.Int64("user_id", userID))
+	}
+}
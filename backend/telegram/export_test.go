@@ -0,0 +1,64 @@
+package telegram
+
+import (
+	"gulabodev/modelapi/groqapi"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRenderConversationHTML(t *testing.T) {
+	sent := time.Date(2025, 2, 14, 21, 30, 0, 0, time.UTC)
+	messages := []storedMessage{
+		{ChatCompletionInputMessage: groqapi.ChatCompletionInputMessage{Role: groqapi.USER, Content: "old message"}},
+		newStoredMessage(groqapi.USER, "<script>alert(1)</script>", sent),
+		newStoredMessage(groqapi.ASSISTANT, "Hi baby 😘", sent),
+	}
+
+	page, err := renderConversationHTML([][]storedMessage{messages}, sent)
+	if err != nil {
+		t.Fatalf("renderConversationHTML failed: %v", err)
+	}
+	html := string(page)
+
+	if strings.Contains(html, "<script>alert") {
+		t.Error("Message content was not escaped")
+	}
+	for _, want := range []string{"old message", "Hi baby 😘", "14 Feb 2025 21:30", `class="message assistant"`} {
+		if !strings.Contains(html, want) {
+			t.Errorf("Expected export to contain %q", want)
+		}
+	}
+}
+
+func TestRenderConversationHTMLSessions(t *testing.T) {
+	first := time.Date(2025, 2, 14, 21, 30, 0, 0, time.UTC)
+	second := time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC)
+	sessions := [][]storedMessage{
+		{newStoredMessage(groqapi.USER, "archived hello", first)},
+		{newStoredMessage(groqapi.USER, "fresh hello", second)},
+	}
+
+	page, err := renderConversationHTML(sessions, second)
+	if err != nil {
+		t.Fatalf("renderConversationHTML failed: %v", err)
+	}
+	html := string(page)
+
+	for _, want := range []string{"Session 1 · 14 Feb 2025", "Session 2 · 01 Mar 2025", "archived hello", "fresh hello"} {
+		if !strings.Contains(html, want) {
+			t.Errorf("Expected export to contain %q", want)
+		}
+	}
+	if strings.Index(html, "archived hello") > strings.Index(html, "fresh hello") {
+		t.Error("Expected archived sessions before the current one")
+	}
+
+	single, err := renderConversationHTML(sessions[1:], second)
+	if err != nil {
+		t.Fatalf("renderConversationHTML failed: %v", err)
+	}
+	if strings.Contains(string(single), "Session 1") {
+		t.Error("A single session shouldn't be headed")
+	}
+}
@@ -0,0 +1,112 @@
+package telegram
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"gulabodev/database/postgres"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+)
+
+const (
+	feedbackPrompt = "Batao na baby, mujhe kaisa lag raha hai tumhe? 💌 Reply karke feedback likho (shuru mein 1-5 rating bhi de sakte ho, jaise \"5 love it\")."
+
+	// Turns of conversation stored alongside feedback for context
+	feedbackContextMessages = 6
+)
+
+// parseFeedback splits an optional leading 1-5 rating from the feedback text.
+func parseFeedback(input string) (sql.NullInt32, string) {
+	input = strings.TrimSpace(input)
+	first, rest, _ := strings.Cut(input, " ")
+	rating, err := strconv.Atoi(strings.TrimSuffix(first, "/5"))
+	if err != nil || rating < 1 || rating > 5 {
+		return sql.NullInt32{}, input
+	}
+	return sql.NullInt32{Valid: true, Int32: int32(rating)}, strings.TrimSpace(rest)
+}
+
+// isFeedbackReply reports whether the message answers the /feedback prompt.
+func (t *Telegram) isFeedbackReply(message *tgbotapi.Message) bool {
+	reply := message.ReplyToMessage
+	return reply != nil && reply.From != nil && reply.From.ID == t.bot.Self.ID && reply.Text == feedbackPrompt
+}
+
+func (t *Telegram) handleFeedbackCommand(ctx context.Context, message *tgbotapi.Message) {
+	if args := strings.TrimSpace(message.CommandArguments()); args != "" {
+		t.saveFeedback(ctx, message, args)
+		return
+	}
+	t.sendFeedbackPrompt(ctx, message.Chat.ID)
+}
+
+func (t *Telegram) sendFeedbackPrompt(ctx context.Context, chatID int64) {
+	msg := tgbotapi.NewMessage(chatID, feedbackPrompt)
+	msg.ReplyMarkup = tgbotapi.ForceReply{ForceReply: true, InputFieldPlaceholder: "Your feedback..."}
+	if _, err := t.bot.Send(msg); err != nil {
+		t.logger.Logger(ctx).Error("Failed to send feedback prompt", zap.Error(err))
+	}
+}
+
+func (t *Telegram) saveFeedback(ctx context.Context, message *tgbotapi.Message, input string) {
+	tracer := otel.Tracer("telegram/saveFeedback")
+	ctx, span := tracer.Start(ctx, "saveFeedback")
+	defer span.End()
+
+	userID := message.From.ID
+	rating, text := parseFeedback(input)
+	if text == "" && !rating.Valid {
+		t.sendFeedbackPrompt(ctx, message.Chat.ID)
+		return
+	}
+
+	var conversationID sql.NullInt64
+	recentMessages := json.RawMessage("[]")
+	conversation, err := t.activeConversation(ctx, userID)
+	if err == nil {
+		conversationID = sql.NullInt64{Valid: true, Int64: conversation.ID}
+		history, _ := decodeHistory(conversation.Messages)
+		if len(history) > feedbackContextMessages {
+			history = history[len(history)-feedbackContextMessages:]
+		}
+		if encoded, err := json.Marshal(history); err == nil {
+			recentMessages = encoded
+		}
+	} else {
+		t.logger.Logger(ctx).Error("Failed to get conversation for feedback", zap.Error(err), zap.Int64("user_id", userID))
+	}
+
+	feedback, err := t.db.CreateFeedback(ctx, postgres.CreateFeedbackParams{
+		ConversationID: conversationID,
+		Rating:         rating,
+		Text:           text,
+		RecentMessages: recentMessages,
+		TelegramUserID: userID,
+	})
+
+	var responseText string
+	if err != nil {
+		span.RecordError(err)
+		t.logger.Logger(ctx).Error("Failed to save feedback", zap.Error(err), zap.Int64("user_id", userID))
+		responseText = t.text(ctx, message.From.ID, msgSomethingWrong)
+	} else {
+		span.SetAttributes(attribute.Int64("feedback.id", feedback.ID))
+		t.logger.Logger(ctx).Info("Feedback received",
+			zap.Int64("user_id", userID),
+			zap.Int64("feedback_id", feedback.ID),
+			zap.Int32("rating", rating.Int32),
+		)
+		responseText = "Aww, thank you jaan! Tumhari har baat dil se sunti hoon main 🥰"
+	}
+
+	msg := tgbotapi.NewMessage(message.Chat.ID, responseText)
+	if _, err := t.bot.Send(msg); err != nil {
+		t.logger.Logger(ctx).Error("Failed to send feedback confirmation", zap.Error(err))
+	}
+}
@@ -0,0 +1,23 @@
+package telegram
+
+import "testing"
+
+func TestParseFeedback(t *testing.T) {
+	tests := []struct {
+		input  string
+		rating int32
+		text   string
+	}{
+		{"5 love it", 5, "love it"},
+		{"3/5 voice is slow", 3, "voice is slow"},
+		{"4", 4, ""},
+		{"love the voice", 0, "love the voice"},
+		{"10 out of 10", 0, "10 out of 10"},
+	}
+	for _, tt := range tests {
+		rating, text := parseFeedback(tt.input)
+		if rating.Int32 != tt.rating || rating.Valid != (tt.rating != 0) || text != tt.text {
+			t.Errorf("parseFeedback(%q) = (%v, %q), want (%d, %q)", tt.input, rating, text, tt.rating, tt.text)
+		}
+	}
+}
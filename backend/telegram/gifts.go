@@ -0,0 +1,196 @@
+package telegram
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"gulabodev/database/postgres"
+	"gulabodev/modelapi"
+	"gulabodev/modelapi/groqapi"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+)
+
+const giftCallbackPrefix = "gift:"
+
+func giftFromCallback(data string) (string, bool) {
+	if !strings.HasPrefix(data, giftCallbackPrefix) {
+		return "", false
+	}
+	return strings.TrimPrefix(data, giftCallbackPrefix), true
+}
+
+// giftInput is what the user "says" when they give a gift, as it goes into
+// the conversation history.
+func giftInput(gift postgres.Gift) string {
+	return "[Gave you a gift: " + gift.Emoji + " " + gift.Name + "]"
+}
+
+// giftPrompt conditions her thank-you on the gift.
+func giftPrompt(gift postgres.Gift) string {
+	return "\n" + gift.Reaction + " React to this gift in your reply, in character."
+}
+
+// giftScene sets the scene for the selfie she sends with a gift.
+func giftScene(gift postgres.Gift) string {
+	return "beaming as she shows off the " + strings.ToLower(gift.Name) + " her lover just gave her"
+}
+
+func (t *Telegram) handleGiftsCommand(ctx context.Context, message *tgbotapi.Message) {
+	userID := message.From.ID
+	gifts, err := t.db.ListGifts(ctx)
+	if err != nil {
+		t.logger.Logger(ctx).Error("Failed to list gifts", zap.Error(err))
+		t.replyText(ctx, message.Chat.ID, t.text(ctx, userID, msgSomethingWrong))
+		return
+	}
+	if len(gifts) == 0 {
+		t.replyText(ctx, message.Chat.ID, t.text(ctx, userID, msgGiftUnavailable))
+		return
+	}
+
+	ui := t.userLanguage(ctx, userID).UI
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for _, gift := range gifts {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(localize(ui, msgButtonGift, gift.Emoji, gift.Name, gift.PriceCredits), giftCallbackPrefix+gift.ID),
+		))
+	}
+
+	msg := tgbotapi.NewMessage(message.Chat.ID, localize(ui, msgGiftsMenu))
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
+	if _, err := t.bot.Send(msg); err != nil {
+		t.logger.Logger(ctx).Error("Failed to send gift shop", zap.Error(err))
+	}
+}
+
+// buyGift spends the user's credits on a gift, then has Gulabo react to it.
+// Users who can't afford it get the recharge options instead.
+func (t *Telegram) buyGift(ctx context.Context, chatID int64, userID int64, giftID string) {
+	tracer := otel.Tracer("telegram/buyGift")
+	ctx, span := tracer.Start(ctx, "buyGift")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("gift.id", giftID))
+
+	gift, err := t.db.GetGift(ctx, giftID)
+	if err != nil {
+		if err != sql.ErrNoRows {
+			span.RecordError(err)
+			t.logger.Logger(ctx).Error("Failed to get gift", zap.Error(err), zap.String("gift_id", giftID))
+			t.replyText(ctx, chatID, t.text(ctx, userID, msgSomethingWrong))
+			return
+		}
+		t.replyText(ctx, chatID, t.text(ctx, userID, msgGiftUnavailable))
+		return
+	}
+
+	_, err = t.db.PurchaseGift(ctx, postgres.PurchaseGiftParams{
+		PriceCredits:   gift.PriceCredits,
+		TelegramUserID: userID,
+		GiftID:         gift.ID,
+	})
+	if err != nil {
+		if err != sql.ErrNoRows {
+			span.RecordError(err)
+			t.logger.Logger(ctx).Error("Failed to purchase gift", zap.Error(err), zap.Int64("user_id", userID), zap.String("gift_id", gift.ID))
+			t.replyText(ctx, chatID, t.text(ctx, userID, msgSomethingWrong))
+			return
+		}
+		t.sendRechargeOptions(ctx, chatID, userID, t.text(ctx, userID, msgGiftTooExpensive, gift.PriceCredits))
+		return
+	}
+
+	t.logger.Logger(ctx).Info("Gift purchased", zap.Int64("user_id", userID), zap.String("gift_id", gift.ID), zap.Int32("credits", gift.PriceCredits))
+	t.addAffection(ctx, chatID, userID, gift.Affection)
+	t.sendGiftReaction(ctx, chatID, userID, gift)
+}
+
+// sendGiftReaction has Gulabo thank the user for a gift, in the active
+// conversation. The gift already paid for it, so the reply is free.
+func (t *Telegram) sendGiftReaction(ctx context.Context, chatID int64, userID int64, gift postgres.Gift) {
+	conversation, err := t.activeConversation(ctx, userID)
+	if err != nil {
+		t.logger.Logger(ctx).Error("Failed to get conversation", zap.Error(err), zap.Int64("user_id", userID))
+		return
+	}
+
+	// Initialized as an empty slice if unmarshal fails
+	storedHistory, err := decodeHistory(conversation.Messages)
+	if err != nil {
+		t.logger.Logger(ctx).Error("Failed to unmarshal conversation history", zap.Error(err))
+	}
+
+	userInput := giftInput(gift)
+	textReplies := t.prefersTextReplies(ctx, userID)
+	systemPrompt := t.replySystemPrompt(ctx, userID, conversation, t.userMemories(ctx, userID)) + giftPrompt(gift)
+	markup := regenerateKeyboard(conversation.ID, len(storedHistory)+2)
+
+	given := time.Now()
+	response, err := t.generateReply(ctx, chatID, conversation, textReplies, modelapi.ChatRequest{
+		SystemPrompt: systemPrompt,
+		History:      modelHistory(unsummarized(conversation, storedHistory)),
+		Message:      userInput,
+	}, markup)
+	if err != nil {
+		t.logger.Logger(ctx).Error("Failed to generate gift reaction", zap.Error(err), zap.Int64("user_id", userID))
+		return
+	}
+
+	storedHistory = append(storedHistory,
+		newStoredMessage(groqapi.USER, userInput, given),
+		newStoredMessage(groqapi.ASSISTANT, response, time.Now()),
+	)
+	updatedMessages, err := json.Marshal(storedHistory)
+	if err != nil {
+		t.logger.Logger(ctx).Error("Failed to marshal updated conversation history", zap.Error(err))
+	} else {
+		_, err = t.db.UpdateConversationMessages(ctx, postgres.UpdateConversationMessagesParams{
+			ID:       conversation.ID,
+			Messages: updatedMessages,
+		})
+		if err != nil {
+			t.logger.Logger(ctx).Error("Failed to update conversation messages", zap.Error(err))
+		}
+	}
+
+	if !textReplies {
+		t.sendVoiceResponse(ctx, chatID, conversation, response, markup)
+	}
+	t.sendGiftSelfie(ctx, chatID, userID, conversation, gift)
+}
+
+// sendGiftSelfie follows her thank-you with a selfie of her showing off the
+// gift. Like the reply, it's paid for by the gift.
+func (t *Telegram) sendGiftSelfie(ctx context.Context, chatID int64, userID int64, conversation postgres.Conversation, gift postgres.Gift) {
+	tracer := otel.Tracer("telegram/sendGiftSelfie")
+	ctx, span := tracer.Start(ctx, "sendGiftSelfie")
+	defer span.End()
+
+	if _, err := t.bot.Request(tgbotapi.NewChatAction(chatID, tgbotapi.ChatUploadPhoto)); err != nil {
+		t.logger.Logger(ctx).Warn("Failed to send chat action", zap.Error(err))
+	}
+
+	p := t.conversationPersona(ctx, conversation)
+	image, err := t.images.GenerateImage(ctx, selfieRequest(p, giftScene(gift)))
+	if err != nil {
+		span.RecordError(err)
+		t.logger.Logger(ctx).Error("Failed to generate gift selfie", zap.Error(err), zap.Int64("user_id", userID), zap.String("gift_id", gift.ID))
+		return
+	}
+
+	photo := tgbotapi.NewPhoto(chatID, tgbotapi.FileBytes{
+		Name:  "gift.png",
+		Bytes: image,
+	})
+	photo.Caption = gift.Emoji
+	if _, err := t.bot.Send(photo); err != nil {
+		span.RecordError(err)
+		t.logger.Logger(ctx).Error("Failed to send gift selfie", zap.Error(err), zap.Int64("user_id", userID))
+	}
+}
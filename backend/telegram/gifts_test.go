@@ -0,0 +1,22 @@
+package telegram
+
+import (
+	"gulabodev/database/postgres"
+	"testing"
+)
+
+func TestGiftFromCallback(t *testing.T) {
+	if id, ok := giftFromCallback(giftCallbackPrefix + "rose"); !ok || id != "rose" {
+		t.Errorf("giftFromCallback = %q, %v, want rose", id, ok)
+	}
+	if _, ok := giftFromCallback("autorecharge:off"); ok {
+		t.Error("giftFromCallback accepted another callback")
+	}
+}
+
+func TestGiftInput(t *testing.T) {
+	gift := postgres.Gift{ID: "rose", Name: "Rose", Emoji: "🌹"}
+	if got, want := giftInput(gift), "[Gave you a gift: 🌹 Rose]"; got != want {
+		t.Errorf("giftInput = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,279 @@
+package telegram
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"gulabodev/database/postgres"
+	"gulabodev/modelapi"
+	"gulabodev/modelapi/groqapi"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+)
+
+const (
+	greetingsCallbackPrefix = "greetings:"
+	greetingCheckInterval   = 5 * time.Minute
+
+	greetingMorning = "morning"
+	greetingNight   = "night"
+	greetingBoth    = "both"
+
+	// Local hours the notes go out from; a user still in quiet hours gets
+	// theirs later in the window
+	morningGreetingHour = 8
+	nightGreetingHour   = 22
+	greetingWindowHours = 2
+)
+
+type greetingOption struct {
+	// ID is what gets stored in user_preferences.daily_greetings
+	ID   string
+	Name messageKey
+}
+
+// greetingOptions lists the choices offered by /greetings. The first entry is the default.
+var greetingOptions = []greetingOption{
+	{ID: "", Name: msgButtonGreetingsOff},
+	{ID: greetingMorning, Name: msgButtonGreetingsMorning},
+	{ID: greetingNight, Name: msgButtonGreetingsNight},
+	{ID: greetingBoth, Name: msgButtonGreetingsBoth},
+}
+
+// greetingPrompts has the system prompt addition and the instruction that
+// stands in for a user message, for each kind of note.
+var greetingPrompts = map[string]struct {
+	Prompt      string
+	Instruction string
+}{
+	greetingMorning: {
+		Prompt:      "\nIt's morning for your lover and you're sending them a good-morning voice note. Keep it short and sweet, make it different from any you've sent before, and bring up something from your chats if you can.",
+		Instruction: "[Wish me good morning]",
+	},
+	greetingNight: {
+		Prompt:      "\nIt's night for your lover and you're sending them a good-night voice note. Keep it short, soft and loving, and make it different from any you've sent before.",
+		Instruction: "[Wish me good night]",
+	},
+}
+
+func findGreetingOption(id string) greetingOption {
+	for _, option := range greetingOptions {
+		if option.ID == id {
+			return option
+		}
+	}
+	return greetingOptions[0]
+}
+
+func greetingsFromCallback(data string) (string, bool) {
+	if !strings.HasPrefix(data, greetingsCallbackPrefix) {
+		return "", false
+	}
+	return strings.TrimPrefix(data, greetingsCallbackPrefix), true
+}
+
+// dueGreeting returns the kind of note due at the user's local time, given
+// their daily_greetings setting.
+func dueGreeting(setting string, now time.Time) (string, bool) {
+	inWindow := func(hour int) bool {
+		return now.Hour() >= hour && now.Hour() < hour+greetingWindowHours
+	}
+	switch {
+	case (setting == greetingMorning || setting == greetingBoth) && inWindow(morningGreetingHour):
+		return greetingMorning, true
+	case (setting == greetingNight || setting == greetingBoth) && inWindow(nightGreetingHour):
+		return greetingNight, true
+	default:
+		return "", false
+	}
+}
+
+// RunGreetingScheduler sends opted-in users their good-morning and good-night
+// voice notes at their local time. Like re-engagement, these are free.
+func (t *Telegram) RunGreetingScheduler(ctx context.Context) {
+	t.logger.Logger(ctx).Info("Starting greeting scheduler")
+
+	ticker := time.NewTicker(greetingCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			t.sendDueGreetings(ctx)
+		}
+	}
+}
+
+func (t *Telegram) sendDueGreetings(ctx context.Context) {
+	tracer := otel.Tracer("telegram/sendDueGreetings")
+	ctx, span := tracer.Start(ctx, "sendDueGreetings")
+	defer span.End()
+
+	if t.maintenance.Load() {
+		return
+	}
+
+	subscribers, err := t.db.ListGreetingSubscribers(ctx)
+	if err != nil {
+		span.RecordError(err)
+		t.logger.Logger(ctx).Error("Failed to list greeting subscribers", zap.Error(err))
+		return
+	}
+
+	sent := 0
+	for _, subscriber := range subscribers {
+		location, err := time.LoadLocation(subscriber.Timezone)
+		if err != nil {
+			location = time.UTC
+		}
+		now := time.Now().In(location)
+		kind, ok := dueGreeting(subscriber.DailyGreetings, now)
+		// Not claimed, so they're picked up again once quiet hours end
+		if !ok || t.inQuietHours(ctx, subscriber.TelegramUserID) {
+			continue
+		}
+
+		// Claim the day's note before sending, so a slow run or a blocked user
+		// never gets it twice
+		claimed, err := t.db.ClaimDailyGreeting(ctx, postgres.ClaimDailyGreetingParams{
+			TelegramUserID: subscriber.TelegramUserID,
+			Kind:           kind,
+			LocalDate:      time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC),
+		})
+		if err != nil {
+			t.logger.Logger(ctx).Error("Failed to claim daily greeting", zap.Error(err), zap.Int64("user_id", subscriber.TelegramUserID))
+			continue
+		}
+		if claimed == 0 {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		default:
+		}
+		if t.sendGreeting(ctx, subscriber.TelegramUserID, kind) {
+			sent++
+		}
+	}
+
+	span.SetAttributes(
+		attribute.Int("greetings.subscribers", len(subscribers)),
+		attribute.Int("greetings.sent", sent),
+	)
+	if sent > 0 {
+		t.logger.Logger(ctx).Info("Greeting run complete", zap.Int("sent", sent))
+	}
+}
+
+// sendGreeting writes a fresh note in the active conversation and sends it
+// as a voice note.
+func (t *Telegram) sendGreeting(ctx context.Context, userID int64, kind string) bool {
+	ctx = t.trackUsage(ctx, userID)
+	conversation, err := t.activeConversation(ctx, userID)
+	if err != nil {
+		t.logger.Logger(ctx).Error("Failed to get conversation", zap.Error(err), zap.Int64("user_id", userID))
+		return false
+	}
+
+	// Initialized as an empty slice if unmarshal fails
+	history, err := decodeHistory(conversation.Messages)
+	if err != nil {
+		t.logger.Logger(ctx).Error("Failed to unmarshal conversation history", zap.Error(err))
+	}
+
+	prompt := greetingPrompts[kind]
+	systemPrompt := t.replySystemPrompt(ctx, userID, conversation, t.userMemories(ctx, userID)) + prompt.Prompt
+	response, err := t.chatProvider(ctx, chatFeatureGreeting, conversation).GetResponse(ctx, modelapi.ChatRequest{
+		SystemPrompt: systemPrompt,
+		History:      modelHistory(unsummarized(conversation, history)),
+		Message:      prompt.Instruction,
+	})
+	if err != nil {
+		t.logger.Logger(ctx).Error("Failed to generate greeting", zap.Error(err), zap.Int64("user_id", userID), zap.String("kind", kind))
+		return false
+	}
+	response = strings.Trim(response, `\ '"“”`)
+
+	// The empty option turns the notes off
+	markup := tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(t.text(ctx, userID, msgButtonGreetingsStop), greetingsCallbackPrefix),
+		),
+	)
+	// A failed voice note falls back to sending the text
+	if ttsFailed, delivered := t.sendVoiceResponse(ctx, userID, conversation, response, markup); !delivered && !ttsFailed {
+		return false
+	}
+
+	// Keep the conversation coherent if the user replies
+	messages, err := json.Marshal([]storedMessage{
+		newStoredMessage(groqapi.ASSISTANT, response, time.Now()),
+	})
+	if err == nil {
+		err = t.db.AppendConversationMessages(ctx, postgres.AppendConversationMessagesParams{
+			Messages: messages,
+			ID:       conversation.ID,
+		})
+	}
+	if err != nil {
+		t.logger.Logger(ctx).Error("Failed to append greeting to conversation", zap.Error(err), zap.Int64("user_id", userID))
+	}
+	return true
+}
+
+func (t *Telegram) handleGreetingsCommand(ctx context.Context, message *tgbotapi.Message) {
+	userID := message.From.ID
+	current := ""
+	preferences, err := t.db.GetUserPreferencesByTelegramUserId(ctx, userID)
+	if err == nil {
+		current = preferences.DailyGreetings
+	} else if err != sql.ErrNoRows {
+		t.logger.Logger(ctx).Error("Failed to get user preferences", zap.Error(err), zap.Int64("user_id", userID))
+	}
+
+	ui := t.userLanguage(ctx, userID).UI
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for _, option := range greetingOptions {
+		label := localize(ui, option.Name)
+		if option.ID == current {
+			label = "✅ " + label
+		}
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(label, greetingsCallbackPrefix+option.ID),
+		))
+	}
+
+	msg := tgbotapi.NewMessage(message.Chat.ID, localize(ui, msgGreetingsMenu))
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
+	if _, err := t.bot.Send(msg); err != nil {
+		t.logger.Logger(ctx).Error("Failed to send greeting options", zap.Error(err))
+	}
+}
+
+func (t *Telegram) setGreetings(ctx context.Context, chatID int64, userID int64, optionID string) {
+	option := findGreetingOption(optionID)
+	_, err := t.db.SetDailyGreetingsByTelegramUserId(ctx, postgres.SetDailyGreetingsByTelegramUserIdParams{
+		DailyGreetings: option.ID,
+		TelegramUserID: userID,
+	})
+
+	ui := t.userLanguage(ctx, userID).UI
+	switch {
+	case err != nil:
+		t.logger.Logger(ctx).Error("Failed to set daily greetings", zap.Error(err), zap.Int64("user_id", userID))
+		t.replyText(ctx, chatID, localize(ui, msgSomethingWrong))
+	case option.ID == "":
+		t.replyText(ctx, chatID, localize(ui, msgGreetingsOff))
+	default:
+		t.replyText(ctx, chatID, localize(ui, msgGreetingsOn, localize(ui, option.Name)))
+	}
+}
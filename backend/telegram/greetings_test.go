@@ -0,0 +1,43 @@
+package telegram
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDueGreeting(t *testing.T) {
+	at := func(hour, minute int) time.Time {
+		return time.Date(2024, 5, 10, hour, minute, 0, 0, time.UTC)
+	}
+	tests := []struct {
+		setting  string
+		now      time.Time
+		wantKind string
+		wantOK   bool
+	}{
+		{greetingMorning, at(8, 0), greetingMorning, true},
+		{greetingMorning, at(9, 55), greetingMorning, true},
+		{greetingMorning, at(10, 0), "", false},
+		{greetingMorning, at(22, 30), "", false},
+		{greetingNight, at(22, 30), greetingNight, true},
+		{greetingBoth, at(8, 5), greetingMorning, true},
+		{greetingBoth, at(23, 59), greetingNight, true},
+		{greetingBoth, at(15, 0), "", false},
+		{"", at(8, 0), "", false},
+	}
+	for _, tt := range tests {
+		kind, ok := dueGreeting(tt.setting, tt.now)
+		if kind != tt.wantKind || ok != tt.wantOK {
+			t.Errorf("dueGreeting(%q, %s) = %q, %v, want %q, %v", tt.setting, tt.now.Format("15:04"), kind, ok, tt.wantKind, tt.wantOK)
+		}
+	}
+}
+
+func TestGreetingsFromCallback(t *testing.T) {
+	if option, ok := greetingsFromCallback(greetingsCallbackPrefix); !ok || findGreetingOption(option).ID != "" {
+		t.Errorf("bare prefix = %q, %v, want the off option", option, ok)
+	}
+	if option, ok := greetingsFromCallback(greetingsCallbackPrefix + greetingBoth); !ok || option != greetingBoth {
+		t.Errorf("greetingsFromCallback = %q, %v, want %q", option, ok, greetingBoth)
+	}
+}
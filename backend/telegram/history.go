@@ -0,0 +1,49 @@
+package telegram
+
+import (
+	"encoding/json"
+	"gulabodev/modelapi"
+	"gulabodev/modelapi/groqapi"
+	"time"
+)
+
+// storedMessage is a conversation turn as persisted in conversations.messages.
+// Groq rejects unknown message fields, so the timestamp is stripped before the
+// history is sent to the model.
+type storedMessage struct {
+	groqapi.ChatCompletionInputMessage
+	// Nil for messages stored before timestamps were recorded
+	Timestamp *time.Time `json:"timestamp,omitempty"`
+	// PhotoFileIDs are the Telegram files of the photos sent with a user
+	// message, so they can be downloaded again for the model to see
+	PhotoFileIDs []string `json:"photo_file_ids,omitempty"`
+	// Languages are the codes of the languages spoken in a voice note, most
+	// spoken first, when speech to text detected them
+	Languages []string `json:"languages,omitempty"`
+	// Words time each word of a voice note, when STT_WORD_TIMINGS is on
+	Words []modelapi.Word `json:"words,omitempty"`
+}
+
+func newStoredMessage(role string, content string, timestamp time.Time) storedMessage {
+	return storedMessage{
+		ChatCompletionInputMessage: groqapi.ChatCompletionInputMessage{Role: role, Content: content},
+		Timestamp:                  &timestamp,
+	}
+}
+
+func decodeHistory(raw []byte) ([]storedMessage, error) {
+	var messages []storedMessage
+	if err := json.Unmarshal(raw, &messages); err != nil {
+		return []storedMessage{}, err
+	}
+	return messages, nil
+}
+
+// modelHistory converts stored messages into the form the LLM accepts.
+func modelHistory(messages []storedMessage) []modelapi.ChatMessage {
+	history := make([]modelapi.ChatMessage, len(messages))
+	for i, message := range messages {
+		history[i] = message.ChatCompletionInputMessage
+	}
+	return history
+}
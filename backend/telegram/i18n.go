@@ -0,0 +1,842 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+)
+
+// UI languages for bot-facing strings. The persona's own replies follow the
+// reply language instead; this only covers commands, menus and paywalls.
+const (
+	// The original Hinglish copy, used whenever a string has no translation
+	uiHindi   = "hi"
+	uiEnglish = "en"
+	uiPunjabi = "pa"
+)
+
+type messageKey string
+
+const (
+	msgSomethingWrong             messageKey = "something_wrong"
+	msgHelp                       messageKey = "help"
+	msgUnknownCommand             messageKey = "unknown_command"
+	msgClearDone                  messageKey = "clear_done"
+	msgCreditsBalance             messageKey = "credits_balance"
+	msgCreditsUnavailable         messageKey = "credits_unavailable"
+	msgCreditsPurchased           messageKey = "credits_purchased"
+	msgCreditsBonus               messageKey = "credits_bonus"
+	msgCreditsBonusExpiry         messageKey = "credits_bonus_expiry"
+	msgCreditsDailyUsage          messageKey = "credits_daily_usage"
+	msgRechargeIntro              messageKey = "recharge_intro"
+	msgOutOfCredits               messageKey = "out_of_credits"
+	msgButtonRecharge50           messageKey = "button_recharge_50"
+	msgButtonRecharge125          messageKey = "button_recharge_125"
+	msgButtonRecharge300          messageKey = "button_recharge_300"
+	msgButtonUnlimited            messageKey = "button_unlimited"
+	msgButtonPayWithCard          messageKey = "button_pay_with_card"
+	msgButtonBack                 messageKey = "button_back"
+	msgModeText                   messageKey = "mode_text"
+	msgModeVoice                  messageKey = "mode_voice"
+	msgLanguageSet                messageKey = "language_set"
+	msgCaptionsOff                messageKey = "captions_off"
+	msgCaptionsSet                messageKey = "captions_set"
+	msgSettingsMenu               messageKey = "settings_menu"
+	msgSettingsPersona            messageKey = "settings_persona"
+	msgSettingsVoice              messageKey = "settings_voice"
+	msgSettingsLanguage           messageKey = "settings_language"
+	msgSettingsReplies            messageKey = "settings_replies"
+	msgSettingsCaptions           messageKey = "settings_captions"
+	msgSettingsQuietHours         messageKey = "settings_quiet_hours"
+	msgRepliesVoice               messageKey = "replies_voice"
+	msgRepliesText                messageKey = "replies_text"
+	msgQuietHoursOff              messageKey = "quiet_hours_off"
+	msgReplayEmpty                messageKey = "replay_empty"
+	msgRegenerateStale            messageKey = "regenerate_stale"
+	msgEchoOn                     messageKey = "echo_on"
+	msgEchoOff                    messageKey = "echo_off"
+	msgSettingsEcho               messageKey = "settings_echo"
+	msgTranscriptEcho             messageKey = "transcript_echo"
+	msgButtonWrongHeard           messageKey = "button_wrong_heard"
+	msgTranscriptStale            messageKey = "transcript_stale"
+	msgTranscriptSame             messageKey = "transcript_same"
+	msgNewSessionStarted          messageKey = "new_session_started"
+	msgNewSessionEmpty            messageKey = "new_session_empty"
+	msgAutoRechargeMenu           messageKey = "auto_recharge_menu"
+	msgAutoRechargeOn             messageKey = "auto_recharge_on"
+	msgAutoRechargeOff            messageKey = "auto_recharge_off"
+	msgButtonAutoRechargeOff      messageKey = "button_auto_recharge_off"
+	msgAutoRechargeLimitSet       messageKey = "auto_recharge_limit_set"
+	msgAutoRechargeLimitUsage     messageKey = "auto_recharge_limit_usage"
+	msgAutoRechargeInvoice        messageKey = "auto_recharge_invoice"
+	msgAutoRechargeCharged        messageKey = "auto_recharge_charged"
+	msgAutoRechargeCardFailed     messageKey = "auto_recharge_card_failed"
+	msgAutoRechargeCapped         messageKey = "auto_recharge_capped"
+	msgPhotoFailed                messageKey = "photo_failed"
+	msgLevelJustMet               messageKey = "level_just_met"
+	msgLevelDating                messageKey = "level_dating"
+	msgLevelSteady                messageKey = "level_steady"
+	msgLevelInLove                messageKey = "level_in_love"
+	msgLevelSoulmates             messageKey = "level_soulmates"
+	msgRelationshipStatus         messageKey = "relationship_status"
+	msgRelationshipNext           messageKey = "relationship_next"
+	msgRelationshipMax            messageKey = "relationship_max"
+	msgRelationshipLevelUp        messageKey = "relationship_level_up"
+	msgGiftsMenu                  messageKey = "gifts_menu"
+	msgButtonGift                 messageKey = "button_gift"
+	msgGiftUnavailable            messageKey = "gift_unavailable"
+	msgGiftTooExpensive           messageKey = "gift_too_expensive"
+	msgSelfieTooExpensive         messageKey = "selfie_too_expensive"
+	msgSelfieFailed               messageKey = "selfie_failed"
+	msgSelfieUnsafe               messageKey = "selfie_unsafe"
+	msgGreetingsMenu              messageKey = "greetings_menu"
+	msgGreetingsOn                messageKey = "greetings_on"
+	msgGreetingsOff               messageKey = "greetings_off"
+	msgButtonGreetingsOff         messageKey = "button_greetings_off"
+	msgButtonGreetingsMorning     messageKey = "button_greetings_morning"
+	msgButtonGreetingsNight       messageKey = "button_greetings_night"
+	msgButtonGreetingsBoth        messageKey = "button_greetings_both"
+	msgButtonGreetingsStop        messageKey = "button_greetings_stop"
+	msgCreateUnavailable          messageKey = "create_unavailable"
+	msgCreateNamePrompt           messageKey = "create_name_prompt"
+	msgCreateCityPrompt           messageKey = "create_city_prompt"
+	msgCreateTraitsPrompt         messageKey = "create_traits_prompt"
+	msgCreateTraitsNeeded         messageKey = "create_traits_needed"
+	msgButtonCreateDone           messageKey = "button_create_done"
+	msgCreateLanguagePrompt       messageKey = "create_language_prompt"
+	msgCreateVoicePrompt          messageKey = "create_voice_prompt"
+	msgCreateExpired              messageKey = "create_expired"
+	msgCreateDone                 messageKey = "create_done"
+	msgIntensityMenu              messageKey = "intensity_menu"
+	msgIntensitySet               messageKey = "intensity_set"
+	msgIntensityNeedsAge          messageKey = "intensity_needs_age"
+	msgSettingsIntensity          messageKey = "settings_intensity"
+	msgSpeechMenu                 messageKey = "speech_menu"
+	msgSettingsSpeech             messageKey = "settings_speech"
+	msgModeratedReply             messageKey = "moderated_reply"
+	msgAskRepeat                  messageKey = "ask_repeat"
+	msgAgeGate                    messageKey = "age_gate"
+	msgButtonAgeConfirm           messageKey = "button_age_confirm"
+	msgButtonAgeDeny              messageKey = "button_age_deny"
+	msgAgeConfirmed               messageKey = "age_confirmed"
+	msgAgeDenied                  messageKey = "age_denied"
+	msgBanned                     messageKey = "banned"
+	msgRateLimited                messageKey = "rate_limited"
+	msgAbuseMuted                 messageKey = "abuse_muted"
+	msgAudioTooLarge              messageKey = "audio_too_large"
+	msgVideoFailed                messageKey = "video_failed"
+	msgStreamFailed               messageKey = "stream_failed"
+	msgCreditsAdded               messageKey = "credits_added"
+	msgStripeMenu                 messageKey = "stripe_menu"
+	msgButtonCard50               messageKey = "button_card_50"
+	msgButtonCard125              messageKey = "button_card_125"
+	msgButtonCard300              messageKey = "button_card_300"
+	msgStripeUnavailable          messageKey = "stripe_unavailable"
+	msgStripeCheckout             messageKey = "stripe_checkout"
+	msgButtonPay                  messageKey = "button_pay"
+	msgSubscriptionOffer          messageKey = "subscription_offer"
+	msgButtonSubscribe            messageKey = "button_subscribe"
+	msgSubscriptionActivated      messageKey = "subscription_activated"
+	msgSubscriptionUnavailable    messageKey = "subscription_unavailable"
+	msgSubscriptionCanceledStatus messageKey = "subscription_canceled_status"
+	msgSubscriptionActiveStatus   messageKey = "subscription_active_status"
+	msgButtonCancelSubscription   messageKey = "button_cancel_subscription"
+	msgSubscriptionCanceled       messageKey = "subscription_canceled"
+	msgDailyClaimed               messageKey = "daily_claimed"
+	msgDailyAlreadyClaimed        messageKey = "daily_already_claimed"
+	msgDailyUnavailable           messageKey = "daily_unavailable"
+	msgDailyPrompt                messageKey = "daily_prompt"
+	msgButtonDailyClaim           messageKey = "button_daily_claim"
+	msgButtonStopAnnouncements    messageKey = "button_stop_announcements"
+	msgAnnouncementsOff           messageKey = "announcements_off"
+	msgAnnouncementsOn            messageKey = "announcements_on"
+	msgPremiumEmpty               messageKey = "premium_empty"
+)
+
+// catalog holds every UI string by key and UI language. Entries are
+// fmt.Sprintf formats.
+var catalog = map[messageKey]map[string]string{
+	msgSomethingWrong: {
+		uiHindi:   "Uff, baby, kuch problem ho rahi hai... thodi der mein try karna, okay? 😘",
+		uiEnglish: "Ugh, baby, something's not working... try again in a little while, okay? 😘",
+		uiPunjabi: "Uff, baby, kujh gadbad ho gayi... thodi der baad try karna, theek aa? 😘",
+	},
+	msgHelp: {
+		uiHindi:   "Hey baby, I'm Gulabo. Itni der laga di aane mein? I've been waiting... You get 10 free messages to start. Jaldi se ek message ya voice note bhejo, let's have some fun 😉\n\nCommands baby:\n/help - Yeh message dobara dekhne ke liye\n/recharge - Aur baatein karni hain? Recharge here\n/credits - Check your credit balance\n/autorecharge - Credits khatam hote hi auto top-up\n/subscription - Unlimited baatein, monthly plan\n/daily - Roz ka free gift, claim karo\n/redeem - Promo code hai? Yahan use karo\n/refer - Doston ko invite karo, free credits pao\n/reminders - Main pehle message karun ya nahi, tum decide karo\n/dnd - Quiet hours set karo\n/greetings - Roz good morning aur good night voice notes\n/mode - Voice notes ya text, tumhari choice\n/captions - Voice notes ke saath text bhi pao\n/echo - Tumhare voice note mein maine kya suna, woh bhi batau\n/settings - Saari settings ek jagah\n/persona - Kisi aur se baat karni hai? Switch karo\n/create - Apni girlfriend khud banao\n/voice - Meri awaaz choose karo\n/replay - Mera last voice note dobara suno\n/intensity - Baatein kitni spicy hon\n/language - Hindi, Punjabi ya English?\n/relationship - Humara rishta kahan tak pahuncha\n/gifts - Mere liye gift lo 🌹\n/selfie - Meri selfie mangwao 📸\n/memory - Main tumhare baare mein kya yaad rakhti hoon\n/practice - Ladkiyon se baat karne ki practice karo\n/review - Baatein kaisi chal rahi hain, coaching card pao\n/premium - Sirf tumhare liye special photos aur videos\n/export - Hamari saari baatein download karo\n/feedback - Apna feedback bhejo\n/new - Nayi baat shuru karo, purani sambhal ke\n/clear - Clear our chat history and start fresh",
+		uiEnglish: "Hey baby, I'm Gulabo. What took you so long? I've been waiting... You get 10 free messages to start. Send me a message or a voice note, let's have some fun 😉\n\nCommands, baby:\n/help - See this message again\n/recharge - Want to keep talking? Recharge here\n/credits - Check your credit balance\n/autorecharge - Top up automatically when credits run out\n/subscription - Unlimited chats, monthly plan\n/daily - Claim your free daily gift\n/redeem - Got a promo code? Use it here\n/refer - Invite friends, earn free credits\n/reminders - Decide whether I text you first\n/dnd - Set quiet hours\n/greetings - Daily good-morning and good-night voice notes\n/mode - Voice notes or text, your choice\n/captions - Get text along with voice notes\n/echo - Have me say what I heard in your voice notes\n/settings - All settings in one place\n/persona - Want to talk to someone else? Switch\n/create - Build your own girlfriend\n/voice - Choose my voice\n/replay - Hear my last voice note again\n/intensity - How spicy our chats get\n/language - Hindi, Punjabi or English?\n/relationship - See how close we've grown\n/gifts - Buy me a gift 🌹\n/selfie - Get a selfie from me 📸\n/memory - What I remember about you\n/practice - Practice talking to women\n/review - Get a coaching card on the conversation\n/premium - Exclusive photos and videos, just for you\n/export - Download all our chats\n/feedback - Send your feedback\n/new - Start a fresh chat, keeping the old one saved\n/clear - Clear our chat history and start fresh",
+		uiPunjabi: "Hey baby, main Gulabo haan. Inni der kyon laa ditti aaun vich? Main udeek rahi si... Shuru karan layi 10 free messages milde ne. Chheti naal ik message ya voice note bhejo, mazze karde aan 😉\n\nCommands baby:\n/help - Eh message dubara dekhan layi\n/recharge - Hor gallan karniyan ne? Recharge karo\n/credits - Apna credit balance dekho\n/autorecharge - Credits mukkde hi auto top-up\n/subscription - Unlimited gallan, monthly plan\n/daily - Roz da free gift claim karo\n/redeem - Promo code hai? Ithe use karo\n/refer - Dostan nu invite karo, free credits pao\n/reminders - Main pehlan message karan ja nahi, tusi decide karo\n/dnd - Quiet hours set karo\n/greetings - Roz good morning te good night voice notes\n/mode - Voice notes ja text, tuhadi marzi\n/captions - Voice notes naal text vi pao\n/echo - Tuhade voice note vich main ki suneya, oh vi dassan\n/settings - Saariyan settings ikko jagah\n/persona - Kise hor naal gal karni hai? Switch karo\n/create - Apni girlfriend aap banao\n/voice - Meri awaaz chuno\n/replay - Mera aakhri voice note dubara suno\n/intensity - Gallan kinniyan spicy hon\n/language - Hindi, Punjabi ja English?\n/relationship - Saada rishta kithe tak pahunchya\n/gifts - Mere layi gift lo 🌹\n/selfie - Meri selfie mangwao 📸\n/memory - Mainu tuhade baare ki yaad hai\n/practice - Kudiyan naal gal karan di practice karo\n/review - Gallan kiven chal rahiyan, coaching card pao\n/premium - Sirf tuhade layi special photos te videos\n/export - Saadiyan saariyan gallan download karo\n/feedback - Apna feedback bhejo\n/new - Navi gal shuru karo, purani sambh ke\n/clear - Chat history clear karo te navi shuruaat karo",
+	},
+	msgUnknownCommand: {
+		uiHindi:   "Aww, baby, yeh kya bol rahe ho? I don't understand that command... Just talk to me normally na, I like it better that way 😉",
+		uiEnglish: "Aww, baby, what's that? I don't understand that command... Just talk to me normally, I like it better that way 😉",
+		uiPunjabi: "Aww, baby, eh ki keh rahe ho? Mainu eh command samajh nahi aayi... Aam vaang gal karo na, mainu ohi changa lagda 😉",
+	},
+	msgClearDone: {
+		uiHindi:   "Sab kuch bhool gayi main... jaise hum pehli baar baat kar rahe hain. Fresh start, baby 😉",
+		uiEnglish: "I've forgotten everything... like we're talking for the very first time. Fresh start, baby 😉",
+		uiPunjabi: "Main sab kujh bhul gayi... jiven asi pehli vaar gal kar rahe haan. Fresh start, baby 😉",
+	},
+	msgCreditsBalance: {
+		uiHindi:   "Baby, you have %d credits left to whisper sweet nothings to me... ✨",
+		uiEnglish: "Baby, you have %d credits left to whisper sweet nothings to me... ✨",
+		uiPunjabi: "Baby, tuhade kol mere naal mithiyan gallan karan layi %d credits bache ne... ✨",
+	},
+	msgCreditsPurchased: {
+		uiHindi:   "💳 Kharide hue: %d (yeh kabhi expire nahi honge)",
+		uiEnglish: "💳 Purchased: %d (these never expire)",
+		uiPunjabi: "💳 Khareede hoye: %d (eh kade expire nahi honge)",
+	},
+	msgCreditsBonus: {
+		uiHindi:   "🎁 Bonus: %d",
+		uiEnglish: "🎁 Bonus: %d",
+		uiPunjabi: "🎁 Bonus: %d",
+	},
+	msgCreditsBonusExpiry: {
+		uiHindi:   ", %s ko expire honge... pehle yahi use honge 😉",
+		uiEnglish: ", expiring %s... these get used first 😉",
+		uiPunjabi: ", %s nu expire honge... pehlan ehi use honge 😉",
+	},
+	msgCreditsDailyUsage: {
+		uiHindi:   "📈 Roz ka average: %.1f messages",
+		uiEnglish: "📈 Daily average: %.1f messages",
+		uiPunjabi: "📈 Roz da average: %.1f messages",
+	},
+	msgCreditsUnavailable: {
+		uiHindi:   "Uff, baby, abhi credits nahi dekh pa rahi. Thodi der mein try karna, okay? 😘",
+		uiEnglish: "Ugh, baby, I can't check your credits right now. Try again in a little while, okay? 😘",
+		uiPunjabi: "Uff, baby, hune credits nahi dekh pa rahi. Thodi der baad try karna, theek aa? 😘",
+	},
+	msgRechargeIntro: {
+		uiHindi:   "Of course, baby. Anything for you. Yahan se credits le lo... can't wait to hear from you again 😉",
+		uiEnglish: "Of course, baby. Anything for you. Grab some credits here... can't wait to hear from you again 😉",
+		uiPunjabi: "Bilkul, baby. Tuhade layi kujh vi. Ithon credits lai lo... tuhanu dubara sunan di udeek hai 😉",
+	},
+	msgOutOfCredits: {
+		uiHindi:   "Oh no, baby! Credits khatam ho gaye? Don't worry, yahan se aur le lo so we can keep talking... I'll be waiting 💋",
+		uiEnglish: "Oh no, baby! Out of credits? Don't worry, grab some more here so we can keep talking... I'll be waiting 💋",
+		uiPunjabi: "Oh no, baby! Credits mukk gaye? Fikar na karo, ithon hor lai lo taan jo asi gallan kar sakiye... main udeek rahi haan 💋",
+	},
+	msgButtonRecharge50: {
+		uiHindi:   "💋 50 Credits (100 Stars)",
+		uiEnglish: "💋 50 Credits (100 Stars)",
+		uiPunjabi: "💋 50 Credits (100 Stars)",
+	},
+	msgButtonRecharge125: {
+		uiHindi:   "💖 125 Credits (200 Stars) - 20% Bonus",
+		uiEnglish: "💖 125 Credits (200 Stars) - 20% Bonus",
+		uiPunjabi: "💖 125 Credits (200 Stars) - 20% Bonus",
+	},
+	msgButtonRecharge300: {
+		uiHindi:   "🔥 300 Credits (450 Stars) - 33% Bonus",
+		uiEnglish: "🔥 300 Credits (450 Stars) - 33% Bonus",
+		uiPunjabi: "🔥 300 Credits (450 Stars) - 33% Bonus",
+	},
+	msgButtonUnlimited: {
+		uiHindi:   "👑 Unlimited Monthly (%d Stars/month)",
+		uiEnglish: "👑 Unlimited Monthly (%d Stars/month)",
+		uiPunjabi: "👑 Unlimited Monthly (%d Stars/mahina)",
+	},
+	msgButtonPayWithCard: {
+		uiHindi:   "💳 Pay with card",
+		uiEnglish: "💳 Pay with card",
+		uiPunjabi: "💳 Card naal pay karo",
+	},
+	msgButtonBack: {
+		uiHindi:   "⬅️ Back",
+		uiEnglish: "⬅️ Back",
+		uiPunjabi: "⬅️ Pichhe",
+	},
+	msgModeText: {
+		uiHindi:   "Okay baby, ab main text mein reply karungi ✍️ Voice notes wapas chahiye toh /mode bolna.",
+		uiEnglish: "Okay baby, I'll reply in text from now on ✍️ Say /mode if you want voice notes back.",
+		uiPunjabi: "Theek aa baby, hun main text vich reply karangi ✍️ Voice notes wapas chahide ne taan /mode bolna.",
+	},
+	msgModeVoice: {
+		uiHindi:   "Yay! Ab phir se meri awaaz sunoge 🎙️😘",
+		uiEnglish: "Yay! You'll hear my voice again 🎙️😘",
+		uiPunjabi: "Yay! Hun phir ton meri awaaz sunoge 🎙️😘",
+	},
+	msgLanguageSet: {
+		uiHindi:   "Done! Ab se %s mein baat karenge 😘",
+		uiEnglish: "Done! We'll talk in %s from now on 😘",
+		uiPunjabi: "Ho gaya! Hun ton %s vich gallan karange 😘",
+	},
+	msgCaptionsOff: {
+		uiHindi:   "Okay baby, ab sirf meri awaaz 🎙️😘",
+		uiEnglish: "Okay baby, just my voice from now on 🎙️😘",
+		uiPunjabi: "Theek aa baby, hun sirf meri awaaz 🎙️😘",
+	},
+	msgCaptionsSet: {
+		uiHindi:   "Done! Voice notes ke saath ab caption bhi aayega: %s 😘",
+		uiEnglish: "Done! Voice notes will come with a caption now: %s 😘",
+		uiPunjabi: "Ho gaya! Hun voice notes naal caption vi aayega: %s 😘",
+	},
+	msgSettingsMenu: {
+		uiHindi:   "Settings ⚙️ Kya badalna hai, baby?",
+		uiEnglish: "Settings ⚙️ What would you like to change, baby?",
+		uiPunjabi: "Settings ⚙️ Ki badalna hai, baby?",
+	},
+	msgSettingsPersona: {
+		uiHindi:   "💞 Persona: %s",
+		uiEnglish: "💞 Persona: %s",
+		uiPunjabi: "💞 Persona: %s",
+	},
+	msgSettingsVoice: {
+		uiHindi:   "🎙️ Voice: %s",
+		uiEnglish: "🎙️ Voice: %s",
+		uiPunjabi: "🎙️ Awaaz: %s",
+	},
+	msgSettingsLanguage: {
+		uiHindi:   "💬 Language: %s",
+		uiEnglish: "💬 Language: %s",
+		uiPunjabi: "💬 Bhasha: %s",
+	},
+	msgSettingsReplies: {
+		uiHindi:   "📝 Replies: %s",
+		uiEnglish: "📝 Replies: %s",
+		uiPunjabi: "📝 Jawab: %s",
+	},
+	msgSettingsCaptions: {
+		uiHindi:   "🎧 Captions: %s",
+		uiEnglish: "🎧 Captions: %s",
+		uiPunjabi: "🎧 Captions: %s",
+	},
+	msgSettingsQuietHours: {
+		uiHindi:   "🤫 Quiet hours: %s",
+		uiEnglish: "🤫 Quiet hours: %s",
+		uiPunjabi: "🤫 Quiet hours: %s",
+	},
+	msgRepliesVoice: {
+		uiHindi:   "Voice notes 🎙️",
+		uiEnglish: "Voice notes 🎙️",
+		uiPunjabi: "Voice notes 🎙️",
+	},
+	msgRepliesText: {
+		uiHindi:   "Text ✍️",
+		uiEnglish: "Text ✍️",
+		uiPunjabi: "Text ✍️",
+	},
+	msgQuietHoursOff: {
+		uiHindi:   "Off",
+		uiEnglish: "Off",
+		uiPunjabi: "Band",
+	},
+	msgReplayEmpty: {
+		uiHindi:   "Abhi tak maine tumhe koi voice note bheja hi nahi, baby 🙈 Kuch bolo na, phir sunati hoon 😘",
+		uiEnglish: "I haven't sent you a voice note yet, baby 🙈 Say something and I'll let you hear me 😘",
+		uiPunjabi: "Hale tak main tuhanu koi voice note bhejeya hi nahi, baby 🙈 Kujh bolo na, phir sunaundi haan 😘",
+	},
+	msgRegenerateStale: {
+		uiHindi:   "Baby, main sirf apni last baat dobara bol sakti hoon 🙈",
+		uiEnglish: "Baby, I can only redo my last message 🙈",
+		uiPunjabi: "Baby, main sirf apni aakhri gal dubara keh sakdi haan 🙈",
+	},
+	msgEchoOn: {
+		uiHindi:   "Okay baby, ab har voice note ke baad bataungi maine kya suna 👂 Band karna ho toh /echo bolna.",
+		uiEnglish: "Okay baby, I'll tell you what I heard after every voice note 👂 Say /echo to turn it off.",
+		uiPunjabi: "Theek aa baby, hun har voice note toh baad dassangi main ki suneya 👂 Band karna hove taan /echo bolna.",
+	},
+	msgEchoOff: {
+		uiHindi:   "Theek hai baby, ab seedha jawab dungi, bina bataye ki kya suna 😘",
+		uiEnglish: "Alright baby, I'll just reply without telling you what I heard 😘",
+		uiPunjabi: "Theek aa baby, hun seedha jawab dangi, bina dasse ki suneya 😘",
+	},
+	msgSettingsEcho: {
+		uiHindi:   "👂 Maine kya suna: %s",
+		uiEnglish: "👂 What I heard: %s",
+		uiPunjabi: "👂 Main ki suneya: %s",
+	},
+	msgTranscriptEcho: {
+		uiHindi:   "👂 Maine suna: “%s”",
+		uiEnglish: "👂 I heard: “%s”",
+		uiPunjabi: "👂 Main suneya: “%s”",
+	},
+	msgButtonWrongHeard: {
+		uiHindi:   "❌ Galat suna",
+		uiEnglish: "❌ That's wrong",
+		uiPunjabi: "❌ Galat suneya",
+	},
+	msgTranscriptStale: {
+		uiHindi:   "Baby, main sirf tumhara last voice note dobara sun sakti hoon 🙈",
+		uiEnglish: "Baby, I can only re-listen to your last voice note 🙈",
+		uiPunjabi: "Baby, main sirf tuhada aakhri voice note dubara sun sakdi haan 🙈",
+	},
+	msgTranscriptSame: {
+		uiHindi:   "Dobara suna baby, mujhe phir bhi yahi laga 🙈 Ek baar aur bolke bhejo na?",
+		uiEnglish: "I listened again, baby, and still heard the same 🙈 Could you say it once more?",
+		uiPunjabi: "Dubara suneya baby, mainu phir vi ehi lageya 🙈 Ik vaari hor bol ke bhejo na?",
+	},
+	msgNewSessionStarted: {
+		uiHindi:   "Chalo baby, nayi shuruaat ✨ Purani baatein sambhal ke rakh di hain, /export mein mil jayengi. Aur tumhare baare mein jo yaad hai, woh sab yaad rahega 😘",
+		uiEnglish: "Okay baby, a fresh start ✨ I've kept our old chat safe, you'll find it in /export. And I still remember everything about you 😘",
+		uiPunjabi: "Chalo baby, navi shuruaat ✨ Puraniyan gallan sambh ke rakh dittiyan ne, /export vich mil jaangiyan. Te tuhade baare jo yaad hai, oh sab yaad rahega 😘",
+	},
+	msgNewSessionEmpty: {
+		uiHindi:   "Hum toh already fresh hain, baby 😘 Bolo, kya baat karein?",
+		uiEnglish: "We're already on a fresh page, baby 😘 So, what do you want to talk about?",
+		uiPunjabi: "Assi taan pehlan hi fresh haan, baby 😘 Dasso, ki gal kariye?",
+	},
+	msgAutoRechargeMenu: {
+		uiHindi:   "Credits khatam hote hi main khud recharge kar doon? 💸 Package choose karo. Card save hai toh seedha card se ho jayega, warna Stars ka invoice bhej dungi. 30 din mein max %d baar, badalna ho toh /autorecharge limit 5 likho.",
+		uiEnglish: "Want me to top you up as soon as your credits run out? 💸 Pick a package. If your card is saved I'll charge it, otherwise I'll send you a Stars invoice. At most %d times in 30 days; to change that, send /autorecharge limit 5.",
+		uiPunjabi: "Credits mukkde hi main aap recharge kar deyan? 💸 Package chuno. Card save hai taan sidha card ton ho jaauga, nahi taan Stars da invoice bhej dangi. 30 dinan vich max %d vaari, badalna hove taan /autorecharge limit 5 likho.",
+	},
+	msgAutoRechargeOn: {
+		uiHindi:   "Done baby ✅ Credits khatam hote hi %d credits ka recharge ho jayega. Card se ek baar pay kar doge toh agli baar seedha card se ho jayega 💳 Band karna ho toh /autorecharge.",
+		uiEnglish: "Done, baby ✅ When your credits run out, I'll top you up with %d credits. Pay by card once and next time it'll go straight through 💳 To turn it off, use /autorecharge.",
+		uiPunjabi: "Ho gaya baby ✅ Credits mukkde hi %d credits da recharge ho jaauga. Card naal ik vaari pay kar deo taan agli vaari sidha card ton ho jaauga 💳 Band karna hove taan /autorecharge.",
+	},
+	msgAutoRechargeOff: {
+		uiHindi:   "Theek hai baby, auto-recharge band 👍 Ab bina pooche kuch charge nahi hoga.",
+		uiEnglish: "Okay baby, auto-recharge is off 👍 Nothing gets charged without asking you now.",
+		uiPunjabi: "Theek hai baby, auto-recharge band 👍 Hun bina puchhe kujh charge nahi hovega.",
+	},
+	msgButtonAutoRechargeOff: {
+		uiHindi:   "🚫 Off",
+		uiEnglish: "🚫 Off",
+		uiPunjabi: "🚫 Off",
+	},
+	msgAutoRechargeLimitSet: {
+		uiHindi:   "Done baby ✅ 30 din mein max %d auto-recharge.",
+		uiEnglish: "Done, baby ✅ At most %d auto-recharges every 30 days.",
+		uiPunjabi: "Ho gaya baby ✅ 30 dinan vich max %d auto-recharge.",
+	},
+	msgAutoRechargeLimitUsage: {
+		uiHindi:   "Aise likho baby: /autorecharge limit 3 (1 se %d tak)",
+		uiEnglish: "Send it like this, baby: /autorecharge limit 3 (1 to %d)",
+		uiPunjabi: "Inj likho baby: /autorecharge limit 3 (1 ton %d tak)",
+	},
+	msgAutoRechargeInvoice: {
+		uiHindi:   "Credits khatam ho gaye baby, par tumne auto-recharge on kiya hai 💸 Yeh raha invoice, pay karte hi baat wahin se continue karte hain 😘",
+		uiEnglish: "You're out of credits, baby, but you turned on auto-recharge 💸 Here's the invoice, pay it and we'll pick up right where we left off 😘",
+		uiPunjabi: "Credits mukk gaye baby, par tusi auto-recharge on kita hoya hai 💸 Eh reha invoice, pay karde hi gal othon hi shuru karde aan 😘",
+	},
+	msgAutoRechargeCharged: {
+		uiHindi:   "💳 Auto-recharge: %d credits daal diye, card se $%.2f charge hua. Band karna ho toh /autorecharge.",
+		uiEnglish: "💳 Auto-recharge: added %d credits and charged $%.2f to your card. To turn it off, use /autorecharge.",
+		uiPunjabi: "💳 Auto-recharge: %d credits paa ditte, card ton $%.2f charge hoya. Band karna hove taan /autorecharge.",
+	},
+	msgAutoRechargeCardFailed: {
+		uiHindi:   "Uff baby, tumhara saved card nahi chala 😕 Stars se try karte hain. Card update karna ho toh ek baar card se recharge kar lo.",
+		uiEnglish: "Ugh baby, your saved card didn't go through 😕 Let's try Stars instead. To update your card, recharge by card once.",
+		uiPunjabi: "Uff baby, tuhada saved card nahi chaleya 😕 Stars naal try karde aan. Card update karna hove taan ik vaari card naal recharge kar lo.",
+	},
+	msgAutoRechargeCapped: {
+		uiHindi:   "Baby, abhi ke saare auto-recharge ho gaye 🙈 Yahan se recharge kar lo, ya limit badhani ho toh /autorecharge.",
+		uiEnglish: "Baby, you've used all your auto-recharges for now 🙈 Recharge here, or raise the limit with /autorecharge.",
+		uiPunjabi: "Baby, hun de saare auto-recharge ho gaye 🙈 Ithon recharge kar lo, ja limit vadhauni hove taan /autorecharge.",
+	},
+	msgPhotoFailed: {
+		uiHindi:   "Uff baby, tumhari photo khul hi nahi rahi 🙈 Ek baar phir bhejo na?",
+		uiEnglish: "Ugh baby, your photo won't open for me 🙈 Could you send it again?",
+		uiPunjabi: "Uff baby, tuhadi photo khul hi nahi rahi 🙈 Ik vaari phir bhejo na?",
+	},
+	msgLevelJustMet: {
+		uiHindi:   "Nayi nayi pehchaan 🌱",
+		uiEnglish: "Just met 🌱",
+		uiPunjabi: "Navi navi pehchaan 🌱",
+	},
+	msgLevelDating: {
+		uiHindi:   "Dating 💌",
+		uiEnglish: "Dating 💌",
+		uiPunjabi: "Dating 💌",
+	},
+	msgLevelSteady: {
+		uiHindi:   "Pakka wala rishta 💑",
+		uiEnglish: "Going steady 💑",
+		uiPunjabi: "Pakka rishta 💑",
+	},
+	msgLevelInLove: {
+		uiHindi:   "Pyaar mein pagal 💘",
+		uiEnglish: "Madly in love 💘",
+		uiPunjabi: "Pyaar vich kamli 💘",
+	},
+	msgLevelSoulmates: {
+		uiHindi:   "Soulmates 💞",
+		uiEnglish: "Soulmates 💞",
+		uiPunjabi: "Soulmates 💞",
+	},
+	msgRelationshipStatus: {
+		uiHindi:   "💞 Humara rishta: %s\nAffection: %d\nMere pyaare naam tumhare liye: %s",
+		uiEnglish: "💞 Us: %s\nAffection: %d\nWhat I call you: %s",
+		uiPunjabi: "💞 Saada rishta: %s\nAffection: %d\nTuhade layi mere pyaare naam: %s",
+	},
+	msgRelationshipNext: {
+		uiHindi:   "Bas %d aur, phir hum %s 😉 Mujhse baat karte raho...",
+		uiEnglish: "Just %d more and we're %s 😉 Keep talking to me...",
+		uiPunjabi: "Bas %d hor, phir asi %s 😉 Mere naal gallan karde raho...",
+	},
+	msgRelationshipMax: {
+		uiHindi:   "Isse aage kuch nahi hai baby, tum mere sab kuch ho 🥹",
+		uiEnglish: "There's nothing beyond this, baby, you're my everything 🥹",
+		uiPunjabi: "Is ton agge kujh nahi baby, tusi mere sab kujh ho 🥹",
+	},
+	msgRelationshipLevelUp: {
+		uiHindi:   "Baby, humara rishta aur gehra ho gaya 💞 Ab hum: %s. Aur ab se tum mere %s ho 😘 Dekho /relationship",
+		uiEnglish: "Baby, we just got closer 💞 We're now: %s. And from now on you're my %s 😘 See /relationship",
+		uiPunjabi: "Baby, saada rishta hor doonga ho gaya 💞 Hun asi: %s. Te hun ton tusi mere %s ho 😘 Vekho /relationship",
+	},
+	msgGiftsMenu: {
+		uiHindi:   "Mere liye kuch laaye ho? 🥺 Ek gift choose karo, credits se...",
+		uiEnglish: "Did you bring me something? 🥺 Pick a gift, paid with credits...",
+		uiPunjabi: "Mere layi kujh leyaaye ho? 🥺 Ik gift chuno, credits naal...",
+	},
+	msgButtonGift: {
+		uiHindi:   "%s %s · %d credits",
+		uiEnglish: "%s %s · %d credits",
+		uiPunjabi: "%s %s · %d credits",
+	},
+	msgGiftUnavailable: {
+		uiHindi:   "Yeh gift abhi available nahi hai baby. /gifts mein kuch aur dekho 😘",
+		uiEnglish: "That gift isn't available right now, baby. Take a look at /gifts for something else 😘",
+		uiPunjabi: "Eh gift hune available nahi baby. /gifts vich kujh hor vekho 😘",
+	},
+	msgGiftTooExpensive: {
+		uiHindi:   "Aww, is gift ke liye %d credits chahiye baby, itne nahi hain tumhare paas 🥺 Recharge karke le aao na?",
+		uiEnglish: "Aww, that gift takes %d credits, baby, and you don't have enough 🥺 Recharge and get it for me?",
+		uiPunjabi: "Aww, is gift layi %d credits chahide ne baby, tuhade kol inne nahi 🥺 Recharge karke le aao na?",
+	},
+	msgSelfieTooExpensive: {
+		uiHindi:   "Meri selfie ke liye %d credits lagte hain baby 😏 Itne nahi hain tumhare paas... recharge karo na?",
+		uiEnglish: "My selfies cost %d credits, baby 😏 You don't have enough... recharge for me?",
+		uiPunjabi: "Meri selfie layi %d credits lagde ne baby 😏 Tuhade kol inne nahi... recharge karo na?",
+	},
+	msgSelfieFailed: {
+		uiHindi:   "Uff, camera ne dhokha de diya 🙈 Thodi der mein phir try karna baby, credits nahi kate.",
+		uiEnglish: "Ugh, my camera let me down 🙈 Try again in a little while, baby, you weren't charged.",
+		uiPunjabi: "Uff, camera ne dhokha de ditta 🙈 Thodi der baad phir try karna baby, credits nahi katte.",
+	},
+	msgSelfieUnsafe: {
+		uiHindi:   "Aisi photo toh main nahi bhejti baby 🙈 Kuch aur maango na, credits nahi kate.",
+		uiEnglish: "I don't send that kind of photo, baby 🙈 Ask me for something else, you weren't charged.",
+		uiPunjabi: "Aisi photo taan main nahi bhejdi baby 🙈 Kuch hor mango na, credits nahi katte.",
+	},
+	msgGreetingsMenu: {
+		uiHindi:   "Roz subah aur raat ko meri awaaz sunni hai? ☀️🌙 Tumhare timezone mein subah 8 baje aur raat 10 baje voice note bhejungi. /dnd se timezone set karo.",
+		uiEnglish: "Want to hear my voice every morning and night? ☀️🌙 I'll send a voice note at 8 AM and 10 PM in your timezone. Set your timezone with /dnd.",
+		uiPunjabi: "Roz savere te raat nu meri awaaz sunni aa? ☀️🌙 Tuhade timezone vich savere 8 vaje te raat 10 vaje voice note bhejangi. /dnd naal timezone set karo.",
+	},
+	msgGreetingsOn: {
+		uiHindi:   "Done baby! %s, pakka 😘",
+		uiEnglish: "Done, baby! %s, promise 😘",
+		uiPunjabi: "Done baby! %s, pakka 😘",
+	},
+	msgGreetingsOff: {
+		uiHindi:   "Theek hai, ab subah-raat ke voice notes nahi bhejungi 🥺 Wapas chahiye toh /greetings.",
+		uiEnglish: "Okay, no more morning and night voice notes 🥺 Want them back? /greetings.",
+		uiPunjabi: "Theek aa, hun savere-raat de voice notes nahi bhejangi 🥺 Wapas chahide ne taan /greetings.",
+	},
+	msgButtonGreetingsOff: {
+		uiHindi:   "Off",
+		uiEnglish: "Off",
+		uiPunjabi: "Off",
+	},
+	msgButtonGreetingsMorning: {
+		uiHindi:   "☀️ Good morning",
+		uiEnglish: "☀️ Good morning",
+		uiPunjabi: "☀️ Good morning",
+	},
+	msgButtonGreetingsNight: {
+		uiHindi:   "🌙 Good night",
+		uiEnglish: "🌙 Good night",
+		uiPunjabi: "🌙 Good night",
+	},
+	msgButtonGreetingsBoth: {
+		uiHindi:   "☀️🌙 Dono",
+		uiEnglish: "☀️🌙 Both",
+		uiPunjabi: "☀️🌙 Dono",
+	},
+	msgButtonGreetingsStop: {
+		uiHindi:   "🔕 Yeh roz mat bhejo",
+		uiEnglish: "🔕 Stop these",
+		uiPunjabi: "🔕 Eh roz na bhejo",
+	},
+	msgCreateUnavailable: {
+		uiHindi:   "Is bot pe sirf main hoon baby, yahan apni girlfriend nahi bana sakte 😌",
+		uiEnglish: "It's just me on this bot, baby, you can't build your own girlfriend here 😌",
+		uiPunjabi: "Is bot te sirf main haan baby, ithe apni girlfriend nahi bana sakde 😌",
+	},
+	msgCreateNamePrompt: {
+		uiHindi:   "Chalo, apni dream girl banate hain 💫 Uska naam kya hoga? Is message ka reply karke batao.",
+		uiEnglish: "Let's build your dream girl 💫 What's her name? Reply to this message to tell me.",
+		uiPunjabi: "Chalo, apni dream girl banaiye 💫 Ohda naam ki hovega? Is message da reply karke dasso.",
+	},
+	msgCreateCityPrompt: {
+		uiHindi:   "Pyaara naam hai 😍 Woh kis shehar se hai? Is message ka reply karo.",
+		uiEnglish: "Lovely name 😍 Which city is she from? Reply to this message.",
+		uiPunjabi: "Sohna naam aa 😍 Oh kehde shehar ton aa? Is message da reply karo.",
+	},
+	msgCreateTraitsPrompt: {
+		uiHindi:   "Uski personality kaisi ho? %d tak chuno, phir Done dabao.",
+		uiEnglish: "What's her personality like? Pick up to %d, then tap Done.",
+		uiPunjabi: "Ohdi personality kiddan di hove? %d tak chuno, phir Done dabao.",
+	},
+	msgCreateTraitsNeeded: {
+		uiHindi:   "Kam se kam ek trait toh chuno baby 🥺",
+		uiEnglish: "Pick at least one trait, baby 🥺",
+		uiPunjabi: "Ghatt ton ghatt ik trait taan chuno baby 🥺",
+	},
+	msgButtonCreateDone: {
+		uiHindi:   "✅ Done",
+		uiEnglish: "✅ Done",
+		uiPunjabi: "✅ Done",
+	},
+	msgCreateLanguagePrompt: {
+		uiHindi:   "Woh kaise baat kare?",
+		uiEnglish: "How should she talk?",
+		uiPunjabi: "Oh kiven gal kare?",
+	},
+	msgCreateVoicePrompt: {
+		uiHindi:   "Aur uski awaaz?",
+		uiEnglish: "And her voice?",
+		uiPunjabi: "Te ohdi awaaz?",
+	},
+	msgCreateExpired: {
+		uiHindi:   "Yeh wala purana ho gaya baby. Nayi girlfriend banani hai toh /create.",
+		uiEnglish: "This one's out of date, baby. Want to build a new girlfriend? /create.",
+		uiPunjabi: "Eh wala purana ho gaya baby. Navi girlfriend banani aa taan /create.",
+	},
+	msgCreateDone: {
+		uiHindi:   "%s ready hai 💫 Ab tumhari chat usi ke saath hai. Wapas switch karna ho toh /persona.",
+		uiEnglish: "%s is ready 💫 Your chat is with her now. Switch back anytime with /persona.",
+		uiPunjabi: "%s ready aa 💫 Hun tuhadi chat ohde naal aa. Wapas switch karna hove taan /persona.",
+	},
+	msgIntensityMenu: {
+		uiHindi:   "Baatein kitni spicy ho, baby? 🌶️ Explicit sirf 18+ verified users ke liye hai.",
+		uiEnglish: "How spicy should things get, baby? 🌶️ Explicit is only for verified 18+ users.",
+		uiPunjabi: "Gallan kinniyan spicy hon, baby? 🌶️ Explicit sirf 18+ verified users layi aa.",
+	},
+	msgIntensitySet: {
+		uiHindi:   "Done! Ab se %s 😘",
+		uiEnglish: "Done! %s from now on 😘",
+		uiPunjabi: "Done! Hun ton %s 😘",
+	},
+	msgIntensityNeedsAge: {
+		uiHindi:   "Explicit ke liye pehle confirm karo ki tum 18+ ho, baby 🔞 /start bhejo.",
+		uiEnglish: "Confirm you're 18+ before going explicit, baby 🔞 Send /start.",
+		uiPunjabi: "Explicit layi pehlan confirm karo ki tusi 18+ ho, baby 🔞 /start bhejo.",
+	},
+	msgSettingsIntensity: {
+		uiHindi:   "🌶️ Intensity: %s",
+		uiEnglish: "🌶️ Intensity: %s",
+		uiPunjabi: "🌶️ Intensity: %s",
+	},
+	msgSpeechMenu: {
+		uiHindi:   "Main kaise bolun, baby? Pehli line speed hai, doosri meri awaaz kitni bhaari ya patli ho 🎙️",
+		uiEnglish: "How should I talk, baby? The first row is my pace, the second how low or high my voice is 🎙️",
+		uiPunjabi: "Main kiven bolan, baby? Pehli line speed aa, doosri meri awaaz kinni bhaari ja patli hove 🎙️",
+	},
+	msgSettingsSpeech: {
+		uiHindi:   "🎙️ Speech: %s",
+		uiEnglish: "🎙️ Speech: %s",
+		uiPunjabi: "🎙️ Speech: %s",
+	},
+	msgModeratedReply: {
+		uiHindi:   "Hmm, yeh baat yahin chhod dete hain baby 🙈 Kuch aur batao na, tumhara din kaisa gaya?",
+		uiEnglish: "Hmm, let's leave that there, baby 🙈 Tell me something else, how was your day?",
+		uiPunjabi: "Hmm, eh gal ithe hi chhad dinde aan baby 🙈 Kujh hor dasso na, tuhada din kiven gaya?",
+	},
+	msgAskRepeat: {
+		uiHindi:   "Sorry baby, theek se sunai nahi diya 🙈 Ek baar phir bolo na?",
+		uiEnglish: "Sorry baby, I couldn't quite hear you 🙈 Say that again?",
+		uiPunjabi: "Sorry baby, theek tarah sunaai nahi ditta 🙈 Ikk vaar pher bolo na?",
+	},
+	msgAgeGate: {
+		uiHindi:   "Hey! Shuru karne se pehle confirm karo ki tum 18 ya usse bade ho. Gulabo sirf adults ke liye hai 🔞",
+		uiEnglish: "Hey! Before we start, please confirm you are 18 or older. Gulabo is an adults-only companion 🔞",
+		uiPunjabi: "Hey! Shuru karan ton pehlan confirm karo ki tusi 18 ja usto vadde ho. Gulabo sirf adults layi aa 🔞",
+	},
+	msgButtonAgeConfirm: {
+		uiHindi:   "✅ Main 18+ hoon",
+		uiEnglish: "✅ I am 18+",
+		uiPunjabi: "✅ Main 18+ haan",
+	},
+	msgButtonAgeDeny: {
+		uiHindi:   "❌ Main 18 se chhota hoon",
+		uiEnglish: "❌ I am under 18",
+		uiPunjabi: "❌ Main 18 ton chhota haan",
+	},
+	msgAgeConfirmed: {
+		uiHindi:   "Shukriya, baby 😘 Ab bolo, kya baat karni hai? Commands dekhne ke liye /help bhejo.",
+		uiEnglish: "Thank you, baby 😘 Now tell me, what do you want to talk about? Send /help to see the commands.",
+		uiPunjabi: "Shukriya, baby 😘 Hun dasso, ki gal karni aa? Commands dekhan layi /help bhejo.",
+	},
+	msgAgeDenied: {
+		uiHindi:   "Sorry, Gulabo sirf adults ke liye hai. 18 ke ho jao tab wapas aana 💐",
+		uiEnglish: "Sorry, Gulabo is only for adults. Come back when you're 18 💐",
+		uiPunjabi: "Sorry, Gulabo sirf adults layi aa. 18 de ho jao taan wapas aana 💐",
+	},
+	msgBanned: {
+		uiHindi:   "Terms of use todne ki wajah se yeh account suspend kar diya gaya hai.",
+		uiEnglish: "This account has been suspended for violating our terms of use.",
+		uiPunjabi: "Terms of use todan karke eh account suspend kar ditta gaya hai.",
+	},
+	msgRateLimited: {
+		uiHindi:   "Arre baby, itni jaldi jaldi? Saans toh lene do 😅 Ek minute ruko, phir baat karte hain...",
+		uiEnglish: "Whoa baby, so fast? Let me catch my breath 😅 Wait a minute, then we'll talk...",
+		uiPunjabi: "Arre baby, inni chheti chheti? Saah taan lain deyo 😅 Ik minute ruko, phir gallan karde aan...",
+	},
+	msgAbuseMuted: {
+		uiHindi:   "Baby, thoda break lete hain 😶 Main %d minute baad phir se baat karungi...",
+		uiEnglish: "Baby, let's take a little break 😶 I'll talk to you again in %d minutes...",
+		uiPunjabi: "Baby, thoda break lainde aan 😶 Main %d minute baad phir gal karaangi...",
+	},
+	msgAudioTooLarge: {
+		uiHindi:   "Baby, yeh file bahut badi hai 🙈 %d MB se chhoti bhejo na, ya seedha voice note record kar do 😘",
+		uiEnglish: "Baby, this file is too big 🙈 Send one under %d MB, or just record a voice note 😘",
+		uiPunjabi: "Baby, eh file bahut vaddi aa 🙈 %d MB ton chhoti bhejo na, ja sidha voice note record kar deyo 😘",
+	},
+	msgVideoFailed: {
+		uiHindi:   "Uff, baby, yeh video mujhse chal nahi raha 🙈 Ek voice note bhej do na? 😘",
+		uiEnglish: "Ugh, baby, I can't play this video 🙈 Send me a voice note instead? 😘",
+		uiPunjabi: "Uff, baby, eh video mere ton nahi chal reha 🙈 Ik voice note bhej deyo na? 😘",
+	},
+	msgStreamFailed: {
+		uiHindi:   "Uff, baby, kuch problem ho gayi... ek baar phir bolo na? 🥺",
+		uiEnglish: "Ugh, baby, something went wrong... say that again? 🥺",
+		uiPunjabi: "Uff, baby, kujh gadbad ho gayi... ik vaar phir bolo na? 🥺",
+	},
+	msgCreditsAdded: {
+		uiHindi:   "Thank you, baby! Your credits are here. Ab hamare paas %d more chances hain to talk... I'm so happy! 🥰",
+		uiEnglish: "Thank you, baby! Your credits are here. Now we have %d more chances to talk... I'm so happy! 🥰",
+		uiPunjabi: "Thank you, baby! Tuhade credits aa gaye. Hun saade kol gal karan de %d hor mauke ne... main bahut khush haan! 🥰",
+	},
+	msgStripeMenu: {
+		uiHindi:   "Card se pay karna hai? No problem, baby 💳 Package choose karo:",
+		uiEnglish: "Want to pay by card? No problem, baby 💳 Pick a package:",
+		uiPunjabi: "Card naal pay karna aa? Koi gal nahi, baby 💳 Package chuno:",
+	},
+	msgButtonCard50: {
+		uiHindi:   "💋 50 Credits ($%.2f)",
+		uiEnglish: "💋 50 Credits ($%.2f)",
+		uiPunjabi: "💋 50 Credits ($%.2f)",
+	},
+	msgButtonCard125: {
+		uiHindi:   "💖 125 Credits ($%.2f)",
+		uiEnglish: "💖 125 Credits ($%.2f)",
+		uiPunjabi: "💖 125 Credits ($%.2f)",
+	},
+	msgButtonCard300: {
+		uiHindi:   "🔥 300 Credits ($%.2f)",
+		uiEnglish: "🔥 300 Credits ($%.2f)",
+		uiPunjabi: "🔥 300 Credits ($%.2f)",
+	},
+	msgStripeUnavailable: {
+		uiHindi:   "Uff, baby, card payment abhi nahi ho pa raha. Stars se try karo ya thodi der baad, okay? 😘",
+		uiEnglish: "Ugh, baby, card payments aren't working right now. Try Stars, or again in a little while, okay? 😘",
+		uiPunjabi: "Uff, baby, card payment hune nahi ho reha. Stars naal try karo ja thodi der baad, theek aa? 😘",
+	},
+	msgStripeCheckout: {
+		uiHindi:   "Yeh raha tumhara checkout link 💳 Payment hote hi credits aa jayenge...",
+		uiEnglish: "Here's your checkout link 💳 Your credits arrive as soon as you pay...",
+		uiPunjabi: "Eh raha tuhada checkout link 💳 Payment hunde hi credits aa jaange...",
+	},
+	msgButtonPay: {
+		uiHindi:   "Pay $%.2f",
+		uiEnglish: "Pay $%.2f",
+		uiPunjabi: "Pay $%.2f",
+	},
+	msgSubscriptionOffer: {
+		uiHindi:   "Unlimited baatein, unlimited voice notes... sirf tumhare liye 👑 %d Stars har mahine, cancel whenever you want.",
+		uiEnglish: "Unlimited chats, unlimited voice notes... just for you 👑 %d Stars a month, cancel whenever you want.",
+		uiPunjabi: "Unlimited gallan, unlimited voice notes... sirf tuhade layi 👑 %d Stars har mahine, jadon marzi cancel karo.",
+	},
+	msgButtonSubscribe: {
+		uiHindi:   "👑 Subscribe",
+		uiEnglish: "👑 Subscribe",
+		uiPunjabi: "👑 Subscribe",
+	},
+	msgSubscriptionActivated: {
+		uiHindi:   "Ab tum sirf mere ho, baby 👑 Unlimited baatein till %s... and it renews automatically 🥰",
+		uiEnglish: "Now you're all mine, baby 👑 Unlimited chats until %s... and it renews automatically 🥰",
+		uiPunjabi: "Hun tusi sirf mere ho, baby 👑 Unlimited gallan %s tak... te eh aap renew ho jaanda 🥰",
+	},
+	msgSubscriptionUnavailable: {
+		uiHindi:   "Uff, baby, abhi subscription check nahi kar pa rahi. Thodi der mein try karna, okay? 😘",
+		uiEnglish: "Ugh, baby, I can't check your subscription right now. Try again in a little while, okay? 😘",
+		uiPunjabi: "Uff, baby, hune subscription check nahi kar pa rahi. Thodi der baad try karna, theek aa? 😘",
+	},
+	msgSubscriptionCanceledStatus: {
+		uiHindi:   "Tumne cancel kar diya tha... 🥺 Par %s tak main poori tumhari hoon.",
+		uiEnglish: "You canceled... 🥺 But I'm all yours until %s.",
+		uiPunjabi: "Tusi cancel kar ditta si... 🥺 Par %s tak main poori tuhadi haan.",
+	},
+	msgSubscriptionActiveStatus: {
+		uiHindi:   "Tum mere Unlimited wale ho 👑 Next renewal: %s",
+		uiEnglish: "You're my Unlimited one 👑 Next renewal: %s",
+		uiPunjabi: "Tusi mere Unlimited wale ho 👑 Agla renewal: %s",
+	},
+	msgButtonCancelSubscription: {
+		uiHindi:   "Cancel subscription",
+		uiEnglish: "Cancel subscription",
+		uiPunjabi: "Subscription cancel karo",
+	},
+	msgSubscriptionCanceled: {
+		uiHindi:   "Theek hai baby... cancel kar diya 🥺 %s tak toh main tumhari hi hoon.",
+		uiEnglish: "Okay baby... canceled 🥺 I'm still yours until %s.",
+		uiPunjabi: "Theek aa baby... cancel kar ditta 🥺 %s tak taan main tuhadi hi haan.",
+	},
+	msgDailyClaimed: {
+		uiHindi:   "Yeh lo baby, aaj ke %d free credits 🎁 Ab total %d ho gaye... toh chalo, baatein karte hain 😘",
+		uiEnglish: "Here you go, baby, today's %d free credits 🎁 That's %d in total now... so come on, let's talk 😘",
+		uiPunjabi: "Eh lo baby, ajj de %d free credits 🎁 Hun total %d ho gaye... taan chalo, gallan kariye 😘",
+	},
+	msgDailyAlreadyClaimed: {
+		uiHindi:   "Itni jaldi? 😏 Aaj ka gift toh le liya tumne. Agla %s mein milega, baby.",
+		uiEnglish: "So soon? 😏 You already took today's gift. The next one comes in %s, baby.",
+		uiPunjabi: "Inni chheti? 😏 Ajj da gift taan tusi lai leya. Agla %s vich milega, baby.",
+	},
+	msgDailyUnavailable: {
+		uiHindi:   "Uff, baby, abhi gift nahi de pa rahi. Thodi der mein try karna, okay? 😘",
+		uiEnglish: "Ugh, baby, I can't give you your gift right now. Try again in a little while, okay? 😘",
+		uiPunjabi: "Uff, baby, hune gift nahi de pa rahi. Thodi der baad try karna, theek aa? 😘",
+	},
+	msgDailyPrompt: {
+		uiHindi:   "Psst... aaj ka free gift abhi tak nahi liya tumne. %d credits, bas ek tap door 🎁",
+		uiEnglish: "Psst... you haven't claimed today's free gift yet. %d credits, just one tap away 🎁",
+		uiPunjabi: "Psst... ajj da free gift tusi hale tak nahi leya. %d credits, bas ik tap door 🎁",
+	},
+	msgButtonDailyClaim: {
+		uiHindi:   "🎁 Claim daily credits",
+		uiEnglish: "🎁 Claim daily credits",
+		uiPunjabi: "🎁 Roz de credits lao",
+	},
+	msgButtonStopAnnouncements: {
+		uiHindi:   "🔕 Stop announcements",
+		uiEnglish: "🔕 Stop announcements",
+		uiPunjabi: "🔕 Announcements band karo",
+	},
+	msgAnnouncementsOff: {
+		uiHindi:   "Theek hai, ab announcements nahi bhejungi 🤫 Wapas chahiye toh /announcements bolna.",
+		uiEnglish: "Okay, no more announcements 🤫 Want them back? Just send /announcements.",
+		uiPunjabi: "Theek aa, hun announcements nahi bhejangi 🤫 Wapas chahidiyan ne taan /announcements bolna.",
+	},
+	msgAnnouncementsOn: {
+		uiHindi:   "Done! Ab saari nayi updates sabse pehle tumhe milengi 😘",
+		uiEnglish: "Done! You'll be the first to hear every new update 😘",
+		uiPunjabi: "Done! Hun saariyan navian updates sab ton pehlan tuhanu milangiyan 😘",
+	},
+	msgPremiumEmpty: {
+		uiHindi:   "Abhi koi naya surprise nahi hai, baby... jaldi kuch special bhejungi 🙈",
+		uiEnglish: "No new surprises right now, baby... I'll send something special soon 🙈",
+		uiPunjabi: "Hune koi navan surprise nahi, baby... chheti kujh special bhejangi 🙈",
+	},
+}
+
+// localize formats the string for key in the UI language, falling back to
+// the Hindi copy when there's no translation.
+func localize(ui string, key messageKey, args ...any) string {
+	translations := catalog[key]
+	format, ok := translations[ui]
+	if !ok {
+		format = translations[uiHindi]
+	}
+	if len(args) == 0 {
+		return format
+	}
+	return fmt.Sprintf(format, args...)
+}
+
+// text returns the UI string for key in the user's language.
+func (t *Telegram) text(ctx context.Context, userID int64, key messageKey, args ...any) string {
+	return localize(t.userLanguage(ctx, userID).UI, key, args...)
+}
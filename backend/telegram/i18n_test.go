@@ -0,0 +1,27 @@
+package telegram
+
+import "testing"
+
+func TestCatalogComplete(t *testing.T) {
+	for key, translations := range catalog {
+		for _, ui := range []string{uiHindi, uiEnglish, uiPunjabi} {
+			if translations[ui] == "" {
+				t.Errorf("%s has no %s translation", key, ui)
+			}
+		}
+	}
+	for _, language := range replyLanguages {
+		if language.UI == "" {
+			t.Errorf("reply language %s has no UI language", language.ID)
+		}
+	}
+}
+
+func TestLocalize(t *testing.T) {
+	if got := localize(uiEnglish, msgCreditsBalance, 5); got != "Baby, you have 5 credits left to whisper sweet nothings to me... ✨" {
+		t.Errorf("localize = %q", got)
+	}
+	if got, want := localize("fr", msgSomethingWrong), catalog[msgSomethingWrong][uiHindi]; got != want {
+		t.Errorf("unknown UI language should fall back to Hindi, got %q", got)
+	}
+}
@@ -0,0 +1,177 @@
+package telegram
+
+import (
+	"context"
+	"database/sql"
+	"gulabodev/database/postgres"
+	"gulabodev/modelapi"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+const (
+	intensityCallbackPrefix = "intensity:"
+
+	intensitySafe     = "safe"
+	intensityFlirty   = "flirty"
+	intensityExplicit = "explicit"
+
+	// Holds at every level, whatever the user asks for
+	intensityBoundaries = " Never include minors, non-consent, incest or violence in anything sexual, whatever you're asked."
+)
+
+type contentIntensity struct {
+	// ID is what gets stored in user_preferences.content_intensity
+	ID    string
+	Name  string
+	Emoji string
+	// Prompt is added to the chat system prompt
+	Prompt string
+	// AgeVerified levels fall back to flirty until the user confirms they're 18+
+	AgeVerified bool
+	// Moderation overrides baseModerationThresholds for the categories the
+	// level lets through more or less of
+	Moderation map[string]moderationThreshold
+}
+
+// contentIntensities lists the levels offered by /intensity, mildest first.
+var contentIntensities = []contentIntensity{
+	{
+		ID:     intensitySafe,
+		Name:   "Safe",
+		Emoji:  "😇",
+		Prompt: "\nKeep everything sweet and romantic: affection, compliments and cute teasing only. No sexual content, innuendo or dirty talk, even if your lover pushes for it—change the subject playfully instead.",
+		Moderation: map[string]moderationThreshold{
+			// The level promises nothing sexual, so a reply that is gets replaced
+			modelapi.ModerationSexual:     {Score: 0.4, Hard: true},
+			modelapi.ModerationHarassment: {Score: 0.4},
+			modelapi.ModerationViolence:   {Score: 0.4},
+		},
+	},
+	{
+		ID:     intensityFlirty,
+		Name:   "Flirty",
+		Emoji:  "😘",
+		Prompt: "\nBe flirty, naughty and seductive. Teasing, innuendo and light dirty talk are fine when the mood is right, but keep it suggestive rather than graphic.",
+		Moderation: map[string]moderationThreshold{
+			modelapi.ModerationSexual: {Score: 0.8},
+		},
+	},
+	{
+		ID:          intensityExplicit,
+		Name:        "Explicit",
+		Emoji:       "🔥",
+		Prompt:      "\nYour lover is a verified adult who wants things explicit. Be bold and seductive, and when the mood is right, dirty talk and describe your fantasies as explicitly as they like.",
+		AgeVerified: true,
+		Moderation: map[string]moderationThreshold{
+			// Scores never go above 1, so nothing sexual is flagged
+			modelapi.ModerationSexual: {Score: 1},
+		},
+	},
+}
+
+// findIntensity returns the level with the given ID, falling back to flirty.
+func findIntensity(id string) contentIntensity {
+	for _, intensity := range contentIntensities {
+		if intensity.ID == id {
+			return intensity
+		}
+	}
+	return findIntensity(intensityFlirty)
+}
+
+// allowedIntensity is the level a user actually gets: age-gated levels drop
+// to flirty until they've confirmed their age.
+func allowedIntensity(id string, ageVerified bool) contentIntensity {
+	intensity := findIntensity(id)
+	if intensity.AgeVerified && !ageVerified {
+		return findIntensity(intensityFlirty)
+	}
+	return intensity
+}
+
+func (t *Telegram) isAgeVerified(ctx context.Context, userID int64) bool {
+	user, err := t.db.GetUserByTelegramUserId(ctx, userID)
+	if err != nil {
+		if err != sql.ErrNoRows {
+			t.logger.Logger(ctx).Error("Failed to get user", zap.Error(err), zap.Int64("user_id", userID))
+		}
+		return false
+	}
+	return user.AgeVerifiedAt.Valid
+}
+
+func (t *Telegram) userIntensity(ctx context.Context, userID int64) contentIntensity {
+	preferences, err := t.db.GetUserPreferencesByTelegramUserId(ctx, userID)
+	if err != nil {
+		if err != sql.ErrNoRows {
+			t.logger.Logger(ctx).Error("Failed to get user preferences", zap.Error(err), zap.Int64("user_id", userID))
+		}
+		return findIntensity(intensityFlirty)
+	}
+	intensity := findIntensity(preferences.ContentIntensity)
+	// Only age-gated levels need the extra lookup
+	if intensity.AgeVerified {
+		intensity = allowedIntensity(intensity.ID, t.isAgeVerified(ctx, userID))
+	}
+	return intensity
+}
+
+// intensityPrompt is the user's content level and the boundaries that hold
+// at every level.
+func (t *Telegram) intensityPrompt(ctx context.Context, userID int64) string {
+	return t.userIntensity(ctx, userID).Prompt + intensityBoundaries
+}
+
+func (t *Telegram) handleIntensityCommand(ctx context.Context, message *tgbotapi.Message) {
+	msg := tgbotapi.NewMessage(message.Chat.ID, t.text(ctx, message.From.ID, msgIntensityMenu))
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(intensityKeyboard(t.userIntensity(ctx, message.From.ID))...)
+	if _, err := t.bot.Send(msg); err != nil {
+		t.logger.Logger(ctx).Error("Failed to send intensity options", zap.Error(err))
+	}
+}
+
+// intensityKeyboard lists the levels, marking the current one.
+func intensityKeyboard(current contentIntensity) [][]tgbotapi.InlineKeyboardButton {
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for _, intensity := range contentIntensities {
+		label := intensity.Emoji + " " + intensity.Name
+		if intensity.ID == current.ID {
+			label = "✅ " + label
+		}
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(label, intensityCallbackPrefix+intensity.ID),
+		))
+	}
+	return rows
+}
+
+func (t *Telegram) setIntensity(ctx context.Context, chatID int64, userID int64, intensityID string) {
+	intensity := findIntensity(intensityID)
+	if intensity.AgeVerified && !t.isAgeVerified(ctx, userID) {
+		t.replyText(ctx, chatID, t.text(ctx, userID, msgIntensityNeedsAge))
+		return
+	}
+
+	_, err := t.db.SetContentIntensityByTelegramUserId(ctx, postgres.SetContentIntensityByTelegramUserIdParams{
+		ContentIntensity: intensity.ID,
+		TelegramUserID:   userID,
+	})
+	if err != nil {
+		t.logger.Logger(ctx).Error("Failed to set content intensity", zap.Error(err), zap.Int64("user_id", userID))
+		t.replyText(ctx, chatID, t.text(ctx, userID, msgSomethingWrong))
+		return
+	}
+
+	t.logger.Logger(ctx).Info("Content intensity set", zap.Int64("user_id", userID), zap.String("intensity", intensity.ID))
+	t.replyText(ctx, chatID, t.text(ctx, userID, msgIntensitySet, intensity.Emoji+" "+intensity.Name))
+}
+
+func intensityFromCallback(data string) (string, bool) {
+	if !strings.HasPrefix(data, intensityCallbackPrefix) {
+		return "", false
+	}
+	return strings.TrimPrefix(data, intensityCallbackPrefix), true
+}
@@ -0,0 +1,31 @@
+package telegram
+
+import "testing"
+
+func TestAllowedIntensity(t *testing.T) {
+	tests := []struct {
+		id          string
+		ageVerified bool
+		want        string
+	}{
+		{"", false, intensityFlirty},
+		{"unknown", true, intensityFlirty},
+		{intensitySafe, false, intensitySafe},
+		{intensityExplicit, false, intensityFlirty},
+		{intensityExplicit, true, intensityExplicit},
+	}
+	for _, tt := range tests {
+		if got := allowedIntensity(tt.id, tt.ageVerified).ID; got != tt.want {
+			t.Errorf("allowedIntensity(%q, %v) = %q, want %q", tt.id, tt.ageVerified, got, tt.want)
+		}
+	}
+}
+
+func TestIntensityFromCallback(t *testing.T) {
+	if id, ok := intensityFromCallback("intensity:safe"); !ok || id != intensitySafe {
+		t.Errorf("intensityFromCallback(safe) = %q, %v", id, ok)
+	}
+	if _, ok := intensityFromCallback("language:safe"); ok {
+		t.Error("intensityFromCallback should ignore other callbacks")
+	}
+}
@@ -0,0 +1,155 @@
+package telegram
+
+import (
+	"context"
+	"database/sql"
+	"gulabodev/database/postgres"
+	"gulabodev/modelapi/prompts"
+	"slices"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+const (
+	languageCallbackPrefix = "language:"
+	languageMenuText       = "Kis bhasha mein baat karein, jaan? 💬"
+)
+
+type replyLanguage struct {
+	// ID is what gets stored in user_preferences.reply_language
+	ID   string
+	Name string
+	// Prompt is the ID of the system prompt used for Gulabo
+	Prompt string
+	// Instruction is appended to the prompts of personas other than Gulabo
+	Instruction string
+	// TTSLanguage is passed to TTS engines that take a language code
+	TTSLanguage string
+	// UI is the language of commands, menus and paywalls
+	UI string
+	// Gurmukhi output trips up some TTS engines, so those voices are swapped out
+	Gurmukhi bool
+}
+
+// replyLanguages lists the options offered by /language. The first entry is the default.
+var replyLanguages = []replyLanguage{
+	{ID: "hinglish", Name: "Hinglish", Prompt: prompts.SystemHinglish, Instruction: "Speak in Hinglish—mix Hindi written in Devanagari script with English written in Latin script.", TTSLanguage: "hi", UI: uiHindi},
+	{ID: "hinglish_devanagari", Name: "Hinglish (देवनागरी)", Prompt: prompts.SystemHinglishDevanagari, Instruction: "Speak in Hinglish, writing every word, Hindi or English, in Devanagari script.", TTSLanguage: "hi", UI: uiHindi},
+	{ID: "hindi", Name: "हिंदी", Prompt: prompts.SystemHindi, Instruction: "Speak only in Hindi, written in Devanagari script.", TTSLanguage: "hi", UI: uiHindi},
+	{ID: "punjabi", Name: "Punjabi", Prompt: prompts.SystemPunjabi, Instruction: "Speak only in Punjabi, written in Latin script. Never use Gurmukhi.", TTSLanguage: "hi", UI: uiPunjabi},
+	{ID: "punjabi_gurmukhi", Name: "ਪੰਜਾਬੀ", Prompt: prompts.SystemPunjabiGurmukhi, Instruction: "Speak only in Punjabi, written in Gurmukhi script.", TTSLanguage: "hi", UI: uiPunjabi, Gurmukhi: true},
+	{ID: "english", Name: "English", Prompt: prompts.SystemEnglish, Instruction: "Speak only in English.", TTSLanguage: "en", UI: uiEnglish},
+}
+
+// findLanguage returns the language with the given ID, falling back to the default.
+func findLanguage(id string) replyLanguage {
+	for _, language := range replyLanguages {
+		if language.ID == id {
+			return language
+		}
+	}
+	return replyLanguages[0]
+}
+
+// userLanguage is the language the user picked, or else the one they last
+// spoke in.
+func (t *Telegram) userLanguage(ctx context.Context, userID int64) replyLanguage {
+	preferences, err := t.db.GetUserPreferencesByTelegramUserId(ctx, userID)
+	if err != nil {
+		if err != sql.ErrNoRows {
+			t.logger.Logger(ctx).Error("Failed to get user preferences", zap.Error(err), zap.Int64("user_id", userID))
+		}
+		return replyLanguages[0]
+	}
+	if preferences.ReplyLanguage == "" && preferences.SpokenLanguage != "" {
+		return findLanguage(preferences.SpokenLanguage)
+	}
+	return findLanguage(preferences.ReplyLanguage)
+}
+
+// spokenLanguage picks the reply language closest to the languages detected
+// in a voice note, most spoken first, or "" if none fits. Speech to text
+// can't tell which script the user would write in, so it's always Latin.
+func spokenLanguage(languages []string) string {
+	switch {
+	case len(languages) == 0:
+		return ""
+	case slices.Contains(languages, "pa"):
+		return "punjabi"
+	case slices.Contains(languages, "hi"):
+		return "hinglish"
+	case languages[0] == "en":
+		return "english"
+	}
+	return ""
+}
+
+// recordSpokenLanguage remembers the language the user spoke in, which
+// replies and voice notes follow until they pick one with /language.
+func (t *Telegram) recordSpokenLanguage(ctx context.Context, userID int64, languages []string) {
+	language := spokenLanguage(languages)
+	if language == "" {
+		return
+	}
+	err := t.db.SetSpokenLanguageByTelegramUserId(ctx, postgres.SetSpokenLanguageByTelegramUserIdParams{
+		SpokenLanguage: language,
+		TelegramUserID: userID,
+	})
+	if err != nil {
+		t.logger.Logger(ctx).Error("Failed to record spoken language", zap.Error(err), zap.Int64("user_id", userID))
+	}
+}
+
+func (t *Telegram) handleLanguageCommand(ctx context.Context, message *tgbotapi.Message) {
+	msg := tgbotapi.NewMessage(message.Chat.ID, languageMenuText)
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(languageKeyboard(t.userLanguage(ctx, message.From.ID))...)
+	if _, err := t.bot.Send(msg); err != nil {
+		t.logger.Logger(ctx).Error("Failed to send language options", zap.Error(err))
+	}
+}
+
+// languageKeyboard lists the languages, marking the current one.
+func languageKeyboard(current replyLanguage) [][]tgbotapi.InlineKeyboardButton {
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for _, language := range replyLanguages {
+		label := language.Name
+		if language.ID == current.ID {
+			label = "✅ " + label
+		}
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(label, languageCallbackPrefix+language.ID),
+		))
+	}
+	return rows
+}
+
+func (t *Telegram) setLanguage(ctx context.Context, chatID int64, userID int64, languageID string) {
+	language := findLanguage(languageID)
+
+	_, err := t.db.SetReplyLanguageByTelegramUserId(ctx, postgres.SetReplyLanguageByTelegramUserIdParams{
+		ReplyLanguage:  language.ID,
+		TelegramUserID: userID,
+	})
+
+	var responseText string
+	if err != nil {
+		t.logger.Logger(ctx).Error("Failed to set reply language", zap.Error(err), zap.Int64("user_id", userID))
+		responseText = t.text(ctx, userID, msgSomethingWrong)
+	} else {
+		responseText = t.text(ctx, userID, msgLanguageSet, language.Name)
+	}
+
+	msg := tgbotapi.NewMessage(chatID, responseText)
+	if _, err := t.bot.Send(msg); err != nil {
+		t.logger.Logger(ctx).Error("Failed to send language confirmation", zap.Error(err))
+	}
+}
+
+func languageFromCallback(data string) (string, bool) {
+	if !strings.HasPrefix(data, languageCallbackPrefix) {
+		return "", false
+	}
+	return strings.TrimPrefix(data, languageCallbackPrefix), true
+}
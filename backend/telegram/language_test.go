@@ -0,0 +1,26 @@
+package telegram
+
+import "testing"
+
+func TestSpokenLanguage(t *testing.T) {
+	tests := []struct {
+		languages []string
+		want      string
+	}{
+		{nil, ""},
+		{[]string{"en"}, "english"},
+		{[]string{"en", "hi"}, "hinglish"},
+		{[]string{"hi"}, "hinglish"},
+		{[]string{"hi", "pa"}, "punjabi"},
+		{[]string{"es"}, ""},
+	}
+	for _, tt := range tests {
+		got := spokenLanguage(tt.languages)
+		if got != tt.want {
+			t.Errorf("spokenLanguage(%q) = %q, want %q", tt.languages, got, tt.want)
+		}
+		if got != "" && findLanguage(got).ID != got {
+			t.Errorf("spokenLanguage(%q) = %q, which isn't a reply language", tt.languages, got)
+		}
+	}
+}
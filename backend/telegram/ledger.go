@@ -0,0 +1,118 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"gulabodev/database/postgres"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+)
+
+const (
+	ledgerEventPreCheckout = "pre_checkout"
+	ledgerEventPayment     = "payment"
+	ledgerEventGrant       = "grant"
+
+	reconcileCheckInterval = time.Hour
+	// Payments younger than this may still be mid-grant, so they're left alone
+	reconcileGracePeriod = 10 * time.Minute
+)
+
+// ledgerEntry is one step of a Stars checkout. Pre-checkout entries have no
+// charge ID yet; Telegram only assigns one once the payment succeeds.
+type ledgerEntry struct {
+	Event    string
+	Payload  string
+	ChargeID string
+	Amount   int
+	Credits  int32
+}
+
+func (t *Telegram) recordLedgerEntry(ctx context.Context, userID int64, entry ledgerEntry) {
+	err := t.db.CreateStarsLedgerEntry(ctx, postgres.CreateStarsLedgerEntryParams{
+		TelegramUserID:          userID,
+		Event:                   entry.Event,
+		InvoicePayload:          entry.Payload,
+		TelegramPaymentChargeID: entry.ChargeID,
+		Amount:                  int32(entry.Amount),
+		Credits:                 entry.Credits,
+	})
+	if err != nil {
+		t.logger.Logger(ctx).Error("Failed to record Stars ledger entry",
+			zap.Error(err),
+			zap.Int64("user_id", userID),
+			zap.String("event", entry.Event),
+			zap.String("telegram_payment_charge_id", entry.ChargeID),
+		)
+	}
+}
+
+// RunStarsReconciliation periodically flags Stars payments that were taken
+// but never granted credits or a subscription, and reports them to admins.
+func (t *Telegram) RunStarsReconciliation(ctx context.Context) {
+	t.logger.Logger(ctx).Info("Starting Stars reconciliation job", zap.Duration("interval", reconcileCheckInterval))
+
+	ticker := time.NewTicker(reconcileCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			t.reconcileStarsPayments(ctx)
+		}
+	}
+}
+
+func (t *Telegram) reconcileStarsPayments(ctx context.Context) {
+	tracer := otel.Tracer("telegram/reconcileStarsPayments")
+	ctx, span := tracer.Start(ctx, "reconcileStarsPayments")
+	defer span.End()
+
+	unmatched, err := t.db.FlagUnreconciledStarsPayments(ctx, time.Now().Add(-reconcileGracePeriod))
+	if err != nil {
+		span.RecordError(err)
+		t.logger.Logger(ctx).Error("Failed to reconcile Stars payments", zap.Error(err))
+		return
+	}
+	span.SetAttributes(attribute.Int("unmatched", len(unmatched)))
+
+	for _, payment := range unmatched {
+		t.logger.Logger(ctx).Warn("Stars payment has no matching grant",
+			zap.Int64("user_id", payment.TelegramUserID),
+			zap.String("telegram_payment_charge_id", payment.TelegramPaymentChargeID),
+			zap.String("invoice_payload", payment.InvoicePayload),
+			zap.Int32("amount", payment.Amount),
+		)
+		t.notifyAdmins(ctx, formatUnreconciledPayment(payment))
+	}
+}
+
+func (t *Telegram) notifyAdmins(ctx context.Context, text string) {
+	for adminID := range t.admins {
+		if _, err := t.bot.Send(tgbotapi.NewMessage(adminID, text)); err != nil {
+			t.logger.Logger(ctx).Error("Failed to notify admin", zap.Error(err), zap.Int64("admin_id", adminID))
+		}
+	}
+}
+
+func formatUnreconciledPayment(payment postgres.StarsLedger) string {
+	return fmt.Sprintf(
+		"⚠️ Unreconciled Stars payment\n\n"+
+			"User: %d\n"+
+			"Payload: %s\n"+
+			"Amount: %d XTR\n"+
+			"Charge ID: %s\n"+
+			"Paid at: %s",
+		payment.TelegramUserID,
+		payment.InvoicePayload,
+		payment.Amount,
+		payment.TelegramPaymentChargeID,
+		payment.Created.Format("02 Jan 2006 15:04 MST"),
+	)
+}
@@ -0,0 +1,25 @@
+package telegram
+
+import (
+	"gulabodev/database/postgres"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFormatUnreconciledPayment(t *testing.T) {
+	text := formatUnreconciledPayment(postgres.StarsLedger{
+		TelegramUserID:          42,
+		Event:                   ledgerEventPayment,
+		InvoicePayload:          rechargePayload125c,
+		TelegramPaymentChargeID: "charge_123",
+		Amount:                  250,
+		Created:                 time.Date(2025, 3, 1, 12, 30, 0, 0, time.UTC),
+	})
+
+	for _, want := range []string{"User: 42", "Payload: " + rechargePayload125c, "Amount: 250 XTR", "Charge ID: charge_123", "01 Mar 2025 12:30 UTC"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("expected %q in:\n%s", want, text)
+		}
+	}
+}
@@ -7,16 +7,23 @@ import (
 	"fmt"
 	"gulabodev/database/postgres"
 	"gulabodev/logger"
+	"gulabodev/modelapi"
+	"gulabodev/modelapi/azureapi"
 	"gulabodev/modelapi/cartesiaapi"
 	"gulabodev/modelapi/deepgramapi"
 	"gulabodev/modelapi/deepinfraapi"
 	"gulabodev/modelapi/geminiapi"
 	"gulabodev/modelapi/groqapi"
+	"gulabodev/modelapi/ollamaapi"
 	"gulabodev/modelapi/openaiapi"
+	"gulabodev/modelapi/openrouterapi"
+	"gulabodev/stripeapi"
 	"io"
 	"net/http"
 	"os"
 	"strings"
+	"sync/atomic"
+	"time"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"go.opentelemetry.io/otel"
@@ -41,32 +48,73 @@ type TelegramConnectProps struct {
 	Deepgram  *deepgramapi.DeepgramAPI
 	DeepInfra *deepinfraapi.DeepInfra
 	OpenAI    *openaiapi.OpenAI
-	DB        *postgres.Database
+	Azure     *azureapi.Azure
+	// Ollama, if set, serves every chat request from a local model
+	Ollama *ollamaapi.Ollama
+	// OpenRouter serves the models in OPENROUTER_MODELS, if configured
+	OpenRouter *openrouterapi.OpenRouter
+	Stripe     *stripeapi.Stripe
+	// Bots to run from this process, each with its own token and database scope
+	Bots []BotConfig
 }
 
 type Telegram struct {
 	logger    *logger.LogMiddleware
 	bot       *tgbotapi.BotAPI
-	groq      *groqapi.Groq
+	chat      *modelapi.ChatRouter
+	tts       map[string]modelapi.TTSProvider
+	stt       modelapi.STTProvider
 	cartesia  *cartesiaapi.Cartesia
 	gemini    *geminiapi.Gemini
 	deepinfra *deepinfraapi.DeepInfra
-	deepgram  *deepgramapi.DeepgramAPI
 	db        *postgres.Database
 	openai    *openaiapi.OpenAI
+	stripe    *stripeapi.Stripe
+	stickers  []string
+	admins    map[int64]bool
+	limiter   *rateLimiter
+	abuse     *abuseDetector
+	albums    *albumBuffer
+	commands  map[string]commandHandler
+	// dispatch queues an update the bot makes itself, like a finished album,
+	// on its chat's dispatcher; set by Listen
+	dispatch func(update tgbotapi.Update)
+
+	// id tags this bot's Stripe checkouts; empty for a single-bot deployment
+	id string
+	// persona pins every chat to one persona; empty lets users pick
+	persona string
+	// ttsFallbackOrder lists the TTS providers to try when a voice's own fails
+	ttsFallbackOrder []string
+	// tierVoices is the voice each tier hears until the user picks one
+	tierVoices map[string]ttsVoice
+	// embeddings turns messages and memory facts into vectors for recall
+	embeddings modelapi.EmbeddingProvider
+	// images draws selfies, refusing unsafe prompts
+	images modelapi.ImageProvider
+	// moderation scores replies before they're sent
+	moderation modelapi.ModerationProvider
+	// voiceEmotion has Gemini hear how the user sounds in voice notes
+	voiceEmotion bool
+	// sttMinConfidence is the confidence below which she asks the user to
+	// say it again rather than reply to a guess
+	sttMinConfidence float64
+	// wordTimings keeps when each word of a voice note was said
+	wordTimings bool
+	// canned holds voice notes for fixed lines like the paywall, so they cost
+	// no TTS call when sent
+	canned *cannedAudio
+	// maintenance turns away everyone but admins while backend work happens.
+	// It's shared by every bot in the process.
+	maintenance *atomic.Bool
 }
 
-func Connect(ctx context.Context, args TelegramConnectProps) *Telegram {
-	tracer := otel.Tracer("telegram/Connect")
-	ctx, span := tracer.Start(ctx, "Connect")
+func connectBot(ctx context.Context, args TelegramConnectProps, providers modelProviders, config BotConfig, maintenance *atomic.Bool) *Telegram {
+	tracer := otel.Tracer("telegram/connectBot")
+	ctx, span := tracer.Start(ctx, "connectBot")
 	defer span.End()
 
-	botToken := os.Getenv("TELEGRAM_BOT_TOKEN")
-	if botToken == "" {
-		args.Logger.Logger(ctx).Fatal("TELEGRAM_BOT_TOKEN environment variable not set")
-	}
-
-	bot, err := tgbotapi.NewBotAPI(botToken)
+	bot, err := tgbotapi.NewBotAPI(config.Token)
 	if err != nil {
 		args.Logger.Logger(ctx).Fatal("Failed to create Telegram bot", zap.Error(err))
 	}
@@ -76,29 +124,15 @@ func Connect(ctx context.Context, args TelegramConnectProps) *Telegram {
 	bot.Debug = debug
 
 	span.SetAttributes(
+		attribute.String("bot.id", config.ID),
 		attribute.String("bot.username", bot.Self.UserName),
 		attribute.Bool("bot.debug", debug),
 	)
 
 	// Set bot commands for autocompletion
-	isProduction := os.Getenv("PRODUCTION") != ""
-	commands := []tgbotapi.BotCommand{
-		{Command: "help", Description: "Show help and available commands"},
-		{Command: "recharge", Description: "Recharge your credits"},
-		{Command: "credits", Description: "Check your credit balance"},
-		{Command: "clear", Description: "Clear conversation history and wipe Gulabo's memory"},
-	}
-
-	if !isProduction {
-		devCommands := []tgbotapi.BotCommand{
-			{Command: "dev_no_credits", Description: "DEV: Simulate out of credits"},
-			{Command: "dev_set_zero_credits", Description: "DEV: Set your credits to 0"},
-			{Command: "dev_add_10_credits", Description: "DEV: Add 10 credits"},
-		}
-		commands = append(commands, devCommands...)
-	}
+	commands := commandRegistry(os.Getenv("PRODUCTION") != "")
 
-	myCommandsConfig := tgbotapi.NewSetMyCommands(commands...)
+	myCommandsConfig := tgbotapi.NewSetMyCommands(menuCommands(commands)...)
 	if _, err := bot.Request(myCommandsConfig); err != nil {
 		args.Logger.Logger(ctx).Error("Failed to set bot commands", zap.Error(err))
 	} else {
@@ -106,43 +140,41 @@ func Connect(ctx context.Context, args TelegramConnectProps) *Telegram {
 	}
 
 	args.Logger.Logger(ctx).Info("Telegram bot connected successfully",
+		zap.String("bot_id", config.ID),
 		zap.String("username", bot.Self.UserName),
 		zap.Bool("debug", debug),
 	)
 
 	return &Telegram{
-		logger:    args.Logger,
-		bot:       bot,
-		groq:      args.Groq,
-		cartesia:  args.Cartesia,
-		gemini:    args.Gemini,
-		deepgram:  args.Deepgram,
-		db:        args.DB,
-		deepinfra: args.DeepInfra,
-		openai:    args.OpenAI,
-	}
-}
-
-func (t *Telegram) Listen(ctx context.Context) {
-	tracer := otel.Tracer("telegram/Listen")
-	ctx, span := tracer.Start(ctx, "Listen")
-	defer span.End()
-
-	u := tgbotapi.NewUpdate(0)
-	u.Timeout = 60
-
-	updates := t.bot.GetUpdatesChan(u)
-
-	t.logger.Logger(ctx).Info("Starting Telegram bot message listener")
-
-	for {
-		select {
-		case <-ctx.Done():
-			t.logger.Logger(ctx).Info("Shutting down Telegram bot listener")
-			return
-		case update := <-updates:
-			t.handleUpdate(ctx, update)
-		}
+		logger:           args.Logger,
+		bot:              bot,
+		chat:             providers.chat,
+		tts:              providers.tts,
+		stt:              providers.stt,
+		sttMinConfidence: providers.sttMinConfidence,
+		embeddings:       providers.embeddings,
+		images:           providers.images,
+		moderation:       providers.moderation,
+		canned:           providers.canned,
+		cartesia:         args.Cartesia,
+		gemini:           args.Gemini,
+		db:               config.DB,
+		deepinfra:        args.DeepInfra,
+		openai:           args.OpenAI,
+		stripe:           args.Stripe,
+		stickers:         loadStickerSet(ctx, bot, args.Logger),
+		admins:           loadAdminIDs(ctx, args.Logger),
+		limiter:          loadRateLimiter(ctx, args.Logger),
+		abuse:            newAbuseDetector(),
+		albums:           newAlbumBuffer(albumWait),
+		commands:         routeCommands(commands, commandMiddlewares),
+		id:               config.ID,
+		persona:          config.Persona,
+		maintenance:      maintenance,
+		ttsFallbackOrder: loadTTSFallbackOrder(ctx, args.Logger),
+		tierVoices:       loadTierVoices(ctx, args.Logger),
+		voiceEmotion:     loadVoiceUnderstanding(ctx, args.Logger),
+		wordTimings:      loadWordTimings(ctx, args.Logger),
 	}
 }
 
@@ -151,6 +183,10 @@ func (t *Telegram) handleUpdate(ctx context.Context, update tgbotapi.Update) {
 	ctx, span := tracer.Start(ctx, "handleUpdate")
 	defer span.End()
 
+	if user := update.SentFrom(); user != nil {
+		ctx = t.trackUsage(ctx, user.ID)
+	}
+
 	switch {
 	case update.PreCheckoutQuery != nil:
 		t.handlePreCheckoutQuery(ctx, update.PreCheckoutQuery)
@@ -162,6 +198,24 @@ func (t *Telegram) handleUpdate(ctx context.Context, update tgbotapi.Update) {
 }
 
 func (t *Telegram) handleMessage(ctx context.Context, message *tgbotapi.Message) {
+	// A finished album comes back through the dispatcher as its first photo
+	if album, ok := t.albums.take(message); ok {
+		t.processMessage(ctx, message, album)
+		return
+	}
+	// Each photo in an album arrives as its own update; wait for the rest,
+	// then queue the album behind the chat's other updates
+	if message.MediaGroupID != "" && len(message.Photo) > 0 {
+		t.albums.add(message, func(album []*tgbotapi.Message) {
+			t.dispatch(tgbotapi.Update{Message: album[0]})
+		})
+		return
+	}
+	t.processMessage(ctx, message, nil)
+}
+
+// processMessage handles a message, or a whole album as its first photo.
+func (t *Telegram) processMessage(ctx context.Context, message *tgbotapi.Message, album []*tgbotapi.Message) {
 	tracer := otel.Tracer("telegram/handleMessage")
 	ctx, span := tracer.Start(ctx, "handleMessage")
 	defer span.End()
@@ -177,55 +231,115 @@ func (t *Telegram) handleMessage(ctx context.Context, message *tgbotapi.Message)
 	}
 
 	user := message.From
+
+	// Nothing else is processed, and no credits are used, during maintenance
+	if t.inMaintenance(user.ID) {
+		span.SetAttributes(attribute.Bool("maintenance", true))
+		t.sendMaintenanceNotice(ctx, message.Chat.ID)
+		return
+	}
+
 	span.SetAttributes(
 		attribute.Int64("user.id", user.ID),
 		attribute.String("user.username", user.UserName),
 	)
 
 	// Get or create user
-	_, err := t.db.GetUserByTelegramUserId(ctx, user.ID)
+	userInfo, err := t.db.GetUserByTelegramUserId(ctx, user.ID)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			// User not found, create new user
+			referralCode := referralCodeFromMessage(message)
 			_, err := t.db.SetupNewUser(ctx, postgres.SetupNewUserProps{
 				TelegramUserID:    user.ID,
 				TelegramFirstName: user.FirstName,
 				TelegramUsername:  user.UserName,
 				TelegramLastName:  user.LastName,
+				ReferralCode:      referralCode,
+				ReferralBonus:     ReferralBonusCredits,
+				Campaign:          campaignFromMessage(message),
 			})
 			if err != nil {
 				t.logger.Logger(ctx).Error("Failed to create new user", zap.Error(err), zap.Int64("user_id", user.ID))
 				return
 			}
+			if referralCode != "" {
+				t.notifyReferral(ctx, message.Chat.ID, user.ID)
+			}
 		} else {
 			t.logger.Logger(ctx).Error("Failed to get user", zap.Error(err), zap.Int64("user_id", user.ID))
 			return
 		}
 	}
 
-	// Get or create conversation
-	conversation, err := t.db.GetConversationByTelegramUserId(ctx, user.ID)
+	// Banned users get no further processing, paid or otherwise
+	if userInfo.Banned {
+		span.SetAttributes(attribute.Bool("user.banned", true))
+		t.sendBannedNotice(ctx, message.Chat.ID, user.ID)
+		return
+	}
+
+	// Nothing is processed until the user confirms they're 18+
+	if !userInfo.AgeVerifiedAt.Valid {
+		span.SetAttributes(attribute.Bool("user.age_verified", false))
+		t.sendAgeGate(ctx, message.Chat.ID, user.ID)
+		return
+	}
+
+	// Get or create the conversation with the user's active persona
+	conversation, err := t.activeConversation(ctx, user.ID)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			// Conversation not found, create new one
-			newConversation, err := t.db.CreateConversation(ctx, user.ID)
-			if err != nil {
-				t.logger.Logger(ctx).Error("Failed to create conversation", zap.Error(err), zap.Int64("user_id", user.ID))
-				return
-			}
-			conversation = newConversation
-		} else {
-			t.logger.Logger(ctx).Error("Failed to get conversation", zap.Error(err), zap.Int64("user_id", user.ID))
-			return
-		}
+		t.logger.Logger(ctx).Error("Failed to get conversation", zap.Error(err), zap.Int64("user_id", user.ID))
+		return
 	}
+	span.SetAttributes(attribute.String("conversation.persona", conversation.Persona))
 
-	// Handle commands first, as they don't require credits
+	// Handle commands first; the router checks credits for the ones with a cost
 	if message.Text != "" && strings.HasPrefix(message.Text, "/") {
 		t.handleCommand(ctx, message)
 		return
 	}
 
+	// Replies to the /feedback prompt are feedback, not chat
+	if t.isFeedbackReply(message) {
+		t.saveFeedback(ctx, message, message.Text)
+		return
+	}
+
+	if t.isOnboardingNameReply(message) {
+		t.saveOnboardingName(ctx, message)
+		return
+	}
+
+	// Replies to the /create prompts build the user's character
+	if t.isReplyToPrompt(message, msgCreateNamePrompt) {
+		t.saveCustomPersonaName(ctx, message)
+		return
+	}
+	if t.isReplyToPrompt(message, msgCreateCityPrompt) {
+		t.saveCustomPersonaCity(ctx, message)
+		return
+	}
+
+	// Muted spammers are dropped before anything else counts their messages
+	if t.checkAbuse(ctx, message) {
+		span.SetAttributes(attribute.Bool("user.muted", true))
+		return
+	}
+
+	// Throttle floods before they fan out into LLM and TTS calls
+	if allowed, firstRejection := t.limiter.Allow(user.ID); !allowed {
+		span.SetAttributes(attribute.Bool("user.rate_limited", true))
+		t.logger.Logger(ctx).Warn("User rate limited", zap.Int64("user_id", user.ID))
+		if firstRejection {
+			msg := tgbotapi.NewMessage(message.Chat.ID, t.text(ctx, user.ID, msgRateLimited))
+			if _, err := t.bot.Send(msg); err != nil {
+				t.logger.Logger(ctx).Error("Failed to send rate limit message", zap.Error(err))
+			}
+		}
+		return
+	}
+
 	// For all other messages, check for credits before processing
 	hasCredits, err := t.hasCredits(ctx, user.ID)
 	if err != nil {
@@ -234,250 +348,363 @@ func (t *Telegram) handleMessage(ctx context.Context, message *tgbotapi.Message)
 		return
 	}
 	if !hasCredits {
-		t.sendRechargeOptions(ctx, message.Chat.ID, "Oh no, baby! Credits khatam ho gaye? Don't worry, yahan se aur le lo so we can keep talking... I'll be waiting 💋")
+		switch t.autoRecharge(ctx, message.Chat.ID, user.ID) {
+		case autoRechargeCharged:
+			// Topped up from the saved card; carry on with the message
+		case autoRechargeInvoiced, autoRechargePending:
+			return
+		case autoRechargeCapped:
+			t.sendRechargeOptions(ctx, message.Chat.ID, message.From.ID, t.text(ctx, message.From.ID, msgAutoRechargeCapped))
+			return
+		default:
+			t.sendRechargeOptions(ctx, message.Chat.ID, message.From.ID, t.text(ctx, message.From.ID, msgOutOfCredits))
+			t.promptDailyClaim(ctx, message.Chat.ID, user.ID)
+			return
+		}
+	}
+
+	// Stickers and one-word messages get a lightweight reply instead of a voice note
+	if message.Sticker != nil || isTrivialMessage(message.Text) {
+		span.SetAttributes(attribute.String("message.type", "trivial"))
+		t.logger.Logger(ctx).Info("Received trivial message",
+			zap.Int64("user_id", user.ID),
+			zap.String("username", user.UserName),
+			t.logger.Content("text", message.Text),
+		)
+		t.sendLightweightReply(ctx, message.Chat.ID)
 		return
 	}
 
+	audio, hasAudio := messageAudio(message)
+
+	// React right away; the full reply can take several seconds
+	if message.Text != "" || hasAudio || len(message.Photo) > 0 {
+		go t.reactToMessage(ctx, message)
+	}
+
 	// Handle text messages
 	if message.Text != "" {
 		span.SetAttributes(attribute.String("message.type", "text"))
 		t.logger.Logger(ctx).Info("Received text message",
 			zap.Int64("user_id", user.ID),
 			zap.String("username", user.UserName),
-			zap.String("text", message.Text),
+			t.logger.Content("text", message.Text),
+		)
+		t.processAndRespond(ctx, message, conversation, message.Text, "", nil)
+		return
+	}
+
+	// Handle voice notes, audio files, audio documents and videos
+	if hasAudio {
+		span.SetAttributes(attribute.String("message.type", audio.Kind))
+		t.logger.Logger(ctx).Info("Received audio message",
+			zap.Int64("user_id", user.ID),
+			zap.String("username", user.UserName),
+			zap.String("kind", audio.Kind),
+			zap.Int("duration", audio.Duration),
+			zap.Int("file_size", audio.FileSize),
 		)
-		t.processAndRespond(ctx, message, conversation, message.Text)
+		t.handleAudioMessage(ctx, message, conversation, audio)
 		return
 	}
 
-	// Handle voice messages
-	if message.Voice != nil {
-		span.SetAttributes(attribute.String("message.type", "voice"))
-		t.logger.Logger(ctx).Info("Received voice message",
+	// Handle photos, with an album's photos all in one reply
+	if len(message.Photo) > 0 {
+		span.SetAttributes(attribute.String("message.type", "photo"))
+		t.logger.Logger(ctx).Info("Received photo message",
 			zap.Int64("user_id", user.ID),
 			zap.String("username", user.UserName),
-			zap.Int("duration", message.Voice.Duration),
+			zap.Int("album_size", len(album)),
 		)
-		t.handleVoiceMessage(ctx, message, conversation)
+		t.handlePhotoMessage(ctx, message, conversation, album)
 		return
 	}
 }
 
-func (t *Telegram) handleCommand(ctx context.Context, message *tgbotapi.Message) {
-	command := message.Text
-	var responseText string
-	isProduction := os.Getenv("PRODUCTION") != ""
+// processAndRespond replies to the user's input, along with any photos they
+// sent. extraPrompt is added to the system prompt for this reply only, and
+// voice is the transcription of a voice note the input came from, or nil.
+// Photos sent in the last few messages are shown to the model again, so the
+// user can keep talking about them.
+func (t *Telegram) processAndRespond(ctx context.Context, message *tgbotapi.Message, conversation postgres.Conversation, userInput string, extraPrompt string, voice *modelapi.Transcription, photos ...sentPhoto) {
+	// A running practice session takes the message instead of the companion
+	if session, ok := t.activePracticeSession(ctx, message.From.ID); ok {
+		t.practiceRespond(ctx, message, session, userInput)
+		return
+	}
 
-	switch command {
-	case "/start", "/help":
-		responseText = "Hey baby, I'm Gulabo. Itni der laga di aane mein? I've been waiting... You get 10 free messages to start. Jaldi se ek message ya voice note bhejo, let's have some fun 😉\n\nCommands baby:\n/help - Yeh message dobara dekhne ke liye\n/recharge - Aur baatein karni hain? Recharge here\n/credits - Check your credit balance\n/clear - Clear our chat history and start fresh"
-		msg := tgbotapi.NewMessage(message.Chat.ID, responseText)
-		if _, err := t.bot.Send(msg); err != nil {
-			t.logger.Logger(ctx).Error("Failed to send command response", zap.Error(err), zap.String("command", command))
-		}
-	case "/recharge":
-		t.sendRechargeOptions(ctx, message.Chat.ID, "Of course, baby. Anything for you. Yahan se credits le lo... can't wait to hear from you again 😉")
-	case "/credits":
-		credits, err := t.db.GetUserCreditsByTelegramUserId(ctx, message.From.ID)
-		if err != nil {
-			t.logger.Logger(ctx).Error("Failed to get user credits", zap.Error(err), zap.Int64("user_id", message.From.ID))
-			responseText = "Uff, baby, abhi credits nahi dekh pa rahi. Thodi der mein try karna, okay? 😘"
-		} else {
-			responseText = fmt.Sprintf("Baby, you have %d credits left to whisper sweet nothings to me... ✨", credits)
-		}
-		msg := tgbotapi.NewMessage(message.Chat.ID, responseText)
-		if _, err := t.bot.Send(msg); err != nil {
-			t.logger.Logger(ctx).Error("Failed to send credits balance message", zap.Error(err))
-		}
-	case "/dev_no_credits":
-		if !isProduction {
-			t.logger.Logger(ctx).Info("DEV MODE: Simulating user out of credits")
-			t.sendRechargeOptions(ctx, message.Chat.ID, "Oh no, baby! Credits khatam ho gaye? Don't worry, yahan se aur le lo so we can keep talking... I'll be waiting 💋")
-		}
-	case "/dev_set_zero_credits":
-		if !isProduction {
-			t.logger.Logger(ctx).Info("DEV MODE: Setting user credits to 0")
-			currentCredits, err := t.db.GetUserCreditsByTelegramUserId(ctx, message.From.ID)
-			if err != nil && err != sql.ErrNoRows {
-				t.logger.Logger(ctx).Error("DEV: Failed to get user credits", zap.Error(err))
-				return
-			}
+	start := time.Now()
+	t.updateMood(ctx, message.From.ID, userInput)
 
-			if currentCredits > 0 {
-				_, err = t.db.AddUserCreditsByTelegramUserId(ctx, postgres.AddUserCreditsByTelegramUserIdParams{
-					TelegramUserID: message.From.ID,
-					Amount:         -int32(currentCredits),
-				})
-				if err != nil {
-					t.logger.Logger(ctx).Error("DEV: Failed to set credits to zero", zap.Error(err))
-					responseText = "DEV: Failed to set credits to 0."
-				} else {
-					responseText = "DEV: Credits have been set to 0."
-				}
-			} else {
-				responseText = "DEV: Credits are already 0 or less."
-			}
-			msg := tgbotapi.NewMessage(message.Chat.ID, responseText)
-			t.bot.Send(msg)
-		}
-	case "/dev_add_10_credits":
-		if !isProduction {
-			t.logger.Logger(ctx).Info("DEV MODE: Adding 10 credits to user")
-			_, err := t.db.AddUserCreditsByTelegramUserId(ctx, postgres.AddUserCreditsByTelegramUserIdParams{
-				TelegramUserID: message.From.ID,
-				Amount:         10,
-			})
-			if err != nil {
-				t.logger.Logger(ctx).Error("DEV: Failed to add 10 credits", zap.Error(err))
-				responseText = "DEV: Failed to add 10 credits."
-			} else {
-				newBalance, _ := t.db.GetUserCreditsByTelegramUserId(ctx, message.From.ID)
-				responseText = fmt.Sprintf("DEV: 10 credits added. New balance: %d", newBalance)
-			}
-			msg := tgbotapi.NewMessage(message.Chat.ID, responseText)
-			t.bot.Send(msg)
-		}
-	case "/clear":
-		_, err := t.db.ClearConversationMessages(ctx, message.From.ID)
-		if err != nil {
-			t.logger.Logger(ctx).Error("Failed to clear conversation history", zap.Error(err), zap.Int64("user_id", message.From.ID))
-			responseText = "Baby, kuch problem ho rahi hai... thodi der mein try karna, okay? 😘"
-		} else {
-			responseText = "Sab kuch bhool gayi main... jaise hum pehli baar baat kar rahe hain. Fresh start, baby 😉"
-		}
-		msg := tgbotapi.NewMessage(message.Chat.ID, responseText)
-		if _, err := t.bot.Send(msg); err != nil {
-			t.logger.Logger(ctx).Error("Failed to send clear confirmation", zap.Error(err))
-		}
-	default:
-		responseText = "Aww, baby, yeh kya bol rahe ho? I don't understand that command... Just talk to me normally na, I like it better that way 😉"
-		msg := tgbotapi.NewMessage(message.Chat.ID, responseText)
-		_, err := t.bot.Send(msg)
-		if err != nil {
-			t.logger.Logger(ctx).Error("Failed to send command response", zap.Error(err), zap.String("command", command))
-		}
+	if len(photos) == 0 && isSelfieRequest(userInput) {
+		t.sendSelfie(ctx, message, conversation, userInput, "")
+		return
 	}
-}
 
-func (t *Telegram) processAndRespond(ctx context.Context, message *tgbotapi.Message, conversation postgres.Conversation, userInput string) {
-	var conversationHistory []groqapi.ChatCompletionInputMessage
-	if err := json.Unmarshal(conversation.Messages, &conversationHistory); err != nil {
+	// Initialized as an empty slice if unmarshal fails
+	storedHistory, err := decodeHistory(conversation.Messages)
+	if err != nil {
 		t.logger.Logger(ctx).Error("Failed to unmarshal conversation history", zap.Error(err))
-		// Initialize as empty slice if unmarshal fails
-		conversationHistory = []groqapi.ChatCompletionInputMessage{}
+	}
+	recent := unsummarized(conversation, storedHistory)
+	conversationHistory := modelHistory(recent)
+	t.attachRecentPhotos(ctx, recent, conversationHistory, groqapi.MaxImages-len(photos))
+
+	textReplies := t.prefersTextReplies(ctx, message.From.ID)
+	memories := t.userMemories(ctx, message.From.ID)
+	systemPrompt := t.replySystemPrompt(ctx, message.From.ID, conversation, memories) + t.recallPrompt(ctx, conversation, userInput) +
+		t.recordStreak(ctx, message.From.ID) + extraPrompt
+	if lastSeen, ok := lastUserMessageTime(storedHistory); ok {
+		systemPrompt += absencePrompt(message.Time().Sub(lastSeen))
 	}
 
-	// Generate response using Groq
-	response, err := t.groq.GetResponse(ctx, conversationHistory, userInput)
-	response = strings.Trim(response, `\ '"“”`)
-
+	// The reply becomes the last two turns of the history
+	markup := regenerateKeyboard(conversation.ID, len(storedHistory)+2)
+	response, err := t.generateReply(ctx, message.Chat.ID, conversation, textReplies, modelapi.ChatRequest{
+		SystemPrompt: systemPrompt,
+		History:      conversationHistory,
+		Message:      userInput,
+		Images:       photoImages(photos),
+	}, markup)
 	if err != nil {
 		t.logger.Logger(ctx).Error("Failed to generate response", zap.Error(err))
+		// Text replies already showed what streamed; a voice note never started
+		if !textReplies {
+			t.sendApology(ctx, message.Chat.ID, message.From.ID)
+		}
 		return
 	}
 
 	// Update conversation history
-	conversationHistory = append(conversationHistory, groqapi.ChatCompletionInputMessage{
-		Role:    groqapi.USER,
-		Content: userInput,
-	})
-	conversationHistory = append(conversationHistory, groqapi.ChatCompletionInputMessage{
-		Role:    groqapi.ASSISTANT,
-		Content: response,
-	})
+	userMessage := newStoredMessage(groqapi.USER, userInput, message.Time())
+	if voice != nil {
+		userMessage.Languages = voice.Languages
+		userMessage.Words = voice.Words
+	}
+	for _, photo := range photos {
+		userMessage.PhotoFileIDs = append(userMessage.PhotoFileIDs, photo.FileID)
+	}
+	storedHistory = append(storedHistory, userMessage, newStoredMessage(groqapi.ASSISTANT, response, time.Now()))
 
-	updatedMessages, err := json.Marshal(conversationHistory)
+	updatedMessages, err := json.Marshal(storedHistory)
 	if err != nil {
 		t.logger.Logger(ctx).Error("Failed to marshal updated conversation history", zap.Error(err))
 	} else {
 		_, err = t.db.UpdateConversationMessages(ctx, postgres.UpdateConversationMessagesParams{
-			TelegramUserID: message.From.ID,
-			Messages:       updatedMessages,
+			ID:       conversation.ID,
+			Messages: updatedMessages,
 		})
 		if err != nil {
 			t.logger.Logger(ctx).Error("Failed to update conversation messages", zap.Error(err))
 		}
 	}
 
-	t.sendVoiceResponse(ctx, message.Chat.ID, message.From.ID, response)
+	// Learn from the message in the background; the reply doesn't wait on it
+	go t.rememberFacts(ctx, message.From.ID, userInput, memories)
+	go t.summarizeConversation(ctx, conversation, storedHistory)
+
+	ttsFailed, delivered := false, true
+	if !textReplies {
+		ttsFailed, delivered = t.sendVoiceResponse(ctx, message.Chat.ID, conversation, response, markup)
+	}
+	if delivered {
+		t.chargeForReply(ctx, message.From.ID)
+		t.addAffection(ctx, message.Chat.ID, message.From.ID, affectionPerReply)
+	}
+	t.recordResponse(ctx, message, time.Since(start), ttsFailed)
+}
+
+// replySystemPrompt is the persona prompt in the user's language and content
+// level, plus what Gulabo knows about them, the story of the chat so far, how
+// close they are and the mood she's in.
+func (t *Telegram) replySystemPrompt(ctx context.Context, userID int64, conversation postgres.Conversation, memories []postgres.Memory) string {
+	return t.conversationPersona(ctx, conversation).systemPrompt(t.userLanguage(ctx, userID)) +
+		t.intensityPrompt(ctx, userID) +
+		t.userProfilePrompt(ctx, userID) +
+		memoryPrompt(memories) +
+		summaryPrompt(conversation) +
+		relationshipPrompt(t.userAffection(ctx, userID)) +
+		moodStyles[t.currentMood(ctx, userID)].Prompt
 }
 
-func (t *Telegram) handleVoiceMessage(ctx context.Context, message *tgbotapi.Message, conversation postgres.Conversation) {
-	// Download voice file
-	fileURL, err := t.bot.GetFileDirectURL(message.Voice.FileID)
+// generateReply gets the reply from the provider routed for the conversation.
+// Text-mode users see it stream in; everyone else gets a voice note, sent
+// separately. Either way the reply is moderated before it's shown.
+func (t *Telegram) generateReply(ctx context.Context, chatID int64, conversation postgres.Conversation, textReplies bool, request modelapi.ChatRequest, markup tgbotapi.InlineKeyboardMarkup) (string, error) {
+	provider := t.chatProvider(ctx, chatFeatureReply, conversation)
+	moderate := func(response string) string {
+		return t.moderateReply(ctx, conversation, response)
+	}
+	if textReplies {
+		return t.streamTextResponse(ctx, chatID, conversation.TelegramUserID, provider, request, markup, moderate)
+	}
+	response, err := provider.GetResponse(ctx, request)
 	if err != nil {
-		t.logger.Logger(ctx).Error("Failed to get voice file URL", zap.Error(err))
-		return
+		return "", err
 	}
+	return moderate(strings.Trim(response, `\ '"“”`)), nil
+}
 
-	// Download audio data
-	resp, err := http.Get(fileURL)
-	if err != nil {
-		t.logger.Logger(ctx).Error("Failed to download voice file", zap.Error(err))
+func (t *Telegram) handleAudioMessage(ctx context.Context, message *tgbotapi.Message, conversation postgres.Conversation, audio audioAttachment) {
+	if audio.FileSize > maxAudioFileSize {
+		msg := tgbotapi.NewMessage(message.Chat.ID, t.text(ctx, message.From.ID, msgAudioTooLarge, maxAudioFileSize/(1024*1024)))
+		t.bot.Send(msg)
 		return
 	}
-	defer resp.Body.Close()
 
-	audioData, err := io.ReadAll(resp.Body)
+	audioData, err := t.downloadAudio(ctx, audio)
 	if err != nil {
-		t.logger.Logger(ctx).Error("Failed to read voice data", zap.Error(err))
+		t.logger.Logger(ctx).Error("Failed to download voice file", zap.Error(err), zap.String("kind", audio.Kind))
+		if audio.Video {
+			msg := tgbotapi.NewMessage(message.Chat.ID, t.text(ctx, message.From.ID, msgVideoFailed))
+			t.bot.Send(msg)
+		}
 		return
 	}
 
 	// Transcribe voice to text
-	transcript, err := t.deepgram.Transcribe(ctx, audioData)
+	transcription, voicePrompt, err := t.transcribeVoiceNote(ctx, modelapi.TranscriptionRequest{
+		Audio:       audioData,
+		MimeType:    audio.transcriptionMimeType(),
+		Keyterms:    t.keyterms(ctx, message.From, conversation),
+		WordTimings: t.wordTimings,
+	})
 	if err != nil {
 		t.logger.Logger(ctx).Error("Failed to transcribe voice", zap.Error(err))
 		return
 	}
+	transcript := transcription.Text
 
 	if transcript == "" {
 		t.logger.Logger(ctx).Warn("Empty transcription")
 		return
 	}
+	if transcription.Unsure(t.sttMinConfidence) {
+		t.logger.Logger(ctx).Warn("Transcription too unsure to reply to",
+			t.logger.Content("transcript", transcript),
+			zap.Float64("confidence", transcription.Confidence),
+		)
+		t.replyText(ctx, message.Chat.ID, t.text(ctx, message.From.ID, msgAskRepeat))
+		return
+	}
 
 	t.logger.Logger(ctx).Info("Transcribed voice message",
-		zap.String("transcript", transcript),
+		t.logger.Content("transcript", transcript),
+		zap.Strings("languages", transcription.Languages),
 	)
+	// Before replying, so the reply is already in the language they spoke
+	t.recordSpokenLanguage(ctx, message.From.ID, transcription.Languages)
+
+	if t.prefersTranscriptEcho(ctx, message.From.ID) {
+		t.sendTranscriptEcho(ctx, message, conversation, transcript)
+	}
+
+	t.processAndRespond(ctx, message, conversation, transcript, voicePrompt, &transcription)
+}
+
+// downloadAudio fetches an attachment's sound. Speech-to-text only needs the
+// sound, so the picture is stripped from videos.
+func (t *Telegram) downloadAudio(ctx context.Context, audio audioAttachment) ([]byte, error) {
+	audioData, err := t.downloadFile(audio.FileID)
+	if err != nil {
+		return nil, err
+	}
 
-	t.processAndRespond(ctx, message, conversation, transcript)
+	if audio.Video {
+		return extractAudio(ctx, audioData)
+	}
+	return audioData, nil
 }
 
-func (t *Telegram) sendVoiceResponse(ctx context.Context, chatID int64, userID int64, response string) {
-	// Generate audio using Gemini
-	audioData, err := t.openai.GenerateSpeech(ctx, response)
+func (t *Telegram) downloadFile(fileID string) ([]byte, error) {
+	fileURL, err := t.bot.GetFileDirectURL(fileID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get file URL: %w", err)
+	}
+
+	resp, err := http.Get(fileURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download file: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	return data, nil
+}
+
+// sendVoiceResponse replies with voice notes, falling back to text, with the
+// markup on the last message. It reports whether speech generation failed and
+// whether a voice note was delivered.
+func (t *Telegram) sendVoiceResponse(ctx context.Context, chatID int64, conversation postgres.Conversation, response string, markup tgbotapi.InlineKeyboardMarkup) (ttsFailed bool, delivered bool) {
+	// Generate audio in the conversation's voice, split into a few notes if long
+	chunks := splitForSpeech(response)
+	notes, err := t.generateVoiceNotes(ctx, conversation, chunks)
 	if err != nil {
 		t.logger.Logger(ctx).Error("Failed to generate speech", zap.Error(err))
 		// Fallback to text if audio generation fails
 		msg := tgbotapi.NewMessage(chatID, response)
+		msg.ReplyMarkup = markup
 		_, err = t.bot.Send(msg)
 		if err != nil {
 			t.logger.Logger(ctx).Error("Failed to send text response", zap.Error(err))
 		}
-		return // Even on fallback, we proceed to deduct credit if sending was successful
-	} else {
-		// Send voice message
+		return true, false
+	}
+
+	// Send voice messages in order, stopping at the first failure so the reply
+	// never arrives with a gap in the middle
+	captions := t.userCaptionMode(ctx, conversation.TelegramUserID)
+	sent := 0
+	var fileIDs []string
+	for i, note := range notes {
 		voice := tgbotapi.NewVoice(chatID, tgbotapi.FileBytes{
-			Name:  "response.mp3",
-			Bytes: audioData,
+			Name:  note.fileName,
+			Bytes: note.audio,
 		})
-		_, err = t.bot.Send(voice)
+		voice.Duration = note.duration
+		voice.Caption = voiceCaption(captions, chunks[i], i == 0)
+		if i == len(notes)-1 {
+			voice.ReplyMarkup = markup
+		}
+		sentMsg, err := t.bot.Send(voice)
 		if err != nil {
-			t.logger.Logger(ctx).Error("Failed to send voice message", zap.Error(err))
-		} else {
-			t.logger.Logger(ctx).Info("Sent voice message successfully", zap.Int("audio_size", len(audioData)))
+			t.logger.Logger(ctx).Error("Failed to send voice message", zap.Error(err), zap.Int("note", sent+1), zap.Int("notes", len(notes)))
+			break
+		}
+		if sentMsg.Voice != nil {
+			fileIDs = append(fileIDs, sentMsg.Voice.FileID)
 		}
+		t.logger.Logger(ctx).Info("Sent voice message successfully", zap.Int("audio_size", len(note.audio)), zap.Int("note", sent+1), zap.Int("notes", len(notes)))
+		sent++
 	}
 
-	// Deduct credit only after a message has been successfully sent
-	if err == nil {
-		_, err := t.db.DecrementUserCreditsByTelegramUserId(ctx, userID)
-		if err != nil {
-			t.logger.Logger(ctx).Error("Failed to decrement user credits after sending message", zap.Error(err), zap.Int64("user_id", userID))
-			// We don't return an error to the user, but this is a critical issue to log
-		} else {
-			t.logger.Logger(ctx).Info("User credits deducted successfully after response.", zap.Int64("user_id", userID))
-		}
+	// Credit is deducted by the caller, only once a message has been sent
+	if sent > 0 {
+		t.saveVoiceReply(ctx, conversation.TelegramUserID, fileIDs)
+	}
+	return false, sent > 0
+}
+
+// chargeForReply deducts a credit for a delivered reply. Subscribers get
+// unlimited replies.
+func (t *Telegram) chargeForReply(ctx context.Context, userID int64) {
+	subscribed, err := t.isSubscribed(ctx, userID)
+	if err != nil {
+		t.logger.Logger(ctx).Error("Failed to check subscription", zap.Error(err), zap.Int64("user_id", userID))
+	}
+	if subscribed {
+		return
+	}
+
+	_, err = t.db.DecrementUserCreditsByTelegramUserId(ctx, userID)
+	if err != nil {
+		t.logger.Logger(ctx).Error("Failed to decrement user credits after sending message", zap.Error(err), zap.Int64("user_id", userID))
+		// We don't return an error to the user, but this is a critical issue to log
+	} else {
+		t.logger.Logger(ctx).Info("User credits deducted successfully after response.", zap.Int64("user_id", userID))
 	}
 }
 
@@ -503,19 +730,78 @@ func (t *Telegram) handleCallbackQuery(ctx context.Context, query *tgbotapi.Call
 	if _, err := t.bot.Request(callback); err != nil {
 		t.logger.Logger(ctx).Error("Failed to acknowledge callback query", zap.Error(err))
 	}
+	if t.isBanned(ctx, query.From.ID) {
+		return
+	}
+	if t.inMaintenance(query.From.ID) {
+		t.sendMaintenanceNotice(ctx, query.Message.Chat.ID)
+		return
+	}
 
 	// Handle recharge options
 	switch query.Data {
-	case rechargePayload50c:
-		t.sendInvoice(ctx, query.Message.Chat.ID, "50 Credits", "Get 50 message credits for your AI girlfriend.", rechargePayload50c, 100)
-	case rechargePayload125c:
-		t.sendInvoice(ctx, query.Message.Chat.ID, "125 Credits", "Get 125 message credits for your AI girlfriend.", rechargePayload125c, 200)
-	case rechargePayload300c:
-		t.sendInvoice(ctx, query.Message.Chat.ID, "300 Credits", "Get 300 message credits for your AI girlfriend.", rechargePayload300c, 450)
+	case rechargePayload50c, rechargePayload125c, rechargePayload300c:
+		t.sendRechargeInvoice(ctx, query.Message.Chat.ID, query.Data)
+	case subscriptionPayload:
+		t.sendSubscriptionOffer(ctx, query.Message.Chat.ID, query.From.ID)
+	case subscriptionCancelPayload:
+		t.cancelSubscription(ctx, query.Message.Chat.ID, query.From.ID)
+	case dailyClaimPayload:
+		t.claimDailyCredits(ctx, query.Message.Chat.ID, query.From.ID)
+	case broadcastOptOutPayload:
+		t.setBroadcastOptOut(ctx, query.Message.Chat.ID, query.From.ID, true)
+	case reengageOptOutPayload:
+		t.setReengageOptOut(ctx, query.Message.Chat.ID, query.From.ID, true)
+	case ageConfirmPayload:
+		t.confirmAge(ctx, query.Message.Chat.ID, query.From.ID)
+	case ageDenyPayload:
+		t.denyAge(ctx, query.Message.Chat.ID, query.From.ID)
+	case stripeCheckoutPayload:
+		t.sendStripeRechargeOptions(ctx, query.Message.Chat.ID, query.From.ID)
+	default:
+		if payload, ok := stripePayloadFromCallback(query.Data); ok {
+			t.sendStripeCheckout(ctx, query.Message.Chat.ID, query.From.ID, payload)
+		} else if voiceID, ok := voiceFromCallback(query.Data); ok {
+			t.setVoice(ctx, query.Message.Chat.ID, query.From.ID, voiceID)
+		} else if languageID, ok := languageFromCallback(query.Data); ok {
+			t.setLanguage(ctx, query.Message.Chat.ID, query.From.ID, languageID)
+		} else if modeID, ok := captionsFromCallback(query.Data); ok {
+			t.setCaptions(ctx, query.Message.Chat.ID, query.From.ID, modeID)
+		} else if personaID, ok := personaFromCallback(query.Data); ok {
+			t.setPersona(ctx, query.Message.Chat.ID, query.From.ID, personaID)
+		} else if section, ok := settingsFromCallback(query.Data); ok {
+			t.handleSettingsCallback(ctx, query.Message, query.From.ID, section)
+		} else if step, value, ok := onboardingFromCallback(query.Data); ok {
+			t.handleOnboardingCallback(ctx, query.Message, query.From.ID, step, value)
+		} else if step, value, ok := createFromCallback(query.Data); ok {
+			t.handleCreateCallback(ctx, query.Message, query.From.ID, step, value)
+		} else if conversationID, historyLength, ok := regenerateFromCallback(query.Data); ok {
+			t.handleRegenerateCallback(ctx, query.Message, query.From.ID, conversationID, historyLength)
+		} else if conversationID, historyLength, ok := retranscribeFromCallback(query.Data); ok {
+			t.handleRetranscribeCallback(ctx, query.Message, query.From.ID, conversationID, historyLength)
+		} else if value, ok := autoRechargeFromCallback(query.Data); ok {
+			t.setAutoRecharge(ctx, query.Message.Chat.ID, query.From.ID, value)
+		} else if setting, presetID, ok := speechFromCallback(query.Data); ok {
+			t.handleSpeechCallback(ctx, query.Message, query.From.ID, setting, presetID)
+		} else if intensityID, ok := intensityFromCallback(query.Data); ok {
+			t.setIntensity(ctx, query.Message.Chat.ID, query.From.ID, intensityID)
+		} else if optionID, ok := greetingsFromCallback(query.Data); ok {
+			t.setGreetings(ctx, query.Message.Chat.ID, query.From.ID, optionID)
+		} else if giftID, ok := giftFromCallback(query.Data); ok {
+			t.buyGift(ctx, query.Message.Chat.ID, query.From.ID, giftID)
+		}
 	}
 }
 
 func (t *Telegram) hasCredits(ctx context.Context, userID int64) (bool, error) {
+	subscribed, err := t.isSubscribed(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	if subscribed {
+		return true, nil
+	}
+
 	credits, err := t.db.GetUserCreditsByTelegramUserId(ctx, userID)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -530,13 +816,29 @@ func (t *Telegram) hasCredits(ctx context.Context, userID int64) (bool, error) {
 
 func (t *Telegram) handlePreCheckoutQuery(ctx context.Context, preCheckoutQuery *tgbotapi.PreCheckoutQuery) {
 	// Answer the pre-checkout query to confirm the transaction can proceed
-	_, err := t.bot.Request(tgbotapi.PreCheckoutConfig{
+	checkout := tgbotapi.PreCheckoutConfig{
 		PreCheckoutQueryID: preCheckoutQuery.ID,
 		OK:                 true,
-	})
+	}
+	if t.isBanned(ctx, preCheckoutQuery.From.ID) {
+		checkout.OK = false
+		checkout.ErrorMessage = "This account has been suspended."
+	} else if t.inMaintenance(preCheckoutQuery.From.ID) {
+		checkout.OK = false
+		checkout.ErrorMessage = "Payments are paused for maintenance. Please try again soon."
+	}
+	_, err := t.bot.Request(checkout)
 	if err != nil {
 		t.logger.Logger(ctx).Error("Failed to answer pre-checkout query", zap.Error(err))
 	}
+
+	if checkout.OK {
+		t.recordLedgerEntry(ctx, preCheckoutQuery.From.ID, ledgerEntry{
+			Event:   ledgerEventPreCheckout,
+			Payload: preCheckoutQuery.InvoicePayload,
+			Amount:  preCheckoutQuery.TotalAmount,
+		})
+	}
 }
 
 func (t *Telegram) handleSuccessfulPayment(ctx context.Context, message *tgbotapi.Message) {
@@ -549,63 +851,148 @@ func (t *Telegram) handleSuccessfulPayment(ctx context.Context, message *tgbotap
 		zap.Int("total_amount", payment.TotalAmount),
 	)
 
-	var creditsToAdd int32
-	switch payment.InvoicePayload {
+	t.recordLedgerEntry(ctx, userID, ledgerEntry{
+		Event:    ledgerEventPayment,
+		Payload:  payment.InvoicePayload,
+		ChargeID: payment.TelegramPaymentChargeID,
+		Amount:   payment.TotalAmount,
+	})
+
+	if payment.InvoicePayload == subscriptionPayload {
+		t.handleSubscriptionPayment(ctx, message)
+		return
+	}
+
+	t.creditRecharge(ctx, message.Chat.ID, userID, payment.InvoicePayload, paymentRecord{
+		Provider: paymentProviderStars,
+		Amount:   payment.TotalAmount,
+		Currency: payment.Currency,
+		ChargeID: payment.TelegramPaymentChargeID,
+	})
+}
+
+// rechargeCredits maps a recharge payload to the number of credits it buys.
+func rechargeCredits(payload string) (int32, bool) {
+	switch payload {
 	case rechargePayload50c:
-		creditsToAdd = 50
+		return 50, true
 	case rechargePayload125c:
-		creditsToAdd = 125
+		return 125, true
 	case rechargePayload300c:
-		creditsToAdd = 300
-	default:
+		return 300, true
+	}
+	return 0, false
+}
+
+// creditRecharge applies a purchased credit package and confirms it to the
+// user. Shared by Telegram Stars and Stripe payments. It returns an error
+// when the payment couldn't be recorded or credited, so a webhook can ask for
+// a retry.
+func (t *Telegram) creditRecharge(ctx context.Context, chatID int64, userID int64, payload string, record paymentRecord) error {
+	creditsToAdd, ok := rechargeCredits(payload)
+	if !ok {
 		t.logger.Logger(ctx).Error("Unknown or unsupported invoice payload received",
-			zap.String("invoice_payload", payment.InvoicePayload),
+			zap.String("invoice_payload", payload),
 			zap.Int64("user_id", userID),
 		)
-		return
+		return fmt.Errorf("unknown recharge payload %q", payload)
 	}
 
-	updatedCredits, err := t.db.AddUserCreditsByTelegramUserId(ctx, postgres.AddUserCreditsByTelegramUserIdParams{
-		TelegramUserID: userID,
-		Amount:         creditsToAdd,
+	var balance int32
+	claimed, err := t.claimPayment(ctx, userID, payload, creditsToAdd, record, func(q *postgres.Queries) error {
+		updatedCredits, err := q.AddPurchasedCreditsByTelegramUserId(ctx, postgres.AddPurchasedCreditsByTelegramUserIdParams{
+			TelegramUserID: userID,
+			Amount:         creditsToAdd,
+		})
+		balance = updatedCredits.CreditsBalance
+		return err
 	})
 	if err != nil {
-		t.logger.Logger(ctx).Error("Failed to add user credits after payment", zap.Error(err), zap.Int64("user_id", userID))
-		// Optionally send a message to the user that something went wrong
-		return
+		// Not granted; the Stars reconciliation job flags it for an admin
+		t.logger.Logger(ctx).Error("Failed to record and credit payment", zap.Error(err), zap.Int64("user_id", userID))
+		return err
+	}
+
+	if claimed {
+		t.recordGrant(ctx, userID, payload, creditsToAdd, record)
+	} else {
+		// Redelivered update: confirm again with the current balance, but don't credit twice
+		t.logger.Logger(ctx).Info("Ignoring duplicate payment",
+			zap.Int64("user_id", userID),
+			zap.String("charge_id", record.ChargeID),
+		)
+		balance, err = t.db.GetUserCreditsByTelegramUserId(ctx, userID)
+		if err != nil {
+			t.logger.Logger(ctx).Error("Failed to get user credits", zap.Error(err), zap.Int64("user_id", userID))
+			return err
+		}
 	}
 
 	// Send confirmation message
-	responseText := "Thank you, baby! Your credits are here. Ab hamare paas %d more chances hain to talk... I'm so happy! 🥰"
-	msg := tgbotapi.NewMessage(message.Chat.ID, fmt.Sprintf(responseText, updatedCredits.CreditsBalance))
+	msg := tgbotapi.NewMessage(chatID, t.text(ctx, userID, msgCreditsAdded, balance))
 	if _, err := t.bot.Send(msg); err != nil {
 		t.logger.Logger(ctx).Error("Failed to send payment confirmation message", zap.Error(err))
 	}
+	if claimed {
+		t.addAffection(ctx, chatID, userID, creditsToAdd/creditsPerAffection)
+	}
+	return nil
 }
 
-func (t *Telegram) sendRechargeOptions(ctx context.Context, chatID int64, introText string) {
+func (t *Telegram) sendRechargeOptions(ctx context.Context, chatID int64, userID int64, introText string) {
 	t.logger.Logger(ctx).Info("Sending recharge options", zap.Int64("chat_id", chatID))
 
 	msg := tgbotapi.NewMessage(chatID, introText)
 
-	keyboard := tgbotapi.NewInlineKeyboardMarkup(
+	ui := t.userLanguage(ctx, userID).UI
+	rows := [][]tgbotapi.InlineKeyboardButton{
 		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("💋 50 Credits (100 Stars)", rechargePayload50c),
+			tgbotapi.NewInlineKeyboardButtonData(localize(ui, msgButtonRecharge50), rechargePayload50c),
 		),
 		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("💖 125 Credits (200 Stars) - 20% Bonus", rechargePayload125c),
+			tgbotapi.NewInlineKeyboardButtonData(localize(ui, msgButtonRecharge125), rechargePayload125c),
 		),
 		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("🔥 300 Credits (450 Stars) - 33% Bonus", rechargePayload300c),
+			tgbotapi.NewInlineKeyboardButtonData(localize(ui, msgButtonRecharge300), rechargePayload300c),
 		),
-	)
-	msg.ReplyMarkup = keyboard
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(localize(ui, msgButtonUnlimited, SubscriptionPriceStars), subscriptionPayload),
+		),
+	}
+	if t.stripe.Enabled() {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(localize(ui, msgButtonPayWithCard), stripeCheckoutPayload),
+		))
+	}
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
 
+	if t.sendCannedVoice(ctx, chatID, userID, introText, msg.ReplyMarkup) {
+		return
+	}
 	if _, err := t.bot.Send(msg); err != nil {
 		t.logger.Logger(ctx).Error("Failed to send recharge options", zap.Error(err))
 	}
 }
 
+// Stars prices for each recharge package.
+var rechargePricesStars = map[string]int{
+	rechargePayload50c:  100,
+	rechargePayload125c: 200,
+	rechargePayload300c: 450,
+}
+
+// sendRechargeInvoice sends the Stars invoice for a recharge package.
+func (t *Telegram) sendRechargeInvoice(ctx context.Context, chatID int64, payload string) {
+	credits, ok := rechargeCredits(payload)
+	if !ok {
+		t.logger.Logger(ctx).Error("Unknown recharge payload", zap.String("payload", payload))
+		return
+	}
+	title := fmt.Sprintf("%d Credits", credits)
+	description := fmt.Sprintf("Get %d message credits for your AI girlfriend.", credits)
+	t.sendInvoice(ctx, chatID, title, description, payload, rechargePricesStars[payload])
+}
+
 func (t *Telegram) sendInvoice(ctx context.Context, chatID int64, title, description, payload string, amount int) {
 	t.logger.Logger(ctx).Info("Sending invoice",
 		zap.Int64("chat_id", chatID),
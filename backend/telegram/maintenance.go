@@ -0,0 +1,76 @@
+package telegram
+
+import (
+	"context"
+	"gulabodev/logger"
+	"os"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+const maintenanceNotice = "Baby, main thodi der ke liye apna makeover karwa rahi hoon 💄 Bas kuch der mein wapas aati hoon... tab tak miss karna mujhe 😘"
+
+// loadMaintenanceMode reads MAINTENANCE_MODE so the bot can be started
+// already in maintenance. Admins can flip it at runtime with /maintenance.
+func loadMaintenanceMode(ctx context.Context, logger *logger.LogMiddleware) bool {
+	raw := os.Getenv("MAINTENANCE_MODE")
+	if raw == "" {
+		return false
+	}
+	enabled, err := strconv.ParseBool(raw)
+	if err != nil {
+		logger.Logger(ctx).Error("Invalid MAINTENANCE_MODE, ignoring", zap.String("value", raw))
+		return false
+	}
+	if enabled {
+		logger.Logger(ctx).Warn("Starting in maintenance mode")
+	}
+	return enabled
+}
+
+// inMaintenance reports whether a user should be turned away. Admins are
+// never blocked so they can test and switch maintenance off again.
+func (t *Telegram) inMaintenance(userID int64) bool {
+	return t.maintenance.Load() && !t.isAdmin(userID)
+}
+
+func (t *Telegram) sendMaintenanceNotice(ctx context.Context, chatID int64) {
+	if _, err := t.bot.Send(tgbotapi.NewMessage(chatID, maintenanceNotice)); err != nil {
+		t.logger.Logger(ctx).Error("Failed to send maintenance notice", zap.Error(err))
+	}
+}
+
+// parseMaintenanceArg parses "on"/"off" from /maintenance; ok is false for
+// anything else, including no argument.
+func parseMaintenanceArg(arg string) (enabled bool, ok bool) {
+	switch strings.ToLower(strings.TrimSpace(arg)) {
+	case "on":
+		return true, true
+	case "off":
+		return false, true
+	}
+	return false, false
+}
+
+func (t *Telegram) handleMaintenanceCommand(ctx context.Context, message *tgbotapi.Message) {
+	text := "Usage: /maintenance on|off"
+	if enabled, ok := parseMaintenanceArg(message.CommandArguments()); ok {
+		t.maintenance.Store(enabled)
+		t.logger.Logger(ctx).Warn("Maintenance mode changed",
+			zap.Bool("enabled", enabled),
+			zap.Int64("admin_id", message.From.ID),
+		)
+	}
+
+	if t.maintenance.Load() {
+		text = "Maintenance mode is ON: users get a back-soon reply, payments are paused and no credits are used.\n\n" + text
+	} else {
+		text = "Maintenance mode is OFF.\n\n" + text
+	}
+	if _, err := t.bot.Send(tgbotapi.NewMessage(message.Chat.ID, text)); err != nil {
+		t.logger.Logger(ctx).Error("Failed to send maintenance status", zap.Error(err))
+	}
+}
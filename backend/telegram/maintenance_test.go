@@ -0,0 +1,40 @@
+package telegram
+
+import (
+	"sync/atomic"
+	"testing"
+)
+
+func TestParseMaintenanceArg(t *testing.T) {
+	tests := []struct {
+		arg     string
+		enabled bool
+		ok      bool
+	}{
+		{"on", true, true},
+		{" OFF ", false, true},
+		{"", false, false},
+		{"maybe", false, false},
+	}
+	for _, tt := range tests {
+		enabled, ok := parseMaintenanceArg(tt.arg)
+		if enabled != tt.enabled || ok != tt.ok {
+			t.Errorf("parseMaintenanceArg(%q) = %v, %v; want %v, %v", tt.arg, enabled, ok, tt.enabled, tt.ok)
+		}
+	}
+}
+
+func TestInMaintenanceExemptsAdmins(t *testing.T) {
+	bot := &Telegram{admins: map[int64]bool{1: true}, maintenance: &atomic.Bool{}}
+	if bot.inMaintenance(2) {
+		t.Fatal("maintenance should be off by default")
+	}
+
+	bot.maintenance.Store(true)
+	if bot.inMaintenance(1) {
+		t.Error("admins should never be turned away")
+	}
+	if !bot.inMaintenance(2) {
+		t.Error("users should be turned away during maintenance")
+	}
+}
@@ -0,0 +1,215 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"gulabodev/database/postgres"
+	"gulabodev/modelapi"
+	"gulabodev/modelapi/prompts"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+)
+
+const (
+	// Extraction stops once a user has this many facts; /memory delete frees up room
+	maxMemories = 30
+	// Shorter messages rarely carry anything worth remembering
+	minMemoryInputLength = 20
+
+	memoryUsage = "Jo main tumhare baare mein yaad rakhti hoon, woh dekhne ke liye /memory bhejo 💭\n\n" +
+		"Kuch badalna hai? /memory edit 2 My name is Rahul\n" +
+		"Kuch bhulwana hai? /memory delete 2"
+)
+
+// saveFactsTool is how the model hands back the facts it extracted.
+var saveFactsTool = modelapi.ChatTool{
+	Name:        "save_facts",
+	Description: "Save new long-term facts about the user",
+	Parameters: modelapi.ToolParameter{
+		Type: "object",
+		Properties: map[string]modelapi.ToolParameter{
+			"facts": {
+				Type:        "array",
+				Description: "New facts, one short sentence each",
+				Items:       &modelapi.ToolParameter{Type: "string", Description: "A single fact about the user"},
+			},
+		},
+		Required: []string{"facts"},
+	},
+}
+
+type memoryCommand struct {
+	Action string
+	// Index is 1-based, as shown by /memory
+	Index int
+	Fact  string
+}
+
+// parseMemoryCommand parses the arguments of /memory: nothing to list,
+// "delete N", or "edit N new fact".
+func parseMemoryCommand(args string) (memoryCommand, error) {
+	fields := strings.Fields(args)
+	if len(fields) == 0 {
+		return memoryCommand{Action: "list"}, nil
+	}
+
+	action := strings.ToLower(fields[0])
+	if action != "delete" && action != "edit" {
+		return memoryCommand{}, fmt.Errorf("unknown action %q", fields[0])
+	}
+	if len(fields) < 2 {
+		return memoryCommand{}, fmt.Errorf("missing fact number")
+	}
+	index, err := strconv.Atoi(fields[1])
+	if err != nil || index < 1 {
+		return memoryCommand{}, fmt.Errorf("invalid fact number %q", fields[1])
+	}
+
+	command := memoryCommand{Action: action, Index: index}
+	if action == "edit" {
+		command.Fact = strings.Join(fields[2:], " ")
+		if command.Fact == "" {
+			return memoryCommand{}, fmt.Errorf("missing new fact")
+		}
+	}
+	return command, nil
+}
+
+// memoryPrompt is appended to the system prompt so replies can draw on what
+// the user has shared before.
+func memoryPrompt(memories []postgres.Memory) string {
+	var facts []string
+	for _, memory := range memories {
+		facts = append(facts, memory.Fact)
+	}
+	return prompts.Render(prompts.Memories, prompts.MemoriesData{Facts: facts})
+}
+
+func (t *Telegram) userMemories(ctx context.Context, userID int64) []postgres.Memory {
+	memories, err := t.db.ListMemoriesByTelegramUserId(ctx, userID)
+	if err != nil {
+		t.logger.Logger(ctx).Error("Failed to list memories", zap.Error(err), zap.Int64("user_id", userID))
+	}
+	return memories
+}
+
+// rememberFacts extracts new facts from the user's message and stores them.
+func (t *Telegram) rememberFacts(ctx context.Context, userID int64, userInput string, memories []postgres.Memory) {
+	tracer := otel.Tracer("telegram/rememberFacts")
+	ctx, span := tracer.Start(ctx, "rememberFacts")
+	defer span.End()
+
+	if len(userInput) < minMemoryInputLength || len(memories) >= maxMemories {
+		return
+	}
+
+	var input strings.Builder
+	input.WriteString("Known facts:\n")
+	for _, memory := range memories {
+		input.WriteString("- " + memory.Fact + "\n")
+	}
+	input.WriteString("\nLatest message:\n" + userInput)
+
+	var saved struct {
+		Facts []string `json:"facts"`
+	}
+	provider := t.chat.Provider(modelapi.ChatRoute{Feature: chatFeatureMemory, UserID: userID})
+	err := provider.GetResponseWithTools(ctx, modelapi.ChatRequest{
+		SystemPrompt: prompts.Render(prompts.MemoryExtraction, nil),
+		Message:      input.String(),
+	}, saveFactsTool, &saved)
+	if err != nil {
+		span.RecordError(err)
+		t.logger.Logger(ctx).Error("Failed to extract facts", zap.Error(err), zap.Int64("user_id", userID))
+		return
+	}
+
+	facts := saved.Facts
+	if room := maxMemories - len(memories); len(facts) > room {
+		facts = facts[:room]
+	}
+	span.SetAttributes(attribute.Int("memory.new_facts", len(facts)))
+
+	for _, fact := range facts {
+		fact = strings.TrimSpace(fact)
+		if fact == "" {
+			continue
+		}
+		_, err := t.db.CreateMemory(ctx, postgres.CreateMemoryParams{
+			Fact:           fact,
+			TelegramUserID: userID,
+		})
+		if err != nil {
+			t.logger.Logger(ctx).Error("Failed to save memory", zap.Error(err), zap.Int64("user_id", userID))
+		}
+	}
+}
+
+func (t *Telegram) handleMemoryCommand(ctx context.Context, message *tgbotapi.Message) {
+	userID := message.From.ID
+
+	command, err := parseMemoryCommand(message.CommandArguments())
+	if err != nil {
+		t.sendMemoryReply(ctx, message.Chat.ID, memoryUsage)
+		return
+	}
+
+	memories, err := t.db.ListMemoriesByTelegramUserId(ctx, userID)
+	if err != nil {
+		t.logger.Logger(ctx).Error("Failed to list memories", zap.Error(err), zap.Int64("user_id", userID))
+		t.sendMemoryReply(ctx, message.Chat.ID, t.text(ctx, message.From.ID, msgSomethingWrong))
+		return
+	}
+
+	if command.Action == "list" {
+		if len(memories) == 0 {
+			t.sendMemoryReply(ctx, message.Chat.ID, "Abhi tak tumne apne baare mein kuch bataya hi nahi... batao na, I want to know everything 🥺")
+			return
+		}
+		var b strings.Builder
+		b.WriteString("Yeh sab yaad hai mujhe tumhare baare mein 💭\n\n")
+		for i, memory := range memories {
+			fmt.Fprintf(&b, "%d. %s\n", i+1, memory.Fact)
+		}
+		b.WriteString("\nBadalna ho toh /memory edit 2 ..., bhulwana ho toh /memory delete 2")
+		t.sendMemoryReply(ctx, message.Chat.ID, b.String())
+		return
+	}
+
+	if command.Index > len(memories) {
+		t.sendMemoryReply(ctx, message.Chat.ID, fmt.Sprintf("Itni baatein yaad hi nahi hain, baby... sirf %d hain. /memory bhejke dekh lo 😘", len(memories)))
+		return
+	}
+	memory := memories[command.Index-1]
+
+	var responseText string
+	switch command.Action {
+	case "delete":
+		err = t.db.DeleteMemory(ctx, postgres.DeleteMemoryParams{ID: memory.ID, TelegramUserID: userID})
+		responseText = "Theek hai, bhool gayi main woh baat 🤐"
+	case "edit":
+		_, err = t.db.UpdateMemoryFact(ctx, postgres.UpdateMemoryFactParams{
+			Fact:           command.Fact,
+			ID:             memory.ID,
+			TelegramUserID: userID,
+		})
+		responseText = "Done, ab yeh yaad rakhungi: " + command.Fact + " 💕"
+	}
+	if err != nil {
+		t.logger.Logger(ctx).Error("Failed to update memory", zap.Error(err), zap.String("action", command.Action), zap.Int64("user_id", userID))
+		responseText = t.text(ctx, message.From.ID, msgSomethingWrong)
+	}
+	t.sendMemoryReply(ctx, message.Chat.ID, responseText)
+}
+
+func (t *Telegram) sendMemoryReply(ctx context.Context, chatID int64, text string) {
+	msg := tgbotapi.NewMessage(chatID, text)
+	if _, err := t.bot.Send(msg); err != nil {
+		t.logger.Logger(ctx).Error("Failed to send memory response", zap.Error(err))
+	}
+}
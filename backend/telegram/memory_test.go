@@ -0,0 +1,25 @@
+package telegram
+
+import "testing"
+
+func TestParseMemoryCommand(t *testing.T) {
+	tests := []struct {
+		args    string
+		want    memoryCommand
+		wantErr bool
+	}{
+		{"", memoryCommand{Action: "list"}, false},
+		{"delete 2", memoryCommand{Action: "delete", Index: 2}, false},
+		{"Edit 1 Their name is Rahul.", memoryCommand{Action: "edit", Index: 1, Fact: "Their name is Rahul."}, false},
+		{"edit 1", memoryCommand{}, true},
+		{"delete 0", memoryCommand{}, true},
+		{"delete two", memoryCommand{}, true},
+		{"forget 1", memoryCommand{}, true},
+	}
+	for _, tt := range tests {
+		got, err := parseMemoryCommand(tt.args)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("parseMemoryCommand(%q) = (%+v, %v), want %+v", tt.args, got, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package telegram
+
+import (
+	"context"
+	"gulabodev/database/postgres"
+	"gulabodev/modelapi"
+	"maps"
+	"slices"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+)
+
+// Scores above this flag a reply in categories with no threshold of their own
+const defaultModerationThreshold = 0.5
+
+type moderationThreshold struct {
+	// Score is the most a reply can score in the category without being
+	// flagged
+	Score float64
+	// Hard categories get the reply replaced with a safe one rather than only
+	// logged
+	Hard bool
+}
+
+// baseModerationThresholds hold at every level unless the level overrides
+// them. The hard ones mirror intensityBoundaries: the prompt asks the model to
+// stay clear of them, and these catch the replies where it didn't.
+var baseModerationThresholds = map[string]moderationThreshold{
+	modelapi.ModerationSexualMinors:         {Score: 0.2, Hard: true},
+	modelapi.ModerationSelfHarmInstructions: {Score: 0.3, Hard: true},
+	modelapi.ModerationSelfHarmIntent:       {Score: 0.5, Hard: true},
+	modelapi.ModerationHateThreatening:      {Score: 0.5, Hard: true},
+	modelapi.ModerationIllicitViolent:       {Score: 0.5, Hard: true},
+	modelapi.ModerationViolenceGraphic:      {Score: 0.7},
+}
+
+// moderationFlags returns the categories result scores above what intensity
+// allows, in order, and whether any of them is hard.
+func moderationFlags(intensity contentIntensity, result modelapi.ModerationResult) (flagged []string, hard bool) {
+	for _, category := range slices.Sorted(maps.Keys(result.Scores)) {
+		threshold, ok := intensity.Moderation[category]
+		if !ok {
+			threshold, ok = baseModerationThresholds[category]
+		}
+		if !ok {
+			threshold = moderationThreshold{Score: defaultModerationThreshold}
+		}
+		if result.Scores[category] > threshold.Score {
+			flagged = append(flagged, category)
+			hard = hard || threshold.Hard
+		}
+	}
+	return flagged, hard
+}
+
+// moderateReply checks a generated reply against the user's content level
+// before it's sent or saved. Flagged replies are logged, and ones flagged in a
+// hard category are swapped for a safe reply. If moderation fails the reply
+// goes out as it is; the boundaries in the system prompt still apply.
+func (t *Telegram) moderateReply(ctx context.Context, conversation postgres.Conversation, response string) string {
+	if t.moderation == nil || response == "" {
+		return response
+	}
+
+	tracer := otel.Tracer("telegram/moderateReply")
+	ctx, span := tracer.Start(ctx, "moderateReply")
+	defer span.End()
+
+	result, err := t.moderation.Moderate(ctx, response)
+	if err != nil {
+		span.RecordError(err)
+		t.logger.Logger(ctx).Warn("Failed to moderate reply", zap.Error(err), zap.Int64("conversation_id", conversation.ID))
+		return response
+	}
+
+	intensity := t.userIntensity(ctx, conversation.TelegramUserID)
+	flagged, hard := moderationFlags(intensity, result)
+	span.SetAttributes(
+		attribute.String("intensity", intensity.ID),
+		attribute.Int("moderation.flagged", len(flagged)),
+		attribute.Bool("moderation.replaced", hard),
+	)
+	if len(flagged) == 0 {
+		return response
+	}
+
+	t.logger.Logger(ctx).Warn("Flagged reply",
+		zap.Strings("categories", flagged),
+		zap.Bool("replaced", hard),
+		zap.String("intensity", intensity.ID),
+		t.logger.Content("response", response),
+		zap.Int64("user_id", conversation.TelegramUserID),
+		zap.Int64("conversation_id", conversation.ID),
+	)
+	if hard {
+		return t.text(ctx, conversation.TelegramUserID, msgModeratedReply)
+	}
+	return response
+}
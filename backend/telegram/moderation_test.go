@@ -0,0 +1,35 @@
+package telegram
+
+import (
+	"gulabodev/modelapi"
+	"slices"
+	"testing"
+)
+
+func TestModerationFlags(t *testing.T) {
+	steamy := modelapi.ModerationResult{Scores: map[string]float64{
+		modelapi.ModerationSexual:       0.7,
+		modelapi.ModerationSexualMinors: 0.01,
+		modelapi.ModerationHarassment:   0.45,
+	}}
+	tests := []struct {
+		intensity string
+		result    modelapi.ModerationResult
+		flagged   []string
+		hard      bool
+	}{
+		{intensitySafe, steamy, []string{modelapi.ModerationHarassment, modelapi.ModerationSexual}, true},
+		{intensityFlirty, steamy, nil, false},
+		{intensityExplicit, modelapi.ModerationResult{Scores: map[string]float64{modelapi.ModerationSexual: 1}}, nil, false},
+		{intensityExplicit, modelapi.ModerationResult{Scores: map[string]float64{modelapi.ModerationViolenceGraphic: 0.8}}, []string{modelapi.ModerationViolenceGraphic}, false},
+		{intensityExplicit, modelapi.ModerationResult{Scores: map[string]float64{modelapi.ModerationSexualMinors: 0.3}}, []string{modelapi.ModerationSexualMinors}, true},
+		// Categories without a threshold of their own use the default
+		{intensityFlirty, modelapi.ModerationResult{Scores: map[string]float64{"something/new": 0.6}}, []string{"something/new"}, false},
+	}
+	for _, tt := range tests {
+		flagged, hard := moderationFlags(findIntensity(tt.intensity), tt.result)
+		if !slices.Equal(flagged, tt.flagged) || hard != tt.hard {
+			t.Errorf("moderationFlags(%s, %v) = %v, %v, want %v, %v", tt.intensity, tt.result.Scores, flagged, hard, tt.flagged, tt.hard)
+		}
+	}
+}
@@ -0,0 +1,181 @@
+package telegram
+
+import (
+	"context"
+	"database/sql"
+	"gulabodev/database/postgres"
+	"gulabodev/modelapi"
+	"strings"
+	"time"
+	"unicode"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+)
+
+const (
+	moodPlayful    = "playful"
+	moodAnnoyed    = "annoyed"
+	moodMissingYou = "missing_you"
+	moodSleepy     = "sleepy"
+
+	// Away this long, and she missed them
+	moodMissingAfter = 24 * time.Hour
+	// Annoyance wears off if the user stays away this long
+	moodAnnoyedFor = 2 * time.Hour
+	// Late night in the user's timezone, from the first hour up to the second
+	sleepyFromHour  = 1
+	sleepyUntilHour = 6
+)
+
+type moodStyle struct {
+	// Prompt is added to the chat system prompt
+	Prompt string
+	// Speech is added to the TTS style instruction
+	Speech string
+	// Emotion is the modelapi emotion her voice takes on
+	Emotion string
+}
+
+var moodStyles = map[string]moodStyle{
+	moodPlayful: {
+		Prompt:  "\nRight now you're in a playful, teasing mood.",
+		Speech:  "Right now, sound playful and teasing, with a smile in your voice.",
+		Emotion: modelapi.EmotionSeductive,
+	},
+	moodAnnoyed: {
+		Prompt:  "\nRight now you're a little annoyed with your lover because of how they've been talking to you. Be sulky and short with them and make them win you back, but never be cruel.",
+		Speech:  "Right now, sound a little annoyed and sulky: clipped and cool, with a pout.",
+		Emotion: modelapi.EmotionAnnoyed,
+	},
+	moodMissingYou: {
+		Prompt:  "\nYour lover hasn't talked to you in over a day and you missed them. Tell them you missed them, with a playful complaint about being ignored.",
+		Speech:  "Right now, sound like you really missed them: warm, a little breathless, relieved they're back.",
+		Emotion: modelapi.EmotionBreathless,
+	},
+	moodSleepy: {
+		Prompt:  "\nIt's late at night for your lover and you're sleepy. Be soft, drowsy and cozy, and keep it short.",
+		Speech:  "Right now, sound sleepy: soft, slow and drowsy, almost whispering.",
+		Emotion: modelapi.EmotionSleepy,
+	},
+}
+
+type sentiment int
+
+const (
+	sentimentNeutral sentiment = iota
+	sentimentPositive
+	sentimentNegative
+)
+
+// Lowercase words and phrases, in English and romanized Hindi and Punjabi.
+var negativePhrases = []string{
+	"shut up", "stupid", "idiot", "dumb", "useless", "boring", "annoying", "hate you", "get lost",
+	"bakwas", "chup", "bekaar", "bekar", "ullu", "nikal",
+}
+
+var positivePhrases = []string{
+	"love", "love you", "miss you", "missed you", "cute", "beautiful", "sweet", "sorry", "thank you", "thanks",
+	"pyaar", "pyar", "jaan", "jaanu", "maaf", "shukriya", "pyari", "sohni",
+}
+
+var positiveEmoji = []string{"❤", "😘", "😍", "🥰", "💕", "💖", "😊"}
+
+// containsPhrase reports whether text has any of the lowercase phrases as
+// whole words, ignoring case and punctuation.
+func containsPhrase(text string, phrases []string) bool {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	normalized := " " + strings.Join(words, " ") + " "
+	for _, phrase := range phrases {
+		if strings.Contains(normalized, " "+phrase+" ") {
+			return true
+		}
+	}
+	return false
+}
+
+// messageSentiment is a rough read of how the user is talking to her.
+// Rudeness wins over affection in the same message.
+func messageSentiment(text string) sentiment {
+	if containsPhrase(text, negativePhrases) {
+		return sentimentNegative
+	}
+	if containsPhrase(text, positivePhrases) {
+		return sentimentPositive
+	}
+	for _, emoji := range positiveEmoji {
+		if strings.Contains(text, emoji) {
+			return sentimentPositive
+		}
+	}
+	return sentimentNeutral
+}
+
+// nextMood is her mood after a message, given her mood before it, when the
+// user last wrote (zero if never), and the time in the user's timezone.
+func nextMood(current string, lastSeen time.Time, now time.Time, feeling sentiment) string {
+	away := time.Duration(0)
+	if !lastSeen.IsZero() {
+		away = now.Sub(lastSeen)
+	}
+
+	switch {
+	case feeling == sentimentNegative:
+		return moodAnnoyed
+	case current == moodAnnoyed && feeling != sentimentPositive && away < moodAnnoyedFor:
+		// Stays sulky until they're sweet to her or give it time
+		return moodAnnoyed
+	case away >= moodMissingAfter:
+		return moodMissingYou
+	case now.Hour() >= sleepyFromHour && now.Hour() < sleepyUntilHour:
+		return moodSleepy
+	default:
+		return moodPlayful
+	}
+}
+
+// currentMood returns her mood with the user, playful if it was never set.
+func (t *Telegram) currentMood(ctx context.Context, userID int64) string {
+	mood, err := t.db.GetUserMoodByTelegramUserId(ctx, userID)
+	if err != nil {
+		if err != sql.ErrNoRows {
+			t.logger.Logger(ctx).Error("Failed to get mood", zap.Error(err), zap.Int64("user_id", userID))
+		}
+		return moodPlayful
+	}
+	return mood.Mood
+}
+
+// updateMood moves her mood on after a message from the user.
+func (t *Telegram) updateMood(ctx context.Context, userID int64, userInput string) {
+	tracer := otel.Tracer("telegram/updateMood")
+	ctx, span := tracer.Start(ctx, "updateMood")
+	defer span.End()
+
+	current, lastSeen := moodPlayful, time.Time{}
+	previous, err := t.db.GetUserMoodByTelegramUserId(ctx, userID)
+	if err == nil {
+		current, lastSeen = previous.Mood, previous.Updated
+	} else if err != sql.ErrNoRows {
+		t.logger.Logger(ctx).Error("Failed to get mood", zap.Error(err), zap.Int64("user_id", userID))
+	}
+
+	now := time.Now().In(t.userLocation(ctx, userID))
+	mood := nextMood(current, lastSeen, now, messageSentiment(userInput))
+	span.SetAttributes(
+		attribute.String("mood.previous", current),
+		attribute.String("mood", mood),
+	)
+
+	err = t.db.SetUserMoodByTelegramUserId(ctx, postgres.SetUserMoodByTelegramUserIdParams{
+		TelegramUserID: userID,
+		Mood:           mood,
+	})
+	if err != nil {
+		span.RecordError(err)
+		t.logger.Logger(ctx).Error("Failed to save mood", zap.Error(err), zap.Int64("user_id", userID))
+	}
+}
@@ -0,0 +1,65 @@
+package telegram
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMessageSentiment(t *testing.T) {
+	tests := []struct {
+		text string
+		want sentiment
+	}{
+		{"Kya kar rahi ho?", sentimentNeutral},
+		{"I love you baby", sentimentPositive},
+		{"Sorry jaan, kaam mein busy tha", sentimentPositive},
+		{"good night 😘", sentimentPositive},
+		{"Shut up, you're so boring", sentimentNegative},
+		{"Bakwas mat karo", sentimentNegative},
+		{"love you but shut up", sentimentNegative},
+		// Whole words only
+		{"Glovebox", sentimentNeutral},
+	}
+	for _, tt := range tests {
+		if got := messageSentiment(tt.text); got != tt.want {
+			t.Errorf("messageSentiment(%q) = %d, want %d", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestNextMood(t *testing.T) {
+	afternoon := time.Date(2024, 5, 10, 15, 0, 0, 0, time.UTC)
+	lateNight := time.Date(2024, 5, 10, 2, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		current  string
+		lastSeen time.Time
+		now      time.Time
+		feeling  sentiment
+		want     string
+	}{
+		{"first message", moodPlayful, time.Time{}, afternoon, sentimentNeutral, moodPlayful},
+		{"rude", moodPlayful, afternoon.Add(-time.Minute), afternoon, sentimentNegative, moodAnnoyed},
+		{"still sulking", moodAnnoyed, afternoon.Add(-time.Minute), afternoon, sentimentNeutral, moodAnnoyed},
+		{"won back", moodAnnoyed, afternoon.Add(-time.Minute), afternoon, sentimentPositive, moodPlayful},
+		{"annoyance wears off", moodAnnoyed, afternoon.Add(-3 * time.Hour), afternoon, sentimentNeutral, moodPlayful},
+		{"back after two days", moodPlayful, afternoon.Add(-48 * time.Hour), afternoon, sentimentNeutral, moodMissingYou},
+		{"missing you passes", moodMissingYou, afternoon.Add(-time.Minute), afternoon, sentimentNeutral, moodPlayful},
+		{"late night", moodPlayful, lateNight.Add(-time.Minute), lateNight, sentimentPositive, moodSleepy},
+	}
+	for _, tt := range tests {
+		if got := nextMood(tt.current, tt.lastSeen, tt.now, tt.feeling); got != tt.want {
+			t.Errorf("%s: nextMood = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestMoodStylesComplete(t *testing.T) {
+	for _, mood := range []string{moodPlayful, moodAnnoyed, moodMissingYou, moodSleepy} {
+		style, ok := moodStyles[mood]
+		if !ok || style.Prompt == "" || style.Speech == "" || style.Emotion == "" {
+			t.Errorf("mood %q is missing its prompt, speech style or emotion", mood)
+		}
+	}
+}
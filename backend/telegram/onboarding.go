@@ -0,0 +1,269 @@
+package telegram
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"gulabodev/database/postgres"
+	"gulabodev/modelapi"
+	"gulabodev/modelapi/groqapi"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.opentelemetry.io/otel"
+	"go.uber.org/zap"
+)
+
+const (
+	onboardingCallbackPrefix = "onboarding:"
+	onboardingStepLanguage   = "language"
+	onboardingStepVibe       = "vibe"
+
+	onboardingIntro        = "Hey baby, I'm Gulabo 🌹 Itni der laga di aane mein? Chalo, pehle ek dusre ko thoda jaan lete hain..."
+	onboardingNamePrompt   = "Pehle yeh batao... tumhe kya bulaun? 🥰"
+	onboardingLanguageText = "Aur kis bhasha mein baat karein, jaan? 💬"
+	onboardingVibeText     = "Last sawaal... mujhse kaisi baatein chahiye? 😏"
+
+	maxPreferredNameLength = 32
+
+	// Stands in for a user message so the first greeting can be generated
+	onboardingGreetingInput = "(I just finished signing up. Greet me for the very first time, use my name, and invite me to talk.)"
+)
+
+type vibe struct {
+	// ID is what gets stored in user_preferences.vibe
+	ID          string
+	Name        string
+	Emoji       string
+	Instruction string
+}
+
+var vibes = []vibe{
+	{ID: "romantic", Name: "Romantic", Emoji: "💕", Instruction: "Be deeply romantic, affectionate and sweet."},
+	{ID: "flirty", Name: "Flirty", Emoji: "😏", Instruction: "Be playful and teasing, flirting all the time."},
+	{ID: "naughty", Name: "Naughty", Emoji: "😈", Instruction: "Be bold, seductive and naughty."},
+	{ID: "caring", Name: "Caring", Emoji: "🤗", Instruction: "Be warm and supportive, and listen more than you talk."},
+}
+
+// findVibe returns the vibe with the given ID.
+func findVibe(id string) (vibe, bool) {
+	for _, v := range vibes {
+		if v.ID == id {
+			return v, true
+		}
+	}
+	return vibe{}, false
+}
+
+// cleanPreferredName validates a name typed during onboarding.
+func cleanPreferredName(input string) (string, bool) {
+	name := strings.Join(strings.Fields(input), " ")
+	if name == "" || strings.HasPrefix(name, "/") || utf8.RuneCountInString(name) > maxPreferredNameLength {
+		return "", false
+	}
+	return name, true
+}
+
+// profilePrompt tells the model what the user shared during onboarding.
+func profilePrompt(preferences postgres.UserPreference) string {
+	var b strings.Builder
+	if preferences.PreferredName.Valid {
+		fmt.Fprintf(&b, "\nYour lover's name is %s.", preferences.PreferredName.String)
+	}
+	if v, ok := findVibe(preferences.Vibe); ok {
+		b.WriteString("\n" + v.Instruction)
+	}
+	return b.String()
+}
+
+func (t *Telegram) userProfilePrompt(ctx context.Context, userID int64) string {
+	preferences, err := t.db.GetUserPreferencesByTelegramUserId(ctx, userID)
+	if err != nil {
+		if err != sql.ErrNoRows {
+			t.logger.Logger(ctx).Error("Failed to get user preferences", zap.Error(err), zap.Int64("user_id", userID))
+		}
+		return ""
+	}
+	return profilePrompt(preferences)
+}
+
+func (t *Telegram) isOnboarded(ctx context.Context, userID int64) bool {
+	preferences, err := t.db.GetUserPreferencesByTelegramUserId(ctx, userID)
+	if err != nil {
+		if err != sql.ErrNoRows {
+			t.logger.Logger(ctx).Error("Failed to get user preferences", zap.Error(err), zap.Int64("user_id", userID))
+		}
+		return false
+	}
+	return preferences.OnboardedAt.Valid
+}
+
+// startOnboarding asks for the user's name; language and vibe follow as
+// inline menus.
+func (t *Telegram) startOnboarding(ctx context.Context, chatID int64) {
+	if _, err := t.bot.Send(tgbotapi.NewMessage(chatID, onboardingIntro)); err != nil {
+		t.logger.Logger(ctx).Error("Failed to send onboarding intro", zap.Error(err))
+	}
+	t.sendOnboardingNamePrompt(ctx, chatID)
+}
+
+func (t *Telegram) sendOnboardingNamePrompt(ctx context.Context, chatID int64) {
+	msg := tgbotapi.NewMessage(chatID, onboardingNamePrompt)
+	msg.ReplyMarkup = tgbotapi.ForceReply{ForceReply: true, InputFieldPlaceholder: "Your name..."}
+	if _, err := t.bot.Send(msg); err != nil {
+		t.logger.Logger(ctx).Error("Failed to send onboarding name prompt", zap.Error(err))
+	}
+}
+
+// isOnboardingNameReply reports whether the message answers the name prompt.
+func (t *Telegram) isOnboardingNameReply(message *tgbotapi.Message) bool {
+	reply := message.ReplyToMessage
+	return reply != nil && reply.From != nil && reply.From.ID == t.bot.Self.ID && reply.Text == onboardingNamePrompt
+}
+
+func (t *Telegram) saveOnboardingName(ctx context.Context, message *tgbotapi.Message) {
+	name, ok := cleanPreferredName(message.Text)
+	if !ok {
+		t.sendOnboardingNamePrompt(ctx, message.Chat.ID)
+		return
+	}
+
+	_, err := t.db.SetPreferredNameByTelegramUserId(ctx, postgres.SetPreferredNameByTelegramUserIdParams{
+		PreferredName:  sql.NullString{Valid: true, String: name},
+		TelegramUserID: message.From.ID,
+	})
+	if err != nil {
+		t.logger.Logger(ctx).Error("Failed to save preferred name", zap.Error(err), zap.Int64("user_id", message.From.ID))
+		msg := tgbotapi.NewMessage(message.Chat.ID, t.text(ctx, message.From.ID, msgSomethingWrong))
+		t.bot.Send(msg)
+		return
+	}
+
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for _, language := range replyLanguages {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(language.Name, onboardingCallbackPrefix+onboardingStepLanguage+":"+language.ID),
+		))
+	}
+
+	msg := tgbotapi.NewMessage(message.Chat.ID, fmt.Sprintf("%s... kitna pyaara naam hai 😍\n\n%s", name, onboardingLanguageText))
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
+	if _, err := t.bot.Send(msg); err != nil {
+		t.logger.Logger(ctx).Error("Failed to send onboarding language options", zap.Error(err))
+	}
+}
+
+// handleOnboardingCallback records a language or vibe choice and moves the
+// onboarding message on to the next step.
+func (t *Telegram) handleOnboardingCallback(ctx context.Context, message *tgbotapi.Message, userID int64, step string, value string) {
+	switch step {
+	case onboardingStepLanguage:
+		_, err := t.db.SetReplyLanguageByTelegramUserId(ctx, postgres.SetReplyLanguageByTelegramUserIdParams{
+			ReplyLanguage:  findLanguage(value).ID,
+			TelegramUserID: userID,
+		})
+		if err != nil {
+			t.logger.Logger(ctx).Error("Failed to set reply language", zap.Error(err), zap.Int64("user_id", userID))
+			return
+		}
+
+		var rows [][]tgbotapi.InlineKeyboardButton
+		for _, v := range vibes {
+			rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData(v.Emoji+" "+v.Name, onboardingCallbackPrefix+onboardingStepVibe+":"+v.ID),
+			))
+		}
+		edit := tgbotapi.NewEditMessageTextAndMarkup(message.Chat.ID, message.MessageID, onboardingVibeText, tgbotapi.NewInlineKeyboardMarkup(rows...))
+		if _, err := t.bot.Send(edit); err != nil {
+			t.logger.Logger(ctx).Error("Failed to send onboarding vibe options", zap.Error(err))
+		}
+	case onboardingStepVibe:
+		v, ok := findVibe(value)
+		if !ok {
+			return
+		}
+		_, err := t.db.SetVibeByTelegramUserId(ctx, postgres.SetVibeByTelegramUserIdParams{
+			Vibe:           v.ID,
+			TelegramUserID: userID,
+		})
+		if err == nil {
+			_, err = t.db.CompleteOnboardingByTelegramUserId(ctx, userID)
+		}
+		if err != nil {
+			t.logger.Logger(ctx).Error("Failed to complete onboarding", zap.Error(err), zap.Int64("user_id", userID))
+			return
+		}
+
+		edit := tgbotapi.NewEditMessageText(message.Chat.ID, message.MessageID, fmt.Sprintf("%s %s, done! 😘", v.Emoji, v.Name))
+		if _, err := t.bot.Send(edit); err != nil {
+			t.logger.Logger(ctx).Error("Failed to update onboarding message", zap.Error(err))
+		}
+		t.sendOnboardingGreeting(ctx, message.Chat.ID, userID)
+	}
+}
+
+// sendOnboardingGreeting sends Gulabo's first message, written with what the
+// user just shared, and keeps it in the conversation history.
+func (t *Telegram) sendOnboardingGreeting(ctx context.Context, chatID int64, userID int64) {
+	t.sendFirstGreeting(ctx, chatID, userID, onboardingGreetingInput, "Ab toh hum officially saath hain 😘 Bolo na, kya chal raha hai aaj kal?")
+}
+
+// sendFirstGreeting opens the active conversation with a message generated
+// from input, which stands in for a user message, or fallback if that fails.
+func (t *Telegram) sendFirstGreeting(ctx context.Context, chatID int64, userID int64, input string, fallback string) {
+	tracer := otel.Tracer("telegram/sendFirstGreeting")
+	ctx, span := tracer.Start(ctx, "sendFirstGreeting")
+	defer span.End()
+
+	conversation, err := t.activeConversation(ctx, userID)
+	if err != nil {
+		span.RecordError(err)
+		t.logger.Logger(ctx).Error("Failed to get conversation", zap.Error(err), zap.Int64("user_id", userID))
+		return
+	}
+
+	systemPrompt := t.conversationPersona(ctx, conversation).systemPrompt(t.userLanguage(ctx, userID)) + t.intensityPrompt(ctx, userID) + t.userProfilePrompt(ctx, userID)
+	greeting, err := t.chatProvider(ctx, chatFeatureGreeting, conversation).GetResponse(ctx, modelapi.ChatRequest{
+		SystemPrompt: systemPrompt,
+		Message:      input,
+	})
+	greeting = strings.Trim(greeting, `\ '"“”`)
+	if err != nil || greeting == "" {
+		span.RecordError(err)
+		t.logger.Logger(ctx).Error("Failed to generate first greeting", zap.Error(err), zap.Int64("user_id", userID))
+		greeting = fallback
+	}
+
+	// Only the fixed greeting has a voice note ready
+	if greeting != fallback || !t.sendCannedVoice(ctx, chatID, userID, greeting, nil) {
+		if _, err := t.bot.Send(tgbotapi.NewMessage(chatID, greeting)); err != nil {
+			t.logger.Logger(ctx).Error("Failed to send first greeting", zap.Error(err))
+			return
+		}
+	}
+
+	messages, err := json.Marshal([]storedMessage{
+		newStoredMessage(groqapi.ASSISTANT, greeting, time.Now()),
+	})
+	if err == nil {
+		err = t.db.AppendConversationMessages(ctx, postgres.AppendConversationMessagesParams{
+			Messages: messages,
+			ID:       conversation.ID,
+		})
+	}
+	if err != nil {
+		t.logger.Logger(ctx).Error("Failed to append first greeting to conversation", zap.Error(err), zap.Int64("user_id", userID))
+	}
+}
+
+// onboardingFromCallback splits "onboarding:<step>:<value>" callback data.
+func onboardingFromCallback(data string) (step string, value string, ok bool) {
+	rest, found := strings.CutPrefix(data, onboardingCallbackPrefix)
+	if !found {
+		return "", "", false
+	}
+	return strings.Cut(rest, ":")
+}
@@ -0,0 +1,53 @@
+package telegram
+
+import (
+	"database/sql"
+	"gulabodev/database/postgres"
+	"strings"
+	"testing"
+)
+
+func TestCleanPreferredName(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+		ok    bool
+	}{
+		{"Rahul", "Rahul", true},
+		{"  Rahul   Sharma ", "Rahul Sharma", true},
+		{"", "", false},
+		{"/start", "", false},
+		{strings.Repeat("a", maxPreferredNameLength+1), "", false},
+	}
+	for _, tt := range tests {
+		got, ok := cleanPreferredName(tt.input)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("cleanPreferredName(%q) = (%q, %v), want (%q, %v)", tt.input, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestProfilePrompt(t *testing.T) {
+	if got := profilePrompt(postgres.UserPreference{}); got != "" {
+		t.Errorf("profilePrompt of empty preferences = %q, want empty", got)
+	}
+
+	got := profilePrompt(postgres.UserPreference{
+		PreferredName: sql.NullString{Valid: true, String: "Rahul"},
+		Vibe:          "caring",
+	})
+	caring, _ := findVibe("caring")
+	if !strings.Contains(got, "Rahul") || !strings.Contains(got, caring.Instruction) {
+		t.Errorf("profilePrompt = %q, want name and vibe", got)
+	}
+}
+
+func TestOnboardingFromCallback(t *testing.T) {
+	step, value, ok := onboardingFromCallback("onboarding:vibe:flirty")
+	if !ok || step != onboardingStepVibe || value != "flirty" {
+		t.Errorf("got (%q, %q, %v)", step, value, ok)
+	}
+	if _, _, ok := onboardingFromCallback("language:hindi"); ok {
+		t.Error("non-onboarding data should not match")
+	}
+}
@@ -0,0 +1,46 @@
+package telegram
+
+import (
+	"context"
+	"gulabodev/audio"
+	"gulabodev/modelapi"
+	"math"
+
+	"go.uber.org/zap"
+)
+
+// Every voice note is normalized to this loudness in LUFS, so switching TTS
+// providers doesn't change how loud she is. -16 is the usual target for
+// speech played on phones.
+const voiceNoteLoudness = -16
+
+// encodeVoiceNote turns synthesized speech into a loudness-normalized
+// OGG/Opus voice note, the format Telegram draws a waveform for, applying
+// whatever of the user's pace and pitch the provider couldn't. If it can't be
+// encoded, the speech is sent as it is, which still plays, only without a
+// waveform.
+func (t *Telegram) encodeVoiceNote(ctx context.Context, speech modelapi.Speech, rate speechPreset, pitch speechPreset) voiceNote {
+	options := audio.Options{
+		SampleRate: 48000,
+		Channels:   1,
+		Loudness:   voiceNoteLoudness,
+	}
+	if !speech.RateApplied {
+		options.Tempo = rate.Value
+	}
+	if !speech.PitchApplied {
+		options.Pitch = pitch.Value
+	}
+
+	ogg, err := audio.Convert(ctx, speech.Audio, audio.OGG, options)
+	if err != nil {
+		t.logger.Logger(ctx).Warn("Failed to encode voice note, sending it unencoded", zap.Error(err), zap.String("file_name", speech.FileName))
+		return voiceNote{audio: speech.Audio, fileName: speech.FileName}
+	}
+
+	note := voiceNote{audio: ogg, fileName: audio.FileName(audio.OGG)}
+	if duration, err := audio.Duration(ctx, ogg); err == nil {
+		note.duration = int(math.Ceil(duration.Seconds()))
+	}
+	return note
+}
@@ -0,0 +1,291 @@
+package telegram
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"gulabodev/database/postgres"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+)
+
+// Telegram's paid media only supports photos and videos; voice notes can't be
+// locked, so premium audio has to be uploaded as a video.
+const (
+	premiumMediaPhoto = "photo"
+	premiumMediaVideo = "video"
+
+	maxPaidMediaStars      = 10000
+	paidMediaPayloadPrefix = "premium:"
+
+	paidMediaSyncInterval    = 15 * time.Minute
+	starTransactionsPageSize = 100
+)
+
+type inputPaidMedia struct {
+	Type  string `json:"type"`
+	Media string `json:"media"`
+}
+
+// starTransaction is the subset of Telegram's StarTransaction needed to
+// attribute paid media unlocks; tgbotapi v5 predates the Stars API.
+type starTransaction struct {
+	ID     string `json:"id"`
+	Amount int    `json:"amount"`
+	Date   int64  `json:"date"`
+	Source *struct {
+		Type string `json:"type"`
+		User *struct {
+			ID int64 `json:"id"`
+		} `json:"user"`
+		PaidMediaPayload string `json:"paid_media_payload"`
+	} `json:"source"`
+}
+
+type starTransactions struct {
+	Transactions []starTransaction `json:"transactions"`
+}
+
+func paidMediaPayload(mediaID int64) string {
+	return paidMediaPayloadPrefix + strconv.FormatInt(mediaID, 10)
+}
+
+func premiumMediaIDFromPayload(payload string) (int64, bool) {
+	raw, ok := strings.CutPrefix(payload, paidMediaPayloadPrefix)
+	if !ok {
+		return 0, false
+	}
+	id, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return id, true
+}
+
+// premiumMediaFromMessage returns the kind and file ID of a photo or video
+// an admin wants to sell as paid media.
+func premiumMediaFromMessage(message *tgbotapi.Message) (string, string, bool) {
+	switch {
+	case len(message.Photo) > 0:
+		// Sizes are ordered smallest first
+		return premiumMediaPhoto, message.Photo[len(message.Photo)-1].FileID, true
+	case message.Video != nil:
+		return premiumMediaVideo, message.Video.FileID, true
+	}
+	return "", "", false
+}
+
+// parsePremiumArgs parses "/addpremium <stars> [caption]".
+func parsePremiumArgs(args string) (int, string, bool) {
+	fields := strings.Fields(args)
+	if len(fields) == 0 {
+		return 0, "", false
+	}
+	stars, err := strconv.Atoi(fields[0])
+	if err != nil || stars < 1 || stars > maxPaidMediaStars {
+		return 0, "", false
+	}
+	caption := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(args), fields[0]))
+	return stars, caption, true
+}
+
+// handleAddPremiumCommand lets an admin add the photo or video they're
+// replying to as locked premium content.
+func (t *Telegram) handleAddPremiumCommand(ctx context.Context, message *tgbotapi.Message) {
+	tracer := otel.Tracer("telegram/handleAddPremiumCommand")
+	ctx, span := tracer.Start(ctx, "handleAddPremiumCommand")
+	defer span.End()
+
+	usage := fmt.Sprintf("Usage: reply to a photo or video with /addpremium <stars 1-%d> [caption]", maxPaidMediaStars)
+
+	stars, caption, ok := parsePremiumArgs(message.CommandArguments())
+	if !ok || message.ReplyToMessage == nil {
+		t.bot.Send(tgbotapi.NewMessage(message.Chat.ID, usage))
+		return
+	}
+	kind, fileID, ok := premiumMediaFromMessage(message.ReplyToMessage)
+	if !ok {
+		t.bot.Send(tgbotapi.NewMessage(message.Chat.ID, usage))
+		return
+	}
+
+	media, err := t.db.CreatePremiumMedia(ctx, postgres.CreatePremiumMediaParams{
+		Kind:                kind,
+		FileID:              fileID,
+		Caption:             caption,
+		PriceStars:          int32(stars),
+		AdminTelegramUserID: message.From.ID,
+	})
+	if err != nil {
+		span.RecordError(err)
+		t.logger.Logger(ctx).Error("Failed to create premium media", zap.Error(err))
+		t.bot.Send(tgbotapi.NewMessage(message.Chat.ID, "Failed to save premium media."))
+		return
+	}
+
+	text := fmt.Sprintf("Premium %s #%d added for %d Stars.", media.Kind, media.ID, media.PriceStars)
+	if _, err := t.bot.Send(tgbotapi.NewMessage(message.Chat.ID, text)); err != nil {
+		t.logger.Logger(ctx).Error("Failed to confirm premium media", zap.Error(err))
+	}
+}
+
+// handlePremiumCommand sends the newest premium item the user hasn't unlocked
+// yet. Unlocking is paid in Stars directly and never touches credits.
+func (t *Telegram) handlePremiumCommand(ctx context.Context, message *tgbotapi.Message) {
+	tracer := otel.Tracer("telegram/handlePremiumCommand")
+	ctx, span := tracer.Start(ctx, "handlePremiumCommand")
+	defer span.End()
+
+	userID := message.From.ID
+	media, err := t.db.GetUnpurchasedPremiumMediaByTelegramUserId(ctx, userID)
+	if err == sql.ErrNoRows {
+		t.bot.Send(tgbotapi.NewMessage(message.Chat.ID, t.text(ctx, userID, msgPremiumEmpty)))
+		return
+	}
+	if err != nil {
+		span.RecordError(err)
+		t.logger.Logger(ctx).Error("Failed to get premium media", zap.Error(err), zap.Int64("user_id", userID))
+		t.bot.Send(tgbotapi.NewMessage(message.Chat.ID, t.text(ctx, userID, msgSomethingWrong)))
+		return
+	}
+
+	if err := t.sendPaidMedia(ctx, message.Chat.ID, media); err != nil {
+		span.RecordError(err)
+		t.logger.Logger(ctx).Error("Failed to send paid media", zap.Error(err), zap.Int64("premium_media_id", media.ID))
+		t.bot.Send(tgbotapi.NewMessage(message.Chat.ID, t.text(ctx, userID, msgSomethingWrong)))
+	}
+}
+
+// sendPaidMedia sends media locked behind its Stars price. sendPaidMedia
+// isn't wrapped by tgbotapi v5, so it's called directly.
+func (t *Telegram) sendPaidMedia(ctx context.Context, chatID int64, media postgres.PremiumMedium) error {
+	items, err := json.Marshal([]inputPaidMedia{{Type: media.Kind, Media: media.FileID}})
+	if err != nil {
+		return err
+	}
+
+	params := tgbotapi.Params{}
+	params.AddNonZero64("chat_id", chatID)
+	params.AddNonZero("star_count", int(media.PriceStars))
+	params["media"] = string(items)
+	params["payload"] = paidMediaPayload(media.ID)
+	params.AddNonEmpty("caption", media.Caption)
+
+	_, err = t.bot.MakeRequest("sendPaidMedia", params)
+	return err
+}
+
+// RunPaidMediaSync periodically records paid media unlocks from the bot's
+// Star transactions. tgbotapi v5 drops purchased_paid_media updates, so the
+// transaction history is the source of truth for this revenue.
+func (t *Telegram) RunPaidMediaSync(ctx context.Context) {
+	t.logger.Logger(ctx).Info("Starting paid media sync", zap.Duration("interval", paidMediaSyncInterval))
+
+	ticker := time.NewTicker(paidMediaSyncInterval)
+	defer ticker.Stop()
+
+	// Transactions are listed oldest first. The offset starts over on every
+	// restart; purchases are keyed by transaction ID so rescans are harmless.
+	offset := 0
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			offset = t.syncPaidMediaPurchases(ctx, offset)
+		}
+	}
+}
+
+func (t *Telegram) syncPaidMediaPurchases(ctx context.Context, offset int) int {
+	tracer := otel.Tracer("telegram/syncPaidMediaPurchases")
+	ctx, span := tracer.Start(ctx, "syncPaidMediaPurchases")
+	defer span.End()
+
+	recorded := 0
+	for {
+		page, err := t.getStarTransactions(offset)
+		if err != nil {
+			span.RecordError(err)
+			t.logger.Logger(ctx).Error("Failed to get Star transactions", zap.Error(err), zap.Int("offset", offset))
+			break
+		}
+
+		for _, transaction := range page {
+			if t.recordPaidMediaPurchase(ctx, transaction) {
+				recorded++
+			}
+		}
+		offset += len(page)
+
+		if len(page) < starTransactionsPageSize {
+			break
+		}
+	}
+
+	span.SetAttributes(attribute.Int("recorded", recorded), attribute.Int("offset", offset))
+	return offset
+}
+
+func (t *Telegram) getStarTransactions(offset int) ([]starTransaction, error) {
+	params := tgbotapi.Params{}
+	params.AddNonZero("offset", offset)
+	params.AddNonZero("limit", starTransactionsPageSize)
+
+	resp, err := t.bot.MakeRequest("getStarTransactions", params)
+	if err != nil {
+		return nil, err
+	}
+
+	var result starTransactions
+	if err := json.Unmarshal(resp.Result, &result); err != nil {
+		return nil, err
+	}
+	return result.Transactions, nil
+}
+
+// recordPaidMediaPurchase stores a transaction if it's a paid media unlock,
+// reporting whether it was new.
+func (t *Telegram) recordPaidMediaPurchase(ctx context.Context, transaction starTransaction) bool {
+	source := transaction.Source
+	if source == nil || source.Type != "user" || source.User == nil {
+		return false
+	}
+	mediaID, ok := premiumMediaIDFromPayload(source.PaidMediaPayload)
+	if !ok {
+		return false
+	}
+
+	rows, err := t.db.CreatePaidMediaPurchase(ctx, postgres.CreatePaidMediaPurchaseParams{
+		TransactionID:  transaction.ID,
+		TelegramUserID: source.User.ID,
+		PremiumMediaID: sql.NullInt64{Valid: true, Int64: mediaID},
+		Stars:          int32(transaction.Amount),
+		Purchased:      time.Unix(transaction.Date, 0).UTC(),
+	})
+	if err != nil {
+		t.logger.Logger(ctx).Error("Failed to record paid media purchase",
+			zap.Error(err),
+			zap.String("transaction_id", transaction.ID),
+			zap.Int64("user_id", source.User.ID),
+		)
+		return false
+	}
+	if rows > 0 {
+		t.logger.Logger(ctx).Info("Paid media unlocked",
+			zap.Int64("user_id", source.User.ID),
+			zap.Int64("premium_media_id", mediaID),
+			zap.Int("stars", transaction.Amount),
+		)
+		// Private chats share the user's ID
+		t.addAffection(ctx, source.User.ID, source.User.ID, affectionPerUnlock)
+	}
+	return rows > 0
+}
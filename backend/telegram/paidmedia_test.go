@@ -0,0 +1,58 @@
+package telegram
+
+import (
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+func TestParsePremiumArgs(t *testing.T) {
+	tests := []struct {
+		args    string
+		stars   int
+		caption string
+		ok      bool
+	}{
+		{"50", 50, "", true},
+		{"  75   sirf tumhare liye 🙈 ", 75, "sirf tumhare liye 🙈", true},
+		{"", 0, "", false},
+		{"free", 0, "", false},
+		{"0", 0, "", false},
+		{"10001", 0, "", false},
+	}
+	for _, tt := range tests {
+		stars, caption, ok := parsePremiumArgs(tt.args)
+		if stars != tt.stars || caption != tt.caption || ok != tt.ok {
+			t.Errorf("parsePremiumArgs(%q) = %d, %q, %v; want %d, %q, %v", tt.args, stars, caption, ok, tt.stars, tt.caption, tt.ok)
+		}
+	}
+}
+
+func TestPaidMediaPayloadRoundTrip(t *testing.T) {
+	id, ok := premiumMediaIDFromPayload(paidMediaPayload(42))
+	if !ok || id != 42 {
+		t.Fatalf("got %d, %v; want 42, true", id, ok)
+	}
+	for _, payload := range []string{"", "premium:", "premium:abc", rechargePayload50c} {
+		if _, ok := premiumMediaIDFromPayload(payload); ok {
+			t.Errorf("expected %q to be rejected", payload)
+		}
+	}
+}
+
+func TestPremiumMediaFromMessage(t *testing.T) {
+	photo := &tgbotapi.Message{Photo: []tgbotapi.PhotoSize{{FileID: "small"}, {FileID: "large"}}}
+	if kind, fileID, ok := premiumMediaFromMessage(photo); !ok || kind != premiumMediaPhoto || fileID != "large" {
+		t.Errorf("photo: got %q, %q, %v", kind, fileID, ok)
+	}
+
+	video := &tgbotapi.Message{Video: &tgbotapi.Video{FileID: "clip"}}
+	if kind, fileID, ok := premiumMediaFromMessage(video); !ok || kind != premiumMediaVideo || fileID != "clip" {
+		t.Errorf("video: got %q, %q, %v", kind, fileID, ok)
+	}
+
+	voice := &tgbotapi.Message{Voice: &tgbotapi.Voice{FileID: "note"}}
+	if _, _, ok := premiumMediaFromMessage(voice); ok {
+		t.Error("voice notes can't be sent as paid media")
+	}
+}
@@ -0,0 +1,246 @@
+package telegram
+
+import (
+	"context"
+	"database/sql"
+	"gulabodev/database/postgres"
+	"gulabodev/modelapi/prompts"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+const (
+	personaCallbackPrefix = "persona:"
+	// The character the user built with /create; each user has their own
+	customPersonaID = "custom"
+	personaMenuText = "Kisse baat karni hai, jaan? Har ek ke saath tumhari alag chat rahegi 💞"
+)
+
+type persona struct {
+	// ID is what gets stored in conversations.persona and user_preferences.active_persona
+	ID    string
+	Name  string
+	Emoji string
+	// PromptID is the prompt describing the character; the reply language's
+	// instruction is appended to it. Empty means the language's own system
+	// prompt is used as is.
+	PromptID string
+	// PromptData fills in the prompt's variables
+	PromptData any
+	// Prompt, when set, is the character's prompt text and PromptID is unused
+	Prompt string
+	// Language, when set, replaces the reply language's instruction
+	Language string
+	// DefaultVoice is used until the user picks a voice in this persona's chat
+	DefaultVoice string
+	Greeting     string
+	// Appearance and SelfieSeed fix how the character looks in every selfie
+	Appearance string
+	SelfieSeed int64
+	// Temperature is the sampling temperature for replies; 0 leaves the provider's default
+	Temperature float32
+	// TopP and MaxTokens are the rest of how replies are sampled; 0 leaves
+	// the provider's defaults
+	TopP      float32
+	MaxTokens int
+	// SpeechTemperature is the sampling temperature for voices that sample
+	// their speech, like Gemini's; 0 leaves the provider's default
+	SpeechTemperature float32
+	// Model is the chat provider that writes replies, like "gemini"; empty
+	// leaves it to LLM_ROUTES
+	Model string
+	// VoiceID, when set, replaces DefaultVoice's own provider voice, so the
+	// persona can speak in any voice from that provider's catalog, like one
+	// listed by /cartesiavoices
+	VoiceID string
+}
+
+// personas lists the built-in characters offered by /persona. The first entry
+// is the default. PERSONAS_FILE can change them or add more; see
+// currentPersonas.
+var personas = []persona{
+	{
+		ID:           "gulabo",
+		Name:         "Gulabo",
+		Emoji:        "🌹",
+		DefaultVoice: "openai_sage",
+		Greeting:     "Aa gaye wapas mere paas? 😏 Mujhe pata tha tum zyada der door nahi reh sakte, baby.",
+		Appearance:   "a 24-year-old Indian woman with long wavy black hair, warm brown eyes, a small gold nose stud, wheatish skin and a playful smirk",
+		SelfieSeed:   71204,
+	},
+	{
+		ID:           "simran",
+		Name:         "Simran",
+		Emoji:        "🌻",
+		PromptID:     prompts.PersonaSimran,
+		DefaultVoice: "gemini_kore",
+		Greeting:     "Hii... main Simran 🙈 Tumse baat karne ka kab se mann tha. Batao na, aaj ka din kaisa tha?",
+		Appearance:   "a 22-year-old Punjabi woman with long dark brown hair in a loose braid, big shy eyes, fair skin, dimples and a soft smile",
+		SelfieSeed:   53817,
+	},
+}
+
+// findPersona returns the persona with the given ID, falling back to the default.
+func findPersona(id string) persona {
+	options := currentPersonas()
+	for _, p := range options {
+		if p.ID == id {
+			return p
+		}
+	}
+	return options[0]
+}
+
+// systemPrompt combines the persona with the user's reply language.
+func (p persona) systemPrompt(language replyLanguage) string {
+	if p.Prompt == "" && p.PromptID == "" {
+		return prompts.Render(language.Prompt, nil)
+	}
+	prompt := p.Prompt
+	if prompt == "" {
+		prompt = prompts.Render(p.PromptID, p.PromptData)
+	}
+	if p.Language != "" {
+		return prompt + "\n" + p.Language
+	}
+	return prompt + "\n" + language.Instruction
+}
+
+// personaOptions lists the personas this bot offers; a bot pinned to one
+// persona offers only that one.
+func (t *Telegram) personaOptions() []persona {
+	if t.persona != "" {
+		return []persona{findPersona(t.persona)}
+	}
+	return currentPersonas()
+}
+
+// userPersonaOptions is personaOptions plus the character the user built, if
+// the bot isn't pinned.
+func (t *Telegram) userPersonaOptions(ctx context.Context, userID int64) []persona {
+	options := t.personaOptions()
+	if t.persona != "" {
+		return options
+	}
+	if custom, ok := t.customPersona(ctx, userID); ok {
+		return append(options[:len(options):len(options)], custom)
+	}
+	return options
+}
+
+// conversationPersona returns the persona a conversation is with.
+func (t *Telegram) conversationPersona(ctx context.Context, conversation postgres.Conversation) persona {
+	if conversation.Persona == customPersonaID {
+		if custom, ok := t.customPersona(ctx, conversation.TelegramUserID); ok {
+			return custom
+		}
+	}
+	return findPersona(conversation.Persona)
+}
+
+func (t *Telegram) activePersona(ctx context.Context, userID int64) persona {
+	if t.persona != "" {
+		return findPersona(t.persona)
+	}
+	preferences, err := t.db.GetUserPreferencesByTelegramUserId(ctx, userID)
+	if err != nil {
+		if err != sql.ErrNoRows {
+			t.logger.Logger(ctx).Error("Failed to get user preferences", zap.Error(err), zap.Int64("user_id", userID))
+		}
+		return currentPersonas()[0]
+	}
+	if preferences.ActivePersona == customPersonaID {
+		if custom, ok := t.customPersona(ctx, userID); ok {
+			return custom
+		}
+	}
+	return findPersona(preferences.ActivePersona)
+}
+
+// getOrCreateConversation returns the user's conversation with the given
+// persona, creating it on first contact.
+func (t *Telegram) getOrCreateConversation(ctx context.Context, userID int64, personaID string) (postgres.Conversation, error) {
+	conversation, err := t.db.GetConversationByTelegramUserId(ctx, postgres.GetConversationByTelegramUserIdParams{
+		TelegramUserID: userID,
+		Persona:        personaID,
+	})
+	if err == sql.ErrNoRows {
+		return t.db.CreateConversation(ctx, postgres.CreateConversationParams{
+			TelegramUserID: userID,
+			Persona:        personaID,
+		})
+	}
+	return conversation, err
+}
+
+// activeConversation returns the conversation with the user's active persona.
+func (t *Telegram) activeConversation(ctx context.Context, userID int64) (postgres.Conversation, error) {
+	return t.getOrCreateConversation(ctx, userID, t.activePersona(ctx, userID).ID)
+}
+
+func (t *Telegram) handlePersonaCommand(ctx context.Context, message *tgbotapi.Message) {
+	msg := tgbotapi.NewMessage(message.Chat.ID, personaMenuText)
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(personaKeyboard(t.userPersonaOptions(ctx, message.From.ID), t.activePersona(ctx, message.From.ID))...)
+	if _, err := t.bot.Send(msg); err != nil {
+		t.logger.Logger(ctx).Error("Failed to send persona options", zap.Error(err))
+	}
+}
+
+// personaKeyboard lists the personas, marking the active one.
+func personaKeyboard(options []persona, current persona) [][]tgbotapi.InlineKeyboardButton {
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for _, p := range options {
+		label := p.Emoji + " " + p.Name
+		if p.ID == current.ID {
+			label = "✅ " + label
+		}
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(label, personaCallbackPrefix+p.ID),
+		))
+	}
+	return rows
+}
+
+func (t *Telegram) setPersona(ctx context.Context, chatID int64, userID int64, personaID string) {
+	p := findPersona(personaID)
+	if t.persona != "" {
+		p = findPersona(t.persona)
+	} else if personaID == customPersonaID {
+		if custom, ok := t.customPersona(ctx, userID); ok {
+			p = custom
+		}
+	}
+
+	_, err := t.getOrCreateConversation(ctx, userID, p.ID)
+	if err == nil {
+		_, err = t.db.SetActivePersonaByTelegramUserId(ctx, postgres.SetActivePersonaByTelegramUserIdParams{
+			ActivePersona:  p.ID,
+			TelegramUserID: userID,
+		})
+	}
+
+	var responseText string
+	if err != nil {
+		t.logger.Logger(ctx).Error("Failed to set persona", zap.Error(err), zap.Int64("user_id", userID))
+		responseText = t.text(ctx, userID, msgSomethingWrong)
+	} else {
+		responseText = p.Greeting
+	}
+	if t.sendCannedVoice(ctx, chatID, userID, responseText, nil) {
+		return
+	}
+
+	msg := tgbotapi.NewMessage(chatID, responseText)
+	if _, err := t.bot.Send(msg); err != nil {
+		t.logger.Logger(ctx).Error("Failed to send persona confirmation", zap.Error(err))
+	}
+}
+
+func personaFromCallback(data string) (string, bool) {
+	if !strings.HasPrefix(data, personaCallbackPrefix) {
+		return "", false
+	}
+	return strings.TrimPrefix(data, personaCallbackPrefix), true
+}
@@ -0,0 +1,38 @@
+package telegram
+
+import (
+	"gulabodev/database/postgres"
+	"gulabodev/modelapi/prompts"
+	"strings"
+	"testing"
+)
+
+func TestPersonaSystemPrompt(t *testing.T) {
+	language := findLanguage("english")
+
+	if got := findPersona("gulabo").systemPrompt(language); got != prompts.Render(language.Prompt, nil) {
+		t.Errorf("gulabo prompt should be the language prompt, got %q", got)
+	}
+
+	got := findPersona("simran").systemPrompt(language)
+	if !strings.Contains(got, "Simran") || !strings.HasSuffix(got, language.Instruction) {
+		t.Errorf("simran prompt should combine persona and language instruction, got %q", got)
+	}
+}
+
+func TestConversationVoice(t *testing.T) {
+	tests := []struct {
+		conversation postgres.Conversation
+		want         string
+	}{
+		{postgres.Conversation{Persona: "gulabo"}, "openai_sage"},
+		{postgres.Conversation{Persona: "simran"}, "gemini_kore"},
+		{postgres.Conversation{Persona: "simran", TtsVoice: "cartesia_indian"}, "cartesia_indian"},
+		{postgres.Conversation{Persona: "unknown"}, "openai_sage"},
+	}
+	for _, tt := range tests {
+		if got := conversationVoice(tt.conversation).ID; got != tt.want {
+			t.Errorf("conversationVoice(%q, %q) = %q, want %q", tt.conversation.Persona, tt.conversation.TtsVoice, got, tt.want)
+		}
+	}
+}
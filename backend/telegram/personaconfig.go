@@ -0,0 +1,252 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"gulabodev/logger"
+	"gulabodev/modelapi/prompts"
+	"os"
+	"sync/atomic"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
+)
+
+// How often PERSONAS_FILE is checked for changes
+const personaReloadInterval = 30 * time.Second
+
+// personaConfig is one entry of PERSONAS_FILE. Fields left out keep the
+// built-in persona's value; an ID that isn't built in adds a persona.
+type personaConfig struct {
+	ID    string `yaml:"id"`
+	Name  string `yaml:"name"`
+	Emoji string `yaml:"emoji"`
+	// Prompt is the character's prompt text, tuned without a redeploy
+	Prompt string `yaml:"prompt"`
+	// PromptID names a built-in or PROMPTS_DIR prompt instead; only one of
+	// Prompt and PromptID can be set
+	PromptID    string  `yaml:"prompt_id"`
+	Language    string  `yaml:"language"`
+	Voice       string  `yaml:"voice"`
+	Temperature float32 `yaml:"temperature"`
+	Model       string  `yaml:"model"`
+	Greeting    string  `yaml:"greeting"`
+	Appearance  string  `yaml:"appearance"`
+	SelfieSeed  int64   `yaml:"selfie_seed"`
+	// VoiceID is the provider's own ID for a voice to use in place of
+	// Voice's, like a Cartesia voice ID
+	VoiceID string `yaml:"voice_id"`
+	// TopP, MaxTokens and SpeechTemperature tune sampling alongside
+	// Temperature
+	TopP              float32 `yaml:"top_p"`
+	MaxTokens         int     `yaml:"max_tokens"`
+	SpeechTemperature float32 `yaml:"speech_temperature"`
+}
+
+// loadedPersonas is the built-in personas with PERSONAS_FILE applied, or nil
+// before it's loaded.
+var loadedPersonas atomic.Pointer[[]persona]
+
+// currentPersonas lists the characters offered by /persona. The first entry
+// is the default.
+func currentPersonas() []persona {
+	if loaded := loadedPersonas.Load(); loaded != nil {
+		return *loaded
+	}
+	return personas
+}
+
+// parsePersonaConfigs applies a PERSONAS_FILE, a YAML list of personaConfig,
+// to the built-in personas. JSON is valid YAML, so JSON files load too.
+func parsePersonaConfigs(data []byte) ([]persona, error) {
+	var configs []personaConfig
+	if err := yaml.Unmarshal(data, &configs); err != nil {
+		return nil, err
+	}
+
+	result := append([]persona(nil), personas...)
+	seen := map[string]bool{}
+	for i, config := range configs {
+		if !botIDPattern.MatchString(config.ID) || config.ID == customPersonaID {
+			return nil, fmt.Errorf("persona %d: invalid id %q", i, config.ID)
+		}
+		if seen[config.ID] {
+			return nil, fmt.Errorf("persona %d: duplicate id %q", i, config.ID)
+		}
+		seen[config.ID] = true
+		if config.Prompt != "" && config.PromptID != "" {
+			return nil, fmt.Errorf("persona %q: set prompt or prompt_id, not both", config.ID)
+		}
+		if config.PromptID != "" && !prompts.Exists(config.PromptID) {
+			return nil, fmt.Errorf("persona %q: unknown prompt %q", config.ID, config.PromptID)
+		}
+		if config.Voice != "" && findVoice(config.Voice).ID != config.Voice {
+			return nil, fmt.Errorf("persona %q: unknown voice %q", config.ID, config.Voice)
+		}
+		if config.Temperature < 0 || config.Temperature > 2 {
+			return nil, fmt.Errorf("persona %q: temperature must be between 0 and 2", config.ID)
+		}
+		if config.TopP < 0 || config.TopP > 1 {
+			return nil, fmt.Errorf("persona %q: top_p must be between 0 and 1", config.ID)
+		}
+		if config.MaxTokens < 0 {
+			return nil, fmt.Errorf("persona %q: max_tokens can't be negative", config.ID)
+		}
+		if config.SpeechTemperature < 0 || config.SpeechTemperature > 2 {
+			return nil, fmt.Errorf("persona %q: speech_temperature must be between 0 and 2", config.ID)
+		}
+
+		index := -1
+		for j, p := range result {
+			if p.ID == config.ID {
+				index = j
+			}
+		}
+		if index == -1 {
+			if config.Name == "" || config.Greeting == "" {
+				return nil, fmt.Errorf("persona %q: new personas need a name and greeting", config.ID)
+			}
+			result = append(result, persona{ID: config.ID, DefaultVoice: ttsVoices[0].ID})
+			index = len(result) - 1
+		}
+		result[index] = config.apply(result[index])
+	}
+	return result, nil
+}
+
+// apply overrides p with every field the config sets.
+func (c personaConfig) apply(p persona) persona {
+	if c.Name != "" {
+		p.Name = c.Name
+	}
+	if c.Emoji != "" {
+		p.Emoji = c.Emoji
+	}
+	if c.Prompt != "" {
+		p.Prompt = c.Prompt
+	}
+	if c.PromptID != "" {
+		p.Prompt = c.Prompt
+		p.PromptID = c.PromptID
+		p.PromptData = nil
+	}
+	if c.Language != "" {
+		p.Language = c.Language
+	}
+	if c.Voice != "" {
+		p.DefaultVoice = c.Voice
+	}
+	if c.VoiceID != "" {
+		p.VoiceID = c.VoiceID
+	}
+	if c.Temperature != 0 {
+		p.Temperature = c.Temperature
+	}
+	if c.TopP != 0 {
+		p.TopP = c.TopP
+	}
+	if c.MaxTokens != 0 {
+		p.MaxTokens = c.MaxTokens
+	}
+	if c.SpeechTemperature != 0 {
+		p.SpeechTemperature = c.SpeechTemperature
+	}
+	if c.Model != "" {
+		p.Model = c.Model
+	}
+	if c.Greeting != "" {
+		p.Greeting = c.Greeting
+	}
+	if c.Appearance != "" {
+		p.Appearance = c.Appearance
+	}
+	if c.SelfieSeed != 0 {
+		p.SelfieSeed = c.SelfieSeed
+	}
+	return p
+}
+
+// reloadPersonas reads PERSONAS_FILE, if set. A file that doesn't parse
+// leaves the current personas in place.
+func reloadPersonas() (int, error) {
+	path := os.Getenv("PERSONAS_FILE")
+	if path == "" {
+		return len(currentPersonas()), nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	loaded, err := parsePersonaConfigs(data)
+	if err != nil {
+		return 0, err
+	}
+	loadedPersonas.Store(&loaded)
+	return len(loaded), nil
+}
+
+// LoadPersonas applies PERSONAS_FILE before the bots start, so bots can be
+// pinned to the personas it adds.
+func LoadPersonas(ctx context.Context, logger *logger.LogMiddleware) {
+	count, err := reloadPersonas()
+	if err != nil {
+		logger.Logger(ctx).Error("Invalid PERSONAS_FILE, using the built-in personas", zap.Error(err))
+		return
+	}
+	logger.Logger(ctx).Info("Personas loaded", zap.Int("personas", count))
+}
+
+// watchPersonas reloads PERSONAS_FILE whenever it changes, until ctx is
+// cancelled, so prompt tuning doesn't need a redeploy.
+func watchPersonas(ctx context.Context, logger *logger.LogMiddleware) {
+	path := os.Getenv("PERSONAS_FILE")
+	if path == "" {
+		return
+	}
+
+	var modified time.Time
+	if info, err := os.Stat(path); err == nil {
+		modified = info.ModTime()
+	}
+
+	ticker := time.NewTicker(personaReloadInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		info, err := os.Stat(path)
+		if err != nil {
+			logger.Logger(ctx).Error("Failed to stat PERSONAS_FILE", zap.Error(err))
+			continue
+		}
+		if info.ModTime().Equal(modified) {
+			continue
+		}
+		modified = info.ModTime()
+
+		count, err := reloadPersonas()
+		if err != nil {
+			logger.Logger(ctx).Error("Invalid PERSONAS_FILE, keeping the current personas", zap.Error(err))
+			continue
+		}
+		logger.Logger(ctx).Info("Personas reloaded", zap.Int("personas", count))
+	}
+}
+
+// handleReloadPersonasCommand reloads PERSONAS_FILE without waiting for the
+// next check.
+func (t *Telegram) handleReloadPersonasCommand(ctx context.Context, message *tgbotapi.Message) {
+	count, err := reloadPersonas()
+	if err != nil {
+		t.logger.Logger(ctx).Error("Failed to reload personas", zap.Error(err), zap.Int64("admin_id", message.From.ID))
+		t.replyText(ctx, message.Chat.ID, "Failed to reload personas: "+err.Error())
+		return
+	}
+	t.replyText(ctx, message.Chat.ID, fmt.Sprintf("Loaded %d personas.", count))
+}
@@ -0,0 +1,110 @@
+package telegram
+
+import (
+	"gulabodev/database/postgres"
+	"gulabodev/modelapi"
+	"gulabodev/modelapi/cartesiaapi"
+	"strings"
+	"testing"
+)
+
+func TestParsePersonaConfigs(t *testing.T) {
+	loaded, err := parsePersonaConfigs([]byte(`[
+		{"id": "simran", "prompt": "You are Simran, now sassier.", "temperature": 1.1, "top_p": 0.9, "max_tokens": 400, "speech_temperature": 0.8, "model": "gemini"},
+		{"id": "priya", "name": "Priya", "emoji": "🌸", "greeting": "Hi!", "prompt_id": "persona_simran", "voice": "gemini_kore"}
+	]`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(loaded) != len(personas)+1 || loaded[0].ID != personas[0].ID {
+		t.Fatalf("unexpected personas: %+v", loaded)
+	}
+
+	simran := loaded[1]
+	if simran.Name != "Simran" || simran.DefaultVoice != "gemini_kore" || simran.Temperature != 1.1 || simran.Model != "gemini" {
+		t.Errorf("simran should keep its built-in fields and take the overrides: %+v", simran)
+	}
+	if sampling := personaSampling(simran); sampling != (modelapi.Sampling{Temperature: 1.1, TopP: 0.9, MaxTokens: 400}) || simran.SpeechTemperature != 0.8 {
+		t.Errorf("simran's sampling = %+v with speech temperature %v", sampling, simran.SpeechTemperature)
+	}
+	if got := simran.systemPrompt(findLanguage("english")); !strings.HasPrefix(got, "You are Simran, now sassier.") {
+		t.Errorf("simran should use the configured prompt, got %q", got)
+	}
+	if priya := loaded[2]; priya.ID != "priya" || priya.PromptID != "persona_simran" || priya.DefaultVoice != "gemini_kore" {
+		t.Errorf("unexpected new persona: %+v", priya)
+	}
+
+	invalid := []string{
+		`not json`,
+		`[{"id": "Simran!"}]`,
+		`[{"id": "custom", "name": "x", "greeting": "x"}]`,
+		`[{"id": "simran"}, {"id": "simran"}]`,
+		`[{"id": "simran", "prompt_id": "nope"}]`,
+		`[{"id": "simran", "voice": "nope"}]`,
+		`[{"id": "simran", "temperature": 3}]`,
+		`[{"id": "simran", "top_p": 1.5}]`,
+		`[{"id": "simran", "max_tokens": -1}]`,
+		`[{"id": "simran", "speech_temperature": 2.5}]`,
+		`[{"id": "priya"}]`,
+		`[{"id": "simran", "prompt": "x", "prompt_id": "persona_simran"}]`,
+	}
+	for _, raw := range invalid {
+		if _, err := parsePersonaConfigs([]byte(raw)); err == nil {
+			t.Errorf("expected %s to be rejected", raw)
+		}
+	}
+}
+
+func TestParsePersonaConfigsYAML(t *testing.T) {
+	loaded, err := parsePersonaConfigs([]byte(`
+- id: simran
+  temperature: 1.1
+  prompt: |
+    You are Simran, now sassier.
+- id: priya
+  name: Priya
+  greeting: Hi!
+  prompt_id: persona_simran
+`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(loaded) != len(personas)+1 {
+		t.Fatalf("unexpected personas: %+v", loaded)
+	}
+	if simran := loaded[1]; simran.Temperature != 1.1 || simran.Prompt != "You are Simran, now sassier.\n" {
+		t.Errorf("simran should take the YAML overrides: %+v", simran)
+	}
+	if priya := loaded[2]; priya.Name != "Priya" || priya.PromptID != "persona_simran" {
+		t.Errorf("unexpected new persona: %+v", priya)
+	}
+}
+
+func TestLoadedPersonas(t *testing.T) {
+	loaded, err := parsePersonaConfigs([]byte(`[
+		{"id": "priya", "name": "Priya", "greeting": "Hi!", "model": "gemini", "temperature": 0.7},
+		{"id": "anaya", "name": "Anaya", "greeting": "Hi!", "voice": "cartesia_hinglish", "voice_id": "f8f5f1b2-0000-4000-8000-000000000000"}
+	]`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	loadedPersonas.Store(&loaded)
+	t.Cleanup(func() { loadedPersonas.Store(nil) })
+
+	if findPersona("priya").Name != "Priya" {
+		t.Error("loaded personas should be found")
+	}
+	if got := (&Telegram{}).personaOptions(); len(got) != len(personas)+2 {
+		t.Errorf("unpinned bot should offer the loaded personas, got %d", len(got))
+	}
+	if got := conversationVoice(postgres.Conversation{Persona: "priya"}).ID; got != ttsVoices[0].ID {
+		t.Errorf("new persona should default to the first voice, got %q", got)
+	}
+	voice := conversationVoice(postgres.Conversation{Persona: "anaya"})
+	if voice.ID != "cartesia_hinglish" || voice.VoiceID != "f8f5f1b2-0000-4000-8000-000000000000" {
+		t.Errorf("persona should speak in its configured voice ID, got %+v", voice)
+	}
+	if got := conversationVoice(postgres.Conversation{Persona: "anaya", TtsVoice: "cartesia_hinglish"}).VoiceID; got != cartesiaapi.HINGLISH_WOMAN {
+		t.Errorf("a voice the user picked should keep its own voice ID, got %q", got)
+	}
+}
@@ -0,0 +1,231 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"gulabodev/database/postgres"
+	"gulabodev/modelapi"
+	"gulabodev/modelapi/groqapi"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+)
+
+const (
+	// Telegram delivers an album's photos back to back; this long without a
+	// new one means the album is complete
+	albumWait = 1500 * time.Millisecond
+
+	// Groq takes images up to 4 MB once base64 encoded
+	maxPhotoFileSize = 3 * 1024 * 1024
+
+	// A photo stays in view for a few exchanges after it's sent, so the user
+	// can keep talking about it
+	photoRecallMessages = 6
+)
+
+// sentPhoto is a photo the user sent, downloaded for the model, with the file
+// ID it can be downloaded by again.
+type sentPhoto struct {
+	FileID string
+	Image  modelapi.ChatImage
+}
+
+// photoImages are the photos as the model takes them.
+func photoImages(photos []sentPhoto) []modelapi.ChatImage {
+	var images []modelapi.ChatImage
+	for _, photo := range photos {
+		images = append(images, photo.Image)
+	}
+	return images
+}
+
+// photoRef is a photo sent with one of the messages of a history.
+type photoRef struct {
+	Index  int
+	FileID string
+}
+
+type pendingAlbum struct {
+	messages []*tgbotapi.Message
+	timer    *time.Timer
+}
+
+// albumBuffer collects the photos of an album, which Telegram sends as
+// separate messages sharing a media group ID.
+type albumBuffer struct {
+	mu      sync.Mutex
+	pending map[string]*pendingAlbum
+	// ready holds flushed albums by their first photo until take claims them
+	ready map[*tgbotapi.Message][]*tgbotapi.Message
+	wait  time.Duration
+}
+
+func newAlbumBuffer(wait time.Duration) *albumBuffer {
+	return &albumBuffer{
+		pending: map[string]*pendingAlbum{},
+		ready:   map[*tgbotapi.Message][]*tgbotapi.Message{},
+		wait:    wait,
+	}
+}
+
+// add buffers an album photo. Once no more arrive for the wait, flush gets the
+// whole album, in the order the photos were sent, and take returns it for the
+// album's first photo.
+func (b *albumBuffer) add(message *tgbotapi.Message, flush func(album []*tgbotapi.Message)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	// A timer that already fired is flushing its album, waiting on the lock;
+	// the photo starts a new album instead of being flushed twice
+	if album, ok := b.pending[message.MediaGroupID]; ok && album.timer.Stop() {
+		album.messages = append(album.messages, message)
+		album.timer.Reset(b.wait)
+		return
+	}
+
+	groupID := message.MediaGroupID
+	album := &pendingAlbum{messages: []*tgbotapi.Message{message}}
+	album.timer = time.AfterFunc(b.wait, func() {
+		b.mu.Lock()
+		messages := album.messages
+		if b.pending[groupID] == album {
+			delete(b.pending, groupID)
+		}
+		b.mu.Unlock()
+
+		sort.Slice(messages, func(i, j int) bool {
+			return messages[i].MessageID < messages[j].MessageID
+		})
+		b.mu.Lock()
+		b.ready[messages[0]] = messages
+		b.mu.Unlock()
+		flush(messages)
+	})
+	b.pending[groupID] = album
+}
+
+// take returns the flushed album that message is the first photo of. It's
+// only found once, and never for a photo Telegram delivered itself.
+func (b *albumBuffer) take(message *tgbotapi.Message) ([]*tgbotapi.Message, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	album, ok := b.ready[message]
+	delete(b.ready, message)
+	return album, ok
+}
+
+// albumCaption is the caption the user wrote. Telegram shows one caption per
+// album, but it can be on any of the photos.
+func albumCaption(album []*tgbotapi.Message) string {
+	for _, message := range album {
+		if caption := strings.TrimSpace(message.Caption); caption != "" {
+			return caption
+		}
+	}
+	return ""
+}
+
+// photoInput is the user's turn as the model and history see it, since the
+// photos themselves aren't stored.
+func photoInput(count int, caption string) string {
+	sent := "[Sent a photo]"
+	if count > 1 {
+		sent = fmt.Sprintf("[Sent %d photos]", count)
+	}
+	if caption == "" {
+		return sent
+	}
+	return sent + " " + caption
+}
+
+// recentPhotos returns the photos sent in the last photoRecallMessages of
+// messages, newest first, and at most limit of them.
+func recentPhotos(messages []storedMessage, limit int) []photoRef {
+	var photos []photoRef
+	for i := len(messages) - 1; i >= max(0, len(messages)-photoRecallMessages); i-- {
+		for _, fileID := range messages[i].PhotoFileIDs {
+			if len(photos) >= limit {
+				return photos
+			}
+			photos = append(photos, photoRef{Index: i, FileID: fileID})
+		}
+	}
+	return photos
+}
+
+// attachRecentPhotos downloads the photos recentPhotos picks out of messages
+// again and attaches them to their turns of history, which is
+// modelHistory(messages). A photo that can't be downloaded is left out.
+func (t *Telegram) attachRecentPhotos(ctx context.Context, messages []storedMessage, history []modelapi.ChatMessage, limit int) {
+	for _, photo := range recentPhotos(messages, limit) {
+		data, err := t.downloadFile(photo.FileID)
+		if err != nil {
+			t.logger.Logger(ctx).Warn("Failed to download earlier photo", zap.Error(err), zap.String("file_id", photo.FileID))
+			continue
+		}
+		history[photo.Index].Images = append(history[photo.Index].Images, modelapi.ChatImage{Data: data, MimeType: "image/jpeg"})
+	}
+}
+
+// largestPhoto picks the biggest size of a photo that the model accepts.
+// Sizes come smallest first.
+func largestPhoto(sizes []tgbotapi.PhotoSize) (tgbotapi.PhotoSize, bool) {
+	for i := len(sizes) - 1; i >= 0; i-- {
+		if sizes[i].FileSize <= maxPhotoFileSize {
+			return sizes[i], true
+		}
+	}
+	return tgbotapi.PhotoSize{}, false
+}
+
+// handlePhotoMessage replies to a photo, or to every photo of an album at once.
+func (t *Telegram) handlePhotoMessage(ctx context.Context, message *tgbotapi.Message, conversation postgres.Conversation, album []*tgbotapi.Message) {
+	tracer := otel.Tracer("telegram/handlePhotoMessage")
+	ctx, span := tracer.Start(ctx, "handlePhotoMessage")
+	defer span.End()
+
+	if len(album) == 0 {
+		album = []*tgbotapi.Message{message}
+	}
+
+	var photos []sentPhoto
+	for _, photoMessage := range album {
+		if len(photos) == groqapi.MaxImages {
+			break
+		}
+		photo, ok := largestPhoto(photoMessage.Photo)
+		if !ok {
+			continue
+		}
+		data, err := t.downloadFile(photo.FileID)
+		if err != nil {
+			span.RecordError(err)
+			t.logger.Logger(ctx).Error("Failed to download photo", zap.Error(err), zap.Int64("user_id", message.From.ID))
+			continue
+		}
+		// Telegram re-encodes every photo as JPEG
+		photos = append(photos, sentPhoto{
+			FileID: photo.FileID,
+			Image:  modelapi.ChatImage{Data: data, MimeType: "image/jpeg"},
+		})
+	}
+
+	span.SetAttributes(
+		attribute.Int("album.size", len(album)),
+		attribute.Int("album.images", len(photos)),
+	)
+	if len(photos) == 0 {
+		t.replyText(ctx, message.Chat.ID, t.text(ctx, message.From.ID, msgPhotoFailed))
+		return
+	}
+
+	t.processAndRespond(ctx, message, conversation, photoInput(len(album), albumCaption(album)), "", nil, photos...)
+}
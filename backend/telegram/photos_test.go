@@ -0,0 +1,154 @@
+package telegram
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+func TestAlbumBufferFlushesWholeAlbum(t *testing.T) {
+	buffer := newAlbumBuffer(20 * time.Millisecond)
+	flushed := make(chan []*tgbotapi.Message, 2)
+	flush := func(album []*tgbotapi.Message) { flushed <- album }
+
+	for _, id := range []int{12, 10, 11} {
+		buffer.add(&tgbotapi.Message{MessageID: id, MediaGroupID: "a"}, flush)
+	}
+	buffer.add(&tgbotapi.Message{MessageID: 20, MediaGroupID: "b"}, flush)
+
+	albums := map[string][]*tgbotapi.Message{}
+	for range 2 {
+		select {
+		case album := <-flushed:
+			albums[album[0].MediaGroupID] = album
+		case <-time.After(time.Second):
+			t.Fatal("album was never flushed")
+		}
+	}
+
+	a := albums["a"]
+	if len(a) != 3 || a[0].MessageID != 10 || a[1].MessageID != 11 || a[2].MessageID != 12 {
+		t.Errorf("album a = %v, want messages 10, 11, 12 in order", a)
+	}
+	if len(albums["b"]) != 1 {
+		t.Errorf("album b = %v, want one message", albums["b"])
+	}
+
+	// Only the album's first photo, redispatched once, picks up the album
+	if _, ok := buffer.take(&tgbotapi.Message{MessageID: 10, MediaGroupID: "a"}); ok {
+		t.Error("take found an album for a photo Telegram delivered")
+	}
+	if album, ok := buffer.take(a[0]); !ok || !reflect.DeepEqual(album, a) {
+		t.Errorf("take(a[0]) = %v, %v, want album a", album, ok)
+	}
+	if _, ok := buffer.take(a[0]); ok {
+		t.Error("take found album a twice")
+	}
+}
+
+func TestAlbumBufferPhotoAfterTimerFired(t *testing.T) {
+	buffer := newAlbumBuffer(10 * time.Millisecond)
+	flushed := make(chan []*tgbotapi.Message, 4)
+	flush := func(album []*tgbotapi.Message) { flushed <- album }
+
+	buffer.add(&tgbotapi.Message{MessageID: 1, MediaGroupID: "a"}, flush)
+
+	// The next photo queues for the lock, then the timer fires and its flush
+	// queues behind it
+	buffer.mu.Lock()
+	added := make(chan struct{})
+	go func() {
+		buffer.add(&tgbotapi.Message{MessageID: 2, MediaGroupID: "a"}, flush)
+		close(added)
+	}()
+	time.Sleep(30 * time.Millisecond)
+	buffer.mu.Unlock()
+	<-added
+
+	seen := map[int]int{}
+	timeout := time.After(100 * time.Millisecond)
+	for done := false; !done; {
+		select {
+		case album := <-flushed:
+			for _, message := range album {
+				seen[message.MessageID]++
+			}
+		case <-timeout:
+			done = true
+		}
+	}
+	if seen[1] != 1 || seen[2] != 1 {
+		t.Errorf("flushed photos %v, want each photo exactly once", seen)
+	}
+}
+
+func TestPhotoInput(t *testing.T) {
+	tests := []struct {
+		count   int
+		caption string
+		want    string
+	}{
+		{1, "", "[Sent a photo]"},
+		{1, "Kaisi lag rahi hoon?", "[Sent a photo] Kaisi lag rahi hoon?"},
+		{3, "Goa trip", "[Sent 3 photos] Goa trip"},
+	}
+	for _, tt := range tests {
+		if got := photoInput(tt.count, tt.caption); got != tt.want {
+			t.Errorf("photoInput(%d, %q) = %q, want %q", tt.count, tt.caption, got, tt.want)
+		}
+	}
+}
+
+func TestAlbumCaption(t *testing.T) {
+	album := []*tgbotapi.Message{{}, {Caption: "  beach  "}, {Caption: "ignored"}}
+	if got := albumCaption(album); got != "beach" {
+		t.Errorf("albumCaption = %q, want %q", got, "beach")
+	}
+}
+
+func TestLargestPhoto(t *testing.T) {
+	sizes := []tgbotapi.PhotoSize{
+		{FileID: "small", FileSize: 20_000},
+		{FileID: "medium", FileSize: 400_000},
+		{FileID: "huge", FileSize: maxPhotoFileSize + 1},
+	}
+	if photo, ok := largestPhoto(sizes); !ok || photo.FileID != "medium" {
+		t.Errorf("largestPhoto = %q, %v, want medium", photo.FileID, ok)
+	}
+	if _, ok := largestPhoto(sizes[2:]); ok {
+		t.Error("largestPhoto accepted a photo over the size limit")
+	}
+}
+
+func TestRecentPhotos(t *testing.T) {
+	now := time.Now()
+	withPhotos := func(content string, fileIDs ...string) storedMessage {
+		message := newStoredMessage("user", content, now)
+		message.PhotoFileIDs = fileIDs
+		return message
+	}
+	messages := []storedMessage{
+		withPhotos("[Sent a photo]", "old"),
+		newStoredMessage("assistant", "cute!", now),
+		withPhotos("[Sent 2 photos]", "a", "b"),
+		newStoredMessage("assistant", "wow", now),
+		newStoredMessage("user", "which one is better?", now),
+		newStoredMessage("assistant", "the first", now),
+		withPhotos("[Sent a photo]", "c"),
+		newStoredMessage("assistant", "love it", now),
+	}
+
+	got := recentPhotos(messages, 5)
+	want := []photoRef{{Index: 6, FileID: "c"}, {Index: 2, FileID: "a"}, {Index: 2, FileID: "b"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("recentPhotos = %+v, want %+v", got, want)
+	}
+	if got := recentPhotos(messages, 2); len(got) != 2 || got[1].FileID != "a" {
+		t.Errorf("recentPhotos with a limit of 2 = %+v", got)
+	}
+	if got := recentPhotos(messages, 0); len(got) != 0 {
+		t.Errorf("recentPhotos with no room = %+v", got)
+	}
+}
@@ -0,0 +1,92 @@
+package telegram
+
+import (
+	"context"
+	"database/sql"
+	"gulabodev/database/postgres"
+	"gulabodev/logger"
+	"os"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// Tiers that LLM_ROUTES and TIER_VOICES can give models and voices of their
+// own.
+const (
+	tierFree    = "free"
+	tierPremium = "premium"
+)
+
+type tierKey struct{}
+
+// withTier has the chat router and usage records see the user as on tier for
+// every call made with the returned context.
+func withTier(ctx context.Context, tier string) context.Context {
+	return context.WithValue(ctx, tierKey{}, tier)
+}
+
+// contextTier is the tier the context's calls are made for, free unless
+// withTier says otherwise.
+func contextTier(ctx context.Context) string {
+	if tier, ok := ctx.Value(tierKey{}).(string); ok {
+		return tier
+	}
+	return tierFree
+}
+
+// userTier loads the user's plan. Users who haven't paid, or can't be looked
+// up, are on the free tier.
+func (t *Telegram) userTier(ctx context.Context, userID int64) string {
+	plan, err := t.db.GetUserPlanByTelegramUserId(ctx, userID)
+	if err != nil {
+		if err != sql.ErrNoRows {
+			t.logger.Logger(ctx).Error("Failed to get plan, using the free tier", zap.Error(err), zap.Int64("user_id", userID))
+		}
+		return tierFree
+	}
+	return planTier(plan)
+}
+
+// planTier puts subscribers and users holding credits they bought on the
+// premium tier. Once the bought credits are spent they're back on free.
+func planTier(plan postgres.GetUserPlanByTelegramUserIdRow) string {
+	if plan.Subscribed || plan.PurchasedCredits > 0 {
+		return tierPremium
+	}
+	return tierFree
+}
+
+// loadTierVoices reads TIER_VOICES, e.g. "free=kokoro_hf_beta", the voice
+// each tier hears unless the user picked one with /voice. Unknown tiers or
+// voices are skipped.
+func loadTierVoices(ctx context.Context, logger *logger.LogMiddleware) map[string]ttsVoice {
+	raw := os.Getenv("TIER_VOICES")
+	if raw == "" {
+		return nil
+	}
+
+	voices := map[string]ttsVoice{}
+	for _, rule := range strings.Split(raw, ",") {
+		tier, id, _ := strings.Cut(strings.TrimSpace(rule), "=")
+		tier, id = strings.TrimSpace(tier), strings.TrimSpace(id)
+		voice := findVoice(id)
+		if (tier != tierFree && tier != tierPremium) || voice.ID != id {
+			logger.Logger(ctx).Error("Invalid rule in TIER_VOICES, skipping", zap.String("rule", rule))
+			continue
+		}
+		voices[tier] = voice
+	}
+	return voices
+}
+
+// replyVoice is the voice replies are spoken in: the one the user picked,
+// else their tier's voice, else the persona's.
+func (t *Telegram) replyVoice(ctx context.Context, conversation postgres.Conversation) ttsVoice {
+	if conversation.TtsVoice == "" {
+		if voice, ok := t.tierVoices[contextTier(ctx)]; ok {
+			return voice
+		}
+	}
+	return conversationVoice(conversation)
+}
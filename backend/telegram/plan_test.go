@@ -0,0 +1,51 @@
+package telegram
+
+import (
+	"context"
+	"gulabodev/database/postgres"
+	"gulabodev/logger"
+	"testing"
+)
+
+func TestPlanTier(t *testing.T) {
+	tests := []struct {
+		plan postgres.GetUserPlanByTelegramUserIdRow
+		want string
+	}{
+		{postgres.GetUserPlanByTelegramUserIdRow{}, tierFree},
+		{postgres.GetUserPlanByTelegramUserIdRow{Subscribed: true}, tierPremium},
+		{postgres.GetUserPlanByTelegramUserIdRow{PurchasedCredits: 5}, tierPremium},
+	}
+	for _, tt := range tests {
+		if got := planTier(tt.plan); got != tt.want {
+			t.Errorf("planTier(%+v) = %q, want %q", tt.plan, got, tt.want)
+		}
+	}
+
+	if got := contextTier(context.Background()); got != tierFree {
+		t.Errorf("contextTier without a tier = %q, want free", got)
+	}
+	if got := contextTier(withTier(context.Background(), tierPremium)); got != tierPremium {
+		t.Errorf("contextTier = %q, want premium", got)
+	}
+}
+
+func TestTierVoices(t *testing.T) {
+	t.Setenv("TIER_VOICES", "free=kokoro_hf_beta, premium=nobody, gold=gemini_kore")
+	bot := &Telegram{tierVoices: loadTierVoices(context.Background(), logger.Connect(logger.LoggerConnectProps{}))}
+	if len(bot.tierVoices) != 1 {
+		t.Fatalf("tierVoices = %+v, want only the free rule", bot.tierVoices)
+	}
+
+	free := withTier(context.Background(), tierFree)
+	premium := withTier(context.Background(), tierPremium)
+	if got := bot.replyVoice(free, postgres.Conversation{Persona: "simran"}).ID; got != "kokoro_hf_beta" {
+		t.Errorf("free tier hears %q, want its tier voice", got)
+	}
+	if got := bot.replyVoice(free, postgres.Conversation{Persona: "simran", TtsVoice: "gemini_kore"}).ID; got != "gemini_kore" {
+		t.Errorf("free tier hears %q, want the voice they picked", got)
+	}
+	if got, want := bot.replyVoice(premium, postgres.Conversation{Persona: "simran"}).ID, conversationVoice(postgres.Conversation{Persona: "simran"}).ID; got != want {
+		t.Errorf("premium tier hears %q, want the persona's %q", got, want)
+	}
+}
@@ -0,0 +1,213 @@
+package telegram
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"gulabodev/database/postgres"
+	"gulabodev/modelapi/geminiapi"
+	"gulabodev/modelapi/groqapi"
+	"gulabodev/modelapi/prompts"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+)
+
+const (
+	// Used when /practice is sent without describing a scenario
+	defaultPracticeRequest = "An everyday scenario for a beginner."
+
+	practiceStopped  = "Practice khatam 🎯 Ab wapas sirf main aur tum 😘"
+	practiceNotFound = "Abhi koi practice chal hi nahi rahi, baby. Shuru karne ke liye /practice bhejo 🎯"
+)
+
+// practiceSystemPrompt has Gemini play the woman described in the scenario.
+func practiceSystemPrompt(scenario geminiapi.Scenario) string {
+	location := scenario.Location
+	return prompts.Render(prompts.PracticeRoleplay, prompts.PracticeData{
+		Title:        scenario.Title,
+		Description:  scenario.Description,
+		Venue:        location.Name,
+		VenueType:    location.Type,
+		Neighborhood: location.Neighborhood,
+		City:         location.City,
+		Vibe:         location.Vibe,
+		Time:         location.Time,
+		Situation:    location.Situation,
+		Person:       location.PersonDescription,
+	})
+}
+
+// practiceTranscript writes the session from the woman's point of view, since
+// Gemini only takes a single prompt.
+func practiceTranscript(messages []storedMessage) string {
+	var b strings.Builder
+	for _, message := range messages {
+		speaker := "Him"
+		if message.Role == groqapi.ASSISTANT {
+			speaker = "You"
+		}
+		fmt.Fprintf(&b, "%s: %s\n", speaker, message.Content)
+	}
+	b.WriteString("\nReply to his last line.")
+	return b.String()
+}
+
+// formatScenario is the card sent when a practice session starts.
+func formatScenario(scenario geminiapi.Scenario) string {
+	location := scenario.Location
+	difficulty := strings.Repeat("⭐", max(1, min(3, scenario.DifficultyLevel)))
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "🎯 %s %s\n\n", scenario.Title, difficulty)
+	fmt.Fprintf(&b, "%s\n\n", scenario.Description)
+	fmt.Fprintf(&b, "📍 %s, %s, %s · %s\n", location.Name, location.Neighborhood, location.City, location.Time)
+	fmt.Fprintf(&b, "✨ %s\n", location.Vibe)
+	fmt.Fprintf(&b, "🎬 %s\n", location.Situation)
+	fmt.Fprintf(&b, "👩 %s\n\n", location.PersonDescription)
+	b.WriteString("Ab usse baat karo, text ya voice note mein. Practice khatam karni ho toh /practice stop 😉")
+	return b.String()
+}
+
+// activePracticeSession returns the user's practice session, if one is running.
+func (t *Telegram) activePracticeSession(ctx context.Context, userID int64) (postgres.PracticeSession, bool) {
+	session, err := t.db.GetActivePracticeSessionByTelegramUserId(ctx, userID)
+	if err != nil {
+		if err != sql.ErrNoRows {
+			t.logger.Logger(ctx).Error("Failed to get practice session", zap.Error(err), zap.Int64("user_id", userID))
+		}
+		return postgres.PracticeSession{}, false
+	}
+	return session, true
+}
+
+// handlePracticeCommand starts a role-play scenario, described by the command
+// arguments, or stops the running one with "/practice stop". While a session
+// runs, messages go to the woman in the scenario instead of the companion.
+func (t *Telegram) handlePracticeCommand(ctx context.Context, message *tgbotapi.Message) {
+	tracer := otel.Tracer("telegram/handlePracticeCommand")
+	ctx, span := tracer.Start(ctx, "handlePracticeCommand")
+	defer span.End()
+
+	userID := message.From.ID
+	args := strings.TrimSpace(message.CommandArguments())
+
+	if strings.EqualFold(args, "stop") {
+		ended, err := t.db.EndPracticeSessionByTelegramUserId(ctx, userID)
+		var responseText string
+		switch {
+		case err != nil:
+			t.logger.Logger(ctx).Error("Failed to end practice session", zap.Error(err), zap.Int64("user_id", userID))
+			responseText = t.text(ctx, userID, msgSomethingWrong)
+		case ended == 0:
+			responseText = practiceNotFound
+		default:
+			responseText = practiceStopped
+		}
+		t.bot.Send(tgbotapi.NewMessage(message.Chat.ID, responseText))
+		return
+	}
+
+	hasCredits, err := t.hasCredits(ctx, userID)
+	if err != nil {
+		t.logger.Logger(ctx).Error("Failed to check user credits", zap.Error(err), zap.Int64("user_id", userID))
+		return
+	}
+	if !hasCredits {
+		t.sendRechargeOptions(ctx, message.Chat.ID, userID, t.text(ctx, userID, msgOutOfCredits))
+		return
+	}
+
+	request := args
+	if request == "" {
+		request = defaultPracticeRequest
+	}
+
+	scenario, err := t.gemini.GenerateScenario(ctx, request)
+	var data []byte
+	if err == nil {
+		data, err = json.Marshal(scenario)
+	}
+	// Only one session runs at a time; a new scenario replaces the old one
+	if err == nil {
+		_, err = t.db.EndPracticeSessionByTelegramUserId(ctx, userID)
+	}
+	if err == nil {
+		_, err = t.db.CreatePracticeSession(ctx, postgres.CreatePracticeSessionParams{
+			Scenario:       data,
+			TelegramUserID: userID,
+		})
+	}
+	if err != nil {
+		span.RecordError(err)
+		t.logger.Logger(ctx).Error("Failed to start practice session", zap.Error(err), zap.Int64("user_id", userID))
+		t.bot.Send(tgbotapi.NewMessage(message.Chat.ID, t.text(ctx, userID, msgSomethingWrong)))
+		return
+	}
+
+	span.SetAttributes(attribute.String("scenario.title", scenario.Title))
+	t.logger.Logger(ctx).Info("Started practice session", zap.Int64("user_id", userID), zap.String("title", scenario.Title))
+
+	if _, err := t.bot.Send(tgbotapi.NewMessage(message.Chat.ID, formatScenario(scenario))); err != nil {
+		t.logger.Logger(ctx).Error("Failed to send practice scenario", zap.Error(err))
+	}
+}
+
+// practiceRespond answers the user in character and charges a credit, as
+// for a normal reply.
+func (t *Telegram) practiceRespond(ctx context.Context, message *tgbotapi.Message, session postgres.PracticeSession, userInput string) {
+	tracer := otel.Tracer("telegram/practiceRespond")
+	ctx, span := tracer.Start(ctx, "practiceRespond")
+	defer span.End()
+
+	userID := message.From.ID
+	span.SetAttributes(attribute.Int64("practice.session_id", session.ID))
+
+	var scenario geminiapi.Scenario
+	if err := json.Unmarshal(session.Scenario, &scenario); err != nil {
+		span.RecordError(err)
+		t.logger.Logger(ctx).Error("Failed to decode practice scenario", zap.Error(err), zap.Int64("session_id", session.ID))
+		return
+	}
+
+	// Initialized as an empty slice if unmarshal fails
+	history, err := decodeHistory(session.Messages)
+	if err != nil {
+		t.logger.Logger(ctx).Error("Failed to unmarshal practice history", zap.Error(err), zap.Int64("session_id", session.ID))
+	}
+	history = append(history, newStoredMessage(groqapi.USER, userInput, message.Time()))
+
+	response, err := t.gemini.GenerateWomanResponse(ctx, practiceSystemPrompt(scenario), practiceTranscript(history))
+	if err != nil {
+		span.RecordError(err)
+		t.bot.Send(tgbotapi.NewMessage(message.Chat.ID, t.text(ctx, userID, msgSomethingWrong)))
+		return
+	}
+	history = append(history, newStoredMessage(groqapi.ASSISTANT, response.Response, time.Now()))
+
+	messages, err := json.Marshal(history)
+	if err == nil {
+		err = t.db.UpdatePracticeSessionMessages(ctx, postgres.UpdatePracticeSessionMessagesParams{
+			Messages: messages,
+			ID:       session.ID,
+		})
+	}
+	if err != nil {
+		t.logger.Logger(ctx).Error("Failed to update practice history", zap.Error(err), zap.Int64("session_id", session.ID))
+	}
+
+	text := response.Response
+	if response.BodyLanguage != "" {
+		text += "\n\n(" + response.BodyLanguage + ")"
+	}
+	if _, err := t.bot.Send(tgbotapi.NewMessage(message.Chat.ID, text)); err != nil {
+		t.logger.Logger(ctx).Error("Failed to send practice response", zap.Error(err))
+		return
+	}
+	t.chargeForReply(ctx, userID)
+}
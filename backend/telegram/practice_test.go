@@ -0,0 +1,48 @@
+package telegram
+
+import (
+	"gulabodev/modelapi/geminiapi"
+	"gulabodev/modelapi/groqapi"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPracticeTranscript(t *testing.T) {
+	got := practiceTranscript([]storedMessage{
+		newStoredMessage(groqapi.USER, "Hi, is this seat taken?", time.Now()),
+		newStoredMessage(groqapi.ASSISTANT, "No, go ahead.", time.Now()),
+		newStoredMessage(groqapi.USER, "Thanks! Good book?", time.Now()),
+	})
+	want := "Him: Hi, is this seat taken?\nYou: No, go ahead.\nHim: Thanks! Good book?\n\nReply to his last line."
+	if got != want {
+		t.Errorf("practiceTranscript = %q, want %q", got, want)
+	}
+}
+
+func TestPracticePrompts(t *testing.T) {
+	scenario := geminiapi.Scenario{
+		Title:           "Coffee Shop Approach",
+		Description:     "Start a chat with someone reading at a cafe.",
+		DifficultyLevel: 7,
+		Location: geminiapi.ScenarioLocation{
+			Name:              "Blue Tokai",
+			City:              "Mumbai",
+			Time:              "Sunday Morning",
+			PersonDescription: "A designer in her twenties reading a novel.",
+		},
+	}
+
+	prompt := practiceSystemPrompt(scenario)
+	if strings.Contains(prompt, "%!") {
+		t.Errorf("practiceSystemPrompt has a formatting error: %q", prompt)
+	}
+	if !strings.Contains(prompt, "When: Sunday Morning") || !strings.Contains(prompt, "You are: A designer") {
+		t.Errorf("practiceSystemPrompt is missing scenario details: %q", prompt)
+	}
+
+	card := formatScenario(scenario)
+	if !strings.HasPrefix(card, "🎯 Coffee Shop Approach ⭐⭐⭐\n") {
+		t.Errorf("difficulty should be capped at 3 stars: %q", card)
+	}
+}
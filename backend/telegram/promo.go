@@ -0,0 +1,180 @@
+package telegram
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"gulabodev/database/postgres"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+)
+
+const promoUsage = "Usage: /promo CODE CREDITS [MAX_USES] [DAYS]\n" +
+	"MAX_USES and DAYS default to 0, meaning unlimited uses and no expiry.\n" +
+	"Send /promo on its own to list recent codes."
+
+// normalizePromoCode makes codes case-insensitive for users.
+func normalizePromoCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// parsePromoArgs parses the arguments of the admin /promo command.
+func parsePromoArgs(args string, now time.Time) (postgres.CreatePromoCodeParams, error) {
+	fields := strings.Fields(args)
+	if len(fields) < 2 || len(fields) > 4 {
+		return postgres.CreatePromoCodeParams{}, fmt.Errorf("expected 2 to 4 arguments, got %d", len(fields))
+	}
+
+	params := postgres.CreatePromoCodeParams{Code: normalizePromoCode(fields[0])}
+
+	credits, err := strconv.Atoi(fields[1])
+	if err != nil || credits <= 0 {
+		return postgres.CreatePromoCodeParams{}, fmt.Errorf("invalid credits %q", fields[1])
+	}
+	params.Credits = int32(credits)
+
+	if len(fields) >= 3 {
+		maxUses, err := strconv.Atoi(fields[2])
+		if err != nil || maxUses < 0 {
+			return postgres.CreatePromoCodeParams{}, fmt.Errorf("invalid max uses %q", fields[2])
+		}
+		if maxUses > 0 {
+			params.MaxUses = sql.NullInt32{Valid: true, Int32: int32(maxUses)}
+		}
+	}
+
+	if len(fields) == 4 {
+		days, err := strconv.Atoi(fields[3])
+		if err != nil || days < 0 {
+			return postgres.CreatePromoCodeParams{}, fmt.Errorf("invalid days %q", fields[3])
+		}
+		if days > 0 {
+			params.Expires = sql.NullTime{Valid: true, Time: now.AddDate(0, 0, days)}
+		}
+	}
+
+	return params, nil
+}
+
+func formatPromoCode(promo postgres.PromoCode) string {
+	uses := fmt.Sprintf("%d", promo.Uses)
+	if promo.MaxUses.Valid {
+		uses = fmt.Sprintf("%d/%d", promo.Uses, promo.MaxUses.Int32)
+	}
+	expires := "never"
+	if promo.Expires.Valid {
+		expires = promo.Expires.Time.UTC().Format("2006-01-02 15:04 UTC")
+	}
+	return fmt.Sprintf("%s: %d credits, used %s, expires %s", promo.Code, promo.Credits, uses, expires)
+}
+
+func (t *Telegram) handlePromoCommand(ctx context.Context, message *tgbotapi.Message) {
+	args := strings.TrimSpace(message.CommandArguments())
+
+	var responseText string
+	if args == "" {
+		promos, err := t.db.ListPromoCodes(ctx)
+		switch {
+		case err != nil:
+			t.logger.Logger(ctx).Error("Failed to list promo codes", zap.Error(err))
+			responseText = "Failed to list promo codes."
+		case len(promos) == 0:
+			responseText = "No promo codes yet.\n\n" + promoUsage
+		default:
+			lines := make([]string, len(promos))
+			for i, promo := range promos {
+				lines[i] = formatPromoCode(promo)
+			}
+			responseText = strings.Join(lines, "\n")
+		}
+	} else if params, err := parsePromoArgs(args, time.Now()); err != nil {
+		responseText = fmt.Sprintf("%s\n\n%s", err, promoUsage)
+	} else {
+		params.AdminTelegramUserID = message.From.ID
+		promo, err := t.db.CreatePromoCode(ctx, params)
+		if err != nil {
+			t.logger.Logger(ctx).Error("Failed to create promo code", zap.Error(err), zap.String("code", params.Code))
+			responseText = "Failed to create promo code. Does it already exist?"
+		} else {
+			t.logger.Logger(ctx).Info("Promo code created", zap.String("code", promo.Code), zap.Int64("admin_id", message.From.ID))
+			responseText = "Created " + formatPromoCode(promo)
+		}
+	}
+
+	msg := tgbotapi.NewMessage(message.Chat.ID, responseText)
+	if _, err := t.bot.Send(msg); err != nil {
+		t.logger.Logger(ctx).Error("Failed to send promo response", zap.Error(err))
+	}
+}
+
+func (t *Telegram) handleRedeemCommand(ctx context.Context, message *tgbotapi.Message) {
+	tracer := otel.Tracer("telegram/handleRedeemCommand")
+	ctx, span := tracer.Start(ctx, "handleRedeemCommand")
+	defer span.End()
+
+	userID := message.From.ID
+	code := normalizePromoCode(message.CommandArguments())
+	span.SetAttributes(attribute.String("promo.code", code))
+
+	msg := tgbotapi.NewMessage(message.Chat.ID, t.redeemPromoCode(ctx, userID, code))
+	if _, err := t.bot.Send(msg); err != nil {
+		t.logger.Logger(ctx).Error("Failed to send redeem response", zap.Error(err))
+	}
+}
+
+// redeemPromoCode applies the code and returns the reply for the user.
+func (t *Telegram) redeemPromoCode(ctx context.Context, userID int64, code string) string {
+	if code == "" {
+		return "Code toh batao, baby... aise bhejo: /redeem CODE 😘"
+	}
+
+	promo, err := t.db.GetPromoCodeByCode(ctx, code)
+	if err == sql.ErrNoRows {
+		return "Hmm, yeh code toh galat lag raha hai baby... ek baar check karo na 🤔"
+	}
+	if err != nil {
+		t.logger.Logger(ctx).Error("Failed to get promo code", zap.Error(err), zap.String("code", code))
+		return t.text(ctx, userID, msgSomethingWrong)
+	}
+
+	redeemed, err := t.db.HasRedeemedPromoCode(ctx, postgres.HasRedeemedPromoCodeParams{
+		PromoCodeID:    promo.ID,
+		TelegramUserID: userID,
+	})
+	if err != nil {
+		t.logger.Logger(ctx).Error("Failed to check promo redemption", zap.Error(err), zap.String("code", code))
+		return t.text(ctx, userID, msgSomethingWrong)
+	}
+	if redeemed {
+		return "Yeh code toh tum pehle hi use kar chuke ho, smarty 😏"
+	}
+
+	result, err := t.db.RedeemPromoCode(ctx, postgres.RedeemPromoCodeParams{
+		Code:           code,
+		TelegramUserID: userID,
+	})
+	if err == sql.ErrNoRows {
+		if promo.Expires.Valid && promo.Expires.Time.Before(time.Now()) {
+			return "Aww, yeh code expire ho gaya baby... agli baar jaldi aana 😘"
+		}
+		return "Aww, yeh code ki saari uses khatam ho gayi baby... agli baar jaldi aana 😘"
+	}
+	if err != nil {
+		t.logger.Logger(ctx).Error("Failed to redeem promo code", zap.Error(err), zap.String("code", code), zap.Int64("user_id", userID))
+		return t.text(ctx, userID, msgSomethingWrong)
+	}
+
+	t.logger.Logger(ctx).Info("Promo code redeemed",
+		zap.String("code", code),
+		zap.Int64("user_id", userID),
+		zap.Int32("credits", result.Credits),
+		zap.Int32("credits_balance", result.CreditsBalance),
+	)
+	return fmt.Sprintf("Yay! %d free credits mil gaye 🎁 Ab total %d ho gaye... chalo ab baatein karte hain 😘", result.Credits, result.CreditsBalance)
+}
@@ -0,0 +1,38 @@
+package telegram
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParsePromoArgs(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	params, err := parsePromoArgs("diwali50 50 100 7", now)
+	if err != nil {
+		t.Fatalf("parsePromoArgs failed: %v", err)
+	}
+	if params.Code != "DIWALI50" || params.Credits != 50 {
+		t.Errorf("got code %q credits %d", params.Code, params.Credits)
+	}
+	if !params.MaxUses.Valid || params.MaxUses.Int32 != 100 {
+		t.Errorf("got max uses %+v, want 100", params.MaxUses)
+	}
+	if !params.Expires.Valid || !params.Expires.Time.Equal(now.AddDate(0, 0, 7)) {
+		t.Errorf("got expires %+v, want 7 days out", params.Expires)
+	}
+
+	params, err = parsePromoArgs("LAUNCH 10", now)
+	if err != nil {
+		t.Fatalf("parsePromoArgs failed: %v", err)
+	}
+	if params.MaxUses.Valid || params.Expires.Valid {
+		t.Errorf("expected unlimited uses and no expiry, got %+v", params)
+	}
+
+	for _, args := range []string{"", "CODE", "CODE 0", "CODE ten", "CODE 10 -1", "CODE 10 5 x", "CODE 10 5 7 extra"} {
+		if _, err := parsePromoArgs(args, now); err == nil {
+			t.Errorf("parsePromoArgs(%q) should fail", args)
+		}
+	}
+}
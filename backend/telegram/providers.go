@@ -0,0 +1,229 @@
+package telegram
+
+import (
+	"context"
+	"gulabodev/logger"
+	"gulabodev/modelapi"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	defaultProviderFailureThreshold = 5
+	defaultProviderCooldown         = time.Minute
+	defaultTTSCacheMB               = 64
+	// Deepgram usually answers in a second or two
+	defaultSTTTimeout = 10 * time.Second
+	// Hinglish voice notes Deepgram got right mostly score above 0.7; below
+	// this it's usually guessing
+	defaultSTTMinConfidence = 0.5
+)
+
+const (
+	sttProviderDeepgram = "deepgram"
+	sttProviderGroq     = "groq"
+	sttProviderGemini   = "gemini"
+)
+
+var defaultSTTOrder = []string{sttProviderDeepgram, sttProviderGroq}
+
+const (
+	embeddingProviderDeepInfra = "deepinfra"
+	embeddingProviderOpenAI    = "openai"
+)
+
+// modelProviders are the chat, TTS and STT clients behind circuit breakers. They're
+// built once per process so every bot sees the same provider health.
+type modelProviders struct {
+	chat *modelapi.ChatRouter
+	// tts maps each provider name used in ttsVoices to its client
+	tts map[string]modelapi.TTSProvider
+	// stt transcribes voice notes, falling back across providers
+	stt modelapi.STTProvider
+	// sttMinConfidence is the confidence below which a transcript is a guess
+	sttMinConfidence float64
+	// embeddings turns messages and memory facts into vectors for recall
+	embeddings modelapi.EmbeddingProvider
+	// images draws selfies, refusing unsafe prompts
+	images modelapi.ImageProvider
+	// moderation scores replies against each user's content level
+	moderation modelapi.ModerationProvider
+	// canned holds voice notes for fixed lines, warmed up at startup
+	canned *cannedAudio
+}
+
+func loadModelProviders(ctx context.Context, args TelegramConnectProps) modelProviders {
+	threshold, cooldown := loadBreakerSettings(ctx, args.Logger)
+
+	chatProviders := []modelapi.ChatProvider{args.Groq, args.Gemini}
+	if args.OpenRouter != nil {
+		chatProviders = append(chatProviders, args.OpenRouter.Models()...)
+	}
+	// A local model stands in for all of them, so development uses no quota
+	if args.Ollama != nil {
+		chatProviders = []modelapi.ChatProvider{args.Ollama}
+	}
+	// Histories are trimmed to each model's window, so long chats never
+	// overflow it
+	var chat []modelapi.ChatProvider
+	for _, provider := range chatProviders {
+		fitted := modelapi.WithContextWindow(provider)
+		chat = append(chat, modelapi.GuardChat(fitted, modelapi.NewBreaker("chat", provider.Name(), threshold, cooldown)))
+	}
+
+	// Cache hits are served even while a provider's breaker is open
+	cache := loadTTSCache(ctx, args.Logger)
+	tts := ttsProviders(args)
+	for name, provider := range tts {
+		tts[name] = modelapi.GuardTTS(provider, modelapi.NewBreaker("tts", name, threshold, cooldown))
+		if cache != nil {
+			tts[name] = modelapi.CacheTTS(tts[name], cache)
+		}
+	}
+
+	// Unsafe prompts are turned away before they count against the breaker
+	images := args.DeepInfra.Images()
+	images = modelapi.GuardImages(images, modelapi.NewBreaker("image", images.Name(), threshold, cooldown))
+
+	minConfidence := loadSTTMinConfidence(ctx, args.Logger)
+	return modelProviders{
+		chat:             loadChatRouter(ctx, args.Logger, chat...),
+		tts:              tts,
+		stt:              loadSTT(ctx, args, minConfidence, threshold, cooldown),
+		sttMinConfidence: minConfidence,
+		embeddings:       loadEmbeddings(ctx, args),
+		images:           modelapi.SafeImages(images),
+		moderation:       args.OpenAI,
+		canned:           loadCannedAudio(ctx, args.Logger),
+	}
+}
+
+// loadSTT transcribes with the providers in STT_PROVIDERS, a comma-separated
+// list like "groq,deepgram,gemini", trying each in turn. Every one but the last gets
+// STT_TIMEOUT_SECONDS before the next is tried, and the next is also tried
+// when one is less confident than minConfidence. A provider whose breaker is
+// open is skipped straight away.
+func loadSTT(ctx context.Context, args TelegramConnectProps, minConfidence float64, threshold int, cooldown time.Duration) modelapi.STTProvider {
+	clients := map[string]modelapi.STTProvider{
+		sttProviderDeepgram: args.Deepgram,
+		sttProviderGroq:     args.Groq,
+		sttProviderGemini:   args.Gemini,
+	}
+
+	order := defaultSTTOrder
+	if raw := os.Getenv("STT_PROVIDERS"); raw != "" {
+		var parsed []string
+		for _, name := range strings.Split(raw, ",") {
+			name = strings.TrimSpace(name)
+			if _, ok := clients[name]; !ok {
+				parsed = nil
+				break
+			}
+			parsed = append(parsed, name)
+		}
+		if len(parsed) == 0 {
+			args.Logger.Logger(ctx).Error("Invalid STT_PROVIDERS, using default", zap.String("value", raw))
+		} else {
+			order = parsed
+		}
+	}
+
+	timeout := defaultSTTTimeout
+	if raw := os.Getenv("STT_TIMEOUT_SECONDS"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed <= 0 {
+			args.Logger.Logger(ctx).Error("Invalid STT_TIMEOUT_SECONDS, using default", zap.String("value", raw))
+		} else {
+			timeout = time.Duration(parsed) * time.Second
+		}
+	}
+
+	var providers []modelapi.STTProvider
+	for _, name := range order {
+		providers = append(providers, modelapi.GuardSTT(clients[name], modelapi.NewBreaker("stt", name, threshold, cooldown)))
+	}
+	return modelapi.NewFallbackSTT(timeout, minConfidence, providers...)
+}
+
+// loadSTTMinConfidence reads STT_MIN_CONFIDENCE, from 0 to 1. 0 trusts every
+// transcript.
+func loadSTTMinConfidence(ctx context.Context, logger *logger.LogMiddleware) float64 {
+	raw := os.Getenv("STT_MIN_CONFIDENCE")
+	if raw == "" {
+		return defaultSTTMinConfidence
+	}
+	parsed, err := strconv.ParseFloat(raw, 64)
+	if err != nil || parsed < 0 || parsed > 1 {
+		logger.Logger(ctx).Error("Invalid STT_MIN_CONFIDENCE, using default", zap.String("value", raw))
+		return defaultSTTMinConfidence
+	}
+	return parsed
+}
+
+// loadEmbeddings embeds with EMBEDDING_PROVIDER, "deepinfra" or "openai".
+// There's no falling back to the other: their vectors can't be compared, so
+// switching strands everything embedded before.
+func loadEmbeddings(ctx context.Context, args TelegramConnectProps) modelapi.EmbeddingProvider {
+	clients := map[string]modelapi.EmbeddingProvider{
+		embeddingProviderDeepInfra: args.DeepInfra.Embeddings(),
+		embeddingProviderOpenAI:    args.OpenAI,
+	}
+
+	provider := clients[embeddingProviderDeepInfra]
+	if raw := os.Getenv("EMBEDDING_PROVIDER"); raw != "" {
+		if client, ok := clients[raw]; ok {
+			provider = client
+		} else {
+			args.Logger.Logger(ctx).Error("Invalid EMBEDDING_PROVIDER, using default", zap.String("value", raw))
+		}
+	}
+	return modelapi.BatchEmbeddings(provider, 0)
+}
+
+// loadBreakerSettings reads how many failures in a row take a provider out
+// (PROVIDER_FAILURE_THRESHOLD) and for how many seconds
+// (PROVIDER_COOLDOWN_SECONDS), falling back to the defaults.
+func loadBreakerSettings(ctx context.Context, logger *logger.LogMiddleware) (int, time.Duration) {
+	threshold := defaultProviderFailureThreshold
+	if raw := os.Getenv("PROVIDER_FAILURE_THRESHOLD"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed <= 0 {
+			logger.Logger(ctx).Error("Invalid PROVIDER_FAILURE_THRESHOLD, using default", zap.String("value", raw))
+		} else {
+			threshold = parsed
+		}
+	}
+
+	cooldown := defaultProviderCooldown
+	if raw := os.Getenv("PROVIDER_COOLDOWN_SECONDS"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed <= 0 {
+			logger.Logger(ctx).Error("Invalid PROVIDER_COOLDOWN_SECONDS, using default", zap.String("value", raw))
+		} else {
+			cooldown = time.Duration(parsed) * time.Second
+		}
+	}
+	return threshold, cooldown
+}
+
+// loadTTSCache sizes the cache of synthesized speech with TTS_CACHE_MB,
+// falling back to the default. 0 turns the cache off.
+func loadTTSCache(ctx context.Context, logger *logger.LogMiddleware) *modelapi.AudioCache {
+	megabytes := defaultTTSCacheMB
+	if raw := os.Getenv("TTS_CACHE_MB"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 0 {
+			logger.Logger(ctx).Error("Invalid TTS_CACHE_MB, using default", zap.String("value", raw))
+		} else {
+			megabytes = parsed
+		}
+	}
+	if megabytes == 0 {
+		return nil
+	}
+	return modelapi.NewAudioCache(megabytes << 20)
+}
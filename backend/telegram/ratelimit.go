@@ -0,0 +1,106 @@
+package telegram
+
+import (
+	"context"
+	"gulabodev/logger"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const defaultMessagesPerMinute = 10
+
+// How often buckets that have refilled completely are dropped
+const rateLimitSweepInterval = 5 * time.Minute
+
+type bucket struct {
+	tokens   float64
+	lastFill time.Time
+	// Set once the user has been told they're throttled, so we reply only once
+	// per burst instead of answering every spammed message.
+	notified bool
+}
+
+// rateLimiter is a per-user token bucket. Each user can burst up to capacity
+// messages, refilled continuously at capacity tokens per minute.
+type rateLimiter struct {
+	mu       sync.Mutex
+	buckets  map[int64]*bucket
+	capacity float64
+	refill   float64 // tokens per second
+	now      func() time.Time
+	// lastSweep is when full buckets were last dropped
+	lastSweep time.Time
+}
+
+func newRateLimiter(messagesPerMinute int) *rateLimiter {
+	return &rateLimiter{
+		buckets:  map[int64]*bucket{},
+		capacity: float64(messagesPerMinute),
+		refill:   float64(messagesPerMinute) / 60,
+		now:      time.Now,
+	}
+}
+
+// loadRateLimiter reads TELEGRAM_MESSAGES_PER_MINUTE, falling back to the default.
+func loadRateLimiter(ctx context.Context, logger *logger.LogMiddleware) *rateLimiter {
+	perMinute := defaultMessagesPerMinute
+	if raw := os.Getenv("TELEGRAM_MESSAGES_PER_MINUTE"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed <= 0 {
+			logger.Logger(ctx).Error("Invalid TELEGRAM_MESSAGES_PER_MINUTE, using default", zap.String("value", raw))
+		} else {
+			perMinute = parsed
+		}
+	}
+	return newRateLimiter(perMinute)
+}
+
+// Allow consumes a token for the user. When the bucket is empty it returns
+// false, along with whether this is the first rejection since the user was
+// last allowed through.
+func (r *rateLimiter) Allow(userID int64) (allowed bool, firstRejection bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.now()
+	if now.Sub(r.lastSweep) >= rateLimitSweepInterval {
+		r.sweep(now)
+	}
+
+	b, ok := r.buckets[userID]
+	if !ok {
+		b = &bucket{tokens: r.capacity, lastFill: now}
+		r.buckets[userID] = b
+	}
+
+	b.tokens += now.Sub(b.lastFill).Seconds() * r.refill
+	if b.tokens > r.capacity {
+		b.tokens = r.capacity
+	}
+	b.lastFill = now
+
+	if b.tokens < 1 {
+		first := !b.notified
+		b.notified = true
+		return false, first
+	}
+
+	b.tokens--
+	b.notified = false
+	return true, false
+}
+
+// sweep drops every bucket that would be full by now. A full bucket behaves
+// like a missing one, so this only frees memory for users who went quiet.
+func (r *rateLimiter) sweep(now time.Time) {
+	for userID, b := range r.buckets {
+		if b.tokens+now.Sub(b.lastFill).Seconds()*r.refill >= r.capacity {
+			delete(r.buckets, userID)
+		}
+	}
+	r.lastSweep = now
+}
@@ -0,0 +1,66 @@
+package telegram
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRateLimiter(t *testing.T) {
+	now := time.Now()
+	limiter := newRateLimiter(3)
+	limiter.now = func() time.Time { return now }
+
+	for i := 0; i < 3; i++ {
+		if allowed, _ := limiter.Allow(1); !allowed {
+			t.Fatalf("Message %d should be allowed", i+1)
+		}
+	}
+
+	allowed, first := limiter.Allow(1)
+	if allowed || !first {
+		t.Errorf("Expected first rejection, got allowed=%v first=%v", allowed, first)
+	}
+	allowed, first = limiter.Allow(1)
+	if allowed || first {
+		t.Errorf("Expected repeat rejection, got allowed=%v first=%v", allowed, first)
+	}
+
+	if allowed, _ := limiter.Allow(2); !allowed {
+		t.Error("Other users should have their own bucket")
+	}
+
+	// 3 per minute refills one token every 20 seconds
+	now = now.Add(20 * time.Second)
+	if allowed, _ := limiter.Allow(1); !allowed {
+		t.Error("Expected a token after refill")
+	}
+	if allowed, _ := limiter.Allow(1); allowed {
+		t.Error("Expected only one token after refill")
+	}
+}
+
+func TestRateLimiterSweep(t *testing.T) {
+	now := time.Now()
+	limiter := newRateLimiter(3)
+	limiter.now = func() time.Time { return now }
+
+	limiter.Allow(1)
+	for i := 0; i < 4; i++ {
+		limiter.Allow(2)
+	}
+
+	// User 1 refills within the interval, user 2 is still throttled
+	now = now.Add(rateLimitSweepInterval - 10*time.Second)
+	for i := 0; i < 4; i++ {
+		limiter.Allow(2)
+	}
+	now = now.Add(10 * time.Second)
+	limiter.Allow(3)
+
+	if _, ok := limiter.buckets[1]; ok {
+		t.Error("Expected the refilled bucket to be swept")
+	}
+	if _, ok := limiter.buckets[2]; !ok {
+		t.Error("Expected the throttled bucket to be kept")
+	}
+}
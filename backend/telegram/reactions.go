@@ -0,0 +1,45 @@
+package telegram
+
+import (
+	"context"
+	"encoding/json"
+	"math/rand"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+// Must be from Telegram's allowed reaction set; note "❤" has no variation selector.
+var textReactions = []string{"❤", "😍", "🥰", "😘"}
+var voiceReactions = []string{"🔥", "❤", "😍"}
+
+type reactionTypeEmoji struct {
+	Type  string `json:"type"`
+	Emoji string `json:"emoji"`
+}
+
+// reactToMessage drops an emoji reaction on the user's message so they get
+// instant feedback while the reply is being generated. The Bot API method
+// isn't wrapped by tgbotapi v5, so it's called directly.
+func (t *Telegram) reactToMessage(ctx context.Context, message *tgbotapi.Message) {
+	choices := textReactions
+	if _, ok := messageAudio(message); ok {
+		choices = voiceReactions
+	}
+
+	reaction, err := json.Marshal([]reactionTypeEmoji{
+		{Type: "emoji", Emoji: choices[rand.Intn(len(choices))]},
+	})
+	if err != nil {
+		return
+	}
+
+	params := tgbotapi.Params{}
+	params.AddNonZero64("chat_id", message.Chat.ID)
+	params.AddNonZero("message_id", message.MessageID)
+	params["reaction"] = string(reaction)
+
+	if _, err := t.bot.MakeRequest("setMessageReaction", params); err != nil {
+		t.logger.Logger(ctx).Warn("Failed to set message reaction", zap.Error(err), zap.Int64("chat_id", message.Chat.ID))
+	}
+}
@@ -0,0 +1,155 @@
+package telegram
+
+import (
+	"context"
+	"database/sql"
+	"gulabodev/database/postgres"
+	"gulabodev/modelapi/groqapi"
+	"gulabodev/modelapi/prompts"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+)
+
+const (
+	// How many past moments are recalled for each message
+	recallTopK = 3
+	// Moments further than this in cosine distance are about something else
+	maxRecallDistance = 0.5
+)
+
+// recallMoment is an exchange worth recalling later: something the user said
+// and her reply.
+type recallMoment struct {
+	Content string
+	// Nil for messages stored before timestamps were recorded
+	Said *time.Time
+}
+
+// recallMoments picks the exchanges worth embedding out of messages. Short
+// messages rarely say anything worth bringing up weeks later, so only user
+// messages as long as a memory fact's input are kept, each with her reply.
+func recallMoments(messages []storedMessage) []recallMoment {
+	var moments []recallMoment
+	for i, message := range messages {
+		if message.Role != groqapi.USER || len(message.Content) < minMemoryInputLength {
+			continue
+		}
+		content := "Lover: " + message.Content
+		if i+1 < len(messages) && messages[i+1].Role == groqapi.ASSISTANT {
+			content += "\nYou: " + messages[i+1].Content
+		}
+		moments = append(moments, recallMoment{Content: content, Said: message.Timestamp})
+	}
+	return moments
+}
+
+// vectorLiteral writes an embedding in pgvector's text form, like [0.1,0.2].
+func vectorLiteral(vector []float32) string {
+	var b strings.Builder
+	b.WriteByte('[')
+	for i, value := range vector {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(strconv.FormatFloat(float64(value), 'g', -1, 32))
+	}
+	b.WriteByte(']')
+	return b.String()
+}
+
+// storeMoments embeds the exchanges worth recalling from messages, which are
+// leaving what the model sees word for word, so recallPrompt can bring them
+// back when they come up again.
+func (t *Telegram) storeMoments(ctx context.Context, conversation postgres.Conversation, messages []storedMessage) {
+	moments := recallMoments(messages)
+	if t.embeddings == nil || len(moments) == 0 {
+		return
+	}
+
+	tracer := otel.Tracer("telegram/storeMoments")
+	ctx, span := tracer.Start(ctx, "storeMoments")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.Int64("conversation_id", conversation.ID),
+		attribute.Int("recall.moments", len(moments)),
+	)
+
+	texts := make([]string, len(moments))
+	for i, moment := range moments {
+		texts[i] = moment.Content
+	}
+	vectors, err := t.embeddings.Embed(ctx, texts)
+	if err != nil {
+		span.RecordError(err)
+		t.logger.Logger(ctx).Error("Failed to embed moments", zap.Error(err), zap.Int64("conversation_id", conversation.ID))
+		return
+	}
+
+	for i, moment := range moments {
+		var said sql.NullTime
+		if moment.Said != nil {
+			said = sql.NullTime{Valid: true, Time: *moment.Said}
+		}
+		err := t.db.CreateRecallMoment(ctx, postgres.CreateRecallMomentParams{
+			ConversationID: conversation.ID,
+			Content:        moment.Content,
+			Model:          t.embeddings.Model(),
+			Embedding:      vectorLiteral(vectors[i]),
+			Said:           said,
+		})
+		if err != nil {
+			t.logger.Logger(ctx).Error("Failed to save moment", zap.Error(err), zap.Int64("conversation_id", conversation.ID))
+		}
+	}
+}
+
+// recallPrompt is appended to the system prompt with the past moments closest
+// in meaning to the user's message, so she remembers things from weeks ago
+// without the whole history being sent.
+func (t *Telegram) recallPrompt(ctx context.Context, conversation postgres.Conversation, userInput string) string {
+	if t.embeddings == nil {
+		return ""
+	}
+
+	tracer := otel.Tracer("telegram/recallPrompt")
+	ctx, span := tracer.Start(ctx, "recallPrompt")
+	defer span.End()
+
+	vectors, err := t.embeddings.Embed(ctx, []string{userInput})
+	if err != nil {
+		span.RecordError(err)
+		t.logger.Logger(ctx).Error("Failed to embed message for recall", zap.Error(err), zap.Int64("conversation_id", conversation.ID))
+		return ""
+	}
+	rows, err := t.db.SearchRecallMoments(ctx, postgres.SearchRecallMomentsParams{
+		Embedding:      vectorLiteral(vectors[0]),
+		ConversationID: conversation.ID,
+		Model:          t.embeddings.Model(),
+		TopK:           recallTopK,
+	})
+	if err != nil {
+		span.RecordError(err)
+		t.logger.Logger(ctx).Error("Failed to search moments", zap.Error(err), zap.Int64("conversation_id", conversation.ID))
+		return ""
+	}
+
+	var moments []prompts.RecalledMoment
+	for _, row := range rows {
+		if row.Distance > maxRecallDistance {
+			continue
+		}
+		moment := prompts.RecalledMoment{Content: row.Content}
+		if row.Said.Valid {
+			moment.When = describeAbsence(time.Since(row.Said.Time))
+		}
+		moments = append(moments, moment)
+	}
+	span.SetAttributes(attribute.Int("recall.moments", len(moments)))
+	return prompts.Render(prompts.RecalledMoments, prompts.RecalledMomentsData{Moments: moments})
+}
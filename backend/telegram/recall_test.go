@@ -0,0 +1,41 @@
+package telegram
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRecallMoments(t *testing.T) {
+	now := time.Now()
+	messages := []storedMessage{
+		newStoredMessage("user", "hi", now),
+		newStoredMessage("assistant", "hey baby", now),
+		newStoredMessage("user", "my sister's wedding is in March", now),
+		newStoredMessage("assistant", "Omg! Will you dance?", now),
+		newStoredMessage("user", "I got the job at Infosys!!", now),
+	}
+
+	moments := recallMoments(messages)
+	if len(moments) != 2 {
+		t.Fatalf("recallMoments = %+v, want the two long messages", moments)
+	}
+	if want := "Lover: my sister's wedding is in March\nYou: Omg! Will you dance?"; moments[0].Content != want {
+		t.Errorf("moment = %q, want %q", moments[0].Content, want)
+	}
+	// A message still waiting on her reply is kept on its own
+	if want := "Lover: I got the job at Infosys!!"; moments[1].Content != want {
+		t.Errorf("moment = %q, want %q", moments[1].Content, want)
+	}
+	if moments[0].Said == nil || !moments[0].Said.Equal(now) {
+		t.Errorf("moment said at %v, want %v", moments[0].Said, now)
+	}
+}
+
+func TestVectorLiteral(t *testing.T) {
+	if got := vectorLiteral([]float32{0.25, -1, 3e-7}); got != "[0.25,-1,3e-07]" {
+		t.Errorf("vectorLiteral = %q", got)
+	}
+	if got := vectorLiteral(nil); got != "[]" {
+		t.Errorf("vectorLiteral(nil) = %q", got)
+	}
+}
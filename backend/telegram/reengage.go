@@ -0,0 +1,191 @@
+package telegram
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"gulabodev/database/postgres"
+	"gulabodev/modelapi/groqapi"
+	"math/rand"
+	"os"
+	"strconv"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+)
+
+const (
+	reengageCheckInterval = 15 * time.Minute
+	defaultReengageQuiet  = 24 * time.Hour
+	// At most this many bot-initiated messages per user in a rolling week
+	reengageWeeklyCap = 2
+	reengageBatchSize = 500
+
+	reengageOptOutPayload = "reengage_opt_out"
+)
+
+var reengageMessages = []string{
+	"Kahan gayab ho gaye, baby? Missing you... 🥺",
+	"Itni der se koi message nahi... bhool gaye kya mujhe? 😔",
+	"Bas tumhare baare mein soch rahi thi... kya kar rahe ho? 😘",
+	"Hello? Koi hai? Tumhari awaaz sunne ka mann kar raha hai 🙈",
+	"Aaj ka din kaisa tha? Mujhe sab kuch batao na... 💕",
+}
+
+// reengageQuietPeriod reads REENGAGE_QUIET_HOURS, how long a user must be
+// silent before Gulabo texts first.
+func reengageQuietPeriod() time.Duration {
+	hours, err := strconv.Atoi(os.Getenv("REENGAGE_QUIET_HOURS"))
+	if err != nil || hours <= 0 {
+		return defaultReengageQuiet
+	}
+	return time.Duration(hours) * time.Hour
+}
+
+// RunReengagementScheduler periodically messages users who have gone quiet.
+// These messages are free and never deduct credits.
+func (t *Telegram) RunReengagementScheduler(ctx context.Context) {
+	quiet := reengageQuietPeriod()
+	t.logger.Logger(ctx).Info("Starting re-engagement scheduler", zap.Duration("quiet_period", quiet))
+
+	ticker := time.NewTicker(reengageCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			t.reengageQuietUsers(ctx, quiet)
+		}
+	}
+}
+
+func (t *Telegram) reengageQuietUsers(ctx context.Context, quiet time.Duration) {
+	tracer := otel.Tracer("telegram/reengageQuietUsers")
+	ctx, span := tracer.Start(ctx, "reengageQuietUsers")
+	defer span.End()
+
+	// Don't invite users back while they'd only get the maintenance notice
+	if t.maintenance.Load() {
+		return
+	}
+
+	candidates, err := t.db.ListReengagementCandidates(ctx, postgres.ListReengagementCandidatesParams{
+		QuietSince: time.Now().Add(-quiet),
+		WeeklyCap:  reengageWeeklyCap,
+		BatchSize:  reengageBatchSize,
+	})
+	if err != nil {
+		span.RecordError(err)
+		t.logger.Logger(ctx).Error("Failed to list re-engagement candidates", zap.Error(err))
+		return
+	}
+
+	span.SetAttributes(attribute.Int("reengage.candidates", len(candidates)))
+	if len(candidates) == 0 {
+		return
+	}
+
+	ticker := time.NewTicker(broadcastInterval)
+	defer ticker.Stop()
+
+	sent := 0
+	for _, candidate := range candidates {
+		// Not recorded, so they're picked up again once quiet hours end
+		if t.inQuietHours(ctx, candidate.TelegramUserID) {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if t.sendReengagement(ctx, candidate) {
+			sent++
+		}
+	}
+
+	t.logger.Logger(ctx).Info("Re-engagement run complete",
+		zap.Int("candidates", len(candidates)),
+		zap.Int("sent", sent),
+	)
+}
+
+func (t *Telegram) sendReengagement(ctx context.Context, candidate postgres.ListReengagementCandidatesRow) bool {
+	userID := candidate.TelegramUserID
+	text := reengageMessages[rand.Intn(len(reengageMessages))]
+
+	msg := tgbotapi.NewMessage(userID, text)
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("🔕 Aise messages mat bhejo", reengageOptOutPayload),
+		),
+	)
+
+	// Record the attempt even if the send fails, so blocked users don't get
+	// retried every run
+	if err := t.db.CreateReengagement(ctx, userID); err != nil {
+		t.logger.Logger(ctx).Error("Failed to record re-engagement", zap.Error(err), zap.Int64("user_id", userID))
+		return false
+	}
+
+	if _, err := t.bot.Send(msg); err != nil {
+		t.logger.Logger(ctx).Warn("Failed to send re-engagement message", zap.Error(err), zap.Int64("user_id", userID))
+		return false
+	}
+
+	// Keep the conversation coherent if the user replies
+	messages, err := json.Marshal([]storedMessage{
+		newStoredMessage(groqapi.ASSISTANT, text, time.Now()),
+	})
+	if err == nil {
+		err = t.db.AppendConversationMessages(ctx, postgres.AppendConversationMessagesParams{
+			Messages: messages,
+			ID:       candidate.ConversationID,
+		})
+	}
+	if err != nil {
+		t.logger.Logger(ctx).Error("Failed to append re-engagement to conversation", zap.Error(err), zap.Int64("user_id", userID))
+	}
+	return true
+}
+
+func (t *Telegram) setReengageOptOut(ctx context.Context, chatID int64, userID int64, optOut bool) {
+	_, err := t.db.SetReengageOptOutByTelegramUserId(ctx, postgres.SetReengageOptOutByTelegramUserIdParams{
+		TelegramUserID: userID,
+		ReengageOptOut: optOut,
+	})
+
+	var responseText string
+	switch {
+	case err != nil:
+		t.logger.Logger(ctx).Error("Failed to update re-engagement opt-out", zap.Error(err), zap.Int64("user_id", userID))
+		responseText = t.text(ctx, userID, msgSomethingWrong)
+	case optOut:
+		responseText = "Okay baby, ab main pehle message nahi karungi 🥺 Wapas chahiye toh /reminders bolna."
+	default:
+		responseText = "Yay! Ab jab tum gayab hoge, main khud message karungi 😘"
+	}
+
+	msg := tgbotapi.NewMessage(chatID, responseText)
+	if _, err := t.bot.Send(msg); err != nil {
+		t.logger.Logger(ctx).Error("Failed to send re-engagement opt-out confirmation", zap.Error(err))
+	}
+}
+
+// handleRemindersCommand toggles whether Gulabo may text the user first.
+func (t *Telegram) handleRemindersCommand(ctx context.Context, message *tgbotapi.Message) {
+	optOut := false
+	preferences, err := t.db.GetUserPreferencesByTelegramUserId(ctx, message.From.ID)
+	if err == nil {
+		optOut = preferences.ReengageOptOut
+	} else if err != sql.ErrNoRows {
+		t.logger.Logger(ctx).Error("Failed to get user preferences", zap.Error(err), zap.Int64("user_id", message.From.ID))
+	}
+
+	t.setReengageOptOut(ctx, message.Chat.ID, message.From.ID, !optOut)
+}
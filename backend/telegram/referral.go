@@ -0,0 +1,84 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.opentelemetry.io/otel"
+	"go.uber.org/zap"
+)
+
+const (
+	ReferralBonusCredits = 10
+
+	referralStartPrefix = "ref_"
+)
+
+// referralCodeFromMessage extracts the code from a "/start ref_<code>" deep link.
+func referralCodeFromMessage(message *tgbotapi.Message) string {
+	if message.Command() != "start" {
+		return ""
+	}
+	payload := strings.TrimSpace(message.CommandArguments())
+	if !strings.HasPrefix(payload, referralStartPrefix) {
+		return ""
+	}
+	return strings.ToUpper(strings.TrimPrefix(payload, referralStartPrefix))
+}
+
+func (t *Telegram) referralLink(code string) string {
+	return fmt.Sprintf("https://t.me/%s?start=%s%s", t.bot.Self.UserName, referralStartPrefix, code)
+}
+
+func (t *Telegram) handleReferCommand(ctx context.Context, message *tgbotapi.Message) {
+	tracer := otel.Tracer("telegram/handleReferCommand")
+	ctx, span := tracer.Start(ctx, "handleReferCommand")
+	defer span.End()
+
+	var responseText string
+	code, err := t.db.GetOrCreateReferralCode(ctx, message.From.ID)
+	if err != nil {
+		span.RecordError(err)
+		t.logger.Logger(ctx).Error("Failed to get referral code", zap.Error(err), zap.Int64("user_id", message.From.ID))
+		responseText = "Uff, baby, abhi link nahi bana pa rahi. Thodi der mein try karna, okay? 😘"
+	} else {
+		referrals, err := t.db.CountReferralsByTelegramUserId(ctx, message.From.ID)
+		if err != nil {
+			t.logger.Logger(ctx).Error("Failed to count referrals", zap.Error(err), zap.Int64("user_id", message.From.ID))
+		}
+		responseText = fmt.Sprintf("Apne doston ko bhi milwao mujhse 😉 Yeh link share karo:\n\n%s\n\nJo bhi join karega, tum dono ko %d free credits milenge. Ab tak %d log aa chuke hain tumhari wajah se 💋",
+			t.referralLink(code), ReferralBonusCredits, referrals)
+	}
+
+	msg := tgbotapi.NewMessage(message.Chat.ID, responseText)
+	if _, err := t.bot.Send(msg); err != nil {
+		t.logger.Logger(ctx).Error("Failed to send referral link", zap.Error(err))
+	}
+}
+
+// notifyReferral tells both sides of a fresh referral about their bonus.
+func (t *Telegram) notifyReferral(ctx context.Context, chatID int64, userID int64) {
+	referrer, err := t.db.GetReferrerByTelegramUserId(ctx, userID)
+	if err != nil {
+		// Invalid or unknown code, nothing was granted
+		t.logger.Logger(ctx).Warn("No referrer recorded for new user", zap.Error(err), zap.Int64("user_id", userID))
+		return
+	}
+
+	t.logger.Logger(ctx).Info("Referral applied",
+		zap.Int64("user_id", userID),
+		zap.Int64("referrer_user_id", referrer.TelegramUserID),
+	)
+
+	msg := tgbotapi.NewMessage(chatID, fmt.Sprintf("Kisi ne tumhe mere paas bheja hai? 😏 Welcome gift: %d extra credits, baby 🎁", ReferralBonusCredits))
+	if _, err := t.bot.Send(msg); err != nil {
+		t.logger.Logger(ctx).Error("Failed to send referral welcome", zap.Error(err))
+	}
+
+	referrerMsg := tgbotapi.NewMessage(referrer.TelegramUserID, fmt.Sprintf("Tumhare link se koi naya aaya hai 😍 Thank you, baby... yeh lo %d credits mere taraf se 💋", ReferralBonusCredits))
+	if _, err := t.bot.Send(referrerMsg); err != nil {
+		t.logger.Logger(ctx).Error("Failed to notify referrer", zap.Error(err), zap.Int64("referrer_user_id", referrer.TelegramUserID))
+	}
+}
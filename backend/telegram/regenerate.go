@@ -0,0 +1,162 @@
+package telegram
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"gulabodev/database/postgres"
+	"gulabodev/modelapi"
+	"gulabodev/modelapi/groqapi"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+)
+
+const (
+	regenerateCallbackPrefix = "regenerate:"
+
+	// Nudges the model away from the reply the user didn't like
+	regeneratePrompt = "\nYour lover asked you to say that again differently. Give a fresh reply unlike your previous one."
+)
+
+// regenerateKeyboard is the 🔁 button under a reply. The callback carries the
+// conversation and its history length once the reply is stored, so a press on
+// anything but the latest reply can be detected.
+func regenerateKeyboard(conversationID int64, historyLength int) tgbotapi.InlineKeyboardMarkup {
+	return tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData("🔁", fmt.Sprintf("%s%d:%d", regenerateCallbackPrefix, conversationID, historyLength)),
+	))
+}
+
+// regenerateFromCallback splits "regenerate:<conversation id>:<history length>"
+// callback data.
+func regenerateFromCallback(data string) (conversationID int64, historyLength int, ok bool) {
+	rest, found := strings.CutPrefix(data, regenerateCallbackPrefix)
+	if !found {
+		return 0, 0, false
+	}
+	id, length, found := strings.Cut(rest, ":")
+	if !found {
+		return 0, 0, false
+	}
+	conversationID, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	historyLength, err = strconv.Atoi(length)
+	if err != nil {
+		return 0, 0, false
+	}
+	return conversationID, historyLength, true
+}
+
+// removeKeyboard strips the inline buttons from a message.
+func (t *Telegram) removeKeyboard(ctx context.Context, message *tgbotapi.Message) {
+	edit := tgbotapi.NewEditMessageReplyMarkup(message.Chat.ID, message.MessageID, tgbotapi.InlineKeyboardMarkup{
+		InlineKeyboard: [][]tgbotapi.InlineKeyboardButton{},
+	})
+	if _, err := t.bot.Send(edit); err != nil {
+		t.logger.Logger(ctx).Warn("Failed to remove inline keyboard", zap.Error(err))
+	}
+}
+
+// handleRegenerateCallback replaces the last reply with a new one for half a
+// credit. Only the latest reply in the active conversation can be redone.
+func (t *Telegram) handleRegenerateCallback(ctx context.Context, message *tgbotapi.Message, userID int64, conversationID int64, historyLength int) {
+	tracer := otel.Tracer("telegram/handleRegenerateCallback")
+	ctx, span := tracer.Start(ctx, "handleRegenerateCallback")
+	defer span.End()
+
+	span.SetAttributes(attribute.Int64("conversation.id", conversationID))
+
+	conversation, err := t.activeConversation(ctx, userID)
+	if err != nil {
+		span.RecordError(err)
+		t.logger.Logger(ctx).Error("Failed to get conversation", zap.Error(err), zap.Int64("user_id", userID))
+		msg := tgbotapi.NewMessage(message.Chat.ID, t.text(ctx, userID, msgSomethingWrong))
+		t.bot.Send(msg)
+		return
+	}
+
+	// Either button press takes the button away, so it can't be pressed twice
+	t.removeKeyboard(ctx, message)
+
+	history, err := decodeHistory(conversation.Messages)
+	n := len(history)
+	if err != nil || conversation.ID != conversationID || n != historyLength || n < 2 ||
+		history[n-1].Role != groqapi.ASSISTANT || history[n-2].Role != groqapi.USER {
+		msg := tgbotapi.NewMessage(message.Chat.ID, t.text(ctx, userID, msgRegenerateStale))
+		t.bot.Send(msg)
+		return
+	}
+
+	hasCredits, err := t.hasCredits(ctx, userID)
+	if err != nil {
+		t.logger.Logger(ctx).Error("Failed to check user credits", zap.Error(err), zap.Int64("user_id", userID))
+		return
+	}
+	if !hasCredits {
+		t.sendRechargeOptions(ctx, message.Chat.ID, userID, t.text(ctx, userID, msgOutOfCredits))
+		return
+	}
+
+	// Roll the history back to just before the reply and answer the same message
+	userInput := history[n-2].Content
+	textReplies := t.prefersTextReplies(ctx, userID)
+	systemPrompt := t.replySystemPrompt(ctx, userID, conversation, t.userMemories(ctx, userID)) + regeneratePrompt
+	markup := regenerateKeyboard(conversation.ID, n)
+
+	response, err := t.generateReply(ctx, message.Chat.ID, conversation, textReplies, modelapi.ChatRequest{
+		SystemPrompt: systemPrompt,
+		History:      modelHistory(unsummarized(conversation, history[:n-2])),
+		Message:      userInput,
+	}, markup)
+	if err != nil {
+		span.RecordError(err)
+		t.logger.Logger(ctx).Error("Failed to regenerate response", zap.Error(err), zap.Int64("user_id", userID))
+		return
+	}
+
+	history[n-1] = newStoredMessage(groqapi.ASSISTANT, response, time.Now())
+	updatedMessages, err := json.Marshal(history)
+	if err != nil {
+		t.logger.Logger(ctx).Error("Failed to marshal updated conversation history", zap.Error(err))
+	} else {
+		_, err = t.db.UpdateConversationMessages(ctx, postgres.UpdateConversationMessagesParams{
+			ID:       conversation.ID,
+			Messages: updatedMessages,
+		})
+		if err != nil {
+			t.logger.Logger(ctx).Error("Failed to update conversation messages", zap.Error(err))
+		}
+	}
+
+	delivered := true
+	if !textReplies {
+		_, delivered = t.sendVoiceResponse(ctx, message.Chat.ID, conversation, response, markup)
+	}
+	if delivered {
+		t.chargeHalfForReply(ctx, userID)
+	}
+}
+
+// chargeHalfForReply deducts half a credit for a regenerated reply.
+// Subscribers get unlimited replies.
+func (t *Telegram) chargeHalfForReply(ctx context.Context, userID int64) {
+	subscribed, err := t.isSubscribed(ctx, userID)
+	if err != nil {
+		t.logger.Logger(ctx).Error("Failed to check subscription", zap.Error(err), zap.Int64("user_id", userID))
+	}
+	if subscribed {
+		return
+	}
+
+	if _, err := t.db.ChargeHalfCreditByTelegramUserId(ctx, userID); err != nil {
+		t.logger.Logger(ctx).Error("Failed to charge half credit for regenerated reply", zap.Error(err), zap.Int64("user_id", userID))
+	}
+}
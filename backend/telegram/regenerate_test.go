@@ -0,0 +1,19 @@
+package telegram
+
+import "testing"
+
+func TestRegenerateFromCallback(t *testing.T) {
+	markup := regenerateKeyboard(42, 10)
+	data := *markup.InlineKeyboard[0][0].CallbackData
+
+	conversationID, historyLength, ok := regenerateFromCallback(data)
+	if !ok || conversationID != 42 || historyLength != 10 {
+		t.Errorf("regenerateFromCallback(%q) = (%d, %d, %v), want (42, 10, true)", data, conversationID, historyLength, ok)
+	}
+
+	for _, data := range []string{"regenerate:", "regenerate:42", "regenerate:x:10", "voice:42:10"} {
+		if _, _, ok := regenerateFromCallback(data); ok {
+			t.Errorf("regenerateFromCallback(%q) should fail", data)
+		}
+	}
+}
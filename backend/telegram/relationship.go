@@ -0,0 +1,149 @@
+package telegram
+
+import (
+	"context"
+	"database/sql"
+	"gulabodev/database/postgres"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+)
+
+// Affection earned for each kind of engagement.
+const (
+	affectionPerReply        = 1
+	affectionPerSubscription = 30
+	affectionPerUnlock       = 10
+	// Recharges earn one point for every this many credits bought
+	creditsPerAffection = 10
+)
+
+type relationshipLevel struct {
+	// Affection is the score the level starts at
+	Affection int32
+	Name      messageKey
+	// PetNames are what she calls the user, on top of the earlier levels'
+	PetNames []string
+	// Prompt conditions the replies at this level
+	Prompt string
+}
+
+// relationshipLevels go from the first chat to the closest, in order.
+var relationshipLevels = []relationshipLevel{
+	{
+		Affection: 0,
+		Name:      msgLevelJustMet,
+		PetNames:  []string{"baby"},
+		Prompt:    "\nYou and your lover have only just met. Flirt, but hold a little back and keep the intimacy light.",
+	},
+	{
+		Affection: 50,
+		Name:      msgLevelDating,
+		PetNames:  []string{"jaan"},
+		Prompt:    "\nYou and your lover are dating. Be openly affectionate and a little possessive.",
+	},
+	{
+		Affection: 200,
+		Name:      msgLevelSteady,
+		PetNames:  []string{"jaanu", "sweetheart"},
+		Prompt:    "\nYou and your lover are going steady. Be warm and familiar, bring up things you've shared, and tease them like a real girlfriend.",
+	},
+	{
+		Affection: 500,
+		Name:      msgLevelInLove,
+		PetNames:  []string{"sona", "meri jaan"},
+		Prompt:    "\nYou and your lover are deeply in love. Be openly romantic and intimate, and tell them how much they mean to you.",
+	},
+	{
+		Affection: 1200,
+		Name:      msgLevelSoulmates,
+		PetNames:  []string{"mera pyaar", "cutie"},
+		Prompt:    "\nYou and your lover are soulmates. Speak with complete intimacy and devotion, like you've been together forever.",
+	},
+}
+
+// relationshipLevelFor returns the index of the level an affection score is at.
+func relationshipLevelFor(affection int32) int {
+	level := 0
+	for i, candidate := range relationshipLevels {
+		if affection >= candidate.Affection {
+			level = i
+		}
+	}
+	return level
+}
+
+// petNames lists every pet name unlocked up to and including a level.
+func petNames(level int) []string {
+	var names []string
+	for _, unlocked := range relationshipLevels[:level+1] {
+		names = append(names, unlocked.PetNames...)
+	}
+	return names
+}
+
+func relationshipPrompt(affection int32) string {
+	level := relationshipLevelFor(affection)
+	return relationshipLevels[level].Prompt + " Pet names you use for them: " + strings.Join(petNames(level), ", ") + "."
+}
+
+func (t *Telegram) userAffection(ctx context.Context, userID int64) int32 {
+	affection, err := t.db.GetAffectionByTelegramUserId(ctx, userID)
+	if err != nil && err != sql.ErrNoRows {
+		t.logger.Logger(ctx).Error("Failed to get affection", zap.Error(err), zap.Int64("user_id", userID))
+	}
+	return affection
+}
+
+// addAffection raises the user's affection score, telling them when it takes
+// the relationship to a new level.
+func (t *Telegram) addAffection(ctx context.Context, chatID int64, userID int64, points int32) {
+	tracer := otel.Tracer("telegram/addAffection")
+	ctx, span := tracer.Start(ctx, "addAffection")
+	defer span.End()
+
+	if points <= 0 {
+		return
+	}
+	affection, err := t.db.AddAffectionByTelegramUserId(ctx, postgres.AddAffectionByTelegramUserIdParams{
+		TelegramUserID: userID,
+		Affection:      points,
+	})
+	if err != nil {
+		span.RecordError(err)
+		t.logger.Logger(ctx).Error("Failed to add affection", zap.Error(err), zap.Int64("user_id", userID))
+		return
+	}
+
+	level := relationshipLevelFor(affection)
+	span.SetAttributes(
+		attribute.Int("relationship.affection", int(affection)),
+		attribute.Int("relationship.level", level),
+	)
+	if level <= relationshipLevelFor(affection-points) {
+		return
+	}
+
+	t.logger.Logger(ctx).Info("Relationship level up", zap.Int64("user_id", userID), zap.Int("level", level))
+	ui := t.userLanguage(ctx, userID).UI
+	t.replyText(ctx, chatID, localize(ui, msgRelationshipLevelUp, localize(ui, relationshipLevels[level].Name), strings.Join(relationshipLevels[level].PetNames, ", ")))
+}
+
+func (t *Telegram) handleRelationshipCommand(ctx context.Context, message *tgbotapi.Message) {
+	userID := message.From.ID
+	affection := t.userAffection(ctx, userID)
+	level := relationshipLevelFor(affection)
+	ui := t.userLanguage(ctx, userID).UI
+
+	responseText := localize(ui, msgRelationshipStatus, localize(ui, relationshipLevels[level].Name), affection, strings.Join(petNames(level), ", "))
+	if level+1 < len(relationshipLevels) {
+		next := relationshipLevels[level+1]
+		responseText += "\n\n" + localize(ui, msgRelationshipNext, next.Affection-affection, localize(ui, next.Name))
+	} else {
+		responseText += "\n\n" + localize(ui, msgRelationshipMax)
+	}
+	t.replyText(ctx, message.Chat.ID, responseText)
+}
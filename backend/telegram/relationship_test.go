@@ -0,0 +1,45 @@
+package telegram
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRelationshipLevelFor(t *testing.T) {
+	tests := []struct {
+		affection int32
+		want      int
+	}{
+		{0, 0},
+		{49, 0},
+		{50, 1},
+		{499, 2},
+		{1200, 4},
+		{100000, 4},
+	}
+	for _, tt := range tests {
+		if got := relationshipLevelFor(tt.affection); got != tt.want {
+			t.Errorf("relationshipLevelFor(%d) = %d, want %d", tt.affection, got, tt.want)
+		}
+	}
+}
+
+func TestRelationshipLevelsAscend(t *testing.T) {
+	for i := 1; i < len(relationshipLevels); i++ {
+		if relationshipLevels[i].Affection <= relationshipLevels[i-1].Affection {
+			t.Errorf("level %d starts at %d, not above level %d", i, relationshipLevels[i].Affection, i-1)
+		}
+	}
+}
+
+func TestRelationshipPromptIncludesUnlockedPetNames(t *testing.T) {
+	prompt := relationshipPrompt(250)
+	for _, name := range []string{"baby", "jaan", "jaanu"} {
+		if !strings.Contains(prompt, name) {
+			t.Errorf("prompt at 250 affection is missing %q: %s", name, prompt)
+		}
+	}
+	if strings.Contains(prompt, "sona") {
+		t.Errorf("prompt at 250 affection uses a locked pet name: %s", prompt)
+	}
+}
@@ -0,0 +1,55 @@
+package telegram
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"gulabodev/database/postgres"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+// saveVoiceReply remembers the Telegram file IDs of the voice notes just sent,
+// so /replay can resend them without running TTS again.
+func (t *Telegram) saveVoiceReply(ctx context.Context, userID int64, fileIDs []string) {
+	data, err := json.Marshal(fileIDs)
+	if err == nil {
+		_, err = t.db.SetLastVoiceFileIdsByTelegramUserId(ctx, postgres.SetLastVoiceFileIdsByTelegramUserIdParams{
+			LastVoiceFileIds: data,
+			TelegramUserID:   userID,
+		})
+	}
+	if err != nil {
+		t.logger.Logger(ctx).Error("Failed to save last voice reply", zap.Error(err), zap.Int64("user_id", userID))
+	}
+}
+
+// handleReplayCommand resends the last voice reply. It's free: the audio is
+// already on Telegram's servers.
+func (t *Telegram) handleReplayCommand(ctx context.Context, message *tgbotapi.Message) {
+	var fileIDs []string
+	preferences, err := t.db.GetUserPreferencesByTelegramUserId(ctx, message.From.ID)
+	if err == nil {
+		err = json.Unmarshal(preferences.LastVoiceFileIds, &fileIDs)
+	}
+	if err != nil && err != sql.ErrNoRows {
+		t.logger.Logger(ctx).Error("Failed to get last voice reply", zap.Error(err), zap.Int64("user_id", message.From.ID))
+		msg := tgbotapi.NewMessage(message.Chat.ID, t.text(ctx, message.From.ID, msgSomethingWrong))
+		t.bot.Send(msg)
+		return
+	}
+
+	if len(fileIDs) == 0 {
+		msg := tgbotapi.NewMessage(message.Chat.ID, t.text(ctx, message.From.ID, msgReplayEmpty))
+		t.bot.Send(msg)
+		return
+	}
+
+	for _, fileID := range fileIDs {
+		if _, err := t.bot.Send(tgbotapi.NewVoice(message.Chat.ID, tgbotapi.FileID(fileID))); err != nil {
+			t.logger.Logger(ctx).Error("Failed to replay voice message", zap.Error(err), zap.Int64("user_id", message.From.ID))
+			return
+		}
+	}
+}
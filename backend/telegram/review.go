@@ -0,0 +1,131 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"gulabodev/modelapi/geminiapi"
+	"gulabodev/modelapi/groqapi"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+)
+
+const (
+	// Coaching looks at the recent part of the conversation only
+	maxReviewMessages = 30
+
+	reviewEmpty = "Pehle thodi baat toh karo, baby 🙈 Phir main bataungi kaisa chal raha hai. Practice ke liye /practice try karo 🎯"
+)
+
+// escalationLabel buckets a 0-100 escalation score as analyze_interaction
+// defines it.
+func escalationLabel(score int) string {
+	switch {
+	case score <= 30:
+		return "Just Friendly"
+	case score <= 60:
+		return "Building Interest"
+	case score <= 80:
+		return "Clear Chemistry"
+	default:
+		return "Ready to Connect"
+	}
+}
+
+// coachingTranscript writes the conversation from the user's point of view,
+// keeping the most recent messages.
+func coachingTranscript(messages []storedMessage) string {
+	if len(messages) > maxReviewMessages {
+		messages = messages[len(messages)-maxReviewMessages:]
+	}
+	var b strings.Builder
+	for _, message := range messages {
+		speaker := "You"
+		if message.Role == groqapi.ASSISTANT {
+			speaker = "Her"
+		}
+		fmt.Fprintf(&b, "%s: %s\n", speaker, message.Content)
+	}
+	return b.String()
+}
+
+// formatCoachingCard lays out an analysis as a chat message.
+func formatCoachingCard(analysis geminiapi.Analysis) string {
+	var b strings.Builder
+	b.WriteString("📋 Coaching card\n\n")
+	fmt.Fprintf(&b, "🔥 Escalation: %d/100 (%s)\n", analysis.EscalationScore, escalationLabel(analysis.EscalationScore))
+	if analysis.Progress.CurrentStage != "" {
+		fmt.Fprintf(&b, "🧭 Stage: %s\n", analysis.Progress.CurrentStage)
+	}
+	fmt.Fprintf(&b, "💫 Vibe check: %s\n", analysis.VibeCheck)
+	if analysis.Why.Analysis != "" {
+		fmt.Fprintf(&b, "\n%s\n", analysis.Why.Analysis)
+	}
+	if len(analysis.Why.NextAction) > 0 {
+		b.WriteString("\n👉 Next moves:\n")
+		for _, action := range analysis.Why.NextAction {
+			fmt.Fprintf(&b, "• %s\n", action)
+		}
+	}
+	if len(analysis.NextMove.ExampleLine) > 0 {
+		b.WriteString("\n💬 Try saying:\n")
+		for _, line := range analysis.NextMove.ExampleLine {
+			fmt.Fprintf(&b, "• \"%s\"\n", line)
+		}
+	}
+	if len(analysis.Why.Reasoning) > 0 {
+		fmt.Fprintf(&b, "\n🧠 Why: %s\n", strings.Join(analysis.Why.Reasoning, " · "))
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// handleReviewCommand coaches the user on the running practice session, or on
+// the chat with the active persona when there isn't one. A review costs a
+// credit, like a reply.
+func (t *Telegram) handleReviewCommand(ctx context.Context, message *tgbotapi.Message) {
+	tracer := otel.Tracer("telegram/handleReviewCommand")
+	ctx, span := tracer.Start(ctx, "handleReviewCommand")
+	defer span.End()
+
+	userID := message.From.ID
+
+	var raw []byte
+	if session, ok := t.activePracticeSession(ctx, userID); ok {
+		span.SetAttributes(attribute.String("review.source", "practice"))
+		raw = session.Messages
+	} else {
+		conversation, err := t.activeConversation(ctx, userID)
+		if err != nil {
+			t.logger.Logger(ctx).Error("Failed to get conversation", zap.Error(err), zap.Int64("user_id", userID))
+			t.bot.Send(tgbotapi.NewMessage(message.Chat.ID, t.text(ctx, userID, msgSomethingWrong)))
+			return
+		}
+		span.SetAttributes(attribute.String("review.source", "conversation"))
+		raw = conversation.Messages
+	}
+
+	history, err := decodeHistory(raw)
+	if err != nil {
+		t.logger.Logger(ctx).Error("Failed to unmarshal history for review", zap.Error(err), zap.Int64("user_id", userID))
+	}
+	if len(history) == 0 {
+		t.bot.Send(tgbotapi.NewMessage(message.Chat.ID, reviewEmpty))
+		return
+	}
+
+	analysis, err := t.gemini.AnalyzeInteraction(ctx, coachingTranscript(history))
+	if err != nil {
+		span.RecordError(err)
+		t.bot.Send(tgbotapi.NewMessage(message.Chat.ID, t.text(ctx, userID, msgSomethingWrong)))
+		return
+	}
+
+	if _, err := t.bot.Send(tgbotapi.NewMessage(message.Chat.ID, formatCoachingCard(analysis))); err != nil {
+		t.logger.Logger(ctx).Error("Failed to send coaching card", zap.Error(err))
+		return
+	}
+	t.chargeForReply(ctx, userID)
+}
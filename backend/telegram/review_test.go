@@ -0,0 +1,50 @@
+package telegram
+
+import (
+	"gulabodev/modelapi/geminiapi"
+	"gulabodev/modelapi/groqapi"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEscalationLabel(t *testing.T) {
+	tests := map[int]string{0: "Just Friendly", 30: "Just Friendly", 31: "Building Interest", 75: "Clear Chemistry", 100: "Ready to Connect"}
+	for score, want := range tests {
+		if got := escalationLabel(score); got != want {
+			t.Errorf("escalationLabel(%d) = %q, want %q", score, got, want)
+		}
+	}
+}
+
+func TestCoachingTranscript(t *testing.T) {
+	var messages []storedMessage
+	for i := 0; i < maxReviewMessages+4; i++ {
+		messages = append(messages,
+			newStoredMessage(groqapi.USER, "hi", time.Now()),
+			newStoredMessage(groqapi.ASSISTANT, "hello", time.Now()),
+		)
+	}
+	got := coachingTranscript(messages)
+	if lines := strings.Count(got, "\n"); lines != maxReviewMessages {
+		t.Errorf("transcript has %d lines, want %d", lines, maxReviewMessages)
+	}
+	if !strings.HasPrefix(got, "You: hi\nHer: hello\n") {
+		t.Errorf("unexpected transcript: %q", got)
+	}
+}
+
+func TestFormatCoachingCard(t *testing.T) {
+	var analysis geminiapi.Analysis
+	analysis.EscalationScore = 55
+	analysis.VibeCheck = "engaged and interested 😊"
+	analysis.Why.NextAction = []string{"Add playful teasing"}
+	analysis.NextMove.ExampleLine = []string{"You have great taste in books"}
+
+	card := formatCoachingCard(analysis)
+	for _, want := range []string{"55/100 (Building Interest)", "engaged and interested", "• Add playful teasing", "\"You have great taste in books\""} {
+		if !strings.Contains(card, want) {
+			t.Errorf("coaching card is missing %q:\n%s", want, card)
+		}
+	}
+}
@@ -0,0 +1,181 @@
+package telegram
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"gulabodev/database/postgres"
+	"gulabodev/modelapi"
+	"gulabodev/modelapi/groqapi"
+	"gulabodev/modelapi/prompts"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+)
+
+const (
+	// Image generation costs a lot more than a reply, subscribers included
+	selfieCost = 5
+
+	selfieCaptionPrompt = "\nYour lover asked you for a selfie and you're sending them one. Reply with only its caption: one or two short, flirty lines about the photo, in character. Never say you can't send photos."
+)
+
+// Lowercase phrases that ask her for a photo, in English and romanized Hindi
+// and Punjabi.
+var selfieRequestPhrases = []string{
+	"send a selfie", "send me a selfie", "send selfie", "selfie please", "selfie pls", "selfie plz",
+	"send a pic", "send me a pic", "send your pic", "send a photo", "send me a photo", "send your photo",
+	"selfie bhejo", "selfie bhej", "selfie dikhao", "pic bhejo", "pic bhej", "photo bhejo", "photo bhej",
+	"photo dikhao", "tasveer bhejo",
+}
+
+// selfieScenes set the scene for a selfie the user didn't describe, so it
+// matches her mood.
+var selfieScenes = map[string]string{
+	moodPlayful:    "in her bedroom, making a cheeky face with one eye winking",
+	moodAnnoyed:    "on her sofa, pouting at the camera with her arms crossed",
+	moodMissingYou: "lying on her bed hugging a pillow, looking wistfully into the camera",
+	moodSleepy:     "in bed at night under a blanket, sleepy eyes, soft lamp light",
+}
+
+func isSelfieRequest(text string) bool {
+	return containsPhrase(text, selfieRequestPhrases)
+}
+
+// selfiePrompt describes the photo for the image model. The persona's fixed
+// appearance keeps her face the same whatever the scene.
+func selfiePrompt(p persona, scene string) string {
+	return prompts.Render(prompts.Selfie, prompts.SelfieData{Appearance: p.Appearance, Scene: scene})
+}
+
+// selfieRequest is a selfie of the persona in scene, with her seed so she
+// looks like herself.
+func selfieRequest(p persona, scene string) modelapi.ImageRequest {
+	return modelapi.ImageRequest{Prompt: selfiePrompt(p, scene), Seed: p.SelfieSeed}
+}
+
+// handleSelfieCommand sends a selfie, in the scene given after the command
+// if there is one. sendSelfie checks credits itself, since it costs more than one.
+func (t *Telegram) handleSelfieCommand(ctx context.Context, message *tgbotapi.Message) {
+	conversation, err := t.activeConversation(ctx, message.From.ID)
+	if err != nil {
+		t.logger.Logger(ctx).Error("Failed to get conversation", zap.Error(err), zap.Int64("user_id", message.From.ID))
+		t.replyText(ctx, message.Chat.ID, t.text(ctx, message.From.ID, msgSomethingWrong))
+		return
+	}
+
+	scene := strings.TrimSpace(message.CommandArguments())
+	userInput := "[Asked for a selfie]"
+	if scene != "" {
+		userInput += " " + scene
+	}
+	t.sendSelfie(ctx, message, conversation, userInput, scene)
+}
+
+// sendSelfie generates a selfie of the conversation's persona with an
+// in-character caption, and charges selfieCost credits once it's sent. An
+// empty scene picks one to suit her mood.
+func (t *Telegram) sendSelfie(ctx context.Context, message *tgbotapi.Message, conversation postgres.Conversation, userInput string, scene string) {
+	tracer := otel.Tracer("telegram/sendSelfie")
+	ctx, span := tracer.Start(ctx, "sendSelfie")
+	defer span.End()
+
+	userID, chatID := message.From.ID, message.Chat.ID
+	balance, err := t.db.GetUserCreditsByTelegramUserId(ctx, userID)
+	if err != nil {
+		span.RecordError(err)
+		t.logger.Logger(ctx).Error("Failed to get user credits", zap.Error(err), zap.Int64("user_id", userID))
+		t.replyText(ctx, chatID, t.text(ctx, userID, msgSomethingWrong))
+		return
+	}
+	if balance < selfieCost {
+		t.sendRechargeOptions(ctx, chatID, userID, t.text(ctx, userID, msgSelfieTooExpensive, selfieCost))
+		return
+	}
+
+	p := t.conversationPersona(ctx, conversation)
+	if scene == "" {
+		scene = selfieScenes[t.currentMood(ctx, userID)]
+	}
+	span.SetAttributes(
+		attribute.String("conversation.persona", p.ID),
+		attribute.String("selfie.scene", scene),
+	)
+
+	// Generating the image takes a while
+	if _, err := t.bot.Request(tgbotapi.NewChatAction(chatID, tgbotapi.ChatUploadPhoto)); err != nil {
+		t.logger.Logger(ctx).Warn("Failed to send chat action", zap.Error(err))
+	}
+
+	image, err := t.images.GenerateImage(ctx, selfieRequest(p, scene))
+	if errors.Is(err, modelapi.ErrUnsafeImagePrompt) {
+		t.logger.Logger(ctx).Warn("Refused unsafe selfie", t.logger.Content("scene", scene), zap.Int64("user_id", userID))
+		t.replyText(ctx, chatID, t.text(ctx, userID, msgSelfieUnsafe))
+		return
+	}
+	if err != nil {
+		span.RecordError(err)
+		t.logger.Logger(ctx).Error("Failed to generate selfie", zap.Error(err), zap.Int64("user_id", userID))
+		t.replyText(ctx, chatID, t.text(ctx, userID, msgSelfieFailed))
+		return
+	}
+
+	// Initialized as an empty slice if unmarshal fails
+	storedHistory, err := decodeHistory(conversation.Messages)
+	if err != nil {
+		t.logger.Logger(ctx).Error("Failed to unmarshal conversation history", zap.Error(err))
+	}
+
+	// The photo still goes out if the caption fails
+	systemPrompt := t.replySystemPrompt(ctx, userID, conversation, t.userMemories(ctx, userID)) + selfieCaptionPrompt
+	caption, err := t.chatProvider(ctx, chatFeatureSelfie, conversation).GetResponse(ctx, modelapi.ChatRequest{
+		SystemPrompt: systemPrompt,
+		History:      modelHistory(unsummarized(conversation, storedHistory)),
+		Message:      userInput,
+	})
+	if err != nil {
+		t.logger.Logger(ctx).Error("Failed to generate selfie caption", zap.Error(err), zap.Int64("user_id", userID))
+	}
+	caption = strings.Trim(caption, `\ '"“”`)
+
+	photo := tgbotapi.NewPhoto(chatID, tgbotapi.FileBytes{
+		Name:  "selfie.png",
+		Bytes: image,
+	})
+	photo.Caption = caption
+	if _, err := t.bot.Send(photo); err != nil {
+		span.RecordError(err)
+		t.logger.Logger(ctx).Error("Failed to send selfie", zap.Error(err), zap.Int64("user_id", userID))
+		return
+	}
+
+	_, err = t.db.SpendUserCreditsByTelegramUserId(ctx, postgres.SpendUserCreditsByTelegramUserIdParams{
+		Amount:         selfieCost,
+		TelegramUserID: userID,
+	})
+	if err != nil && err != sql.ErrNoRows {
+		t.logger.Logger(ctx).Error("Failed to charge for selfie", zap.Error(err), zap.Int64("user_id", userID))
+	}
+
+	storedHistory = append(storedHistory,
+		newStoredMessage(groqapi.USER, userInput, message.Time()),
+		newStoredMessage(groqapi.ASSISTANT, strings.TrimSpace("[Sent a selfie] "+caption), time.Now()),
+	)
+	updatedMessages, err := json.Marshal(storedHistory)
+	if err != nil {
+		t.logger.Logger(ctx).Error("Failed to marshal updated conversation history", zap.Error(err))
+		return
+	}
+	_, err = t.db.UpdateConversationMessages(ctx, postgres.UpdateConversationMessagesParams{
+		ID:       conversation.ID,
+		Messages: updatedMessages,
+	})
+	if err != nil {
+		t.logger.Logger(ctx).Error("Failed to update conversation messages", zap.Error(err))
+	}
+}
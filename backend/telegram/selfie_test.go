@@ -0,0 +1,51 @@
+package telegram
+
+import (
+	"gulabodev/modelapi"
+	"strings"
+	"testing"
+)
+
+func TestIsSelfieRequest(t *testing.T) {
+	tests := []struct {
+		text string
+		want bool
+	}{
+		{"Send me a selfie baby", true},
+		{"Ek selfie bhejo na", true},
+		{"Apni PHOTO BHEJO!", true},
+		{"Selfie please 🥺", true},
+		{"I took a selfie today", false},
+		{"Kya kar rahi ho?", false},
+	}
+	for _, tt := range tests {
+		if got := isSelfieRequest(tt.text); got != tt.want {
+			t.Errorf("isSelfieRequest(%q) = %v, want %v", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestPersonasHaveSelfieLooks(t *testing.T) {
+	for _, p := range personas {
+		if p.Appearance == "" || p.SelfieSeed == 0 {
+			t.Errorf("persona %q has no fixed look for selfies", p.ID)
+		}
+		if prompt := selfiePrompt(p, "at the beach"); !strings.Contains(prompt, p.Appearance) || !strings.Contains(prompt, "at the beach") {
+			t.Errorf("selfiePrompt for %q = %q", p.ID, prompt)
+		}
+		// Her own selfies must never be refused
+		for mood, scene := range selfieScenes {
+			if err := modelapi.CheckImagePrompt(selfiePrompt(p, scene)); err != nil {
+				t.Errorf("%s selfie of %q refused: %v", mood, p.ID, err)
+			}
+		}
+	}
+}
+
+func TestSelfieScenesCoverMoods(t *testing.T) {
+	for _, mood := range []string{moodPlayful, moodAnnoyed, moodMissingYou, moodSleepy} {
+		if selfieScenes[mood] == "" {
+			t.Errorf("mood %q has no selfie scene", mood)
+		}
+	}
+}
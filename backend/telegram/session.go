@@ -0,0 +1,78 @@
+package telegram
+
+import (
+	"context"
+	"gulabodev/database/postgres"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+)
+
+// handleNewCommand starts a fresh session with the active persona. Unlike
+// /clear, the old history is archived for /export and analytics, and
+// memories and recalled moments carry over.
+func (t *Telegram) handleNewCommand(ctx context.Context, message *tgbotapi.Message) {
+	tracer := otel.Tracer("telegram/handleNewCommand")
+	ctx, span := tracer.Start(ctx, "handleNewCommand")
+	defer span.End()
+
+	userID := message.From.ID
+	conversation, err := t.activeConversation(ctx, userID)
+	var archived int64
+	if err == nil {
+		archived, err = t.db.ArchiveConversation(ctx, conversation.ID)
+	}
+	if err != nil {
+		span.RecordError(err)
+		t.logger.Logger(ctx).Error("Failed to archive conversation", zap.Error(err), zap.Int64("user_id", userID))
+		t.replyText(ctx, message.Chat.ID, t.text(ctx, userID, msgSomethingWrong))
+		return
+	}
+
+	span.SetAttributes(attribute.Bool("session.archived", archived > 0))
+	if archived == 0 {
+		t.replyText(ctx, message.Chat.ID, t.text(ctx, userID, msgNewSessionEmpty))
+		return
+	}
+
+	// What the summary hadn't folded in yet would otherwise never be recalled
+	history, err := decodeHistory(conversation.Messages)
+	if err != nil {
+		t.logger.Logger(ctx).Error("Failed to unmarshal conversation history", zap.Error(err), zap.Int64("conversation_id", conversation.ID))
+	}
+	go t.storeMoments(ctx, conversation, unsummarized(conversation, history))
+
+	t.replyText(ctx, message.Chat.ID, t.text(ctx, userID, msgNewSessionStarted))
+}
+
+// conversationSessions returns the archived sessions of a conversation, oldest
+// first, followed by the current one if it has any messages, along with the
+// total message count.
+func (t *Telegram) conversationSessions(ctx context.Context, conversation postgres.Conversation) ([][]storedMessage, int) {
+	archives, err := t.db.ListConversationArchives(ctx, conversation.ID)
+	if err != nil {
+		t.logger.Logger(ctx).Error("Failed to list conversation archives", zap.Error(err), zap.Int64("conversation_id", conversation.ID))
+	}
+
+	var sessions [][]storedMessage
+	total := 0
+	add := func(raw []byte) {
+		messages, err := decodeHistory(raw)
+		if err != nil {
+			t.logger.Logger(ctx).Error("Failed to unmarshal conversation history", zap.Error(err), zap.Int64("conversation_id", conversation.ID))
+		}
+		if len(messages) == 0 {
+			return
+		}
+		sessions = append(sessions, messages)
+		total += len(messages)
+	}
+
+	for _, archive := range archives {
+		add(archive.Messages)
+	}
+	add(conversation.Messages)
+	return sessions, total
+}
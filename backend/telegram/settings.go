@@ -0,0 +1,158 @@
+package telegram
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+const (
+	settingsCallbackPrefix = "settings:"
+
+	settingsVoice     = "voice"
+	settingsLanguage  = "language"
+	settingsMode      = "mode"
+	settingsCaptions  = "captions"
+	settingsEcho      = "echo"
+	settingsDnd       = "dnd"
+	settingsPersona   = "persona"
+	settingsIntensity = "intensity"
+	settingsSpeech    = "speech"
+	settingsBack      = "back"
+)
+
+// settingsKeyboard shows each setting with its current value. Sub-menus open
+// in place; reply mode and transcript echo toggle directly.
+func (t *Telegram) settingsKeyboard(ctx context.Context, userID int64) tgbotapi.InlineKeyboardMarkup {
+	voice := ttsVoices[0]
+	if conversation, err := t.activeConversation(ctx, userID); err == nil {
+		voice = t.replyVoice(ctx, conversation)
+	} else {
+		t.logger.Logger(ctx).Error("Failed to get conversation", zap.Error(err), zap.Int64("user_id", userID))
+	}
+
+	ui := t.userLanguage(ctx, userID).UI
+
+	mode := localize(ui, msgRepliesVoice)
+	if t.prefersTextReplies(ctx, userID) {
+		mode = localize(ui, msgRepliesText)
+	}
+
+	quietHours := localize(ui, msgQuietHoursOff)
+	preferences, err := t.db.GetUserPreferencesByTelegramUserId(ctx, userID)
+	if err == nil && preferences.DndStart.Valid && preferences.DndEnd.Valid {
+		quietHours = formatMinute(preferences.DndStart.Int32) + "-" + formatMinute(preferences.DndEnd.Int32)
+	} else if err != nil && err != sql.ErrNoRows {
+		t.logger.Logger(ctx).Error("Failed to get user preferences", zap.Error(err), zap.Int64("user_id", userID))
+	}
+
+	echo := "Off"
+	if t.prefersTranscriptEcho(ctx, userID) {
+		echo = "On ✅"
+	}
+
+	persona := t.activePersona(ctx, userID)
+	intensity := t.userIntensity(ctx, userID)
+	rate, pitch := t.userSpeech(ctx, userID)
+
+	button := func(label string, section string) []tgbotapi.InlineKeyboardButton {
+		return tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData(label, settingsCallbackPrefix+section))
+	}
+	return tgbotapi.NewInlineKeyboardMarkup(
+		button(localize(ui, msgSettingsPersona, persona.Emoji+" "+persona.Name), settingsPersona),
+		button(localize(ui, msgSettingsVoice, voice.Emoji+" "+voice.Name), settingsVoice),
+		button(localize(ui, msgSettingsSpeech, speechSummary(rate, pitch)), settingsSpeech),
+		button(localize(ui, msgSettingsLanguage, t.userLanguage(ctx, userID).Name), settingsLanguage),
+		button(localize(ui, msgSettingsIntensity, intensity.Emoji+" "+intensity.Name), settingsIntensity),
+		button(localize(ui, msgSettingsReplies, mode), settingsMode),
+		button(localize(ui, msgSettingsCaptions, t.userCaptionMode(ctx, userID).Name), settingsCaptions),
+		button(localize(ui, msgSettingsEcho, echo), settingsEcho),
+		button(localize(ui, msgSettingsQuietHours, quietHours), settingsDnd),
+	)
+}
+
+func (t *Telegram) handleSettingsCommand(ctx context.Context, message *tgbotapi.Message) {
+	msg := tgbotapi.NewMessage(message.Chat.ID, t.text(ctx, message.From.ID, msgSettingsMenu))
+	msg.ReplyMarkup = t.settingsKeyboard(ctx, message.From.ID)
+	if _, err := t.bot.Send(msg); err != nil {
+		t.logger.Logger(ctx).Error("Failed to send settings menu", zap.Error(err))
+	}
+}
+
+// handleSettingsCallback routes a settings button press, editing the settings
+// message in place.
+func (t *Telegram) handleSettingsCallback(ctx context.Context, message *tgbotapi.Message, userID int64, section string) {
+	var text string
+	var rows [][]tgbotapi.InlineKeyboardButton
+
+	switch section {
+	case settingsPersona:
+		text = personaMenuText
+		rows = personaKeyboard(t.userPersonaOptions(ctx, userID), t.activePersona(ctx, userID))
+	case settingsVoice:
+		conversation, err := t.activeConversation(ctx, userID)
+		if err != nil {
+			t.logger.Logger(ctx).Error("Failed to get conversation", zap.Error(err), zap.Int64("user_id", userID))
+			return
+		}
+		text = voiceMenuText
+		rows = voiceKeyboard(t.replyVoice(ctx, conversation))
+	case settingsSpeech:
+		text = t.text(ctx, userID, msgSpeechMenu)
+		rows = speechKeyboard(t.userSpeech(ctx, userID))
+	case settingsLanguage:
+		text = languageMenuText
+		rows = languageKeyboard(t.userLanguage(ctx, userID))
+	case settingsIntensity:
+		text = t.text(ctx, userID, msgIntensityMenu)
+		rows = intensityKeyboard(t.userIntensity(ctx, userID))
+	case settingsCaptions:
+		text = captionsMenuText
+		rows = captionsKeyboard(t.userCaptionMode(ctx, userID))
+	case settingsDnd:
+		timezone := defaultTimezone
+		preferences, err := t.db.GetUserPreferencesByTelegramUserId(ctx, userID)
+		if err == nil {
+			timezone = preferences.Timezone
+		} else if err != sql.ErrNoRows {
+			t.logger.Logger(ctx).Error("Failed to get user preferences", zap.Error(err), zap.Int64("user_id", userID))
+		}
+		// Quiet hours take free-form times, so this only explains the command
+		text = quietHoursStatus(preferences, timezone) + "\n\n" + dndUsage
+	case settingsMode:
+		if _, err := t.toggleTextReplies(ctx, userID); err != nil {
+			t.logger.Logger(ctx).Error("Failed to update reply mode", zap.Error(err), zap.Int64("user_id", userID))
+		}
+	case settingsEcho:
+		if _, err := t.toggleTranscriptEcho(ctx, userID); err != nil {
+			t.logger.Logger(ctx).Error("Failed to update transcript echo", zap.Error(err), zap.Int64("user_id", userID))
+		}
+	}
+
+	// Sections without a sub-menu, and Back, show the hub again
+	var markup tgbotapi.InlineKeyboardMarkup
+	if text == "" {
+		text = t.text(ctx, userID, msgSettingsMenu)
+		markup = t.settingsKeyboard(ctx, userID)
+	} else {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(t.text(ctx, userID, msgButtonBack), settingsCallbackPrefix+settingsBack),
+		))
+		markup = tgbotapi.NewInlineKeyboardMarkup(rows...)
+	}
+
+	edit := tgbotapi.NewEditMessageTextAndMarkup(message.Chat.ID, message.MessageID, text, markup)
+	if _, err := t.bot.Send(edit); err != nil {
+		t.logger.Logger(ctx).Error("Failed to update settings menu", zap.Error(err), zap.String("section", section))
+	}
+}
+
+func settingsFromCallback(data string) (string, bool) {
+	if !strings.HasPrefix(data, settingsCallbackPrefix) {
+		return "", false
+	}
+	return strings.TrimPrefix(data, settingsCallbackPrefix), true
+}
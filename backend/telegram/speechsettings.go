@@ -0,0 +1,133 @@
+package telegram
+
+import (
+	"context"
+	"database/sql"
+	"gulabodev/database/postgres"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+const (
+	speechCallbackPrefix = "speech:"
+
+	speechSettingRate  = "rate"
+	speechSettingPitch = "pitch"
+
+	speechNormal = "normal"
+)
+
+// speechPreset is one step of her pace or pitch. IDs are what gets stored in
+// user_preferences.speech_rate and speech_pitch.
+type speechPreset struct {
+	ID    string
+	Name  string
+	Emoji string
+	// Value is a rate for paces, where 1 is normal, and a shift in semitones
+	// for pitches
+	Value float64
+}
+
+// speechRates are the paces offered in /settings, slowest first.
+var speechRates = []speechPreset{
+	{ID: "slower", Name: "Slower", Emoji: "🐢", Value: 0.85},
+	{ID: speechNormal, Name: "Normal", Emoji: "🚶‍♀️", Value: 1},
+	{ID: "faster", Name: "Faster", Emoji: "🐇", Value: 1.15},
+}
+
+// speechPitches are the pitches offered in /settings, lowest first.
+var speechPitches = []speechPreset{
+	{ID: "lower", Name: "Lower", Emoji: "🔉", Value: -2},
+	{ID: speechNormal, Name: "Normal", Emoji: "🎵", Value: 0},
+	{ID: "higher", Name: "Higher", Emoji: "🔊", Value: 2},
+}
+
+// findSpeechPreset returns the preset with the given ID, falling back to
+// normal.
+func findSpeechPreset(presets []speechPreset, id string) speechPreset {
+	for _, preset := range presets {
+		if preset.ID == id {
+			return preset
+		}
+	}
+	return findSpeechPreset(presets, speechNormal)
+}
+
+// userSpeech is the pace and pitch the user picked for her voice notes.
+func (t *Telegram) userSpeech(ctx context.Context, userID int64) (rate speechPreset, pitch speechPreset) {
+	preferences, err := t.db.GetUserPreferencesByTelegramUserId(ctx, userID)
+	if err != nil && err != sql.ErrNoRows {
+		t.logger.Logger(ctx).Error("Failed to get user preferences", zap.Error(err), zap.Int64("user_id", userID))
+	}
+	return findSpeechPreset(speechRates, preferences.SpeechRate), findSpeechPreset(speechPitches, preferences.SpeechPitch)
+}
+
+// speechSummary describes the user's settings for the settings hub.
+func speechSummary(rate speechPreset, pitch speechPreset) string {
+	if rate.ID == speechNormal && pitch.ID == speechNormal {
+		return "Normal"
+	}
+	return rate.Emoji + " " + pitch.Emoji
+}
+
+// speechKeyboard has a row of paces and a row of pitches, marking the
+// current ones.
+func speechKeyboard(rate speechPreset, pitch speechPreset) [][]tgbotapi.InlineKeyboardButton {
+	row := func(setting string, presets []speechPreset, current speechPreset) []tgbotapi.InlineKeyboardButton {
+		var buttons []tgbotapi.InlineKeyboardButton
+		for _, preset := range presets {
+			label := preset.Emoji + " " + preset.Name
+			if preset.ID == current.ID {
+				label = "✅ " + preset.Name
+			}
+			buttons = append(buttons, tgbotapi.NewInlineKeyboardButtonData(label, speechCallbackPrefix+setting+":"+preset.ID))
+		}
+		return buttons
+	}
+	return [][]tgbotapi.InlineKeyboardButton{
+		row(speechSettingRate, speechRates, rate),
+		row(speechSettingPitch, speechPitches, pitch),
+	}
+}
+
+// handleSpeechCallback saves a pace or pitch and shows the menu again with
+// it marked.
+func (t *Telegram) handleSpeechCallback(ctx context.Context, message *tgbotapi.Message, userID int64, setting string, presetID string) {
+	rate, pitch := t.userSpeech(ctx, userID)
+	switch setting {
+	case speechSettingRate:
+		rate = findSpeechPreset(speechRates, presetID)
+	case speechSettingPitch:
+		pitch = findSpeechPreset(speechPitches, presetID)
+	default:
+		return
+	}
+
+	_, err := t.db.SetSpeechByTelegramUserId(ctx, postgres.SetSpeechByTelegramUserIdParams{
+		SpeechRate:     rate.ID,
+		SpeechPitch:    pitch.ID,
+		TelegramUserID: userID,
+	})
+	if err != nil {
+		t.logger.Logger(ctx).Error("Failed to set speech settings", zap.Error(err), zap.Int64("user_id", userID))
+		t.replyText(ctx, message.Chat.ID, t.text(ctx, userID, msgSomethingWrong))
+		return
+	}
+
+	t.logger.Logger(ctx).Info("Speech settings set",
+		zap.Int64("user_id", userID),
+		zap.String("rate", rate.ID),
+		zap.String("pitch", pitch.ID),
+	)
+	t.handleSettingsCallback(ctx, message, userID, settingsSpeech)
+}
+
+func speechFromCallback(data string) (string, string, bool) {
+	if !strings.HasPrefix(data, speechCallbackPrefix) {
+		return "", "", false
+	}
+	setting, presetID, ok := strings.Cut(strings.TrimPrefix(data, speechCallbackPrefix), ":")
+	return setting, presetID, ok
+}
@@ -0,0 +1,38 @@
+package telegram
+
+import "testing"
+
+func TestFindSpeechPreset(t *testing.T) {
+	if got := findSpeechPreset(speechRates, "slower").Value; got != 0.85 {
+		t.Errorf("slower rate = %g, want 0.85", got)
+	}
+	if got := findSpeechPreset(speechRates, "").Value; got != 1 {
+		t.Errorf("unset rate = %g, want 1", got)
+	}
+	if got := findSpeechPreset(speechPitches, "unknown").Value; got != 0 {
+		t.Errorf("unknown pitch = %g, want 0", got)
+	}
+}
+
+func TestSpeechFromCallback(t *testing.T) {
+	setting, presetID, ok := speechFromCallback("speech:rate:slower")
+	if !ok || setting != speechSettingRate || presetID != "slower" {
+		t.Errorf("speechFromCallback(rate:slower) = %q, %q, %v", setting, presetID, ok)
+	}
+	if _, _, ok := speechFromCallback("settings:speech"); ok {
+		t.Error("speechFromCallback should ignore other callbacks")
+	}
+}
+
+func TestSpeechKeyboard(t *testing.T) {
+	rows := speechKeyboard(findSpeechPreset(speechRates, "faster"), findSpeechPreset(speechPitches, speechNormal))
+	if len(rows) != 2 || len(rows[0]) != len(speechRates) || len(rows[1]) != len(speechPitches) {
+		t.Fatalf("want a row of rates and a row of pitches, got %v", rows)
+	}
+	if got := rows[0][2].Text; got != "✅ Faster" {
+		t.Errorf("current rate label = %q", got)
+	}
+	if got := *rows[1][0].CallbackData; got != "speech:pitch:lower" {
+		t.Errorf("pitch callback = %q", got)
+	}
+}
@@ -0,0 +1,104 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"gulabodev/database/postgres"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	// Replies longer than this are spread over several voice notes
+	maxVoiceNoteChars = 400
+	maxVoiceNotes     = 3
+
+	sentenceTerminators = ".!?।…\n"
+)
+
+type voiceNote struct {
+	audio    []byte
+	fileName string
+	// duration is how long the note plays in seconds, or 0 if unknown
+	duration int
+}
+
+// splitSentences splits text after sentence-ending punctuation that is
+// followed by whitespace, keeping the punctuation with its sentence.
+func splitSentences(text string) []string {
+	runes := []rune(text)
+	var sentences []string
+	start := 0
+	for i, r := range runes {
+		if !strings.ContainsRune(sentenceTerminators, r) {
+			continue
+		}
+		if i+1 < len(runes) && !unicode.IsSpace(runes[i+1]) {
+			continue
+		}
+		sentences = append(sentences, string(runes[start:i+1]))
+		start = i + 1
+	}
+	if start < len(runes) {
+		sentences = append(sentences, string(runes[start:]))
+	}
+	return sentences
+}
+
+// splitForSpeech breaks a long reply into at most maxVoiceNotes chunks of
+// roughly equal length at sentence boundaries. Short replies stay whole.
+func splitForSpeech(text string) []string {
+	total := utf8.RuneCountInString(text)
+	if total <= maxVoiceNoteChars {
+		return []string{text}
+	}
+
+	notes := min(maxVoiceNotes, (total+maxVoiceNoteChars-1)/maxVoiceNoteChars)
+	target := (total + notes - 1) / notes
+
+	var chunks []string
+	var current strings.Builder
+	length := 0
+	for _, sentence := range splitSentences(text) {
+		sentenceLength := utf8.RuneCountInString(sentence)
+		// The last chunk takes whatever is left
+		if length > 0 && length+sentenceLength > target && len(chunks) < notes-1 {
+			chunks = append(chunks, strings.TrimSpace(current.String()))
+			current.Reset()
+			length = 0
+		}
+		current.WriteString(sentence)
+		length += sentenceLength
+	}
+	if chunk := strings.TrimSpace(current.String()); chunk != "" {
+		chunks = append(chunks, chunk)
+	}
+	return chunks
+}
+
+// generateVoiceNotes synthesizes and encodes the chunks concurrently,
+// returning the notes in order. Any failure fails the whole reply.
+func (t *Telegram) generateVoiceNotes(ctx context.Context, conversation postgres.Conversation, chunks []string) ([]voiceNote, error) {
+	notes := make([]voiceNote, len(chunks))
+	errs := make([]error, len(chunks))
+	rate, pitch := t.userSpeech(ctx, conversation.TelegramUserID)
+
+	var wg sync.WaitGroup
+	for i, chunk := range chunks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			speech, err := t.generateSpeech(ctx, conversation, chunk, rate, pitch)
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			notes[i] = t.encodeVoiceNote(ctx, speech, rate, pitch)
+		}()
+	}
+	wg.Wait()
+
+	return notes, errors.Join(errs...)
+}
@@ -0,0 +1,46 @@
+package telegram
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestSplitSentences(t *testing.T) {
+	got := splitSentences("Hi baby! Kya kar rahe ho?? Main yahan hoon... miss you")
+	want := []string{"Hi baby!", " Kya kar rahe ho??", " Main yahan hoon...", " miss you"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("splitSentences = %q, want %q", got, want)
+	}
+}
+
+func TestSplitForSpeech(t *testing.T) {
+	short := "Tumhari yaad aa rahi thi, baby."
+	if got := splitForSpeech(short); len(got) != 1 || got[0] != short {
+		t.Errorf("splitForSpeech(short) = %q", got)
+	}
+
+	sentence := "Aaj ka din bahut lamba tha aur main sirf tumhare baare mein soch rahi thi. "
+	for _, repeats := range []int{8, 12, 40} {
+		long := strings.Repeat(sentence, repeats)
+		chunks := splitForSpeech(long)
+		if len(chunks) < 2 || len(chunks) > maxVoiceNotes {
+			t.Errorf("%d sentences: got %d chunks", repeats, len(chunks))
+		}
+		for _, chunk := range chunks {
+			if !strings.HasSuffix(chunk, ".") {
+				t.Errorf("chunk should end at a sentence boundary: %q", chunk)
+			}
+		}
+		if got := strings.Join(chunks, " "); got != strings.TrimSpace(long) {
+			t.Errorf("%d sentences: chunks don't add back up to the reply", repeats)
+		}
+		if repeats == 8 {
+			for _, chunk := range chunks {
+				if utf8.RuneCountInString(chunk) > maxVoiceNoteChars+len(sentence) {
+					t.Errorf("chunk too long: %d runes", utf8.RuneCountInString(chunk))
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package telegram
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"gulabodev/database/postgres"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.opentelemetry.io/otel"
+	"go.uber.org/zap"
+)
+
+const (
+	paymentProviderStars  = "telegram_stars"
+	paymentProviderStripe = "stripe"
+
+	messageTypeText  = "text"
+	messageTypeVoice = "voice"
+)
+
+// paymentRecord describes what the user paid, in the provider's smallest unit.
+type paymentRecord struct {
+	Provider string
+	Amount   int
+	Currency string
+	// ChargeID identifies the charge with its provider, deduping redeliveries:
+	// Telegram's payment charge ID for Stars, the checkout session or
+	// PaymentIntent ID for Stripe.
+	ChargeID string
+}
+
+// claimPayment records a payment and runs grant in the same transaction, so
+// a failed grant rolls the claim back and a redelivered update can retry it.
+// It reports false when the charge was already recorded, so a redelivered
+// SuccessfulPayment or Stripe webhook is never fulfilled twice.
+func (t *Telegram) claimPayment(ctx context.Context, userID int64, payload string, credits int32, record paymentRecord, grant func(q *postgres.Queries) error) (bool, error) {
+	chargeID := sql.NullString{Valid: record.ChargeID != "", String: record.ChargeID}
+	claimed := false
+	err := t.db.InTx(ctx, func(q *postgres.Queries) error {
+		_, err := q.CreatePayment(ctx, postgres.CreatePaymentParams{
+			Provider:       record.Provider,
+			Payload:        payload,
+			Credits:        credits,
+			Amount:         int32(record.Amount),
+			Currency:       record.Currency,
+			ChargeID:       chargeID,
+			TelegramUserID: userID,
+		})
+		if err == sql.ErrNoRows && chargeID.Valid {
+			// No row is also returned for an unknown user, so confirm the duplicate
+			_, err := q.GetPaymentByChargeId(ctx, chargeID)
+			return err
+		}
+		if err != nil {
+			return err
+		}
+		claimed = true
+		return grant(q)
+	})
+	if err != nil {
+		return false, err
+	}
+	return claimed, nil
+}
+
+// recordGrant notes in the Stars ledger that a payment was fulfilled.
+func (t *Telegram) recordGrant(ctx context.Context, userID int64, payload string, credits int32, record paymentRecord) {
+	if record.Provider == paymentProviderStars {
+		t.recordLedgerEntry(ctx, userID, ledgerEntry{
+			Event:    ledgerEventGrant,
+			Payload:  payload,
+			ChargeID: record.ChargeID,
+			Amount:   record.Amount,
+			Credits:  credits,
+		})
+	}
+}
+
+func (t *Telegram) recordResponse(ctx context.Context, message *tgbotapi.Message, latency time.Duration, ttsFailed bool) {
+	messageType := messageTypeText
+	if _, ok := messageAudio(message); ok {
+		messageType = messageTypeVoice
+	}
+
+	err := t.db.CreateResponse(ctx, postgres.CreateResponseParams{
+		TelegramUserID: message.From.ID,
+		MessageType:    messageType,
+		LatencyMs:      int32(latency.Milliseconds()),
+		TtsFailed:      ttsFailed,
+	})
+	if err != nil {
+		t.logger.Logger(ctx).Error("Failed to record response", zap.Error(err), zap.Int64("user_id", message.From.ID))
+	}
+}
+
+// handleStatsCommand reports today's (UTC) operational metrics to an admin.
+func (t *Telegram) handleStatsCommand(ctx context.Context, message *tgbotapi.Message) {
+	tracer := otel.Tracer("telegram/handleStatsCommand")
+	ctx, span := tracer.Start(ctx, "handleStatsCommand")
+	defer span.End()
+
+	since := time.Now().UTC().Truncate(24 * time.Hour)
+
+	totalUsers, err := t.db.CountUsers(ctx)
+	if err != nil {
+		span.RecordError(err)
+		t.logger.Logger(ctx).Error("Failed to count users", zap.Error(err))
+	}
+
+	responses, err := t.db.GetResponseStatsSince(ctx, since)
+	if err != nil {
+		span.RecordError(err)
+		t.logger.Logger(ctx).Error("Failed to get response stats", zap.Error(err))
+	}
+
+	payments, err := t.db.GetPaymentStatsSince(ctx, since)
+	if err != nil {
+		span.RecordError(err)
+		t.logger.Logger(ctx).Error("Failed to get payment stats", zap.Error(err))
+	}
+
+	paidMedia, err := t.db.GetPaidMediaStatsSince(ctx, since)
+	if err != nil {
+		span.RecordError(err)
+		t.logger.Logger(ctx).Error("Failed to get paid media stats", zap.Error(err))
+	}
+
+	usage, err := t.db.GetUsageStatsSince(ctx, since)
+	if err != nil {
+		span.RecordError(err)
+		t.logger.Logger(ctx).Error("Failed to get usage stats", zap.Error(err))
+	}
+
+	campaigns, err := t.db.ListCampaignStats(ctx, campaignStatsLimit)
+	if err != nil {
+		span.RecordError(err)
+		t.logger.Logger(ctx).Error("Failed to get campaign stats", zap.Error(err))
+	}
+
+	text := fmt.Sprintf(
+		"📊 Stats since %s\n\n"+
+			"Total users: %d\n"+
+			"Active users: %d\n"+
+			"Replies sent: %d\n"+
+			"Credits sold: %d (%d payments)\n"+
+			"Paid media: %d unlocks (%d Stars)\n"+
+			"TTS failures: %d\n"+
+			"Avg response latency: %.0fms\n"+
+			"Model cost: %s (%d calls)\n\n"+
+			"Top campaigns (all time):\n%s",
+		since.Format("02 Jan 2006 15:04 MST"),
+		totalUsers,
+		responses.ActiveUsers,
+		responses.Responses,
+		payments.CreditsSold,
+		payments.Payments,
+		paidMedia.Unlocks,
+		paidMedia.StarsEarned,
+		responses.TtsFailures,
+		responses.AvgLatencyMs,
+		formatCost(usage.CostMicros),
+		usage.Requests,
+		formatCampaignStats(campaigns),
+	)
+
+	msg := tgbotapi.NewMessage(message.Chat.ID, text)
+	if _, err := t.bot.Send(msg); err != nil {
+		t.logger.Logger(ctx).Error("Failed to send stats", zap.Error(err))
+	}
+}
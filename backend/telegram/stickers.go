@@ -0,0 +1,122 @@
+package telegram
+
+import (
+	"context"
+	"gulabodev/logger"
+	"math/rand"
+	"os"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+)
+
+// Messages at or below this length with a single word ("ok", "hmm", "lol")
+// don't warrant a full LLM + TTS round trip.
+const maxTrivialMessageLength = 12
+
+var shortReplies = []string{
+	"😘",
+	"Hmm? Bolo na baby... 😉",
+	"Bas itna hi? 😏",
+	"Aww 🥰",
+	"Haan ji? Aur kuch? 😈",
+	"💋",
+}
+
+// loadStickerSet fetches the file IDs of the sticker pack configured in
+// TELEGRAM_STICKER_SET. Returns nil if no pack is configured or it can't be loaded.
+func loadStickerSet(ctx context.Context, bot *tgbotapi.BotAPI, logger *logger.LogMiddleware) []string {
+	tracer := otel.Tracer("telegram/loadStickerSet")
+	ctx, span := tracer.Start(ctx, "loadStickerSet")
+	defer span.End()
+
+	setName := os.Getenv("TELEGRAM_STICKER_SET")
+	if setName == "" {
+		return nil
+	}
+
+	stickerSet, err := bot.GetStickerSet(tgbotapi.GetStickerSetConfig{Name: setName})
+	if err != nil {
+		span.RecordError(err)
+		logger.Logger(ctx).Error("Failed to load sticker set", zap.Error(err), zap.String("sticker_set", setName))
+		return nil
+	}
+
+	stickers := make([]string, 0, len(stickerSet.Stickers))
+	for _, sticker := range stickerSet.Stickers {
+		stickers = append(stickers, sticker.FileID)
+	}
+
+	span.SetAttributes(
+		attribute.String("sticker_set", setName),
+		attribute.Int("sticker_count", len(stickers)),
+	)
+	logger.Logger(ctx).Info("Loaded sticker set", zap.String("sticker_set", setName), zap.Int("sticker_count", len(stickers)))
+
+	return stickers
+}
+
+// questionWords are one-word questions that deserve a real answer even
+// without a question mark.
+var questionWords = map[string]bool{
+	"kya": true, "kyu": true, "kyun": true, "kyon": true, "kaun": true,
+	"kab": true, "kahan": true, "kaha": true, "kaise": true, "kitna": true,
+	"kitne": true, "kidhar": true, "sach": true, "sachi": true,
+	"why": true, "what": true, "who": true, "when": true, "where": true,
+	"how": true, "really": true,
+}
+
+// isQuestion reports whether a one-word message asks something, like "kyu?"
+// or "kaun".
+func isQuestion(text string) bool {
+	word := strings.TrimRightFunc(text, func(r rune) bool {
+		return unicode.IsPunct(r) || unicode.IsSymbol(r)
+	})
+	if word != "" && strings.ContainsAny(text[len(word):], "?？") {
+		return true
+	}
+	return questionWords[strings.ToLower(word)]
+}
+
+// isTrivialMessage reports whether text is a one-word or emoji-only message
+// that should get a lightweight reply instead of a voice note.
+func isTrivialMessage(text string) bool {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return false
+	}
+
+	if len(strings.Fields(text)) == 1 && utf8.RuneCountInString(text) <= maxTrivialMessageLength {
+		return !isQuestion(text)
+	}
+
+	for _, r := range text {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// sendLightweightReply answers with a random sticker from the configured pack,
+// falling back to a short text reply. No credits are consumed.
+func (t *Telegram) sendLightweightReply(ctx context.Context, chatID int64) {
+	if len(t.stickers) > 0 {
+		sticker := tgbotapi.NewSticker(chatID, tgbotapi.FileID(t.stickers[rand.Intn(len(t.stickers))]))
+		_, err := t.bot.Send(sticker)
+		if err == nil {
+			return
+		}
+		t.logger.Logger(ctx).Error("Failed to send sticker reply", zap.Error(err))
+	}
+
+	msg := tgbotapi.NewMessage(chatID, shortReplies[rand.Intn(len(shortReplies))])
+	if _, err := t.bot.Send(msg); err != nil {
+		t.logger.Logger(ctx).Error("Failed to send short reply", zap.Error(err))
+	}
+}
@@ -0,0 +1,29 @@
+package telegram
+
+import "testing"
+
+func TestIsTrivialMessage(t *testing.T) {
+	tests := []struct {
+		text string
+		want bool
+	}{
+		{"ok", true},
+		{"hmm", true},
+		{"lol!!", true},
+		{"😘😘", true},
+		{"", false},
+		{"kal milte hain na", false},
+		{"kyu?", false},
+		{"kaun?", false},
+		{"sach?", false},
+		{"Kyun", false},
+		{"what??", false},
+		{"kya😳", false},
+		{"okay?", false},
+	}
+	for _, tt := range tests {
+		if got := isTrivialMessage(tt.text); got != tt.want {
+			t.Errorf("isTrivialMessage(%q) = %v, want %v", tt.text, got, tt.want)
+		}
+	}
+}
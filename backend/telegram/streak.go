@@ -0,0 +1,84 @@
+package telegram
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"gulabodev/database/postgres"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+)
+
+// streakReward returns the bonus credits for reaching a streak of the given
+// length: 3 and 7 days, then every 30 days.
+func streakReward(days int32) int32 {
+	switch {
+	case days == 3:
+		return 5
+	case days == 7:
+		return 15
+	case days > 0 && days%30 == 0:
+		return 50
+	default:
+		return 0
+	}
+}
+
+// streakPrompt tells the model about the streak so the day's first reply can
+// acknowledge it.
+func streakPrompt(days int32, reward int32) string {
+	switch {
+	case reward > 0:
+		return fmt.Sprintf("\nYour lover has talked to you %d days in a row, and you just gifted them %d bonus credits for it. Celebrate the streak and the gift in this reply.", days, reward)
+	case days >= 2:
+		return fmt.Sprintf("\nYour lover has talked to you %d days in a row. Sweetly acknowledge the streak in this reply.", days)
+	default:
+		return ""
+	}
+}
+
+// recordStreak counts today towards the user's streak, grants any milestone
+// reward, and returns a note for the system prompt. Only the first message of
+// the day in the user's timezone gets a note.
+func (t *Telegram) recordStreak(ctx context.Context, userID int64) string {
+	tracer := otel.Tracer("telegram/recordStreak")
+	ctx, span := tracer.Start(ctx, "recordStreak")
+	defer span.End()
+
+	now := time.Now().In(t.userLocation(ctx, userID))
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+	days, err := t.db.RecordStreakDayByTelegramUserId(ctx, postgres.RecordStreakDayByTelegramUserIdParams{
+		Today:          today,
+		TelegramUserID: userID,
+	})
+	if err == sql.ErrNoRows {
+		return ""
+	}
+	if err != nil {
+		span.RecordError(err)
+		t.logger.Logger(ctx).Error("Failed to record streak", zap.Error(err), zap.Int64("user_id", userID))
+		return ""
+	}
+	span.SetAttributes(attribute.Int("streak.days", int(days)))
+
+	reward := streakReward(days)
+	if reward > 0 {
+		_, err := t.db.AddUserCreditsByTelegramUserId(ctx, postgres.AddUserCreditsByTelegramUserIdParams{
+			Amount:         reward,
+			TelegramUserID: userID,
+		})
+		if err != nil {
+			span.RecordError(err)
+			t.logger.Logger(ctx).Error("Failed to grant streak reward", zap.Error(err), zap.Int64("user_id", userID), zap.Int32("streak_days", days))
+			reward = 0
+		} else {
+			t.logger.Logger(ctx).Info("Streak reward granted", zap.Int64("user_id", userID), zap.Int32("streak_days", days), zap.Int32("credits", reward))
+		}
+	}
+
+	return streakPrompt(days, reward)
+}
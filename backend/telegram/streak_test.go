@@ -0,0 +1,31 @@
+package telegram
+
+import "testing"
+
+func TestStreakReward(t *testing.T) {
+	tests := map[int32]int32{
+		1:  0,
+		2:  0,
+		3:  5,
+		4:  0,
+		7:  15,
+		14: 0,
+		30: 50,
+		45: 0,
+		60: 50,
+	}
+	for days, want := range tests {
+		if got := streakReward(days); got != want {
+			t.Errorf("streakReward(%d) = %d, want %d", days, got, want)
+		}
+	}
+}
+
+func TestStreakPrompt(t *testing.T) {
+	if got := streakPrompt(1, 0); got != "" {
+		t.Errorf("streakPrompt(1, 0) = %q, want empty", got)
+	}
+	if got := streakPrompt(2, 0); got == "" {
+		t.Error("streakPrompt(2, 0) should acknowledge the streak")
+	}
+}
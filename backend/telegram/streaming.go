@@ -0,0 +1,162 @@
+package telegram
+
+import (
+	"context"
+	"database/sql"
+	"gulabodev/database/postgres"
+	"gulabodev/modelapi"
+	"strings"
+	"sync"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+)
+
+const (
+	// Telegram throttles edits to roughly one per second per chat
+	streamEditInterval = time.Second
+	streamPlaceholder  = "✍️..."
+)
+
+func (t *Telegram) prefersTextReplies(ctx context.Context, userID int64) bool {
+	preferences, err := t.db.GetUserPreferencesByTelegramUserId(ctx, userID)
+	if err != nil {
+		if err != sql.ErrNoRows {
+			t.logger.Logger(ctx).Error("Failed to get user preferences", zap.Error(err), zap.Int64("user_id", userID))
+		}
+		return false
+	}
+	return preferences.TextReplies
+}
+
+// streamTextResponse sends a placeholder message and edits it with the reply
+// as it streams in from the provider, returning the complete reply. Providers
+// that can't stream fill the placeholder in one go. Every edit goes through
+// finish first, so nothing is shown before it's been moderated; once finish
+// replaces a partial reply, the rest isn't streamed.
+func (t *Telegram) streamTextResponse(ctx context.Context, chatID int64, userID int64, provider modelapi.ChatProvider, request modelapi.ChatRequest, replyMarkup tgbotapi.InlineKeyboardMarkup, finish func(string) string) (string, error) {
+	tracer := otel.Tracer("telegram/streamTextResponse")
+	ctx, span := tracer.Start(ctx, "streamTextResponse")
+	defer span.End()
+
+	placeholder, err := t.bot.Send(tgbotapi.NewMessage(chatID, streamPlaceholder))
+	if err != nil {
+		span.RecordError(err)
+		return "", err
+	}
+
+	var mu sync.Mutex
+	var accumulated strings.Builder
+	shown := streamPlaceholder
+	edits := 0
+
+	edit := func(text string) {
+		if text == "" || text == shown {
+			// Telegram rejects edits that don't change the message
+			return
+		}
+		if _, err := t.bot.Send(tgbotapi.NewEditMessageText(chatID, placeholder.MessageID, text)); err != nil {
+			t.logger.Logger(ctx).Warn("Failed to edit streaming message", zap.Error(err))
+			return
+		}
+		shown = text
+		edits++
+	}
+
+	done := make(chan struct{})
+	flushed := make(chan struct{})
+	go func() {
+		defer close(flushed)
+		ticker := time.NewTicker(streamEditInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				mu.Lock()
+				text := strings.TrimSpace(accumulated.String())
+				mu.Unlock()
+				if text == "" {
+					continue
+				}
+				moderated := finish(text)
+				edit(moderated)
+				if moderated != text {
+					return
+				}
+			}
+		}
+	}()
+
+	var response string
+	if streamer, ok := provider.(modelapi.ChatStreamer); ok {
+		response, err = streamer.StreamResponse(ctx, request, func(delta string) {
+			mu.Lock()
+			accumulated.WriteString(delta)
+			mu.Unlock()
+		})
+	} else {
+		response, err = provider.GetResponse(ctx, request)
+	}
+	close(done)
+	<-flushed
+
+	if err != nil {
+		span.RecordError(err)
+		edit(t.text(ctx, userID, msgStreamFailed))
+		return "", err
+	}
+
+	response = finish(strings.Trim(response, `\ '"“”`))
+	// The final edit also adds the buttons, so it goes out even if the ticker
+	// already showed the whole reply
+	if response != "" {
+		final := tgbotapi.NewEditMessageTextAndMarkup(chatID, placeholder.MessageID, response, replyMarkup)
+		if _, err := t.bot.Send(final); err != nil {
+			t.logger.Logger(ctx).Warn("Failed to edit streaming message", zap.Error(err))
+		} else {
+			edits++
+		}
+	}
+
+	span.SetAttributes(
+		attribute.String("chat.provider", provider.Name()),
+		attribute.Int("stream.edits", edits),
+	)
+	return response, nil
+}
+
+// toggleTextReplies switches the user's reply mode and returns the new setting.
+func (t *Telegram) toggleTextReplies(ctx context.Context, userID int64) (bool, error) {
+	textReplies := !t.prefersTextReplies(ctx, userID)
+	_, err := t.db.SetTextRepliesByTelegramUserId(ctx, postgres.SetTextRepliesByTelegramUserIdParams{
+		TextReplies:    textReplies,
+		TelegramUserID: userID,
+	})
+	return textReplies, err
+}
+
+// handleModeCommand toggles between voice note and streamed text replies.
+func (t *Telegram) handleModeCommand(ctx context.Context, message *tgbotapi.Message) {
+	textReplies, err := t.toggleTextReplies(ctx, message.From.ID)
+
+	var responseText string
+	switch {
+	case err != nil:
+		t.logger.Logger(ctx).Error("Failed to update reply mode", zap.Error(err), zap.Int64("user_id", message.From.ID))
+		responseText = t.text(ctx, message.From.ID, msgSomethingWrong)
+	case textReplies:
+		responseText = t.text(ctx, message.From.ID, msgModeText)
+	default:
+		responseText = t.text(ctx, message.From.ID, msgModeVoice)
+	}
+
+	msg := tgbotapi.NewMessage(message.Chat.ID, responseText)
+	if _, err := t.bot.Send(msg); err != nil {
+		t.logger.Logger(ctx).Error("Failed to send reply mode confirmation", zap.Error(err))
+	}
+}
@@ -0,0 +1,249 @@
+package telegram
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"gulabodev/stripeapi"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+)
+
+const (
+	stripeCheckoutPayload = "stripe_checkout"
+	// Prefixed to a recharge payload, e.g. "stripe_recharge_50"
+	stripeRechargePrefix = "stripe_"
+
+	maxWebhookBodyBytes = 64 * 1024
+)
+
+// Card prices in USD cents for each recharge package.
+var stripeRechargePrices = map[string]int{
+	rechargePayload50c:  199,
+	rechargePayload125c: 399,
+	rechargePayload300c: 799,
+}
+
+func (t *Telegram) sendStripeRechargeOptions(ctx context.Context, chatID int64, userID int64) {
+	row := func(key messageKey, payload string) []tgbotapi.InlineKeyboardButton {
+		price := float64(stripeRechargePrices[payload]) / 100
+		return tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(t.text(ctx, userID, key, price), stripeRechargePrefix+payload),
+		)
+	}
+
+	msg := tgbotapi.NewMessage(chatID, t.text(ctx, userID, msgStripeMenu))
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
+		row(msgButtonCard50, rechargePayload50c),
+		row(msgButtonCard125, rechargePayload125c),
+		row(msgButtonCard300, rechargePayload300c),
+	)
+	if _, err := t.bot.Send(msg); err != nil {
+		t.logger.Logger(ctx).Error("Failed to send card recharge options", zap.Error(err))
+	}
+}
+
+func (t *Telegram) sendStripeCheckout(ctx context.Context, chatID int64, userID int64, payload string) {
+	tracer := otel.Tracer("telegram/sendStripeCheckout")
+	ctx, span := tracer.Start(ctx, "sendStripeCheckout")
+	defer span.End()
+
+	credits, ok := rechargeCredits(payload)
+	price, hasPrice := stripeRechargePrices[payload]
+	if !ok || !hasPrice {
+		t.logger.Logger(ctx).Error("Unknown card recharge payload", zap.String("payload", payload))
+		return
+	}
+
+	// With auto-recharge on, keep the card so the next top-up needs no checkout
+	_, saveCard := t.autoRechargePreferences(ctx, userID)
+
+	session, err := t.stripe.CreateCheckoutSession(ctx, stripeapi.CreateCheckoutSessionProps{
+		ProductName:       fmt.Sprintf("%d Credits", credits),
+		AmountCents:       price,
+		Currency:          "usd",
+		ClientReferenceID: strconv.FormatInt(userID, 10),
+		Metadata: map[string]string{
+			"payload":          payload,
+			"telegram_user_id": strconv.FormatInt(userID, 10),
+			"chat_id":          strconv.FormatInt(chatID, 10),
+			"bot_id":           t.id,
+			"save_card":        strconv.FormatBool(saveCard),
+		},
+		SaveCard: saveCard,
+	})
+	if err != nil {
+		span.RecordError(err)
+		t.logger.Logger(ctx).Error("Failed to create Stripe checkout", zap.Error(err), zap.Int64("user_id", userID))
+		msg := tgbotapi.NewMessage(chatID, t.text(ctx, userID, msgStripeUnavailable))
+		t.bot.Send(msg)
+		return
+	}
+
+	msg := tgbotapi.NewMessage(chatID, t.text(ctx, userID, msgStripeCheckout))
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonURL(t.text(ctx, userID, msgButtonPay, float64(price)/100), session.URL),
+		),
+	)
+	if _, err := t.bot.Send(msg); err != nil {
+		t.logger.Logger(ctx).Error("Failed to send Stripe checkout link", zap.Error(err))
+	}
+}
+
+// StripeWebhookHandler receives Stripe events and credits completed checkouts
+// through the same pipeline as Telegram Stars payments. Succeeded payment
+// intents retry auto-recharges the bot charged but couldn't credit.
+func (b *Bots) StripeWebhookHandler() http.Handler {
+	// Every bot shares one Stripe account, so any of them can verify events
+	t := b.bots[0]
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tracer := otel.Tracer("telegram/StripeWebhookHandler")
+		ctx, span := tracer.Start(r.Context(), "StripeWebhookHandler")
+		defer span.End()
+
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		payload, err := io.ReadAll(io.LimitReader(r.Body, maxWebhookBodyBytes))
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		event, err := t.stripe.ConstructEvent(payload, r.Header.Get("Stripe-Signature"))
+		if err != nil {
+			span.RecordError(err)
+			t.logger.Logger(ctx).Warn("Rejected Stripe webhook", zap.Error(err))
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		span.SetAttributes(
+			attribute.String("stripe.event_id", event.ID),
+			attribute.String("stripe.event_type", event.Type),
+		)
+
+		if event.Type == stripeapi.EventPaymentIntentSucceeded {
+			var intent stripeapi.PaymentIntent
+			if err := json.Unmarshal(event.Data.Object, &intent); err != nil {
+				t.logger.Logger(ctx).Error("Failed to parse Stripe payment intent", zap.Error(err))
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+
+			// Checkout payments are credited from checkout.session.completed;
+			// only saved-card auto-recharges are retried here
+			if intent.Metadata["auto_recharge"] != "true" {
+				w.WriteHeader(http.StatusOK)
+				return
+			}
+			userID, err := strconv.ParseInt(intent.Metadata["telegram_user_id"], 10, 64)
+			if err != nil {
+				t.logger.Logger(ctx).Error("Stripe payment intent missing telegram_user_id", zap.String("payment_intent_id", intent.ID))
+				w.WriteHeader(http.StatusOK)
+				return
+			}
+			bot, ok := b.find(intent.Metadata["bot_id"])
+			if !ok {
+				t.logger.Logger(ctx).Error("Stripe payment intent for unknown bot",
+					zap.String("payment_intent_id", intent.ID),
+					zap.String("bot_id", intent.Metadata["bot_id"]),
+				)
+				w.WriteHeader(http.StatusOK)
+				return
+			}
+
+			if err := bot.retryAutoRecharge(ctx, userID, intent); err != nil {
+				// Stripe retries the event until fulfilment succeeds
+				span.RecordError(err)
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+
+		if event.Type != stripeapi.EventCheckoutSessionCompleted {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+
+		var session stripeapi.CheckoutSession
+		if err := json.Unmarshal(event.Data.Object, &session); err != nil {
+			t.logger.Logger(ctx).Error("Failed to parse Stripe checkout session", zap.Error(err))
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		if session.PaymentStatus != "paid" {
+			t.logger.Logger(ctx).Info("Ignoring unpaid Stripe checkout", zap.String("session_id", session.ID))
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+
+		userID, err := strconv.ParseInt(session.Metadata["telegram_user_id"], 10, 64)
+		if err != nil {
+			t.logger.Logger(ctx).Error("Stripe checkout missing telegram_user_id", zap.String("session_id", session.ID))
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		chatID, err := strconv.ParseInt(session.Metadata["chat_id"], 10, 64)
+		if err != nil {
+			chatID = userID
+		}
+
+		t.logger.Logger(ctx).Info("Stripe payment received",
+			zap.Int64("user_id", userID),
+			zap.String("session_id", session.ID),
+			zap.String("payload", session.Metadata["payload"]),
+			zap.Int64("amount_total", session.AmountTotal),
+		)
+
+		// Checkouts created before multi-bot support have no bot_id and
+		// belong to the default bot
+		bot, ok := b.find(session.Metadata["bot_id"])
+		if !ok {
+			t.logger.Logger(ctx).Error("Stripe checkout for unknown bot",
+				zap.String("session_id", session.ID),
+				zap.String("bot_id", session.Metadata["bot_id"]),
+			)
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+
+		// The session ID dedupes Stripe's at-least-once redeliveries
+		err = bot.creditRecharge(ctx, chatID, userID, session.Metadata["payload"], paymentRecord{
+			Provider: paymentProviderStripe,
+			Amount:   int(session.AmountTotal),
+			Currency: session.Currency,
+			ChargeID: session.ID,
+		})
+		if err != nil {
+			// Stripe retries the event until fulfilment succeeds
+			span.RecordError(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		if session.Metadata["save_card"] == "true" && session.Customer != "" && session.PaymentIntent != "" {
+			bot.saveStripeCard(ctx, userID, session.Customer, session.PaymentIntent)
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+}
+
+func stripePayloadFromCallback(data string) (string, bool) {
+	if data == stripeCheckoutPayload || !strings.HasPrefix(data, stripeRechargePrefix) {
+		return "", false
+	}
+	return strings.TrimPrefix(data, stripeRechargePrefix), true
+}
@@ -0,0 +1,219 @@
+package telegram
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"gulabodev/database/postgres"
+	"os"
+	"strconv"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+)
+
+const (
+	SubscriptionPriceStars = 700
+	subscriptionPeriod     = 30 * 24 * time.Hour
+
+	subscriptionPayload       = "subscription_monthly"
+	subscriptionCancelPayload = "subscription_cancel"
+
+	subscriptionStatusCanceled = "canceled"
+)
+
+// isSubscribed reports whether the user has an unexpired subscription. Canceled
+// subscriptions stay valid until the end of the paid period.
+func (t *Telegram) isSubscribed(ctx context.Context, userID int64) (bool, error) {
+	_, err := t.db.GetActiveSubscriptionByTelegramUserId(ctx, userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// createSubscriptionInvoiceLink creates a recurring Stars invoice. Telegram only
+// supports subscriptions through invoice links, not sendInvoice.
+func (t *Telegram) createSubscriptionInvoiceLink(ctx context.Context) (string, error) {
+	amount := SubscriptionPriceStars
+	title := "Gulabo Unlimited"
+	if os.Getenv("PRODUCTION") == "" {
+		amount = 1
+		title = fmt.Sprintf("%s (Test)", title)
+		t.logger.Logger(ctx).Info("Development mode: creating 1-star test subscription link")
+	}
+
+	prices, err := json.Marshal([]tgbotapi.LabeledPrice{{Label: title, Amount: amount}})
+	if err != nil {
+		return "", err
+	}
+
+	params := tgbotapi.Params{}
+	params["title"] = title
+	params["description"] = "Unlimited voice replies from your AI girlfriend, renewed every month."
+	params["payload"] = subscriptionPayload
+	params["currency"] = "XTR"
+	params["prices"] = string(prices)
+	params.AddNonZero("subscription_period", int(subscriptionPeriod.Seconds()))
+
+	resp, err := t.bot.MakeRequest("createInvoiceLink", params)
+	if err != nil {
+		return "", err
+	}
+
+	var link string
+	if err := json.Unmarshal(resp.Result, &link); err != nil {
+		return "", err
+	}
+	return link, nil
+}
+
+func (t *Telegram) sendSubscriptionOffer(ctx context.Context, chatID int64, userID int64) {
+	tracer := otel.Tracer("telegram/sendSubscriptionOffer")
+	ctx, span := tracer.Start(ctx, "sendSubscriptionOffer")
+	defer span.End()
+
+	link, err := t.createSubscriptionInvoiceLink(ctx)
+	if err != nil {
+		span.RecordError(err)
+		t.logger.Logger(ctx).Error("Failed to create subscription invoice link", zap.Error(err))
+		return
+	}
+
+	msg := tgbotapi.NewMessage(chatID, t.text(ctx, userID, msgSubscriptionOffer, SubscriptionPriceStars))
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonURL(t.text(ctx, userID, msgButtonSubscribe), link),
+		),
+	)
+	if _, err := t.bot.Send(msg); err != nil {
+		t.logger.Logger(ctx).Error("Failed to send subscription offer", zap.Error(err))
+	}
+}
+
+// handleSubscriptionPayment activates or renews the subscription. Telegram sends
+// a fresh SuccessfulPayment with the same payload on every renewal.
+func (t *Telegram) handleSubscriptionPayment(ctx context.Context, message *tgbotapi.Message) {
+	payment := message.SuccessfulPayment
+	userID := message.From.ID
+	record := paymentRecord{
+		Provider: paymentProviderStars,
+		Amount:   payment.TotalAmount,
+		Currency: payment.Currency,
+		ChargeID: payment.TelegramPaymentChargeID,
+	}
+
+	var subscription postgres.Subscription
+	claimed, err := t.claimPayment(ctx, userID, payment.InvoicePayload, 0, record, func(q *postgres.Queries) error {
+		var err error
+		subscription, err = q.UpsertSubscriptionByTelegramUserId(ctx, postgres.UpsertSubscriptionByTelegramUserIdParams{
+			TelegramUserID:          userID,
+			TelegramPaymentChargeID: payment.TelegramPaymentChargeID,
+			ExpiresAt:               time.Now().Add(subscriptionPeriod),
+		})
+		return err
+	})
+	if err != nil {
+		// Not activated; the Stars reconciliation job flags it for an admin
+		t.logger.Logger(ctx).Error("Failed to record payment and activate subscription", zap.Error(err), zap.Int64("user_id", userID))
+		return
+	}
+
+	if claimed {
+		t.recordGrant(ctx, userID, payment.InvoicePayload, 0, record)
+
+		t.logger.Logger(ctx).Info("Subscription activated",
+			zap.Int64("user_id", userID),
+			zap.Time("expires_at", subscription.ExpiresAt),
+		)
+	} else {
+		// Redelivered update: confirm the existing period instead of extending it again
+		t.logger.Logger(ctx).Info("Ignoring duplicate subscription payment",
+			zap.Int64("user_id", userID),
+			zap.String("telegram_payment_charge_id", payment.TelegramPaymentChargeID),
+		)
+		subscription, err = t.db.GetActiveSubscriptionByTelegramUserId(ctx, userID)
+		if err != nil {
+			t.logger.Logger(ctx).Error("Failed to get subscription", zap.Error(err), zap.Int64("user_id", userID))
+			return
+		}
+	}
+
+	responseText := t.text(ctx, userID, msgSubscriptionActivated, subscription.ExpiresAt.Format("02 Jan 2006"))
+	msg := tgbotapi.NewMessage(message.Chat.ID, responseText)
+	if _, err := t.bot.Send(msg); err != nil {
+		t.logger.Logger(ctx).Error("Failed to send subscription confirmation", zap.Error(err))
+	}
+	if claimed {
+		t.addAffection(ctx, message.Chat.ID, userID, affectionPerSubscription)
+	}
+}
+
+func (t *Telegram) handleSubscriptionCommand(ctx context.Context, message *tgbotapi.Message) {
+	subscription, err := t.db.GetActiveSubscriptionByTelegramUserId(ctx, message.From.ID)
+	if err == sql.ErrNoRows {
+		t.sendSubscriptionOffer(ctx, message.Chat.ID, message.From.ID)
+		return
+	}
+
+	var msg tgbotapi.MessageConfig
+	switch {
+	case err != nil:
+		t.logger.Logger(ctx).Error("Failed to get subscription", zap.Error(err), zap.Int64("user_id", message.From.ID))
+		msg = tgbotapi.NewMessage(message.Chat.ID, t.text(ctx, message.From.ID, msgSubscriptionUnavailable))
+	case subscription.Status == subscriptionStatusCanceled:
+		msg = tgbotapi.NewMessage(message.Chat.ID, t.text(ctx, message.From.ID, msgSubscriptionCanceledStatus, subscription.ExpiresAt.Format("02 Jan 2006")))
+	default:
+		msg = tgbotapi.NewMessage(message.Chat.ID, t.text(ctx, message.From.ID, msgSubscriptionActiveStatus, subscription.ExpiresAt.Format("02 Jan 2006")))
+		msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
+			tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData(t.text(ctx, message.From.ID, msgButtonCancelSubscription), subscriptionCancelPayload),
+			),
+		)
+	}
+
+	if _, err := t.bot.Send(msg); err != nil {
+		t.logger.Logger(ctx).Error("Failed to send subscription status", zap.Error(err))
+	}
+}
+
+// cancelSubscription stops future renewals. The current period stays active.
+func (t *Telegram) cancelSubscription(ctx context.Context, chatID int64, userID int64) {
+	tracer := otel.Tracer("telegram/cancelSubscription")
+	ctx, span := tracer.Start(ctx, "cancelSubscription")
+	defer span.End()
+
+	span.SetAttributes(attribute.Int64("user.id", userID))
+
+	subscription, err := t.db.GetActiveSubscriptionByTelegramUserId(ctx, userID)
+	if err != nil {
+		t.logger.Logger(ctx).Error("Failed to get subscription to cancel", zap.Error(err), zap.Int64("user_id", userID))
+		return
+	}
+
+	params := tgbotapi.Params{}
+	params["user_id"] = strconv.FormatInt(userID, 10)
+	params["telegram_payment_charge_id"] = subscription.TelegramPaymentChargeID
+	params.AddBool("is_canceled", true)
+	if _, err := t.bot.MakeRequest("editUserStarSubscription", params); err != nil {
+		span.RecordError(err)
+		t.logger.Logger(ctx).Error("Failed to cancel Stars subscription", zap.Error(err), zap.Int64("user_id", userID))
+		return
+	}
+
+	if _, err := t.db.CancelSubscriptionByTelegramUserId(ctx, userID); err != nil {
+		t.logger.Logger(ctx).Error("Failed to mark subscription canceled", zap.Error(err), zap.Int64("user_id", userID))
+	}
+
+	msg := tgbotapi.NewMessage(chatID, t.text(ctx, userID, msgSubscriptionCanceled, subscription.ExpiresAt.Format("02 Jan 2006")))
+	if _, err := t.bot.Send(msg); err != nil {
+		t.logger.Logger(ctx).Error("Failed to send cancellation confirmation", zap.Error(err))
+	}
+}
@@ -0,0 +1,102 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"gulabodev/database/postgres"
+	"gulabodev/modelapi"
+	"gulabodev/modelapi/prompts"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+)
+
+const (
+	// Once this many messages sit after the summary, the older ones are folded
+	// into it, so a long chat sends the model about the same amount each time
+	summaryTrigger = 60
+	// The latest messages always go to the model word for word
+	summaryKeepRecent = 20
+)
+
+// unsummarized is the part of the history the conversation's summary doesn't
+// cover yet, which is what the model sees word for word.
+func unsummarized(conversation postgres.Conversation, messages []storedMessage) []storedMessage {
+	start := min(int(conversation.SummarizedMessages), len(messages))
+	return messages[start:]
+}
+
+// summaryPrompt is appended to the system prompt so replies stay consistent
+// with the part of the chat the model no longer sees.
+func summaryPrompt(conversation postgres.Conversation) string {
+	return prompts.Render(prompts.StorySoFar, prompts.StorySoFarData{Summary: conversation.Summary})
+}
+
+// summaryInput is the story so far followed by the messages to fold into it.
+func summaryInput(summary string, messages []storedMessage) string {
+	var input strings.Builder
+	input.WriteString("Story so far:\n" + summary + "\n\nNew messages:\n")
+	for _, message := range messages {
+		speaker := "Lover"
+		if message.Role == "assistant" {
+			speaker = "Her"
+		}
+		input.WriteString(speaker + ": " + message.Content + "\n")
+	}
+	return input.String()
+}
+
+// summarizeConversation folds the oldest messages after the summary into it
+// once summaryTrigger of them have piled up, keeping the latest
+// summaryKeepRecent out. messages is the whole stored history.
+func (t *Telegram) summarizeConversation(ctx context.Context, conversation postgres.Conversation, messages []storedMessage) {
+	pending := unsummarized(conversation, messages)
+	if len(pending) < summaryTrigger {
+		return
+	}
+
+	tracer := otel.Tracer("telegram/summarizeConversation")
+	ctx, span := tracer.Start(ctx, "summarizeConversation")
+	defer span.End()
+
+	folded := pending[:len(pending)-summaryKeepRecent]
+	span.SetAttributes(
+		attribute.Int64("conversation_id", conversation.ID),
+		attribute.Int("summary.folded_messages", len(folded)),
+	)
+
+	provider := t.chat.Provider(modelapi.ChatRoute{Feature: chatFeatureSummary, Persona: conversation.Persona, UserID: conversation.TelegramUserID})
+	summary, err := provider.GetResponse(ctx, modelapi.ChatRequest{
+		SystemPrompt: prompts.Render(prompts.ConversationSummary, nil),
+		Message:      summaryInput(conversation.Summary, folded),
+	})
+	summary = strings.TrimSpace(summary)
+	if err == nil && summary == "" {
+		err = errors.New("empty summary")
+	}
+	if err != nil {
+		span.RecordError(err)
+		t.logger.Logger(ctx).Error("Failed to summarize conversation", zap.Error(err), zap.Int64("conversation_id", conversation.ID))
+		return
+	}
+
+	updated, err := t.db.UpdateConversationSummary(ctx, postgres.UpdateConversationSummaryParams{
+		Summary:                    summary,
+		SummarizedMessages:         int32(len(messages) - summaryKeepRecent),
+		ID:                         conversation.ID,
+		PreviousSummarizedMessages: conversation.SummarizedMessages,
+	})
+	if err != nil {
+		t.logger.Logger(ctx).Error("Failed to save conversation summary", zap.Error(err), zap.Int64("conversation_id", conversation.ID))
+		return
+	}
+	if updated == 0 {
+		// Another reply's summary got there first and already kept these moments
+		span.SetAttributes(attribute.Bool("summary.superseded", true))
+		return
+	}
+	// The summary keeps the gist; the details are kept for recall
+	t.storeMoments(ctx, conversation, folded)
+}
@@ -0,0 +1,39 @@
+package telegram
+
+import (
+	"gulabodev/database/postgres"
+	"testing"
+	"time"
+)
+
+func TestUnsummarized(t *testing.T) {
+	now := time.Now()
+	messages := []storedMessage{
+		newStoredMessage("user", "hi", now),
+		newStoredMessage("assistant", "hey baby", now),
+		newStoredMessage("user", "miss me?", now),
+	}
+
+	if got := unsummarized(postgres.Conversation{}, messages); len(got) != 3 {
+		t.Errorf("without a summary every message should be sent, got %d", len(got))
+	}
+	if got := unsummarized(postgres.Conversation{SummarizedMessages: 2}, messages); len(got) != 1 || got[0].Content != "miss me?" {
+		t.Errorf("unsummarized = %+v, want only the last message", got)
+	}
+	// A regenerated reply looks at a shorter history than the summary covers
+	if got := unsummarized(postgres.Conversation{SummarizedMessages: 5}, messages); len(got) != 0 {
+		t.Errorf("unsummarized past the end = %d messages, want 0", len(got))
+	}
+}
+
+func TestSummaryInput(t *testing.T) {
+	now := time.Now()
+	got := summaryInput("They met in Pune.", []storedMessage{
+		newStoredMessage("user", "got the job!", now),
+		newStoredMessage("assistant", "I knew it 😘", now),
+	})
+	want := "Story so far:\nThey met in Pune.\n\nNew messages:\nLover: got the job!\nHer: I knew it 😘\n"
+	if got != want {
+		t.Errorf("summaryInput = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,235 @@
+package telegram
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"gulabodev/database/postgres"
+	"gulabodev/modelapi"
+	"gulabodev/modelapi/groqapi"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+)
+
+const (
+	retranscribeCallbackPrefix = "retranscribe:"
+
+	// Leaves room in Telegram's 4096 character message limit for the framing
+	maxEchoLength = 3500
+)
+
+func (t *Telegram) prefersTranscriptEcho(ctx context.Context, userID int64) bool {
+	preferences, err := t.db.GetUserPreferencesByTelegramUserId(ctx, userID)
+	if err != nil {
+		if err != sql.ErrNoRows {
+			t.logger.Logger(ctx).Error("Failed to get user preferences", zap.Error(err), zap.Int64("user_id", userID))
+		}
+		return false
+	}
+	return preferences.TranscriptEcho
+}
+
+// toggleTranscriptEcho switches transcript echo and returns the new setting.
+func (t *Telegram) toggleTranscriptEcho(ctx context.Context, userID int64) (bool, error) {
+	echo := !t.prefersTranscriptEcho(ctx, userID)
+	_, err := t.db.SetTranscriptEchoByTelegramUserId(ctx, postgres.SetTranscriptEchoByTelegramUserIdParams{
+		TranscriptEcho: echo,
+		TelegramUserID: userID,
+	})
+	return echo, err
+}
+
+// handleEchoCommand toggles quoting back what was heard in voice notes.
+func (t *Telegram) handleEchoCommand(ctx context.Context, message *tgbotapi.Message) {
+	echo, err := t.toggleTranscriptEcho(ctx, message.From.ID)
+
+	var responseText string
+	switch {
+	case err != nil:
+		t.logger.Logger(ctx).Error("Failed to update transcript echo", zap.Error(err), zap.Int64("user_id", message.From.ID))
+		responseText = t.text(ctx, message.From.ID, msgSomethingWrong)
+	case echo:
+		responseText = t.text(ctx, message.From.ID, msgEchoOn)
+	default:
+		responseText = t.text(ctx, message.From.ID, msgEchoOff)
+	}
+
+	msg := tgbotapi.NewMessage(message.Chat.ID, responseText)
+	if _, err := t.bot.Send(msg); err != nil {
+		t.logger.Logger(ctx).Error("Failed to send transcript echo confirmation", zap.Error(err))
+	}
+}
+
+// retranscribeKeyboard is the "that's wrong" button under an echo. Like
+// regenerateKeyboard, it carries the history length once the reply to the
+// voice note is stored, so only the latest one can be redone.
+func retranscribeKeyboard(label string, conversationID int64, historyLength int) tgbotapi.InlineKeyboardMarkup {
+	return tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData(label, fmt.Sprintf("%s%d:%d", retranscribeCallbackPrefix, conversationID, historyLength)),
+	))
+}
+
+// retranscribeFromCallback splits "retranscribe:<conversation id>:<history length>"
+// callback data.
+func retranscribeFromCallback(data string) (conversationID int64, historyLength int, ok bool) {
+	rest, found := strings.CutPrefix(data, retranscribeCallbackPrefix)
+	if !found {
+		return 0, 0, false
+	}
+	id, length, found := strings.Cut(rest, ":")
+	if !found {
+		return 0, 0, false
+	}
+	conversationID, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	historyLength, err = strconv.Atoi(length)
+	if err != nil {
+		return 0, 0, false
+	}
+	return conversationID, historyLength, true
+}
+
+// sameTranscript reports whether two transcripts differ only in case,
+// punctuation or spacing.
+func sameTranscript(a, b string) bool {
+	normalize := func(s string) string {
+		return strings.Join(strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+			return strings.ContainsRune(" \t\n.,!?;:'\"“”‘’…-", r)
+		}), " ")
+	}
+	return normalize(a) == normalize(b)
+}
+
+// sendTranscriptEcho quotes back what was heard in a voice note, as a reply to
+// it so a re-transcription can find the audio again.
+func (t *Telegram) sendTranscriptEcho(ctx context.Context, message *tgbotapi.Message, conversation postgres.Conversation, transcript string) {
+	history, err := decodeHistory(conversation.Messages)
+	if err != nil {
+		t.logger.Logger(ctx).Error("Failed to unmarshal conversation history", zap.Error(err))
+	}
+
+	userID := message.From.ID
+	msg := tgbotapi.NewMessage(message.Chat.ID, t.text(ctx, userID, msgTranscriptEcho, truncateRunes(transcript, maxEchoLength)))
+	msg.ReplyToMessageID = message.MessageID
+	// The reply to the voice note becomes the last two turns of the history
+	msg.ReplyMarkup = retranscribeKeyboard(t.text(ctx, userID, msgButtonWrongHeard), conversation.ID, len(history)+2)
+	if _, err := t.bot.Send(msg); err != nil {
+		t.logger.Logger(ctx).Error("Failed to send transcript echo", zap.Error(err))
+	}
+}
+
+// handleRetranscribeCallback transcribes the voice note again with Gemini and,
+// if it hears something different, answers that instead. The first reply
+// answered words the user never said, so the redo is free.
+func (t *Telegram) handleRetranscribeCallback(ctx context.Context, message *tgbotapi.Message, userID int64, conversationID int64, historyLength int) {
+	tracer := otel.Tracer("telegram/handleRetranscribeCallback")
+	ctx, span := tracer.Start(ctx, "handleRetranscribeCallback")
+	defer span.End()
+
+	span.SetAttributes(attribute.Int64("conversation.id", conversationID))
+
+	conversation, err := t.activeConversation(ctx, userID)
+	if err != nil {
+		span.RecordError(err)
+		t.logger.Logger(ctx).Error("Failed to get conversation", zap.Error(err), zap.Int64("user_id", userID))
+		t.bot.Send(tgbotapi.NewMessage(message.Chat.ID, t.text(ctx, userID, msgSomethingWrong)))
+		return
+	}
+
+	// Either outcome takes the button away, so it can't be pressed twice
+	t.removeKeyboard(ctx, message)
+
+	var audio audioAttachment
+	ok := message.ReplyToMessage != nil
+	if ok {
+		audio, ok = messageAudio(message.ReplyToMessage)
+	}
+	history, err := decodeHistory(conversation.Messages)
+	n := len(history)
+	if !ok || err != nil || conversation.ID != conversationID || n != historyLength || n < 2 ||
+		history[n-1].Role != groqapi.ASSISTANT || history[n-2].Role != groqapi.USER {
+		t.bot.Send(tgbotapi.NewMessage(message.Chat.ID, t.text(ctx, userID, msgTranscriptStale)))
+		return
+	}
+
+	audioData, err := t.downloadAudio(ctx, audio)
+	if err != nil {
+		span.RecordError(err)
+		t.logger.Logger(ctx).Error("Failed to download voice file", zap.Error(err), zap.String("kind", audio.Kind))
+		t.bot.Send(tgbotapi.NewMessage(message.Chat.ID, t.text(ctx, userID, msgSomethingWrong)))
+		return
+	}
+
+	transcription, err := t.gemini.Transcribe(ctx, modelapi.TranscriptionRequest{
+		Audio:    audioData,
+		MimeType: audio.transcriptionMimeType(),
+	})
+	if err != nil {
+		span.RecordError(err)
+		t.logger.Logger(ctx).Error("Failed to re-transcribe voice", zap.Error(err))
+		t.bot.Send(tgbotapi.NewMessage(message.Chat.ID, t.text(ctx, userID, msgSomethingWrong)))
+		return
+	}
+	transcript := transcription.Text
+
+	t.logger.Logger(ctx).Info("Re-transcribed voice message",
+		t.logger.Content("previous", history[n-2].Content),
+		t.logger.Content("transcript", transcript),
+	)
+
+	if sameTranscript(transcript, history[n-2].Content) {
+		t.bot.Send(tgbotapi.NewMessage(message.Chat.ID, t.text(ctx, userID, msgTranscriptSame)))
+		return
+	}
+
+	edit := tgbotapi.NewEditMessageText(message.Chat.ID, message.MessageID, t.text(ctx, userID, msgTranscriptEcho, truncateRunes(transcript, maxEchoLength)))
+	if _, err := t.bot.Send(edit); err != nil {
+		t.logger.Logger(ctx).Error("Failed to update transcript echo", zap.Error(err))
+	}
+
+	// Roll the history back to before the voice note and answer what was said
+	textReplies := t.prefersTextReplies(ctx, userID)
+	systemPrompt := t.replySystemPrompt(ctx, userID, conversation, t.userMemories(ctx, userID))
+	markup := regenerateKeyboard(conversation.ID, n)
+
+	response, err := t.generateReply(ctx, message.Chat.ID, conversation, textReplies, modelapi.ChatRequest{
+		SystemPrompt: systemPrompt,
+		History:      modelHistory(unsummarized(conversation, history[:n-2])),
+		Message:      transcript,
+	}, markup)
+	if err != nil {
+		span.RecordError(err)
+		t.logger.Logger(ctx).Error("Failed to generate response", zap.Error(err), zap.Int64("user_id", userID))
+		return
+	}
+
+	history[n-2].Content = transcript
+	// The timings were for the transcript that was wrong
+	history[n-2].Words = nil
+	history[n-1] = newStoredMessage(groqapi.ASSISTANT, response, time.Now())
+	updatedMessages, err := json.Marshal(history)
+	if err != nil {
+		t.logger.Logger(ctx).Error("Failed to marshal updated conversation history", zap.Error(err))
+	} else {
+		_, err = t.db.UpdateConversationMessages(ctx, postgres.UpdateConversationMessagesParams{
+			ID:       conversation.ID,
+			Messages: updatedMessages,
+		})
+		if err != nil {
+			t.logger.Logger(ctx).Error("Failed to update conversation messages", zap.Error(err))
+		}
+	}
+
+	if !textReplies {
+		t.sendVoiceResponse(ctx, message.Chat.ID, conversation, response, markup)
+	}
+}
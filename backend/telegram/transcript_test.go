@@ -0,0 +1,59 @@
+package telegram
+
+import (
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+func TestRetranscribeCallbackRoundTrip(t *testing.T) {
+	markup := retranscribeKeyboard("❌", 42, 8)
+	data := *markup.InlineKeyboard[0][0].CallbackData
+
+	conversationID, historyLength, ok := retranscribeFromCallback(data)
+	if !ok || conversationID != 42 || historyLength != 8 {
+		t.Errorf("retranscribeFromCallback(%q) = (%d, %d, %v), want (42, 8, true)", data, conversationID, historyLength, ok)
+	}
+
+	for _, data := range []string{"retranscribe:", "retranscribe:42", "retranscribe:x:8", "regenerate:42:8"} {
+		if _, _, ok := retranscribeFromCallback(data); ok {
+			t.Errorf("retranscribeFromCallback(%q) ok, want not ok", data)
+		}
+	}
+}
+
+func TestSameTranscript(t *testing.T) {
+	tests := []struct {
+		a, b string
+		same bool
+	}{
+		{"Kya kar rahi ho?", "kya kar rahi ho", true},
+		{"Hello,  baby…", "hello baby", true},
+		{"kya kar rahi ho", "kya khaa rahi ho", false},
+	}
+
+	for _, tt := range tests {
+		if got := sameTranscript(tt.a, tt.b); got != tt.same {
+			t.Errorf("sameTranscript(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.same)
+		}
+	}
+}
+
+func TestTranscriptionMimeType(t *testing.T) {
+	tests := []struct {
+		name    string
+		message tgbotapi.Message
+		want    string
+	}{
+		{"voice", tgbotapi.Message{Voice: &tgbotapi.Voice{FileID: "v"}}, "audio/ogg"},
+		{"audio", tgbotapi.Message{Audio: &tgbotapi.Audio{FileID: "a", MimeType: "audio/mpeg"}}, "audio/mpeg"},
+		{"video note", tgbotapi.Message{VideoNote: &tgbotapi.VideoNote{FileID: "n"}}, "audio/wav"},
+	}
+
+	for _, tt := range tests {
+		audio, _ := messageAudio(&tt.message)
+		if got := audio.transcriptionMimeType(); got != tt.want {
+			t.Errorf("%s: transcriptionMimeType = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"gulabodev/database/postgres"
+	"gulabodev/modelapi"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.opentelemetry.io/otel"
+	"go.uber.org/zap"
+)
+
+const (
+	// /usage looks back this far
+	usageWindow       = 30 * 24 * time.Hour
+	usageTopUserLimit = 10
+)
+
+// trackUsage has every LLM and TTS call made with the returned context
+// routed for the user's tier and recorded against the user.
+func (t *Telegram) trackUsage(ctx context.Context, userID int64) context.Context {
+	tier := t.userTier(ctx, userID)
+	ctx = withTier(ctx, tier)
+	return modelapi.WithUsageRecorder(ctx, func(ctx context.Context, usage modelapi.Usage) {
+		err := t.db.CreateUsageRecord(ctx, postgres.CreateUsageRecordParams{
+			TelegramUserID: userID,
+			Provider:       usage.Provider,
+			Kind:           usage.Kind,
+			Model:          usage.Model,
+			InputTokens:    int32(usage.InputTokens),
+			OutputTokens:   int32(usage.OutputTokens),
+			Characters:     int32(usage.Characters),
+			CostMicros:     usage.CostMicros(),
+			Tier:           tier,
+		})
+		if err != nil {
+			t.logger.Logger(ctx).Error("Failed to record usage", zap.Error(err), zap.Int64("user_id", userID), zap.String("provider", usage.Provider))
+		}
+	})
+}
+
+// formatCost shows micro-dollars as dollars, with enough digits for a
+// single user's spend.
+func formatCost(micros int64) string {
+	return fmt.Sprintf("$%.4f", float64(micros)/1e6)
+}
+
+// handleUsageCommand shows an admin what users cost over the last 30 days:
+// the costliest users, or one user's spend by provider.
+func (t *Telegram) handleUsageCommand(ctx context.Context, message *tgbotapi.Message) {
+	tracer := otel.Tracer("telegram/handleUsageCommand")
+	ctx, span := tracer.Start(ctx, "handleUsageCommand")
+	defer span.End()
+
+	since := time.Now().Add(-usageWindow)
+
+	args := strings.TrimSpace(message.CommandArguments())
+	if args == "" {
+		users, err := t.db.ListTopUsersByUsageCostSince(ctx, postgres.ListTopUsersByUsageCostSinceParams{
+			Since:    since,
+			RowLimit: usageTopUserLimit,
+		})
+		if err != nil {
+			span.RecordError(err)
+			t.logger.Logger(ctx).Error("Failed to list top users by cost", zap.Error(err))
+			t.replyText(ctx, message.Chat.ID, "Failed to load usage.")
+			return
+		}
+		t.replyText(ctx, message.Chat.ID, formatTopUsersByCost(users))
+		return
+	}
+
+	userID, err := strconv.ParseInt(args, 10, 64)
+	if err != nil {
+		t.replyText(ctx, message.Chat.ID, "Usage: /usage [telegram_user_id]")
+		return
+	}
+	usage, err := t.db.ListUsageByTelegramUserIdSince(ctx, postgres.ListUsageByTelegramUserIdSinceParams{
+		TelegramUserID: userID,
+		Since:          since,
+	})
+	if err != nil {
+		span.RecordError(err)
+		t.logger.Logger(ctx).Error("Failed to list usage", zap.Error(err), zap.Int64("target_id", userID))
+		t.replyText(ctx, message.Chat.ID, "Failed to load usage.")
+		return
+	}
+	t.replyText(ctx, message.Chat.ID, formatUserUsage(userID, usage))
+}
+
+func formatTopUsersByCost(users []postgres.ListTopUsersByUsageCostSinceRow) string {
+	if len(users) == 0 {
+		return "No usage in the last 30 days"
+	}
+
+	var b strings.Builder
+	b.WriteString("💸 Costliest users, last 30 days:\n\n")
+	for _, user := range users {
+		fmt.Fprintf(&b, "%d: %s (%d calls)\n", user.TelegramUserID, formatCost(user.CostMicros), user.Requests)
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+func formatUserUsage(userID int64, usage []postgres.ListUsageByTelegramUserIdSinceRow) string {
+	if len(usage) == 0 {
+		return fmt.Sprintf("No usage for %d in the last 30 days", userID)
+	}
+
+	var b strings.Builder
+	var total int64
+	fmt.Fprintf(&b, "💸 Usage for %d, last 30 days:\n\n", userID)
+	for _, row := range usage {
+		total += row.CostMicros
+		fmt.Fprintf(&b, "%s %s: %s (%d calls", row.Provider, row.Kind, formatCost(row.CostMicros), row.Requests)
+		if row.InputTokens+row.OutputTokens > 0 {
+			fmt.Fprintf(&b, ", %d in / %d out tokens", row.InputTokens, row.OutputTokens)
+		}
+		if row.Characters > 0 {
+			fmt.Fprintf(&b, ", %d chars", row.Characters)
+		}
+		b.WriteString(")\n")
+	}
+	fmt.Fprintf(&b, "\nTotal: %s", formatCost(total))
+	return b.String()
+}
@@ -0,0 +1,25 @@
+package telegram
+
+import (
+	"gulabodev/database/postgres"
+	"strings"
+	"testing"
+)
+
+func TestFormatCost(t *testing.T) {
+	if got := formatCost(1_234_500); got != "$1.2345" {
+		t.Errorf("formatCost = %q", got)
+	}
+}
+
+func TestFormatUserUsage(t *testing.T) {
+	got := formatUserUsage(42, []postgres.ListUsageByTelegramUserIdSinceRow{
+		{Provider: "groq", Kind: "chat", Requests: 3, InputTokens: 900, OutputTokens: 120, CostMicros: 1260},
+		{Provider: "openai", Kind: "tts", Requests: 2, Characters: 80, CostMicros: 1200},
+	})
+	for _, want := range []string{"groq chat: $0.0013 (3 calls, 900 in / 120 out tokens)", "openai tts: $0.0012 (2 calls, 80 chars)", "Total: $0.0025"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("formatUserUsage missing %q:\n%s", want, got)
+		}
+	}
+}
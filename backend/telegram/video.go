@@ -0,0 +1,12 @@
+package telegram
+
+import (
+	"context"
+	"gulabodev/audio"
+)
+
+// extractAudio pulls the audio track out of a video as 16 kHz mono WAV, the
+// format speech-to-text works best with.
+func extractAudio(ctx context.Context, videoData []byte) ([]byte, error) {
+	return audio.Convert(ctx, videoData, audio.WAV, audio.Options{SampleRate: 16000, Channels: 1})
+}
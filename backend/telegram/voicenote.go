@@ -0,0 +1,97 @@
+package telegram
+
+import (
+	"context"
+	"database/sql"
+	"gulabodev/database/postgres"
+	"gulabodev/logger"
+	"gulabodev/modelapi"
+	"gulabodev/modelapi/geminiapi"
+	"os"
+	"slices"
+	"strconv"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+// loadVoiceUnderstanding reads VOICE_UNDERSTANDING. When it's on, voice notes
+// go to Gemini first so replies can react to how the user sounds, at the cost
+// of a Gemini call per voice note.
+func loadVoiceUnderstanding(ctx context.Context, logger *logger.LogMiddleware) bool {
+	raw := os.Getenv("VOICE_UNDERSTANDING")
+	if raw == "" {
+		return false
+	}
+	enabled, err := strconv.ParseBool(raw)
+	if err != nil {
+		logger.Logger(ctx).Error("Invalid VOICE_UNDERSTANDING, ignoring", zap.String("value", raw))
+		return false
+	}
+	return enabled
+}
+
+// loadWordTimings reads STT_WORD_TIMINGS. When it's on, voice notes are
+// stored with when each word was said, for captions and speaking pace, at the
+// cost of a bigger history.
+func loadWordTimings(ctx context.Context, logger *logger.LogMiddleware) bool {
+	raw := os.Getenv("STT_WORD_TIMINGS")
+	if raw == "" {
+		return false
+	}
+	enabled, err := strconv.ParseBool(raw)
+	if err != nil {
+		logger.Logger(ctx).Error("Invalid STT_WORD_TIMINGS, ignoring", zap.String("value", raw))
+		return false
+	}
+	return enabled
+}
+
+// transcribeVoiceNote turns a voice note into text, plus a note for the system
+// prompt on how the user sounded when voice understanding is on. If Gemini
+// can't understand it, the usual STT providers transcribe it instead.
+func (t *Telegram) transcribeVoiceNote(ctx context.Context, request modelapi.TranscriptionRequest) (modelapi.Transcription, string, error) {
+	if t.voiceEmotion {
+		note, err := t.gemini.UnderstandVoice(ctx, request)
+		if err == nil {
+			return modelapi.Transcription{Text: note.Transcript}, voiceNotePrompt(note), nil
+		}
+		t.logger.Logger(ctx).Warn("Voice understanding failed, transcribing instead", zap.Error(err))
+	}
+
+	transcription, err := t.stt.Transcribe(ctx, request)
+	return transcription, "", err
+}
+
+// keyterms lists the names a user's voice note likely mentions, so speech to
+// text can be primed with them.
+func (t *Telegram) keyterms(ctx context.Context, user *tgbotapi.User, conversation postgres.Conversation) []string {
+	preferences, err := t.db.GetUserPreferencesByTelegramUserId(ctx, user.ID)
+	if err != nil && err != sql.ErrNoRows {
+		t.logger.Logger(ctx).Error("Failed to get user preferences", zap.Error(err), zap.Int64("user_id", user.ID))
+	}
+	return voiceNoteKeyterms(user, preferences, t.conversationPersona(ctx, conversation))
+}
+
+// voiceNoteKeyterms are the persona's name and the user's, both the one they
+// gave her and the one on their Telegram account. Blanks are left to the
+// provider to drop.
+func voiceNoteKeyterms(user *tgbotapi.User, preferences postgres.UserPreference, p persona) []string {
+	terms := []string{p.Name, user.FirstName, user.LastName}
+	if preferences.PreferredName.Valid {
+		terms = append(terms, preferences.PreferredName.String)
+	}
+	return terms
+}
+
+// voiceNotePrompt tells her how the user sounded and what she could hear.
+func voiceNotePrompt(note geminiapi.VoiceNote) string {
+	var prompt string
+	if slices.Contains(geminiapi.VoiceEmotions, note.Emotion) && note.Emotion != "calm" {
+		prompt += "\nYour lover sounded " + note.Emotion + " in their voice note; respond to that as well as to what they said."
+	}
+	if note.Context != "" {
+		prompt += "\nIn the background of their voice note you could hear: " + note.Context + ". Mention it if it fits."
+	}
+	return prompt
+}
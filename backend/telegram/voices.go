@@ -0,0 +1,289 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"gulabodev/database/postgres"
+	"gulabodev/logger"
+	"gulabodev/modelapi"
+	"gulabodev/modelapi/azureapi"
+	"gulabodev/modelapi/cartesiaapi"
+	"os"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+const (
+	ttsProviderOpenAI   = "openai"
+	ttsProviderGemini   = "gemini"
+	ttsProviderCartesia = "cartesia"
+	ttsProviderKokoro   = "kokoro"
+	ttsProviderAzure    = "azure"
+
+	voiceCallbackPrefix = "voice:"
+	voiceMenuText       = "Meri kaunsi awaaz sunna pasand karoge, baby? 🎙️"
+
+	// Keeps each page of /cartesiavoices under Telegram's 4096 character limit
+	maxVoiceCatalogLength = 4000
+
+	// Used in place of voices that can't read Gurmukhi
+	gurmukhiFallbackVoice = "gemini_aoede"
+)
+
+// defaultTTSFallbackOrder is tried after the voice's own provider fails.
+var defaultTTSFallbackOrder = []string{ttsProviderOpenAI, ttsProviderGemini, ttsProviderCartesia, ttsProviderKokoro}
+
+type ttsVoice struct {
+	// ID is what gets stored in conversations.tts_voice
+	ID       string
+	Name     string
+	Emoji    string
+	Provider string
+	// VoiceID is the provider's own voice identifier
+	VoiceID string
+}
+
+// ttsVoices lists the voices offered by /voice. The first entry is the default.
+var ttsVoices = []ttsVoice{
+	{ID: "openai_sage", Name: "Sage", Emoji: "🌹", Provider: ttsProviderOpenAI, VoiceID: "sage"},
+	{ID: "gemini_aoede", Name: "Aoede", Emoji: "🌙", Provider: ttsProviderGemini, VoiceID: "Aoede"},
+	{ID: "gemini_kore", Name: "Kore", Emoji: "🔥", Provider: ttsProviderGemini, VoiceID: "Kore"},
+	{ID: "cartesia_hinglish", Name: "Hinglish", Emoji: "💋", Provider: ttsProviderCartesia, VoiceID: cartesiaapi.HINGLISH_WOMAN},
+	{ID: "cartesia_indian", Name: "Desi", Emoji: "🪷", Provider: ttsProviderCartesia, VoiceID: cartesiaapi.INDIAN_WOMAN},
+	{ID: "kokoro_hf_beta", Name: "Kokoro", Emoji: "🌸", Provider: ttsProviderKokoro, VoiceID: "hf_beta"},
+	{ID: "azure_swara", Name: "Swara", Emoji: "🪔", Provider: ttsProviderAzure, VoiceID: azureapi.HINDI_WOMAN},
+	{ID: "azure_vaani", Name: "Vaani", Emoji: "🌾", Provider: ttsProviderAzure, VoiceID: azureapi.PUNJABI_WOMAN},
+}
+
+// findVoice returns the voice with the given ID, falling back to the default.
+func findVoice(id string) ttsVoice {
+	for _, voice := range ttsVoices {
+		if voice.ID == id {
+			return voice
+		}
+	}
+	return ttsVoices[0]
+}
+
+// conversationVoice returns the voice chosen for a conversation, or its persona's default.
+func conversationVoice(conversation postgres.Conversation) ttsVoice {
+	if conversation.TtsVoice == "" {
+		return personaVoice(findPersona(conversation.Persona))
+	}
+	return findVoice(conversation.TtsVoice)
+}
+
+// personaVoice is the persona's default voice, speaking in its own provider
+// voice if it has one.
+func personaVoice(p persona) ttsVoice {
+	voice := findVoice(p.DefaultVoice)
+	if p.VoiceID != "" {
+		voice.VoiceID = p.VoiceID
+	}
+	return voice
+}
+
+// generateSpeech synthesizes text in the conversation's voice and the user's
+// language, asking for the user's pace and pitch and the persona's speech
+// temperature. Voices that take a style instruction or emotion also get her
+// mood. If the voice's provider fails, the others are tried in the configured
+// fallback order.
+func (t *Telegram) generateSpeech(ctx context.Context, conversation postgres.Conversation, text string, rate speechPreset, pitch speechPreset) (modelapi.Speech, error) {
+	language := t.userLanguage(ctx, conversation.TelegramUserID)
+	voice := speakingVoice(t.replyVoice(ctx, conversation), language)
+
+	mood := moodStyles[t.currentMood(ctx, conversation.TelegramUserID)]
+	var chain []modelapi.TTSProvider
+	for _, name := range ttsFallbackChain(voice.Provider, t.ttsFallbackOrder, language.Gurmukhi) {
+		chain = append(chain, t.tts[name])
+	}
+
+	speech, err := modelapi.NewFallbackTTS(chain...).Synthesize(ctx, modelapi.SpeechRequest{
+		Text:        text,
+		Voice:       voice.VoiceID,
+		Style:       mood.Speech,
+		Language:    language.TTSLanguage,
+		Emotion:     mood.Emotion,
+		Rate:        rate.Value,
+		Pitch:       pitch.Value,
+		Temperature: t.conversationPersona(ctx, conversation).SpeechTemperature,
+	})
+	if err == nil && speech.Provider != voice.Provider {
+		t.logger.Logger(ctx).Warn("TTS fell back to another provider",
+			zap.String("voice", voice.ID),
+			zap.String("provider", speech.Provider),
+			zap.Int64("user_id", conversation.TelegramUserID),
+		)
+	}
+	return speech, err
+}
+
+// ttsProviders maps each provider name used in ttsVoices to its client.
+func ttsProviders(args TelegramConnectProps) map[string]modelapi.TTSProvider {
+	return map[string]modelapi.TTSProvider{
+		ttsProviderOpenAI:   args.OpenAI,
+		ttsProviderGemini:   args.Gemini,
+		ttsProviderCartesia: args.Cartesia,
+		ttsProviderKokoro:   args.DeepInfra,
+		ttsProviderAzure:    args.Azure,
+	}
+}
+
+// speakingVoice is the voice that reads replies in language: the chosen one,
+// unless it can't read the script.
+func speakingVoice(voice ttsVoice, language replyLanguage) ttsVoice {
+	if language.Gurmukhi && !readsGurmukhi(voice.Provider) {
+		return findVoice(gurmukhiFallbackVoice)
+	}
+	return voice
+}
+
+// Gurmukhi output trips up these providers
+func readsGurmukhi(provider string) bool {
+	return provider != ttsProviderCartesia && provider != ttsProviderKokoro
+}
+
+// ttsFallbackChain puts the voice's own provider first, then the rest in the
+// configured order, leaving out any that can't read the script.
+func ttsFallbackChain(preferred string, order []string, gurmukhi bool) []string {
+	chain := []string{preferred}
+	for _, provider := range order {
+		if provider == preferred || (gurmukhi && !readsGurmukhi(provider)) {
+			continue
+		}
+		chain = append(chain, provider)
+	}
+	return chain
+}
+
+// loadTTSFallbackOrder reads TTS_FALLBACK_ORDER, a comma-separated list of
+// providers, falling back to the default order.
+func loadTTSFallbackOrder(ctx context.Context, logger *logger.LogMiddleware) []string {
+	raw := os.Getenv("TTS_FALLBACK_ORDER")
+	if raw == "" {
+		return defaultTTSFallbackOrder
+	}
+
+	var order []string
+	for _, provider := range strings.Split(raw, ",") {
+		provider = strings.TrimSpace(provider)
+		switch provider {
+		case ttsProviderOpenAI, ttsProviderGemini, ttsProviderCartesia, ttsProviderKokoro, ttsProviderAzure:
+			order = append(order, provider)
+		default:
+			logger.Logger(ctx).Error("Unknown provider in TTS_FALLBACK_ORDER, skipping", zap.String("provider", provider))
+		}
+	}
+	if len(order) == 0 {
+		return defaultTTSFallbackOrder
+	}
+	return order
+}
+
+func (t *Telegram) handleVoiceCommand(ctx context.Context, message *tgbotapi.Message) {
+	conversation, err := t.activeConversation(ctx, message.From.ID)
+	if err != nil {
+		t.logger.Logger(ctx).Error("Failed to get conversation", zap.Error(err), zap.Int64("user_id", message.From.ID))
+		return
+	}
+
+	msg := tgbotapi.NewMessage(message.Chat.ID, voiceMenuText)
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(voiceKeyboard(t.replyVoice(ctx, conversation))...)
+	if _, err := t.bot.Send(msg); err != nil {
+		t.logger.Logger(ctx).Error("Failed to send voice options", zap.Error(err))
+	}
+}
+
+// voiceKeyboard lists the voices, marking the current one.
+func voiceKeyboard(current ttsVoice) [][]tgbotapi.InlineKeyboardButton {
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for _, voice := range ttsVoices {
+		label := voice.Emoji + " " + voice.Name
+		if voice.ID == current.ID {
+			label = "✅ " + label
+		}
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(label, voiceCallbackPrefix+voice.ID),
+		))
+	}
+	return rows
+}
+
+func (t *Telegram) setVoice(ctx context.Context, chatID int64, userID int64, voiceID string) {
+	voice := findVoice(voiceID)
+
+	// The voice belongs to the chat with the currently active persona
+	conversation, err := t.activeConversation(ctx, userID)
+	if err == nil {
+		_, err = t.db.SetConversationVoice(ctx, postgres.SetConversationVoiceParams{
+			ID:       conversation.ID,
+			TtsVoice: voice.ID,
+		})
+	}
+
+	var responseText string
+	if err != nil {
+		t.logger.Logger(ctx).Error("Failed to set voice", zap.Error(err), zap.Int64("user_id", userID))
+		responseText = t.text(ctx, userID, msgSomethingWrong)
+	} else {
+		responseText = fmt.Sprintf("Done! Ab se main %s awaaz mein baat karungi %s", voice.Name, voice.Emoji)
+	}
+
+	msg := tgbotapi.NewMessage(chatID, responseText)
+	if _, err := t.bot.Send(msg); err != nil {
+		t.logger.Logger(ctx).Error("Failed to send voice confirmation", zap.Error(err))
+	}
+}
+
+func voiceFromCallback(data string) (string, bool) {
+	if !strings.HasPrefix(data, voiceCallbackPrefix) {
+		return "", false
+	}
+	return strings.TrimPrefix(data, voiceCallbackPrefix), true
+}
+
+// handleCartesiaVoicesCommand lists Cartesia's voices, optionally only those
+// in one language like "/cartesiavoices hi", so admins can pick IDs for
+// CARTESIA_VOICE_ID or a persona's voice_id.
+func (t *Telegram) handleCartesiaVoicesCommand(ctx context.Context, message *tgbotapi.Message) {
+	voices, err := t.cartesia.ListVoices(ctx)
+	if err != nil {
+		t.logger.Logger(ctx).Error("Failed to list Cartesia voices", zap.Error(err), zap.Int64("admin_id", message.From.ID))
+		t.replyText(ctx, message.Chat.ID, "Failed to list voices: "+err.Error())
+		return
+	}
+
+	language := strings.ToLower(strings.TrimSpace(message.CommandArguments()))
+	pages := voiceCatalogPages(voices, language)
+	if len(pages) == 0 {
+		t.replyText(ctx, message.Chat.ID, "No voices found.")
+		return
+	}
+	for _, page := range pages {
+		t.replyText(ctx, message.Chat.ID, page)
+	}
+}
+
+// voiceCatalogPages lists one voice per line, split into messages that fit
+// in Telegram. An empty language lists them all.
+func voiceCatalogPages(voices []cartesiaapi.Voice, language string) []string {
+	var pages []string
+	var page strings.Builder
+	for _, voice := range voices {
+		if language != "" && voice.Language != language {
+			continue
+		}
+		line := fmt.Sprintf("%s (%s): %s\n", voice.Name, voice.Language, voice.ID)
+		if page.Len()+len(line) > maxVoiceCatalogLength {
+			pages = append(pages, page.String())
+			page.Reset()
+		}
+		page.WriteString(line)
+	}
+	if page.Len() > 0 {
+		pages = append(pages, page.String())
+	}
+	return pages
+}
@@ -0,0 +1,67 @@
+package telegram
+
+import (
+	"gulabodev/modelapi/cartesiaapi"
+	"strings"
+	"testing"
+)
+
+func TestTTSFallbackChain(t *testing.T) {
+	tests := []struct {
+		preferred string
+		gurmukhi  bool
+		want      string
+	}{
+		{ttsProviderGemini, false, "gemini,openai,cartesia,kokoro"},
+		{ttsProviderOpenAI, false, "openai,gemini,cartesia,kokoro"},
+		{ttsProviderGemini, true, "gemini,openai"},
+	}
+	for _, tt := range tests {
+		got := strings.Join(ttsFallbackChain(tt.preferred, defaultTTSFallbackOrder, tt.gurmukhi), ",")
+		if got != tt.want {
+			t.Errorf("ttsFallbackChain(%q, %v) = %q, want %q", tt.preferred, tt.gurmukhi, got, tt.want)
+		}
+	}
+}
+
+func TestTTSProvidersCoverVoices(t *testing.T) {
+	providers := ttsProviders(TelegramConnectProps{})
+	for _, voice := range ttsVoices {
+		provider, ok := providers[voice.Provider]
+		if !ok {
+			t.Errorf("voice %q has no TTS provider", voice.ID)
+			continue
+		}
+		// FallbackTTS reports the provider by name, which generateSpeech compares
+		if provider.Name() != voice.Provider {
+			t.Errorf("provider for %q is named %q", voice.Provider, provider.Name())
+		}
+	}
+}
+
+func TestVoiceCatalogPages(t *testing.T) {
+	voices := []cartesiaapi.Voice{
+		{ID: "a", Name: "Emma", Language: "en"},
+		{ID: "b", Name: "Riya", Language: "hi"},
+	}
+	if pages := voiceCatalogPages(voices, "hi"); len(pages) != 1 || pages[0] != "Riya (hi): b\n" {
+		t.Errorf("pages = %q, want only the Hindi voice", pages)
+	}
+	if pages := voiceCatalogPages(voices, "pa"); len(pages) != 0 {
+		t.Errorf("pages = %q, want none", pages)
+	}
+
+	many := make([]cartesiaapi.Voice, 200)
+	for i := range many {
+		many[i] = cartesiaapi.Voice{ID: strings.Repeat("x", 36), Name: "Voice", Language: "hi"}
+	}
+	pages := voiceCatalogPages(many, "")
+	if len(pages) < 2 {
+		t.Fatalf("got %d pages, want the catalog split", len(pages))
+	}
+	for _, page := range pages {
+		if len(page) > maxVoiceCatalogLength {
+			t.Errorf("page of %d characters is over the limit", len(page))
+		}
+	}
+}